@@ -21,9 +21,11 @@ package data
 import (
 	"google.golang.org/protobuf/proto"
 
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
 	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
 	propertyv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/property/v1"
 	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	tracev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/trace/v1"
 	"github.com/apache/skywalking-banyandb/pkg/bus"
 )
 
@@ -48,6 +50,16 @@ var (
 		TopicStreamLocalIndexWrite.String():    TopicStreamLocalIndexWrite,
 		TopicStreamSeriesSync.String():         TopicStreamSeriesSync,
 		TopicStreamElementIndexSync.String():   TopicStreamElementIndexSync,
+		TopicTraceWrite.String():               TopicTraceWrite,
+		TopicTraceQuery.String():               TopicTraceQuery,
+		TopicTraceGet.String():                 TopicTraceGet,
+		TopicTracePartSync.String():            TopicTracePartSync,
+		TopicStreamDelete.String():             TopicStreamDelete,
+		TopicMeasureDelete.String():            TopicMeasureDelete,
+		TopicStreamShardMove.String():          TopicStreamShardMove,
+		TopicMeasureShardMove.String():         TopicMeasureShardMove,
+		TopicReplicaRepair.String():            TopicReplicaRepair,
+		TopicStreamPartRepair.String():         TopicStreamPartRepair,
 	}
 
 	// TopicRequestMap is the map of topic name to request message.
@@ -107,6 +119,36 @@ var (
 		TopicStreamElementIndexSync: func() proto.Message {
 			return nil
 		},
+		TopicTraceWrite: func() proto.Message {
+			return &tracev1.InternalWriteRequest{}
+		},
+		TopicTraceQuery: func() proto.Message {
+			return &tracev1.QueryRequest{}
+		},
+		TopicTraceGet: func() proto.Message {
+			return &tracev1.GetTracesRequest{}
+		},
+		TopicTracePartSync: func() proto.Message {
+			return nil
+		},
+		TopicStreamDelete: func() proto.Message {
+			return &streamv1.InternalDeleteRequest{}
+		},
+		TopicMeasureDelete: func() proto.Message {
+			return &measurev1.InternalDeleteRequest{}
+		},
+		TopicStreamShardMove: func() proto.Message {
+			return &databasev1.InternalMoveShardsRequest{}
+		},
+		TopicMeasureShardMove: func() proto.Message {
+			return &databasev1.InternalMoveShardsRequest{}
+		},
+		TopicReplicaRepair: func() proto.Message {
+			return &databasev1.InternalRepairShardsRequest{}
+		},
+		TopicStreamPartRepair: func() proto.Message {
+			return nil
+		},
 	}
 
 	// TopicResponseMap is the map of topic name to response message.
@@ -133,6 +175,27 @@ var (
 		TopicPropertyRepair: func() proto.Message {
 			return &propertyv1.InternalRepairResponse{}
 		},
+		TopicTraceQuery: func() proto.Message {
+			return &tracev1.QueryResponse{}
+		},
+		TopicTraceGet: func() proto.Message {
+			return &tracev1.GetTracesResponse{}
+		},
+		TopicStreamDelete: func() proto.Message {
+			return &streamv1.DeleteResponse{}
+		},
+		TopicMeasureDelete: func() proto.Message {
+			return &measurev1.DeleteResponse{}
+		},
+		TopicStreamShardMove: func() proto.Message {
+			return &databasev1.InternalMoveShardsResponse{}
+		},
+		TopicMeasureShardMove: func() proto.Message {
+			return &databasev1.InternalMoveShardsResponse{}
+		},
+		TopicReplicaRepair: func() proto.Message {
+			return &databasev1.InternalRepairShardsResponse{}
+		},
 	}
 
 	// TopicCommon is the common topic for data transmission.
@@ -58,6 +58,15 @@ var MeasureDeleteExpiredSegmentsKindVersion = common.KindVersion{
 // TopicMeasureDeleteExpiredSegments is the measure delete topic.
 var TopicMeasureDeleteExpiredSegments = bus.BiTopic(MeasureDeleteExpiredSegmentsKindVersion.String())
 
+// MeasureDeleteKindVersion is the version tag of measure delete kind.
+var MeasureDeleteKindVersion = common.KindVersion{
+	Version: "v1",
+	Kind:    "measure-delete",
+}
+
+// TopicMeasureDelete is the measure delete topic.
+var TopicMeasureDelete = bus.BiTopic(MeasureDeleteKindVersion.String())
+
 // MeasurePartSyncKindVersion is the version tag of measure part sync kind.
 var MeasurePartSyncKindVersion = common.KindVersion{
 	Version: "v1",
@@ -93,3 +102,12 @@ var MeasureSeriesSyncKindVersion = common.KindVersion{
 
 // TopicMeasureSeriesSync is the measure series sync topic.
 var TopicMeasureSeriesSync = bus.BiTopic(MeasureSeriesSyncKindVersion.String())
+
+// MeasureShardMoveKindVersion is the version tag of measure shard move kind.
+var MeasureShardMoveKindVersion = common.KindVersion{
+	Version: "v1",
+	Kind:    "measure-shard-move",
+}
+
+// TopicMeasureShardMove is the measure shard move topic.
+var TopicMeasureShardMove = bus.BiTopic(MeasureShardMoveKindVersion.String())
@@ -49,6 +49,15 @@ var StreamDeleteExpiredSegmentsKindVersion = common.KindVersion{
 // TopicDeleteExpiredStreamSegments is the delete stream segments topic.
 var TopicDeleteExpiredStreamSegments = bus.BiTopic(StreamDeleteExpiredSegmentsKindVersion.String())
 
+// StreamDeleteKindVersion is the version tag of stream delete kind.
+var StreamDeleteKindVersion = common.KindVersion{
+	Version: "v1",
+	Kind:    "stream-delete",
+}
+
+// TopicStreamDelete is the stream delete topic.
+var TopicStreamDelete = bus.BiTopic(StreamDeleteKindVersion.String())
+
 // StreamPartSyncKindVersion is the version tag of part sync kind.
 var StreamPartSyncKindVersion = common.KindVersion{
 	Version: "v1",
@@ -93,3 +102,21 @@ var StreamElementIndexSyncKindVersion = common.KindVersion{
 
 // TopicStreamElementIndexSync is the element index sync topic.
 var TopicStreamElementIndexSync = bus.BiTopic(StreamElementIndexSyncKindVersion.String())
+
+// StreamShardMoveKindVersion is the version tag of stream shard move kind.
+var StreamShardMoveKindVersion = common.KindVersion{
+	Version: "v1",
+	Kind:    "stream-shard-move",
+}
+
+// TopicStreamShardMove is the stream shard move topic.
+var TopicStreamShardMove = bus.BiTopic(StreamShardMoveKindVersion.String())
+
+// StreamPartRepairKindVersion is the version tag of stream part repair kind.
+var StreamPartRepairKindVersion = common.KindVersion{
+	Version: "v1",
+	Kind:    "stream-part-repair",
+}
+
+// TopicStreamPartRepair is the stream part repair topic.
+var TopicStreamPartRepair = bus.BiTopic(StreamPartRepairKindVersion.String())
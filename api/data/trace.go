@@ -40,6 +40,15 @@ var TraceQueryKindVersion = common.KindVersion{
 // TopicTraceQuery is the trace query topic.
 var TopicTraceQuery = bus.BiTopic(TraceQueryKindVersion.String())
 
+// TraceGetKindVersion is the version tag of trace get kind.
+var TraceGetKindVersion = common.KindVersion{
+	Version: "v1",
+	Kind:    "trace-get",
+}
+
+// TopicTraceGet is the topic of fetching traces by their IDs.
+var TopicTraceGet = bus.BiTopic(TraceGetKindVersion.String())
+
 // TraceDeleteExpiredSegmentsKindVersion is the version tag of trace delete segments kind.
 var TraceDeleteExpiredSegmentsKindVersion = common.KindVersion{
 	Version: "v1",
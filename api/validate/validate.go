@@ -243,6 +243,9 @@ func IndexRule(indexRule *databasev1.IndexRule) error {
 	if indexRule.Type == databasev1.IndexRule_TYPE_UNSPECIFIED {
 		return errors.New("indexRule type is unspecified")
 	}
+	if indexRule.Separator != "" && indexRule.Type != databasev1.IndexRule_TYPE_TREE {
+		return errors.New("indexRule separator is only available for the tree index")
+	}
 	return nil
 }
 
@@ -35,8 +35,10 @@ import (
 	"github.com/apache/skywalking-banyandb/banyand/observability"
 	"github.com/apache/skywalking-banyandb/banyand/queue"
 	"github.com/apache/skywalking-banyandb/banyand/stream"
+	"github.com/apache/skywalking-banyandb/banyand/trace"
 	"github.com/apache/skywalking-banyandb/pkg/bus"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/node"
 	"github.com/apache/skywalking-banyandb/pkg/query/executor"
 	"github.com/apache/skywalking-banyandb/pkg/run"
 )
@@ -58,40 +60,63 @@ var _ Service = (*queryService)(nil)
 type queryService struct {
 	metaService          metadata.Repo
 	pipeline             queue.Server
+	publisher            bus.Publisher
 	omr                  observability.MetricsRegistry
 	log                  *logger.Logger
 	sqp                  *streamQueryProcessor
 	mqp                  *measureQueryProcessor
 	tqp                  *topNQueryProcessor
+	trqp                 *traceQueryProcessor
+	trgp                 *traceGetProcessor
 	closer               *run.Closer
+	routers              map[commonv1.Catalog]node.ShardRouter
 	nodeID               string
 	hotStageNodeSelector string
 	slowQuery            time.Duration
 }
 
 // NewService return a new query service.
-func NewService(metaService metadata.Repo, pipeline queue.Server, broadcaster bus.Broadcaster, omr observability.MetricsRegistry,
-	streamSchemaSVC stream.Service, measureSchemaSVC measure.Service,
+// The data node selectors route the shards of the stream and measure groups, which the queries with a read policy read the replicas of.
+func NewService(metaService metadata.Repo, pipeline queue.Server, client queue.Client, omr observability.MetricsRegistry,
+	streamSchemaSVC stream.Service, measureSchemaSVC measure.Service, traceSchemaSVC trace.Service,
+	dataNodeSelectors map[commonv1.Catalog]node.Selector,
 ) (Service, error) {
 	svc := &queryService{
 		metaService: metaService,
 		closer:      run.NewCloser(1),
 		pipeline:    pipeline,
+		publisher:   client,
 		omr:         omr,
+		routers:     make(map[commonv1.Catalog]node.ShardRouter),
+	}
+	for catalog, sel := range dataNodeSelectors {
+		if router, ok := sel.(node.ShardRouter); ok {
+			svc.routers[catalog] = router
+		}
 	}
 	svc.sqp = &streamQueryProcessor{
 		queryService:  svc,
 		streamService: streamSchemaSVC,
-		broadcaster:   broadcaster,
+		broadcaster:   client,
 	}
 	svc.mqp = &measureQueryProcessor{
 		queryService:   svc,
 		measureService: measureSchemaSVC,
-		broadcaster:    broadcaster,
+		broadcaster:    client,
 	}
 	svc.tqp = &topNQueryProcessor{
 		queryService: svc,
-		broadcaster:  broadcaster,
+		broadcaster:  client,
+	}
+	svc.trqp = &traceQueryProcessor{
+		queryService: svc,
+		traceService: traceSchemaSVC,
+		broadcaster:  client,
+	}
+	svc.trgp = &traceGetProcessor{
+		queryService: svc,
+		traceService: traceSchemaSVC,
+		broadcaster:  client,
 	}
 	return svc, nil
 }
@@ -115,8 +140,7 @@ func (q *queryService) PreRun(ctx context.Context) error {
 	if val == nil {
 		return errors.New("node id is empty")
 	}
-	node := val.(common.Node)
-	q.nodeID = node.NodeID
+	q.nodeID = val.(common.Node).NodeID
 	val = ctx.Value(common.ContextNodeSelectorKey)
 	if val != nil {
 		q.hotStageNodeSelector = val.(string)
@@ -128,6 +152,8 @@ func (q *queryService) PreRun(ctx context.Context) error {
 		q.pipeline.Subscribe(data.TopicStreamQuery, q.sqp),
 		q.pipeline.Subscribe(data.TopicMeasureQuery, q.mqp),
 		q.pipeline.Subscribe(data.TopicTopNQuery, q.tqp),
+		q.pipeline.Subscribe(data.TopicTraceQuery, q.trqp),
+		q.pipeline.Subscribe(data.TopicTraceGet, q.trgp),
 	)
 }
 
@@ -177,6 +203,14 @@ type distributedContext struct {
 	bus.Broadcaster
 	timeRange     *modelv1.TimeRange
 	nodeSelectors map[string][]string
+	replicas      *replicaReader
+}
+
+func (dc *distributedContext) Broadcast(timeout time.Duration, topic bus.Topic, message bus.Message) ([]bus.Future, error) {
+	if dc.replicas == nil {
+		return dc.Broadcaster.Broadcast(timeout, topic, message)
+	}
+	return dc.replicas.read(timeout, topic, message)
 }
 
 func (dc *distributedContext) TimeRange() *modelv1.TimeRange {
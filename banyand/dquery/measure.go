@@ -126,10 +126,24 @@ func (p *measureQueryProcessor) Rev(ctx context.Context, message bus.Message) (r
 		}()
 	}
 
+	switch queryCriteria.Explain {
+	case commonv1.ExplainMode_EXPLAIN_MODE_PLAN:
+		resp = bus.NewMessage(bus.MessageID(now), &measurev1.QueryResponse{Plan: logical.Explain(plan, nil)})
+		return
+	case commonv1.ExplainMode_EXPLAIN_MODE_ANALYZE:
+		var analyzer *query.Analyzer
+		analyzer, ctx = query.NewAnalyzer(ctx)
+		defer func() {
+			if d, ok := resp.Data().(*measurev1.QueryResponse); ok {
+				d.Plan = logical.Explain(plan, analyzer)
+			}
+		}()
+	}
 	mIterator, err := plan.(executor.MeasureExecutable).Execute(executor.WithDistributedExecutionContext(ctx, &distributedContext{
 		Broadcaster:   p.broadcaster,
 		timeRange:     queryCriteria.TimeRange,
 		nodeSelectors: nodeSelectors,
+		replicas:      p.replicaReader(p.routers[commonv1.Catalog_CATALOG_MEASURE], queryCriteria.ReadPolicy, queryCriteria.Groups, nodeSelectors),
 	}))
 	if err != nil {
 		ml.Error().Err(err).Dur("latency", time.Since(n)).RawJSON("req", logger.Proto(queryCriteria)).Msg("fail to query")
@@ -0,0 +1,193 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package dquery
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/node"
+)
+
+// replicaReader sends a query to the data nodes holding as many replicas of the queried shards as the read policy requires.
+// A node failing to respond in time is replaced by the other replicas of its shards.
+type replicaReader struct {
+	publisher bus.Publisher
+	router    node.ShardRouter
+	groups    []string
+	policy    modelv1.ReadPolicy
+}
+
+// replicaReader returns the reader of the replicas of the groups' shards,
+// or nil if the query is broadcast to the selected data nodes.
+func (q *queryService) replicaReader(router node.ShardRouter, policy modelv1.ReadPolicy, groups []string,
+	nodeSelectors map[string][]string,
+) *replicaReader {
+	// The shards are routed to the data nodes of the hot stage, the other stages are always broadcast.
+	if policy == modelv1.ReadPolicy_READ_POLICY_UNSPECIFIED || router == nil || len(nodeSelectors) > 0 {
+		return nil
+	}
+	return &replicaReader{
+		publisher: q.publisher,
+		router:    router,
+		groups:    groups,
+		policy:    policy,
+	}
+}
+
+func (r *replicaReader) required(copies int) int {
+	switch r.policy {
+	case modelv1.ReadPolicy_READ_POLICY_ANY:
+		return 1
+	case modelv1.ReadPolicy_READ_POLICY_QUORUM:
+		return copies/2 + 1
+	default:
+		return copies
+	}
+}
+
+type replicaResult struct {
+	err  error
+	node string
+	m    bus.Message
+}
+
+// read sends the message to the chosen nodes, and waits for their responses.
+// The futures of the responses are returned once every shard is read from enough replicas.
+func (r *replicaReader) read(timeout time.Duration, topic bus.Topic, message bus.Message) ([]bus.Future, error) {
+	shards := make(map[string][][]string, len(r.groups))
+	total := 0
+	for _, g := range r.groups {
+		copies, ok := r.router.Shards(g)
+		if !ok {
+			return nil, errors.Errorf("the shards of group %s aren't found", g)
+		}
+		shards[g] = copies
+		for _, c := range copies {
+			total += len(c)
+		}
+	}
+	alive := make(map[string]struct{})
+	for _, n := range r.router.Nodes() {
+		alive[n] = struct{}{}
+	}
+	failed := make(map[string]error)
+	available := func(n string) bool {
+		_, ok := alive[n]
+		_, hasFailed := failed[n]
+		return ok && !hasFailed
+	}
+	var reading []string
+	plan := func() ([]string, error) {
+		var chosen []string
+		var err error
+		for _, g := range r.groups {
+			nodes, short := node.PlanRead(shards[g], r.required, slices.Concat(reading, chosen), available)
+			if len(short) > 0 {
+				err = multierr.Append(err, errors.Errorf("shards %v of group %s haven't enough available replicas for %s",
+					short, g, r.policy))
+			}
+			chosen = append(chosen, nodes...)
+		}
+		if err != nil {
+			for n, nodeErr := range failed {
+				err = multierr.Append(err, errors.WithMessagef(nodeErr, "failed to read from node %s", n))
+			}
+		}
+		return chosen, err
+	}
+
+	// Every node is sent the message once at most.
+	results := make(chan replicaResult, total)
+	pending := 0
+	send := func(nodes []string) {
+		for _, n := range nodes {
+			reading = append(reading, n)
+			pending++
+			go func(n string) {
+				m, err := r.get(timeout, topic, bus.NewMessageWithNode(message.ID(), n, message.Data()))
+				results <- replicaResult{node: n, m: m, err: err}
+			}(n)
+		}
+	}
+	nodes, err := plan()
+	if err != nil {
+		return nil, err
+	}
+	send(nodes)
+	var futures []bus.Future
+	for pending > 0 {
+		res := <-results
+		pending--
+		if res.err == nil {
+			futures = append(futures, &replicaFuture{m: res.m})
+			continue
+		}
+		failed[res.node] = res.err
+		reading = slices.DeleteFunc(reading, func(n string) bool {
+			return n == res.node
+		})
+		if nodes, err = plan(); err != nil {
+			return nil, err
+		}
+		send(nodes)
+	}
+	return futures, nil
+}
+
+// get sends the message to its node, and waits for the response until the timeout.
+func (r *replicaReader) get(timeout time.Duration, topic bus.Topic, message bus.Message) (bus.Message, error) {
+	f, err := r.publisher.Publish(context.Background(), topic, message)
+	if err != nil {
+		return bus.Message{}, err
+	}
+	ch := make(chan replicaResult, 1)
+	go func() {
+		m, getErr := f.Get()
+		ch <- replicaResult{m: m, err: getErr}
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case res := <-ch:
+		return res.m, res.err
+	case <-timer.C:
+		return bus.Message{}, errors.Errorf("no response in %s", timeout)
+	}
+}
+
+var _ bus.Future = (*replicaFuture)(nil)
+
+// replicaFuture is a received response of a replica.
+type replicaFuture struct {
+	m bus.Message
+}
+
+func (f *replicaFuture) Get() (bus.Message, error) {
+	return f.m, nil
+}
+
+func (f *replicaFuture) GetAll() ([]bus.Message, error) {
+	return []bus.Message{f.m}, nil
+}
@@ -123,12 +123,26 @@ func (p *streamQueryProcessor) Rev(ctx context.Context, message bus.Message) (re
 			span.Stop()
 		}()
 	}
+	switch queryCriteria.Explain {
+	case commonv1.ExplainMode_EXPLAIN_MODE_PLAN:
+		resp = bus.NewMessage(bus.MessageID(now), &streamv1.QueryResponse{Plan: logical.Explain(plan, nil)})
+		return
+	case commonv1.ExplainMode_EXPLAIN_MODE_ANALYZE:
+		var analyzer *query.Analyzer
+		analyzer, ctx = query.NewAnalyzer(ctx)
+		defer func() {
+			if d, ok := resp.Data().(*streamv1.QueryResponse); ok {
+				d.Plan = logical.Explain(plan, analyzer)
+			}
+		}()
+	}
 	se := plan.(executor.StreamExecutable)
 	defer se.Close()
 	entities, err := se.Execute(executor.WithDistributedExecutionContext(ctx, &distributedContext{
 		Broadcaster:   p.broadcaster,
 		timeRange:     queryCriteria.TimeRange,
 		nodeSelectors: nodeSelectors,
+		replicas:      p.replicaReader(p.routers[commonv1.Catalog_CATALOG_STREAM], queryCriteria.ReadPolicy, queryCriteria.Groups, nodeSelectors),
 	}))
 	if err != nil {
 		p.log.Error().Err(err).RawJSON("req", logger.Proto(queryCriteria)).Msg("fail to execute the query plan")
@@ -0,0 +1,285 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package dquery
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/multierr"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/api/data"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	tracev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/trace/v1"
+	"github.com/apache/skywalking-banyandb/banyand/trace"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/query"
+	"github.com/apache/skywalking-banyandb/pkg/query/executor"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+	logical_trace "github.com/apache/skywalking-banyandb/pkg/query/logical/trace"
+)
+
+const defaultTraceGetTimeout = 30 * time.Second
+
+type traceQueryProcessor struct {
+	traceService trace.Service
+	broadcaster  bus.Broadcaster
+	*queryService
+	*bus.UnImplementedHealthyListener
+}
+
+func (p *traceQueryProcessor) Rev(ctx context.Context, message bus.Message) (resp bus.Message) {
+	n := time.Now()
+	now := n.UnixNano()
+	queryCriteria, ok := message.Data().(*tracev1.QueryRequest)
+	if !ok {
+		resp = bus.NewMessage(bus.MessageID(now), common.NewError("invalid event data type"))
+		return
+	}
+	if p.log.Debug().Enabled() {
+		p.log.Debug().RawJSON("criteria", logger.Proto(queryCriteria)).Msg("received a query request")
+	}
+
+	var schemas []logical.Schema
+	for _, group := range queryCriteria.Groups {
+		meta := &commonv1.Metadata{
+			Name:  queryCriteria.Name,
+			Group: group,
+		}
+		ec, err := p.traceService.Trace(meta)
+		if err != nil {
+			resp = bus.NewMessage(bus.MessageID(now), common.NewError("fail to get execution context for trace %s: %v", meta.GetName(), err))
+			return
+		}
+		s, err := logical_trace.BuildSchema(ec.GetSchema(), ec.GetIndexRules())
+		if err != nil {
+			resp = bus.NewMessage(bus.MessageID(now), common.NewError("fail to build schema for trace %s: %v", meta.GetName(), err))
+			return
+		}
+		schemas = append(schemas, s)
+	}
+
+	plan, err := logical_trace.DistributedAnalyze(queryCriteria, schemas)
+	if err != nil {
+		resp = bus.NewMessage(bus.MessageID(now), common.NewError("fail to analyze the query request for trace %s: %v", queryCriteria.Name, err))
+		return
+	}
+
+	if p.log.Debug().Enabled() {
+		p.log.Debug().Str("plan", plan.String()).Msg("query plan")
+	}
+	nodeSelectors := make(map[string][]string)
+	for _, g := range queryCriteria.Groups {
+		if gs, ok := p.traceService.LoadGroup(g); ok {
+			if ns, exist := p.parseNodeSelector(queryCriteria.Stages, gs.GetSchema().ResourceOpts); exist {
+				nodeSelectors[g] = ns
+			} else if len(gs.GetSchema().ResourceOpts.Stages) > 0 {
+				p.log.Error().Strs("req_stages", queryCriteria.Stages).Strs("default_stages", gs.GetSchema().GetResourceOpts().GetDefaultStages()).Msg("no stage found")
+				resp = bus.NewMessage(bus.MessageID(now), common.NewError("no stage found in request or default stages in resource opts"))
+				return
+			}
+		} else {
+			p.log.Error().RawJSON("req", logger.Proto(queryCriteria)).Msg("group not found")
+			resp = bus.NewMessage(bus.MessageID(now), common.NewError("group %s not found", g))
+			return
+		}
+	}
+	if len(queryCriteria.Stages) > 0 && len(nodeSelectors) == 0 {
+		p.log.Error().RawJSON("req", logger.Proto(queryCriteria)).Msg("no stage found")
+		resp = bus.NewMessage(bus.MessageID(now), common.NewError("no stage found"))
+		return
+	}
+	if queryCriteria.Trace {
+		var tracer *query.Tracer
+		var span *query.Span
+		tracer, ctx = query.NewTracer(ctx, n.Format(time.RFC3339Nano))
+		span, ctx = tracer.StartSpan(ctx, "distributed-%s", p.queryService.nodeID)
+		span.Tag("plan", plan.String())
+		span.Tagf("nodeSelectors", "%v", nodeSelectors)
+		defer func() {
+			switch d := resp.Data().(type) {
+			case *tracev1.QueryResponse:
+				d.TraceQueryResult = tracer.ToProto()
+			case *common.Error:
+				span.Error(errors.New(d.Error()))
+				resp = bus.NewMessage(bus.MessageID(now), &tracev1.QueryResponse{TraceQueryResult: tracer.ToProto()})
+			default:
+				panic("unexpected data type")
+			}
+			span.Stop()
+		}()
+	}
+	switch queryCriteria.Explain {
+	case commonv1.ExplainMode_EXPLAIN_MODE_PLAN:
+		resp = bus.NewMessage(bus.MessageID(now), &tracev1.QueryResponse{Plan: logical.Explain(plan, nil)})
+		return
+	case commonv1.ExplainMode_EXPLAIN_MODE_ANALYZE:
+		var analyzer *query.Analyzer
+		analyzer, ctx = query.NewAnalyzer(ctx)
+		defer func() {
+			if d, ok := resp.Data().(*tracev1.QueryResponse); ok {
+				d.Plan = logical.Explain(plan, analyzer)
+			}
+		}()
+	}
+	te := plan.(executor.TraceExecutable)
+	defer te.Close()
+	spans, err := te.Execute(executor.WithDistributedExecutionContext(ctx, &distributedContext{
+		Broadcaster:   p.broadcaster,
+		timeRange:     queryCriteria.TimeRange,
+		nodeSelectors: nodeSelectors,
+	}))
+	if err != nil {
+		p.log.Error().Err(err).RawJSON("req", logger.Proto(queryCriteria)).Msg("fail to execute the query plan")
+		resp = bus.NewMessage(bus.MessageID(now), common.NewError("execute the query plan for trace %s: %v", queryCriteria.Name, err))
+		return
+	}
+
+	resp = bus.NewMessage(bus.MessageID(now), &tracev1.QueryResponse{Spans: spans})
+	if !queryCriteria.Trace && p.slowQuery > 0 {
+		latency := time.Since(n)
+		if latency > p.slowQuery {
+			p.log.Warn().Dur("latency", latency).RawJSON("req", logger.Proto(queryCriteria)).Int("resp_count", len(spans)).Msg("trace slow query")
+		}
+	}
+	return
+}
+
+type traceGetProcessor struct {
+	traceService trace.Service
+	broadcaster  bus.Broadcaster
+	*queryService
+	*bus.UnImplementedHealthyListener
+}
+
+func (p *traceGetProcessor) Rev(ctx context.Context, message bus.Message) (resp bus.Message) {
+	n := time.Now()
+	now := n.UnixNano()
+	req, ok := message.Data().(*tracev1.GetTracesRequest)
+	if !ok {
+		resp = bus.NewMessage(bus.MessageID(now), common.NewError("invalid event data type"))
+		return
+	}
+	if p.log.Debug().Enabled() {
+		p.log.Debug().RawJSON("req", logger.Proto(req)).Msg("received a get traces request")
+	}
+	nodeSelectors := make(map[string][]string)
+	for _, g := range req.Groups {
+		gs, ok := p.traceService.LoadGroup(g)
+		if !ok {
+			p.log.Error().RawJSON("req", logger.Proto(req)).Msg("group not found")
+			resp = bus.NewMessage(bus.MessageID(now), common.NewError("group %s not found", g))
+			return
+		}
+		if ns, exist := p.parseNodeSelector(req.Stages, gs.GetSchema().ResourceOpts); exist {
+			nodeSelectors[g] = ns
+		} else if len(gs.GetSchema().ResourceOpts.Stages) > 0 {
+			p.log.Error().Strs("req_stages", req.Stages).Strs("default_stages", gs.GetSchema().GetResourceOpts().GetDefaultStages()).Msg("no stage found")
+			resp = bus.NewMessage(bus.MessageID(now), common.NewError("no stage found in request or default stages in resource opts"))
+			return
+		}
+	}
+	var tracer *query.Tracer
+	var span *query.Span
+	if req.Trace {
+		tracer, ctx = query.NewTracer(ctx, n.Format(time.RFC3339Nano))
+		span, _ = tracer.StartSpan(ctx, "distributed-get-traces-%s", p.queryService.nodeID)
+		span.Tagf("nodeSelectors", "%v", nodeSelectors)
+		defer func() {
+			switch d := resp.Data().(type) {
+			case *tracev1.GetTracesResponse:
+				d.TraceQueryResult = tracer.ToProto()
+			case *common.Error:
+				span.Error(errors.New(d.Error()))
+				resp = bus.NewMessage(bus.MessageID(now), &tracev1.GetTracesResponse{TraceQueryResult: tracer.ToProto()})
+			default:
+				panic("unexpected data type")
+			}
+			span.Stop()
+		}()
+	}
+	ff, err := p.broadcaster.Broadcast(defaultTraceGetTimeout, data.TopicTraceGet,
+		bus.NewMessageWithNodeSelectors(bus.MessageID(now), nodeSelectors, req.TimeRange, req))
+	if err != nil {
+		resp = bus.NewMessage(bus.MessageID(now), common.NewError("fail to broadcast the get traces request for trace %s: %v", req.Name, err))
+		return
+	}
+	var allErr error
+	var responses []*tracev1.GetTracesResponse
+	for _, f := range ff {
+		m, getErr := f.Get()
+		if getErr != nil {
+			allErr = multierr.Append(allErr, getErr)
+			continue
+		}
+		switch d := m.Data().(type) {
+		case *tracev1.GetTracesResponse:
+			if span != nil {
+				span.AddSubTrace(d.TraceQueryResult)
+			}
+			responses = append(responses, d)
+		case *common.Error:
+			allErr = multierr.Append(allErr, errors.New(d.Error()))
+		}
+	}
+	if allErr != nil {
+		p.log.Error().Err(allErr).RawJSON("req", logger.Proto(req)).Msg("fail to get traces from data nodes")
+		resp = bus.NewMessage(bus.MessageID(now), common.NewError("fail to get traces for trace %s: %v", req.Name, allErr))
+		return
+	}
+	resp = bus.NewMessage(bus.MessageID(now), &tracev1.GetTracesResponse{Traces: mergeTraces(req.TraceIds, responses)})
+	return
+}
+
+// mergeTraces assembles the spans returned by data nodes into traces in the order of the requested IDs.
+// Replicas return identical spans, so they are deduplicated by the raw span data.
+func mergeTraces(traceIDs []string, responses []*tracev1.GetTracesResponse) []*tracev1.Trace {
+	spans := make(map[string][]*tracev1.Span)
+	seen := make(map[string]map[string]struct{})
+	for _, resp := range responses {
+		for _, t := range resp.Traces {
+			ss, ok := seen[t.TraceId]
+			if !ok {
+				ss = make(map[string]struct{})
+				seen[t.TraceId] = ss
+			}
+			for _, s := range t.Spans {
+				key := convert.BytesToString(s.Span)
+				if _, ok := ss[key]; ok {
+					continue
+				}
+				ss[key] = struct{}{}
+				spans[t.TraceId] = append(spans[t.TraceId], s)
+			}
+		}
+	}
+	result := make([]*tracev1.Trace, 0, len(spans))
+	for _, traceID := range traceIDs {
+		ss, ok := spans[traceID]
+		if !ok {
+			continue
+		}
+		delete(spans, traceID)
+		result = append(result, &tracev1.Trace{TraceId: traceID, Spans: ss})
+	}
+	return result
+}
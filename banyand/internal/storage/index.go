@@ -94,6 +94,10 @@ func (s *seriesIndex) EnableExternalSegments() (index.ExternalSegmentStreamer, e
 	return s.store.EnableExternalSegments()
 }
 
+func (s *seriesIndex) Statistics() index.Statistics {
+	return s.store
+}
+
 func (s *seriesIndex) filter(ctx context.Context, series []*pbv1.Series,
 	projection []index.FieldKey, secondaryQuery index.Query, timeRange *timestamp.TimeRange,
 ) (data SeriesData, err error) {
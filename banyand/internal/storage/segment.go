@@ -144,6 +144,14 @@ func (s *segment[T, O]) Tables() (tt []T, cc []Cache) {
 	return tt, cc
 }
 
+func (s *segment[T, O]) Table(shardID common.ShardID) (T, bool) {
+	if s, ok := s.getShard(shardID); ok {
+		return s.table, true
+	}
+	var t T
+	return t, false
+}
+
 func (s *segment[T, O]) incRef(ctx context.Context) error {
 	s.lastAccessed.Store(time.Now().UnixNano())
 	if atomic.LoadInt32(&s.refCount) <= 0 {
@@ -102,6 +102,7 @@ type IndexDB interface {
 	Search(ctx context.Context, series []*pbv1.Series, opts IndexSearchOpts) (SeriesData, [][]byte, error)
 	SearchWithoutSeries(ctx context.Context, opts IndexSearchOpts) (sd SeriesData, sortedValues [][]byte, err error)
 	EnableExternalSegments() (index.ExternalSegmentStreamer, error)
+	Statistics() index.Statistics
 }
 
 // TSDB allows listing and getting shard details.
@@ -112,6 +113,8 @@ type TSDB[T TSTable, O any] interface {
 	Tick(ts int64)
 	UpdateOptions(opts *commonv1.ResourceOpts)
 	TakeFileSnapshot(dst string) error
+	// TakeShardFileSnapshot takes the snapshot of a shard along with the series indexes of the segments holding it.
+	TakeShardFileSnapshot(dst string, shardID common.ShardID) error
 	GetExpiredSegmentsTimeRange() *timestamp.TimeRange
 	DeleteExpiredSegments(timeRange timestamp.TimeRange) int64
 }
@@ -122,6 +125,7 @@ type Segment[T TSTable, O any] interface {
 	GetTimeRange() timestamp.TimeRange
 	CreateTSTableIfNotExist(shardID common.ShardID) (T, error)
 	Tables() ([]T, []Cache)
+	Table(shardID common.ShardID) (T, bool)
 	Lookup(ctx context.Context, series []*pbv1.Series) (pbv1.SeriesList, error)
 	IndexDB() IndexDB
 }
@@ -190,6 +194,17 @@ func (ir IntervalRule) NextTime(current time.Time) time.Time {
 	panic("invalid interval unit")
 }
 
+// PreviousTime returns the previous time point based on the current time and interval rule.
+func (ir IntervalRule) PreviousTime(current time.Time) time.Time {
+	switch ir.Unit {
+	case HOUR:
+		return current.Add(-time.Hour * time.Duration(ir.Num))
+	case DAY:
+		return current.AddDate(0, 0, -ir.Num)
+	}
+	panic("invalid interval unit")
+}
+
 func (ir IntervalRule) estimatedDuration() time.Duration {
 	switch ir.Unit {
 	case HOUR:
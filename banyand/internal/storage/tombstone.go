@@ -0,0 +1,226 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"hash/crc32"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/apache/skywalking-banyandb/pkg/encoding"
+)
+
+var (
+	tombstoneCRCTable = crc32.MakeTable(crc32.Castagnoli)
+
+	errTombstonesCorrupted = errors.New("tombstones are corrupted")
+)
+
+// Tombstone marks the rows identified by the timestamp and the ID as deleted.
+// Only the rows in the parts whose IDs are not greater than PartID are deleted,
+// so that the rows written after the deletion are kept.
+type Tombstone struct {
+	Timestamp int64
+	// ID is the element ID of a stream or the series ID of a measure.
+	ID     uint64
+	PartID uint64
+}
+
+func (t Tombstone) less(other Tombstone) bool {
+	if t.Timestamp == other.Timestamp {
+		return t.ID < other.ID
+	}
+	return t.Timestamp < other.Timestamp
+}
+
+// Tombstones is an immutable set of tombstones sorted by the timestamp and the ID.
+// A nil set is empty.
+type Tombstones struct {
+	items []Tombstone
+}
+
+// Len returns the number of tombstones.
+func (ts *Tombstones) Len() int {
+	if ts == nil {
+		return 0
+	}
+	return len(ts.items)
+}
+
+// TimeRange returns the smallest and the largest timestamps of the tombstones.
+func (ts *Tombstones) TimeRange() (int64, int64) {
+	if ts.Len() == 0 {
+		return 0, 0
+	}
+	return ts.items[0].Timestamp, ts.items[len(ts.items)-1].Timestamp
+}
+
+func (ts *Tombstones) search(timestamp int64, id uint64) int {
+	target := Tombstone{Timestamp: timestamp, ID: id}
+	return sort.Search(len(ts.items), func(i int) bool {
+		return !ts.items[i].less(target)
+	})
+}
+
+// Overlaps reports whether any tombstone falls into [minTimestamp, maxTimestamp].
+func (ts *Tombstones) Overlaps(minTimestamp, maxTimestamp int64) bool {
+	if ts.Len() == 0 {
+		return false
+	}
+	i := ts.search(minTimestamp, 0)
+	return i < len(ts.items) && ts.items[i].Timestamp <= maxTimestamp
+}
+
+// IsDeleted reports whether the row in the part is deleted.
+func (ts *Tombstones) IsDeleted(partID, id uint64, timestamp int64) bool {
+	if ts.Len() == 0 {
+		return false
+	}
+	i := ts.search(timestamp, id)
+	if i >= len(ts.items) {
+		return false
+	}
+	t := ts.items[i]
+	return t.Timestamp == timestamp && t.ID == id && partID <= t.PartID
+}
+
+// Add returns a new set which includes the items.
+// The tombstone keeps the larger part ID if it's added twice.
+func (ts *Tombstones) Add(items []Tombstone) *Tombstones {
+	result := &Tombstones{items: make([]Tombstone, 0, ts.Len()+len(items))}
+	if ts != nil {
+		result.items = append(result.items, ts.items...)
+	}
+	result.items = append(result.items, items...)
+	sort.SliceStable(result.items, func(i, j int) bool {
+		return result.items[i].less(result.items[j])
+	})
+	dst := result.items[:0]
+	for _, t := range result.items {
+		if n := len(dst); n > 0 && dst[n-1].Timestamp == t.Timestamp && dst[n-1].ID == t.ID {
+			if dst[n-1].PartID < t.PartID {
+				dst[n-1].PartID = t.PartID
+			}
+			continue
+		}
+		dst = append(dst, t)
+	}
+	result.items = dst
+	return result
+}
+
+// Retain returns the set of the tombstones which keep reports true.
+// It returns ts itself if all tombstones are kept.
+func (ts *Tombstones) Retain(keep func(t Tombstone) bool) *Tombstones {
+	if ts.Len() == 0 {
+		return ts
+	}
+	var items []Tombstone
+	for i := range ts.items {
+		if keep(ts.items[i]) {
+			items = append(items, ts.items[i])
+		}
+	}
+	if len(items) == len(ts.items) {
+		return ts
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	return &Tombstones{items: items}
+}
+
+// Rebase returns a new set whose tombstones delete the rows in the parts whose IDs are not greater than partID.
+// A part synced from another node rebases its tombstones on the local part IDs.
+func (ts *Tombstones) Rebase(partID uint64) *Tombstones {
+	if ts.Len() == 0 {
+		return nil
+	}
+	result := &Tombstones{items: append([]Tombstone(nil), ts.items...)}
+	for i := range result.items {
+		result.items[i].PartID = partID
+	}
+	return result
+}
+
+// UnionTombstones returns the set which includes the tombstones of all sets.
+func UnionTombstones(sets ...*Tombstones) *Tombstones {
+	var result *Tombstones
+	for _, ts := range sets {
+		switch {
+		case ts.Len() == 0:
+		case result == nil:
+			result = ts
+		default:
+			result = result.Add(ts.items)
+		}
+	}
+	return result
+}
+
+// Marshal appends the tombstones to dst with a checksum and returns the result.
+func (ts *Tombstones) Marshal(dst []byte) []byte {
+	start := len(dst)
+	dst = encoding.Uint32ToBytes(dst, 0)
+	dst = encoding.VarUint64ToBytes(dst, uint64(ts.Len()))
+	if ts != nil {
+		for _, t := range ts.items {
+			dst = encoding.VarInt64ToBytes(dst, t.Timestamp)
+			dst = encoding.Uint64ToBytes(dst, t.ID)
+			dst = encoding.VarUint64ToBytes(dst, t.PartID)
+		}
+	}
+	checksum := encoding.Uint32ToBytes(nil, crc32.Checksum(dst[start+4:], tombstoneCRCTable))
+	copy(dst[start:], checksum)
+	return dst
+}
+
+// UnmarshalTombstones unmarshals the tombstones marshaled by Marshal.
+func UnmarshalTombstones(src []byte) (*Tombstones, error) {
+	if len(src) < 4 {
+		return nil, errTombstonesCorrupted
+	}
+	if encoding.BytesToUint32(src) != crc32.Checksum(src[4:], tombstoneCRCTable) {
+		return nil, errors.WithMessage(errTombstonesCorrupted, "checksum mismatch")
+	}
+	src = src[4:]
+	var count uint64
+	src, count = encoding.BytesToVarUint64(src)
+	if count == 0 {
+		return nil, nil
+	}
+	result := &Tombstones{items: make([]Tombstone, 0, count)}
+	var err error
+	for i := uint64(0); i < count; i++ {
+		var t Tombstone
+		if src, t.Timestamp, err = encoding.BytesToVarInt64(src); err != nil {
+			return nil, errors.WithMessagef(errTombstonesCorrupted, "cannot unmarshal the timestamp: %v", err)
+		}
+		if len(src) < 8 {
+			return nil, errors.WithMessage(errTombstonesCorrupted, "cannot unmarshal the id")
+		}
+		t.ID = encoding.BytesToUint64(src)
+		src, t.PartID = encoding.BytesToVarUint64(src[8:])
+		result.items = append(result.items, t)
+	}
+	if len(src) > 0 {
+		return nil, errors.WithMessagef(errTombstonesCorrupted, "unexpected %d bytes left", len(src))
+	}
+	return result, nil
+}
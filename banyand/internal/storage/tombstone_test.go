@@ -0,0 +1,101 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTombstones(t *testing.T) {
+	var ts *Tombstones
+	assert.Equal(t, 0, ts.Len())
+	assert.False(t, ts.IsDeleted(1, 1, 1))
+	assert.False(t, ts.Overlaps(0, 100))
+
+	ts = ts.Add([]Tombstone{
+		{Timestamp: 20, ID: 2, PartID: 5},
+		{Timestamp: 10, ID: 1, PartID: 5},
+	})
+	ts = ts.Add([]Tombstone{
+		{Timestamp: 10, ID: 1, PartID: 8},
+		{Timestamp: 10, ID: 3, PartID: 3},
+	})
+	assert.Equal(t, 3, ts.Len())
+	minTimestamp, maxTimestamp := ts.TimeRange()
+	assert.Equal(t, int64(10), minTimestamp)
+	assert.Equal(t, int64(20), maxTimestamp)
+
+	assert.True(t, ts.IsDeleted(8, 1, 10))
+	assert.False(t, ts.IsDeleted(9, 1, 10), "the rows written after the deletion are kept")
+	assert.True(t, ts.IsDeleted(5, 2, 20))
+	assert.False(t, ts.IsDeleted(6, 2, 20))
+	assert.False(t, ts.IsDeleted(1, 2, 10))
+	assert.False(t, ts.IsDeleted(1, 4, 10))
+
+	assert.True(t, ts.Overlaps(0, 10))
+	assert.True(t, ts.Overlaps(15, 25))
+	assert.False(t, ts.Overlaps(11, 19))
+	assert.False(t, ts.Overlaps(21, 30))
+
+	assert.Same(t, ts, ts.Retain(func(Tombstone) bool { return true }))
+	retained := ts.Retain(func(t Tombstone) bool { return t.Timestamp > 10 })
+	assert.Equal(t, 1, retained.Len())
+	assert.True(t, retained.IsDeleted(1, 2, 20))
+	assert.Nil(t, ts.Retain(func(Tombstone) bool { return false }))
+
+	rebased := ts.Rebase(2)
+	assert.False(t, rebased.IsDeleted(3, 1, 10))
+	assert.True(t, rebased.IsDeleted(2, 3, 10))
+	assert.True(t, ts.IsDeleted(8, 1, 10), "the rebased set is a copy")
+}
+
+func TestUnionTombstones(t *testing.T) {
+	assert.Nil(t, UnionTombstones())
+	assert.Nil(t, UnionTombstones(nil, nil))
+	ts1 := (*Tombstones)(nil).Add([]Tombstone{{Timestamp: 10, ID: 1, PartID: 5}})
+	ts2 := (*Tombstones)(nil).Add([]Tombstone{{Timestamp: 10, ID: 1, PartID: 8}, {Timestamp: 20, ID: 2, PartID: 3}})
+	assert.Same(t, ts1, UnionTombstones(nil, ts1, nil))
+
+	union := UnionTombstones(ts1, nil, ts2)
+	assert.Equal(t, 2, union.Len())
+	assert.True(t, union.IsDeleted(8, 1, 10), "the larger part ID is kept")
+	assert.True(t, union.IsDeleted(3, 2, 20))
+	assert.Equal(t, 1, ts1.Len(), "the sets are not modified")
+}
+
+func TestTombstonesMarshal(t *testing.T) {
+	ts := (*Tombstones)(nil).Add([]Tombstone{
+		{Timestamp: -1, ID: 1, PartID: 1},
+		{Timestamp: 1 << 40, ID: 1 << 63, PartID: 1 << 50},
+	})
+	data := ts.Marshal(nil)
+	got, err := UnmarshalTombstones(data)
+	require.NoError(t, err)
+	assert.Equal(t, ts.items, got.items)
+
+	data[len(data)-1]++
+	_, err = UnmarshalTombstones(data)
+	assert.ErrorIs(t, err, errTombstonesCorrupted)
+
+	got, err = UnmarshalTombstones((*Tombstones)(nil).Marshal(nil))
+	require.NoError(t, err)
+	assert.Equal(t, 0, got.Len())
+}
@@ -246,6 +246,15 @@ func (d *database[T, O]) UpdateOptions(resourceOpts *commonv1.ResourceOpts) {
 }
 
 func (d *database[T, O]) TakeFileSnapshot(dst string) error {
+	return d.takeFileSnapshot(dst, nil)
+}
+
+func (d *database[T, O]) TakeShardFileSnapshot(dst string, shardID common.ShardID) error {
+	return d.takeFileSnapshot(dst, &shardID)
+}
+
+// takeFileSnapshot takes the snapshot of the shard and the segments holding it, or all shards if shardID is nil.
+func (d *database[T, O]) takeFileSnapshot(dst string, shardID *common.ShardID) error {
 	if d.closed.Load() {
 		return errors.New("database is closed")
 	}
@@ -261,6 +270,17 @@ func (d *database[T, O]) TakeFileSnapshot(dst string) error {
 	}()
 
 	for _, seg := range segments {
+		var shards []*shard[T]
+		if sLst := seg.sLst.Load(); sLst != nil {
+			for _, s := range *sLst {
+				if shardID == nil || s.id == *shardID {
+					shards = append(shards, s)
+				}
+			}
+		}
+		if shardID != nil && len(shards) == 0 {
+			continue
+		}
 		segDir := filepath.Base(seg.location)
 		segPath := filepath.Join(dst, segDir)
 		d.lfs.MkdirIfNotExist(segPath, DirPerm)
@@ -277,11 +297,7 @@ func (d *database[T, O]) TakeFileSnapshot(dst string) error {
 			return errors.Wrapf(err, "failed to snapshot index for segment %s", segDir)
 		}
 
-		sLst := seg.sLst.Load()
-		if sLst == nil {
-			continue
-		}
-		for _, shard := range *sLst {
+		for _, shard := range shards {
 			shardDir := filepath.Base(shard.location)
 			shardPath := filepath.Join(segPath, shardDir)
 			d.lfs.MkdirIfNotExist(shardPath, DirPerm)
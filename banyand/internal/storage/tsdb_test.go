@@ -19,6 +19,7 @@ package storage
 
 import (
 	"context"
+	"fmt"
 	"path/filepath"
 	"testing"
 	"time"
@@ -26,6 +27,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 
+	"github.com/apache/skywalking-banyandb/api/common"
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	"github.com/apache/skywalking-banyandb/pkg/meter"
@@ -271,6 +273,58 @@ func TestTakeFileSnapshot(t *testing.T) {
 
 		require.NoError(t, tsdb.Close())
 	})
+
+	t.Run("Take snapshot of a shard", func(t *testing.T) {
+		dir, defFn := test.Space(require.New(t))
+		defer defFn()
+
+		snapshotDir := filepath.Join(dir, "snapshot")
+
+		opts := TSDBOpts[*MockTSTable, any]{
+			Location:        dir,
+			SegmentInterval: IntervalRule{Unit: DAY, Num: 1},
+			TTL:             IntervalRule{Unit: DAY, Num: 3},
+			ShardNum:        2,
+			TSTableCreator:  MockTSTableCreator,
+		}
+
+		ctx := context.Background()
+		mc := timestamp.NewMockClock()
+
+		ts, err := time.ParseInLocation("2006-01-02 15:04:05", "2024-05-01 00:00:00", time.Local)
+		require.NoError(t, err)
+		mc.Set(ts)
+		ctx = timestamp.SetClock(ctx, mc)
+
+		serviceCache := NewServiceCache()
+		tsdb, err := OpenTSDB(ctx, opts, serviceCache, group)
+		require.NoError(t, err)
+		defer tsdb.Close()
+
+		// The first segment holds both shards, and the second one only holds shard 0.
+		seg, err := tsdb.CreateSegmentIfNotExist(ts)
+		require.NoError(t, err)
+		for _, shardID := range []common.ShardID{0, 1} {
+			_, err = seg.CreateTSTableIfNotExist(shardID)
+			require.NoError(t, err)
+		}
+		segLocation := seg.(*segment[*MockTSTable, any]).location
+		seg.DecRef()
+		seg, err = tsdb.CreateSegmentIfNotExist(ts.Add(24 * time.Hour))
+		require.NoError(t, err)
+		_, err = seg.CreateTSTableIfNotExist(0)
+		require.NoError(t, err)
+		otherSegLocation := seg.(*segment[*MockTSTable, any]).location
+		seg.DecRef()
+
+		require.NoError(t, tsdb.TakeShardFileSnapshot(snapshotDir, 1))
+
+		segDir := filepath.Join(snapshotDir, filepath.Base(segLocation))
+		require.DirExists(t, filepath.Join(segDir, seriesIndexDirName))
+		require.DirExists(t, filepath.Join(segDir, fmt.Sprintf(shardTemplate, 1)))
+		require.NoDirExists(t, filepath.Join(segDir, fmt.Sprintf(shardTemplate, 0)), "the other shards should be skipped")
+		require.NoDirExists(t, filepath.Join(snapshotDir, filepath.Base(otherSegLocation)), "the segments without the shard should be skipped")
+	})
 }
 
 func TestTSDBCollect(t *testing.T) {
@@ -19,12 +19,17 @@ package grpc
 
 import (
 	"context"
+	"strings"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
 	"github.com/apache/skywalking-banyandb/banyand/liaison/pkg/auth"
 )
 
@@ -35,13 +40,17 @@ func authInterceptor(cfg *auth.Config) grpc.UnaryServerInterceptor {
 		info *grpc.UnaryServerInfo,
 		handler grpc.UnaryHandler,
 	) (interface{}, error) {
-		if !cfg.Enabled {
+		if !auth.IsEnabled(cfg) {
 			return handler(ctx, req)
 		}
 		if info.FullMethod == "/grpc.health.v1.Health/Check" && !cfg.HealthAuthEnabled {
 			return handler(ctx, req)
 		}
-		if err := validateUser(ctx, cfg); err != nil {
+		identity, err := authenticate(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		if err = authorize(cfg, identity, info.FullMethod, req); err != nil {
 			return nil, err
 		}
 		return handler(ctx, req)
@@ -55,38 +64,259 @@ func authStreamInterceptor(cfg *auth.Config) grpc.StreamServerInterceptor {
 		info *grpc.StreamServerInfo,
 		handler grpc.StreamHandler,
 	) error {
-		if !cfg.Enabled {
+		if !auth.IsEnabled(cfg) {
 			return handler(srv, stream)
 		}
 		if info.FullMethod == "/grpc.health.v1.Health/Check" && !cfg.HealthAuthEnabled {
 			return handler(srv, stream)
 		}
-		if err := validateUser(stream.Context(), cfg); err != nil {
+		identity, err := authenticate(stream.Context(), cfg)
+		if err != nil {
 			return err
 		}
-		return handler(srv, stream)
+		// The identity is the client whose writes are limited by the write quotas.
+		stream = &identifiedServerStream{
+			ServerStream: stream,
+			ctx:          context.WithValue(stream.Context(), identityKey{}, identity),
+		}
+		if !auth.RBACEnabled(cfg) {
+			return handler(srv, stream)
+		}
+		return handler(srv, &authorizedServerStream{
+			ServerStream: stream,
+			cfg:          cfg,
+			identity:     identity,
+			fullMethod:   info.FullMethod,
+		})
+	}
+}
+
+type identityKey struct{}
+
+// identifiedServerStream carries the authenticated identity in the context of a stream.
+type identifiedServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *identifiedServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// authorizedServerStream authorizes every message received from a stream,
+// since the groups a stream writes to are only known from its messages.
+type authorizedServerStream struct {
+	grpc.ServerStream
+	cfg        *auth.Config
+	identity   *auth.Identity
+	fullMethod string
+}
+
+func (s *authorizedServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
 	}
+	return authorize(s.cfg, s.identity, s.fullMethod, m)
 }
 
-func validateUser(ctx context.Context, cfg *auth.Config) error {
+// authenticate verifies the credentials in the metadata.
+// A client presents either the "username" and "password", an "x-api-key", or an "authorization" with
+// the Basic or Bearer scheme.
+func authenticate(ctx context.Context, cfg *auth.Config) (*auth.Identity, error) {
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
-		return status.Errorf(codes.Unauthenticated, "metadata is not provided")
+		return nil, status.Errorf(codes.Unauthenticated, "metadata is not provided")
 	}
 
-	usernames := md.Get("username")
-	passwords := md.Get("password")
+	identity, err := auth.Authenticate(cfg, credentialsFromMetadata(md))
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "Invalid credentials")
+	}
+	return identity, nil
+}
 
-	if len(usernames) == 0 || len(passwords) == 0 {
-		return status.Errorf(codes.Unauthenticated, "Invalid credentials")
+func credentialsFromMetadata(md metadata.MD) auth.Credentials {
+	first := func(key string) string {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+	creds := auth.Credentials{
+		Username: first("username"),
+		Password: first("password"),
+		APIKey:   first("x-api-key"),
 	}
+	if authorization := first("authorization"); authorization != "" {
+		if parsed, err := auth.ParseAuthorization(authorization); err == nil {
+			creds.BearerToken = parsed.BearerToken
+			if creds.Username == "" && creds.Password == "" {
+				creds.Username, creds.Password = parsed.Username, parsed.Password
+			}
+		}
+	}
+	return creds
+}
 
-	username := usernames[0]
-	password := passwords[0]
+// serviceCatalogs maps the services to the catalog their resources belong to.
+// The services of catalog-agnostic resources, like groups and index rules, map to an empty catalog.
+var serviceCatalogs = map[string]string{
+	"banyandb.stream.v1.StreamService":                     auth.CatalogStream,
+	"banyandb.measure.v1.MeasureService":                   auth.CatalogMeasure,
+	"banyandb.trace.v1.TraceService":                       auth.CatalogTrace,
+	"banyandb.property.v1.PropertyService":                 auth.CatalogProperty,
+	"banyandb.database.v1.StreamRegistryService":           auth.CatalogStream,
+	"banyandb.database.v1.MeasureRegistryService":          auth.CatalogMeasure,
+	"banyandb.database.v1.TraceRegistryService":            auth.CatalogTrace,
+	"banyandb.database.v1.PropertyRegistryService":         auth.CatalogProperty,
+	"banyandb.database.v1.GroupRegistryService":            "",
+	"banyandb.database.v1.IndexRuleRegistryService":        "",
+	"banyandb.database.v1.IndexRuleBindingRegistryService": "",
+	"banyandb.database.v1.TopNAggregationRegistryService":  "",
+	"banyandb.database.v1.SnapshotService":                 "",
+	"banyandb.database.v1.ClusterService":                  "",
+	"opentelemetry.proto.collector.trace.v1.TraceService":  auth.CatalogTrace,
+}
 
-	if !auth.CheckUsernameAndPassword(cfg, username, password) {
-		return status.Errorf(codes.Unauthenticated, "Invalid credentials")
+// permissionFreeServices are the services which don't require a permission.
+// The methods of the other services out of serviceCatalogs are denied.
+var permissionFreeServices = map[string]struct{}{
+	"grpc.health.v1.Health":      {},
+	"banyandb.common.v1.Service": {},
+	// A BydbQL query is authorized on the request it's compiled to, see bydbQLService.authorize.
+	"banyandb.bydbql.v1.BydbQLService": {},
+}
+
+// methodPermission returns the permission required to call the method.
+// The methods of the services out of serviceCatalogs return false.
+func methodPermission(fullMethod string) (service string, permission auth.Permission, ok bool) {
+	service, method, found := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !found {
+		return "", "", false
+	}
+	if _, ok = serviceCatalogs[service]; !ok {
+		return service, "", false
+	}
+	switch {
+	case service == "banyandb.database.v1.SnapshotService":
+		return service, auth.PermissionSnapshot, true
+	case service == "banyandb.database.v1.ClusterService":
+		if method == "Rebalance" || method == "Repair" {
+			return service, auth.PermissionSchemaAdmin, true
+		}
+		return service, auth.PermissionRead, true
+	case strings.HasSuffix(service, "RegistryService"):
+		switch method {
+		case "Create", "Update", "Delete":
+			return service, auth.PermissionSchemaAdmin, true
+		}
+		return service, auth.PermissionRead, true
+	}
+	switch method {
+	case "Write", "Apply", "Delete", "DeleteExpiredSegments", "Export":
+		return service, auth.PermissionWrite, true
 	}
+	return service, auth.PermissionRead, true
+}
+
+// resourceScope is a group of a catalog a request accesses.
+type resourceScope struct {
+	catalog string
+	group   string
+}
 
+func authorize(cfg *auth.Config, identity *auth.Identity, fullMethod string, req interface{}) error {
+	if !auth.RBACEnabled(cfg) {
+		return nil
+	}
+	service, permission, ok := methodPermission(fullMethod)
+	if !ok {
+		if _, free := permissionFreeServices[service]; free {
+			return nil
+		}
+		return status.Errorf(codes.PermissionDenied, "%s is not allowed to call %s", identity.Name, fullMethod)
+	}
+	for _, scope := range requestScopes(req, serviceCatalogs[service]) {
+		if auth.Authorize(cfg, identity, permission, scope.catalog, scope.group) {
+			continue
+		}
+		if scope.group == "" {
+			return status.Errorf(codes.PermissionDenied, "%s is not allowed to %s all groups", identity.Name, permission)
+		}
+		return status.Errorf(codes.PermissionDenied, "%s is not allowed to %s group %s", identity.Name, permission, scope.group)
+	}
 	return nil
 }
+
+// requestScopes returns the groups a request accesses.
+// A request without groups, like listing all groups, accesses all of them, which is represented by an empty group.
+func requestScopes(req interface{}, catalog string) []resourceScope {
+	switch r := req.(type) {
+	case *databasev1.SnapshotRequest:
+		if len(r.GetGroups()) == 0 {
+			return []resourceScope{{}}
+		}
+		scopes := make([]resourceScope, 0, len(r.GetGroups()))
+		for _, g := range r.GetGroups() {
+			scopes = append(scopes, resourceScope{catalog: catalogName(g.GetCatalog()), group: g.GetGroup()})
+		}
+		return scopes
+	case *databasev1.GroupRegistryServiceCreateRequest:
+		return []resourceScope{{catalog: catalogName(r.GetGroup().GetCatalog()), group: r.GetGroup().GetMetadata().GetName()}}
+	case *databasev1.GroupRegistryServiceUpdateRequest:
+		return []resourceScope{{catalog: catalogName(r.GetGroup().GetCatalog()), group: r.GetGroup().GetMetadata().GetName()}}
+	}
+	var groups []string
+	if m, ok := req.(proto.Message); ok {
+		groups = messageGroups(m.ProtoReflect(), 2)
+	}
+	if len(groups) == 0 {
+		return []resourceScope{{catalog: catalog}}
+	}
+	scopes := make([]resourceScope, 0, len(groups))
+	for _, g := range groups {
+		scopes = append(scopes, resourceScope{catalog: catalog, group: g})
+	}
+	return scopes
+}
+
+// messageGroups looks up the groups in a request and the messages it embeds down to the depth.
+// Requests carry their groups in a "groups" or "group" field, or in the group of their metadata.
+func messageGroups(m protoreflect.Message, depth int) []string {
+	if m == nil || !m.IsValid() {
+		return nil
+	}
+	switch v := m.Interface().(type) {
+	case interface{ GetGroups() []string }:
+		return v.GetGroups()
+	case interface{ GetGroup() string }:
+		if v.GetGroup() != "" {
+			return []string{v.GetGroup()}
+		}
+		return nil
+	case interface{ GetMetadata() *commonv1.Metadata }:
+		if v.GetMetadata().GetGroup() != "" {
+			return []string{v.GetMetadata().GetGroup()}
+		}
+		return nil
+	}
+	if depth == 0 {
+		return nil
+	}
+	var groups []string
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.Kind() != protoreflect.MessageKind || fd.IsList() || fd.IsMap() {
+			return true
+		}
+		groups = append(groups, messageGroups(v.Message(), depth-1)...)
+		return true
+	})
+	return groups
+}
+
+func catalogName(catalog commonv1.Catalog) string {
+	if catalog == commonv1.Catalog_CATALOG_UNSPECIFIED {
+		return ""
+	}
+	return strings.ToLower(strings.TrimPrefix(catalog.String(), "CATALOG_"))
+}
@@ -0,0 +1,264 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	bydbqlv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/bydbql/v1"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	propertyv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/property/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/banyand/liaison/pkg/auth"
+)
+
+func newTestAuthConfig() *auth.Config {
+	return &auth.Config{
+		Enabled: true,
+		Users: []auth.User{
+			{Username: "admin", Password: "admin", Roles: []string{"admin"}},
+			{Username: "tenant", Password: "tenant", Roles: []string{"tenant"}},
+		},
+		Roles: []auth.Role{
+			{
+				Name: "admin",
+				Rules: []auth.Rule{{Permissions: []auth.Permission{
+					auth.PermissionRead, auth.PermissionWrite, auth.PermissionSchemaAdmin, auth.PermissionSnapshot,
+				}}},
+			},
+			{
+				Name: "tenant",
+				Rules: []auth.Rule{
+					{Permissions: []auth.Permission{auth.PermissionRead, auth.PermissionWrite}, Groups: []string{"tenant_a"}, Catalogs: []string{auth.CatalogStream}},
+				},
+			},
+		},
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	cfg := newTestAuthConfig()
+	tests := []struct {
+		req        interface{}
+		name       string
+		username   string
+		fullMethod string
+		allowed    bool
+	}{
+		{
+			name:       "query the own group",
+			username:   "tenant",
+			fullMethod: "/banyandb.stream.v1.StreamService/Query",
+			req:        &streamv1.QueryRequest{Groups: []string{"tenant_a"}},
+			allowed:    true,
+		},
+		{
+			name:       "query another group",
+			username:   "tenant",
+			fullMethod: "/banyandb.stream.v1.StreamService/Query",
+			req:        &streamv1.QueryRequest{Groups: []string{"tenant_a", "tenant_b"}},
+		},
+		{
+			name:       "write the own group",
+			username:   "tenant",
+			fullMethod: "/banyandb.stream.v1.StreamService/Write",
+			req:        &streamv1.WriteRequest{Metadata: &commonv1.Metadata{Group: "tenant_a", Name: "sw"}},
+			allowed:    true,
+		},
+		{
+			name:       "write the own group of another catalog",
+			username:   "tenant",
+			fullMethod: "/banyandb.property.v1.PropertyService/Apply",
+			req: &propertyv1.ApplyRequest{Property: &propertyv1.Property{
+				Metadata: &commonv1.Metadata{Group: "tenant_a", Name: "p"},
+			}},
+		},
+		{
+			name:       "create a stream without the schema admin permission",
+			username:   "tenant",
+			fullMethod: "/banyandb.database.v1.StreamRegistryService/Create",
+			req: &databasev1.StreamRegistryServiceCreateRequest{Stream: &databasev1.Stream{
+				Metadata: &commonv1.Metadata{Group: "tenant_a", Name: "sw"},
+			}},
+		},
+		{
+			name:       "read the schema of the own group",
+			username:   "tenant",
+			fullMethod: "/banyandb.database.v1.StreamRegistryService/Get",
+			req:        &databasev1.StreamRegistryServiceGetRequest{Metadata: &commonv1.Metadata{Group: "tenant_a", Name: "sw"}},
+			allowed:    true,
+		},
+		{
+			name:       "delete another group",
+			username:   "tenant",
+			fullMethod: "/banyandb.database.v1.GroupRegistryService/Delete",
+			req:        &databasev1.GroupRegistryServiceDeleteRequest{Group: "tenant_b"},
+		},
+		{
+			name:       "list all groups",
+			username:   "tenant",
+			fullMethod: "/banyandb.database.v1.GroupRegistryService/List",
+			req:        &databasev1.GroupRegistryServiceListRequest{},
+		},
+		{
+			name:       "take a snapshot",
+			username:   "tenant",
+			fullMethod: "/banyandb.database.v1.SnapshotService/Snapshot",
+			req:        &databasev1.SnapshotRequest{},
+		},
+		{
+			name:       "rebalance the shards of the own group",
+			username:   "tenant",
+			fullMethod: "/banyandb.database.v1.ClusterService/Rebalance",
+			req:        &databasev1.ClusterServiceRebalanceRequest{Groups: []string{"tenant_a"}},
+		},
+		{
+			name:       "repair the shards of the own group",
+			username:   "tenant",
+			fullMethod: "/banyandb.database.v1.ClusterService/Repair",
+			req:        &databasev1.ClusterServiceRepairRequest{Groups: []string{"tenant_a"}},
+		},
+		{
+			name:       "list the shards of the own group",
+			username:   "tenant",
+			fullMethod: "/banyandb.database.v1.ClusterService/ListShards",
+			req:        &databasev1.ClusterServiceListShardsRequest{Group: "tenant_a"},
+			allowed:    true,
+		},
+		{
+			name:       "get the api version",
+			username:   "tenant",
+			fullMethod: "/banyandb.common.v1.Service/GetAPIVersion",
+			req:        &commonv1.GetAPIVersionRequest{},
+			allowed:    true,
+		},
+		{
+			name:       "call a method out of the catalogs as the admin",
+			username:   "admin",
+			fullMethod: "/banyandb.property.v1.GossipService/Propagate",
+			req:        &commonv1.GetAPIVersionRequest{},
+		},
+		{
+			name:       "call a method of an unknown service as the admin",
+			username:   "admin",
+			fullMethod: "/unknown.v1.Service/Get",
+			req:        &commonv1.GetAPIVersionRequest{},
+		},
+		{
+			name:       "create a group as the admin",
+			username:   "admin",
+			fullMethod: "/banyandb.database.v1.GroupRegistryService/Create",
+			req: &databasev1.GroupRegistryServiceCreateRequest{Group: &commonv1.Group{
+				Metadata: &commonv1.Metadata{Name: "tenant_c"},
+				Catalog:  commonv1.Catalog_CATALOG_MEASURE,
+			}},
+			allowed: true,
+		},
+		{
+			name:       "take a snapshot as the admin",
+			username:   "admin",
+			fullMethod: "/banyandb.database.v1.SnapshotService/Snapshot",
+			req:        &databasev1.SnapshotRequest{},
+			allowed:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := auth.Authenticate(cfg, auth.Credentials{Username: tt.username, Password: tt.username})
+			require.NoError(t, err)
+			err = authorize(cfg, identity, tt.fullMethod, tt.req)
+			if tt.allowed {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		})
+	}
+}
+
+func TestAuthorizeWithoutRoles(t *testing.T) {
+	cfg := &auth.Config{
+		Enabled: true,
+		Users:   []auth.User{{Username: "admin", Password: "admin"}},
+	}
+	assert.NoError(t, authorize(cfg, &auth.Identity{Name: "admin"}, "/banyandb.database.v1.GroupRegistryService/Delete",
+		&databasev1.GroupRegistryServiceDeleteRequest{Group: "tenant_b"}))
+}
+
+func TestBydbQLAuthorizeBeforeLookingUpSchemas(t *testing.T) {
+	// The service has no schema repository, so a query reaching the schema lookup panics.
+	s := &bydbQLService{cfg: newTestAuthConfig()}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("username", "tenant", "password", "tenant"))
+	for _, query := range []string{
+		"SELECT * FROM STREAM sw IN tenant_b",
+		"SELECT * FROM STREAM sw IN (tenant_a, tenant_b)",
+		"SELECT * FROM MEASURE service_cpm IN tenant_a",
+	} {
+		t.Run(query, func(t *testing.T) {
+			_, err := s.Query(ctx, &bydbqlv1.QueryRequest{Query: query})
+			assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		})
+	}
+}
+
+func TestCredentialsFromMetadata(t *testing.T) {
+	tests := []struct {
+		md   metadata.MD
+		want auth.Credentials
+		name string
+	}{
+		{
+			name: "username and password",
+			md:   metadata.Pairs("username", "admin", "password", "secret"),
+			want: auth.Credentials{Username: "admin", Password: "secret"},
+		},
+		{
+			name: "basic authorization",
+			md:   metadata.Pairs("authorization", "Basic YWRtaW46c2VjcmV0"),
+			want: auth.Credentials{Username: "admin", Password: "secret"},
+		},
+		{
+			name: "bearer token",
+			md:   metadata.Pairs("authorization", "Bearer token"),
+			want: auth.Credentials{BearerToken: "token"},
+		},
+		{
+			name: "API key",
+			md:   metadata.Pairs("x-api-key", "key"),
+			want: auth.Credentials{APIKey: "key"},
+		},
+		{
+			name: "unsupported authorization",
+			md:   metadata.Pairs("authorization", "Digest username=admin"),
+			want: auth.Credentials{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, credentialsFromMetadata(tt.md))
+		})
+	}
+}
@@ -0,0 +1,198 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	bydbqlv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/bydbql/v1"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	"github.com/apache/skywalking-banyandb/banyand/liaison/pkg/auth"
+	"github.com/apache/skywalking-banyandb/banyand/metadata"
+	"github.com/apache/skywalking-banyandb/pkg/bydbql"
+)
+
+// queryMethods are the methods a BydbQL query is executed by, whose permissions the query requires.
+var queryMethods = map[bydbql.Kind]string{
+	bydbql.KindStream:   "/banyandb.stream.v1.StreamService/Query",
+	bydbql.KindMeasure:  "/banyandb.measure.v1.MeasureService/Query",
+	bydbql.KindTopN:     "/banyandb.measure.v1.MeasureService/TopN",
+	bydbql.KindTrace:    "/banyandb.trace.v1.TraceService/Query",
+	bydbql.KindProperty: "/banyandb.property.v1.PropertyService/Query",
+}
+
+// bydbQLService compiles the BydbQL queries with the schemas of the resources they select from,
+// and executes the compiled requests by the query services.
+type bydbQLService struct {
+	bydbqlv1.UnimplementedBydbQLServiceServer
+	schemaRepo     metadata.Repo
+	streamSVC      *streamService
+	measureSVC     *measureService
+	traceSVC       *traceService
+	propertyServer *propertyServer
+	cfg            *auth.Config
+}
+
+// validatable is a request generated with the validation rules.
+type validatable interface {
+	Validate() error
+}
+
+// authorize checks the groups of the parsed query against the permission of the method executing it,
+// since the authorization interceptor doesn't know the groups of a BydbQL query.
+// It runs before the schemas are looked up, so that a query can't probe the resources of the groups it isn't allowed to access.
+func (s *bydbQLService) authorize(ctx context.Context, q *bydbql.Query) error {
+	if !auth.IsEnabled(s.cfg) || !auth.RBACEnabled(s.cfg) {
+		return nil
+	}
+	identity, err := authenticate(ctx, s.cfg)
+	if err != nil {
+		return err
+	}
+	service, permission, ok := methodPermission(queryMethods[q.Kind])
+	if !ok {
+		return status.Errorf(codes.InvalidArgument, "unsupported resource %s", q.Kind)
+	}
+	for _, group := range q.Groups {
+		if !auth.Authorize(s.cfg, identity, permission, serviceCatalogs[service], group) {
+			return status.Errorf(codes.PermissionDenied, "%s is not allowed to %s group %s", identity.Name, permission, group)
+		}
+	}
+	return nil
+}
+
+// validate checks the compiled request against the validation rules the query services apply.
+func validate(req validatable) error {
+	if err := req.Validate(); err != nil {
+		return status.Errorf(codes.InvalidArgument, "the compiled request is invalid: %v", err)
+	}
+	return nil
+}
+
+func (s *bydbQLService) Query(ctx context.Context, req *bydbqlv1.QueryRequest) (*bydbqlv1.QueryResponse, error) {
+	q, err := bydbql.Parse(req.GetQuery())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err = s.authorize(ctx, q); err != nil {
+		return nil, err
+	}
+	// The schema of the resource is looked up in the first group, as the resource shares the schema in all groups.
+	subject := &commonv1.Metadata{Group: q.Groups[0], Name: q.Name}
+	now := time.Now()
+	switch q.Kind {
+	case bydbql.KindStream:
+		stream, err := s.schemaRepo.StreamRegistry().GetStream(ctx, subject)
+		if err != nil {
+			return nil, err
+		}
+		rules, err := s.schemaRepo.IndexRules(ctx, subject)
+		if err != nil {
+			return nil, err
+		}
+		streamReq, err := q.StreamRequest(stream, rules, now)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if err = validate(streamReq); err != nil {
+			return nil, err
+		}
+		resp, err := s.streamSVC.Query(ctx, streamReq)
+		if err != nil {
+			return nil, err
+		}
+		return &bydbqlv1.QueryResponse{Result: &bydbqlv1.QueryResponse_StreamResult{StreamResult: resp}}, nil
+	case bydbql.KindMeasure:
+		measure, err := s.schemaRepo.MeasureRegistry().GetMeasure(ctx, subject)
+		if err != nil {
+			return nil, err
+		}
+		rules, err := s.schemaRepo.IndexRules(ctx, subject)
+		if err != nil {
+			return nil, err
+		}
+		measureReq, err := q.MeasureRequest(measure, rules, now)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if err = validate(measureReq); err != nil {
+			return nil, err
+		}
+		resp, err := s.measureSVC.Query(ctx, measureReq)
+		if err != nil {
+			return nil, err
+		}
+		return &bydbqlv1.QueryResponse{Result: &bydbqlv1.QueryResponse_MeasureResult{MeasureResult: resp}}, nil
+	case bydbql.KindTopN:
+		topNReq, err := q.TopNRequest(now)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if err = validate(topNReq); err != nil {
+			return nil, err
+		}
+		resp, err := s.measureSVC.TopN(ctx, topNReq)
+		if err != nil {
+			return nil, err
+		}
+		return &bydbqlv1.QueryResponse{Result: &bydbqlv1.QueryResponse_TopNResult{TopNResult: resp}}, nil
+	case bydbql.KindTrace:
+		trace, err := s.schemaRepo.TraceRegistry().GetTrace(ctx, subject)
+		if err != nil {
+			return nil, err
+		}
+		rules, err := s.schemaRepo.IndexRules(ctx, subject)
+		if err != nil {
+			return nil, err
+		}
+		traceReq, err := q.TraceRequest(trace, rules, now)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if err = validate(traceReq); err != nil {
+			return nil, err
+		}
+		resp, err := s.traceSVC.Query(ctx, traceReq)
+		if err != nil {
+			return nil, err
+		}
+		return &bydbqlv1.QueryResponse{Result: &bydbqlv1.QueryResponse_TraceResult{TraceResult: resp}}, nil
+	case bydbql.KindProperty:
+		property, err := s.schemaRepo.PropertyRegistry().GetProperty(ctx, subject)
+		if err != nil {
+			return nil, err
+		}
+		propertyReq, err := q.PropertyRequest(property)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if err = validate(propertyReq); err != nil {
+			return nil, err
+		}
+		resp, err := s.propertyServer.Query(ctx, propertyReq)
+		if err != nil {
+			return nil, err
+		}
+		return &bydbqlv1.QueryResponse{Result: &bydbqlv1.QueryResponse_PropertyResult{PropertyResult: resp}}, nil
+	}
+	return nil, status.Errorf(codes.InvalidArgument, "unsupported resource %s", q.Kind)
+}
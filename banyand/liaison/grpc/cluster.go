@@ -0,0 +1,458 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/api/data"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata"
+	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/node"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+const (
+	// shardMoveTimeout is the time the data nodes have to move the shards of a rebalancing.
+	shardMoveTimeout = time.Hour
+	// repairStartTimeout is the time the data nodes have to start the repairs, which run in the background.
+	repairStartTimeout = 30 * time.Second
+)
+
+var shardMoveTopics = map[commonv1.Catalog]bus.Topic{
+	commonv1.Catalog_CATALOG_STREAM:  data.TopicStreamShardMove,
+	commonv1.Catalog_CATALOG_MEASURE: data.TopicMeasureShardMove,
+}
+
+type repairKey struct {
+	group   string
+	shardID uint32
+}
+
+type shardKey struct {
+	group     string
+	shardID   uint32
+	replicaID uint32
+}
+
+type clusterService struct {
+	databasev1.UnimplementedClusterServiceServer
+	schemaRegistry metadata.Repo
+	pipeline       queue.Client
+	routers        map[commonv1.Catalog]node.ShardRouter
+	log            *logger.Logger
+	scheduler      *timestamp.Scheduler
+	repairCron     string
+}
+
+func (cs *clusterService) setLogger(log *logger.Logger) {
+	cs.log = log
+}
+
+func (cs *clusterService) ListShards(ctx context.Context, req *databasev1.ClusterServiceListShardsRequest) (*databasev1.ClusterServiceListShardsResponse, error) {
+	var groupNames []string
+	if req.GetGroup() != "" {
+		groupNames = []string{req.GetGroup()}
+	}
+	groups, err := cs.groups(ctx, groupNames)
+	if err != nil {
+		return nil, err
+	}
+	resp := &databasev1.ClusterServiceListShardsResponse{}
+	for _, g := range groups {
+		shards, ok := cs.routers[g.Catalog].Shards(g.GetMetadata().GetName())
+		if !ok {
+			continue
+		}
+		for shardID, copies := range shards {
+			resp.Shards = append(resp.Shards, &databasev1.ShardNodes{
+				Group:   g.GetMetadata().GetName(),
+				ShardId: uint32(shardID),
+				Nodes:   copies,
+			})
+		}
+	}
+	for _, r := range cs.routers {
+		resp.Nodes = append(resp.Nodes, r.Nodes()...)
+	}
+	slices.Sort(resp.Nodes)
+	resp.Nodes = slices.Compact(resp.Nodes)
+	return resp, nil
+}
+
+// Rebalance assigns the copies of the shards to the data nodes except the drained ones with the minimal movement.
+// The parts are moved before the routing is switched to the new assignment, so that the targets never serve a copy
+// without its data. The copies failing to be moved stay on their sources, so that they're planned again by the next
+// rebalancing. The sources keep serving the moved parts until the switch, after which they send the parts written
+// during the moves to the targets and remove all moved parts.
+func (cs *clusterService) Rebalance(ctx context.Context, req *databasev1.ClusterServiceRebalanceRequest) (*databasev1.ClusterServiceRebalanceResponse, error) {
+	drained := make(map[string]struct{}, len(req.GetDrainNodes()))
+	for _, n := range req.GetDrainNodes() {
+		if _, err := cs.schemaRegistry.NodeRegistry().GetNode(ctx, n); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "node %s isn't found: %v", n, err)
+		}
+		drained[n] = struct{}{}
+	}
+	groups, err := cs.groups(ctx, req.GetGroups())
+	if err != nil {
+		return nil, err
+	}
+
+	type plan struct {
+		group      *commonv1.Group
+		shards     [][]string
+		assignment [][]string
+	}
+	var plans []plan
+	resp := &databasev1.ClusterServiceRebalanceResponse{}
+	for _, g := range groups {
+		router := cs.routers[g.Catalog]
+		name := g.GetMetadata().GetName()
+		shards, ok := router.Shards(name)
+		if !ok {
+			continue
+		}
+		alive := router.Nodes()
+		nodes := slices.DeleteFunc(slices.Clone(alive), func(n string) bool {
+			_, ok := drained[n]
+			return ok
+		})
+		assignment, moves, planErr := node.PlanRebalance(shards, nodes)
+		if planErr != nil {
+			return nil, status.Errorf(codes.FailedPrecondition, "failed to rebalance group %s: %v", name, planErr)
+		}
+		for _, m := range moves {
+			move := &databasev1.ShardMove{
+				Group:     name,
+				ShardId:   m.ShardID,
+				ReplicaId: m.ReplicaID,
+				Source:    m.Source,
+				Target:    m.Target,
+			}
+			if !slices.Contains(alive, m.Source) {
+				// The data of a copy on a left node is sent from another copy of the shard, which stays where it is.
+				move.Source = ""
+				for _, n := range shards[m.ShardID] {
+					if n != m.Source && slices.Contains(alive, n) {
+						move.Source = n
+						move.KeepSource = true
+						break
+					}
+				}
+				if move.Source == "" {
+					move.Error = fmt.Sprintf("no available copy of the shard is found since node %s isn't available", m.Source)
+				}
+			}
+			resp.Moves = append(resp.Moves, move)
+		}
+		plans = append(plans, plan{group: g, shards: shards, assignment: assignment})
+	}
+	if req.GetDryRun() {
+		return resp, nil
+	}
+
+	if err = cs.move(ctx, groups, resp.Moves); err != nil {
+		return nil, err
+	}
+
+	failed := make(map[string][]*databasev1.ShardMove)
+	var catchUps []*databasev1.ShardMove
+	for _, m := range resp.Moves {
+		switch {
+		case m.Error != "":
+			failed[m.Group] = append(failed[m.Group], m)
+		case !m.KeepSource:
+			// The copies sent from another copy of the shard are caught up by the replica repair.
+			c := proto.Clone(m).(*databasev1.ShardMove)
+			c.CatchUp = true
+			catchUps = append(catchUps, c)
+		}
+	}
+	for _, p := range plans {
+		for _, m := range failed[p.group.GetMetadata().GetName()] {
+			if source := p.shards[m.ShardId][m.ReplicaId]; slices.Contains(cs.routers[p.group.Catalog].Nodes(), source) {
+				p.assignment[m.ShardId][m.ReplicaId] = source
+			}
+		}
+		if err = cs.assign(ctx, p.group, p.assignment); err != nil {
+			return nil, err
+		}
+	}
+	if len(catchUps) == 0 {
+		return resp, nil
+	}
+
+	// The sources skip the parts sent by the first moves, and remove them along with the parts written since then.
+	if err = cs.move(ctx, groups, catchUps); err != nil {
+		return nil, err
+	}
+	moves := make(map[shardKey]*databasev1.ShardMove, len(resp.Moves))
+	for _, m := range resp.Moves {
+		moves[shardKey{group: m.Group, shardID: m.ShardId, replicaID: m.ReplicaId}] = m
+	}
+	for _, c := range catchUps {
+		if c.Error != "" {
+			moves[shardKey{group: c.Group, shardID: c.ShardId, replicaID: c.ReplicaId}].Error =
+				fmt.Sprintf("the parts written during the move aren't sent and the moved parts are left on the source: %s", c.Error)
+		}
+	}
+	return resp, nil
+}
+
+// Repair starts the anti-entropy repairs of the shards in the groups, which compare the digests of the parts
+// between the available copies of each shard and send the missing data to each other.
+// The repairs run in the background on the data nodes, the response only reports whether they're started.
+func (cs *clusterService) Repair(ctx context.Context, req *databasev1.ClusterServiceRepairRequest) (*databasev1.ClusterServiceRepairResponse, error) {
+	groups, err := cs.groups(ctx, req.GetGroups())
+	if err != nil {
+		return nil, err
+	}
+	resp := &databasev1.ClusterServiceRepairResponse{}
+	pending := make(map[repairKey]*databasev1.ShardRepair)
+	request := &databasev1.InternalRepairShardsRequest{}
+	for _, g := range groups {
+		router := cs.routers[g.Catalog]
+		name := g.GetMetadata().GetName()
+		shards, ok := router.Shards(name)
+		if !ok {
+			continue
+		}
+		alive := router.Nodes()
+		for shardID, copies := range shards {
+			var nodes []string
+			for _, n := range copies {
+				if slices.Contains(alive, n) && !slices.Contains(nodes, n) {
+					nodes = append(nodes, n)
+				}
+			}
+			repair := &databasev1.ShardRepair{
+				Group:   name,
+				ShardId: uint32(shardID),
+				Nodes:   nodes,
+			}
+			resp.Repairs = append(resp.Repairs, repair)
+			if len(nodes) < 2 {
+				repair.Error = "fewer than two available copies of the shard are found"
+				continue
+			}
+			request.Repairs = append(request.Repairs, repair)
+			pending[repairKey{group: name, shardID: uint32(shardID)}] = repair
+		}
+	}
+	if len(request.Repairs) == 0 {
+		return resp, nil
+	}
+
+	ff, err := cs.pipeline.Broadcast(repairStartTimeout, data.TopicReplicaRepair, bus.NewMessage(bus.MessageID(time.Now().UnixNano()), request))
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to send the repairs: %v", err)
+	}
+	for _, f := range ff {
+		msg, errGet := f.Get()
+		if errGet != nil {
+			cs.log.Error().Err(errGet).Msg("failed to get the result of the repairs")
+			continue
+		}
+		switch d := msg.Data().(type) {
+		case *common.Error:
+			cs.log.Error().Str("error", d.Error()).Msg("failed to repair the shards")
+		case *databasev1.InternalRepairShardsResponse:
+			for _, result := range d.Repairs {
+				k := repairKey{group: result.Group, shardID: result.ShardId}
+				if r, ok := pending[k]; ok {
+					r.Error = result.Error
+					delete(pending, k)
+				}
+			}
+		}
+	}
+	for _, r := range pending {
+		r.Error = fmt.Sprintf("no response from node %s, the replica repair might be disabled on it", r.Nodes[0])
+	}
+	return resp, nil
+}
+
+// repairAll starts the repairs of all the stream and measure groups, which is scheduled by the trigger cron.
+func (cs *clusterService) repairAll(ctx context.Context) {
+	resp, err := cs.Repair(ctx, &databasev1.ClusterServiceRepairRequest{})
+	if err != nil {
+		cs.log.Error().Err(err).Msg("failed to repair the replicas")
+		return
+	}
+	for _, r := range resp.Repairs {
+		if r.Error != "" {
+			cs.log.Warn().Str("group", r.Group).Uint32("shard", r.ShardId).Str("error", r.Error).Msg("failed to start the repair")
+		}
+	}
+}
+
+// startRepairScheduler schedules the repairs of all the groups if the trigger cron is set.
+func (cs *clusterService) startRepairScheduler() error {
+	if cs.repairCron == "" {
+		return nil
+	}
+	cs.scheduler = timestamp.NewScheduler(cs.log, timestamp.NewClock())
+	err := cs.scheduler.Register("replica-repair", cron.Minute|cron.Hour|cron.Dom|cron.Month|cron.Dow|cron.Descriptor,
+		cs.repairCron, func(time.Time, *logger.Logger) bool {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*repairStartTimeout)
+			defer cancel()
+			cs.repairAll(ctx)
+			return true
+		})
+	if err != nil {
+		return fmt.Errorf("failed to add the replica repair cron task: %w", err)
+	}
+	return nil
+}
+
+func (cs *clusterService) stopRepairScheduler() {
+	if cs.scheduler != nil {
+		cs.scheduler.Close()
+	}
+}
+
+// groups returns the stream and measure groups in the names, or all of them if the names are empty.
+func (cs *clusterService) groups(ctx context.Context, names []string) ([]*commonv1.Group, error) {
+	if len(names) == 0 {
+		all, err := cs.schemaRegistry.GroupRegistry().ListGroup(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var groups []*commonv1.Group
+		for _, g := range all {
+			if _, ok := cs.routers[g.Catalog]; ok {
+				groups = append(groups, g)
+			}
+		}
+		return groups, nil
+	}
+	groups := make([]*commonv1.Group, 0, len(names))
+	for _, n := range names {
+		g, err := cs.schemaRegistry.GroupRegistry().GetGroup(ctx, n)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "group %s isn't found: %v", n, err)
+		}
+		if _, ok := cs.routers[g.Catalog]; !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "the shards of group %s in catalog %s aren't managed by the cluster", n, g.Catalog)
+		}
+		groups = append(groups, g)
+	}
+	return groups, nil
+}
+
+// assign stores the assignment of the shards in the group, which the selectors route the writes by.
+func (cs *clusterService) assign(ctx context.Context, group *commonv1.Group, assignment [][]string) error {
+	if group.GetResourceOpts() == nil {
+		return status.Errorf(codes.FailedPrecondition, "group %s has no resource options", group.GetMetadata().GetName())
+	}
+	assignments := make([]*commonv1.ShardAssignment, 0, len(assignment))
+	for shardID, copies := range assignment {
+		assignments = append(assignments, &commonv1.ShardAssignment{
+			ShardId: uint32(shardID),
+			Nodes:   slices.Clone(copies),
+		})
+	}
+	if len(assignments) == len(group.GetResourceOpts().GetShardAssignments()) && slices.EqualFunc(assignments,
+		group.GetResourceOpts().GetShardAssignments(), func(a, b *commonv1.ShardAssignment) bool { return proto.Equal(a, b) }) {
+		return nil
+	}
+	g := proto.Clone(group).(*commonv1.Group)
+	g.ResourceOpts.ShardAssignments = assignments
+	if err := cs.schemaRegistry.GroupRegistry().UpdateGroup(ctx, g); err != nil {
+		return status.Errorf(codes.Internal, "failed to update the shard assignments of group %s: %v", g.GetMetadata().GetName(), err)
+	}
+	group.ResourceOpts.ShardAssignments = assignments
+	return nil
+}
+
+// move sends the moves to the data nodes of each catalog, and records the errors of the failed moves.
+func (cs *clusterService) move(ctx context.Context, groups []*commonv1.Group, moves []*databasev1.ShardMove) error {
+	catalogs := make(map[string]commonv1.Catalog, len(groups))
+	for _, g := range groups {
+		catalogs[g.GetMetadata().GetName()] = g.Catalog
+	}
+	requests := make(map[commonv1.Catalog]*databasev1.InternalMoveShardsRequest)
+	targets := make(map[string]*databasev1.Node)
+	pending := make(map[shardKey]*databasev1.ShardMove)
+	for _, m := range moves {
+		if m.Error != "" {
+			continue
+		}
+		if _, ok := targets[m.Target]; !ok {
+			n, err := cs.schemaRegistry.NodeRegistry().GetNode(ctx, m.Target)
+			if err != nil {
+				return status.Errorf(codes.Internal, "failed to get node %s: %v", m.Target, err)
+			}
+			targets[m.Target] = n
+		}
+		catalog := catalogs[m.Group]
+		r, ok := requests[catalog]
+		if !ok {
+			r = &databasev1.InternalMoveShardsRequest{}
+			requests[catalog] = r
+		}
+		if !slices.ContainsFunc(r.Targets, func(n *databasev1.Node) bool { return n.GetMetadata().GetName() == m.Target }) {
+			r.Targets = append(r.Targets, targets[m.Target])
+		}
+		r.Moves = append(r.Moves, m)
+		pending[shardKey{group: m.Group, shardID: m.ShardId, replicaID: m.ReplicaId}] = m
+	}
+
+	for catalog, r := range requests {
+		ff, err := cs.pipeline.Broadcast(shardMoveTimeout, shardMoveTopics[catalog], bus.NewMessage(bus.MessageID(time.Now().UnixNano()), r))
+		if err != nil {
+			return status.Errorf(codes.Unavailable, "failed to send the moves: %v", err)
+		}
+		for _, f := range ff {
+			msg, errGet := f.Get()
+			if errGet != nil {
+				cs.log.Error().Err(errGet).Msg("failed to get the result of the moves")
+				continue
+			}
+			switch d := msg.Data().(type) {
+			case *common.Error:
+				cs.log.Error().Str("error", d.Error()).Msg("failed to move the shards")
+			case *databasev1.InternalMoveShardsResponse:
+				for _, result := range d.Moves {
+					k := shardKey{group: result.Group, shardID: result.ShardId, replicaID: result.ReplicaId}
+					if m, ok := pending[k]; ok {
+						m.Error = result.Error
+						delete(pending, k)
+					}
+				}
+			}
+		}
+	}
+	for _, m := range pending {
+		m.Error = fmt.Sprintf("no response from the source node %s", m.Source)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+)
+
+// deleteBatchSize is the number of the rows which are looked up and deleted in a round.
+const deleteBatchSize = 1000
+
+var errDeleteNoProgress = errors.New("the deleted rows are still returned by the query")
+
+// deleteKey identifies a deleted row by its id and timestamp.
+type deleteKey struct {
+	id        uint64
+	timestamp int64
+}
+
+// deleteProgress detects the rounds which look up the rows deleted by the previous round.
+type deleteProgress struct {
+	last map[deleteKey]struct{}
+}
+
+func (dp *deleteProgress) next(keys []deleteKey) error {
+	for _, k := range keys {
+		if _, ok := dp.last[k]; ok {
+			return errDeleteNoProgress
+		}
+	}
+	dp.last = make(map[deleteKey]struct{}, len(keys))
+	for _, k := range keys {
+		dp.last[k] = struct{}{}
+	}
+	return nil
+}
+
+// lookUpDeleted queries a batch of the rows to delete.
+// It returns nil data without an error if no node serves the query,
+// which is reported as io.EOF by either the publishing or the future.
+func lookUpDeleted(ctx context.Context, broadcaster bus.Publisher, topic bus.Topic, req proto.Message) (any, error) {
+	feat, err := broadcaster.Publish(ctx, topic, bus.NewMessage(bus.MessageID(time.Now().UnixNano()), req))
+	if err == nil {
+		var msg bus.Message
+		if msg, err = feat.Get(); err == nil {
+			return msg.Data(), nil
+		}
+	}
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	return nil, err
+}
+
+// broadcastDelete sends the tombstones to all data nodes and waits for them to be applied.
+func broadcastDelete(pipeline queue.Client, topic bus.Topic, req proto.Message) error {
+	ff, err := pipeline.Broadcast(defaultQueryTimeout, topic, bus.NewMessage(bus.MessageID(time.Now().UnixNano()), req))
+	if err != nil {
+		return err
+	}
+	for _, f := range ff {
+		msg, errGet := f.Get()
+		if errGet != nil {
+			return errGet
+		}
+		if e, ok := msg.Data().(*common.Error); ok {
+			return errors.New(e.Error())
+		}
+	}
+	return nil
+}
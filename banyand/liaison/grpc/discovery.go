@@ -47,7 +47,11 @@ type discoveryService struct {
 }
 
 func newDiscoveryService(kind schema.Kind, metadataRepo metadata.Repo, nodeRegistry NodeRegistry, gr *groupRepo) *discoveryService {
-	er := &entityRepo{entitiesMap: make(map[identity]partition.Locator)}
+	er := &entityRepo{
+		entitiesMap: make(map[identity]partition.Locator),
+		traceMap:    make(map[identity]traceTagIndex),
+		traceSchema: make(map[identity]*databasev1.Trace),
+	}
 	return newDiscoveryServiceWithEntityRepo(kind, metadataRepo, nodeRegistry, gr, er)
 }
 
@@ -187,9 +191,17 @@ type entityRepo struct {
 	log         *logger.Logger
 	entitiesMap map[identity]partition.Locator
 	measureMap  map[identity]*databasev1.Measure
+	traceMap    map[identity]traceTagIndex
+	traceSchema map[identity]*databasev1.Trace
 	sync.RWMutex
 }
 
+// traceTagIndex records the positions of the trace ID and timestamp tags in a trace schema.
+type traceTagIndex struct {
+	traceIDIdx   int
+	timestampIdx int
+}
+
 // OnAddOrUpdate implements schema.EventHandler.
 func (e *entityRepo) OnAddOrUpdate(schemaMetadata schema.Metadata) {
 	var l partition.Locator
@@ -206,32 +218,32 @@ func (e *entityRepo) OnAddOrUpdate(schemaMetadata schema.Metadata) {
 		modRevision = stream.GetMetadata().GetModRevision()
 		l = partition.NewEntityLocator(stream.TagFamilies, stream.Entity, modRevision)
 		id = getID(stream.GetMetadata())
+	case schema.KindTrace:
+		trace := schemaMetadata.Spec.(*databasev1.Trace)
+		modRevision = trace.GetMetadata().GetModRevision()
+		id = getID(trace.GetMetadata())
 	default:
 		return
 	}
 	if le := e.log.Debug(); le.Enabled() {
-		var kind string
-		switch schemaMetadata.Kind {
-		case schema.KindMeasure:
-			kind = "measure"
-		case schema.KindStream:
-			kind = "stream"
-		default:
-			kind = "unknown"
-		}
 		le.
 			Str("action", "add_or_update").
 			Stringer("subject", id).
-			Str("kind", kind).
+			Str("kind", kindName(schemaMetadata.Kind)).
 			Msg("entity added or updated")
 	}
 	e.RWMutex.Lock()
 	defer e.RWMutex.Unlock()
 	e.entitiesMap[id] = partition.Locator{TagLocators: l.TagLocators, ModRevision: modRevision}
-	if schemaMetadata.Kind == schema.KindMeasure {
+	switch schemaMetadata.Kind {
+	case schema.KindMeasure:
 		measure := schemaMetadata.Spec.(*databasev1.Measure)
 		e.measureMap[id] = measure
-	} else {
+	case schema.KindTrace:
+		trace := schemaMetadata.Spec.(*databasev1.Trace)
+		e.traceMap[id] = parseTraceTagIndex(trace)
+		e.traceSchema[id] = trace
+	default:
 		delete(e.measureMap, id) // Ensure measure is not stored for streams
 	}
 }
@@ -246,29 +258,25 @@ func (e *entityRepo) OnDelete(schemaMetadata schema.Metadata) {
 	case schema.KindStream:
 		stream := schemaMetadata.Spec.(*databasev1.Stream)
 		id = getID(stream.GetMetadata())
+	case schema.KindTrace:
+		trace := schemaMetadata.Spec.(*databasev1.Trace)
+		id = getID(trace.GetMetadata())
 	default:
 		return
 	}
 	if le := e.log.Debug(); le.Enabled() {
-		var kind string
-		switch schemaMetadata.Kind {
-		case schema.KindMeasure:
-			kind = "measure"
-		case schema.KindStream:
-			kind = "stream"
-		default:
-			kind = "unknown"
-		}
 		le.
 			Str("action", "delete").
 			Stringer("subject", id).
-			Str("kind", kind).
+			Str("kind", kindName(schemaMetadata.Kind)).
 			Msg("entity deletedTime")
 	}
 	e.RWMutex.Lock()
 	defer e.RWMutex.Unlock()
 	delete(e.entitiesMap, id)
 	delete(e.measureMap, id) // Ensure measure is not stored for streams
+	delete(e.traceMap, id)
+	delete(e.traceSchema, id)
 }
 
 func (e *entityRepo) getLocator(id identity) (partition.Locator, bool) {
@@ -281,6 +289,46 @@ func (e *entityRepo) getLocator(id identity) (partition.Locator, bool) {
 	return el, true
 }
 
+func (e *entityRepo) getTraceTagIndex(id identity) (traceTagIndex, bool) {
+	e.RWMutex.RLock()
+	defer e.RWMutex.RUnlock()
+	ti, ok := e.traceMap[id]
+	return ti, ok
+}
+
+func (e *entityRepo) getTrace(id identity) (*databasev1.Trace, bool) {
+	e.RWMutex.RLock()
+	defer e.RWMutex.RUnlock()
+	trace, ok := e.traceSchema[id]
+	return trace, ok
+}
+
+func parseTraceTagIndex(trace *databasev1.Trace) traceTagIndex {
+	ti := traceTagIndex{traceIDIdx: -1, timestampIdx: -1}
+	for i, tag := range trace.GetTags() {
+		switch tag.GetName() {
+		case trace.GetTraceIdTagName():
+			ti.traceIDIdx = i
+		case trace.GetTimestampTagName():
+			ti.timestampIdx = i
+		}
+	}
+	return ti
+}
+
+func kindName(kind schema.Kind) string {
+	switch kind {
+	case schema.KindMeasure:
+		return "measure"
+	case schema.KindStream:
+		return "stream"
+	case schema.KindTrace:
+		return "trace"
+	default:
+		return "unknown"
+	}
+}
+
 var _ schema.EventHandler = (*shardingKeyRepo)(nil)
 
 type shardingKeyRepo struct {
@@ -45,10 +45,12 @@ type measureService struct {
 	measurev1.UnimplementedMeasureServiceServer
 	ingestionAccessLog accesslog.Log
 	pipeline           queue.Client
+	dataPipeline       queue.Client
 	broadcaster        queue.Client
 	*discoveryService
 	l               *logger.Logger
 	metrics         *metrics
+	writeQuota      *writeQuota
 	writeTimeout    time.Duration
 	maxWaitDuration time.Duration
 }
@@ -70,6 +72,7 @@ func (ms *measureService) Write(measure measurev1.MeasureService_WriteServer) er
 	publisher := ms.pipeline.NewBatchPublisher(ms.writeTimeout)
 	ms.metrics.totalStreamStarted.Inc(1, "measure", "write")
 	start := time.Now()
+	client := clientOf(ctx)
 	var succeedSent []succeedSentMessage
 
 	defer ms.handleWriteCleanup(publisher, &succeedSent, measure, start)
@@ -98,6 +101,11 @@ func (ms *measureService) Write(measure measurev1.MeasureService_WriteServer) er
 			continue
 		}
 
+		if !ms.writeQuota.admit(writeRequest.Metadata.Group, client, "measure", writeRequest) {
+			ms.sendReply(writeRequest.GetMetadata(), modelv1.Status_STATUS_RATE_LIMITED, writeRequest.GetMessageId(), measure)
+			continue
+		}
+
 		if err := ms.processAndPublishRequest(ctx, writeRequest, publisher, &succeedSent, measure); err != nil {
 			continue
 		}
@@ -316,6 +324,105 @@ func (ms *measureService) Query(ctx context.Context, req *measurev1.QueryRequest
 	return nil, nil
 }
 
+// Delete looks up the data points matching the criteria in batches, and sends their tombstones to the data nodes.
+func (ms *measureService) Delete(ctx context.Context, req *measurev1.DeleteRequest) (resp *measurev1.DeleteResponse, err error) {
+	for _, g := range req.Groups {
+		ms.metrics.totalStarted.Inc(1, g, "measure", "delete")
+	}
+	start := time.Now()
+	defer func() {
+		for _, g := range req.Groups {
+			ms.metrics.totalFinished.Inc(1, g, "measure", "delete")
+			if err != nil {
+				ms.metrics.totalErr.Inc(1, g, "measure", "delete")
+			}
+			ms.metrics.totalLatency.Inc(time.Since(start).Seconds(), g, "measure", "delete")
+		}
+	}()
+	if err = timestamp.CheckTimeRange(req.GetTimeRange()); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v is invalid :%s", req.GetTimeRange(), err)
+	}
+	resp = &measurev1.DeleteResponse{}
+	for _, g := range req.Groups {
+		var deleted int64
+		deleted, err = ms.deleteFromGroup(ctx, g, req)
+		resp.Deleted += deleted
+		if err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+func (ms *measureService) deleteFromGroup(ctx context.Context, group string, req *measurev1.DeleteRequest) (int64, error) {
+	measure, err := ms.metadataRepo.MeasureRegistry().GetMeasure(ctx, &commonv1.Metadata{Group: group, Name: req.Name})
+	if err != nil {
+		return 0, err
+	}
+	if measure.GetIndexMode() {
+		return 0, status.Errorf(codes.InvalidArgument, "measure %s/%s is in the index mode, its data points can't be deleted", group, req.Name)
+	}
+	queryReq := &measurev1.QueryRequest{
+		Groups:    []string{group},
+		Name:      req.Name,
+		TimeRange: req.TimeRange,
+		Criteria:  req.Criteria,
+		Limit:     deleteBatchSize,
+	}
+	// The query requires a projection, a field or the first tag is enough to locate the data points.
+	if len(measure.GetFields()) > 0 {
+		queryReq.FieldProjection = &measurev1.QueryRequest_FieldProjection{Names: []string{measure.Fields[0].Name}}
+	} else {
+		for _, tf := range measure.GetTagFamilies() {
+			if len(tf.GetTags()) > 0 {
+				queryReq.TagProjection = &modelv1.TagProjection{
+					TagFamilies: []*modelv1.TagProjection_TagFamily{{Name: tf.Name, Tags: []string{tf.Tags[0].Name}}},
+				}
+				break
+			}
+		}
+	}
+	var deleted int64
+	var progress deleteProgress
+	for {
+		resp, errQuery := lookUpDeleted(ctx, ms.broadcaster, data.TopicMeasureQuery, queryReq)
+		if errQuery != nil {
+			return deleted, errQuery
+		}
+		var dataPoints []*measurev1.DataPoint
+		switch d := resp.(type) {
+		case *measurev1.QueryResponse:
+			dataPoints = d.DataPoints
+		case *common.Error:
+			return deleted, errors.WithMessage(errQueryMsg, d.Error())
+		}
+		if len(dataPoints) == 0 {
+			return deleted, nil
+		}
+		keys := make([]deleteKey, 0, len(dataPoints))
+		tombstones := make([]*measurev1.DataPointTombstone, 0, len(dataPoints))
+		for _, dp := range dataPoints {
+			k := deleteKey{id: dp.Sid, timestamp: dp.Timestamp.AsTime().UnixNano()}
+			keys = append(keys, k)
+			tombstones = append(tombstones, &measurev1.DataPointTombstone{Sid: k.id, Timestamp: k.timestamp})
+		}
+		if err = progress.next(keys); err != nil {
+			return deleted, err
+		}
+		if err = broadcastDelete(ms.dataPipeline, data.TopicMeasureDelete, &measurev1.InternalDeleteRequest{
+			Group:      group,
+			Name:       req.Name,
+			Tombstones: tombstones,
+		}); err != nil {
+			return deleted, err
+		}
+		deleted += int64(len(tombstones))
+		if len(dataPoints) < deleteBatchSize {
+			return deleted, nil
+		}
+	}
+}
+
 func (ms *measureService) TopN(ctx context.Context, topNRequest *measurev1.TopNRequest) (resp *measurev1.TopNResponse, err error) {
 	if err = timestamp.CheckTimeRange(topNRequest.GetTimeRange()); err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "%v is invalid :%s", topNRequest.GetTimeRange(), err)
@@ -43,6 +43,8 @@ type metrics struct {
 	totalRegistryFinished meter.Counter
 	totalRegistryErr      meter.Counter
 	totalRegistryLatency  meter.Counter
+
+	totalWriteRateLimited meter.Counter
 }
 
 func newMetrics(factory *observability.Factory) *metrics {
@@ -64,5 +66,6 @@ func newMetrics(factory *observability.Factory) *metrics {
 		totalRegistryFinished:     factory.NewCounter("total_registry_finished", "group", "service", "method"),
 		totalRegistryErr:          factory.NewCounter("total_registry_err", "group", "service", "method"),
 		totalRegistryLatency:      factory.NewCounter("total_registry_latency", "group", "service", "method"),
+		totalWriteRateLimited:     factory.NewCounter("total_write_rate_limited", "group", "service", "scope"),
 	}
 }
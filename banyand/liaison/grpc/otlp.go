@@ -0,0 +1,379 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	collectortracev1 "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	otlpcommonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	otlpresourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+	otlptracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	tracev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/trace/v1"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+// The span fields which can be mapped to the tags besides the attributes.
+const (
+	otlpFieldSpanID        = "span_id"
+	otlpFieldParentSpanID  = "parent_span_id"
+	otlpFieldSpanName      = "span_name"
+	otlpFieldSpanKind      = "span_kind"
+	otlpFieldStatusCode    = "status_code"
+	otlpFieldStatusMessage = "status_message"
+	otlpFieldStartTime     = "start_time"
+	otlpFieldEndTime       = "end_time"
+	otlpFieldDuration      = "duration"
+	otlpFieldScopeName     = "scope_name"
+)
+
+var (
+	errOTLPSpanStartTimeAbsent = errors.New("the span start time is absent")
+	errOTLPTargetIncomplete    = errors.New("both the group and the name of the OTLP trace are required")
+)
+
+// otlpTraceService receives the spans exported by the OpenTelemetry protocol
+// and writes them to a trace through the trace write pipeline.
+type otlpTraceService struct {
+	collectortracev1.UnimplementedTraceServiceServer
+	traceSVC *traceService
+	// tagMapping maps the tag names to the span fields or the attribute keys.
+	// The tags absent in it are filled by the span field or the attribute of the same name.
+	tagMapping map[string]string
+	group      string
+	name       string
+}
+
+func (s *otlpTraceService) enabled() bool {
+	return s.group != "" || s.name != ""
+}
+
+func (s *otlpTraceService) validate() error {
+	if s.enabled() && (s.group == "" || s.name == "") {
+		return errOTLPTargetIncomplete
+	}
+	return nil
+}
+
+func (s *otlpTraceService) Export(ctx context.Context, req *collectortracev1.ExportTraceServiceRequest) (
+	resp *collectortracev1.ExportTraceServiceResponse, err error,
+) {
+	ts := s.traceSVC
+	ts.metrics.totalStarted.Inc(1, s.group, "trace", "otlp_export")
+	start := time.Now()
+	defer func() {
+		ts.metrics.totalFinished.Inc(1, s.group, "trace", "otlp_export")
+		if err != nil {
+			ts.metrics.totalErr.Inc(1, s.group, "trace", "otlp_export")
+		}
+		ts.metrics.totalLatency.Inc(time.Since(start).Seconds(), s.group, "trace", "otlp_export")
+	}()
+
+	metadata := &commonv1.Metadata{Group: s.group, Name: s.name}
+	tagIndex, err := ts.locateTagIndex(&tracev1.WriteRequest{Metadata: metadata})
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	trace, ok := ts.entityRepo.getTrace(getID(metadata))
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "finding the trace schema by: %v", metadata)
+	}
+
+	var rejected int64
+	var rejectedMsg string
+	reject := func(err error) {
+		rejected++
+		if rejectedMsg == "" {
+			rejectedMsg = err.Error()
+		}
+	}
+	publisher := ts.pipeline.NewBatchPublisher(ts.writeTimeout)
+	var nodes []string
+	var errPublish error
+spans:
+	for _, rs := range req.GetResourceSpans() {
+		for _, ss := range rs.GetScopeSpans() {
+			for _, span := range ss.GetSpans() {
+				writeEntity, errConvert := convertOTLPSpan(metadata, trace, s.tagMapping, rs.GetResource(), ss.GetScope(), span)
+				if errConvert != nil {
+					reject(errConvert)
+					continue
+				}
+				if errConvert = ts.validateTimestamp(writeEntity, tagIndex); errConvert != nil {
+					reject(errConvert)
+					continue
+				}
+				shardID, errShard := ts.shardID(writeEntity, tagIndex)
+				if errShard != nil {
+					reject(errShard)
+					continue
+				}
+				if ts.ingestionAccessLog != nil {
+					if errAL := ts.ingestionAccessLog.Write(writeEntity); errAL != nil {
+						ts.l.Error().Err(errAL).Msg("failed to write ingestion access log")
+					}
+				}
+				spanNodes, errPub := ts.publishMessages(ctx, publisher, writeEntity, shardID)
+				if errPub != nil {
+					errPublish = errPub
+					break spans
+				}
+				nodes = append(nodes, spanNodes...)
+			}
+		}
+	}
+	cee, errClose := publisher.Close()
+	if errPublish == nil {
+		errPublish = errClose
+	}
+	for _, node := range nodes {
+		if errPublish != nil {
+			break
+		}
+		if ce, failed := cee[node]; failed {
+			errPublish = errors.New(ce.Error())
+		}
+	}
+	if errPublish != nil {
+		ts.l.Error().Err(errPublish).Msg("failed to write the OTLP spans")
+		// The exporters retry the unavailable requests. The spans written twice share the same version.
+		return nil, status.Errorf(codes.Unavailable, "failed to write the spans: %v", errPublish)
+	}
+
+	resp = &collectortracev1.ExportTraceServiceResponse{}
+	if rejected > 0 {
+		if dl := ts.l.Debug(); dl.Enabled() {
+			dl.Int64("rejected", rejected).Str("reason", rejectedMsg).Msg("rejected the OTLP spans")
+		}
+		resp.PartialSuccess = &collectortracev1.ExportTracePartialSuccess{
+			RejectedSpans: rejected,
+			ErrorMessage:  rejectedMsg,
+		}
+	}
+	return resp, nil
+}
+
+// convertOTLPSpan converts an OTLP span to the write request of the trace.
+// The trace ID tag is the hex encoded trace ID, and the timestamp tag is the start time of the span.
+// The other tags are looked up among the span fields, the span attributes and the resource attributes in order.
+// A tag is null if the value is absent or can't be converted to the tag type.
+func convertOTLPSpan(metadata *commonv1.Metadata, trace *databasev1.Trace, tagMapping map[string]string,
+	resource *otlpresourcev1.Resource, scope *otlpcommonv1.InstrumentationScope, span *otlptracev1.Span,
+) (*tracev1.WriteRequest, error) {
+	if len(span.GetTraceId()) == 0 {
+		return nil, errors.WithStack(errTraceIDEmpty)
+	}
+	if span.GetStartTimeUnixNano() == 0 {
+		return nil, errors.WithStack(errOTLPSpanStartTimeAbsent)
+	}
+	data, err := proto.Marshal(span)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode the span")
+	}
+	version := span.GetEndTimeUnixNano()
+	if version == 0 {
+		version = span.GetStartTimeUnixNano()
+	}
+	tags := make([]*modelv1.TagValue, 0, len(trace.GetTags()))
+	for _, spec := range trace.GetTags() {
+		switch spec.GetName() {
+		case trace.GetTraceIdTagName():
+			tags = append(tags, &modelv1.TagValue{Value: &modelv1.TagValue_Str{
+				Str: &modelv1.Str{Value: hex.EncodeToString(span.GetTraceId())},
+			}})
+			continue
+		case trace.GetTimestampTagName():
+			tags = append(tags, &modelv1.TagValue{Value: &modelv1.TagValue_Timestamp{
+				Timestamp: timestamppb.New(time.Unix(0, int64(span.GetStartTimeUnixNano()))),
+			}})
+			continue
+		}
+		key := spec.GetName()
+		if mapped, ok := tagMapping[key]; ok {
+			key = mapped
+		}
+		tv := pbv1.NullTagValue
+		if v := otlpSpanValue(key, resource, scope, span); v != nil {
+			if converted := otlpTagValue(spec.GetType(), v); converted != nil {
+				tv = converted
+			}
+		}
+		tags = append(tags, tv)
+	}
+	return &tracev1.WriteRequest{
+		Metadata: metadata,
+		Tags:     tags,
+		Span:     data,
+		Version:  version,
+	}, nil
+}
+
+func otlpSpanValue(key string, resource *otlpresourcev1.Resource, scope *otlpcommonv1.InstrumentationScope,
+	span *otlptracev1.Span,
+) *otlpcommonv1.AnyValue {
+	str := func(s string) *otlpcommonv1.AnyValue {
+		if s == "" {
+			return nil
+		}
+		return &otlpcommonv1.AnyValue{Value: &otlpcommonv1.AnyValue_StringValue{StringValue: s}}
+	}
+	integer := func(i int64) *otlpcommonv1.AnyValue {
+		return &otlpcommonv1.AnyValue{Value: &otlpcommonv1.AnyValue_IntValue{IntValue: i}}
+	}
+	switch key {
+	case otlpFieldSpanID:
+		return str(hex.EncodeToString(span.GetSpanId()))
+	case otlpFieldParentSpanID:
+		return str(hex.EncodeToString(span.GetParentSpanId()))
+	case otlpFieldSpanName:
+		return str(span.GetName())
+	case otlpFieldSpanKind:
+		return str(span.GetKind().String())
+	case otlpFieldStatusCode:
+		return str(span.GetStatus().GetCode().String())
+	case otlpFieldStatusMessage:
+		return str(span.GetStatus().GetMessage())
+	case otlpFieldStartTime:
+		return integer(int64(span.GetStartTimeUnixNano()))
+	case otlpFieldEndTime:
+		if span.GetEndTimeUnixNano() == 0 {
+			return nil
+		}
+		return integer(int64(span.GetEndTimeUnixNano()))
+	case otlpFieldDuration:
+		if span.GetEndTimeUnixNano() < span.GetStartTimeUnixNano() {
+			return nil
+		}
+		return integer(int64(span.GetEndTimeUnixNano() - span.GetStartTimeUnixNano()))
+	case otlpFieldScopeName:
+		return str(scope.GetName())
+	}
+	for _, kv := range span.GetAttributes() {
+		if kv.GetKey() == key {
+			return kv.GetValue()
+		}
+	}
+	for _, kv := range resource.GetAttributes() {
+		if kv.GetKey() == key {
+			return kv.GetValue()
+		}
+	}
+	return nil
+}
+
+// otlpTagValue converts an attribute value to the tag value of the type.
+// It returns nil if the value can't be converted.
+func otlpTagValue(tagType databasev1.TagType, v *otlpcommonv1.AnyValue) *modelv1.TagValue {
+	switch tagType {
+	case databasev1.TagType_TAG_TYPE_STRING:
+		if s, ok := otlpString(v); ok {
+			return &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: s}}}
+		}
+	case databasev1.TagType_TAG_TYPE_INT:
+		if i, ok := otlpInt(v); ok {
+			return &modelv1.TagValue{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{Value: i}}}
+		}
+	case databasev1.TagType_TAG_TYPE_FLOAT:
+		switch x := v.GetValue().(type) {
+		case *otlpcommonv1.AnyValue_DoubleValue:
+			return &modelv1.TagValue{Value: &modelv1.TagValue_Float{Float: &modelv1.Float{Value: x.DoubleValue}}}
+		case *otlpcommonv1.AnyValue_IntValue:
+			return &modelv1.TagValue{Value: &modelv1.TagValue_Float{Float: &modelv1.Float{Value: float64(x.IntValue)}}}
+		}
+	case databasev1.TagType_TAG_TYPE_STRING_ARRAY:
+		values := otlpArray(v)
+		arr := make([]string, 0, len(values))
+		for _, e := range values {
+			s, ok := otlpString(e)
+			if !ok {
+				return nil
+			}
+			arr = append(arr, s)
+		}
+		return &modelv1.TagValue{Value: &modelv1.TagValue_StrArray{StrArray: &modelv1.StrArray{Value: arr}}}
+	case databasev1.TagType_TAG_TYPE_INT_ARRAY:
+		values := otlpArray(v)
+		arr := make([]int64, 0, len(values))
+		for _, e := range values {
+			i, ok := otlpInt(e)
+			if !ok {
+				return nil
+			}
+			arr = append(arr, i)
+		}
+		return &modelv1.TagValue{Value: &modelv1.TagValue_IntArray{IntArray: &modelv1.IntArray{Value: arr}}}
+	case databasev1.TagType_TAG_TYPE_DATA_BINARY:
+		switch x := v.GetValue().(type) {
+		case *otlpcommonv1.AnyValue_BytesValue:
+			return &modelv1.TagValue{Value: &modelv1.TagValue_BinaryData{BinaryData: x.BytesValue}}
+		case *otlpcommonv1.AnyValue_StringValue:
+			return &modelv1.TagValue{Value: &modelv1.TagValue_BinaryData{BinaryData: []byte(x.StringValue)}}
+		}
+	case databasev1.TagType_TAG_TYPE_TIMESTAMP:
+		// The integer attributes are the nanoseconds since the epoch, like the span times.
+		if x, ok := v.GetValue().(*otlpcommonv1.AnyValue_IntValue); ok {
+			return &modelv1.TagValue{Value: &modelv1.TagValue_Timestamp{Timestamp: timestamppb.New(time.Unix(0, x.IntValue))}}
+		}
+	}
+	return nil
+}
+
+func otlpString(v *otlpcommonv1.AnyValue) (string, bool) {
+	switch x := v.GetValue().(type) {
+	case *otlpcommonv1.AnyValue_StringValue:
+		return x.StringValue, true
+	case *otlpcommonv1.AnyValue_IntValue:
+		return strconv.FormatInt(x.IntValue, 10), true
+	case *otlpcommonv1.AnyValue_DoubleValue:
+		return strconv.FormatFloat(x.DoubleValue, 'g', -1, 64), true
+	case *otlpcommonv1.AnyValue_BoolValue:
+		return strconv.FormatBool(x.BoolValue), true
+	}
+	return "", false
+}
+
+func otlpInt(v *otlpcommonv1.AnyValue) (int64, bool) {
+	switch x := v.GetValue().(type) {
+	case *otlpcommonv1.AnyValue_IntValue:
+		return x.IntValue, true
+	case *otlpcommonv1.AnyValue_StringValue:
+		i, err := strconv.ParseInt(x.StringValue, 10, 64)
+		return i, err == nil
+	}
+	return 0, false
+}
+
+// otlpArray returns the elements of an array value, or a scalar value as a single element.
+func otlpArray(v *otlpcommonv1.AnyValue) []*otlpcommonv1.AnyValue {
+	if arr, ok := v.GetValue().(*otlpcommonv1.AnyValue_ArrayValue); ok {
+		return arr.ArrayValue.GetValues()
+	}
+	return []*otlpcommonv1.AnyValue{v}
+}
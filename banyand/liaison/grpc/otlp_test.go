@@ -0,0 +1,118 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	otlpcommonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	otlpresourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+	otlptracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+)
+
+func otlpStr(key, value string) *otlpcommonv1.KeyValue {
+	return &otlpcommonv1.KeyValue{Key: key, Value: &otlpcommonv1.AnyValue{Value: &otlpcommonv1.AnyValue_StringValue{StringValue: value}}}
+}
+
+func otlpInt64(key string, value int64) *otlpcommonv1.KeyValue {
+	return &otlpcommonv1.KeyValue{Key: key, Value: &otlpcommonv1.AnyValue{Value: &otlpcommonv1.AnyValue_IntValue{IntValue: value}}}
+}
+
+func TestConvertOTLPSpan(t *testing.T) {
+	trace := &databasev1.Trace{
+		Metadata: &commonv1.Metadata{Group: "otel", Name: "spans"},
+		Tags: []*databasev1.TraceTagSpec{
+			{Name: "trace_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+			{Name: "service", Type: databasev1.TagType_TAG_TYPE_STRING},
+			{Name: "span_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+			{Name: "timestamp", Type: databasev1.TagType_TAG_TYPE_TIMESTAMP},
+			{Name: "duration", Type: databasev1.TagType_TAG_TYPE_INT},
+			{Name: "http_status", Type: databasev1.TagType_TAG_TYPE_INT},
+			{Name: "db_system", Type: databasev1.TagType_TAG_TYPE_STRING},
+			{Name: "hosts", Type: databasev1.TagType_TAG_TYPE_STRING_ARRAY},
+			{Name: "sampling_ratio", Type: databasev1.TagType_TAG_TYPE_FLOAT},
+		},
+		TraceIdTagName:   "trace_id",
+		TimestampTagName: "timestamp",
+	}
+	mapping := map[string]string{
+		"service":     "service.name",
+		"http_status": "http.response.status_code",
+		"hosts":       "host.name",
+	}
+	startTime := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	span := &otlptracev1.Span{
+		TraceId:           []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10},
+		SpanId:            []byte{0xa1, 0xa2, 0xa3, 0xa4, 0xa5, 0xa6, 0xa7, 0xa8},
+		Name:              "GET /users",
+		StartTimeUnixNano: uint64(startTime.UnixNano()),
+		EndTimeUnixNano:   uint64(startTime.Add(15 * time.Millisecond).UnixNano()),
+		Attributes: []*otlpcommonv1.KeyValue{
+			otlpStr("http.response.status_code", "200"),
+			otlpStr("sampling_ratio", "high"),
+		},
+	}
+	resource := &otlpresourcev1.Resource{
+		Attributes: []*otlpcommonv1.KeyValue{
+			otlpStr("service.name", "users"),
+			otlpStr("host.name", "node-1"),
+			otlpInt64("http.response.status_code", 500),
+		},
+	}
+	metadata := &commonv1.Metadata{Group: "otel", Name: "spans"}
+
+	req, err := convertOTLPSpan(metadata, trace, mapping, resource, nil, span)
+	require.NoError(t, err)
+	assert.Same(t, metadata, req.GetMetadata())
+	assert.Equal(t, span.GetEndTimeUnixNano(), req.GetVersion())
+	decoded := &otlptracev1.Span{}
+	require.NoError(t, proto.Unmarshal(req.GetSpan(), decoded))
+	assert.True(t, proto.Equal(span, decoded))
+
+	tags := req.GetTags()
+	require.Len(t, tags, len(trace.GetTags()))
+	assert.Equal(t, "0102030405060708090a0b0c0d0e0f10", tags[0].GetStr().GetValue())
+	assert.Equal(t, "users", tags[1].GetStr().GetValue())
+	assert.Equal(t, "a1a2a3a4a5a6a7a8", tags[2].GetStr().GetValue())
+	assert.True(t, startTime.Equal(tags[3].GetTimestamp().AsTime()))
+	assert.Equal(t, int64(15*time.Millisecond), tags[4].GetInt().GetValue())
+	assert.Equal(t, int64(200), tags[5].GetInt().GetValue(), "the span attributes precede the resource attributes")
+	assert.Same(t, pbv1.NullTagValue, tags[6], "the absent attribute is null")
+	assert.Equal(t, []string{"node-1"}, tags[7].GetStrArray().GetValue())
+	assert.Same(t, pbv1.NullTagValue, tags[8], "the attribute which can't be converted is null")
+
+	_, err = convertOTLPSpan(metadata, trace, mapping, resource, nil, &otlptracev1.Span{StartTimeUnixNano: 1})
+	assert.ErrorIs(t, err, errTraceIDEmpty)
+	_, err = convertOTLPSpan(metadata, trace, mapping, resource, nil, &otlptracev1.Span{TraceId: span.GetTraceId()})
+	assert.ErrorIs(t, err, errOTLPSpanStartTimeAbsent)
+}
+
+func TestOTLPTraceServiceValidate(t *testing.T) {
+	assert.False(t, (&otlpTraceService{}).enabled())
+	assert.NoError(t, (&otlpTraceService{}).validate())
+	assert.NoError(t, (&otlpTraceService{group: "otel", name: "spans"}).validate())
+	assert.ErrorIs(t, (&otlpTraceService{group: "otel"}).validate(), errOTLPTargetIncomplete)
+}
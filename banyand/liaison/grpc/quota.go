@@ -0,0 +1,84 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"net"
+
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/apache/skywalking-banyandb/banyand/liaison/pkg/auth"
+	"github.com/apache/skywalking-banyandb/banyand/liaison/pkg/quota"
+)
+
+// writeQuota admits the writes of the measures and the streams by the quotas of their groups and clients.
+type writeQuota struct {
+	limiter     *quota.Limiter
+	metrics     *metrics
+	configFile  string
+	limitsBytes bool
+}
+
+func (w *writeQuota) init(m *metrics) error {
+	w.metrics = m
+	if w.configFile == "" {
+		return nil
+	}
+	cfg, err := quota.LoadConfig(w.configFile)
+	if err != nil {
+		return err
+	}
+	w.limiter = quota.NewLimiter(cfg)
+	w.limitsBytes = w.limiter.LimitsBytes()
+	return nil
+}
+
+// admit returns false if the write exceeds the quota of its group or client.
+func (w *writeQuota) admit(group, client, service string, req proto.Message) bool {
+	if w.limiter == nil {
+		return true
+	}
+	size := 0
+	if w.limitsBytes {
+		size = proto.Size(req)
+	}
+	scope := w.limiter.Admit(group, client, size)
+	if scope == quota.ScopeNone {
+		return true
+	}
+	w.metrics.totalWriteRateLimited.Inc(1, group, service, string(scope))
+	return false
+}
+
+// clientOf returns the authenticated name of the client, or the host of its address if the authentication is disabled.
+func clientOf(ctx context.Context) string {
+	if identity, ok := ctx.Value(identityKey{}).(*auth.Identity); ok && identity != nil {
+		return identity.Name
+	}
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
@@ -0,0 +1,56 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestClientOf(t *testing.T) {
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 17912}})
+	assert.Equal(t, "10.0.0.1", clientOf(ctx), "the host identifies the client if the authentication is disabled")
+	assert.Empty(t, clientOf(context.Background()))
+
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("username", "admin", "password", "admin"))
+	var client string
+	err := authStreamInterceptor(newTestAuthConfig())(nil, &testServerStream{ctx: ctx},
+		&grpc.StreamServerInfo{FullMethod: "/banyandb.measure.v1.MeasureService/Write"},
+		func(_ interface{}, stream grpc.ServerStream) error {
+			client = clientOf(stream.Context())
+			return nil
+		})
+	require.NoError(t, err)
+	assert.Equal(t, "admin", client, "the authenticated name identifies the client")
+}
@@ -608,7 +608,15 @@ func (rs *groupRegistryServer) Update(ctx context.Context, req *databasev1.Group
 		rs.metrics.totalRegistryFinished.Inc(1, g, "group", "update")
 		rs.metrics.totalRegistryLatency.Inc(time.Since(start).Seconds(), g, "group", "update")
 	}()
-	if err := rs.schemaRegistry.GroupRegistry().UpdateGroup(ctx, req.GetGroup()); err != nil {
+	group := req.GetGroup()
+	if opts := group.GetResourceOpts(); opts != nil && len(opts.ShardAssignments) == 0 {
+		// The shard assignments are maintained by the rebalancing, so that an update without them keeps them.
+		existing, getErr := rs.schemaRegistry.GroupRegistry().GetGroup(ctx, group.GetMetadata().GetName())
+		if getErr == nil && existing.GetResourceOpts() != nil {
+			opts.ShardAssignments = existing.GetResourceOpts().GetShardAssignments()
+		}
+	}
+	if err := rs.schemaRegistry.GroupRegistry().UpdateGroup(ctx, group); err != nil {
 		rs.metrics.totalRegistryErr.Inc(1, g, "group", "update")
 		return nil, err
 	}
@@ -28,6 +28,7 @@ import (
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/recovery"
 	grpc_validator "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/validator"
 	"github.com/pkg/errors"
+	collectortracev1 "go.opentelemetry.io/proto/otlp/collector/trace/v1"
 	grpclib "google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
@@ -35,17 +36,20 @@ import (
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/status"
 
+	bydbqlv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/bydbql/v1"
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
 	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
 	propertyv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/property/v1"
 	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	tracev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/trace/v1"
 	"github.com/apache/skywalking-banyandb/banyand/liaison/pkg/auth"
 	"github.com/apache/skywalking-banyandb/banyand/metadata"
 	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
 	"github.com/apache/skywalking-banyandb/banyand/observability"
 	"github.com/apache/skywalking-banyandb/banyand/queue"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/node"
 	"github.com/apache/skywalking-banyandb/pkg/partition"
 	"github.com/apache/skywalking-banyandb/pkg/run"
 	pkgtls "github.com/apache/skywalking-banyandb/pkg/tls"
@@ -75,6 +79,9 @@ type NodeRegistries struct {
 	StreamLiaisonNodeRegistry  NodeRegistry
 	MeasureLiaisonNodeRegistry NodeRegistry
 	PropertyNodeRegistry       NodeRegistry
+	TraceLiaisonNodeRegistry   NodeRegistry
+	// DataNodeSelectors are the selectors routing the shards of the catalogs to the data nodes, which are rebalanced by the cluster service.
+	DataNodeSelectors map[commonv1.Catalog]node.Selector
 }
 
 type server struct {
@@ -89,10 +96,16 @@ type server struct {
 	streamSVC *streamService
 	*streamRegistryServer
 	measureSVC *measureService
+	traceSVC   *traceService
+	otlpSVC    *otlpTraceService
+	bydbQLSVC  *bydbQLService
+	clusterSVC *clusterService
 	log        *logger.Logger
 	*propertyRegistryServer
-	ser         *grpclib.Server
-	tlsReloader *pkgtls.Reloader
+	ser          *grpclib.Server
+	tlsReloader  *pkgtls.Reloader
+	authReloader *auth.Reloader
+	writeQuota   *writeQuota
 	*propertyServer
 	*indexRuleBindingRegistryServer
 	*traceRegistryServer
@@ -118,14 +131,24 @@ func NewServer(_ context.Context, tir1Client, tir2Client, broadcaster queue.Clie
 ) Server {
 	gr := &groupRepo{resourceOpts: make(map[string]*commonv1.ResourceOpts)}
 	er := &entityRepo{entitiesMap: make(map[identity]partition.Locator), measureMap: make(map[identity]*databasev1.Measure)}
+	wq := &writeQuota{}
 	streamSVC := &streamService{
 		discoveryService: newDiscoveryService(schema.KindStream, schemaRegistry, nr.StreamLiaisonNodeRegistry, gr),
 		pipeline:         tir1Client,
+		dataPipeline:     tir2Client,
 		broadcaster:      broadcaster,
+		writeQuota:       wq,
 	}
 	measureSVC := &measureService{
 		discoveryService: newDiscoveryServiceWithEntityRepo(schema.KindMeasure, schemaRegistry, nr.MeasureLiaisonNodeRegistry, gr, er),
 		pipeline:         tir1Client,
+		dataPipeline:     tir2Client,
+		broadcaster:      broadcaster,
+		writeQuota:       wq,
+	}
+	traceSVC := &traceService{
+		discoveryService: newDiscoveryService(schema.KindTrace, schemaRegistry, nr.TraceLiaisonNodeRegistry, gr),
+		pipeline:         tir1Client,
 		broadcaster:      broadcaster,
 	}
 
@@ -133,6 +156,8 @@ func NewServer(_ context.Context, tir1Client, tir2Client, broadcaster queue.Clie
 		omr:        omr,
 		streamSVC:  streamSVC,
 		measureSVC: measureSVC,
+		traceSVC:   traceSVC,
+		otlpSVC:    &otlpTraceService{traceSVC: traceSVC},
 		groupRepo:  gr,
 		streamRegistryServer: &streamRegistryServer{
 			schemaRegistry: schemaRegistry,
@@ -166,8 +191,27 @@ func NewServer(_ context.Context, tir1Client, tir2Client, broadcaster queue.Clie
 		},
 		schemaRepo: schemaRegistry,
 		cfg:        auth.InitCfg(),
+		writeQuota: wq,
+	}
+	s.bydbQLSVC = &bydbQLService{
+		schemaRepo:     schemaRegistry,
+		streamSVC:      streamSVC,
+		measureSVC:     measureSVC,
+		traceSVC:       traceSVC,
+		propertyServer: s.propertyServer,
+		cfg:            s.cfg,
+	}
+	s.clusterSVC = &clusterService{
+		schemaRegistry: schemaRegistry,
+		pipeline:       tir2Client,
+		routers:        make(map[commonv1.Catalog]node.ShardRouter),
 	}
-	s.accessLogRecorders = []accessLogRecorder{streamSVC, measureSVC}
+	for catalog, sel := range nr.DataNodeSelectors {
+		if router, ok := sel.(node.ShardRouter); ok {
+			s.clusterSVC.routers[catalog] = router
+		}
+	}
+	s.accessLogRecorders = []accessLogRecorder{streamSVC, measureSVC, traceSVC}
 
 	return s
 }
@@ -176,10 +220,16 @@ func (s *server) PreRun(_ context.Context) error {
 	s.log = logger.GetLogger("liaison-grpc")
 	s.streamSVC.setLogger(s.log.Named("stream-t1"))
 	s.measureSVC.setLogger(s.log)
+	s.traceSVC.setLogger(s.log.Named("trace"))
 	s.propertyServer.SetLogger(s.log)
+	s.clusterSVC.setLogger(s.log.Named("cluster"))
+	if err := s.clusterSVC.startRepairScheduler(); err != nil {
+		return err
+	}
 	components := []*discoveryService{
 		s.streamSVC.discoveryService,
 		s.measureSVC.discoveryService,
+		s.traceSVC.discoveryService,
 		s.propertyServer.discoveryService,
 	}
 	s.schemaRepo.RegisterHandler("liaison", schema.KindGroup, s.groupRepo)
@@ -193,6 +243,13 @@ func (s *server) PreRun(_ context.Context) error {
 		if err := auth.LoadConfig(s.cfg, s.authConfigFile); err != nil {
 			return err
 		}
+		var err error
+		if s.authReloader, err = auth.NewReloader(s.cfg, s.authConfigFile, s.log); err != nil {
+			return err
+		}
+		if err = s.authReloader.Start(); err != nil {
+			return err
+		}
 	}
 
 	if s.enableIngestionAccessLog {
@@ -206,6 +263,7 @@ func (s *server) PreRun(_ context.Context) error {
 	s.metrics = metrics
 	s.streamSVC.metrics = metrics
 	s.measureSVC.metrics = metrics
+	s.traceSVC.metrics = metrics
 	s.propertyServer.metrics = metrics
 	s.streamRegistryServer.metrics = metrics
 	s.indexRuleBindingRegistryServer.metrics = metrics
@@ -215,6 +273,9 @@ func (s *server) PreRun(_ context.Context) error {
 	s.topNAggregationRegistryServer.metrics = metrics
 	s.propertyRegistryServer.metrics = metrics
 	s.traceRegistryServer.metrics = metrics
+	if err := s.writeQuota.init(metrics); err != nil {
+		return err
+	}
 
 	if s.tls {
 		var err error
@@ -253,17 +314,29 @@ func (s *server) FlagSet() *run.FlagSet {
 	fs.StringVar(&s.keyFile, "key-file", "", "the TLS key file")
 	fs.StringVar(&s.authConfigFile, "auth-config-file", "", "Path to the authentication config file (YAML format)")
 	fs.BoolVar(&s.cfg.HealthAuthEnabled, "enable-health-auth", false, "enable authentication for health check")
+	fs.StringVar(&s.writeQuota.configFile, "write-quota-config-file", "",
+		"Path to the config file of the write quotas of the groups and the clients (YAML format), the writes aren't limited if it's empty")
 	fs.StringVar(&s.host, "grpc-host", "", "the host of banyand listens")
 	fs.Uint32Var(&s.port, "grpc-port", 17912, "the port of banyand listens")
 	fs.BoolVar(&s.enableIngestionAccessLog, "enable-ingestion-access-log", false, "enable ingestion access log")
 	fs.StringVar(&s.accessLogRootPath, "access-log-root-path", "", "access log root path")
 	fs.DurationVar(&s.streamSVC.writeTimeout, "stream-write-timeout", 15*time.Second, "timeout for writing stream among liaison nodes")
 	fs.DurationVar(&s.measureSVC.writeTimeout, "measure-write-timeout", 15*time.Second, "timeout for writing measure among liaison nodes")
+	fs.DurationVar(&s.traceSVC.writeTimeout, "trace-write-timeout", 15*time.Second, "timeout for writing trace among liaison nodes")
 	fs.DurationVar(&s.measureSVC.maxWaitDuration, "measure-metadata-cache-wait-duration", 0,
 		"the maximum duration to wait for metadata cache to load (for testing purposes)")
 	fs.DurationVar(&s.streamSVC.maxWaitDuration, "stream-metadata-cache-wait-duration", 0,
 		"the maximum duration to wait for metadata cache to load (for testing purposes)")
+	fs.DurationVar(&s.traceSVC.maxWaitDuration, "trace-metadata-cache-wait-duration", 0,
+		"the maximum duration to wait for metadata cache to load (for testing purposes)")
+	fs.StringVar(&s.otlpSVC.group, "otlp-trace-group", "", "the group of the trace which stores the spans received by OTLP")
+	fs.StringVar(&s.otlpSVC.name, "otlp-trace-name", "", "the name of the trace which stores the spans received by OTLP, "+
+		"the OTLP receiver is enabled if it's set with the group")
+	fs.StringToStringVar(&s.otlpSVC.tagMapping, "otlp-trace-tag-mapping", nil,
+		"the span fields or attributes which fill the trace tags, in the format of tag=attribute")
 	fs.IntVar(&s.propertyServer.repairQueueCount, "property-repair-queue-count", 128, "the number of queues for property repair")
+	fs.StringVar(&s.clusterSVC.repairCron, "replica-repair-trigger-cron", "",
+		"the cron expression for triggering the repairs of the stream and measure replicas, no repair is triggered if it's empty")
 	return fs
 }
 
@@ -275,6 +348,9 @@ func (s *server) Validate() error {
 	if s.enableIngestionAccessLog && s.accessLogRootPath == "" {
 		return errAccessLogRootPath
 	}
+	if err := s.otlpSVC.validate(); err != nil {
+		return err
+	}
 	if !s.tls {
 		return nil
 	}
@@ -328,6 +404,11 @@ func (s *server) Serve() run.StopNotify {
 	commonv1.RegisterServiceServer(s.ser, &apiVersionService{})
 	streamv1.RegisterStreamServiceServer(s.ser, s.streamSVC)
 	measurev1.RegisterMeasureServiceServer(s.ser, s.measureSVC)
+	tracev1.RegisterTraceServiceServer(s.ser, s.traceSVC)
+	bydbqlv1.RegisterBydbQLServiceServer(s.ser, s.bydbQLSVC)
+	if s.otlpSVC.enabled() {
+		collectortracev1.RegisterTraceServiceServer(s.ser, s.otlpSVC)
+	}
 	databasev1.RegisterGroupRegistryServiceServer(s.ser, s.groupRegistryServer)
 	databasev1.RegisterIndexRuleBindingRegistryServiceServer(s.ser, s.indexRuleBindingRegistryServer)
 	databasev1.RegisterIndexRuleRegistryServiceServer(s.ser, s.indexRuleRegistryServer)
@@ -336,6 +417,7 @@ func (s *server) Serve() run.StopNotify {
 	propertyv1.RegisterPropertyServiceServer(s.ser, s.propertyServer)
 	databasev1.RegisterTopNAggregationRegistryServiceServer(s.ser, s.topNAggregationRegistryServer)
 	databasev1.RegisterSnapshotServiceServer(s.ser, s)
+	databasev1.RegisterClusterServiceServer(s.ser, s.clusterSVC)
 	databasev1.RegisterPropertyRegistryServiceServer(s.ser, s.propertyRegistryServer)
 	databasev1.RegisterTraceRegistryServiceServer(s.ser, s.traceRegistryServer)
 	grpc_health_v1.RegisterHealthServer(s.ser, health.NewServer())
@@ -365,6 +447,10 @@ func (s *server) GracefulStop() {
 	if s.tls && s.tlsReloader != nil {
 		s.tlsReloader.Stop()
 	}
+	if s.authReloader != nil {
+		s.authReloader.Stop()
+	}
+	s.clusterSVC.stopRepairScheduler()
 	stopped := make(chan struct{})
 	go func() {
 		s.ser.GracefulStop()
@@ -19,6 +19,7 @@ package grpc
 
 import (
 	"context"
+	"encoding/hex"
 	"io"
 	"time"
 
@@ -45,10 +46,12 @@ type streamService struct {
 	streamv1.UnimplementedStreamServiceServer
 	ingestionAccessLog accesslog.Log
 	pipeline           queue.Client
+	dataPipeline       queue.Client
 	broadcaster        queue.Client
 	*discoveryService
 	l               *logger.Logger
 	metrics         *metrics
+	writeQuota      *writeQuota
 	writeTimeout    time.Duration
 	maxWaitDuration time.Duration
 }
@@ -173,6 +176,7 @@ func (s *streamService) Write(stream streamv1.StreamService_WriteServer) error {
 	}()
 
 	ctx := stream.Context()
+	client := clientOf(ctx)
 	for {
 		select {
 		case <-ctx.Done():
@@ -211,6 +215,11 @@ func (s *streamService) Write(stream streamv1.StreamService_WriteServer) error {
 			continue
 		}
 
+		if !s.writeQuota.admit(writeEntity.Metadata.Group, client, "stream", writeEntity) {
+			reply(writeEntity.GetMetadata(), modelv1.Status_STATUS_RATE_LIMITED, writeEntity.GetMessageId(), stream, s.l)
+			continue
+		}
+
 		tagValues, shardID, err := s.navigateWithRetry(writeEntity)
 		if err != nil {
 			s.l.Error().Err(err).RawJSON("written", logger.Proto(writeEntity)).Msg("navigation failed")
@@ -299,6 +308,102 @@ func (s *streamService) Query(ctx context.Context, req *streamv1.QueryRequest) (
 	return nil, nil
 }
 
+// Delete looks up the elements matching the criteria in batches, and sends their tombstones to the data nodes.
+func (s *streamService) Delete(ctx context.Context, req *streamv1.DeleteRequest) (resp *streamv1.DeleteResponse, err error) {
+	for _, g := range req.Groups {
+		s.metrics.totalStarted.Inc(1, g, "stream", "delete")
+	}
+	start := time.Now()
+	defer func() {
+		for _, g := range req.Groups {
+			s.metrics.totalFinished.Inc(1, g, "stream", "delete")
+			if err != nil {
+				s.metrics.totalErr.Inc(1, g, "stream", "delete")
+			}
+			s.metrics.totalLatency.Inc(time.Since(start).Seconds(), g, "stream", "delete")
+		}
+	}()
+	if err = timestamp.CheckTimeRange(req.GetTimeRange()); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v is invalid :%s", req.GetTimeRange(), err)
+	}
+	resp = &streamv1.DeleteResponse{}
+	for _, g := range req.Groups {
+		var deleted int64
+		deleted, err = s.deleteFromGroup(ctx, g, req)
+		resp.Deleted += deleted
+		if err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+func (s *streamService) deleteFromGroup(ctx context.Context, group string, req *streamv1.DeleteRequest) (int64, error) {
+	stream, err := s.metadataRepo.StreamRegistry().GetStream(ctx, &commonv1.Metadata{Group: group, Name: req.Name})
+	if err != nil {
+		return 0, err
+	}
+	// The query requires a projection, the first tag is enough to locate the elements.
+	projection := &modelv1.TagProjection{}
+	for _, tf := range stream.GetTagFamilies() {
+		if len(tf.GetTags()) > 0 {
+			projection.TagFamilies = []*modelv1.TagProjection_TagFamily{{Name: tf.Name, Tags: []string{tf.Tags[0].Name}}}
+			break
+		}
+	}
+	var deleted int64
+	var progress deleteProgress
+	for {
+		queryReq := &streamv1.QueryRequest{
+			Groups:     []string{group},
+			Name:       req.Name,
+			TimeRange:  req.TimeRange,
+			Criteria:   req.Criteria,
+			Projection: projection,
+			Limit:      deleteBatchSize,
+		}
+		resp, errQuery := lookUpDeleted(ctx, s.broadcaster, data.TopicStreamQuery, queryReq)
+		if errQuery != nil {
+			return deleted, errQuery
+		}
+		var elements []*streamv1.Element
+		switch d := resp.(type) {
+		case *streamv1.QueryResponse:
+			elements = d.Elements
+		case *common.Error:
+			return deleted, errors.WithMessage(errQueryMsg, d.Error())
+		}
+		if len(elements) == 0 {
+			return deleted, nil
+		}
+		keys := make([]deleteKey, 0, len(elements))
+		tombstones := make([]*streamv1.ElementTombstone, 0, len(elements))
+		for _, e := range elements {
+			id, errID := hex.DecodeString(e.ElementId)
+			if errID != nil || len(id) != 8 {
+				return deleted, errors.Errorf("invalid element id %q", e.ElementId)
+			}
+			k := deleteKey{id: convert.BytesToUint64(id), timestamp: e.Timestamp.AsTime().UnixNano()}
+			keys = append(keys, k)
+			tombstones = append(tombstones, &streamv1.ElementTombstone{ElementId: k.id, Timestamp: k.timestamp})
+		}
+		if err = progress.next(keys); err != nil {
+			return deleted, err
+		}
+		if err = broadcastDelete(s.dataPipeline, data.TopicStreamDelete, &streamv1.InternalDeleteRequest{
+			Group:      group,
+			Name:       req.Name,
+			Tombstones: tombstones,
+		}); err != nil {
+			return deleted, err
+		}
+		deleted += int64(len(tombstones))
+		if len(elements) < deleteBatchSize {
+			return deleted, nil
+		}
+	}
+}
+
 func (s *streamService) Close() error {
 	if s.ingestionAccessLog != nil {
 		return s.ingestionAccessLog.Close()
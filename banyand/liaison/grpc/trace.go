@@ -0,0 +1,413 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/api/data"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	tracev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/trace/v1"
+	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/pkg/accesslog"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/partition"
+	"github.com/apache/skywalking-banyandb/pkg/query"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+var (
+	errTraceSchemaNotFound = errors.New("trace schema not found")
+	errTraceSchemaExpired  = errors.New("expired trace schema")
+	errTraceIDEmpty        = errors.New("trace id is empty")
+)
+
+type traceService struct {
+	tracev1.UnimplementedTraceServiceServer
+	ingestionAccessLog accesslog.Log
+	pipeline           queue.Client
+	broadcaster        queue.Client
+	*discoveryService
+	l               *logger.Logger
+	metrics         *metrics
+	writeTimeout    time.Duration
+	maxWaitDuration time.Duration
+}
+
+func (s *traceService) setLogger(log *logger.Logger) {
+	s.l = log
+}
+
+func (s *traceService) activeIngestionAccessLog(root string) (err error) {
+	if s.ingestionAccessLog, err = accesslog.
+		NewFileLog(root, "trace-ingest-%s", 10*time.Minute, s.log); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *traceService) validateTimestamp(writeEntity *tracev1.WriteRequest, tagIndex traceTagIndex) error {
+	if tagIndex.timestampIdx < 0 || tagIndex.timestampIdx >= len(writeEntity.GetTags()) {
+		return errors.New("the timestamp tag is absent")
+	}
+	if err := timestamp.CheckPb(writeEntity.GetTags()[tagIndex.timestampIdx].GetTimestamp()); err != nil {
+		s.l.Error().Stringer("written", writeEntity).Err(err).Msg("the span time is invalid")
+		return err
+	}
+	return nil
+}
+
+func (s *traceService) validateMetadata(writeEntity *tracev1.WriteRequest) error {
+	if writeEntity.Metadata.ModRevision > 0 {
+		traceCache, existed := s.entityRepo.getLocator(getID(writeEntity.GetMetadata()))
+		if !existed {
+			return errTraceSchemaNotFound
+		}
+		if writeEntity.Metadata.ModRevision != traceCache.ModRevision {
+			return errTraceSchemaExpired
+		}
+	}
+	return nil
+}
+
+func (s *traceService) locateTagIndex(writeEntity *tracev1.WriteRequest) (traceTagIndex, error) {
+	id := getID(writeEntity.GetMetadata())
+	if s.maxWaitDuration > 0 {
+		retryInterval := 10 * time.Millisecond
+		startTime := time.Now()
+		for {
+			if tagIndex, ok := s.entityRepo.getTraceTagIndex(id); ok {
+				return tagIndex, nil
+			}
+			if time.Since(startTime) > s.maxWaitDuration {
+				break
+			}
+			time.Sleep(retryInterval)
+			retryInterval = time.Duration(float64(retryInterval) * 1.5)
+			if retryInterval > time.Second {
+				retryInterval = time.Second
+			}
+		}
+	}
+	tagIndex, ok := s.entityRepo.getTraceTagIndex(id)
+	if !ok {
+		return traceTagIndex{}, errors.Wrapf(errNotExist, "finding the trace schema by: %v", writeEntity.GetMetadata())
+	}
+	return tagIndex, nil
+}
+
+// shardID routes spans by their trace ID, so that all spans of a trace are stored in the same shard.
+func (s *traceService) shardID(writeEntity *tracev1.WriteRequest, tagIndex traceTagIndex) (common.ShardID, error) {
+	metadata := writeEntity.GetMetadata()
+	shardNum, existed := s.groupRepo.shardNum(metadata.GetGroup())
+	if !existed {
+		return common.ShardID(0), errors.Wrapf(errNotExist, "finding the shard num by: %v", metadata)
+	}
+	if tagIndex.traceIDIdx < 0 || tagIndex.traceIDIdx >= len(writeEntity.GetTags()) {
+		return common.ShardID(0), errors.Wrapf(errTraceIDEmpty, "the trace id tag is absent in: %v", metadata)
+	}
+	traceID := writeEntity.GetTags()[tagIndex.traceIDIdx].GetStr().GetValue()
+	if traceID == "" {
+		return common.ShardID(0), errors.WithStack(errTraceIDEmpty)
+	}
+	shardID, err := partition.ShardID(convert.StringToBytes(traceID), shardNum)
+	if err != nil {
+		return common.ShardID(0), err
+	}
+	return common.ShardID(shardID), nil
+}
+
+func (s *traceService) publishMessages(
+	ctx context.Context,
+	publisher queue.BatchPublisher,
+	writeEntity *tracev1.WriteRequest,
+	shardID common.ShardID,
+) ([]string, error) {
+	iwr := &tracev1.InternalWriteRequest{
+		Request: writeEntity,
+		ShardId: uint32(shardID),
+	}
+	nodeID, err := s.nodeRegistry.Locate(writeEntity.GetMetadata().GetGroup(), writeEntity.GetMetadata().GetName(), uint32(shardID), 0)
+	if err != nil {
+		return nil, err
+	}
+
+	message := bus.NewBatchMessageWithNode(bus.MessageID(time.Now().UnixNano()), nodeID, iwr)
+	if _, err := publisher.Publish(ctx, data.TopicTraceWrite, message); err != nil {
+		return nil, err
+	}
+	return []string{nodeID}, nil
+}
+
+func (s *traceService) Write(stream tracev1.TraceService_WriteServer) error {
+	reply := func(metadata *commonv1.Metadata, status modelv1.Status, version uint64, stream tracev1.TraceService_WriteServer, logger *logger.Logger) {
+		if status != modelv1.Status_STATUS_SUCCEED {
+			s.metrics.totalStreamMsgReceivedErr.Inc(1, metadata.Group, "trace", "write")
+		}
+		s.metrics.totalStreamMsgSent.Inc(1, metadata.Group, "trace", "write")
+		if errResp := stream.Send(&tracev1.WriteResponse{Metadata: metadata, Status: status.String(), Version: version}); errResp != nil {
+			if dl := logger.Debug(); dl.Enabled() {
+				dl.Err(errResp).Msg("failed to send trace write response")
+			}
+			s.metrics.totalStreamMsgSentErr.Inc(1, metadata.Group, "trace", "write")
+		}
+	}
+
+	s.metrics.totalStreamStarted.Inc(1, "trace", "write")
+	publisher := s.pipeline.NewBatchPublisher(s.writeTimeout)
+	start := time.Now()
+	var succeedSent []succeedSentMessage
+	requestCount := 0
+	defer func() {
+		cee, err := publisher.Close()
+		for _, ssm := range succeedSent {
+			code := modelv1.Status_STATUS_SUCCEED
+			if cee != nil {
+				for _, node := range ssm.nodes {
+					if ce, ok := cee[node]; ok {
+						code = ce.Status()
+						break
+					}
+				}
+			}
+			reply(ssm.metadata, code, ssm.messageID, stream, s.l)
+		}
+		if err != nil {
+			s.l.Error().Err(err).Msg("failed to close the publisher")
+		}
+		if dl := s.l.Debug(); dl.Enabled() {
+			dl.Int("total_requests", requestCount).Msg("completed trace write batch")
+		}
+		s.metrics.totalStreamFinished.Inc(1, "trace", "write")
+		s.metrics.totalStreamLatency.Inc(time.Since(start).Seconds(), "trace", "write")
+	}()
+
+	ctx := stream.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		writeEntity, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			if !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled) {
+				s.l.Error().Stringer("written", writeEntity).Err(err).Msg("failed to receive message")
+			}
+			return err
+		}
+
+		requestCount++
+		s.metrics.totalStreamMsgReceived.Inc(1, writeEntity.Metadata.Group, "trace", "write")
+
+		if err = s.validateMetadata(writeEntity); err != nil {
+			status := modelv1.Status_STATUS_INTERNAL_ERROR
+			if errors.Is(err, errTraceSchemaNotFound) {
+				status = modelv1.Status_STATUS_NOT_FOUND
+			} else if errors.Is(err, errTraceSchemaExpired) {
+				status = modelv1.Status_STATUS_EXPIRED_SCHEMA
+			}
+			s.l.Error().Err(err).Stringer("written", writeEntity).Msg("metadata validation failed")
+			reply(writeEntity.GetMetadata(), status, writeEntity.GetVersion(), stream, s.l)
+			continue
+		}
+
+		tagIndex, err := s.locateTagIndex(writeEntity)
+		if err != nil {
+			s.l.Error().Err(err).RawJSON("written", logger.Proto(writeEntity)).Msg("failed to find the trace schema")
+			reply(writeEntity.GetMetadata(), modelv1.Status_STATUS_NOT_FOUND, writeEntity.GetVersion(), stream, s.l)
+			continue
+		}
+
+		if err = s.validateTimestamp(writeEntity, tagIndex); err != nil {
+			reply(writeEntity.GetMetadata(), modelv1.Status_STATUS_INVALID_TIMESTAMP, writeEntity.GetVersion(), stream, s.l)
+			continue
+		}
+
+		shardID, err := s.shardID(writeEntity, tagIndex)
+		if err != nil {
+			s.l.Error().Err(err).RawJSON("written", logger.Proto(writeEntity)).Msg("navigation failed")
+			reply(writeEntity.GetMetadata(), modelv1.Status_STATUS_INTERNAL_ERROR, writeEntity.GetVersion(), stream, s.l)
+			continue
+		}
+
+		if s.ingestionAccessLog != nil {
+			if errAL := s.ingestionAccessLog.Write(writeEntity); errAL != nil {
+				s.l.Error().Err(errAL).Msg("failed to write ingestion access log")
+			}
+		}
+
+		nodes, err := s.publishMessages(ctx, publisher, writeEntity, shardID)
+		if err != nil {
+			s.l.Error().Err(err).RawJSON("written", logger.Proto(writeEntity)).Msg("publishing failed")
+			reply(writeEntity.GetMetadata(), modelv1.Status_STATUS_INTERNAL_ERROR, writeEntity.GetVersion(), stream, s.l)
+			continue
+		}
+
+		succeedSent = append(succeedSent, succeedSentMessage{
+			metadata:  writeEntity.GetMetadata(),
+			messageID: writeEntity.GetVersion(),
+			nodes:     nodes,
+		})
+	}
+}
+
+var emptyTraceQueryResponse = &tracev1.QueryResponse{Spans: make([]*tracev1.Span, 0)}
+
+func (s *traceService) Query(ctx context.Context, req *tracev1.QueryRequest) (resp *tracev1.QueryResponse, err error) {
+	for _, g := range req.Groups {
+		s.metrics.totalStarted.Inc(1, g, "trace", "query")
+	}
+	start := time.Now()
+	defer func() {
+		for _, g := range req.Groups {
+			s.metrics.totalFinished.Inc(1, g, "trace", "query")
+			if err != nil {
+				s.metrics.totalErr.Inc(1, g, "trace", "query")
+			}
+			s.metrics.totalLatency.Inc(time.Since(start).Seconds(), g, "trace", "query")
+		}
+	}()
+	timeRange := req.GetTimeRange()
+	if timeRange == nil {
+		req.TimeRange = timestamp.DefaultTimeRange
+	}
+	if err = timestamp.CheckTimeRange(req.GetTimeRange()); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v is invalid :%s", req.GetTimeRange(), err)
+	}
+	now := time.Now()
+	if req.Trace {
+		tracer, _ := query.NewTracer(ctx, now.Format(time.RFC3339Nano))
+		span, _ := tracer.StartSpan(ctx, "trace-grpc")
+		span.Tag("request", convert.BytesToString(logger.Proto(req)))
+		defer func() {
+			if err != nil {
+				span.Error(err)
+			} else {
+				span.AddSubTrace(resp.TraceQueryResult)
+				resp.TraceQueryResult = tracer.ToProto()
+			}
+			span.Stop()
+		}()
+	}
+	message := bus.NewMessage(bus.MessageID(now.UnixNano()), req)
+	feat, errQuery := s.broadcaster.Publish(ctx, data.TopicTraceQuery, message)
+	if errQuery != nil {
+		if errors.Is(errQuery, io.EOF) {
+			return emptyTraceQueryResponse, nil
+		}
+		return nil, errQuery
+	}
+	msg, errFeat := feat.Get()
+	if errFeat != nil {
+		if errors.Is(errFeat, io.EOF) {
+			return emptyTraceQueryResponse, nil
+		}
+		return nil, errFeat
+	}
+	data := msg.Data()
+	switch d := data.(type) {
+	case *tracev1.QueryResponse:
+		return d, nil
+	case *common.Error:
+		return nil, errors.WithMessage(errQueryMsg, d.Error())
+	}
+	return nil, nil
+}
+
+var emptyGetTracesResponse = &tracev1.GetTracesResponse{Traces: make([]*tracev1.Trace, 0)}
+
+func (s *traceService) GetTraces(ctx context.Context, req *tracev1.GetTracesRequest) (resp *tracev1.GetTracesResponse, err error) {
+	for _, g := range req.Groups {
+		s.metrics.totalStarted.Inc(1, g, "trace", "get_traces")
+	}
+	start := time.Now()
+	defer func() {
+		for _, g := range req.Groups {
+			s.metrics.totalFinished.Inc(1, g, "trace", "get_traces")
+			if err != nil {
+				s.metrics.totalErr.Inc(1, g, "trace", "get_traces")
+			}
+			s.metrics.totalLatency.Inc(time.Since(start).Seconds(), g, "trace", "get_traces")
+		}
+	}()
+	// The time range is optional. Without it, all the segments are searched for the traces.
+	if req.GetTimeRange() != nil {
+		if err = timestamp.CheckTimeRange(req.GetTimeRange()); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "%v is invalid :%s", req.GetTimeRange(), err)
+		}
+	}
+	now := time.Now()
+	if req.Trace {
+		tracer, _ := query.NewTracer(ctx, now.Format(time.RFC3339Nano))
+		span, _ := tracer.StartSpan(ctx, "trace-get-grpc")
+		span.Tag("request", convert.BytesToString(logger.Proto(req)))
+		defer func() {
+			if err != nil {
+				span.Error(err)
+			} else {
+				span.AddSubTrace(resp.TraceQueryResult)
+				resp.TraceQueryResult = tracer.ToProto()
+			}
+			span.Stop()
+		}()
+	}
+	message := bus.NewMessage(bus.MessageID(now.UnixNano()), req)
+	feat, errQuery := s.broadcaster.Publish(ctx, data.TopicTraceGet, message)
+	if errQuery != nil {
+		if errors.Is(errQuery, io.EOF) {
+			return emptyGetTracesResponse, nil
+		}
+		return nil, errQuery
+	}
+	msg, errFeat := feat.Get()
+	if errFeat != nil {
+		if errors.Is(errFeat, io.EOF) {
+			return emptyGetTracesResponse, nil
+		}
+		return nil, errFeat
+	}
+	switch d := msg.Data().(type) {
+	case *tracev1.GetTracesResponse:
+		return d, nil
+	case *common.Error:
+		return nil, errors.WithMessage(errQueryMsg, d.Error())
+	}
+	return nil, nil
+}
+
+func (s *traceService) Close() error {
+	if s.ingestionAccessLog != nil {
+		return s.ingestionAccessLog.Close()
+	}
+	return nil
+}
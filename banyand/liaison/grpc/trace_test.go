@@ -0,0 +1,113 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	tracev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/trace/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+func newTestTraceService() *traceService {
+	log := logger.GetLogger("test")
+	ds := newDiscoveryService(schema.KindTrace, nil, NewLocalNodeRegistry(),
+		&groupRepo{resourceOpts: make(map[string]*commonv1.ResourceOpts)})
+	ds.SetLogger(log)
+	ds.groupRepo.OnAddOrUpdate(schema.Metadata{
+		TypeMeta: schema.TypeMeta{Kind: schema.KindGroup},
+		Spec: &commonv1.Group{
+			Metadata:     &commonv1.Metadata{Name: "sw_trace"},
+			Catalog:      commonv1.Catalog_CATALOG_TRACE,
+			ResourceOpts: &commonv1.ResourceOpts{ShardNum: 4},
+		},
+	})
+	ds.entityRepo.OnAddOrUpdate(schema.Metadata{
+		TypeMeta: schema.TypeMeta{Kind: schema.KindTrace},
+		Spec: &databasev1.Trace{
+			Metadata: &commonv1.Metadata{Group: "sw_trace", Name: "segment", ModRevision: 1},
+			Tags: []*databasev1.TraceTagSpec{
+				{Name: "service_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+				{Name: "trace_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+				{Name: "timestamp", Type: databasev1.TagType_TAG_TYPE_TIMESTAMP},
+			},
+			TraceIdTagName:   "trace_id",
+			TimestampTagName: "timestamp",
+		},
+	})
+	svc := &traceService{discoveryService: ds}
+	svc.setLogger(log)
+	return svc
+}
+
+func newTestTraceWriteRequest(traceID string) *tracev1.WriteRequest {
+	return &tracev1.WriteRequest{
+		Metadata: &commonv1.Metadata{Group: "sw_trace", Name: "segment"},
+		Tags: []*modelv1.TagValue{
+			{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "svc"}}},
+			{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: traceID}}},
+			{Value: &modelv1.TagValue_Timestamp{Timestamp: timestamppb.New(time.Now())}},
+		},
+		Span:    []byte("span"),
+		Version: 1,
+	}
+}
+
+func TestTraceServiceShardRouting(t *testing.T) {
+	svc := newTestTraceService()
+	req := newTestTraceWriteRequest("trace-1")
+	tagIndex, err := svc.locateTagIndex(req)
+	require.NoError(t, err)
+	assert.Equal(t, 1, tagIndex.traceIDIdx)
+	assert.Equal(t, 2, tagIndex.timestampIdx)
+	require.NoError(t, svc.validateTimestamp(req, tagIndex))
+
+	shardID, err := svc.shardID(req, tagIndex)
+	require.NoError(t, err)
+	assert.Less(t, uint32(shardID), uint32(4))
+	for i := 0; i < 10; i++ {
+		another, errShard := svc.shardID(newTestTraceWriteRequest("trace-1"), tagIndex)
+		require.NoError(t, errShard)
+		assert.Equal(t, shardID, another, "spans of the same trace must be routed to the same shard")
+	}
+
+	_, err = svc.shardID(newTestTraceWriteRequest(""), tagIndex)
+	assert.ErrorIs(t, err, errTraceIDEmpty)
+}
+
+func TestTraceServiceValidateMetadata(t *testing.T) {
+	svc := newTestTraceService()
+	req := newTestTraceWriteRequest("trace-1")
+	req.Metadata.ModRevision = 1
+	assert.NoError(t, svc.validateMetadata(req))
+	req.Metadata.ModRevision = 2
+	assert.ErrorIs(t, svc.validateMetadata(req), errTraceSchemaExpired)
+	req.Metadata.Name = "unknown"
+	assert.ErrorIs(t, svc.validateMetadata(req), errTraceSchemaNotFound)
+	_, err := svc.locateTagIndex(req)
+	assert.ErrorIs(t, err, errNotExist)
+}
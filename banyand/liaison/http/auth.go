@@ -19,7 +19,6 @@ package http
 
 import (
 	"context"
-	"encoding/base64"
 	"net/http"
 	"strings"
 
@@ -37,7 +36,7 @@ func authMiddleware(cfg *auth.Config) func(http.Handler) http.Handler {
 				return
 			}
 
-			if !cfg.Enabled {
+			if !auth.IsEnabled(cfg) {
 				next.ServeHTTP(w, r)
 				return
 			}
@@ -47,48 +46,49 @@ func authMiddleware(cfg *auth.Config) func(http.Handler) http.Handler {
 				return
 			}
 
-			authHeader := r.Header.Get("Authorization")
-			if authHeader == "" {
-				w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
-				http.Error(w, "Authorization header is missing", http.StatusUnauthorized)
-				return
-			}
-
-			if !strings.HasPrefix(authHeader, "Basic ") {
-				http.Error(w, "Invalid authorization header format", http.StatusBadRequest)
-				return
-			}
-
-			encodedCredentials := strings.TrimPrefix(authHeader, "Basic ")
-
-			decodedBytes, err := base64.StdEncoding.DecodeString(encodedCredentials)
+			creds, err := credentialsFromRequest(r)
 			if err != nil {
-				http.Error(w, "Failed to decode authorization header", http.StatusBadRequest)
-				return
-			}
-
-			decodedCredentials := string(decodedBytes)
-
-			parts := strings.SplitN(decodedCredentials, ":", 2)
-			if len(parts) != 2 {
+				if errors.Is(err, auth.ErrCredentialsNotFound) {
+					w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
+					http.Error(w, "Authorization header is missing", http.StatusUnauthorized)
+					return
+				}
 				http.Error(w, "Invalid authorization header format", http.StatusBadRequest)
 				return
 			}
-
-			username := parts[0]
-			password := parts[1]
-			if !auth.CheckUsernameAndPassword(cfg, username, password) {
+			if _, err = auth.Authenticate(cfg, creds); err != nil {
 				http.Error(w, `{"error": "invalid credentials"}`, http.StatusUnauthorized)
 				return
 			}
 
-			r.Header.Set("Grpc-Metadata-Username", username)
-			r.Header.Set("Grpc-Metadata-Password", password)
+			// The gateway forwards the credentials to the gRPC server, whose interceptors authorize the request
+			// against the roles of the client. A denied request gets a 403 from the gateway.
+			// The Authorization header, which carries the bearer token, is forwarded by the gateway as is.
+			if creds.Username != "" || creds.Password != "" {
+				r.Header.Set("Grpc-Metadata-Username", creds.Username)
+				r.Header.Set("Grpc-Metadata-Password", creds.Password)
+			}
+			if creds.APIKey != "" {
+				r.Header.Set("Grpc-Metadata-X-Api-Key", creds.APIKey)
+			}
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
+// credentialsFromRequest reads the credentials from the X-API-Key header,
+// or the Authorization header with the Basic or Bearer scheme.
+func credentialsFromRequest(r *http.Request) (auth.Credentials, error) {
+	if apiKey := r.Header.Get("X-API-Key"); apiKey != "" {
+		return auth.Credentials{APIKey: apiKey}, nil
+	}
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		return auth.Credentials{}, auth.ErrCredentialsNotFound
+	}
+	return auth.ParseAuthorization(authHeader)
+}
+
 var staticPaths = []string{
 	"/favicon.ico",
 	"/banyandb.ico",
@@ -109,20 +109,29 @@ func buildGRPCContextForHealthCheck(cfg *auth.Config, r *http.Request) (context.
 	ctx := r.Context()
 
 	if cfg.HealthAuthEnabled {
-		username := r.Header.Get("Grpc-Metadata-Username")
-		password := r.Header.Get("Grpc-Metadata-Password")
-
-		if username == "" || password == "" {
+		md := outgoingMetadata(r)
+		if md.Len() == 0 {
 			return nil, errors.New("missing authentication metadata")
 		}
 
-		md := metadata.New(map[string]string{
-			"username": username,
-			"password": password,
-		})
-
 		ctx = metadata.NewOutgoingContext(ctx, md)
 	}
 
 	return ctx, nil
 }
+
+// outgoingMetadata returns the credentials which the auth middleware puts in the request as the gRPC metadata.
+func outgoingMetadata(r *http.Request) metadata.MD {
+	md := metadata.MD{}
+	if username, password := r.Header.Get("Grpc-Metadata-Username"), r.Header.Get("Grpc-Metadata-Password"); username != "" && password != "" {
+		md.Set("username", username)
+		md.Set("password", password)
+	}
+	if apiKey := r.Header.Get("Grpc-Metadata-X-Api-Key"); apiKey != "" {
+		md.Set("x-api-key", apiKey)
+	}
+	if authHeader := r.Header.Get("Authorization"); strings.HasPrefix(strings.ToLower(authHeader), "bearer ") {
+		md.Set("authorization", authHeader)
+	}
+	return md
+}
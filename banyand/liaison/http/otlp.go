@@ -0,0 +1,132 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package http
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	collectortracev1 "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+const (
+	otlpTracesPath = "/api/otlp/v1/traces"
+
+	otlpContentTypeProtobuf = "application/x-protobuf"
+	otlpContentTypeJSON     = "application/json"
+
+	maxOTLPBodySize = 32 << 20
+)
+
+// otlpTracesHandler receives the spans by OTLP/HTTP and forwards them to the OTLP/gRPC receiver.
+type otlpTracesHandler struct {
+	client collectortracev1.TraceServiceClient
+	l      *logger.Logger
+}
+
+func (h *otlpTracesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (contentType != otlpContentTypeProtobuf && contentType != otlpContentTypeJSON) {
+		http.Error(w, "unsupported content type, expect application/x-protobuf or application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+	body := io.Reader(http.MaxBytesReader(w, r.Body, maxOTLPBodySize))
+	switch r.Header.Get("Content-Encoding") {
+	case "", "identity":
+	case "gzip":
+		gr, errGzip := gzip.NewReader(body)
+		if errGzip != nil {
+			h.writeError(w, contentType, status.Newf(codes.InvalidArgument, "invalid gzip body: %v", errGzip))
+			return
+		}
+		defer gr.Close()
+		body = gr
+	default:
+		http.Error(w, "unsupported content encoding", http.StatusUnsupportedMediaType)
+		return
+	}
+	// The limit applies to the decompressed body too, so that a small gzip bomb can't exhaust the memory.
+	data, err := io.ReadAll(io.LimitReader(body, maxOTLPBodySize+1))
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) || (err == nil && len(data) > maxOTLPBodySize) {
+		h.write(w, contentType, http.StatusRequestEntityTooLarge,
+			status.Newf(codes.InvalidArgument, "the body is larger than %d bytes", maxOTLPBodySize).Proto())
+		return
+	}
+	if err != nil {
+		h.writeError(w, contentType, status.Newf(codes.InvalidArgument, "failed to read the body: %v", err))
+		return
+	}
+	req := &collectortracev1.ExportTraceServiceRequest{}
+	if contentType == otlpContentTypeJSON {
+		err = protojson.Unmarshal(data, req)
+	} else {
+		err = proto.Unmarshal(data, req)
+	}
+	if err != nil {
+		h.writeError(w, contentType, status.Newf(codes.InvalidArgument, "failed to decode the request: %v", err))
+		return
+	}
+
+	ctx := r.Context()
+	if md := outgoingMetadata(r); md.Len() > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, md)
+	}
+	resp, err := h.client.Export(ctx, req)
+	if err != nil {
+		h.writeError(w, contentType, status.Convert(err))
+		return
+	}
+	h.write(w, contentType, http.StatusOK, resp)
+}
+
+// writeError responds the status encoded in the content type of the request, as OTLP/HTTP requires.
+func (h *otlpTracesHandler) writeError(w http.ResponseWriter, contentType string, st *status.Status) {
+	// The exporters retry the unavailable requests, which are mapped to 503.
+	h.write(w, contentType, runtime.HTTPStatusFromCode(st.Code()), st.Proto())
+}
+
+func (h *otlpTracesHandler) write(w http.ResponseWriter, contentType string, code int, m proto.Message) {
+	var data []byte
+	var err error
+	if contentType == otlpContentTypeJSON {
+		data, err = protojson.Marshal(m)
+	} else {
+		data, err = proto.Marshal(m)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(code)
+	if _, err = w.Write(data); err != nil {
+		h.l.Error().Err(err).Msg("failed to write the OTLP response")
+	}
+}
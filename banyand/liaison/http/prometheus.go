@@ -0,0 +1,480 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+	"github.com/apache/skywalking-banyandb/pkg/prometheus/prompb"
+)
+
+const (
+	prometheusWritePath = "/api/v1/write"
+	prometheusReadPath  = "/api/v1/read"
+
+	maxPrometheusBodySize = 32 << 20
+	// maxPrometheusDecodedBodySize is the maximum size of a decompressed body.
+	maxPrometheusDecodedBodySize = 32 << 20
+)
+
+var (
+	errPrometheusMetricName    = errors.New("the metric name is absent")
+	errPrometheusSampleLimit   = errors.New("the query selects too many samples")
+	errPrometheusResponseTypes = errors.New("only the samples response type is supported")
+	errPrometheusMatcher       = errors.New("invalid label matcher")
+)
+
+// prometheusHandler serves the Prometheus remote write and remote read protocols.
+// A metric is stored in the measure of the same name, its labels are stored in the string tags,
+// and its samples are stored in the float field "value".
+type prometheusHandler struct {
+	measureClient measurev1.MeasureServiceClient
+	schemaRepo    *prometheusSchemaRepo
+	l             *logger.Logger
+	group         string
+	maxSamples    uint32
+}
+
+func (h *prometheusHandler) outgoingContext(r *http.Request) context.Context {
+	ctx := r.Context()
+	if md := outgoingMetadata(r); md.Len() > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, md)
+	}
+	return ctx
+}
+
+func readSnappyBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	compressed, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPrometheusBodySize))
+	if err != nil {
+		return nil, err
+	}
+	return prompb.DecodeSnappy(compressed, maxPrometheusDecodedBodySize)
+}
+
+// writeBodyError responds the error reading the body, which is 413 if the body is too large.
+func writeBodyError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) || errors.Is(err, prompb.ErrTooLarge) {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
+
+// Write stores the samples of the remote write request.
+// The series which can't be stored are rejected with 400, and the others are still written.
+// The failures which might recover, like the absent schema in the cache, are responded by 503, so that Prometheus retries them.
+func (h *prometheusHandler) Write(w http.ResponseWriter, r *http.Request) {
+	data, err := readSnappyBody(w, r)
+	if err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	req := &prompb.WriteRequest{}
+	if err = req.Unmarshal(data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := h.outgoingContext(r)
+
+	metrics := make(map[string][]int)
+	var rejected []string
+	for i := range req.Timeseries {
+		name := ""
+		for _, l := range req.Timeseries[i].Labels {
+			if l.Name == prompb.MetricNameLabel {
+				name = l.Value
+			}
+		}
+		if name == "" {
+			rejected = append(rejected, errPrometheusMetricName.Error())
+			continue
+		}
+		metrics[name] = append(metrics[name], i)
+	}
+
+	var requests []*measurev1.WriteRequest
+	for name, series := range metrics {
+		s, errSchema := h.schemaRepo.ensure(ctx, name, seriesLabelNames(req.Timeseries, series))
+		if errSchema != nil {
+			if !errors.Is(errSchema, errPrometheusMetricNotFound) && !errors.Is(errSchema, errPrometheusLabelNotFound) &&
+				!errors.Is(errSchema, errPrometheusValueField) {
+				h.l.Error().Err(errSchema).Str("metric", name).Msg("failed to resolve the measure of the metric")
+				http.Error(w, errSchema.Error(), http.StatusServiceUnavailable)
+				return
+			}
+			rejected = append(rejected, errSchema.Error())
+			continue
+		}
+		for _, i := range series {
+			requests = append(requests, h.writeRequests(s, req.Timeseries[i])...)
+		}
+	}
+
+	if len(requests) > 0 {
+		invalid, errWrite := h.write(ctx, requests)
+		if errWrite != nil {
+			h.l.Error().Err(errWrite).Msg("failed to write the Prometheus samples")
+			http.Error(w, errWrite.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		if invalid > 0 {
+			rejected = append(rejected, strconv.Itoa(invalid)+" samples have invalid timestamps")
+		}
+	}
+	if len(rejected) > 0 {
+		http.Error(w, strings.Join(rejected, "; "), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func seriesLabelNames(timeseries []prompb.TimeSeries, series []int) []string {
+	set := make(map[string]bool)
+	var names []string
+	for _, i := range series {
+		for _, l := range timeseries[i].Labels {
+			if l.Name == prompb.MetricNameLabel || set[l.Name] {
+				continue
+			}
+			set[l.Name] = true
+			names = append(names, l.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (h *prometheusHandler) writeRequests(s *prometheusSchema, ts prompb.TimeSeries) []*measurev1.WriteRequest {
+	labels := make([]prompb.Label, 0, len(ts.Labels))
+	for _, l := range ts.Labels {
+		if l.Name != prompb.MetricNameLabel {
+			labels = append(labels, l)
+		}
+	}
+	prompb.SortLabels(labels)
+	families := make([]*modelv1.TagFamilyForWrite, len(s.measure.GetTagFamilies()))
+	for i, f := range s.measure.GetTagFamilies() {
+		families[i] = &modelv1.TagFamilyForWrite{Tags: make([]*modelv1.TagValue, len(f.GetTags()))}
+		for j := range families[i].Tags {
+			families[i].Tags[j] = pbv1.NullTagValue
+		}
+	}
+	setTag := func(name, value string) {
+		if loc, ok := s.tags[name]; ok {
+			families[loc.family].Tags[loc.tag] = &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: value}}}
+		}
+	}
+	setTag(prometheusSeriesTag, prompb.LabelsString(labels))
+	for _, l := range labels {
+		setTag(l.Name, l.Value)
+	}
+
+	requests := make([]*measurev1.WriteRequest, 0, len(ts.Samples))
+	for _, sample := range ts.Samples {
+		fields := make([]*modelv1.FieldValue, len(s.measure.GetFields()))
+		for i := range fields {
+			fields[i] = pbv1.NullFieldValue
+		}
+		fields[s.field] = &modelv1.FieldValue{Value: &modelv1.FieldValue_Float{Float: &modelv1.Float{Value: sample.Value}}}
+		requests = append(requests, &measurev1.WriteRequest{
+			Metadata: &commonv1.Metadata{Group: h.group, Name: s.measure.GetMetadata().GetName()},
+			DataPoint: &measurev1.DataPointValue{
+				Timestamp:   timestamppb.New(time.UnixMilli(sample.Timestamp)),
+				TagFamilies: families,
+				Fields:      fields,
+			},
+		})
+	}
+	return requests
+}
+
+// write sends the requests through a write stream and returns the number of the requests with invalid timestamps.
+func (h *prometheusHandler) write(ctx context.Context, requests []*measurev1.WriteRequest) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := h.measureClient.Write(ctx)
+	if err != nil {
+		return 0, err
+	}
+	messageID := uint64(time.Now().UnixNano())
+	for _, req := range requests {
+		req.MessageId = messageID
+		messageID++
+		if err = stream.Send(req); err != nil {
+			return 0, err
+		}
+	}
+	if err = stream.CloseSend(); err != nil {
+		return 0, err
+	}
+	var invalid int
+	var failed error
+	for {
+		resp, errRecv := stream.Recv()
+		if errors.Is(errRecv, io.EOF) {
+			break
+		}
+		if errRecv != nil {
+			return 0, errRecv
+		}
+		switch resp.GetStatus() {
+		case modelv1.Status_STATUS_SUCCEED.String():
+		case modelv1.Status_STATUS_INVALID_TIMESTAMP.String():
+			invalid++
+		default:
+			if failed == nil {
+				failed = errors.Errorf("failed to write %s: %s", resp.GetMetadata().GetName(), resp.GetStatus())
+			}
+		}
+	}
+	return invalid, failed
+}
+
+// Read loads the samples selected by the queries of the remote read request.
+// The matchers on the indexed labels are pushed down to the measure query, and all matchers are applied to the results.
+func (h *prometheusHandler) Read(w http.ResponseWriter, r *http.Request) {
+	data, err := readSnappyBody(w, r)
+	if err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	req := &prompb.ReadRequest{}
+	if err = req.Unmarshal(data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.AcceptedResponseTypes) > 0 {
+		supported := false
+		for _, t := range req.AcceptedResponseTypes {
+			if t == prompb.ResponseTypeSamples {
+				supported = true
+			}
+		}
+		if !supported {
+			http.Error(w, errPrometheusResponseTypes.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	ctx := h.outgoingContext(r)
+	resp := &prompb.ReadResponse{Results: make([]prompb.QueryResult, len(req.Queries))}
+	for i := range req.Queries {
+		series, errQuery := h.query(ctx, req.Queries[i])
+		if errQuery != nil {
+			code := http.StatusInternalServerError
+			if errors.Is(errQuery, errPrometheusMetricName) || errors.Is(errQuery, errPrometheusMatcher) ||
+				errors.Is(errQuery, errPrometheusSampleLimit) {
+				code = http.StatusBadRequest
+			}
+			http.Error(w, errQuery.Error(), code)
+			return
+		}
+		resp.Results[i].Timeseries = series
+	}
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.Header().Set("Content-Encoding", "snappy")
+	if _, err = w.Write(prompb.EncodeSnappy(resp.Marshal())); err != nil {
+		h.l.Error().Err(err).Msg("failed to write the remote read response")
+	}
+}
+
+func (h *prometheusHandler) query(ctx context.Context, q prompb.Query) ([]prompb.TimeSeries, error) {
+	name := ""
+	matchers := make([]func(string) bool, 0, len(q.Matchers))
+	for _, m := range q.Matchers {
+		if m.Name == prompb.MetricNameLabel {
+			if m.Type != prompb.MatchEqual {
+				return nil, errors.WithMessage(errPrometheusMetricName, "the metric name should be selected by the equality matcher")
+			}
+			name = m.Value
+		}
+		match, err := m.Matcher()
+		if err != nil {
+			return nil, errors.WithMessage(errPrometheusMatcher, err.Error())
+		}
+		matchers = append(matchers, match)
+	}
+	if name == "" {
+		return nil, errPrometheusMetricName
+	}
+	s, err := h.schemaRepo.get(ctx, name)
+	if errors.Is(err, errPrometheusMetricNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	criteria, _ := prometheusCriteria(s, q.Matchers)
+	queryReq := &measurev1.QueryRequest{
+		Groups: []string{h.group},
+		Name:   name,
+		TimeRange: &modelv1.TimeRange{
+			Begin: timestamppb.New(time.UnixMilli(q.StartTimestampMs)),
+			End:   timestamppb.New(time.UnixMilli(q.EndTimestampMs + 1)),
+		},
+		Criteria:        criteria,
+		TagProjection:   s.tagProjection(nil),
+		FieldProjection: &measurev1.QueryRequest_FieldProjection{Names: []string{prometheusValueField}},
+		Limit:           h.maxSamples + 1,
+	}
+	resp, err := h.measureClient.Query(ctx, queryReq)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.GetDataPoints()) > int(h.maxSamples) {
+		return nil, errors.WithMessagef(errPrometheusSampleLimit, "limit: %d", h.maxSamples)
+	}
+
+	seriesMap := make(map[string]*prompb.TimeSeries)
+	for _, dp := range resp.GetDataPoints() {
+		labels := []prompb.Label{{Name: prompb.MetricNameLabel, Value: name}}
+		for _, tf := range dp.GetTagFamilies() {
+			for _, t := range tf.GetTags() {
+				if t.GetKey() == prometheusSeriesTag {
+					continue
+				}
+				if v, ok := prometheusLabelValue(t.GetValue()); ok {
+					labels = append(labels, prompb.Label{Name: t.GetKey(), Value: v})
+				}
+			}
+		}
+		if !matchLabels(q.Matchers, matchers, labels) {
+			continue
+		}
+		var value float64
+		for _, f := range dp.GetFields() {
+			if f.GetName() == prometheusValueField {
+				value = f.GetValue().GetFloat().GetValue()
+			}
+		}
+		prompb.SortLabels(labels)
+		key := prompb.LabelsString(labels)
+		ts, ok := seriesMap[key]
+		if !ok {
+			ts = &prompb.TimeSeries{Labels: labels}
+			seriesMap[key] = ts
+		}
+		ts.Samples = append(ts.Samples, prompb.Sample{Value: value, Timestamp: dp.GetTimestamp().AsTime().UnixMilli()})
+	}
+	keys := make([]string, 0, len(seriesMap))
+	for k := range seriesMap {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	result := make([]prompb.TimeSeries, 0, len(keys))
+	for _, k := range keys {
+		ts := seriesMap[k]
+		sort.Slice(ts.Samples, func(i, j int) bool { return ts.Samples[i].Timestamp < ts.Samples[j].Timestamp })
+		result = append(result, *ts)
+	}
+	return result, nil
+}
+
+func matchLabels(matchers []prompb.LabelMatcher, fns []func(string) bool, labels []prompb.Label) bool {
+	for i, m := range matchers {
+		value := ""
+		for _, l := range labels {
+			if l.Name == m.Name {
+				value = l.Value
+				break
+			}
+		}
+		if !fns[i](value) {
+			return false
+		}
+	}
+	return true
+}
+
+func prometheusLabelValue(v *modelv1.TagValue) (string, bool) {
+	switch x := v.GetValue().(type) {
+	case *modelv1.TagValue_Str:
+		return x.Str.GetValue(), x.Str.GetValue() != ""
+	case *modelv1.TagValue_Int:
+		return strconv.FormatInt(x.Int.GetValue(), 10), true
+	case *modelv1.TagValue_Float:
+		return strconv.FormatFloat(x.Float.GetValue(), 'g', -1, 64), true
+	}
+	return "", false
+}
+
+// prometheusCriteria pushes down the matchers which the measure is able to evaluate exactly.
+// The equality matchers are evaluated on the entity and the indexed tags, and the regular expressions on the indexed tags.
+// The matchers selecting the absent labels are left to the results, since the absent tags are not indexed.
+// It reports whether all matchers are pushed down.
+func prometheusCriteria(s *prometheusSchema, matchers []prompb.LabelMatcher) (*modelv1.Criteria, bool) {
+	var conditions []*modelv1.Criteria
+	all := true
+	for _, m := range matchers {
+		if m.Name == prompb.MetricNameLabel {
+			continue
+		}
+		if _, ok := s.tags[m.Name]; !ok {
+			all = false
+			continue
+		}
+		var op modelv1.Condition_BinaryOp
+		switch {
+		case m.Type == prompb.MatchEqual && m.Value != "" && (s.entity[m.Name] || s.indexed[m.Name]):
+			op = modelv1.Condition_BINARY_OP_EQ
+		case m.Type == prompb.MatchRegexp && s.indexed[m.Name] && !regexpMatchesEmpty(m) && !strings.ContainsAny(m.Value, "^$\\"):
+			op = modelv1.Condition_BINARY_OP_REGEX
+		default:
+			all = false
+			continue
+		}
+		conditions = append(conditions, &modelv1.Criteria{Exp: &modelv1.Criteria_Condition{Condition: &modelv1.Condition{
+			Name:  m.Name,
+			Op:    op,
+			Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: m.Value}}},
+		}}})
+	}
+	if len(conditions) == 0 {
+		return nil, all
+	}
+	criteria := conditions[0]
+	for _, c := range conditions[1:] {
+		criteria = &modelv1.Criteria{Exp: &modelv1.Criteria_Le{Le: &modelv1.LogicalExpression{
+			Op:    modelv1.LogicalExpression_LOGICAL_OP_AND,
+			Left:  criteria,
+			Right: c,
+		}}}
+	}
+	return criteria, all
+}
+
+func regexpMatchesEmpty(m prompb.LabelMatcher) bool {
+	match, err := m.Matcher()
+	return err != nil || match("")
+}
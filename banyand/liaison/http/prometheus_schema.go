@@ -0,0 +1,352 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package http
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+)
+
+const (
+	// prometheusSeriesTag holds the labels of a series, which identifies the series of the auto-registered measures.
+	// The labels starting with "__" are reserved by Prometheus, so it never collides with a label.
+	prometheusSeriesTag = "__series__"
+	// prometheusValueField holds the values of the samples.
+	prometheusValueField = "value"
+	// prometheusTagFamily holds the labels of the auto-registered measures.
+	prometheusTagFamily = "default"
+	// prometheusIndexRulePrefix prefixes the index rules created for the labels.
+	prometheusIndexRulePrefix = "prometheus-label-"
+
+	prometheusSchemaTTL = 30 * time.Second
+)
+
+var (
+	errPrometheusMetricNotFound = errors.New("the measure of the metric is not found")
+	errPrometheusLabelNotFound  = errors.New("the tag of the label is not found")
+	errPrometheusValueField     = errors.New("the measure has no float field named " + prometheusValueField)
+)
+
+type prometheusTagLocator struct {
+	family int
+	tag    int
+}
+
+// prometheusSchema is the measure which stores the samples of a metric.
+type prometheusSchema struct {
+	measure  *databasev1.Measure
+	tags     map[string]prometheusTagLocator
+	entity   map[string]bool
+	indexed  map[string]bool
+	loadedAt time.Time
+	field    int
+}
+
+func newPrometheusSchema(measure *databasev1.Measure, indexed map[string]bool) (*prometheusSchema, error) {
+	s := &prometheusSchema{
+		measure:  measure,
+		tags:     make(map[string]prometheusTagLocator),
+		entity:   make(map[string]bool),
+		indexed:  indexed,
+		loadedAt: time.Now(),
+		field:    -1,
+	}
+	for i, f := range measure.GetTagFamilies() {
+		for j, t := range f.GetTags() {
+			s.tags[t.GetName()] = prometheusTagLocator{family: i, tag: j}
+		}
+	}
+	for _, t := range measure.GetEntity().GetTagNames() {
+		s.entity[t] = true
+	}
+	for i, f := range measure.GetFields() {
+		if f.GetName() == prometheusValueField && f.GetFieldType() == databasev1.FieldType_FIELD_TYPE_FLOAT {
+			s.field = i
+		}
+	}
+	if s.field < 0 {
+		return nil, errors.WithMessagef(errPrometheusValueField, "measure: %s", measure.GetMetadata().GetName())
+	}
+	return s, nil
+}
+
+// missingLabels returns the labels which have no tags in the measure.
+func (s *prometheusSchema) missingLabels(labels []string) []string {
+	var missing []string
+	for _, l := range labels {
+		if _, ok := s.tags[l]; !ok {
+			missing = append(missing, l)
+		}
+	}
+	return missing
+}
+
+// tagProjection projects the tags in the order of the measure, or all tags if the tags are absent.
+func (s *prometheusSchema) tagProjection(tags []string) *modelv1.TagProjection {
+	selected := make(map[string]bool, len(tags))
+	for _, t := range tags {
+		selected[t] = true
+	}
+	projection := &modelv1.TagProjection{}
+	for _, f := range s.measure.GetTagFamilies() {
+		tf := &modelv1.TagProjection_TagFamily{Name: f.GetName()}
+		for _, t := range f.GetTags() {
+			if len(tags) == 0 || selected[t.GetName()] {
+				tf.Tags = append(tf.Tags, t.GetName())
+			}
+		}
+		if len(tf.Tags) > 0 {
+			projection.TagFamilies = append(projection.TagFamilies, tf)
+		}
+	}
+	return projection
+}
+
+// prometheusSchemaRepo resolves the measures of the metrics and registers them if they are absent.
+type prometheusSchemaRepo struct {
+	measureClient databasev1.MeasureRegistryServiceClient
+	ruleClient    databasev1.IndexRuleRegistryServiceClient
+	bindingClient databasev1.IndexRuleBindingRegistryServiceClient
+	cache         map[string]*prometheusSchema
+	group         string
+	mu            sync.Mutex
+	autoRegister  bool
+}
+
+// get returns the measure of the metric.
+func (r *prometheusSchemaRepo) get(ctx context.Context, metric string) (*prometheusSchema, error) {
+	r.mu.Lock()
+	s, ok := r.cache[metric]
+	r.mu.Unlock()
+	if ok && time.Since(s.loadedAt) < prometheusSchemaTTL {
+		return s, nil
+	}
+	return r.load(ctx, metric)
+}
+
+// ensure returns the measure of the metric, which has the tags of all labels.
+// The measure and the tags are registered if they are absent and the auto-registration is enabled.
+func (r *prometheusSchemaRepo) ensure(ctx context.Context, metric string, labels []string) (*prometheusSchema, error) {
+	s, err := r.get(ctx, metric)
+	if err == nil && len(s.missingLabels(labels)) > 0 {
+		// The tags might be appended by other liaisons.
+		s, err = r.load(ctx, metric)
+	}
+	if err != nil {
+		if !errors.Is(err, errPrometheusMetricNotFound) || !r.autoRegister {
+			return nil, err
+		}
+		if err = r.createMeasure(ctx, metric, labels); err != nil {
+			return nil, err
+		}
+		return r.load(ctx, metric)
+	}
+	missing := s.missingLabels(labels)
+	if len(missing) == 0 {
+		return s, nil
+	}
+	if !r.autoRegister {
+		return nil, errors.WithMessagef(errPrometheusLabelNotFound, "measure: %s, labels: %v", metric, missing)
+	}
+	if err = r.appendTags(ctx, s.measure, missing); err != nil {
+		return nil, err
+	}
+	return r.load(ctx, metric)
+}
+
+func (r *prometheusSchemaRepo) load(ctx context.Context, metric string) (*prometheusSchema, error) {
+	metadata := &commonv1.Metadata{Group: r.group, Name: metric}
+	resp, err := r.measureClient.Get(ctx, &databasev1.MeasureRegistryServiceGetRequest{Metadata: metadata})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, errors.WithMessagef(errPrometheusMetricNotFound, "measure: %s", metric)
+		}
+		return nil, err
+	}
+	indexed, err := r.indexedTags(ctx, metric)
+	if err != nil {
+		return nil, err
+	}
+	s, err := newPrometheusSchema(resp.GetMeasure(), indexed)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	r.cache[metric] = s
+	r.mu.Unlock()
+	return s, nil
+}
+
+// indexedTags returns the tags of the measure indexed by the inverted index rules in effect.
+func (r *prometheusSchemaRepo) indexedTags(ctx context.Context, metric string) (map[string]bool, error) {
+	bindings, err := r.bindingClient.List(ctx, &databasev1.IndexRuleBindingRegistryServiceListRequest{Group: r.group})
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	ruleNames := make(map[string]bool)
+	for _, b := range bindings.GetIndexRuleBinding() {
+		if b.GetSubject().GetCatalog() != commonv1.Catalog_CATALOG_MEASURE || b.GetSubject().GetName() != metric {
+			continue
+		}
+		if now.Before(b.GetBeginAt().AsTime()) || !now.Before(b.GetExpireAt().AsTime()) {
+			continue
+		}
+		for _, name := range b.GetRules() {
+			ruleNames[name] = true
+		}
+	}
+	indexed := make(map[string]bool)
+	if len(ruleNames) == 0 {
+		return indexed, nil
+	}
+	rules, err := r.ruleClient.List(ctx, &databasev1.IndexRuleRegistryServiceListRequest{Group: r.group})
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range rules.GetIndexRule() {
+		if !ruleNames[rule.GetMetadata().GetName()] || rule.GetType() != databasev1.IndexRule_TYPE_INVERTED || len(rule.GetTags()) != 1 {
+			continue
+		}
+		if analyzer := rule.GetAnalyzer(); analyzer != "" && analyzer != "keyword" {
+			continue
+		}
+		indexed[rule.GetTags()[0]] = true
+	}
+	return indexed, nil
+}
+
+// createMeasure registers the measure of the metric.
+// The series are identified by all their labels, which are held by the series tag.
+// The labels are indexed to be filtered by the matchers.
+func (r *prometheusSchemaRepo) createMeasure(ctx context.Context, metric string, labels []string) error {
+	tags := []*databasev1.TagSpec{{Name: prometheusSeriesTag, Type: databasev1.TagType_TAG_TYPE_STRING}}
+	for _, l := range labels {
+		tags = append(tags, &databasev1.TagSpec{Name: l, Type: databasev1.TagType_TAG_TYPE_STRING})
+	}
+	measure := &databasev1.Measure{
+		Metadata:    &commonv1.Metadata{Group: r.group, Name: metric},
+		TagFamilies: []*databasev1.TagFamilySpec{{Name: prometheusTagFamily, Tags: tags}},
+		Fields: []*databasev1.FieldSpec{{
+			Name:              prometheusValueField,
+			FieldType:         databasev1.FieldType_FIELD_TYPE_FLOAT,
+			EncodingMethod:    databasev1.EncodingMethod_ENCODING_METHOD_GORILLA,
+			CompressionMethod: databasev1.CompressionMethod_COMPRESSION_METHOD_ZSTD,
+		}},
+		Entity: &databasev1.Entity{TagNames: []string{prometheusSeriesTag}},
+	}
+	if err := r.bindLabels(ctx, metric, labels); err != nil {
+		return err
+	}
+	_, err := r.measureClient.Create(ctx, &databasev1.MeasureRegistryServiceCreateRequest{Measure: measure})
+	if status.Code(err) == codes.AlreadyExists {
+		return nil
+	}
+	return err
+}
+
+// appendTags appends the tags of the labels to the measure.
+func (r *prometheusSchemaRepo) appendTags(ctx context.Context, measure *databasev1.Measure, labels []string) error {
+	measure = proto.Clone(measure).(*databasev1.Measure)
+	family := len(measure.GetTagFamilies()) - 1
+	for i, f := range measure.GetTagFamilies() {
+		if f.GetName() == prometheusTagFamily {
+			family = i
+		}
+	}
+	for _, l := range labels {
+		measure.TagFamilies[family].Tags = append(measure.TagFamilies[family].Tags,
+			&databasev1.TagSpec{Name: l, Type: databasev1.TagType_TAG_TYPE_STRING})
+	}
+	if err := r.bindLabels(ctx, measure.GetMetadata().GetName(), labels); err != nil {
+		return err
+	}
+	_, err := r.measureClient.Update(ctx, &databasev1.MeasureRegistryServiceUpdateRequest{Measure: measure})
+	return err
+}
+
+// bindLabels creates the index rules of the labels and binds them to the measure.
+func (r *prometheusSchemaRepo) bindLabels(ctx context.Context, metric string, labels []string) error {
+	rules := make([]string, 0, len(labels))
+	for _, l := range labels {
+		name := prometheusIndexRulePrefix + l
+		_, err := r.ruleClient.Create(ctx, &databasev1.IndexRuleRegistryServiceCreateRequest{IndexRule: &databasev1.IndexRule{
+			Metadata: &commonv1.Metadata{Group: r.group, Name: name},
+			Tags:     []string{l},
+			Type:     databasev1.IndexRule_TYPE_INVERTED,
+		}})
+		if err != nil && status.Code(err) != codes.AlreadyExists {
+			return err
+		}
+		rules = append(rules, name)
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	metadata := &commonv1.Metadata{Group: r.group, Name: metric}
+	existing, err := r.bindingClient.Get(ctx, &databasev1.IndexRuleBindingRegistryServiceGetRequest{Metadata: metadata})
+	if err != nil && status.Code(err) != codes.NotFound {
+		return err
+	}
+	if err == nil {
+		binding := proto.Clone(existing.GetIndexRuleBinding()).(*databasev1.IndexRuleBinding)
+		binding.Rules = mergeRuleNames(binding.GetRules(), rules)
+		_, err = r.bindingClient.Update(ctx, &databasev1.IndexRuleBindingRegistryServiceUpdateRequest{IndexRuleBinding: binding})
+		return err
+	}
+	now := time.Now()
+	_, err = r.bindingClient.Create(ctx, &databasev1.IndexRuleBindingRegistryServiceCreateRequest{IndexRuleBinding: &databasev1.IndexRuleBinding{
+		Metadata: metadata,
+		Rules:    rules,
+		Subject:  &databasev1.Subject{Catalog: commonv1.Catalog_CATALOG_MEASURE, Name: metric},
+		BeginAt:  timestamppb.New(now),
+		ExpireAt: timestamppb.New(now.AddDate(1000, 0, 0)),
+	}})
+	if status.Code(err) == codes.AlreadyExists {
+		return nil
+	}
+	return err
+}
+
+func mergeRuleNames(existing, added []string) []string {
+	set := make(map[string]bool, len(existing)+len(added))
+	for _, n := range existing {
+		set[n] = true
+	}
+	result := existing
+	for _, n := range added {
+		if !set[n] {
+			set[n] = true
+			result = append(result, n)
+		}
+	}
+	sort.Strings(result[len(existing):])
+	return result
+}
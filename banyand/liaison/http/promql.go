@@ -0,0 +1,515 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/prometheus/prompb"
+	"github.com/apache/skywalking-banyandb/pkg/prometheus/promql"
+)
+
+const (
+	promqlQueryPath      = "/api/v1/query"
+	promqlQueryRangePath = "/api/v1/query_range"
+
+	// promqlLookback is how far the instant vector selectors look back for the latest samples.
+	promqlLookback = 5 * time.Minute
+	// promqlMaxPoints limits the number of steps of a range query.
+	promqlMaxPoints = 11000
+
+	promqlAggSum   = "sum"
+	promqlAggCount = "count"
+	promqlAggMin   = "min"
+	promqlAggMax   = "max"
+)
+
+var (
+	errPromQLParameter = errors.New("invalid parameter")
+	errPromQLPushDown  = errors.New("the aggregation can't be pushed down")
+)
+
+// promqlRange is the evaluation timestamps of a query in milliseconds.
+type promqlRange struct {
+	steps []int64
+	start int64
+	step  int64
+}
+
+// bucketSeries is the buckets of a series or a group of series aggregated by the measure.
+type bucketSeries struct {
+	labels  []prompb.Label
+	buckets []promql.Bucket
+}
+
+type promqlResponse struct {
+	Data      *promqlData `json:"data,omitempty"`
+	Status    string      `json:"status"`
+	ErrorType string      `json:"errorType,omitempty"`
+	Error     string      `json:"error,omitempty"`
+}
+
+type promqlData struct {
+	ResultType string         `json:"resultType"`
+	Result     []promqlSeries `json:"result"`
+}
+
+type promqlSeries struct {
+	Metric map[string]string `json:"metric"`
+	Value  []interface{}     `json:"value,omitempty"`
+	Values [][]interface{}   `json:"values,omitempty"`
+}
+
+// Query evaluates an instant query of the PromQL subset.
+func (h *prometheusHandler) Query(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		h.writePromQLError(w, errors.WithMessage(errPromQLParameter, err.Error()))
+		return
+	}
+	t, err := parsePromQLTime(r.Form.Get("time"), time.Now())
+	if err != nil {
+		h.writePromQLError(w, err)
+		return
+	}
+	series, err := h.evalPromQL(r, promqlRange{start: t, steps: []int64{t}})
+	if err != nil {
+		h.writePromQLError(w, err)
+		return
+	}
+	result := make([]promqlSeries, 0, len(series))
+	for _, s := range series {
+		if len(s.Points) == 0 {
+			continue
+		}
+		result = append(result, promqlSeries{Metric: promqlMetric(s.Labels), Value: promqlPoint(s.Points[len(s.Points)-1])})
+	}
+	h.writePromQL(w, http.StatusOK, &promqlResponse{Status: "success", Data: &promqlData{ResultType: "vector", Result: result}})
+}
+
+// QueryRange evaluates a range query of the PromQL subset.
+func (h *prometheusHandler) QueryRange(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		h.writePromQLError(w, errors.WithMessage(errPromQLParameter, err.Error()))
+		return
+	}
+	start, err := parsePromQLTime(r.Form.Get("start"), time.Time{})
+	if err != nil {
+		h.writePromQLError(w, err)
+		return
+	}
+	end, err := parsePromQLTime(r.Form.Get("end"), time.Time{})
+	if err != nil {
+		h.writePromQLError(w, err)
+		return
+	}
+	step, err := parsePromQLStep(r.Form.Get("step"))
+	if err != nil {
+		h.writePromQLError(w, err)
+		return
+	}
+	if end < start {
+		h.writePromQLError(w, errors.WithMessage(errPromQLParameter, "end should not be before start"))
+		return
+	}
+	if (end-start)/step >= promqlMaxPoints {
+		h.writePromQLError(w, errors.WithMessagef(errPromQLParameter, "exceed the maximum resolution of %d points per series", promqlMaxPoints))
+		return
+	}
+	series, err := h.evalPromQL(r, promqlRange{start: start, step: step, steps: promql.Steps(start, end, step)})
+	if err != nil {
+		h.writePromQLError(w, err)
+		return
+	}
+	result := make([]promqlSeries, 0, len(series))
+	for _, s := range series {
+		if len(s.Points) == 0 {
+			continue
+		}
+		ps := promqlSeries{Metric: promqlMetric(s.Labels), Values: make([][]interface{}, 0, len(s.Points))}
+		for _, p := range s.Points {
+			ps.Values = append(ps.Values, promqlPoint(p))
+		}
+		result = append(result, ps)
+	}
+	h.writePromQL(w, http.StatusOK, &promqlResponse{Status: "success", Data: &promqlData{ResultType: "matrix", Result: result}})
+}
+
+func (h *prometheusHandler) evalPromQL(r *http.Request, rng promqlRange) ([]promql.Series, error) {
+	query := r.Form.Get("query")
+	if query == "" {
+		return nil, errors.WithMessage(errPromQLParameter, "the query is absent")
+	}
+	expr, err := promql.Parse(query)
+	if err != nil {
+		return nil, err
+	}
+	series, err := h.eval(h.outgoingContext(r), expr, rng)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(series, func(i, j int) bool {
+		return prompb.LabelsString(series[i].Labels) < prompb.LabelsString(series[j].Labels)
+	})
+	return series, nil
+}
+
+// eval evaluates the expression at the steps.
+// The selectors are compiled to the measure queries, and the range functions are compiled to the aggregations
+// of the samples in the time buckets, which are merged into the windows of the steps.
+// rate and increase are evaluated on the samples, since the counter resets and the extrapolation depend on their order.
+// The aggregations across the series are pushed down too if they are equivalent to aggregating all samples.
+func (h *prometheusHandler) eval(ctx context.Context, expr promql.Expr, rng promqlRange) ([]promql.Series, error) {
+	switch e := expr.(type) {
+	case *promql.VectorSelector:
+		return h.evalSelector(ctx, e, rng)
+	case *promql.Call:
+		if e.Func == promql.FuncRate || e.Func == promql.FuncIncrease {
+			return h.evalCounter(ctx, e, rng)
+		}
+		buckets, err := h.queryBuckets(ctx, e.Arg, e.Func, nil, rng)
+		if err != nil {
+			return nil, err
+		}
+		return evalBuckets(e.Func, e.Arg.Range, buckets, rng), nil
+	case *promql.AggregateExpr:
+		if f, ok := e.PushDownFunction(); ok {
+			call := e.Expr.(*promql.Call)
+			buckets, err := h.queryBuckets(ctx, call.Arg, f, e.Grouping, rng)
+			if err == nil {
+				return evalBuckets(f, call.Arg.Range, buckets, rng), nil
+			}
+			if !errors.Is(err, errPromQLPushDown) {
+				return nil, err
+			}
+		}
+		inner, err := h.eval(ctx, e.Expr, rng)
+		if err != nil {
+			return nil, err
+		}
+		return promql.Aggregate(e.Op, e.Param, e.Grouping, inner), nil
+	}
+	return nil, errors.Errorf("unsupported expression %T", expr)
+}
+
+func evalBuckets(f promql.Function, rng time.Duration, buckets []bucketSeries, r promqlRange) []promql.Series {
+	series := make([]promql.Series, 0, len(buckets))
+	for _, b := range buckets {
+		points := promql.EvalRange(f, rng.Milliseconds(), b.buckets, r.steps)
+		if len(points) > 0 {
+			series = append(series, promql.Series{Labels: b.labels, Points: points})
+		}
+	}
+	return series
+}
+
+// evalSelector selects the latest sample of each series at the steps.
+func (h *prometheusHandler) evalSelector(ctx context.Context, sel *promql.VectorSelector, rng promqlRange) ([]promql.Series, error) {
+	matchers := append([]prompb.LabelMatcher{{Type: prompb.MatchEqual, Name: prompb.MetricNameLabel, Value: sel.Name}}, sel.Matchers...)
+	timeseries, err := h.query(ctx, prompb.Query{
+		Matchers:         matchers,
+		StartTimestampMs: rng.steps[0] - promqlLookback.Milliseconds() + 1,
+		EndTimestampMs:   rng.steps[len(rng.steps)-1],
+	})
+	if err != nil {
+		return nil, err
+	}
+	series := make([]promql.Series, 0, len(timeseries))
+	for _, ts := range timeseries {
+		if points := promql.SelectInstant(promqlSamples(ts), rng.steps, promqlLookback.Milliseconds()); len(points) > 0 {
+			series = append(series, promql.Series{Labels: ts.Labels, Points: points})
+		}
+	}
+	return series, nil
+}
+
+// evalCounter evaluates rate or increase on the samples of each series in the windows of the steps.
+func (h *prometheusHandler) evalCounter(ctx context.Context, call *promql.Call, rng promqlRange) ([]promql.Series, error) {
+	sel := call.Arg
+	matchers := append([]prompb.LabelMatcher{{Type: prompb.MatchEqual, Name: prompb.MetricNameLabel, Value: sel.Name}}, sel.Matchers...)
+	// The window of a step t is [t-range, t).
+	timeseries, err := h.query(ctx, prompb.Query{
+		Matchers:         matchers,
+		StartTimestampMs: rng.steps[0] - sel.Range.Milliseconds(),
+		EndTimestampMs:   rng.steps[len(rng.steps)-1] - 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	series := make([]promql.Series, 0, len(timeseries))
+	for _, ts := range timeseries {
+		if points := promql.EvalCounter(call.Func, sel.Range.Milliseconds(), promqlSamples(ts), rng.steps); len(points) > 0 {
+			series = append(series, promql.Series{Labels: promql.DropMetricName(ts.Labels), Points: points})
+		}
+	}
+	return series, nil
+}
+
+func promqlSamples(ts prompb.TimeSeries) []promql.Point {
+	samples := make([]promql.Point, len(ts.Samples))
+	for i, s := range ts.Samples {
+		samples[i] = promql.Point{T: s.Timestamp, V: s.Value}
+	}
+	return samples
+}
+
+// queryBuckets aggregates the samples of the range vector selector in the time buckets.
+// The samples are grouped by the entity of the measure, which identifies a series.
+// If the grouping is set, the samples are grouped by the tags of the grouping labels instead,
+// which requires all matchers to be evaluated by the measure.
+func (h *prometheusHandler) queryBuckets(ctx context.Context, sel *promql.VectorSelector, f promql.Function,
+	grouping []string, rng promqlRange,
+) ([]bucketSeries, error) {
+	s, err := h.schemaRepo.get(ctx, sel.Name)
+	if errors.Is(err, errPrometheusMetricNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	criteria, pushed := prometheusCriteria(s, sel.Matchers)
+	groupTags := s.measure.GetEntity().GetTagNames()
+	if grouping != nil {
+		groupTags = nil
+		for _, g := range grouping {
+			if _, ok := s.tags[g]; ok {
+				groupTags = append(groupTags, g)
+			}
+		}
+		if !pushed || len(groupTags) == 0 {
+			return nil, errPromQLPushDown
+		}
+	}
+
+	bucket, aligned := promql.BucketSize(rng.start, rng.step, sel.Range.Milliseconds())
+	if !aligned {
+		if grouping != nil {
+			return nil, errors.WithMessagef(errPromQLPushDown, "the start %d isn't aligned to the bucket of %dms", rng.start, bucket)
+		}
+		return h.querySampleBuckets(ctx, sel, rng)
+	}
+
+	// The window of a step t is [t-range, t).
+	begin := rng.steps[0] - sel.Range.Milliseconds()
+	end := rng.steps[len(rng.steps)-1]
+	queryReq := &measurev1.QueryRequest{
+		Groups: []string{h.group},
+		Name:   sel.Name,
+		TimeRange: &modelv1.TimeRange{
+			Begin: timestamppb.New(time.UnixMilli(begin)),
+			End:   timestamppb.New(time.UnixMilli(end)),
+		},
+		Criteria:        criteria,
+		TagProjection:   s.tagProjection(nil),
+		FieldProjection: &measurev1.QueryRequest_FieldProjection{Names: []string{prometheusValueField}},
+		GroupBy: &measurev1.QueryRequest_GroupBy{
+			TagProjection: s.tagProjection(groupTags),
+			FieldName:     prometheusValueField,
+			TimeBucket:    (time.Duration(bucket) * time.Millisecond).String(),
+		},
+		Aggs:  bucketAggregations(f),
+		Limit: h.maxSamples + 1,
+	}
+	if grouping != nil {
+		queryReq.TagProjection = queryReq.GroupBy.TagProjection
+	}
+	resp, err := h.measureClient.Query(ctx, queryReq)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.GetDataPoints()) > int(h.maxSamples) {
+		return nil, errors.WithMessagef(errPrometheusSampleLimit, "limit: %d", h.maxSamples)
+	}
+
+	matchers := make([]func(string) bool, len(sel.Matchers))
+	for i, m := range sel.Matchers {
+		if matchers[i], err = m.Matcher(); err != nil {
+			return nil, err
+		}
+	}
+	seriesMap := make(map[string]*bucketSeries)
+	for _, dp := range resp.GetDataPoints() {
+		var labels []prompb.Label
+		for _, tf := range dp.GetTagFamilies() {
+			for _, t := range tf.GetTags() {
+				if t.GetKey() == prometheusSeriesTag {
+					continue
+				}
+				if v, ok := prometheusLabelValue(t.GetValue()); ok {
+					labels = append(labels, prompb.Label{Name: t.GetKey(), Value: v})
+				}
+			}
+		}
+		if grouping != nil {
+			labels = promql.GroupLabels(labels, grouping)
+		} else if !matchLabels(sel.Matchers, matchers, labels) {
+			continue
+		}
+		prompb.SortLabels(labels)
+		b := promql.Bucket{T: dp.GetTimestamp().AsTime().UnixMilli()}
+		for _, field := range dp.GetFields() {
+			v := promqlFieldValue(field.GetValue())
+			switch field.GetName() {
+			case promqlAggSum:
+				b.Sum = v
+			case promqlAggCount:
+				b.Count = v
+			case promqlAggMin:
+				b.Min = v
+			case promqlAggMax:
+				b.Max = v
+			}
+		}
+		key := prompb.LabelsString(labels)
+		bs, ok := seriesMap[key]
+		if !ok {
+			bs = &bucketSeries{labels: labels}
+			seriesMap[key] = bs
+		}
+		bs.buckets = append(bs.buckets, b)
+	}
+	result := make([]bucketSeries, 0, len(seriesMap))
+	for _, bs := range seriesMap {
+		sort.Slice(bs.buckets, func(i, j int) bool { return bs.buckets[i].T < bs.buckets[j].T })
+		result = append(result, *bs)
+	}
+	return result, nil
+}
+
+// querySampleBuckets returns a bucket of each sample of the range vector selector,
+// which is used if the buckets aggregated by the measure can't compose the windows of the steps.
+func (h *prometheusHandler) querySampleBuckets(ctx context.Context, sel *promql.VectorSelector, rng promqlRange) ([]bucketSeries, error) {
+	matchers := append([]prompb.LabelMatcher{{Type: prompb.MatchEqual, Name: prompb.MetricNameLabel, Value: sel.Name}}, sel.Matchers...)
+	// The window of a step t is [t-range, t).
+	timeseries, err := h.query(ctx, prompb.Query{
+		Matchers:         matchers,
+		StartTimestampMs: rng.steps[0] - sel.Range.Milliseconds(),
+		EndTimestampMs:   rng.steps[len(rng.steps)-1] - 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]bucketSeries, 0, len(timeseries))
+	for _, ts := range timeseries {
+		result = append(result, bucketSeries{labels: promql.DropMetricName(ts.Labels), buckets: promql.SampleBuckets(promqlSamples(ts))})
+	}
+	return result, nil
+}
+
+// bucketAggregations returns the aggregations of the buckets required by the function.
+// The count is always required to tell the empty windows.
+func bucketAggregations(f promql.Function) []*measurev1.QueryRequest_Aggregation {
+	agg := func(name string, af modelv1.AggregationFunction) *measurev1.QueryRequest_Aggregation {
+		return &measurev1.QueryRequest_Aggregation{Function: af, FieldName: prometheusValueField, Name: name}
+	}
+	aggs := []*measurev1.QueryRequest_Aggregation{agg(promqlAggCount, modelv1.AggregationFunction_AGGREGATION_FUNCTION_COUNT)}
+	switch f {
+	case promql.FuncAvgOverTime, promql.FuncSumOverTime:
+		aggs = append(aggs, agg(promqlAggSum, modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM))
+	case promql.FuncMinOverTime:
+		aggs = append(aggs, agg(promqlAggMin, modelv1.AggregationFunction_AGGREGATION_FUNCTION_MIN))
+	case promql.FuncMaxOverTime:
+		aggs = append(aggs, agg(promqlAggMax, modelv1.AggregationFunction_AGGREGATION_FUNCTION_MAX))
+	}
+	return aggs
+}
+
+func promqlFieldValue(v *modelv1.FieldValue) float64 {
+	switch x := v.GetValue().(type) {
+	case *modelv1.FieldValue_Float:
+		return x.Float.GetValue()
+	case *modelv1.FieldValue_Int:
+		return float64(x.Int.GetValue())
+	}
+	return math.NaN()
+}
+
+func parsePromQLTime(s string, defaultValue time.Time) (int64, error) {
+	if s == "" {
+		if defaultValue.IsZero() {
+			return 0, errors.WithMessage(errPromQLParameter, "the time is absent")
+		}
+		return defaultValue.UnixMilli(), nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return int64(math.Round(f * 1000)), nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return 0, errors.WithMessagef(errPromQLParameter, "invalid time %q", s)
+	}
+	return t.UnixMilli(), nil
+}
+
+func parsePromQLStep(s string) (int64, error) {
+	var step int64
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		step = int64(math.Round(f * 1000))
+	} else if d, errDuration := promql.ParseDuration(s); errDuration == nil {
+		step = d.Milliseconds()
+	} else {
+		return 0, errors.WithMessagef(errPromQLParameter, "invalid step %q", s)
+	}
+	if step <= 0 {
+		return 0, errors.WithMessage(errPromQLParameter, "the step should be positive")
+	}
+	return step, nil
+}
+
+func promqlMetric(labels []prompb.Label) map[string]string {
+	metric := make(map[string]string, len(labels))
+	for _, l := range labels {
+		metric[l.Name] = l.Value
+	}
+	return metric
+}
+
+func promqlPoint(p promql.Point) []interface{} {
+	return []interface{}{float64(p.T) / 1000, strconv.FormatFloat(p.V, 'f', -1, 64)}
+}
+
+func (h *prometheusHandler) writePromQLError(w http.ResponseWriter, err error) {
+	code, errorType := http.StatusUnprocessableEntity, "execution"
+	if errors.Is(err, promql.ErrParse) || errors.Is(err, errPromQLParameter) || errors.Is(err, errPrometheusMatcher) {
+		code, errorType = http.StatusBadRequest, "bad_data"
+	}
+	h.writePromQL(w, code, &promqlResponse{Status: "error", ErrorType: errorType, Error: err.Error()})
+}
+
+func (h *prometheusHandler) writePromQL(w http.ResponseWriter, code int, resp *promqlResponse) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if _, err = w.Write(data); err != nil {
+		h.l.Error().Err(err).Msg("failed to write the PromQL response")
+	}
+}
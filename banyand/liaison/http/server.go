@@ -32,17 +32,20 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/pkg/errors"
+	collectortracev1 "go.opentelemetry.io/proto/otlp/collector/trace/v1"
 	"go.uber.org/multierr"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/health/grpc_health_v1"
 
+	bydbqlv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/bydbql/v1"
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
 	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
 	propertyv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/property/v1"
 	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	tracev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/trace/v1"
 	"github.com/apache/skywalking-banyandb/banyand/liaison/pkg/auth"
 	"github.com/apache/skywalking-banyandb/pkg/healthcheck"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
@@ -57,6 +60,7 @@ var (
 	errServerCert = errors.New("http: invalid server cert file")
 	errServerKey  = errors.New("http: invalid server key file")
 	errNoAddr     = errors.New("http: no address")
+	errMaxSamples = errors.New("http: the maximum number of samples of the Prometheus queries should be positive")
 )
 
 // NewServer return a http service.
@@ -92,9 +96,12 @@ type server struct {
 	certFile        string
 	cfg             *auth.Config
 	grpcCert        string
+	promGroup       string
 	grpcMu          sync.Mutex
 	port            uint32
+	promMaxSamples  uint32
 	tls             bool
+	promAutoReg     bool
 }
 
 func (p *server) FlagSet() *run.FlagSet {
@@ -106,6 +113,11 @@ func (p *server) FlagSet() *run.FlagSet {
 	flagSet.StringVar(&p.keyFile, "http-key-file", "", "the TLS key file of http server")
 	flagSet.StringVar(&p.grpcCert, "http-grpc-cert-file", "", "the grpc TLS cert file if grpc server enables tls")
 	flagSet.BoolVar(&p.tls, "http-tls", false, "connection uses TLS if true, else plain HTTP")
+	flagSet.StringVar(&p.promGroup, "prometheus-group", "",
+		"the measure group storing the metrics received by the Prometheus remote write, which enables the Prometheus endpoints if set")
+	flagSet.BoolVar(&p.promAutoReg, "prometheus-auto-register", false,
+		"register the measures and the tags of the absent metrics and labels received by the Prometheus remote write")
+	flagSet.Uint32Var(&p.promMaxSamples, "prometheus-read-max-samples", 1000000, "the maximum number of samples selected by a Prometheus query")
 	return flagSet
 }
 
@@ -114,6 +126,9 @@ func (p *server) Validate() error {
 	if p.listenAddr == ":" {
 		return errNoAddr
 	}
+	if p.promGroup != "" && p.promMaxSamples == 0 {
+		return errMaxSamples
+	}
 	if !p.tls {
 		return nil
 	}
@@ -341,6 +356,18 @@ func (p *server) initGRPCClient() error {
 	}
 	p.grpcClient.Store(client)
 
+	// The connection is shared by the handlers translating the other protocols to gRPC
+	conn, err := grpc.NewClient(p.grpcAddr, opts...)
+	if err != nil {
+		return errors.Wrap(err, "failed to create gRPC client")
+	}
+	go func() {
+		<-ctx.Done()
+		if cerr := conn.Close(); cerr != nil {
+			p.l.Info().Str("addr", p.grpcAddr).Err(cerr).Msg("Failed to close gRPC conn")
+		}
+	}()
+
 	// Create gateway mux with health endpoint
 	p.gwMux = runtime.NewServeMux()
 
@@ -354,11 +381,14 @@ func (p *server) initGRPCClient() error {
 		databasev1.RegisterGroupRegistryServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
 		databasev1.RegisterTopNAggregationRegistryServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
 		databasev1.RegisterSnapshotServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
+		databasev1.RegisterClusterServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
 		databasev1.RegisterPropertyRegistryServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
 		streamv1.RegisterStreamServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
 		measurev1.RegisterMeasureServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
 		propertyv1.RegisterPropertyServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
 		databasev1.RegisterTraceRegistryServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
+		tracev1.RegisterTraceServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
+		bydbqlv1.RegisterBydbQLServiceHandlerFromEndpoint(p.grpcCtx, p.gwMux, p.grpcAddr, opts),
 	)
 	if err != nil {
 		return errors.Wrap(err, "failed to register endpoints")
@@ -389,6 +419,30 @@ func (p *server) initGRPCClient() error {
 		}
 	}))
 
+	newMux.Method(http.MethodPost, otlpTracesPath, &otlpTracesHandler{client: collectortracev1.NewTraceServiceClient(conn), l: p.l})
+	if p.promGroup != "" {
+		prom := &prometheusHandler{
+			measureClient: measurev1.NewMeasureServiceClient(conn),
+			schemaRepo: &prometheusSchemaRepo{
+				measureClient: databasev1.NewMeasureRegistryServiceClient(conn),
+				ruleClient:    databasev1.NewIndexRuleRegistryServiceClient(conn),
+				bindingClient: databasev1.NewIndexRuleBindingRegistryServiceClient(conn),
+				cache:         make(map[string]*prometheusSchema),
+				group:         p.promGroup,
+				autoRegister:  p.promAutoReg,
+			},
+			l:          p.l,
+			group:      p.promGroup,
+			maxSamples: p.promMaxSamples,
+		}
+		newMux.Post(prometheusWritePath, prom.Write)
+		newMux.Post(prometheusReadPath, prom.Read)
+		newMux.Get(promqlQueryPath, prom.Query)
+		newMux.Post(promqlQueryPath, prom.Query)
+		newMux.Get(promqlQueryRangePath, prom.QueryRange)
+		newMux.Post(promqlQueryRangePath, prom.QueryRange)
+	}
+
 	// Mount the gateway mux to the HTTP server
 	newMux.Mount("/api", http.StripPrefix("/api", p.gwMux))
 
@@ -19,25 +19,41 @@
 package auth
 
 import (
-	"crypto/subtle"
 	"fmt"
 	"os"
-	"strings"
+	"path/filepath"
+	"sync"
 
 	"sigs.k8s.io/yaml"
 )
 
 // Config AuthConfig.
+//
+// The users, roles and providers are replaced as a whole when the config file is reloaded,
+// so they must be read with the read lock held.
 type Config struct {
 	Users             []User `yaml:"users"`
-	Enabled           bool   `yaml:"-"`
-	HealthAuthEnabled bool   `yaml:"-"`
+	Roles             []Role `yaml:"roles"`
+	providers         []Provider
+	jwksFile          string
+	mu                sync.RWMutex
+	Enabled           bool `yaml:"-"`
+	HealthAuthEnabled bool `yaml:"-"`
 }
 
 // User details from config file.
+// The password is either a bcrypt or argon2id hash, or a plaintext password.
 type User struct {
-	Username string `yaml:"username"`
-	Password string `yaml:"password"`
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	Roles    []string `yaml:"roles"`
+}
+
+type fileConfig struct {
+	JWT     *JWTConfig `yaml:"jwt"`
+	Users   []User     `yaml:"users"`
+	Roles   []Role     `yaml:"roles"`
+	APIKeys []APIKey   `yaml:"api_keys" json:"api_keys"`
 }
 
 // InitCfg returns Config with default values.
@@ -46,13 +62,13 @@ func InitCfg() *Config {
 		Enabled:           false,
 		HealthAuthEnabled: false,
 		Users:             []User{},
+		Roles:             []Role{},
 	}
 }
 
 // LoadConfig implements the reading of the authentication configuration.
+// The users, roles and providers are replaced atomically, and the config is left untouched if the file is invalid.
 func LoadConfig(cfg *Config, filePath string) error {
-	cfg.Enabled = true
-
 	info, err := os.Stat(filePath)
 	if err != nil {
 		return err
@@ -66,40 +82,59 @@ func LoadConfig(cfg *Config, filePath string) error {
 	if err != nil {
 		return err
 	}
-	err = yaml.Unmarshal(data, cfg)
+	var fc fileConfig
+	err = yaml.Unmarshal(data, &fc)
 	if err != nil {
 		return err
 	}
+	if err = validateAPIKeys(fc.APIKeys); err != nil {
+		return err
+	}
+	if err = validateRoles(&fc); err != nil {
+		return err
+	}
+	providers, err := newProviders(&fc, filepath.Dir(filePath))
+	if err != nil {
+		return err
+	}
+	var jwksFile string
+	if fc.JWT != nil {
+		jwksFile = resolveJWKSFile(*fc.JWT, filepath.Dir(filePath))
+	}
+
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	if !cfg.Enabled {
+		cfg.Enabled = true
+	}
+	cfg.Users = fc.Users
+	cfg.Roles = fc.Roles
+	// The credentials verified by the previous providers might be revoked, so their caches are dropped along with them.
+	cfg.providers = providers
+	cfg.jwksFile = jwksFile
 	return nil
 }
 
-// CheckUsernameAndPassword returns true if the provided username and password match any configured user.
-func CheckUsernameAndPassword(cfg *Config, username, password string) bool {
-	username = strings.TrimSpace(username)
-	password = strings.TrimSpace(password)
-
-	for _, user := range cfg.Users {
-		storedUsername := strings.TrimSpace(user.Username)
-		storedPassword := strings.TrimSpace(user.Password)
-
-		// Convert to []byte
-		usernameBytes := []byte(username)
-		storedUsernameBytes := []byte(storedUsername)
-		passwordBytes := []byte(password)
-		storedPasswordBytes := []byte(storedPassword)
-
-		// Length must match
-		if len(usernameBytes) != len(storedUsernameBytes) || len(passwordBytes) != len(storedPasswordBytes) {
-			continue
-		}
+// referredFiles returns the files the config refers to, which are reloaded along with the config file.
+func referredFiles(cfg *Config) []string {
+	cfg.mu.RLock()
+	defer cfg.mu.RUnlock()
+	if cfg.jwksFile == "" {
+		return nil
+	}
+	return []string{cfg.jwksFile}
+}
 
-		// Use constant-time comparison
-		usernameMatch := subtle.ConstantTimeCompare(usernameBytes, storedUsernameBytes) == 1
-		passwordMatch := subtle.ConstantTimeCompare(passwordBytes, storedPasswordBytes) == 1
+// IsEnabled returns true if the authentication is enabled, which happens once a config file is loaded.
+// The config might be loaded after the servers start, so the flag must be read with the read lock held.
+func IsEnabled(cfg *Config) bool {
+	cfg.mu.RLock()
+	defer cfg.mu.RUnlock()
+	return cfg.Enabled
+}
 
-		if usernameMatch && passwordMatch {
-			return true
-		}
-	}
-	return false
+// CheckUsernameAndPassword returns true if the provided username and password match any configured user.
+func CheckUsernameAndPassword(cfg *Config, username, password string) bool {
+	identity, err := Authenticate(cfg, Credentials{Username: username, Password: password})
+	return err == nil && identity != nil
 }
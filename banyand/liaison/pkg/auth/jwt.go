@@ -0,0 +1,290 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	defaultUsernameClaim = "sub"
+	defaultRolesClaim    = "roles"
+)
+
+// validSigningMethods are the asymmetric algorithms a token can be signed with.
+// Symmetric algorithms and "none" are rejected, since the keys are public.
+var validSigningMethods = []string{
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"EdDSA",
+}
+
+// JWTConfig configures the verification of JSON Web Tokens presented as bearer tokens.
+type JWTConfig struct {
+	// JWKSFile is the path of the JSON Web Key Set holding the public keys. A relative path is resolved
+	// against the directory of the config file.
+	JWKSFile string `yaml:"jwks_file" json:"jwks_file"`
+	// Issuer is the required "iss" claim. It's not checked if empty.
+	Issuer string `yaml:"issuer" json:"issuer"`
+	// Audience is the required "aud" claim. It's not checked if empty.
+	Audience string `yaml:"audience" json:"audience"`
+	// UsernameClaim is the claim naming the client, "sub" by default.
+	UsernameClaim string `yaml:"username_claim" json:"username_claim"`
+	// RolesClaim is the claim listing the roles of the client, "roles" by default.
+	// A nested claim is referred to by a dot-separated path, like "realm_access.roles".
+	RolesClaim string `yaml:"roles_claim" json:"roles_claim"`
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type publicKey struct {
+	key crypto.PublicKey
+	kid string
+	alg string
+}
+
+type jwtProvider struct {
+	parser *jwt.Parser
+	cfg    JWTConfig
+	keys   []publicKey
+}
+
+func newJWTProvider(cfg JWTConfig, dir string) (*jwtProvider, error) {
+	if cfg.JWKSFile == "" {
+		return nil, errors.New("jwks_file of jwt is empty")
+	}
+	if cfg.UsernameClaim == "" {
+		cfg.UsernameClaim = defaultUsernameClaim
+	}
+	if cfg.RolesClaim == "" {
+		cfg.RolesClaim = defaultRolesClaim
+	}
+	jwksFile := resolveJWKSFile(cfg, dir)
+	data, err := os.ReadFile(jwksFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the JWKS file: %w", err)
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the JWKS file %s: %w", jwksFile, err)
+	}
+	return &jwtProvider{
+		cfg:    cfg,
+		keys:   keys,
+		parser: jwt.NewParser(jwt.WithValidMethods(validSigningMethods)),
+	}, nil
+}
+
+// resolveJWKSFile returns the path of the JWKS file, which is relative to the directory of the config file.
+func resolveJWKSFile(cfg JWTConfig, dir string) string {
+	if filepath.IsAbs(cfg.JWKSFile) {
+		return cfg.JWKSFile
+	}
+	return filepath.Join(dir, cfg.JWKSFile)
+}
+
+func (p *jwtProvider) Authenticate(creds Credentials) (*Identity, error) {
+	if creds.BearerToken == "" {
+		return nil, ErrCredentialsNotFound
+	}
+	claims := jwt.MapClaims{}
+	token, err := p.parser.ParseWithClaims(creds.BearerToken, claims, p.lookupKey)
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidCredentials
+	}
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return nil, ErrInvalidCredentials
+	}
+	if p.cfg.Issuer != "" && !claims.VerifyIssuer(p.cfg.Issuer, true) {
+		return nil, ErrInvalidCredentials
+	}
+	if p.cfg.Audience != "" && !claims.VerifyAudience(p.cfg.Audience, true) {
+		return nil, ErrInvalidCredentials
+	}
+	name, _ := lookupClaim(claims, p.cfg.UsernameClaim).(string)
+	if name == "" {
+		return nil, ErrInvalidCredentials
+	}
+	return &Identity{Name: name, Roles: claimStrings(lookupClaim(claims, p.cfg.RolesClaim))}, nil
+}
+
+// lookupKey picks the key the token is signed with.
+// A token without a key ID is only accepted if the key set has a single key.
+func (p *jwtProvider) lookupKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	for _, k := range p.keys {
+		if kid != "" && k.kid != kid {
+			continue
+		}
+		if kid == "" && len(p.keys) > 1 {
+			break
+		}
+		if k.alg != "" && k.alg != token.Method.Alg() {
+			return nil, fmt.Errorf("key %s is not for the algorithm %s", k.kid, token.Method.Alg())
+		}
+		return k.key, nil
+	}
+	return nil, fmt.Errorf("key %q is not found", kid)
+}
+
+func lookupClaim(claims jwt.MapClaims, path string) interface{} {
+	var v interface{} = map[string]interface{}(claims)
+	for _, name := range strings.Split(path, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[name]
+	}
+	return v
+}
+
+// claimStrings converts a claim to strings. A string claim is split by spaces, like the "scope" claim.
+func claimStrings(claim interface{}) []string {
+	switch c := claim.(type) {
+	case string:
+		return strings.Fields(c)
+	case []interface{}:
+		values := make([]string, 0, len(c))
+		for _, v := range c {
+			if s, ok := v.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+func parseJWKS(data []byte) ([]publicKey, error) {
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return nil, err
+	}
+	keys := make([]publicKey, 0, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %w", jwk.Kid, err)
+		}
+		keys = append(keys, publicKey{kid: jwk.Kid, alg: jwk.Alg, key: key})
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no signing keys")
+	}
+	return keys, nil
+}
+
+func (jwk jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+		x, err := decodeBigInt(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+		// The conversion fails if the point is not on the curve.
+		if _, err = key.ECDH(); err != nil {
+			return nil, err
+		}
+		return key, nil
+	case "OKP":
+		if jwk.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", jwk.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,161 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeJWKS(t *testing.T, dir string, rsaKey *rsa.PrivateKey, ecKey *ecdsa.PrivateKey) {
+	encode := func(b []byte) string {
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	jwks := map[string][]map[string]string{
+		"keys": {
+			{
+				"kty": "RSA",
+				"kid": "rsa",
+				"alg": "RS256",
+				"use": "sig",
+				"n":   encode(rsaKey.N.Bytes()),
+				"e":   encode(big.NewInt(int64(rsaKey.E)).Bytes()),
+			},
+			{
+				"kty": "EC",
+				"kid": "ec",
+				"crv": "P-256",
+				"x":   encode(ecKey.X.FillBytes(make([]byte, 32))),
+				"y":   encode(ecKey.Y.FillBytes(make([]byte, 32))),
+			},
+		},
+	}
+	data, err := json.Marshal(jwks)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "jwks.json"), data, 0o600))
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func TestJWTProvider(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	filePath := writeConfig(t, `
+jwt:
+  jwks_file: jwks.json
+  issuer: https://idp.example.com
+  audience: banyandb
+  roles_claim: realm_access.roles
+roles:
+  - name: reader
+    rules:
+      - permissions: [read]
+        groups: [sw_metric]
+`)
+	writeJWKS(t, filepath.Dir(filePath), rsaKey, ecKey)
+	cfg := InitCfg()
+	require.NoError(t, LoadConfig(cfg, filePath))
+
+	claims := func(modify func(jwt.MapClaims)) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"sub":          "oap",
+			"iss":          "https://idp.example.com",
+			"aud":          "banyandb",
+			"exp":          time.Now().Add(time.Hour).Unix(),
+			"realm_access": map[string]interface{}{"roles": []string{"reader", "unknown"}},
+		}
+		if modify != nil {
+			modify(c)
+		}
+		return c
+	}
+
+	identity, err := Authenticate(cfg, Credentials{BearerToken: signToken(t, jwt.SigningMethodRS256, "rsa", rsaKey, claims(nil))})
+	require.NoError(t, err)
+	assert.Equal(t, "oap", identity.Name)
+	assert.Equal(t, []string{"reader", "unknown"}, identity.Roles)
+	assert.True(t, Authorize(cfg, identity, PermissionRead, CatalogMeasure, "sw_metric"))
+	assert.False(t, Authorize(cfg, identity, PermissionWrite, CatalogMeasure, "sw_metric"))
+
+	identity, err = Authenticate(cfg, Credentials{BearerToken: signToken(t, jwt.SigningMethodES256, "ec", ecKey, claims(nil))})
+	require.NoError(t, err)
+	assert.Equal(t, "oap", identity.Name)
+
+	invalidTokens := map[string]string{
+		"expired": signToken(t, jwt.SigningMethodRS256, "rsa", rsaKey, claims(func(c jwt.MapClaims) {
+			c["exp"] = time.Now().Add(-time.Minute).Unix()
+		})),
+		"without expiration": signToken(t, jwt.SigningMethodRS256, "rsa", rsaKey, claims(func(c jwt.MapClaims) {
+			delete(c, "exp")
+		})),
+		"wrong issuer": signToken(t, jwt.SigningMethodRS256, "rsa", rsaKey, claims(func(c jwt.MapClaims) {
+			c["iss"] = "https://other.example.com"
+		})),
+		"wrong audience": signToken(t, jwt.SigningMethodRS256, "rsa", rsaKey, claims(func(c jwt.MapClaims) {
+			c["aud"] = "other"
+		})),
+		"without subject": signToken(t, jwt.SigningMethodRS256, "rsa", rsaKey, claims(func(c jwt.MapClaims) {
+			delete(c, "sub")
+		})),
+		"unknown key":       signToken(t, jwt.SigningMethodRS256, "rsa", otherKey, claims(nil)),
+		"unknown key ID":    signToken(t, jwt.SigningMethodRS256, "other", rsaKey, claims(nil)),
+		"mismatched alg":    signToken(t, jwt.SigningMethodRS512, "rsa", rsaKey, claims(nil)),
+		"symmetric":         signToken(t, jwt.SigningMethodHS256, "rsa", []byte("secret"), claims(nil)),
+		"malformed":         "not.a.token",
+		"without signature": signToken(t, jwt.SigningMethodNone, "rsa", jwt.UnsafeAllowNoneSignatureType, claims(nil)),
+	}
+	for name, token := range invalidTokens {
+		t.Run(name, func(t *testing.T) {
+			_, authErr := Authenticate(cfg, Credentials{BearerToken: token})
+			assert.ErrorIs(t, authErr, ErrInvalidCredentials)
+		})
+	}
+}
+
+func TestParseJWKS(t *testing.T) {
+	_, err := parseJWKS([]byte(`{"keys": []}`))
+	assert.Error(t, err)
+	_, err = parseJWKS([]byte(`{"keys": [{"kty": "EC", "crv": "P-256", "x": "AQ", "y": "AQ"}]}`))
+	assert.Error(t, err, "the point is not on the curve")
+	_, err = parseJWKS([]byte(`{"keys": [{"kty": "oct", "k": "c2VjcmV0"}]}`))
+	assert.Error(t, err)
+}
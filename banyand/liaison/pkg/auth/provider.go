@@ -0,0 +1,238 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/apache/skywalking-banyandb/pkg/auth"
+)
+
+var (
+	// ErrCredentialsNotFound is returned if the credentials a provider verifies are not presented.
+	ErrCredentialsNotFound = errors.New("credentials are not found")
+	// ErrInvalidCredentials is returned if the presented credentials can't be verified.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Credentials are the secrets presented by a client.
+// A client usually presents only one kind of them.
+type Credentials struct {
+	Username    string
+	Password    string
+	BearerToken string
+	APIKey      string
+}
+
+// Identity is an authenticated client along with the roles it's granted.
+type Identity struct {
+	Name  string
+	Roles []string
+}
+
+// Provider authenticates one kind of credentials.
+type Provider interface {
+	// Authenticate returns ErrCredentialsNotFound if the kind of credentials the provider verifies is absent.
+	Authenticate(creds Credentials) (*Identity, error)
+}
+
+// APIKey is a static key granted to a client.
+// The key is either a bcrypt or argon2id hash, or a plaintext key.
+type APIKey struct {
+	Name  string   `yaml:"name"`
+	Key   string   `yaml:"key"`
+	Roles []string `yaml:"roles"`
+}
+
+// Authenticate verifies the credentials by the providers configured.
+// The first provider that recognizes the credentials decides the result.
+func Authenticate(cfg *Config, creds Credentials) (*Identity, error) {
+	cfg.mu.RLock()
+	providers := cfg.providers
+	if providers == nil {
+		// The config isn't loaded from a file, so only the users are known.
+		providers = []Provider{newBasicProvider(cfg.Users)}
+	}
+	cfg.mu.RUnlock()
+	for _, p := range providers {
+		identity, err := p.Authenticate(creds)
+		if errors.Is(err, ErrCredentialsNotFound) {
+			continue
+		}
+		return identity, err
+	}
+	return nil, ErrCredentialsNotFound
+}
+
+// ParseAuthorization parses the value of an Authorization header with the Basic or Bearer scheme.
+func ParseAuthorization(value string) (Credentials, error) {
+	scheme, param, found := strings.Cut(strings.TrimSpace(value), " ")
+	if !found {
+		return Credentials{}, errors.New("invalid authorization format")
+	}
+	param = strings.TrimSpace(param)
+	switch strings.ToLower(scheme) {
+	case "basic":
+		decoded, err := base64.StdEncoding.DecodeString(param)
+		if err != nil {
+			return Credentials{}, fmt.Errorf("failed to decode basic credentials: %w", err)
+		}
+		username, password, ok := strings.Cut(string(decoded), ":")
+		if !ok {
+			return Credentials{}, errors.New("invalid basic credentials format")
+		}
+		return Credentials{Username: username, Password: password}, nil
+	case "bearer":
+		if param == "" {
+			return Credentials{}, errors.New("bearer token is empty")
+		}
+		return Credentials{BearerToken: param}, nil
+	}
+	return Credentials{}, fmt.Errorf("unsupported authorization scheme %q", scheme)
+}
+
+func newProviders(fc *fileConfig, dir string) ([]Provider, error) {
+	providers := []Provider{newBasicProvider(fc.Users)}
+	if len(fc.APIKeys) > 0 {
+		providers = append(providers, newAPIKeyProvider(fc.APIKeys))
+	}
+	if fc.JWT != nil {
+		p, err := newJWTProvider(*fc.JWT, dir)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, p)
+	}
+	return providers, nil
+}
+
+// verifiedCache caches the digests of the credentials that have been verified.
+// Verifying a hashed secret is expensive by design, so a provider verifies the same credentials once
+// until the config is reloaded, which replaces the providers along with their caches.
+type verifiedCache struct {
+	m sync.Map
+}
+
+func (c *verifiedCache) load(secret string) (*Identity, bool) {
+	v, ok := c.m.Load(sha256.Sum256([]byte(secret)))
+	if !ok {
+		return nil, false
+	}
+	return v.(*Identity), true
+}
+
+func (c *verifiedCache) store(secret string, identity *Identity) {
+	c.m.Store(sha256.Sum256([]byte(secret)), identity)
+}
+
+type basicProvider struct {
+	verified *verifiedCache
+	users    []User
+}
+
+func newBasicProvider(users []User) *basicProvider {
+	return &basicProvider{users: users, verified: &verifiedCache{}}
+}
+
+func (p *basicProvider) Authenticate(creds Credentials) (*Identity, error) {
+	username := strings.TrimSpace(creds.Username)
+	password := strings.TrimSpace(creds.Password)
+	if username == "" && password == "" {
+		return nil, ErrCredentialsNotFound
+	}
+	secret := username + "\x00" + password
+	if identity, ok := p.verified.load(secret); ok {
+		return identity, nil
+	}
+
+	for _, user := range p.users {
+		storedUsername := strings.TrimSpace(user.Username)
+		usernameBytes := []byte(username)
+		storedUsernameBytes := []byte(storedUsername)
+		if len(usernameBytes) != len(storedUsernameBytes) || subtle.ConstantTimeCompare(usernameBytes, storedUsernameBytes) != 1 {
+			continue
+		}
+
+		storedPassword := strings.TrimSpace(user.Password)
+		ok, err := auth.VerifyPassword(storedPassword, password)
+		if err != nil || !ok {
+			continue
+		}
+		identity := &Identity{Name: storedUsername, Roles: user.Roles}
+		if auth.IsHashedPassword(storedPassword) {
+			p.verified.store(secret, identity)
+		}
+		return identity, nil
+	}
+	return nil, ErrInvalidCredentials
+}
+
+type apiKeyProvider struct {
+	verified *verifiedCache
+	keys     []APIKey
+}
+
+func newAPIKeyProvider(keys []APIKey) *apiKeyProvider {
+	return &apiKeyProvider{keys: keys, verified: &verifiedCache{}}
+}
+
+func (p *apiKeyProvider) Authenticate(creds Credentials) (*Identity, error) {
+	key := strings.TrimSpace(creds.APIKey)
+	if key == "" {
+		return nil, ErrCredentialsNotFound
+	}
+	if identity, ok := p.verified.load(key); ok {
+		return identity, nil
+	}
+	for _, k := range p.keys {
+		storedKey := strings.TrimSpace(k.Key)
+		ok, err := auth.VerifyPassword(storedKey, key)
+		if err != nil || !ok {
+			continue
+		}
+		identity := &Identity{Name: k.Name, Roles: k.Roles}
+		if auth.IsHashedPassword(storedKey) {
+			p.verified.store(key, identity)
+		}
+		return identity, nil
+	}
+	return nil, ErrInvalidCredentials
+}
+
+func validateAPIKeys(keys []APIKey) error {
+	names := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		if k.Name == "" {
+			return errors.New("API key name is empty")
+		}
+		if _, ok := names[k.Name]; ok {
+			return fmt.Errorf("API key %s is defined more than once", k.Name)
+		}
+		names[k.Name] = struct{}{}
+		if strings.TrimSpace(k.Key) == "" {
+			return fmt.Errorf("API key %s has an empty key", k.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package auth
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/pkg/auth"
+)
+
+func TestAPIKeyProvider(t *testing.T) {
+	hash, err := auth.HashPassword("oap-key", auth.AlgorithmArgon2id)
+	require.NoError(t, err)
+	cfg := InitCfg()
+	require.NoError(t, LoadConfig(cfg, writeConfig(t, fmt.Sprintf(`
+users:
+  - username: admin
+    password: admin
+api_keys:
+  - name: oap
+    key: %q
+    roles: [writer]
+  - name: ui
+    key: ui-key
+roles:
+  - name: writer
+    rules:
+      - permissions: [write]
+        groups: [sw_metric]
+`, hash))))
+
+	identity, err := Authenticate(cfg, Credentials{APIKey: "oap-key"})
+	require.NoError(t, err)
+	assert.Equal(t, &Identity{Name: "oap", Roles: []string{"writer"}}, identity)
+	// The verified key is served from the cache.
+	identity, err = Authenticate(cfg, Credentials{APIKey: "oap-key"})
+	require.NoError(t, err)
+	assert.True(t, Authorize(cfg, identity, PermissionWrite, CatalogMeasure, "sw_metric"))
+	assert.False(t, Authorize(cfg, identity, PermissionWrite, CatalogMeasure, "sw_record"))
+
+	identity, err = Authenticate(cfg, Credentials{APIKey: "ui-key"})
+	require.NoError(t, err)
+	assert.Equal(t, "ui", identity.Name)
+
+	_, err = Authenticate(cfg, Credentials{APIKey: hash})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = Authenticate(cfg, Credentials{APIKey: "admin"})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	// No provider verifies bearer tokens without the JWT config.
+	_, err = Authenticate(cfg, Credentials{BearerToken: "token"})
+	assert.ErrorIs(t, err, ErrCredentialsNotFound)
+	_, err = Authenticate(cfg, Credentials{})
+	assert.ErrorIs(t, err, ErrCredentialsNotFound)
+
+	identity, err = Authenticate(cfg, Credentials{Username: "admin", Password: "admin"})
+	require.NoError(t, err)
+	assert.Equal(t, "admin", identity.Name)
+}
+
+func TestParseAuthorization(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    Credentials
+		wantErr bool
+	}{
+		{
+			name:  "basic",
+			value: "Basic YWRtaW46cGFzczp3b3Jk",
+			want:  Credentials{Username: "admin", Password: "pass:word"},
+		},
+		{
+			name:  "bearer",
+			value: "bearer  token ",
+			want:  Credentials{BearerToken: "token"},
+		},
+		{
+			name:    "invalid base64",
+			value:   "Basic ???",
+			wantErr: true,
+		},
+		{
+			name:    "basic without a colon",
+			value:   "Basic YWRtaW4=",
+			wantErr: true,
+		},
+		{
+			name:    "unsupported scheme",
+			value:   "Digest username=admin",
+			wantErr: true,
+		},
+		{
+			name:    "without a scheme",
+			value:   "token",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAuthorization(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,167 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Permission is an action that a role is allowed to perform.
+type Permission string
+
+const (
+	// PermissionRead allows querying data and reading schemas.
+	PermissionRead Permission = "read"
+	// PermissionWrite allows writing and deleting data.
+	PermissionWrite Permission = "write"
+	// PermissionSchemaAdmin allows creating, updating and deleting schemas.
+	PermissionSchemaAdmin Permission = "schema-admin"
+	// PermissionSnapshot allows taking snapshots.
+	PermissionSnapshot Permission = "snapshot"
+)
+
+// Catalogs a rule can be scoped to.
+const (
+	CatalogStream   = "stream"
+	CatalogMeasure  = "measure"
+	CatalogProperty = "property"
+	CatalogTrace    = "trace"
+)
+
+// wildcard matches all groups or all catalogs.
+const wildcard = "*"
+
+var (
+	validPermissions = []Permission{PermissionRead, PermissionWrite, PermissionSchemaAdmin, PermissionSnapshot}
+	validCatalogs    = []string{CatalogStream, CatalogMeasure, CatalogProperty, CatalogTrace, wildcard}
+)
+
+// Role is a named set of rules granted to users.
+type Role struct {
+	Name  string `yaml:"name"`
+	Rules []Rule `yaml:"rules"`
+}
+
+// Rule grants permissions on groups of catalogs.
+// Empty groups or catalogs, as well as "*", match all of them.
+type Rule struct {
+	Permissions []Permission `yaml:"permissions"`
+	Groups      []string     `yaml:"groups"`
+	Catalogs    []string     `yaml:"catalogs"`
+}
+
+func (r Rule) allows(permission Permission, catalog, group string) bool {
+	if !slices.Contains(r.Permissions, permission) {
+		return false
+	}
+	if catalog != "" && !matches(r.Catalogs, catalog) {
+		return false
+	}
+	if group == "" {
+		return matches(r.Groups, wildcard)
+	}
+	return matches(r.Groups, group)
+}
+
+func matches(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	return slices.Contains(patterns, wildcard) || slices.Contains(patterns, value)
+}
+
+// RBACEnabled returns true if roles are defined in the config.
+// Without roles, every authenticated user has full access.
+func RBACEnabled(cfg *Config) bool {
+	cfg.mu.RLock()
+	defer cfg.mu.RUnlock()
+	return len(cfg.Roles) > 0
+}
+
+// Authorize returns true if the identity is granted the permission on the group of the catalog.
+// An empty catalog means the resource doesn't belong to a specific catalog, so the catalogs of rules are not checked.
+// An empty group means the operation spans all groups, so only the rules covering all groups apply.
+// The roles of the identity that are not defined are ignored.
+func Authorize(cfg *Config, identity *Identity, permission Permission, catalog, group string) bool {
+	cfg.mu.RLock()
+	defer cfg.mu.RUnlock()
+	if len(cfg.Roles) == 0 {
+		return true
+	}
+	if identity == nil {
+		return false
+	}
+	for _, roleName := range identity.Roles {
+		for _, role := range cfg.Roles {
+			if role.Name != roleName {
+				continue
+			}
+			for _, rule := range role.Rules {
+				if rule.allows(permission, catalog, group) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func validateRoles(fc *fileConfig) error {
+	roles := make(map[string]struct{}, len(fc.Roles))
+	for _, role := range fc.Roles {
+		if role.Name == "" {
+			return errors.New("role name is empty")
+		}
+		if _, ok := roles[role.Name]; ok {
+			return fmt.Errorf("role %s is defined more than once", role.Name)
+		}
+		roles[role.Name] = struct{}{}
+		for _, rule := range role.Rules {
+			if len(rule.Permissions) == 0 {
+				return fmt.Errorf("a rule of role %s has no permissions", role.Name)
+			}
+			for _, p := range rule.Permissions {
+				if !slices.Contains(validPermissions, p) {
+					return fmt.Errorf("unknown permission %q in role %s", p, role.Name)
+				}
+			}
+			for _, c := range rule.Catalogs {
+				if !slices.Contains(validCatalogs, c) {
+					return fmt.Errorf("unknown catalog %q in role %s", c, role.Name)
+				}
+			}
+		}
+	}
+	for _, user := range fc.Users {
+		for _, roleName := range user.Roles {
+			if _, ok := roles[roleName]; !ok {
+				return fmt.Errorf("user %s refers to the undefined role %s", user.Username, roleName)
+			}
+		}
+	}
+	for _, key := range fc.APIKeys {
+		for _, roleName := range key.Roles {
+			if _, ok := roles[roleName]; !ok {
+				return fmt.Errorf("API key %s refers to the undefined role %s", key.Name, roleName)
+			}
+		}
+	}
+	return nil
+}
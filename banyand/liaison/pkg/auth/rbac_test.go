@@ -0,0 +1,157 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	filePath := filepath.Join(t.TempDir(), "auth.yaml")
+	require.NoError(t, os.WriteFile(filePath, []byte(content), 0o600))
+	return filePath
+}
+
+func TestLoadConfigWithRoles(t *testing.T) {
+	cfg := InitCfg()
+	require.NoError(t, LoadConfig(cfg, writeConfig(t, `
+users:
+  - username: admin
+    password: admin
+    roles: [admin]
+  - username: reader
+    password: reader
+    roles: [reader]
+roles:
+  - name: admin
+    rules:
+      - permissions: [read, write, schema-admin, snapshot]
+  - name: reader
+    rules:
+      - permissions: [read]
+        groups: [sw_metric, sw_record]
+        catalogs: [measure, stream]
+`)))
+	require.True(t, RBACEnabled(cfg))
+	admin, err := Authenticate(cfg, Credentials{Username: "admin", Password: "admin"})
+	require.NoError(t, err)
+	reader, err := Authenticate(cfg, Credentials{Username: "reader", Password: "reader"})
+	require.NoError(t, err)
+
+	assert.True(t, Authorize(cfg, admin, PermissionSnapshot, "", ""))
+	assert.True(t, Authorize(cfg, admin, PermissionSchemaAdmin, CatalogTrace, "sw_trace"))
+	assert.True(t, Authorize(cfg, reader, PermissionRead, CatalogMeasure, "sw_metric"))
+	assert.True(t, Authorize(cfg, reader, PermissionRead, "", "sw_record"))
+	assert.False(t, Authorize(cfg, reader, PermissionRead, CatalogTrace, "sw_metric"))
+	assert.False(t, Authorize(cfg, reader, PermissionRead, CatalogMeasure, "sw_trace"))
+	assert.False(t, Authorize(cfg, reader, PermissionRead, CatalogMeasure, ""))
+	assert.False(t, Authorize(cfg, reader, PermissionWrite, CatalogMeasure, "sw_metric"))
+	assert.False(t, Authorize(cfg, &Identity{Name: "unknown", Roles: []string{"undefined"}}, PermissionRead, CatalogMeasure, "sw_metric"))
+	assert.False(t, Authorize(cfg, nil, PermissionRead, CatalogMeasure, "sw_metric"))
+}
+
+func TestLoadConfigWithInvalidRoles(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{
+			name: "unknown permission",
+			content: `
+roles:
+  - name: r
+    rules:
+      - permissions: [drop]
+`,
+		},
+		{
+			name: "unknown catalog",
+			content: `
+roles:
+  - name: r
+    rules:
+      - permissions: [read]
+        catalogs: [table]
+`,
+		},
+		{
+			name: "duplicated role",
+			content: `
+roles:
+  - name: r
+    rules:
+      - permissions: [read]
+  - name: r
+    rules:
+      - permissions: [write]
+`,
+		},
+		{
+			name: "API key referring to an undefined role",
+			content: `
+api_keys:
+  - name: oap
+    key: secret
+    roles: [r]
+`,
+		},
+		{
+			name: "API key without a key",
+			content: `
+api_keys:
+  - name: oap
+`,
+		},
+		{
+			name: "JWT without a JWKS file",
+			content: `
+jwt:
+  issuer: https://idp.example.com
+`,
+		},
+		{
+			name: "undefined role",
+			content: `
+users:
+  - username: u
+    password: p
+    roles: [r]
+roles:
+  - name: other
+    rules:
+      - permissions: [read]
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Error(t, LoadConfig(InitCfg(), writeConfig(t, tt.content)))
+		})
+	}
+}
+
+func TestAuthorizeWithoutRoles(t *testing.T) {
+	cfg := &Config{Users: []User{{Username: "admin", Password: "admin"}}}
+	assert.False(t, RBACEnabled(cfg))
+	assert.True(t, Authorize(cfg, &Identity{Name: "admin"}, PermissionSchemaAdmin, CatalogStream, "sw_record"))
+}
@@ -0,0 +1,199 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package auth
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+const reloadDebounce = 500 * time.Millisecond
+
+// Reloader watches the auth config file and reloads the users and roles once it changes.
+// The files the config refers to, like the JWKS file, are watched as well, and the config is reloaded once they change.
+// Requests are authenticated against the config one by one, so established connections
+// pick up the new users with their next request without being dropped.
+type Reloader struct {
+	cfg           *Config
+	watcher       *fsnotify.Watcher
+	log           *logger.Logger
+	debounceTimer *time.Timer
+	stopCh        chan struct{}
+	files         map[string]struct{}
+	dirs          map[string]struct{}
+	filePath      string
+	mu            sync.Mutex
+	stopOnce      sync.Once
+}
+
+// NewReloader creates a Reloader of the config loaded from the file.
+func NewReloader(cfg *Config, filePath string, log *logger.Logger) (*Reloader, error) {
+	if filePath == "" {
+		return nil, errors.New("filePath must be provided")
+	}
+	if log == nil {
+		return nil, errors.New("logger must not be nil")
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create fsnotify watcher")
+	}
+	filePath, err = filepath.Abs(filePath)
+	if err != nil {
+		watcher.Close()
+		return nil, errors.Wrapf(err, "failed to get the absolute path of %s", filePath)
+	}
+	return &Reloader{
+		cfg:      cfg,
+		filePath: filePath,
+		log:      log,
+		watcher:  watcher,
+		stopCh:   make(chan struct{}),
+	}, nil
+}
+
+// Start begins monitoring the config file.
+func (r *Reloader) Start() error {
+	if err := r.watch(); err != nil {
+		return err
+	}
+	go r.watchFile()
+	r.log.Info().Str("file", r.filePath).Msg("started watching the auth config file")
+	return nil
+}
+
+// Stop stops monitoring the config file.
+func (r *Reloader) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stopCh)
+		r.mu.Lock()
+		if r.debounceTimer != nil {
+			r.debounceTimer.Stop()
+		}
+		r.mu.Unlock()
+		if err := r.watcher.Close(); err != nil {
+			r.log.Error().Err(err).Msg("failed to close the auth config watcher")
+		}
+	})
+}
+
+// watch watches the config file and the files it refers to.
+// The directories are watched rather than the files, so that replacing a file by a rename,
+// as editors and Kubernetes secrets do, is detected as well.
+func (r *Reloader) watch() error {
+	files := map[string]struct{}{r.filePath: {}}
+	for _, f := range referredFiles(r.cfg) {
+		absPath, err := filepath.Abs(f)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get the absolute path of %s", f)
+		}
+		files[absPath] = struct{}{}
+	}
+	dirs := make(map[string]struct{}, len(files))
+	for f := range files {
+		dirs[filepath.Dir(f)] = struct{}{}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for dir := range dirs {
+		if _, ok := r.dirs[dir]; ok {
+			continue
+		}
+		if err := r.watcher.Add(dir); err != nil {
+			return errors.Wrapf(err, "failed to watch the directory %s", dir)
+		}
+	}
+	for dir := range r.dirs {
+		if _, ok := dirs[dir]; ok {
+			continue
+		}
+		if err := r.watcher.Remove(dir); err != nil {
+			r.log.Warn().Err(err).Str("dir", dir).Msg("failed to stop watching the directory")
+		}
+	}
+	r.files, r.dirs = files, dirs
+	return nil
+}
+
+func (r *Reloader) isWatched(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.files[filepath.Clean(name)]
+	return ok
+}
+
+func (r *Reloader) watchFile() {
+	for {
+		select {
+		case <-r.stopCh:
+			return
+		case event, ok := <-r.watcher.Events:
+			if !ok {
+				return
+			}
+			if !r.isWatched(event.Name) {
+				continue
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename|fsnotify.Chmod) == 0 {
+				continue
+			}
+			r.log.Debug().Str("file", event.Name).Str("op", event.Op.String()).Msg("detected an auth config file event")
+			r.scheduleReload()
+		case err, ok := <-r.watcher.Errors:
+			if !ok {
+				return
+			}
+			r.log.Error().Err(err).Msg("error in the auth config watcher")
+		}
+	}
+}
+
+// scheduleReload debounces the events of a single update, which usually comes with several writes.
+func (r *Reloader) scheduleReload() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.debounceTimer != nil {
+		r.debounceTimer.Reset(reloadDebounce)
+		return
+	}
+	r.debounceTimer = time.AfterFunc(reloadDebounce, r.reload)
+}
+
+func (r *Reloader) reload() {
+	select {
+	case <-r.stopCh:
+		return
+	default:
+	}
+	if err := LoadConfig(r.cfg, r.filePath); err != nil {
+		r.log.Error().Err(err).Str("file", r.filePath).Msg("failed to reload the auth config, keep using the previous one")
+		return
+	}
+	r.log.Info().Str("file", r.filePath).Msg("reloaded the auth config")
+	// The config might refer to other files now.
+	if err := r.watch(); err != nil {
+		r.log.Error().Err(err).Msg("failed to watch the files the auth config refers to")
+	}
+}
@@ -0,0 +1,117 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/pkg/auth"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+func TestCheckHashedPassword(t *testing.T) {
+	hash, err := auth.HashPassword("secret", auth.AlgorithmBcrypt)
+	require.NoError(t, err)
+	cfg := InitCfg()
+	require.NoError(t, LoadConfig(cfg, writeConfig(t, fmt.Sprintf("users:\n  - username: admin\n    password: %q\n", hash))))
+
+	assert.True(t, CheckUsernameAndPassword(cfg, "admin", "secret"))
+	// The verified credentials are served from the cache.
+	assert.True(t, CheckUsernameAndPassword(cfg, "admin", "secret"))
+	assert.False(t, CheckUsernameAndPassword(cfg, "admin", hash))
+	assert.False(t, CheckUsernameAndPassword(cfg, "other", "secret"))
+}
+
+func TestReloader(t *testing.T) {
+	require.NoError(t, logger.Init(logger.Logging{Env: "dev", Level: "warn"}))
+	filePath := writeConfig(t, "users:\n  - username: admin\n    password: old\n")
+	cfg := InitCfg()
+	require.NoError(t, LoadConfig(cfg, filePath))
+	require.True(t, CheckUsernameAndPassword(cfg, "admin", "old"))
+
+	r, err := NewReloader(cfg, filePath, logger.GetLogger("test"))
+	require.NoError(t, err)
+	require.NoError(t, r.Start())
+	defer r.Stop()
+
+	// Replace the file by a rename, so that the reloader never reads a partially written file.
+	tmpPath := filepath.Join(filepath.Dir(filePath), "auth.yaml.tmp")
+	require.NoError(t, os.WriteFile(tmpPath, []byte("users:\n  - username: admin\n    password: new\n"), 0o600))
+	require.NoError(t, os.Rename(tmpPath, filePath))
+	require.Eventually(t, func() bool {
+		return CheckUsernameAndPassword(cfg, "admin", "new")
+	}, 10*time.Second, 100*time.Millisecond)
+	assert.False(t, CheckUsernameAndPassword(cfg, "admin", "old"))
+
+	// An invalid config is ignored, and the previous one is kept.
+	require.NoError(t, os.WriteFile(filePath, []byte("users:\n  - username: admin\n    password: new\n    roles: [undefined]\n"), 0o600))
+	time.Sleep(2 * reloadDebounce)
+	assert.True(t, CheckUsernameAndPassword(cfg, "admin", "new"))
+}
+
+func TestReloaderJWKS(t *testing.T) {
+	require.NoError(t, logger.Init(logger.Logging{Env: "dev", Level: "warn"}))
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rotatedKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	// The JWKS file is in another directory, which is watched as well.
+	filePath := writeConfig(t, "jwt:\n  jwks_file: keys/jwks.json\n")
+	jwksDir := filepath.Join(filepath.Dir(filePath), "keys")
+	require.NoError(t, os.Mkdir(jwksDir, 0o700))
+	writeJWKS(t, jwksDir, rsaKey, ecKey)
+	cfg := InitCfg()
+	require.NoError(t, LoadConfig(cfg, filePath))
+
+	r, err := NewReloader(cfg, filePath, logger.GetLogger("test"))
+	require.NoError(t, err)
+	require.NoError(t, r.Start())
+	defer r.Stop()
+
+	claims := jwt.MapClaims{"sub": "oap", "exp": time.Now().Add(time.Hour).Unix()}
+	oldToken := signToken(t, jwt.SigningMethodRS256, "rsa", rsaKey, claims)
+	rotatedToken := signToken(t, jwt.SigningMethodRS256, "rsa", rotatedKey, claims)
+	_, err = Authenticate(cfg, Credentials{BearerToken: oldToken})
+	require.NoError(t, err)
+	_, err = Authenticate(cfg, Credentials{BearerToken: rotatedToken})
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+
+	// The rotated keys are picked up without touching the config file.
+	writeJWKS(t, jwksDir, rotatedKey, ecKey)
+	require.Eventually(t, func() bool {
+		_, authErr := Authenticate(cfg, Credentials{BearerToken: rotatedToken})
+		return authErr == nil
+	}, 10*time.Second, 100*time.Millisecond)
+	_, err = Authenticate(cfg, Credentials{BearerToken: oldToken})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
@@ -0,0 +1,77 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package quota limits the rates of the writes by the quotas of their groups and clients.
+package quota
+
+import (
+	"fmt"
+	"os"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Quota is the rates a group or a client writes at. A zero rate is unlimited.
+type Quota struct {
+	PointsPerSecond float64 `json:"points_per_second"`
+	BytesPerSecond  float64 `json:"bytes_per_second"`
+}
+
+// Config is the quotas of the groups and the clients.
+// The clients are identified by their authenticated names, or the hosts of their addresses if the authentication is disabled.
+type Config struct {
+	Groups        map[string]Quota `json:"groups"`
+	Clients       map[string]Quota `json:"clients"`
+	DefaultGroup  Quota            `json:"default_group"`
+	DefaultClient Quota            `json:"default_client"`
+}
+
+// LoadConfig reads the quotas from the YAML file.
+func LoadConfig(filePath string) (*Config, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err = yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+	if err = cfg.DefaultGroup.validate(); err != nil {
+		return nil, fmt.Errorf("invalid default group quota: %w", err)
+	}
+	if err = cfg.DefaultClient.validate(); err != nil {
+		return nil, fmt.Errorf("invalid default client quota: %w", err)
+	}
+	for name, q := range cfg.Groups {
+		if err = q.validate(); err != nil {
+			return nil, fmt.Errorf("invalid quota of group %s: %w", name, err)
+		}
+	}
+	for name, q := range cfg.Clients {
+		if err = q.validate(); err != nil {
+			return nil, fmt.Errorf("invalid quota of client %s: %w", name, err)
+		}
+	}
+	return &cfg, nil
+}
+
+func (q Quota) validate() error {
+	if q.PointsPerSecond < 0 || q.BytesPerSecond < 0 {
+		return fmt.Errorf("the rates must not be negative: %+v", q)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package quota
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "quota.yaml")
+	require.NoError(t, os.WriteFile(filePath, []byte(`
+default_group:
+  points_per_second: 1000
+groups:
+  sw_metric:
+    points_per_second: 5000
+    bytes_per_second: 1048576
+clients:
+  oap:
+    points_per_second: 2000
+`), 0o600))
+	cfg, err := LoadConfig(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, &Config{
+		DefaultGroup: Quota{PointsPerSecond: 1000},
+		Groups:       map[string]Quota{"sw_metric": {PointsPerSecond: 5000, BytesPerSecond: 1048576}},
+		Clients:      map[string]Quota{"oap": {PointsPerSecond: 2000}},
+	}, cfg)
+
+	require.NoError(t, os.WriteFile(filePath, []byte("clients:\n  oap:\n    bytes_per_second: -1\n"), 0o600))
+	_, err = LoadConfig(filePath)
+	assert.ErrorContains(t, err, "client oap")
+}
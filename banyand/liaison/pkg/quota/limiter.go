@@ -0,0 +1,187 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package quota
+
+import (
+	"sync"
+	"time"
+)
+
+// Scope is the kind of quota a write exceeds.
+type Scope string
+
+// The scopes of the quotas.
+const (
+	ScopeNone   Scope = ""
+	ScopeGroup  Scope = "group"
+	ScopeClient Scope = "client"
+)
+
+// idleTimeout is the time after which the buckets of an idle group or client are removed.
+// A bucket is full again long before unless it's in a deep debt, and a full bucket is the same as a new one,
+// so that removing the full buckets doesn't change the admission.
+const idleTimeout = time.Minute
+
+// Limiter admits the writes by the token buckets of their groups and clients.
+// A bucket holds the tokens of one second at most, and it's refilled at the rate of its quota.
+type Limiter struct {
+	lastSweep time.Time
+	now       func() time.Time
+	cfg       *Config
+	groups    map[string]*buckets
+	clients   map[string]*buckets
+	mu        sync.Mutex
+}
+
+// NewLimiter returns a Limiter of the quotas.
+func NewLimiter(cfg *Config) *Limiter {
+	return &Limiter{
+		cfg:     cfg,
+		now:     time.Now,
+		groups:  make(map[string]*buckets),
+		clients: make(map[string]*buckets),
+	}
+}
+
+// LimitsBytes returns true if the bytes of any group or client are limited, so that the sizes of the writes are needed.
+func (l *Limiter) LimitsBytes() bool {
+	if l.cfg.DefaultGroup.BytesPerSecond > 0 || l.cfg.DefaultClient.BytesPerSecond > 0 {
+		return true
+	}
+	for _, q := range l.cfg.Groups {
+		if q.BytesPerSecond > 0 {
+			return true
+		}
+	}
+	for _, q := range l.cfg.Clients {
+		if q.BytesPerSecond > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Admit takes the tokens of a write of the size from the buckets of its group and client.
+// It returns the scope of the quota the write exceeds, whose tokens aren't taken, or ScopeNone if the write is admitted.
+func (l *Limiter) Admit(group, client string, size int) Scope {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	if now.Sub(l.lastSweep) >= idleTimeout {
+		l.sweep(now)
+	}
+	g := l.bucketsOf(l.groups, l.cfg.Groups, l.cfg.DefaultGroup, group, now)
+	c := l.bucketsOf(l.clients, l.cfg.Clients, l.cfg.DefaultClient, client, now)
+	if !g.allows(size) {
+		return ScopeGroup
+	}
+	if !c.allows(size) {
+		return ScopeClient
+	}
+	g.take(size)
+	c.take(size)
+	return ScopeNone
+}
+
+func (l *Limiter) bucketsOf(all map[string]*buckets, quotas map[string]Quota, defaultQuota Quota, name string, now time.Time) *buckets {
+	b, ok := all[name]
+	if !ok {
+		q, specific := quotas[name]
+		if !specific {
+			q = defaultQuota
+		}
+		b = &buckets{
+			points: bucket{rate: q.PointsPerSecond, tokens: q.PointsPerSecond, last: now},
+			bytes:  bucket{rate: q.BytesPerSecond, tokens: q.BytesPerSecond, last: now},
+			used:   now,
+		}
+		all[name] = b
+		return b
+	}
+	b.points.refill(now)
+	b.bytes.refill(now)
+	b.used = now
+	return b
+}
+
+// sweep removes the buckets of the groups and the clients which are idle.
+func (l *Limiter) sweep(now time.Time) {
+	l.lastSweep = now
+	for _, all := range []map[string]*buckets{l.groups, l.clients} {
+		for name, b := range all {
+			if b.idle(now) {
+				delete(all, name)
+			}
+		}
+	}
+}
+
+type buckets struct {
+	used   time.Time
+	points bucket
+	bytes  bucket
+}
+
+// idle returns true if the buckets aren't used for the idle timeout, and they're full.
+func (b *buckets) idle(now time.Time) bool {
+	if now.Sub(b.used) < idleTimeout {
+		return false
+	}
+	b.points.refill(now)
+	b.bytes.refill(now)
+	return b.points.full() && b.bytes.full()
+}
+
+func (b *buckets) allows(size int) bool {
+	return b.points.allows(1) && b.bytes.allows(float64(size))
+}
+
+func (b *buckets) take(size int) {
+	b.points.take(1)
+	b.bytes.take(float64(size))
+}
+
+type bucket struct {
+	last   time.Time
+	rate   float64
+	tokens float64
+}
+
+func (b *bucket) refill(now time.Time) {
+	if b.rate <= 0 {
+		return
+	}
+	b.tokens = min(b.rate, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+}
+
+func (b *bucket) full() bool {
+	return b.rate <= 0 || b.tokens >= b.rate
+}
+
+// allows returns true if the bucket holds the tokens, or it's full.
+// A write larger than the bucket is admitted once the bucket is full, and the bucket is left in debt.
+func (b *bucket) allows(n float64) bool {
+	return b.rate <= 0 || b.tokens >= min(n, b.rate)
+}
+
+func (b *bucket) take(n float64) {
+	if b.rate > 0 {
+		b.tokens -= n
+	}
+}
@@ -0,0 +1,115 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package quota
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLimiter(cfg *Config) (*Limiter, *time.Time) {
+	now := time.Unix(0, 0)
+	l := NewLimiter(cfg)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestLimiterGroupPoints(t *testing.T) {
+	l, now := newTestLimiter(&Config{
+		DefaultGroup: Quota{PointsPerSecond: 2},
+		Groups:       map[string]Quota{"large": {PointsPerSecond: 4}},
+	})
+	assert.Equal(t, ScopeNone, l.Admit("small", "client", 10))
+	assert.Equal(t, ScopeNone, l.Admit("small", "client", 10))
+	assert.Equal(t, ScopeGroup, l.Admit("small", "client", 10))
+	for range 4 {
+		assert.Equal(t, ScopeNone, l.Admit("large", "client", 10), "a group has its own bucket")
+	}
+	assert.Equal(t, ScopeGroup, l.Admit("large", "client", 10))
+
+	*now = now.Add(500 * time.Millisecond)
+	assert.Equal(t, ScopeNone, l.Admit("small", "client", 10))
+	assert.Equal(t, ScopeGroup, l.Admit("small", "client", 10))
+	*now = now.Add(time.Hour)
+	assert.Equal(t, ScopeNone, l.Admit("small", "client", 10))
+	assert.Equal(t, ScopeNone, l.Admit("small", "client", 10))
+	assert.Equal(t, ScopeGroup, l.Admit("small", "client", 10), "a bucket holds the tokens of one second at most")
+}
+
+func TestLimiterClientBytes(t *testing.T) {
+	l, now := newTestLimiter(&Config{
+		DefaultClient: Quota{BytesPerSecond: 100},
+		Clients:       map[string]Quota{"unlimited": {}},
+	})
+	assert.True(t, l.LimitsBytes())
+	assert.Equal(t, ScopeNone, l.Admit("group", "noisy", 60))
+	assert.Equal(t, ScopeClient, l.Admit("group", "noisy", 60))
+	assert.Equal(t, ScopeNone, l.Admit("group", "noisy", 40))
+	for range 10 {
+		assert.Equal(t, ScopeNone, l.Admit("group", "unlimited", 60), "the noisy client doesn't starve the others")
+	}
+
+	// A write larger than the bucket is admitted once the bucket is full.
+	*now = now.Add(time.Second)
+	assert.Equal(t, ScopeNone, l.Admit("group", "noisy", 300))
+	*now = now.Add(time.Second)
+	assert.Equal(t, ScopeClient, l.Admit("group", "noisy", 1), "the bucket is in debt")
+	*now = now.Add(2 * time.Second)
+	assert.Equal(t, ScopeNone, l.Admit("group", "noisy", 1))
+}
+
+func TestLimiterRejectedWriteTakesNoTokens(t *testing.T) {
+	l, _ := newTestLimiter(&Config{
+		DefaultGroup:  Quota{PointsPerSecond: 2},
+		DefaultClient: Quota{PointsPerSecond: 1},
+	})
+	assert.False(t, l.LimitsBytes())
+	assert.Equal(t, ScopeNone, l.Admit("group", "client1", 0))
+	assert.Equal(t, ScopeClient, l.Admit("group", "client1", 0))
+	assert.Equal(t, ScopeNone, l.Admit("group", "client2", 0), "the write rejected by the client's quota takes no tokens of the group")
+	assert.Equal(t, ScopeGroup, l.Admit("group", "client3", 0))
+}
+
+func TestLimiterRemovesIdleBuckets(t *testing.T) {
+	l, now := newTestLimiter(&Config{
+		DefaultGroup:  Quota{PointsPerSecond: 2},
+		DefaultClient: Quota{BytesPerSecond: 1},
+	})
+	assert.Equal(t, ScopeNone, l.Admit("group1", "idle", 1))
+	// The write leaves the bucket of the client in a debt, which takes longer than the idle timeout to refill.
+	assert.Equal(t, ScopeNone, l.Admit("group1", "debtor", 1000))
+	*now = now.Add(idleTimeout / 2)
+	assert.Equal(t, ScopeNone, l.Admit("group2", "active", 1))
+	assert.Len(t, l.groups, 2)
+	assert.Len(t, l.clients, 3)
+
+	*now = now.Add(idleTimeout / 2)
+	assert.Equal(t, ScopeNone, l.Admit("group2", "active", 1))
+	assert.Contains(t, l.groups, "group2")
+	assert.NotContains(t, l.groups, "group1", "the idle group is removed")
+	assert.Contains(t, l.clients, "active")
+	assert.NotContains(t, l.clients, "idle", "the idle client is removed")
+	assert.Contains(t, l.clients, "debtor", "the client in a debt is kept until its bucket is full")
+
+	*now = now.Add(time.Hour)
+	assert.Equal(t, ScopeNone, l.Admit("group2", "active", 1))
+	assert.NotContains(t, l.clients, "debtor")
+	assert.Equal(t, ScopeNone, l.Admit("group1", "debtor", 1), "a removed bucket is created full again")
+}
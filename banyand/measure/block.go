@@ -24,6 +24,7 @@ import (
 
 	"github.com/apache/skywalking-banyandb/api/common"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
 	"github.com/apache/skywalking-banyandb/pkg/bytes"
 	"github.com/apache/skywalking-banyandb/pkg/encoding"
 	"github.com/apache/skywalking-banyandb/pkg/fs"
@@ -444,11 +445,13 @@ type blockCursor struct {
 	idx                 int
 	minTimestamp        int64
 	maxTimestamp        int64
+	tombstones          *storage.Tombstones
 }
 
 func (bc *blockCursor) reset() {
 	bc.idx = 0
 	bc.p = nil
+	bc.tombstones = nil
 	bc.bm.reset()
 	bc.minTimestamp = 0
 	bc.maxTimestamp = 0
@@ -474,6 +477,7 @@ func (bc *blockCursor) init(p *part, bm *blockMetadata, queryOpts queryOptions)
 	bc.maxTimestamp = queryOpts.maxTimestamp
 	bc.tagProjection = queryOpts.TagProjection
 	bc.fieldProjection = queryOpts.FieldProjection
+	bc.tombstones = queryOpts.tombstones
 }
 
 func (bc *blockCursor) copyAllTo(r *model.MeasureResult, storedIndexValue map[common.SeriesID]map[string]*modelv1.TagValue,
@@ -724,8 +728,21 @@ NEXT_FIELD:
 	if !ok {
 		return false
 	}
-	bc.timestamps = append(bc.timestamps, tmpBlock.timestamps[start:end+1]...)
-	bc.versions = append(bc.versions, tmpBlock.versions[start:end+1]...)
+	// idxList keeps the data points which are not deleted, nil means all of them in the range.
+	var idxList []int
+	if bc.tombstones.Overlaps(tmpBlock.timestamps[start], tmpBlock.timestamps[end]) {
+		idxList = make([]int, 0, end-start+1)
+		for i := start; i <= end; i++ {
+			if !bc.tombstones.IsDeleted(bc.p.partMetadata.ID, uint64(bc.bm.seriesID), tmpBlock.timestamps[i]) {
+				idxList = append(idxList, i)
+			}
+		}
+		if len(idxList) == 0 {
+			return false
+		}
+	}
+	bc.timestamps = appendRows(bc.timestamps, tmpBlock.timestamps, start, end, idxList)
+	bc.versions = appendRows(bc.versions, tmpBlock.versions, start, end, idxList)
 
 	for _, cf := range tmpBlock.tagFamilies {
 		tf := columnFamily{
@@ -742,7 +759,7 @@ NEXT_FIELD:
 			if len(cf.columns[i].values) != len(tmpBlock.timestamps) {
 				logger.Panicf("unexpected number of values for tags %q: got %d; want %d", cf.columns[i].name, len(cf.columns[i].values), len(tmpBlock.timestamps))
 			}
-			column.values = append(column.values, cf.columns[i].values[start:end+1]...)
+			column.values = appendRows(column.values, cf.columns[i].values, start, end, idxList)
 			tf.columns = append(tf.columns, column)
 		}
 		bc.tagFamilies = append(bc.tagFamilies, tf)
@@ -761,12 +778,22 @@ NEXT_FIELD:
 			valueType: tmpBlock.field.columns[i].valueType,
 		}
 
-		c.values = append(c.values, tmpBlock.field.columns[i].values[start:end+1]...)
+		c.values = appendRows(c.values, tmpBlock.field.columns[i].values, start, end, idxList)
 		bc.fields.columns = append(bc.fields.columns, c)
 	}
 	return true
 }
 
+func appendRows[T any](dst, src []T, start, end int, idxList []int) []T {
+	if idxList == nil {
+		return append(dst, src[start:end+1]...)
+	}
+	for _, i := range idxList {
+		dst = append(dst, src[i])
+	}
+	return dst
+}
+
 var blockCursorPool = pool.Register[*blockCursor]("measure-blockCursor")
 
 func generateBlockCursor() *blockCursor {
@@ -798,6 +825,54 @@ func (bi *blockPointer) updateMetadata() {
 	bi.bm.timestamps.max = bi.block.timestamps[len(bi.timestamps)-1]
 }
 
+// removeDeleted removes the data points which are deleted by the tombstones from the block of the part.
+func (bi *blockPointer) removeDeleted(partID uint64, tombstones *storage.Tombstones) {
+	count := len(bi.timestamps)
+	if count == 0 || !tombstones.Overlaps(bi.timestamps[0], bi.timestamps[count-1]) {
+		return
+	}
+	bi.retain(func(i int) bool {
+		return !tombstones.IsDeleted(partID, uint64(bi.bm.seriesID), bi.timestamps[i])
+	})
+}
+
+// retain keeps the data points at the indexes which the keep function returns true for.
+func (bi *blockPointer) retain(keep func(i int) bool) {
+	count := len(bi.timestamps)
+	kept := make([]int, 0, count)
+	for i := 0; i < count; i++ {
+		if keep(i) {
+			kept = append(kept, i)
+		}
+	}
+	if len(kept) == count {
+		return
+	}
+	for n, i := range kept {
+		bi.timestamps[n] = bi.timestamps[i]
+		bi.versions[n] = bi.versions[i]
+	}
+	bi.timestamps = bi.timestamps[:len(kept)]
+	bi.versions = bi.versions[:len(kept)]
+	retainColumns := func(columns []column) {
+		for j := range columns {
+			c := &columns[j]
+			if len(c.values) != count {
+				continue
+			}
+			for n, k := range kept {
+				c.values[n] = c.values[k]
+			}
+			c.values = c.values[:len(kept)]
+		}
+	}
+	for i := range bi.tagFamilies {
+		retainColumns(bi.tagFamilies[i].columns)
+	}
+	retainColumns(bi.field.columns)
+	bi.updateMetadata()
+}
+
 func (bi *blockPointer) copyFrom(src *blockPointer) {
 	bi.reset()
 	bi.bm.copyFrom(&src.bm)
@@ -372,7 +372,7 @@ func (m *measure) QueryWithCache(ctx context.Context, mqo model.MeasureQueryOpti
 		result.snapshots = append(result.snapshots, s)
 	}
 
-	if err = m.searchBlocks(ctx, &result, sids, parts, qo); err != nil {
+	if err = m.searchBlocks(ctx, &result, sids, parts, nil, qo); err != nil {
 		return nil, err
 	}
 
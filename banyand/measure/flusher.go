@@ -134,7 +134,7 @@ func (tst *tsTable) flush(snapshot *snapshot, flushCh chan *flusherIntroduction)
 	start := time.Now()
 	partsCount := 0
 	for _, pw := range snapshot.parts {
-		if pw.mp == nil || pw.mp.partMetadata.TotalCount < 1 {
+		if pw.mp == nil || (pw.mp.partMetadata.TotalCount < 1 && pw.mp.tombstones.Len() == 0) {
 			continue
 		}
 		partsCount++
@@ -169,7 +169,7 @@ func (tst *tsTable) persistSnapshot(snapshot *snapshot) {
 	for i := range snapshot.parts {
 		partNames = append(partNames, partName(snapshot.parts[i].ID()))
 	}
-	tst.mustWriteSnapshot(snapshot.epoch, partNames)
+	tst.mustWriteSnapshot(snapshot.epoch, partNames, tst.walMarkToPersist())
 	tst.gc.registerSnapshot(snapshot)
 }
 
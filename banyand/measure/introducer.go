@@ -24,11 +24,13 @@ import (
 
 type introduction struct {
 	memPart *partWrapper
+	removed map[uint64]struct{}
 	applied chan struct{}
 }
 
 func (i *introduction) reset() {
 	i.memPart = nil
+	i.removed = nil
 	i.applied = nil
 }
 
@@ -191,11 +193,19 @@ func (tst *tsTable) introduceMemPart(nextIntroduction *introduction, epoch uint6
 		cur = new(snapshot)
 	}
 
-	next := nextIntroduction.memPart
-	nextSnp := cur.copyAllTo(epoch)
-	nextSnp.parts = append(nextSnp.parts, next)
+	var nextSnp snapshot
+	if len(nextIntroduction.removed) > 0 {
+		nextSnp = cur.remove(epoch, nextIntroduction.removed)
+	} else {
+		nextSnp = cur.copyAllTo(epoch)
+	}
+	// An introduction without a mem part carries the removed parts.
+	if next := nextIntroduction.memPart; next != nil {
+		nextSnp.parts = append(nextSnp.parts, next)
+	}
+	nextSnp.tombstones = nextSnp.unionTombstones()
 	nextSnp.creator = snapshotCreatorMemPart
-	tst.replaceSnapshot(&nextSnp, false)
+	tst.replaceSnapshot(&nextSnp, len(nextIntroduction.removed) > 0)
 	if nextIntroduction.applied != nil {
 		close(nextIntroduction.applied)
 	}
@@ -208,8 +218,16 @@ func (tst *tsTable) introduceFlushed(nextIntroduction *flusherIntroduction, epoc
 	}
 	defer cur.decRef()
 	nextSnp := cur.merge(epoch, nextIntroduction.flushed)
+	nextSnp.tombstones = nextSnp.unionTombstones()
 	nextSnp.creator = snapshotCreatorFlusher
+	advanced := tst.advanceWALMark(func(partID uint64) bool {
+		_, ok := nextIntroduction.flushed[partID]
+		return ok
+	})
 	tst.replaceSnapshot(&nextSnp, true)
+	if advanced {
+		tst.truncateWAL(epoch)
+	}
 	if nextIntroduction.applied != nil {
 		close(nextIntroduction.applied)
 	}
@@ -222,10 +240,33 @@ func (tst *tsTable) introduceMerged(nextIntroduction *mergerIntroduction, epoch
 		return
 	}
 	defer cur.decRef()
+	if !cur.containsAll(nextIntroduction.merged) {
+		// The merged parts have been removed since the merge started, like the parts moved to another node.
+		nextIntroduction.newPart.removable.Store(true)
+		nextIntroduction.newPart.decRef()
+		if nextIntroduction.applied != nil {
+			close(nextIntroduction.applied)
+		}
+		return
+	}
 	nextSnp := cur.remove(epoch, nextIntroduction.merged)
-	nextSnp.parts = append(nextSnp.parts, nextIntroduction.newPart)
+	if newPart := nextIntroduction.newPart; newPart.p.partMetadata.TotalCount > 0 || newPart.p.tombstones.Len() > 0 {
+		nextSnp.parts = append(nextSnp.parts, newPart)
+	} else {
+		// All rows of the merged parts are deleted, and their tombstones delete nothing else.
+		newPart.removable.Store(true)
+		newPart.decRef()
+	}
+	nextSnp.tombstones = nextSnp.unionTombstones()
 	nextSnp.creator = nextIntroduction.creator
+	advanced := tst.advanceWALMark(func(partID uint64) bool {
+		_, ok := nextIntroduction.merged[partID]
+		return ok
+	})
 	tst.replaceSnapshot(&nextSnp, true)
+	if advanced {
+		tst.truncateWAL(epoch)
+	}
 	if nextIntroduction.applied != nil {
 		close(nextIntroduction.applied)
 	}
@@ -239,6 +280,7 @@ func (tst *tsTable) introduceSync(nextIntroduction *syncIntroduction, epoch uint
 	}
 	defer cur.decRef()
 	nextSnp := cur.remove(epoch, nextIntroduction.synced)
+	nextSnp.tombstones = nextSnp.unionTombstones()
 	nextSnp.creator = snapshotCreatorSyncer
 	tst.replaceSnapshot(&nextSnp, true)
 	if nextIntroduction.applied != nil {
@@ -32,6 +32,7 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/partition"
 	"github.com/apache/skywalking-banyandb/pkg/run"
 	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+	"github.com/apache/skywalking-banyandb/pkg/wal"
 )
 
 const (
@@ -44,6 +45,8 @@ const (
 
 	defaultFlushTimeout = 5 * time.Second
 	defaultSyncInterval = 30 * time.Second
+
+	defaultWALSyncInterval = time.Second
 )
 
 type option struct {
@@ -51,8 +54,10 @@ type option struct {
 	tire2Client        queue.Client
 	mergePolicy        *mergePolicy
 	seriesCacheMaxSize run.Bytes
+	walOptions         wal.Options
 	flushTimeout       time.Duration
 	syncInterval       time.Duration
+	walEnabled         bool
 }
 
 type indexSchema struct {
@@ -20,11 +20,13 @@ package measure
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
 	"sync/atomic"
 	"time"
 
 	"github.com/dustin/go-humanize"
 
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
 	"github.com/apache/skywalking-banyandb/pkg/cgroups"
 	"github.com/apache/skywalking-banyandb/pkg/encoding"
 	"github.com/apache/skywalking-banyandb/pkg/fs"
@@ -90,6 +92,8 @@ func (tst *tsTable) mergeLoop(merges chan *mergerIntroduction, flusherNotifier w
 func (tst *tsTable) mergeSnapshot(curSnapshot *snapshot, merges chan *mergerIntroduction, dst []*partWrapper) ([]*partWrapper, error) {
 	freeDiskSize := tst.freeDiskSpace(tst.root)
 	var toBeMerged map[uint64]struct{}
+	tst.mergeMu.Lock()
+	defer tst.mergeMu.Unlock()
 	dst, toBeMerged = tst.getPartsToMerge(curSnapshot, freeDiskSize, dst)
 	if len(dst) < 2 {
 		return nil, nil
@@ -107,7 +111,8 @@ func (tst *tsTable) mergePartsThenSendIntroduction(creator snapshotCreator, part
 	reservedSpace := tst.reserveSpace(parts)
 	defer releaseDiskSpace(reservedSpace)
 	start := time.Now()
-	newPart, err := tst.mergeParts(tst.fileSystem, closeCh, parts, atomic.AddUint64(&tst.curPartID, 1), tst.root)
+	partID, tombstones, carried := tst.allocateMergedPartID(merged)
+	newPart, err := tst.mergeParts(tst.fileSystem, closeCh, parts, partID, tombstones, carried, tst.root)
 	if err != nil {
 		return nil, err
 	}
@@ -203,7 +208,10 @@ func (tst *tsTable) getPartsToMerge(snapshot *snapshot, freeDiskSize uint64, dst
 	var parts []*partWrapper
 
 	for _, pw := range snapshot.parts {
-		if pw.mp != nil || pw.p.partMetadata.TotalCount < 1 {
+		if pw.mp != nil || (pw.p.partMetadata.TotalCount < 1 && pw.p.tombstones.Len() == 0) {
+			continue
+		}
+		if _, ok := tst.pinned[pw.ID()]; ok {
 			continue
 		}
 		parts = append(parts, pw)
@@ -234,7 +242,25 @@ func (tst *tsTable) reserveSpace(parts []*partWrapper) uint64 {
 
 var errNoPartToMerge = fmt.Errorf("no part to merge")
 
-func (tst *tsTable) mergeParts(fileSystem fs.FileSystem, closeCh <-chan struct{}, parts []*partWrapper, partID uint64, root string) (*partWrapper, error) {
+// allocateMergedPartID allocates the ID of the part which merges the parts.
+// It returns the tombstones which delete the rows of the merged parts,
+// and the ones which the merged part carries since they still delete the rows of the other parts.
+// The tombstones added afterward also delete the rows in the merged part.
+func (tst *tsTable) allocateMergedPartID(merged map[uint64]struct{}) (uint64, *storage.Tombstones, *storage.Tombstones) {
+	tst.tombstoneMu.Lock()
+	defer tst.tombstoneMu.Unlock()
+	partID := tst.nextPartID()
+	snp := tst.currentSnapshot()
+	if snp == nil {
+		return partID, nil, nil
+	}
+	defer snp.decRef()
+	return partID, snp.tombstones, snp.carriedTombstones(merged)
+}
+
+func (tst *tsTable) mergeParts(fileSystem fs.FileSystem, closeCh <-chan struct{}, parts []*partWrapper, partID uint64,
+	tombstones, carried *storage.Tombstones, root string,
+) (*partWrapper, error) {
 	if len(parts) == 0 {
 		return nil, errNoPartToMerge
 	}
@@ -244,6 +270,7 @@ func (tst *tsTable) mergeParts(fileSystem fs.FileSystem, closeCh <-chan struct{}
 	for i := range parts {
 		pmi := generatePartMergeIter()
 		pmi.mustInitFromPart(parts[i].p)
+		pmi.tombstones = tombstones
 		pii = append(pii, pmi)
 		totalSize += int64(parts[i].p.partMetadata.CompressedSizeBytes)
 	}
@@ -262,6 +289,12 @@ func (tst *tsTable) mergeParts(fileSystem fs.FileSystem, closeCh <-chan struct{}
 	if err != nil {
 		return nil, err
 	}
+	if carried.Len() > 0 {
+		fs.MustFlush(fileSystem, carried.Marshal(nil), filepath.Join(dstPath, tombstonesFilename), storage.FilePerm)
+		if pm.TotalCount == 0 {
+			pm.MinTimestamp, pm.MaxTimestamp = carried.TimeRange()
+		}
+	}
 	pm.mustWriteMetadata(fileSystem, dstPath)
 	fileSystem.SyncPath(dstPath)
 	p := mustOpenFilePart(partID, root, fileSystem)
@@ -299,7 +332,8 @@ func mergeBlocks(closeCh <-chan struct{}, bw *blockWriter, br *blockReader) (*pa
 		if pendingBlockIsEmpty {
 			br.loadBlockData(getDecoder())
 			pendingBlock.copyFrom(b)
-			pendingBlockIsEmpty = false
+			// The block might be empty if all its data points are deleted.
+			pendingBlockIsEmpty = len(pendingBlock.timestamps) == 0
 			continue
 		}
 
@@ -309,6 +343,7 @@ func mergeBlocks(closeCh <-chan struct{}, bw *blockWriter, br *blockReader) (*pa
 			releaseDecoder()
 			br.loadBlockData(getDecoder())
 			pendingBlock.copyFrom(b)
+			pendingBlockIsEmpty = len(pendingBlock.timestamps) == 0
 			continue
 		}
 
@@ -27,6 +27,7 @@ import (
 	"github.com/apache/skywalking-banyandb/api/common"
 	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
 	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/banyand/repair"
 	"github.com/apache/skywalking-banyandb/pkg/bytes"
 	"github.com/apache/skywalking-banyandb/pkg/fs"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
@@ -38,6 +39,7 @@ const (
 	measurePrimaryName       = "primary"
 	measureMetaName          = "meta"
 	measureTimestampsName    = "timestamps"
+	measureTombstonesName    = "tombstones"
 	measureFieldValuesName   = "fv"
 	measureTagFamiliesPrefix = "tf:"
 	measureTagMetadataPrefix = "tfm:"
@@ -46,6 +48,7 @@ const (
 	primaryFilename                = measurePrimaryName + ".bin"
 	metaFilename                   = measureMetaName + ".bin"
 	timestampsFilename             = measureTimestampsName + ".bin"
+	tombstonesFilename             = measureTombstonesName + ".bin"
 	fieldValuesFilename            = measureFieldValuesName + ".bin"
 	tagFamiliesMetadataFilenameExt = ".tfm"
 	tagFamiliesFilenameExt         = ".tf"
@@ -59,6 +62,8 @@ type part struct {
 	tagFamilyMetadata    map[string]fs.Reader
 	tagFamilies          map[string]fs.Reader
 	cache                storage.Cache
+	tombstones           *storage.Tombstones
+	repairDigests        atomic.Pointer[repair.SlotDigests]
 	path                 string
 	primaryBlockMetadata []primaryBlockMetadata
 	partMetadata         partMetadata
@@ -83,6 +88,7 @@ func (p *part) String() string {
 func openMemPart(mp *memPart) *part {
 	var p part
 	p.partMetadata = mp.partMetadata
+	p.tombstones = mp.tombstones
 
 	p.primaryBlockMetadata = mustReadPrimaryBlockMetadata(p.primaryBlockMetadata[:0], &mp.meta)
 
@@ -104,6 +110,7 @@ func openMemPart(mp *memPart) *part {
 type memPart struct {
 	tagFamilyMetadata map[string]*bytes.Buffer
 	tagFamilies       map[string]*bytes.Buffer
+	tombstones        *storage.Tombstones
 	meta              bytes.Buffer
 	primary           bytes.Buffer
 	timestamps        bytes.Buffer
@@ -130,6 +137,7 @@ func (mp *memPart) mustCreateMemTagFamilyWriters(name string) (fs.Writer, fs.Wri
 
 func (mp *memPart) reset() {
 	mp.partMetadata.reset()
+	mp.tombstones = nil
 	mp.meta.Reset()
 	mp.primary.Reset()
 	mp.timestamps.Reset()
@@ -206,6 +214,9 @@ func (mp *memPart) mustFlush(fileSystem fs.FileSystem, path string) {
 	for name, tfh := range mp.tagFamilyMetadata {
 		fs.MustFlush(fileSystem, tfh.Buf, filepath.Join(path, name+tagFamiliesMetadataFilenameExt), storage.FilePerm)
 	}
+	if mp.tombstones.Len() > 0 {
+		fs.MustFlush(fileSystem, mp.tombstones.Marshal(nil), filepath.Join(path, tombstonesFilename), storage.FilePerm)
+	}
 
 	mp.partMetadata.mustWriteMetadata(fileSystem, path)
 
@@ -322,6 +333,9 @@ func mustOpenFilePart(id uint64, root string, fileSystem fs.FileSystem) *part {
 			}
 			p.tagFamilies[removeExt(e.Name(), tagFamiliesFilenameExt)] = mustOpenReader(path.Join(partPath, e.Name()), fileSystem)
 		}
+		if e.Name() == tombstonesFilename {
+			p.tombstones = mustReadTombstones(path.Join(partPath, e.Name()), fileSystem)
+		}
 	}
 	return &p
 }
@@ -334,6 +348,18 @@ func mustOpenReader(name string, fileSystem fs.FileSystem) fs.Reader {
 	return f
 }
 
+func mustReadTombstones(name string, fileSystem fs.FileSystem) *storage.Tombstones {
+	data, err := fileSystem.Read(name)
+	if err != nil {
+		logger.Panicf("cannot read %q: %s", name, err)
+	}
+	ts, err := storage.UnmarshalTombstones(data)
+	if err != nil {
+		logger.Panicf("cannot unmarshal %q: %s", name, err)
+	}
+	return ts
+}
+
 func removeExt(nameWithExt, ext string) string {
 	return nameWithExt[:len(nameWithExt)-len(ext)]
 }
@@ -379,6 +405,18 @@ func CreatePartFileReaderFromPath(partPath string, lfs fs.FileSystem) ([]queue.F
 		if e.IsDir() {
 			continue
 		}
+		if e.Name() == tombstonesFilename {
+			tombstonesPath := path.Join(partPath, e.Name())
+			tombstonesReader, err := lfs.OpenFile(tombstonesPath)
+			if err != nil {
+				logger.Panicf("cannot open tombstones file %q: %s", tombstonesPath, err)
+			}
+			readers = append(readers, tombstonesReader)
+			files = append(files, queue.FileInfo{
+				Name:   measureTombstonesName,
+				Reader: tombstonesReader.SequentialRead(),
+			})
+		}
 
 		// Tag family metadata files (.tfm)
 		if filepath.Ext(e.Name()) == tagFamiliesMetadataFilenameExt {
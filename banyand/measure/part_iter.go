@@ -252,6 +252,8 @@ func (pi *partIter) findBlock() bool {
 type partMergeIter struct {
 	seqReaders           seqReaders
 	err                  error
+	tombstones           *storage.Tombstones
+	keep                 func(sid common.SeriesID, timestamp, version int64) bool
 	primaryBlockMetadata []primaryBlockMetadata
 	compressedPrimaryBuf []byte
 	primaryBuf           []byte
@@ -262,6 +264,8 @@ type partMergeIter struct {
 
 func (pmi *partMergeIter) reset() {
 	pmi.err = nil
+	pmi.tombstones = nil
+	pmi.keep = nil
 	pmi.seqReaders.reset()
 	pmi.primaryBlockMetadata = nil
 	pmi.primaryMetadataIdx = 0
@@ -332,6 +336,13 @@ func (pmi *partMergeIter) loadBlockMetadata() error {
 
 func (pmi *partMergeIter) mustLoadBlockData(decoder *encoding.BytesBlockDecoder, block *blockPointer) {
 	block.block.mustSeqReadFrom(decoder, &pmi.seqReaders, pmi.block.bm)
+	block.removeDeleted(pmi.partID, pmi.tombstones)
+	if pmi.keep != nil && len(block.timestamps) > 0 {
+		sid := pmi.block.bm.seriesID
+		block.retain(func(i int) bool {
+			return pmi.keep(sid, block.timestamps[i], block.versions[i])
+		})
+	}
 }
 
 func generatePartMergeIter() *partMergeIter {
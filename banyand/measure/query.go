@@ -66,6 +66,7 @@ var _ Measure = (*measure)(nil)
 
 type queryOptions struct {
 	model.MeasureQueryOptions
+	tombstones   *storage.Tombstones
 	minTimestamp int64
 	maxTimestamp int64
 }
@@ -77,16 +78,9 @@ func (m *measure) Query(ctx context.Context, mqo model.MeasureQueryOptions) (mqr
 	if len(mqo.TagProjection) == 0 && len(mqo.FieldProjection) == 0 {
 		return nil, errors.New("invalid query options: tagProjection or fieldProjection is required")
 	}
-	var tsdb storage.TSDB[*tsTable, option]
-	db := m.tsdb.Load()
-	if db == nil {
-		tsdb, err = m.schemaRepo.loadTSDB(m.group)
-		if err != nil {
-			return nil, err
-		}
-		m.tsdb.Store(tsdb)
-	} else {
-		tsdb = db.(storage.TSDB[*tsTable, option])
+	tsdb, err := m.getTSDB()
+	if err != nil {
+		return nil, err
 	}
 
 	segments, err := tsdb.SelectSegments(*mqo.TimeRange)
@@ -142,6 +136,7 @@ func (m *measure) Query(ctx context.Context, mqo model.MeasureQueryOptions) (mqr
 		maxTimestamp:        mqo.TimeRange.End.UnixNano(),
 	}
 	var n int
+	tombstoneIndex := make(map[*part]*storage.Tombstones)
 	for i := range tables {
 		s := tables[i].currentSnapshot()
 		if s == nil {
@@ -152,10 +147,15 @@ func (m *measure) Query(ctx context.Context, mqo model.MeasureQueryOptions) (mqr
 			s.decRef()
 			continue
 		}
+		if s.tombstones.Len() > 0 {
+			for _, p := range parts[len(parts)-n:] {
+				tombstoneIndex[p] = s.tombstones
+			}
+		}
 		result.snapshots = append(result.snapshots, s)
 	}
 
-	if err = m.searchBlocks(ctx, &result, sids, parts, qo); err != nil {
+	if err = m.searchBlocks(ctx, &result, sids, parts, tombstoneIndex, qo); err != nil {
 		return nil, err
 	}
 
@@ -184,6 +184,22 @@ type tagNameWithType struct {
 	typ       pbv1.ValueType
 }
 
+func (m *measure) getTSDB() (storage.TSDB[*tsTable, option], error) {
+	var tsdb storage.TSDB[*tsTable, option]
+	db := m.tsdb.Load()
+	if db == nil {
+		var err error
+		tsdb, err = m.schemaRepo.loadTSDB(m.group)
+		if err != nil {
+			return nil, err
+		}
+		m.tsdb.Store(tsdb)
+	} else {
+		tsdb = db.(storage.TSDB[*tsTable, option])
+	}
+	return tsdb, nil
+}
+
 func (m *measure) searchSeriesList(ctx context.Context, series []*pbv1.Series, mqo model.MeasureQueryOptions,
 	segments []storage.Segment[*tsTable, option],
 ) (sl []common.SeriesID, tables []*tsTable, caches []storage.Cache, storedIndexValue map[common.SeriesID]map[string]*modelv1.TagValue,
@@ -269,7 +285,7 @@ func (m *measure) searchSeriesList(ctx context.Context, series []*pbv1.Series, m
 			}
 			for f, v := range sd.Fields[j] {
 				if tnt, ok := fieldToValueType[f]; ok {
-					tagValues[tnt.fieldName] = mustDecodeTagValue(tnt.typ, v)
+					tagValues[tnt.fieldName] = mustDecodeIndexedTagValue(tnt.typ, v)
 				} else {
 					logger.Panicf("unknown field %s not found in fieldToValueType", f)
 				}
@@ -360,7 +376,9 @@ func (m *measure) buildIndexQueryResult(ctx context.Context, mqo model.MeasureQu
 	return r, nil
 }
 
-func (m *measure) searchBlocks(ctx context.Context, result *queryResult, sids []common.SeriesID, parts []*part, qo queryOptions) error {
+func (m *measure) searchBlocks(ctx context.Context, result *queryResult, sids []common.SeriesID, parts []*part,
+	tombstoneIndex map[*part]*storage.Tombstones, qo queryOptions,
+) error {
 	defFn := startBlockScanSpan(ctx, len(sids), parts, result)
 	defer defFn()
 	tstIter := generateTstIter()
@@ -386,6 +404,7 @@ func (m *measure) searchBlocks(ctx context.Context, result *queryResult, sids []
 		hit++
 		bc := generateBlockCursor()
 		p := tstIter.piHeap[0]
+		qo.tombstones = tombstoneIndex[p.p]
 		bc.init(p.p, p.curBlock, qo)
 		result.data = append(result.data, bc)
 		totalBlockBytes += bc.bm.uncompressedSizeBytes
@@ -413,6 +432,8 @@ func mustDecodeTagValue(valueType pbv1.ValueType, value []byte) *modelv1.TagValu
 	switch valueType {
 	case pbv1.ValueTypeInt64:
 		return int64TagValue(convert.BytesToInt64(value))
+	case pbv1.ValueTypeFloat64:
+		return float64TagValue(convert.BytesToFloat64(value))
 	case pbv1.ValueTypeStr:
 		return strTagValue(string(value))
 	case pbv1.ValueTypeBinaryData:
@@ -441,6 +462,24 @@ func mustDecodeTagValue(valueType pbv1.ValueType, value []byte) *modelv1.TagValu
 	}
 }
 
+// mustDecodeIndexedTagValue decodes a tag value stored in the index, which is encoded by indexedTagValue.
+func mustDecodeIndexedTagValue(valueType pbv1.ValueType, value []byte) *modelv1.TagValue {
+	if valueType == pbv1.ValueTypeFloat64 && value != nil {
+		return float64TagValue(convert.SortableBytesToFloat64(value))
+	}
+	return mustDecodeTagValue(valueType, value)
+}
+
+func float64TagValue(value float64) *modelv1.TagValue {
+	return &modelv1.TagValue{
+		Value: &modelv1.TagValue_Float{
+			Float: &modelv1.Float{
+				Value: value,
+			},
+		},
+	}
+}
+
 func int64TagValue(value int64) *modelv1.TagValue {
 	return &modelv1.TagValue{
 		Value: &modelv1.TagValue_Int{
@@ -825,7 +864,7 @@ func (iqr *indexSortResult) copyTo(src *segResult) (dest *model.MeasureResult) {
 			if tnt, ok := iqr.tfl[i].fieldToValueType[n]; ok {
 				tagFamily.Tags = append(tagFamily.Tags, model.Tag{
 					Name:   n,
-					Values: []*modelv1.TagValue{mustDecodeTagValue(tnt.typ, fr[tnt.fieldName])},
+					Values: []*modelv1.TagValue{mustDecodeIndexedTagValue(tnt.typ, fr[tnt.fieldName])},
 				})
 			} else {
 				logger.Panicf("unknown field %s not found in fieldToValueType", n)
@@ -1480,7 +1480,7 @@ func TestQueryResult_QuotaExceeded(t *testing.T) {
 			result.ctx = context.TODO()
 			// Query all tags
 			result.tagProjection = allTagProjections
-			err = m.searchBlocks(context.TODO(), &result, tt.sids, pp, queryOpts)
+			err = m.searchBlocks(context.TODO(), &result, tt.sids, pp, nil, queryOpts)
 			if tt.expectQuotaExceeded {
 				require.Error(t, err)
 				require.Contains(t, err.Error(), "quota exceeded", "expected quota to be exceeded but got: %v", err)
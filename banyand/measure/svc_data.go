@@ -40,6 +40,7 @@ import (
 	"github.com/apache/skywalking-banyandb/banyand/observability"
 	"github.com/apache/skywalking-banyandb/banyand/protector"
 	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/banyand/queue/pub"
 	"github.com/apache/skywalking-banyandb/pkg/bus"
 	"github.com/apache/skywalking-banyandb/pkg/fs"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
@@ -66,9 +67,14 @@ type dataSVC struct {
 	schemaRepo          *schemaRepo
 	l                   *logger.Logger
 	cm                  *cacheMetrics
+	clientTLS           *pub.ClientTLS
+	movedParts          movedParts
 	root                string
 	dataPath            string
 	snapshotDir         string
+	moveDir             string
+	repairDir           string
+	nodeID              string
 	option              option
 	cc                  storage.CacheConfig
 	maxDiskUsagePercent int
@@ -96,6 +102,10 @@ func (s *dataSVC) FlagSet() *run.FlagSet {
 	flagS.StringVar(&s.root, "measure-root-path", "/tmp", "the root path of measure")
 	flagS.StringVar(&s.dataPath, "measure-data-path", "", "the data directory path of measure. If not set, <measure-root-path>/measure/data will be used")
 	flagS.DurationVar(&s.option.flushTimeout, "measure-flush-timeout", defaultFlushTimeout, "the memory data timeout of measure")
+	flagS.BoolVar(&s.option.walEnabled, "measure-wal-enabled", false, "enable the write-ahead log of measure to recover the unflushed data after a crash")
+	flagS.Var(&s.option.walOptions.SyncPolicy, "measure-wal-sync-policy", "the sync policy of the measure write-ahead log: always, interval or none")
+	flagS.DurationVar(&s.option.walOptions.SyncInterval, "measure-wal-sync-interval", defaultWALSyncInterval,
+		"the sync interval of the measure write-ahead log if the sync policy is interval")
 	s.option.mergePolicy = newDefaultMergePolicy()
 	flagS.VarP(&s.option.mergePolicy.maxFanOutSize, "measure-max-fan-out-size", "", "the upper bound of a single file size after merge of measure")
 	s.option.seriesCacheMaxSize = run.Bytes(32 << 20)
@@ -145,6 +155,8 @@ func (s *dataSVC) PreRun(ctx context.Context) error {
 	s.lfs = fs.NewLocalFileSystemWithLoggerAndLimit(s.l, s.pm.GetLimit())
 	path := path.Join(s.root, s.Name())
 	s.snapshotDir = filepath.Join(path, storage.SnapshotsDir)
+	s.moveDir = filepath.Join(path, shardMoveDir)
+	s.repairDir = filepath.Join(path, replicaRepairDir)
 	observability.UpdatePath(path)
 	if s.dataPath == "" {
 		s.dataPath = filepath.Join(path, storage.DataDir)
@@ -162,6 +174,7 @@ func (s *dataSVC) PreRun(ctx context.Context) error {
 		s.c = storage.NewServiceCacheWithConfig(s.cc)
 	}
 	node := val.(common.Node)
+	s.nodeID = node.NodeID
 	s.schemaRepo = newDataSchemaRepo(s.dataPath, s, node.Labels)
 
 	s.cm = newCacheMetrics(s.omr)
@@ -183,6 +196,14 @@ func (s *dataSVC) PreRun(ctx context.Context) error {
 		return err
 	}
 
+	if err := s.pipeline.Subscribe(data.TopicMeasureDelete, &dataDeleteDataPointsListener{s: s}); err != nil {
+		return err
+	}
+
+	if err := s.pipeline.Subscribe(data.TopicMeasureShardMove, &moveShardsListener{s: s}); err != nil {
+		return err
+	}
+
 	s.pipeline.RegisterChunkedSyncHandler(data.TopicMeasurePartSync, setUpChunkedSyncCallback(s.l, s.schemaRepo))
 	s.pipeline.RegisterChunkedSyncHandler(data.TopicMeasureSeriesSync, setUpSyncSeriesCallback(s.l, s.schemaRepo))
 	err := s.pipeline.Subscribe(data.TopicMeasureSeriesIndexInsert, setUpIndexCallback(s.l, s.schemaRepo, data.TopicMeasureSeriesIndexInsert))
@@ -421,3 +442,26 @@ func (d *dataDeleteStreamSegmentsListener) Rev(_ context.Context, message bus.Me
 	deleted := db.DeleteExpiredSegments(timestamp.NewSectionTimeRange(req.TimeRange.Begin.AsTime(), req.TimeRange.End.AsTime()))
 	return bus.NewMessage(bus.MessageID(time.Now().UnixNano()), deleted)
 }
+
+type dataDeleteDataPointsListener struct {
+	*bus.UnImplementedHealthyListener
+	s *dataSVC
+}
+
+func (d *dataDeleteDataPointsListener) Rev(_ context.Context, message bus.Message) bus.Message {
+	now := time.Now().UnixNano()
+	req, ok := message.Data().(*measurev1.InternalDeleteRequest)
+	if !ok || req == nil {
+		return bus.NewMessage(bus.MessageID(now), common.NewError("invalid delete request"))
+	}
+	db, err := d.s.schemaRepo.loadTSDB(req.Group)
+	if err != nil {
+		d.s.l.Error().Err(err).Str("group", req.Group).Msg("failed to load tsdb")
+		return bus.NewMessage(bus.MessageID(now), common.NewError("failed to load tsdb of group %s: %v", req.Group, err))
+	}
+	deleted, err := deleteDataPoints(db, req.Tombstones)
+	if err != nil {
+		return bus.NewMessage(bus.MessageID(now), common.NewError("failed to delete data points of %s/%s: %v", req.Group, req.Name, err))
+	}
+	return bus.NewMessage(bus.MessageID(now), &measurev1.DeleteResponse{Deleted: deleted})
+}
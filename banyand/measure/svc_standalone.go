@@ -30,11 +30,13 @@ import (
 	"github.com/apache/skywalking-banyandb/api/data"
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
 	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
 	"github.com/apache/skywalking-banyandb/banyand/metadata"
 	"github.com/apache/skywalking-banyandb/banyand/observability"
 	"github.com/apache/skywalking-banyandb/banyand/protector"
 	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
 	"github.com/apache/skywalking-banyandb/pkg/fs"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	"github.com/apache/skywalking-banyandb/pkg/run"
@@ -100,6 +102,10 @@ func (s *standalone) FlagSet() *run.FlagSet {
 	flagS.StringVar(&s.root, "measure-root-path", "/tmp", "the root path of measure")
 	flagS.StringVar(&s.dataPath, "measure-data-path", "", "the data directory path of measure. If not set, <measure-root-path>/measure/data will be used")
 	flagS.DurationVar(&s.option.flushTimeout, "measure-flush-timeout", defaultFlushTimeout, "the memory data timeout of measure")
+	flagS.BoolVar(&s.option.walEnabled, "measure-wal-enabled", false, "enable the write-ahead log of measure to recover the unflushed data after a crash")
+	flagS.Var(&s.option.walOptions.SyncPolicy, "measure-wal-sync-policy", "the sync policy of the measure write-ahead log: always, interval or none")
+	flagS.DurationVar(&s.option.walOptions.SyncInterval, "measure-wal-sync-interval", defaultWALSyncInterval,
+		"the sync interval of the measure write-ahead log if the sync policy is interval")
 	s.option.mergePolicy = newDefaultMergePolicy()
 	flagS.VarP(&s.option.mergePolicy.maxFanOutSize, "measure-max-fan-out-size", "", "the upper bound of a single file size after merge of measure")
 	s.option.seriesCacheMaxSize = run.Bytes(32 << 20)
@@ -184,6 +190,10 @@ func (s *standalone) PreRun(ctx context.Context) error {
 		return err
 	}
 
+	if err := s.pipeline.Subscribe(data.TopicMeasureDelete, &deleteDataPointsListener{s: s}); err != nil {
+		return err
+	}
+
 	writeListener := setUpWriteCallback(s.l, s.schemaRepo, s.maxDiskUsagePercent)
 	// only subscribe metricPipeline for data node
 	if s.metricPipeline != nil {
@@ -245,3 +255,26 @@ func NewStandalone(metadata metadata.Repo, pipeline queue.Server, metricPipeline
 		pm:             pm,
 	}, nil
 }
+
+type deleteDataPointsListener struct {
+	*bus.UnImplementedHealthyListener
+	s *standalone
+}
+
+func (d *deleteDataPointsListener) Rev(_ context.Context, message bus.Message) bus.Message {
+	now := time.Now().UnixNano()
+	req, ok := message.Data().(*measurev1.InternalDeleteRequest)
+	if !ok || req == nil {
+		return bus.NewMessage(bus.MessageID(now), common.NewError("invalid delete request"))
+	}
+	db, err := d.s.schemaRepo.loadTSDB(req.Group)
+	if err != nil {
+		d.s.l.Error().Err(err).Str("group", req.Group).Msg("failed to load tsdb")
+		return bus.NewMessage(bus.MessageID(now), common.NewError("failed to load tsdb of group %s: %v", req.Group, err))
+	}
+	deleted, err := deleteDataPoints(db, req.Tombstones)
+	if err != nil {
+		return bus.NewMessage(bus.MessageID(now), common.NewError("failed to delete data points of %s/%s: %v", req.Group, req.Name, err))
+	}
+	return bus.NewMessage(bus.MessageID(now), &measurev1.DeleteResponse{Deleted: deleted})
+}
@@ -181,7 +181,7 @@ func createPartFileReaders(part *part) ([]queue.FileInfo, func()) {
 func (tst *tsTable) syncSnapshot(curSnapshot *snapshot, syncCh chan *syncIntroduction) error {
 	var partsToSync []*part
 	for _, pw := range curSnapshot.parts {
-		if pw.mp == nil && pw.p.partMetadata.TotalCount > 0 {
+		if pw.mp == nil && (pw.p.partMetadata.TotalCount > 0 || pw.p.tombstones.Len() > 0) {
 			partsToSync = append(partsToSync, pw.p)
 		}
 	}
@@ -35,9 +35,20 @@ type syncPartContext struct {
 	tsTable *tsTable
 	writers *writers
 	memPart *memPart
+	// tombstones buffers the marshaled tombstones of the part until it's complete.
+	tombstones []byte
 }
 
 func (s *syncPartContext) FinishSync() error {
+	if len(s.tombstones) > 0 {
+		tombstones, err := storage.UnmarshalTombstones(s.tombstones)
+		if err != nil {
+			releaseMemPart(s.memPart)
+			_ = s.Close()
+			return fmt.Errorf("cannot unmarshal the tombstones of the synced part: %w", err)
+		}
+		s.memPart.tombstones = tombstones
+	}
 	s.tsTable.mustAddMemPart(s.memPart)
 	return s.Close()
 }
@@ -48,6 +59,7 @@ func (s *syncPartContext) Close() error {
 	s.writers = nil
 	s.memPart = nil
 	s.tsTable = nil
+	s.tombstones = nil
 	return nil
 }
 
@@ -120,6 +132,8 @@ func (s *syncCallback) HandleFileChunk(ctx *queue.ChunkedSyncPartContext, chunk
 		partCtx.writers.primaryWriter.MustWrite(chunk)
 	case fileName == measureTimestampsName:
 		partCtx.writers.timestampsWriter.MustWrite(chunk)
+	case fileName == measureTombstonesName:
+		partCtx.tombstones = append(partCtx.tombstones, chunk...)
 	case fileName == measureFieldValuesName:
 		partCtx.writers.fieldValuesWriter.MustWrite(chunk)
 	case strings.HasPrefix(fileName, measureTagFamiliesPrefix):
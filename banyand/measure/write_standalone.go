@@ -285,7 +285,7 @@ func handleTagFamily(schema *databasev1.Measure, req *measurev1.WriteRequest, lo
 				fieldKey.IndexRuleID = r.GetMetadata().GetId()
 				fieldKey.Analyzer = r.Analyzer
 				if encodeTagValue.value != nil {
-					f := index.NewBytesField(fieldKey, encodeTagValue.value)
+					f := index.NewBytesField(fieldKey, indexedTagValue(encodeTagValue))
 					f.Store = true
 					f.Index = true
 					f.NoSort = r.GetNoSort()
@@ -299,6 +299,7 @@ func handleTagFamily(schema *databasev1.Measure, req *measurev1.WriteRequest, lo
 						fields = append(fields, f)
 					}
 				}
+				fields = appendTreeFields(fields, fieldKey, r, tagValue)
 				releaseNameValue(encodeTagValue)
 				continue
 			}
@@ -349,7 +350,7 @@ func handleIndexMode(schema *databasev1.Measure, req *measurev1.WriteRequest, lo
 				fieldKey.TagName = t.Name
 			}
 			if encodeTagValue.value != nil {
-				f := index.NewBytesField(fieldKey, encodeTagValue.value)
+				f := index.NewBytesField(fieldKey, indexedTagValue(encodeTagValue))
 				f.Store = true
 				f.Index = toIndex
 				f.NoSort = r.GetNoSort()
@@ -363,12 +364,32 @@ func handleIndexMode(schema *databasev1.Measure, req *measurev1.WriteRequest, lo
 					fields = append(fields, f)
 				}
 			}
+			if toIndex {
+				fields = appendTreeFields(fields, fieldKey, r, tagValue)
+			}
 			releaseNameValue(encodeTagValue)
 		}
 	}
 	return fields
 }
 
+// appendTreeFields indexes the ancestors of the string values if the tag is indexed by a tree index.
+func appendTreeFields(fields []index.Field, fieldKey index.FieldKey, r *databasev1.IndexRule, tagValue *modelv1.TagValue) []index.Field {
+	if r.GetType() != databasev1.IndexRule_TYPE_TREE {
+		return fields
+	}
+	sep := index.TreeSeparator(r)
+	if v := tagValue.GetStr(); v != nil {
+		return index.AppendTreeFields(fields, fieldKey, v.Value, sep)
+	}
+	if tagValue.GetStrArray() != nil {
+		for _, v := range tagValue.GetStrArray().Value {
+			fields = index.AppendTreeFields(fields, fieldKey, v, sep)
+		}
+	}
+	return fields
+}
+
 func appendEntityTagsToIndexFields(fields []index.Field, stm *measure, series *pbv1.Series) []index.Field {
 	f := index.NewStringField(subjectField, series.Subject)
 	f.Index = true
@@ -394,7 +415,7 @@ func appendEntityTagsToIndexFields(fields []index.Field, stm *measure, series *p
 			t.Type,
 			series.EntityValues[i])
 		if encodeTagValue.value != nil {
-			f = index.NewBytesField(index.FieldKey{TagName: index.IndexModeEntityTagPrefix + t.Name}, encodeTagValue.value)
+			f = index.NewBytesField(index.FieldKey{TagName: index.IndexModeEntityTagPrefix + t.Name}, indexedTagValue(encodeTagValue))
 			f.Index = true
 			f.NoSort = true
 			fields = append(fields, f)
@@ -404,6 +425,15 @@ func appendEntityTagsToIndexFields(fields []index.Field, stm *measure, series *p
 	return fields
 }
 
+// indexedTagValue returns the bytes of a tag value in the index.
+// Floats are converted to the sortable encoding so that the range queries keep the numeric order.
+func indexedTagValue(nv *nameValue) []byte {
+	if nv.valueType == pbv1.ValueTypeFloat64 {
+		return convert.Float64ToSortableBytes(convert.BytesToFloat64(nv.value))
+	}
+	return nv.value
+}
+
 func (w *writeCallback) Rev(_ context.Context, message bus.Message) (resp bus.Message) {
 	events, ok := message.Data().([]any)
 	if !ok {
@@ -504,6 +534,11 @@ func encodeTagValue(name string, tagType databasev1.TagType, tagValue *modelv1.T
 		if tagValue.GetInt() != nil {
 			nv.value = convert.Int64ToBytes(tagValue.GetInt().GetValue())
 		}
+	case databasev1.TagType_TAG_TYPE_FLOAT:
+		nv.valueType = pbv1.ValueTypeFloat64
+		if tagValue.GetFloat() != nil {
+			nv.value = convert.Float64ToBytes(tagValue.GetFloat().GetValue())
+		}
 	case databasev1.TagType_TAG_TYPE_STRING:
 		nv.valueType = pbv1.ValueTypeStr
 		if tagValue.GetStr() != nil {
@@ -35,6 +35,14 @@ type MessageListener interface {
 	Rev(ctx context.Context, tracer Trace, nextNode *grpc.ClientConn, request *propertyv1.PropagationRequest) error
 }
 
+// GroupListener is a MessageListener which handles the propagation messages of the groups it accepts.
+// The messages of the groups no GroupListener accepts are handled by the first plain MessageListener.
+type GroupListener interface {
+	MessageListener
+	// AcceptGroup reports whether the listener handles the messages of the group.
+	AcceptGroup(group string) bool
+}
+
 // Messenger is an interface that defines methods for message propagation and subscription in a gossip protocol.
 type Messenger interface {
 	MessageClient
@@ -62,6 +70,8 @@ type MessageServer interface {
 	run.Unit
 	// Subscribe allows subscribing to a topic to receive messages.
 	Subscribe(listener MessageListener)
+	// Subscribed reports whether any listener is subscribed.
+	Subscribed() bool
 	// RegisterServices registers the gRPC services with the provided server.
 	RegisterServices(func(r *grpc.Server))
 	// GetServerPort returns the port number of the server.
@@ -66,13 +66,29 @@ func (s *service) RegisterServices(f func(r *grpc.Server)) {
 	s.serviceRegister = append(s.serviceRegister, f)
 }
 
-func (s *service) getListener() MessageListener {
+func (s *service) Subscribed() bool {
 	s.listenersLock.RLock()
 	defer s.listenersLock.RUnlock()
-	if len(s.listeners) == 0 {
-		return nil
+	return len(s.listeners) > 0
+}
+
+func (s *service) getListener(group string) MessageListener {
+	s.listenersLock.RLock()
+	defer s.listenersLock.RUnlock()
+	var fallback MessageListener
+	for _, l := range s.listeners {
+		gl, ok := l.(GroupListener)
+		if !ok {
+			if fallback == nil {
+				fallback = l
+			}
+			continue
+		}
+		if gl.AcceptGroup(group) {
+			return gl
+		}
 	}
-	return s.listeners[0]
+	return fallback
 }
 
 func (s *service) getServiceRegisters() []func(server *grpc.Server) {
@@ -202,7 +218,7 @@ func (q *protocolHandler) handle(ctx context.Context, request *handlingRequest)
 	if len(nodes) == 0 {
 		return nil
 	}
-	listener := q.s.getListener()
+	listener := q.s.getListener(request.Group)
 	if listener == nil {
 		return fmt.Errorf("no listener")
 	}
@@ -130,7 +130,6 @@ func (s *service) PreRun(ctx context.Context) error {
 	node := val.(common.Node)
 	s.nodeID = node.NodeID
 	s.log = logger.GetLogger("gossip-messenger")
-	s.listeners = make([]MessageListener, 0)
 	s.serverMetrics = newServerMetrics(s.omr.With(serverScope))
 	if s.metadata != nil {
 		s.metadata.RegisterHandler("property-repair-nodes", schema.KindNode, s)
@@ -72,6 +72,18 @@ var _ = ginkgo.Describe("Propagation Messenger", func() {
 		gomega.Expect(err).To(gomega.HaveOccurred())
 	})
 
+	ginkgo.It("routes groups to group listeners", func() {
+		messenger := NewMessengerWithoutMetadata(observability.NewBypassRegistry(), 0).(*service)
+		gomega.Expect(messenger.Subscribed()).To(gomega.BeFalse())
+		fallback := newMockListener()
+		groupListener := &mockGroupListener{mockListener: newMockListener(), group: mockGroup}
+		messenger.Subscribe(groupListener)
+		messenger.Subscribe(fallback)
+		gomega.Expect(messenger.Subscribed()).To(gomega.BeTrue())
+		gomega.Expect(messenger.getListener(mockGroup)).To(gomega.BeIdenticalTo(groupListener))
+		gomega.Expect(messenger.getListener("other")).To(gomega.BeIdenticalTo(fallback))
+	})
+
 	ginkgo.It("two nodes and send from self", func() {
 		nodes = startNodes(2)
 		node1, node2 := nodes[0], nodes[1]
@@ -270,3 +282,12 @@ func (m *mockListener) Rev(_ context.Context, _ Trace, nextNode *grpc.ClientConn
 	m.fromNodes = append(m.fromNodes, req.Context.OriginNode)
 	return nil
 }
+
+type mockGroupListener struct {
+	*mockListener
+	group string
+}
+
+func (m *mockGroupListener) AcceptGroup(group string) bool {
+	return m.group == group
+}
@@ -23,6 +23,7 @@ import (
 	"strings"
 
 	propertyv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/property/v1"
+	"github.com/apache/skywalking-banyandb/banyand/property/gossip"
 	"github.com/apache/skywalking-banyandb/pkg/convert"
 	"github.com/apache/skywalking-banyandb/pkg/run"
 )
@@ -34,6 +35,8 @@ type Service interface {
 	run.Service
 
 	GetGossIPGrpcPort() *uint32
+	// GossipMessenger returns the gossip messenger shared with the replica repair of the stream and measure data.
+	GossipMessenger() gossip.Messenger
 }
 
 // GetPropertyID returns the property ID based on the property metadata and revision.
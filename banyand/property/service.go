@@ -156,16 +156,22 @@ func (s *service) PreRun(ctx context.Context) error {
 		return err
 	}
 
-	// if the gossip address is empty or repair scheduler is not start, it means that the gossip is not enabled.
-	if node.PropertyGossipGrpcAddress == "" || s.db.repairScheduler == nil {
+	// if the gossip address is empty, it means that the gossip is not enabled.
+	if node.PropertyGossipGrpcAddress == "" {
+		s.gossipMessenger = nil
+	}
+	if s.gossipMessenger != nil && s.db.repairScheduler != nil {
+		s.gossipMessenger.RegisterServices(s.db.repairScheduler.registerServerToGossip())
+		s.db.repairScheduler.registerClientToGossip(s.gossipMessenger)
+	}
+	// the gossip is shared with the replica repair of the other catalogs, it's not started if no one subscribes to it.
+	if s.gossipMessenger != nil && !s.gossipMessenger.Subscribed() {
 		s.gossipMessenger = nil
 	}
 	if s.gossipMessenger != nil {
 		if err = s.gossipMessenger.PreRun(ctx); err != nil {
 			return err
 		}
-		s.gossipMessenger.RegisterServices(s.db.repairScheduler.registerServerToGossip())
-		s.db.repairScheduler.registerClientToGossip(s.gossipMessenger)
 	}
 	return multierr.Combine(
 		s.pipeline.Subscribe(data.TopicPropertyUpdate, &updateListener{s: s, path: path, maxDiskUsagePercent: s.maxDiskUsagePercent}),
@@ -198,6 +204,10 @@ func (s *service) GetGossIPGrpcPort() *uint32 {
 	return s.gossipMessenger.GetServerPort()
 }
 
+func (s *service) GossipMessenger() gossip.Messenger {
+	return s.gossipMessenger
+}
+
 // NewService returns a new service.
 func NewService(metadata metadata.Repo, pipeline queue.Server, pipelineClient queue.Client, omr observability.MetricsRegistry, pm protector.Memory) (Service, error) {
 	return &service{
@@ -100,7 +100,7 @@ func (p *streamQueryProcessor) Rev(ctx context.Context, message bus.Message) (re
 		metadata = append(metadata, meta)
 	}
 
-	plan, err := logical_stream.Analyze(queryCriteria, metadata, schemas, ecc)
+	plan, err := logical_stream.Analyze(ctx, queryCriteria, metadata, schemas, ecc)
 	if err != nil {
 		resp = bus.NewMessage(bus.MessageID(now), common.NewError("fail to analyze the query request for stream %s: %v", queryCriteria.GetName(), err))
 		return
@@ -129,6 +129,19 @@ func (p *streamQueryProcessor) Rev(ctx context.Context, message bus.Message) (re
 			span.Stop()
 		}()
 	}
+	switch queryCriteria.Explain {
+	case commonv1.ExplainMode_EXPLAIN_MODE_PLAN:
+		resp = bus.NewMessage(bus.MessageID(now), &streamv1.QueryResponse{Plan: logical.Explain(plan, nil)})
+		return
+	case commonv1.ExplainMode_EXPLAIN_MODE_ANALYZE:
+		var analyzer *query.Analyzer
+		analyzer, ctx = query.NewAnalyzer(ctx)
+		defer func() {
+			if d, ok := resp.Data().(*streamv1.QueryResponse); ok {
+				d.Plan = logical.Explain(plan, analyzer)
+			}
+		}()
+	}
 	se := plan.(executor.StreamExecutable)
 	defer se.Close()
 	entities, err := se.Execute(ctx)
@@ -252,7 +265,7 @@ func (p *measureQueryProcessor) executeQuery(ctx context.Context, queryCriteria
 		e.RawJSON("req", logger.Proto(queryCriteria)).Msg("received a query event")
 	}
 
-	plan, err := logical_measure.Analyze(queryCriteria, metadata, schemas, ecc)
+	plan, err := logical_measure.Analyze(ctx, queryCriteria, metadata, schemas, ecc)
 	if err != nil {
 		resp = bus.NewMessage(bus.MessageID(now), common.NewError("fail to analyze the query request for measure %s: %v", queryCriteria.GetName(), err))
 		return
@@ -282,6 +295,19 @@ func (p *measureQueryProcessor) executeQuery(ctx context.Context, queryCriteria
 		e.Str("plan", plan.String()).Msg("query plan")
 	}
 
+	switch queryCriteria.Explain {
+	case commonv1.ExplainMode_EXPLAIN_MODE_PLAN:
+		resp = bus.NewMessage(bus.MessageID(now), &measurev1.QueryResponse{Plan: logical.Explain(plan, nil)})
+		return
+	case commonv1.ExplainMode_EXPLAIN_MODE_ANALYZE:
+		var analyzer *query.Analyzer
+		analyzer, ctx = query.NewAnalyzer(ctx)
+		defer func() {
+			if d, ok := resp.Data().(*measurev1.QueryResponse); ok {
+				d.Plan = logical.Explain(plan, analyzer)
+			}
+		}()
+	}
 	mIterator, err := plan.(executor.MeasureExecutable).Execute(ctx)
 	if err != nil {
 		ml.Error().Err(err).RawJSON("req", logger.Proto(queryCriteria)).Msg("fail to query")
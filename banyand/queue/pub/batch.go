@@ -44,8 +44,78 @@ type batchPublisher struct {
 	streams     map[string]writeStream
 	topic       *bus.Topic
 	failedNodes map[string]*common.Error
-	f           batchFuture
-	timeout     time.Duration
+	// sent is the requests sent to the nodes but not acknowledged yet, which are hinted if the nodes fail before responding.
+	sent    map[string]*sentRequests
+	f       batchFuture
+	timeout time.Duration
+	sentMu  sync.Mutex
+}
+
+// maxSentSize is the maximum size of the unacknowledged requests a batch publisher retains for a node.
+// The node acknowledges a batch only at the end of the stream, so that the requests of a long stream
+// beyond the size can't be hinted if the node fails.
+const maxSentSize = 8 << 20
+
+// sentRequests is the unacknowledged requests sent to a node, whose size is bounded by maxSize.
+type sentRequests struct {
+	requests []*clusterv1.SendRequest
+	size     int64
+}
+
+// add retains the request, and returns the number of the oldest requests dropped to keep the size under maxSize.
+func (sr *sentRequests) add(r *clusterv1.SendRequest, maxSize int64) (dropped int) {
+	sr.requests = append(sr.requests, r)
+	sr.size += int64(len(r.Body))
+	for sr.size > maxSize && len(sr.requests) > 0 {
+		sr.size -= int64(len(sr.requests[0].Body))
+		sr.requests[0] = nil
+		sr.requests = sr.requests[1:]
+		dropped++
+	}
+	return dropped
+}
+
+// ack removes the requests the successful response of the message acknowledges.
+// The node applies the requests of a batch together at the end of the stream,
+// so that a response to a batch acknowledges all of them.
+func (sr *sentRequests) ack(messageID uint64) {
+	requests := sr.requests[:0]
+	for _, r := range sr.requests {
+		if r.BatchMod || r.MessageId == messageID {
+			sr.size -= int64(len(r.Body))
+			continue
+		}
+		requests = append(requests, r)
+	}
+	clear(sr.requests[len(requests):])
+	sr.requests = requests
+}
+
+func (bp *batchPublisher) recordSent(node string, r *clusterv1.SendRequest) {
+	if bp.pub.hints == nil {
+		return
+	}
+	bp.sentMu.Lock()
+	defer bp.sentMu.Unlock()
+	if bp.sent == nil {
+		bp.sent = make(map[string]*sentRequests)
+	}
+	sr, ok := bp.sent[node]
+	if !ok {
+		sr = &sentRequests{}
+		bp.sent[node] = sr
+	}
+	if dropped := sr.add(r, min(maxSentSize, bp.pub.hints.maxSize)); dropped > 0 {
+		bp.pub.hints.metrics.totalDropped.Inc(float64(dropped), node)
+	}
+}
+
+func (bp *batchPublisher) ackSent(node string, messageID uint64) {
+	bp.sentMu.Lock()
+	defer bp.sentMu.Unlock()
+	if sr, ok := bp.sent[node]; ok {
+		sr.ack(messageID)
+	}
 }
 
 // NewBatchPublisher returns a new batch publisher.
@@ -89,7 +159,8 @@ func (bp *batchPublisher) Publish(ctx context.Context, topic bus.Topic, messages
 					err = multierr.Append(err, fmt.Errorf("failed to send message to node %s: %w", node, errSend))
 					return false
 				}
-				return errSend == nil
+				bp.recordSent(node, r)
+				return true
 			}
 			return false
 		}
@@ -109,6 +180,7 @@ func (bp *batchPublisher) Publish(ctx context.Context, topic bus.Topic, messages
 			continue
 		}
 		var client *client
+		var hintable bool
 		// nolint: contextcheck
 		if func() bool {
 			bp.pub.mu.RLock()
@@ -117,6 +189,8 @@ func (bp *batchPublisher) Publish(ctx context.Context, topic bus.Topic, messages
 			client, ok = bp.pub.active[node]
 			if !ok {
 				err = multierr.Append(err, fmt.Errorf("failed to get client for node %s", node))
+				// The node is unavailable, but still in the cluster.
+				_, hintable = bp.pub.registered[node]
 				return true
 			}
 			succeed, ce := bp.pub.checkWritable(node, topic)
@@ -130,6 +204,9 @@ func (bp *batchPublisher) Publish(ctx context.Context, topic bus.Topic, messages
 			err = multierr.Append(err, ce)
 			return true
 		}() {
+			if hintable {
+				bp.pub.hint(node, r)
+			}
 			continue
 		}
 
@@ -139,6 +216,7 @@ func (bp *batchPublisher) Publish(ctx context.Context, topic bus.Topic, messages
 		stream, errCreateStream := client.client.Send(streamCtx)
 		if errCreateStream != nil {
 			err = multierr.Append(err, fmt.Errorf("failed to get stream for node %s: %w", node, errCreateStream))
+			bp.pub.hint(node, r)
 			continue
 		}
 		bp.streams[node] = writeStream{
@@ -146,7 +224,9 @@ func (bp *batchPublisher) Publish(ctx context.Context, topic bus.Topic, messages
 			ctxDoneCh: streamCtx.Done(),
 		}
 		bp.f.events = append(bp.f.events, make(chan batchEvent))
-		_ = sendData()
+		if !sendData() && ctx.Err() == nil {
+			bp.pub.hint(node, r)
+		}
 		go func(s clusterv1.Service_SendClient, deferFn func(), bc chan batchEvent) {
 			defer func() {
 				close(bc)
@@ -157,22 +237,26 @@ func (bp *batchPublisher) Publish(ctx context.Context, topic bus.Topic, messages
 				return
 			default:
 			}
-			resp, errRecv := s.Recv()
-			if errRecv != nil {
-				if isFailoverError(errRecv) {
-					bc <- batchEvent{n: node, e: common.NewErrorWithStatus(modelv1.Status_STATUS_INTERNAL_ERROR, errRecv.Error())}
+			for {
+				resp, errRecv := s.Recv()
+				if errRecv != nil {
+					if isFailoverError(errRecv) {
+						bc <- batchEvent{n: node, e: common.NewErrorWithStatus(modelv1.Status_STATUS_INTERNAL_ERROR, errRecv.Error())}
+					}
+					return
+				}
+				if resp == nil {
+					return
+				}
+				if resp.Error == "" {
+					bp.ackSent(node, resp.MessageId)
+					continue
+				}
+				if isFailoverStatus(resp.Status) {
+					ce := common.NewErrorWithStatus(resp.Status, resp.Error)
+					bc <- batchEvent{n: node, e: ce}
+					return
 				}
-				return
-			}
-			if resp == nil {
-				return
-			}
-			if resp.Error == "" {
-				return
-			}
-			if isFailoverStatus(resp.Status) {
-				ce := common.NewErrorWithStatus(resp.Status, resp.Error)
-				bc <- batchEvent{n: node, e: ce}
 			}
 		}(stream, deferFn, bp.f.events[len(bp.f.events)-1])
 	}
@@ -190,6 +274,14 @@ func (bp *batchPublisher) Close() (cee map[string]*common.Error, err error) {
 	if len(batchEvents) < 1 {
 		return nil, err
 	}
+	for n, e := range batchEvents {
+		// The node fails before acknowledging the requests, so that they might be lost.
+		if sr, ok := bp.sent[n]; ok && e.e.Status() == modelv1.Status_STATUS_INTERNAL_ERROR {
+			for _, r := range sr.requests {
+				bp.pub.hint(n, r)
+			}
+		}
+	}
 	if bp.pub.closer.AddRunning() {
 		go func() {
 			defer bp.pub.closer.Done()
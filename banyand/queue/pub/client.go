@@ -132,6 +132,7 @@ func (p *pub) OnAddOrUpdate(md schema.Metadata) {
 	p.active[name] = &client{conn: conn, client: c, md: md}
 	p.addClient(md)
 	p.log.Info().Str("status", p.dump()).Stringer("node", node).Msg("new node is healthy, add it to active queue")
+	p.replayHints(name, p.active[name])
 }
 
 func (p *pub) registerNode(node *databasev1.Node) {
@@ -280,6 +281,7 @@ func (p *pub) checkClientHealthAndReconnect(conn *grpc.ClientConn, md schema.Met
 						p.addClient(md)
 						delete(p.evictable, name)
 						p.log.Info().Str("status", p.dump()).Stringer("node", en.n).Msg("node is healthy, move it back to active queue")
+						p.replayHints(name, p.active[name])
 					}()
 					return
 				}
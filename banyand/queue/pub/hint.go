@@ -0,0 +1,462 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package pub
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	clusterv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/cluster/v1"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/meter"
+)
+
+const (
+	// hintCapPolicyDropOldest drops the oldest hints of a node to store the new ones once the node's hints reach the cap.
+	hintCapPolicyDropOldest = "drop-oldest"
+	// hintCapPolicyDropNewest drops the new hints of a node once the node's hints reach the cap.
+	hintCapPolicyDropNewest = "drop-newest"
+
+	// hintSegmentsPerNode is the number of the segments the hints of a node are split into,
+	// which are the units the hints are dropped and replayed by.
+	hintSegmentsPerNode = 4
+	hintSegmentExt      = ".hint"
+	hintHeaderSize      = 4
+	defaultHintMaxSize  = 1 << 30
+	// hintReplayTimeout is the time to replay a segment of hints.
+	hintReplayTimeout = 10 * time.Minute
+)
+
+type hintMetrics struct {
+	backlogBytes   meter.Gauge
+	totalHinted    meter.Counter
+	totalDropped   meter.Counter
+	totalReplayed  meter.Counter
+	totalReplayErr meter.Counter
+}
+
+// hintStore persists the messages which fail to be sent to the unavailable nodes on the local disk,
+// so that they're replayed once the nodes are healthy again.
+type hintStore struct {
+	metrics   *hintMetrics
+	l         *logger.Logger
+	nodes     map[string]*nodeHints
+	root      string
+	capPolicy string
+	maxSize   int64
+	mu        sync.Mutex
+}
+
+// nodeHints is the hints of a node, which are appended to the last segment.
+type nodeHints struct {
+	file      *os.File
+	dir       string
+	segments  []hintSegment
+	size      int64
+	nextSeq   uint64
+	mu        sync.Mutex
+	replaying bool
+}
+
+type hintSegment struct {
+	path string
+	size int64
+}
+
+func newHintStore(root string, maxSize int64, capPolicy string, metrics *hintMetrics, l *logger.Logger) (*hintStore, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create the hint directory %s: %w", root, err)
+	}
+	s := &hintStore{
+		root:      root,
+		maxSize:   maxSize,
+		capPolicy: capPolicy,
+		metrics:   metrics,
+		l:         l,
+		nodes:     make(map[string]*nodeHints),
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the hint directory %s: %w", root, err)
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		node, errUnescape := url.PathUnescape(e.Name())
+		if errUnescape != nil {
+			continue
+		}
+		nh, errLoad := loadNodeHints(filepath.Join(root, e.Name()))
+		if errLoad != nil {
+			return nil, errLoad
+		}
+		s.nodes[node] = nh
+		s.updateBacklog(node, nh)
+	}
+	return s, nil
+}
+
+func loadNodeHints(dir string) (*nodeHints, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the hint directory %s: %w", dir, err)
+	}
+	nh := &nodeHints{dir: dir}
+	var seqs []uint64
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), hintSegmentExt) {
+			continue
+		}
+		seq, errParse := strconv.ParseUint(strings.TrimSuffix(e.Name(), hintSegmentExt), 10, 64)
+		if errParse != nil {
+			continue
+		}
+		seqs = append(seqs, seq)
+	}
+	slices.Sort(seqs)
+	for _, seq := range seqs {
+		path := nh.segmentPath(seq)
+		info, errStat := os.Stat(path)
+		if errStat != nil {
+			return nil, fmt.Errorf("failed to stat the hint segment %s: %w", path, errStat)
+		}
+		nh.segments = append(nh.segments, hintSegment{path: path, size: info.Size()})
+		nh.size += info.Size()
+		nh.nextSeq = seq + 1
+	}
+	return nh, nil
+}
+
+func (nh *nodeHints) segmentPath(seq uint64) string {
+	return filepath.Join(nh.dir, fmt.Sprintf("%020d%s", seq, hintSegmentExt))
+}
+
+// add stores the message to the node, and returns false if the message is dropped by the cap.
+func (s *hintStore) add(node string, message []byte) bool {
+	nh, err := s.nodeHints(node)
+	if err != nil {
+		s.l.Error().Err(err).Str("node", node).Msg("failed to store the hint")
+		s.metrics.totalDropped.Inc(1, node)
+		return false
+	}
+	nh.mu.Lock()
+	defer nh.mu.Unlock()
+	recordSize := int64(hintHeaderSize + len(message))
+	if recordSize > s.maxSize {
+		s.metrics.totalDropped.Inc(1, node)
+		return false
+	}
+	if nh.size+recordSize > s.maxSize {
+		if s.capPolicy == hintCapPolicyDropNewest {
+			s.metrics.totalDropped.Inc(1, node)
+			return false
+		}
+		for nh.size+recordSize > s.maxSize && len(nh.segments) > 0 {
+			if len(nh.segments) == 1 {
+				nh.closeFile()
+			}
+			seg := nh.segments[0]
+			nh.segments = nh.segments[1:]
+			nh.size -= seg.size
+			if errRemove := os.Remove(seg.path); errRemove != nil && !errors.Is(errRemove, os.ErrNotExist) {
+				s.l.Warn().Err(errRemove).Str("path", seg.path).Msg("failed to remove the dropped hints")
+			}
+			s.metrics.totalDropped.Inc(1, node)
+			s.l.Warn().Str("node", node).Str("path", seg.path).Msg("the oldest hints are dropped since the hints of the node reach the cap")
+		}
+	}
+	if nh.file == nil || nh.segments[len(nh.segments)-1].size >= s.maxSize/hintSegmentsPerNode {
+		if err = nh.roll(); err != nil {
+			s.l.Error().Err(err).Str("node", node).Msg("failed to create the hint segment")
+			s.metrics.totalDropped.Inc(1, node)
+			return false
+		}
+	}
+	record := make([]byte, recordSize)
+	binary.BigEndian.PutUint32(record, uint32(len(message)))
+	copy(record[hintHeaderSize:], message)
+	if _, err = nh.file.Write(record); err != nil {
+		s.l.Error().Err(err).Str("node", node).Msg("failed to write the hint")
+		s.metrics.totalDropped.Inc(1, node)
+		// The partially written record is skipped when the segment is replayed.
+		nh.closeFile()
+		return false
+	}
+	nh.segments[len(nh.segments)-1].size += recordSize
+	nh.size += recordSize
+	s.metrics.totalHinted.Inc(1, node)
+	s.updateBacklog(node, nh)
+	return true
+}
+
+func (s *hintStore) nodeHints(node string) (*nodeHints, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if nh, ok := s.nodes[node]; ok {
+		return nh, nil
+	}
+	dir := filepath.Join(s.root, url.PathEscape(node))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	nh := &nodeHints{dir: dir}
+	s.nodes[node] = nh
+	return nh, nil
+}
+
+// roll closes the segment being appended, and creates a new one.
+func (nh *nodeHints) roll() error {
+	nh.closeFile()
+	path := nh.segmentPath(nh.nextSeq)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	nh.nextSeq++
+	nh.file = f
+	nh.segments = append(nh.segments, hintSegment{path: path})
+	return nil
+}
+
+func (nh *nodeHints) closeFile() {
+	if nh.file != nil {
+		_ = nh.file.Close()
+		nh.file = nil
+	}
+}
+
+// startReplay returns the segments of the node to replay. The new hints are appended to a new segment during the replay.
+// It returns false if the node has no hints or is being replayed.
+func (s *hintStore) startReplay(node string) ([]hintSegment, bool) {
+	s.mu.Lock()
+	nh, ok := s.nodes[node]
+	s.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	nh.mu.Lock()
+	defer nh.mu.Unlock()
+	if nh.replaying || len(nh.segments) == 0 {
+		return nil, false
+	}
+	nh.replaying = true
+	nh.closeFile()
+	return slices.Clone(nh.segments), true
+}
+
+// finishReplay marks the node's replay finished.
+func (s *hintStore) finishReplay(node string) {
+	s.mu.Lock()
+	nh := s.nodes[node]
+	s.mu.Unlock()
+	nh.mu.Lock()
+	defer nh.mu.Unlock()
+	nh.replaying = false
+}
+
+// remove removes the replayed segment of the node.
+func (s *hintStore) remove(node string, seg hintSegment) {
+	s.mu.Lock()
+	nh := s.nodes[node]
+	s.mu.Unlock()
+	nh.mu.Lock()
+	defer nh.mu.Unlock()
+	i := slices.IndexFunc(nh.segments, func(e hintSegment) bool { return e.path == seg.path })
+	if i < 0 {
+		// The segment has been dropped by the cap.
+		return
+	}
+	nh.size -= nh.segments[i].size
+	nh.segments = slices.Delete(nh.segments, i, i+1)
+	if err := os.Remove(seg.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		s.l.Warn().Err(err).Str("path", seg.path).Msg("failed to remove the replayed hints")
+	}
+	s.updateBacklog(node, nh)
+}
+
+func (s *hintStore) updateBacklog(node string, nh *nodeHints) {
+	s.metrics.backlogBytes.Set(float64(nh.size), node)
+}
+
+func (s *hintStore) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, nh := range s.nodes {
+		nh.mu.Lock()
+		nh.closeFile()
+		nh.mu.Unlock()
+	}
+}
+
+// readHints calls fn with the messages in the segment in order.
+// A record partially written by a crash ends the segment.
+func readHints(path string, fn func(message []byte) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	header := make([]byte, hintHeaderSize)
+	for {
+		if _, err = io.ReadFull(r, header); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil
+			}
+			return err
+		}
+		message := make([]byte, binary.BigEndian.Uint32(header))
+		if _, err = io.ReadFull(r, message); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil
+			}
+			return err
+		}
+		if err = fn(message); err != nil {
+			return err
+		}
+	}
+}
+
+// hint stores the request failing to be sent to the node, which is replayed once the node is healthy again.
+func (p *pub) hint(node string, r *clusterv1.SendRequest) {
+	if p.hints == nil {
+		return
+	}
+	message, err := proto.Marshal(r)
+	if err != nil {
+		p.log.Error().Err(err).Str("node", node).Msg("failed to marshal the hint")
+		return
+	}
+	p.hints.add(node, message)
+}
+
+// replayHints sends the hints of the node by the client in the background.
+func (p *pub) replayHints(node string, c *client) {
+	if p.hints == nil || !p.closer.AddRunning() {
+		return
+	}
+	go func() {
+		defer p.closer.Done()
+		for {
+			segments, ok := p.hints.startReplay(node)
+			if !ok {
+				return
+			}
+			err := p.replaySegments(node, c, segments)
+			p.hints.finishReplay(node)
+			if err != nil {
+				p.hints.metrics.totalReplayErr.Inc(1, node)
+				p.log.Warn().Err(err).Str("node", node).Msg("failed to replay the hints, which are replayed again once the node is healthy")
+				return
+			}
+		}
+	}()
+}
+
+func (p *pub) replaySegments(node string, c *client, segments []hintSegment) error {
+	for _, seg := range segments {
+		select {
+		case <-p.closer.CloseNotify():
+			return errors.New("the queue client is closed")
+		default:
+		}
+		count, err := p.replaySegment(c, seg.path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to replay %s: %w", seg.path, err)
+		}
+		p.hints.metrics.totalReplayed.Inc(float64(count), node)
+		p.hints.remove(node, seg)
+	}
+	return nil
+}
+
+// replaySegment sends the hints in the segment to the node in batches, one batch per topic,
+// since a batch is handled by the listener of its first message's topic.
+func (p *pub) replaySegment(c *client, path string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), hintReplayTimeout)
+	defer cancel()
+	var stream clusterv1.Service_SendClient
+	var topic string
+	var count int
+	flush := func() error {
+		if stream == nil {
+			return nil
+		}
+		s := stream
+		stream = nil
+		if err := s.CloseSend(); err != nil {
+			return err
+		}
+		resp, err := s.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if resp.Error != "" {
+			return errors.New(resp.Error)
+		}
+		return nil
+	}
+	err := readHints(path, func(message []byte) error {
+		r := &clusterv1.SendRequest{}
+		if errUnmarshal := proto.Unmarshal(message, r); errUnmarshal != nil {
+			p.log.Warn().Err(errUnmarshal).Str("path", path).Msg("skip the malformed hint")
+			return nil
+		}
+		if stream != nil && r.Topic != topic {
+			if errFlush := flush(); errFlush != nil {
+				return errFlush
+			}
+		}
+		if stream == nil {
+			var errStream error
+			if stream, errStream = c.client.Send(ctx); errStream != nil {
+				return errStream
+			}
+			topic = r.Topic
+		}
+		r.BatchMod = true
+		count++
+		return stream.Send(r)
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err = flush(); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
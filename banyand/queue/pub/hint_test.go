@@ -0,0 +1,161 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package pub
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	clusterv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/cluster/v1"
+	"github.com/apache/skywalking-banyandb/banyand/observability"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+)
+
+func newTestHintStore(t *testing.T, root string, maxSize int64, capPolicy string) *hintStore {
+	factory := observability.BypassRegistry.With(queuePubScope)
+	s, err := newHintStore(root, maxSize, capPolicy, &hintMetrics{
+		backlogBytes:   factory.NewGauge("hinted_handoff_backlog_bytes", "node"),
+		totalHinted:    factory.NewCounter("hinted_handoff_total_hinted", "node"),
+		totalDropped:   factory.NewCounter("hinted_handoff_total_dropped", "node"),
+		totalReplayed:  factory.NewCounter("hinted_handoff_total_replayed", "node"),
+		totalReplayErr: factory.NewCounter("hinted_handoff_total_replay_err", "node"),
+	}, logger.GetLogger("hint-test"))
+	require.NoError(t, err)
+	t.Cleanup(s.close)
+	return s
+}
+
+func readAllHints(t *testing.T, segments []hintSegment) []string {
+	var messages []string
+	for _, seg := range segments {
+		require.NoError(t, readHints(seg.path, func(message []byte) error {
+			messages = append(messages, string(message))
+			return nil
+		}))
+	}
+	return messages
+}
+
+func TestHintStoreReplay(t *testing.T) {
+	root := t.TempDir()
+	s := newTestHintStore(t, root, 1024, hintCapPolicyDropOldest)
+	for i := 0; i < 10; i++ {
+		assert.True(t, s.add("node1:17912", []byte(fmt.Sprintf("message-%d", i))))
+	}
+	_, ok := s.startReplay("node2:17912")
+	assert.False(t, ok, "node2 has no hints")
+
+	segments, ok := s.startReplay("node1:17912")
+	require.True(t, ok)
+	_, ok = s.startReplay("node1:17912")
+	assert.False(t, ok, "node1 is being replayed")
+	// The new hints are appended to a new segment during the replay.
+	assert.True(t, s.add("node1:17912", []byte("message-10")))
+	messages := readAllHints(t, segments)
+	require.Len(t, messages, 10)
+	assert.Equal(t, "message-0", messages[0])
+	assert.Equal(t, "message-9", messages[9])
+	for _, seg := range segments {
+		s.remove("node1:17912", seg)
+	}
+	s.finishReplay("node1:17912")
+
+	segments, ok = s.startReplay("node1:17912")
+	require.True(t, ok)
+	assert.Equal(t, []string{"message-10"}, readAllHints(t, segments))
+	s.finishReplay("node1:17912")
+}
+
+func TestHintStoreReload(t *testing.T) {
+	root := t.TempDir()
+	s := newTestHintStore(t, root, 1024, hintCapPolicyDropOldest)
+	assert.True(t, s.add("node1:17912", []byte("message-0")))
+	assert.True(t, s.add("node1:17912", []byte("message-1")))
+	s.close()
+
+	s = newTestHintStore(t, root, 1024, hintCapPolicyDropOldest)
+	assert.True(t, s.add("node1:17912", []byte("message-2")))
+	segments, ok := s.startReplay("node1:17912")
+	require.True(t, ok)
+	assert.Equal(t, []string{"message-0", "message-1", "message-2"}, readAllHints(t, segments))
+}
+
+func TestHintStoreCap(t *testing.T) {
+	message := make([]byte, 60)
+	// Each record takes 64 bytes, and a segment holds 4 records.
+	s := newTestHintStore(t, t.TempDir(), 1024, hintCapPolicyDropNewest)
+	for i := 0; i < 16; i++ {
+		assert.True(t, s.add("node1", message))
+	}
+	assert.False(t, s.add("node1", message), "the new hint is dropped")
+	assert.True(t, s.add("node2", message), "the cap is per node")
+
+	s = newTestHintStore(t, t.TempDir(), 1024, hintCapPolicyDropOldest)
+	for i := 0; i < 16; i++ {
+		message[0] = byte(i)
+		assert.True(t, s.add("node1", message))
+	}
+	message[0] = 16
+	assert.True(t, s.add("node1", message), "the oldest segment is dropped")
+	segments, ok := s.startReplay("node1")
+	require.True(t, ok)
+	messages := readAllHints(t, segments)
+	require.Len(t, messages, 13)
+	assert.Equal(t, byte(4), messages[0][0])
+	assert.Equal(t, byte(16), messages[12][0])
+
+	assert.False(t, s.add("node1", make([]byte, 1024)), "a hint larger than the cap is dropped")
+}
+
+func TestSentRequestsAck(t *testing.T) {
+	messageIDs := func(sr *sentRequests) []uint64 {
+		var ids []uint64
+		for _, r := range sr.requests {
+			ids = append(ids, r.MessageId)
+		}
+		return ids
+	}
+	var sr sentRequests
+	for i := 1; i <= 3; i++ {
+		sr.add(&clusterv1.SendRequest{MessageId: uint64(i), Body: make([]byte, 10)}, 1024)
+	}
+	sr.ack(2)
+	assert.Equal(t, []uint64{1, 3}, messageIDs(&sr), "only the unacknowledged requests are hinted")
+	assert.Equal(t, int64(20), sr.size)
+	sr.ack(4)
+	assert.Equal(t, []uint64{1, 3}, messageIDs(&sr))
+
+	sr = sentRequests{}
+	for i := 1; i <= 3; i++ {
+		sr.add(&clusterv1.SendRequest{MessageId: uint64(i), Body: make([]byte, 10), BatchMod: true}, 1024)
+	}
+	sr.ack(0)
+	assert.Empty(t, sr.requests, "the response to a batch acknowledges all of its requests")
+	assert.Zero(t, sr.size)
+
+	sr = sentRequests{}
+	var dropped int
+	for i := 1; i <= 3; i++ {
+		dropped += sr.add(&clusterv1.SendRequest{MessageId: uint64(i), Body: make([]byte, 10), BatchMod: true}, 25)
+	}
+	assert.Equal(t, []uint64{2, 3}, messageIDs(&sr), "the oldest requests are dropped beyond the maximum size")
+	assert.Equal(t, 1, dropped)
+}
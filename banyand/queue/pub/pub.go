@@ -40,6 +40,7 @@ import (
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
 	"github.com/apache/skywalking-banyandb/banyand/metadata"
 	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/banyand/observability"
 	"github.com/apache/skywalking-banyandb/banyand/queue"
 	"github.com/apache/skywalking-banyandb/pkg/bus"
 	"github.com/apache/skywalking-banyandb/pkg/grpchelper"
@@ -61,20 +62,27 @@ var (
 	_ run.Config    = (*pub)(nil)
 )
 
+var queuePubScope = observability.RootScope.SubScope("queue_pub")
+
 type pub struct {
 	schema.UnimplementedOnInitHandler
-	metadata     metadata.Repo
-	evictable    map[string]evictNode
-	log          *logger.Logger
-	registered   map[string]*databasev1.Node
-	active       map[string]*client
-	handlers     map[bus.Topic]schema.EventHandler
-	closer       *run.Closer
-	caCertPath   string
-	prefix       string
-	allowedRoles []databasev1.Role
-	mu           sync.RWMutex
-	tlsEnabled   bool
+	metadata      metadata.Repo
+	omr           observability.MetricsRegistry
+	evictable     map[string]evictNode
+	log           *logger.Logger
+	registered    map[string]*databasev1.Node
+	active        map[string]*client
+	handlers      map[bus.Topic]schema.EventHandler
+	closer        *run.Closer
+	hints         *hintStore
+	caCertPath    string
+	prefix        string
+	hintPath      string
+	hintCapPolicy string
+	allowedRoles  []databasev1.Role
+	hintMaxSize   run.Bytes
+	mu            sync.RWMutex
+	tlsEnabled    bool
 }
 
 func (p *pub) FlagSet() *run.FlagSet {
@@ -87,6 +95,13 @@ func (p *pub) FlagSet() *run.FlagSet {
 	fs := run.NewFlagSet("queue-client")
 	fs.BoolVar(&p.tlsEnabled, prefixFlag("client-tls"), false, fmt.Sprintf("enable client TLS for %s", p.prefix))
 	fs.StringVar(&p.caCertPath, prefixFlag("client-ca-cert"), "", fmt.Sprintf("CA certificate file to verify the %s server", p.prefix))
+	fs.StringVar(&p.hintPath, prefixFlag("hinted-handoff-path"), "",
+		fmt.Sprintf("the directory of the hints of the writes failing to be sent to the unavailable %s nodes, which are replayed once the nodes are healthy. "+
+			"The hinted handoff is disabled if it's empty", p.prefix))
+	p.hintMaxSize = defaultHintMaxSize
+	fs.VarP(&p.hintMaxSize, prefixFlag("hinted-handoff-max-size"), "", fmt.Sprintf("the maximum size of the hints of a %s node", p.prefix))
+	fs.StringVar(&p.hintCapPolicy, prefixFlag("hinted-handoff-cap-policy"), hintCapPolicyDropOldest,
+		fmt.Sprintf("the policy once the hints of a node reach the maximum size: %s or %s", hintCapPolicyDropOldest, hintCapPolicyDropNewest))
 	return fs
 }
 
@@ -95,6 +110,14 @@ func (p *pub) Validate() error {
 	if p.tlsEnabled && p.caCertPath == "" {
 		return fmt.Errorf("TLS is enabled (--internal-tls), but no CA certificate file was provided (--internal-ca-cert is required)")
 	}
+	if p.hintPath != "" {
+		if p.hintMaxSize <= 0 {
+			return fmt.Errorf("the maximum size of the hints must be positive, got %d", p.hintMaxSize)
+		}
+		if p.hintCapPolicy != hintCapPolicyDropOldest && p.hintCapPolicy != hintCapPolicyDropNewest {
+			return fmt.Errorf("unknown hinted handoff cap policy %q", p.hintCapPolicy)
+		}
+	}
 	return nil
 }
 
@@ -115,6 +138,9 @@ func (p *pub) GracefulStop() {
 		_ = c.conn.Close()
 	}
 	p.active = nil
+	if p.hints != nil {
+		p.hints.close()
+	}
 }
 
 // Serve implements run.Service.
@@ -280,6 +306,7 @@ func New(metadata metadata.Repo, roles ...databasev1.Role) queue.Client {
 	}
 	p := &pub{
 		metadata:     metadata,
+		omr:          observability.BypassRegistry,
 		active:       make(map[string]*client),
 		evictable:    make(map[string]evictNode),
 		registered:   make(map[string]*databasev1.Node),
@@ -291,6 +318,13 @@ func New(metadata metadata.Repo, roles ...databasev1.Role) queue.Client {
 	return p
 }
 
+// SetMetricsRegistry sets the registry of the metrics of the queue client created by New.
+func SetMetricsRegistry(client queue.Client, omr observability.MetricsRegistry) {
+	if p, ok := client.(*pub); ok {
+		p.omr = omr
+	}
+}
+
 // NewWithoutMetadata returns a new queue client without metadata, defaulting to data nodes.
 func NewWithoutMetadata() queue.Client {
 	p := New(nil, databasev1.Role_ROLE_DATA)
@@ -298,6 +332,50 @@ func NewWithoutMetadata() queue.Client {
 	return p
 }
 
+// NewWithTLS returns a new queue client without metadata like NewWithoutMetadata,
+// which connects to the data nodes by the TLS settings.
+func NewWithTLS(tls *ClientTLS) queue.Client {
+	p := NewWithoutMetadata()
+	if tls != nil {
+		p.(*pub).tlsEnabled = tls.enabled
+		p.(*pub).caCertPath = tls.caCertPath
+	}
+	return p
+}
+
+// ClientTLS is the TLS settings of the queue clients created by NewWithTLS, like the clients moving the shards
+// between the data nodes. They're configured by the same flags as the queue client of the data nodes in the liaison.
+type ClientTLS struct {
+	caCertPath string
+	enabled    bool
+}
+
+// NewClientTLS returns the TLS settings of the queue clients connecting to the data nodes.
+func NewClientTLS() *ClientTLS {
+	return &ClientTLS{}
+}
+
+// Name implements run.Unit.
+func (c *ClientTLS) Name() string {
+	return "queue-client-tls"
+}
+
+// FlagSet implements run.Config.
+func (c *ClientTLS) FlagSet() *run.FlagSet {
+	fs := run.NewFlagSet("queue-client-tls")
+	fs.BoolVar(&c.enabled, "data-client-tls", false, "enable client TLS for data")
+	fs.StringVar(&c.caCertPath, "data-client-ca-cert", "", "CA certificate file to verify the data server")
+	return fs
+}
+
+// Validate implements run.Config.
+func (c *ClientTLS) Validate() error {
+	if c.enabled && c.caCertPath == "" {
+		return fmt.Errorf("TLS is enabled (--data-client-tls), but no CA certificate file was provided (--data-client-ca-cert is required)")
+	}
+	return nil
+}
+
 func (p *pub) Name() string {
 	return "queue-client-" + p.prefix
 }
@@ -308,6 +386,20 @@ func (p *pub) PreRun(context.Context) error {
 	}
 
 	p.log = logger.GetLogger("server-queue-pub-" + p.prefix)
+	if p.hintPath != "" {
+		factory := p.omr.With(queuePubScope.SubScope(p.prefix))
+		metrics := &hintMetrics{
+			backlogBytes:   factory.NewGauge("hinted_handoff_backlog_bytes", "node"),
+			totalHinted:    factory.NewCounter("hinted_handoff_total_hinted", "node"),
+			totalDropped:   factory.NewCounter("hinted_handoff_total_dropped", "node"),
+			totalReplayed:  factory.NewCounter("hinted_handoff_total_replayed", "node"),
+			totalReplayErr: factory.NewCounter("hinted_handoff_total_replay_err", "node"),
+		}
+		var err error
+		if p.hints, err = newHintStore(p.hintPath, int64(p.hintMaxSize), p.hintCapPolicy, metrics, p.log.Named("hint")); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
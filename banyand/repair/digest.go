@@ -0,0 +1,180 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package repair implements the anti-entropy repair of the replicas of the stream and measure shards.
+//
+// The data of a shard in a segment are split into time slots. The replicas exchange the digests of the slots,
+// and send the data of the differing slots to each other.
+package repair
+
+import (
+	"encoding/binary"
+	"sort"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// SlotCount is the number of the time slots a segment is split into.
+const SlotCount = 32
+
+// Digest is the order-independent digest of the elements or data points in a slot.
+type Digest struct {
+	Count uint64
+	Hash  uint64
+}
+
+// Add adds the hash of an element or a data point to the digest.
+func (d *Digest) Add(h uint64) {
+	d.Count++
+	d.Hash += h
+}
+
+// Merge adds the other digest to the digest.
+func (d *Digest) Merge(other Digest) {
+	d.Count += other.Count
+	d.Hash += other.Hash
+}
+
+// Hash returns the hash of an element or a data point.
+// The id is the element ID of a stream element, or the version of a measure data point.
+func Hash(seriesID uint64, timestamp int64, id uint64) uint64 {
+	var b [24]byte
+	binary.LittleEndian.PutUint64(b[:8], seriesID)
+	binary.LittleEndian.PutUint64(b[8:16], uint64(timestamp))
+	binary.LittleEndian.PutUint64(b[16:], id)
+	return xxhash.Sum64(b[:])
+}
+
+// SlotDigests is the digests of the slots of a segment.
+type SlotDigests [SlotCount]Digest
+
+// Merge adds the digests of the other slots to the ones of the slots.
+func (sd *SlotDigests) Merge(other *SlotDigests) {
+	for i := range sd {
+		sd[i].Merge(other[i])
+	}
+}
+
+// Slot returns the slot of the timestamp in the segment [start, end).
+func Slot(start, end, timestamp int64) int {
+	i := (timestamp - start) / slotWidth(start, end)
+	if i < 0 {
+		return 0
+	}
+	if i >= SlotCount {
+		return SlotCount - 1
+	}
+	return int(i)
+}
+
+// SlotRange returns the time range [begin, end) of the slot in the segment [start, end).
+func SlotRange(start, end int64, slot int) (int64, int64) {
+	width := slotWidth(start, end)
+	begin := min(start+int64(slot)*width, end)
+	if slot == SlotCount-1 {
+		return begin, end
+	}
+	return begin, min(begin+width, end)
+}
+
+func slotWidth(start, end int64) int64 {
+	width := (end - start + SlotCount - 1) / SlotCount
+	if width <= 0 {
+		return 1
+	}
+	return width
+}
+
+// SegmentDigests is the digests of the slots of a segment in a shard.
+type SegmentDigests struct {
+	Slots SlotDigests
+	Start int64
+	End   int64
+}
+
+// SegmentSlots is the slots of a segment whose data differ between the replicas.
+type SegmentSlots struct {
+	Slots []int
+	Start int64
+	End   int64
+}
+
+// Contains reports whether the timestamp is in one of the slots.
+func (ss SegmentSlots) Contains(timestamp int64) bool {
+	if timestamp < ss.Start || timestamp >= ss.End {
+		return false
+	}
+	slot := Slot(ss.Start, ss.End, timestamp)
+	for _, s := range ss.Slots {
+		if s == slot {
+			return true
+		}
+	}
+	return false
+}
+
+// Diff returns the slots whose digests differ between the local and the remote segments.
+// The segments are matched by their start time, and the ones on a side only differ in all their non-empty slots.
+// The slots ending after the settled time are skipped, since the data in them might still be in flight.
+func Diff(local, remote []SegmentDigests, settled int64) []SegmentSlots {
+	type pair struct {
+		local, remote *SegmentDigests
+	}
+	pairs := make(map[int64]*pair, len(local))
+	for i := range local {
+		pairs[local[i].Start] = &pair{local: &local[i]}
+	}
+	for i := range remote {
+		p, ok := pairs[remote[i].Start]
+		if !ok {
+			p = &pair{}
+			pairs[remote[i].Start] = p
+		}
+		p.remote = &remote[i]
+	}
+	var empty SlotDigests
+	result := make([]SegmentSlots, 0, len(pairs))
+	for _, p := range pairs {
+		l, r := &empty, &empty
+		seg := p.local
+		if seg != nil {
+			l = &seg.Slots
+		} else {
+			seg = p.remote
+		}
+		if p.remote != nil {
+			r = &p.remote.Slots
+		}
+		var slots []int
+		for i := range l {
+			if l[i] == r[i] {
+				continue
+			}
+			if _, end := SlotRange(seg.Start, seg.End, i); end > settled {
+				continue
+			}
+			slots = append(slots, i)
+		}
+		if len(slots) > 0 {
+			result = append(result, SegmentSlots{Start: seg.Start, End: seg.End, Slots: slots})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Start < result[j].Start
+	})
+	return result
+}
@@ -0,0 +1,96 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package repair
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDigestIsOrderIndependent(t *testing.T) {
+	var d1, d2 Digest
+	d1.Add(Hash(1, 100, 1))
+	d1.Add(Hash(2, 200, 2))
+	d2.Add(Hash(2, 200, 2))
+	d2.Add(Hash(1, 100, 1))
+	assert.Equal(t, d1, d2)
+	assert.Equal(t, uint64(2), d1.Count)
+
+	var d3 Digest
+	d3.Add(Hash(1, 100, 1))
+	d3.Add(Hash(2, 200, 3))
+	assert.NotEqual(t, d1, d3)
+}
+
+func TestSlot(t *testing.T) {
+	const start, end = int64(0), int64(3200)
+	assert.Equal(t, 0, Slot(start, end, 0))
+	assert.Equal(t, 0, Slot(start, end, 99))
+	assert.Equal(t, 1, Slot(start, end, 100))
+	assert.Equal(t, SlotCount-1, Slot(start, end, 3199))
+	assert.Equal(t, SlotCount-1, Slot(start, end, 5000), "the timestamps out of the segment are clamped")
+	for i := 0; i < SlotCount; i++ {
+		begin, e := SlotRange(start, end, i)
+		assert.Equal(t, i, Slot(start, end, begin))
+		assert.Equal(t, i, Slot(start, end, e-1))
+	}
+	assert.Equal(t, 24, Slot(0, 100, 99))
+	begin, e := SlotRange(0, 100, 24)
+	assert.Equal(t, int64(96), begin)
+	assert.Equal(t, int64(100), e)
+	begin, e = SlotRange(0, 100, SlotCount-1)
+	assert.Equal(t, begin, e, "the slots after the end of a short segment are empty")
+}
+
+func TestDiff(t *testing.T) {
+	var local, remote SegmentDigests
+	local.Start, local.End = 0, 3200
+	remote.Start, remote.End = 0, 3200
+	local.Slots[1].Add(1)
+	remote.Slots[1].Add(1)
+	local.Slots[2].Add(2)
+	remote.Slots[3].Add(3)
+	local.Slots[31].Add(4)
+
+	diff := Diff([]SegmentDigests{local}, []SegmentDigests{remote}, 3200)
+	assert.Equal(t, []SegmentSlots{{Start: 0, End: 3200, Slots: []int{2, 3, 31}}}, diff)
+
+	diff = Diff([]SegmentDigests{local}, []SegmentDigests{remote}, 3100)
+	assert.Equal(t, []SegmentSlots{{Start: 0, End: 3200, Slots: []int{2, 3}}}, diff, "the unsettled slots are skipped")
+
+	var other SegmentDigests
+	other.Start, other.End = 3200, 6400
+	other.Slots[0].Add(5)
+	diff = Diff([]SegmentDigests{local}, []SegmentDigests{remote, other}, 6400)
+	assert.Equal(t, []SegmentSlots{
+		{Start: 0, End: 3200, Slots: []int{2, 3, 31}},
+		{Start: 3200, End: 6400, Slots: []int{0}},
+	}, diff, "the segment on a side only differs in its non-empty slots")
+
+	assert.Empty(t, Diff([]SegmentDigests{local}, []SegmentDigests{local}, 3200))
+}
+
+func TestSegmentSlotsContains(t *testing.T) {
+	ss := SegmentSlots{Start: 0, End: 3200, Slots: []int{1, 3}}
+	assert.False(t, ss.Contains(99))
+	assert.True(t, ss.Contains(100))
+	assert.False(t, ss.Contains(200))
+	assert.True(t, ss.Contains(399))
+	assert.False(t, ss.Contains(3200))
+}
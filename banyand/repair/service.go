@@ -0,0 +1,386 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package repair
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	grpclib "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/api/data"
+	clusterv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/cluster/v1"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	propertyv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/property/v1"
+	"github.com/apache/skywalking-banyandb/banyand/metadata"
+	"github.com/apache/skywalking-banyandb/banyand/observability"
+	"github.com/apache/skywalking-banyandb/banyand/property/gossip"
+	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/meter"
+	"github.com/apache/skywalking-banyandb/pkg/run"
+)
+
+const (
+	defaultSettleTime = time.Hour
+	// RetentionMargin is the time before the expiration of the segments, in which they aren't repaired
+	// since a replica might have removed them.
+	RetentionMargin = 24 * time.Hour
+	// lookupTimeout is the timeout to look up the groups and the nodes in the metadata.
+	lookupTimeout = 10 * time.Second
+)
+
+var (
+	repairScope = observability.RootScope.SubScope("replica_repair")
+
+	_ gossip.GroupListener                 = (*service)(nil)
+	_ clusterv1.ReplicaRepairServiceServer = (*server)(nil)
+	_ bus.MessageListener                  = (*repairShardsListener)(nil)
+)
+
+// Catalog takes the snapshots of the shards of the groups in a catalog.
+type Catalog interface {
+	// Snapshot takes the snapshot of the data of the shard, which is kept until it's released.
+	Snapshot(ctx context.Context, group string, shardID uint32) (Snapshot, error)
+}
+
+// Snapshot is the data of a shard at a point in time.
+type Snapshot interface {
+	// Digests returns the digests of the segments of the shard.
+	Digests() ([]SegmentDigests, error)
+	// Send sends the data in the slots to the node, and returns the number of the sent elements or data points.
+	Send(ctx context.Context, node *databasev1.Node, slots []SegmentSlots) (uint64, error)
+	// Release releases the snapshot.
+	Release()
+}
+
+// Service repairs the replicas of the stream and measure shards through the gossip messenger.
+type Service interface {
+	run.PreRunner
+	run.Config
+	// Register registers the catalog to repair the groups of.
+	Register(catalog commonv1.Catalog, c Catalog)
+}
+
+type service struct {
+	metadata   metadata.Repo
+	pipeline   queue.Server
+	omr        observability.MetricsRegistry
+	messenger  gossip.Messenger
+	catalogs   map[commonv1.Catalog]Catalog
+	l          *logger.Logger
+	metrics    *metrics
+	nodeID     string
+	settleTime time.Duration
+	enabled    bool
+}
+
+// NewService returns a new replica repair service, which shares the gossip messenger of the property service.
+func NewService(metadata metadata.Repo, pipeline queue.Server, omr observability.MetricsRegistry, messenger gossip.Messenger) Service {
+	return &service{
+		metadata:  metadata,
+		pipeline:  pipeline,
+		omr:       omr,
+		messenger: messenger,
+		catalogs:  make(map[commonv1.Catalog]Catalog),
+	}
+}
+
+func (s *service) Register(catalog commonv1.Catalog, c Catalog) {
+	s.catalogs[catalog] = c
+}
+
+func (s *service) Name() string {
+	return "replica-repair"
+}
+
+func (s *service) FlagSet() *run.FlagSet {
+	fs := run.NewFlagSet("replica-repair")
+	fs.BoolVar(&s.enabled, "replica-repair-enabled", false, "whether to repair the replicas of the stream and measure shards")
+	fs.DurationVar(&s.settleTime, "replica-repair-settle-time", defaultSettleTime,
+		"the duration the data are settled before they're repaired, the recent data might still be in flight to the replicas")
+	return fs
+}
+
+func (s *service) Validate() error {
+	if s.settleTime < 0 {
+		return errors.New("replica-repair-settle-time must be greater than or equal to 0")
+	}
+	return nil
+}
+
+func (s *service) PreRun(ctx context.Context) error {
+	s.l = logger.GetLogger(s.Name())
+	if !s.enabled || s.messenger == nil {
+		return nil
+	}
+	val := ctx.Value(common.ContextNodeKey)
+	if val == nil {
+		return errors.New("node id is empty")
+	}
+	node := val.(common.Node)
+	if node.PropertyGossipGrpcAddress == "" {
+		s.l.Warn().Msg("the replica repair is disabled since the gossip address is empty")
+		return nil
+	}
+	s.nodeID = node.NodeID
+	s.metrics = newMetrics(s.omr.With(repairScope))
+	s.messenger.Subscribe(s)
+	srv := &server{s: s}
+	s.messenger.RegisterServices(func(r *grpclib.Server) {
+		clusterv1.RegisterReplicaRepairServiceServer(r, srv)
+	})
+	return s.pipeline.Subscribe(data.TopicReplicaRepair, &repairShardsListener{s: s})
+}
+
+func (s *service) AcceptGroup(group string) bool {
+	_, err := s.catalog(group)
+	return err == nil
+}
+
+func (s *service) catalog(group string) (Catalog, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+	g, err := s.metadata.GroupRegistry().GetGroup(ctx, group)
+	if err != nil {
+		return nil, err
+	}
+	c, ok := s.catalogs[g.Catalog]
+	if !ok {
+		return nil, errors.Errorf("the replicas of group %s in catalog %s can't be repaired", group, g.Catalog)
+	}
+	return c, nil
+}
+
+func (s *service) getNode(ctx context.Context, name string) (*databasev1.Node, error) {
+	ctx, cancel := context.WithTimeout(ctx, lookupTimeout)
+	defer cancel()
+	return s.metadata.NodeRegistry().GetNode(ctx, name)
+}
+
+// Rev compares the digests of the shard with the next node, and sends the data of the differing slots to it.
+// The snapshot is kept until the data are sent, so that the data received from the next node aren't sent back.
+func (s *service) Rev(ctx context.Context, tracer gossip.Trace, nextNode *grpclib.ClientConn, request *propertyv1.PropagationRequest) (err error) {
+	span := tracer.CreateSpan(tracer.ActivateSpan(), "repair replica")
+	span.Tag(gossip.TraceTagGroupName, request.Group)
+	span.Tag(gossip.TraceTagShardID, fmt.Sprintf("%d", request.ShardId))
+	span.Tag(gossip.TraceTagTargetNode, nextNode.Target())
+	start := time.Now()
+	s.metrics.totalStarted.Inc(1, request.Group)
+	defer func() {
+		if err != nil {
+			span.Error(err.Error())
+			s.metrics.totalErr.Inc(1, request.Group)
+		}
+		s.metrics.totalFinished.Inc(1, request.Group)
+		s.metrics.totalLatency.Inc(time.Since(start).Seconds(), request.Group)
+		span.End()
+	}()
+	c, err := s.catalog(request.Group)
+	if err != nil {
+		return errors.Wrapf(gossip.ErrAbortPropagation, "failed to find the catalog of group %s: %v", request.Group, err)
+	}
+	snp, err := c.Snapshot(ctx, request.Group, request.ShardId)
+	if err != nil {
+		return errors.Wrapf(gossip.ErrAbortPropagation, "failed to take the snapshot of shard %d: %v", request.ShardId, err)
+	}
+	defer snp.Release()
+	digests, err := snp.Digests()
+	if err != nil {
+		return errors.Wrapf(gossip.ErrAbortPropagation, "failed to compute the digests of shard %d: %v", request.ShardId, err)
+	}
+	resp, err := clusterv1.NewReplicaRepairServiceClient(nextNode).CompareDigests(ctx, &clusterv1.CompareDigestsRequest{
+		Group:    request.Group,
+		ShardId:  request.ShardId,
+		Node:     s.nodeID,
+		Segments: segmentDigestsToProto(digests),
+	})
+	if err != nil {
+		return errors.WithMessagef(err, "failed to compare the digests with %s", nextNode.Target())
+	}
+	if len(resp.Segments) == 0 {
+		return nil
+	}
+	node, err := s.getNode(ctx, resp.Node)
+	if err != nil {
+		return errors.WithMessagef(err, "failed to get node %s", resp.Node)
+	}
+	return s.send(ctx, snp, node, request.Group, segmentSlotsFromProto(resp.Segments))
+}
+
+func (s *service) send(ctx context.Context, snp Snapshot, node *databasev1.Node, group string, slots []SegmentSlots) error {
+	for _, ss := range slots {
+		s.metrics.totalDiffSlots.Inc(float64(len(ss.Slots)), group)
+	}
+	sent, err := snp.Send(ctx, node, slots)
+	s.metrics.totalSent.Inc(float64(sent), group)
+	if err != nil {
+		return errors.WithMessagef(err, "failed to send the differing slots to %s", node.GetMetadata().GetName())
+	}
+	s.l.Info().Str("group", group).Str("node", node.GetMetadata().GetName()).Uint64("sent", sent).
+		Int("segments", len(slots)).Msg("sent the data of the differing slots")
+	return nil
+}
+
+type server struct {
+	clusterv1.UnimplementedReplicaRepairServiceServer
+	s *service
+}
+
+func (srv *server) CompareDigests(ctx context.Context, req *clusterv1.CompareDigestsRequest) (*clusterv1.CompareDigestsResponse, error) {
+	s := srv.s
+	c, err := s.catalog(req.Group)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to find the catalog of group %s: %v", req.Group, err)
+	}
+	snp, err := c.Snapshot(ctx, req.Group, req.ShardId)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to take the snapshot of shard %d: %v", req.ShardId, err)
+	}
+	defer snp.Release()
+	digests, err := snp.Digests()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to compute the digests of shard %d: %v", req.ShardId, err)
+	}
+	diff := Diff(digests, segmentDigestsFromProto(req.Segments), time.Now().Add(-s.settleTime).UnixNano())
+	resp := &clusterv1.CompareDigestsResponse{
+		Node:     s.nodeID,
+		Segments: segmentSlotsToProto(diff),
+	}
+	if len(diff) == 0 {
+		return resp, nil
+	}
+	node, err := s.getNode(ctx, req.Node)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "failed to get node %s: %v", req.Node, err)
+	}
+	if err = s.send(ctx, snp, node, req.Group, diff); err != nil {
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+	return resp, nil
+}
+
+// repairShardsListener starts the repairs of the shards the data node is the first node of.
+type repairShardsListener struct {
+	*bus.UnImplementedHealthyListener
+	s *service
+}
+
+func (l *repairShardsListener) Rev(_ context.Context, message bus.Message) bus.Message {
+	now := time.Now().UnixNano()
+	req, ok := message.Data().(*databasev1.InternalRepairShardsRequest)
+	if !ok || req == nil {
+		return bus.NewMessage(bus.MessageID(now), common.NewError("invalid repair shards request"))
+	}
+	resp := &databasev1.InternalRepairShardsResponse{}
+	for _, r := range req.Repairs {
+		if len(r.Nodes) == 0 || r.Nodes[0] != l.s.nodeID {
+			continue
+		}
+		repair := proto.Clone(r).(*databasev1.ShardRepair)
+		if err := l.s.messenger.Propagation(repair.Nodes, repair.Group, repair.ShardId); err != nil {
+			l.s.l.Error().Err(err).Str("group", repair.Group).Uint32("shard", repair.ShardId).Msg("failed to start the repair")
+			repair.Error = err.Error()
+		}
+		resp.Repairs = append(resp.Repairs, repair)
+	}
+	return bus.NewMessage(bus.MessageID(now), resp)
+}
+
+func segmentDigestsToProto(digests []SegmentDigests) []*clusterv1.SegmentDigest {
+	result := make([]*clusterv1.SegmentDigest, 0, len(digests))
+	for i := range digests {
+		sd := &clusterv1.SegmentDigest{Start: digests[i].Start, End: digests[i].End}
+		for slot, d := range digests[i].Slots {
+			if d == (Digest{}) {
+				continue
+			}
+			sd.Slots = append(sd.Slots, &clusterv1.SlotDigest{Slot: uint32(slot), Count: d.Count, Hash: d.Hash})
+		}
+		result = append(result, sd)
+	}
+	return result
+}
+
+func segmentDigestsFromProto(segments []*clusterv1.SegmentDigest) []SegmentDigests {
+	result := make([]SegmentDigests, 0, len(segments))
+	for _, seg := range segments {
+		sd := SegmentDigests{Start: seg.Start, End: seg.End}
+		for _, slot := range seg.Slots {
+			if slot.Slot < SlotCount {
+				sd.Slots[slot.Slot] = Digest{Count: slot.Count, Hash: slot.Hash}
+			}
+		}
+		result = append(result, sd)
+	}
+	return result
+}
+
+func segmentSlotsToProto(slots []SegmentSlots) []*clusterv1.SegmentSlots {
+	result := make([]*clusterv1.SegmentSlots, 0, len(slots))
+	for _, ss := range slots {
+		seg := &clusterv1.SegmentSlots{Start: ss.Start, End: ss.End}
+		for _, slot := range ss.Slots {
+			seg.Slots = append(seg.Slots, uint32(slot))
+		}
+		result = append(result, seg)
+	}
+	return result
+}
+
+func segmentSlotsFromProto(segments []*clusterv1.SegmentSlots) []SegmentSlots {
+	result := make([]SegmentSlots, 0, len(segments))
+	for _, seg := range segments {
+		ss := SegmentSlots{Start: seg.Start, End: seg.End}
+		for _, slot := range seg.Slots {
+			if slot < SlotCount {
+				ss.Slots = append(ss.Slots, int(slot))
+			}
+		}
+		result = append(result, ss)
+	}
+	return result
+}
+
+type metrics struct {
+	totalStarted   meter.Counter
+	totalFinished  meter.Counter
+	totalErr       meter.Counter
+	totalLatency   meter.Counter
+	totalDiffSlots meter.Counter
+	totalSent      meter.Counter
+}
+
+func newMetrics(factory *observability.Factory) *metrics {
+	return &metrics{
+		totalStarted:   factory.NewCounter("total_started", "group"),
+		totalFinished:  factory.NewCounter("total_finished", "group"),
+		totalErr:       factory.NewCounter("total_err", "group"),
+		totalLatency:   factory.NewCounter("total_latency", "group"),
+		totalDiffSlots: factory.NewCounter("total_diff_slots", "group"),
+		totalSent:      factory.NewCounter("total_sent", "group"),
+	}
+}
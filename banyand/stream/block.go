@@ -18,7 +18,6 @@
 package stream
 
 import (
-	"bytes"
 	"fmt"
 	"sort"
 
@@ -26,6 +25,7 @@ import (
 
 	"github.com/apache/skywalking-banyandb/api/common"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
 	pkgbytes "github.com/apache/skywalking-banyandb/pkg/bytes"
 	"github.com/apache/skywalking-banyandb/pkg/encoding"
 	"github.com/apache/skywalking-banyandb/pkg/filter"
@@ -115,15 +115,15 @@ func (b *block) processTags(tf tagValues, tagFamilyIdx, i int, elementsLen int)
 		tags[j].filter.SetN(elementsLen)
 		tags[j].filter.ResizeBits((elementsLen*filter.B + 63) / 64)
 		tags[j].filter.Add(t.value)
-		if t.valueType == pbv1.ValueTypeInt64 {
+		if t.valueType == pbv1.ValueTypeInt64 || t.valueType == pbv1.ValueTypeFloat64 {
 			if len(tags[j].min) == 0 {
 				tags[j].min = t.value
-			} else if bytes.Compare(t.value, tags[j].min) == -1 {
+			} else if compareTagValue(t.valueType, t.value, tags[j].min) == -1 {
 				tags[j].min = t.value
 			}
 			if len(tags[j].max) == 0 {
 				tags[j].max = t.value
-			} else if bytes.Compare(t.value, tags[j].max) == 1 {
+			} else if compareTagValue(t.valueType, t.value, tags[j].max) == 1 {
 				tags[j].max = t.value
 			}
 		}
@@ -414,6 +414,7 @@ type blockCursor struct {
 	p                *part
 	timestamps       []int64
 	elementFilter    posting.List
+	tombstones       *storage.Tombstones
 	elementIDs       []uint64
 	tagFamilies      []tagFamily
 	tagValuesDecoder encoding.BytesBlockDecoder
@@ -427,6 +428,7 @@ type blockCursor struct {
 func (bc *blockCursor) reset() {
 	bc.idx = 0
 	bc.p = nil
+	bc.tombstones = nil
 	bc.bm.reset()
 	bc.minTimestamp = 0
 	bc.maxTimestamp = 0
@@ -450,6 +452,7 @@ func (bc *blockCursor) init(p *part, bm *blockMetadata, opts queryOptions) {
 	bc.maxTimestamp = opts.maxTimestamp
 	bc.tagProjection = opts.TagProjection
 	bc.elementFilter = opts.elementFilter
+	bc.tombstones = opts.tombstones
 }
 
 func (bc *blockCursor) copyAllTo(r *model.StreamResult, desc bool) {
@@ -563,9 +566,15 @@ func (bc *blockCursor) loadData(tmpBlock *block) bool {
 
 	idxList := make([]int, 0)
 	var start, end int
-	if bc.elementFilter != nil {
+	if bc.elementFilter != nil || bc.tombstones.Overlaps(tmpBlock.timestamps[0], tmpBlock.timestamps[len(tmpBlock.timestamps)-1]) {
 		for i := range tmpBlock.elementIDs {
-			if bc.elementFilter.Contains(tmpBlock.elementIDs[i]) {
+			if bc.elementFilter != nil && !bc.elementFilter.Contains(tmpBlock.elementIDs[i]) {
+				continue
+			}
+			if bc.elementFilter == nil && (tmpBlock.timestamps[i] < bc.minTimestamp || tmpBlock.timestamps[i] > bc.maxTimestamp) {
+				continue
+			}
+			if !bc.tombstones.IsDeleted(bc.p.partMetadata.ID, tmpBlock.elementIDs[i], tmpBlock.timestamps[i]) {
 				idxList = append(idxList, i)
 				bc.timestamps = append(bc.timestamps, tmpBlock.timestamps[i])
 				bc.elementIDs = append(bc.elementIDs, tmpBlock.elementIDs[i])
@@ -645,6 +654,50 @@ func (bi *blockPointer) updateMetadata() {
 	bi.bm.timestamps.max = bi.block.timestamps[len(bi.timestamps)-1]
 }
 
+// removeDeleted removes the elements which are deleted by the tombstones from the block of the part.
+func (bi *blockPointer) removeDeleted(partID uint64, tombstones *storage.Tombstones) {
+	count := len(bi.timestamps)
+	if count == 0 || !tombstones.Overlaps(bi.timestamps[0], bi.timestamps[count-1]) {
+		return
+	}
+	bi.retain(func(i int) bool {
+		return !tombstones.IsDeleted(partID, bi.elementIDs[i], bi.timestamps[i])
+	})
+}
+
+// retain keeps the elements at the indexes which the keep function returns true for.
+func (bi *blockPointer) retain(keep func(i int) bool) {
+	count := len(bi.timestamps)
+	kept := make([]int, 0, count)
+	for i := 0; i < count; i++ {
+		if keep(i) {
+			kept = append(kept, i)
+		}
+	}
+	if len(kept) == count {
+		return
+	}
+	for n, i := range kept {
+		bi.timestamps[n] = bi.timestamps[i]
+		bi.elementIDs[n] = bi.elementIDs[i]
+	}
+	bi.timestamps = bi.timestamps[:len(kept)]
+	bi.elementIDs = bi.elementIDs[:len(kept)]
+	for i := range bi.tagFamilies {
+		for j := range bi.tagFamilies[i].tags {
+			t := &bi.tagFamilies[i].tags[j]
+			if len(t.values) != count {
+				continue
+			}
+			for n, k := range kept {
+				t.values[n] = t.values[k]
+			}
+			t.values = t.values[:len(kept)]
+		}
+	}
+	bi.updateMetadata()
+}
+
 func (bi *blockPointer) copyFrom(src *blockPointer) {
 	bi.idx = 0
 	bi.bm.copyFrom(&src.bm)
@@ -120,6 +120,7 @@ func getBlockScanner(ctx context.Context, segment storage.Segment[*tsTable, *opt
 	var parts []*part
 	var size, offset int
 	filterIndex := make(map[uint64]posting.List)
+	tombstoneIndex := make(map[*part]*storage.Tombstones)
 	for i := range tabs {
 		filter, filterTS, err := search(ctx, qo, qo.sortedSids, tabs[i], tr)
 		if err != nil {
@@ -141,6 +142,9 @@ func getBlockScanner(ctx context.Context, segment storage.Segment[*tsTable, *opt
 		finalizers = append(finalizers, snp.decRef)
 		for j := offset; j < offset+size; j++ {
 			filterIndex[parts[j].partMetadata.ID] = filter
+			if snp.tombstones.Len() > 0 {
+				tombstoneIndex[parts[j]] = snp.tombstones
+			}
 		}
 		offset += size
 	}
@@ -154,13 +158,14 @@ func getBlockScanner(ctx context.Context, segment storage.Segment[*tsTable, *opt
 		asc = qo.Order.Sort == modelv1.Sort_SORT_ASC || qo.Order.Sort == modelv1.Sort_SORT_UNSPECIFIED
 	}
 	return &blockScanner{
-		parts:       getDisjointParts(parts, asc),
-		filterIndex: filterIndex,
-		qo:          qo,
-		asc:         asc,
-		l:           l,
-		pm:          pm,
-		finalizers:  finalizers,
+		parts:          getDisjointParts(parts, asc),
+		filterIndex:    filterIndex,
+		tombstoneIndex: tombstoneIndex,
+		qo:             qo,
+		asc:            asc,
+		l:              l,
+		pm:             pm,
+		finalizers:     finalizers,
 	}, nil
 }
 
@@ -200,13 +205,14 @@ func search(ctx context.Context, qo queryOptions, seriesList []common.SeriesID,
 type scanFinalizer func()
 
 type blockScanner struct {
-	filterIndex map[uint64]posting.List
-	l           *logger.Logger
-	pm          protector.Memory
-	parts       [][]*part
-	finalizers  []scanFinalizer
-	qo          queryOptions
-	asc         bool
+	filterIndex    map[uint64]posting.List
+	tombstoneIndex map[*part]*storage.Tombstones
+	l              *logger.Logger
+	pm             protector.Memory
+	parts          [][]*part
+	finalizers     []scanFinalizer
+	qo             queryOptions
+	asc            bool
 }
 
 func (bsn *blockScanner) scan(ctx context.Context, blockCh chan *blockScanResultBatch) {
@@ -221,6 +227,8 @@ func (bsn *blockScanner) scan(ctx context.Context, blockCh chan *blockScanResult
 		parts = bsn.parts[len(bsn.parts)-1]
 		bsn.parts = bsn.parts[:len(bsn.parts)-1]
 	}
+	stats := query.GetOperatorStats(ctx)
+	stats.AddParts(len(parts))
 	bma := generateBlockMetadataArray()
 	defer releaseBlockMetadataArray(bma)
 	ti := generateTstIter()
@@ -239,6 +247,7 @@ func (bsn *blockScanner) scan(ctx context.Context, blockCh chan *blockScanResult
 	}
 	var totalBlockBytes uint64
 	for ti.nextBlock() {
+		stats.AddBlocks(1)
 		p := ti.piHeap[0]
 		batch.bss = append(batch.bss, blockScanResult{
 			p: p.p,
@@ -246,6 +255,7 @@ func (bsn *blockScanner) scan(ctx context.Context, blockCh chan *blockScanResult
 		bs := &batch.bss[len(batch.bss)-1]
 		bs.qo.copyFrom(&bsn.qo)
 		bs.qo.elementFilter = bsn.filterIndex[p.p.partMetadata.ID]
+		bs.qo.tombstones = bsn.tombstoneIndex[p.p]
 		bs.bm.copyFrom(p.curBlock)
 		quota := bsn.pm.AvailableBytes()
 		for i := range batch.bss {
@@ -108,6 +108,10 @@ func (e *elementIndex) Search(ctx context.Context, seriesList []uint64, filter i
 	return result, resultTS, nil
 }
 
+func (e *elementIndex) Statistics() index.Statistics {
+	return e.store
+}
+
 func (e *elementIndex) EnableExternalSegments() (index.ExternalSegmentStreamer, error) {
 	return e.store.EnableExternalSegments()
 }
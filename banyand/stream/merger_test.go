@@ -291,7 +291,7 @@ func Test_mergeParts(t *testing.T) {
 				closeCh := make(chan struct{})
 				defer close(closeCh)
 				tst := &tsTable{pm: protector.Nop{}}
-				p, err := tst.mergeParts(fileSystem, closeCh, pp, partID, root)
+				p, err := tst.mergeParts(fileSystem, closeCh, pp, partID, nil, nil, root)
 				if tt.wantErr != nil {
 					if !errors.Is(err, tt.wantErr) {
 						t.Fatalf("Unexpected error: got %v, want %v", err, tt.wantErr)
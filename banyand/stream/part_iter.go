@@ -24,6 +24,7 @@ import (
 	"sort"
 
 	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
 	"github.com/apache/skywalking-banyandb/pkg/bytes"
 	"github.com/apache/skywalking-banyandb/pkg/compress/zstd"
 	"github.com/apache/skywalking-banyandb/pkg/encoding"
@@ -265,15 +266,21 @@ func (pi *partIter) findBlock() bool {
 type partMergeIter struct {
 	seqReaders           seqReaders
 	err                  error
+	tombstones           *storage.Tombstones
+	keep                 func(sid common.SeriesID, timestamp int64, elementID uint64) bool
 	primaryBlockMetadata []primaryBlockMetadata
 	compressedPrimaryBuf []byte
 	primaryBuf           []byte
 	block                blockPointer
 	primaryMetadataIdx   int
+	partID               uint64
 }
 
 func (pmi *partMergeIter) reset() {
 	pmi.err = nil
+	pmi.tombstones = nil
+	pmi.keep = nil
+	pmi.partID = 0
 	pmi.seqReaders.reset()
 	pmi.primaryBlockMetadata = nil
 	pmi.primaryMetadataIdx = 0
@@ -286,6 +293,7 @@ func (pmi *partMergeIter) mustInitFromPart(p *part) {
 	pmi.reset()
 	pmi.seqReaders.init(p)
 	pmi.primaryBlockMetadata = p.primaryBlockMetadata
+	pmi.partID = p.partMetadata.ID
 }
 
 func (pmi *partMergeIter) error() error {
@@ -342,6 +350,13 @@ func (pmi *partMergeIter) loadBlockMetadata() error {
 
 func (pmi *partMergeIter) mustLoadBlockData(decoder *encoding.BytesBlockDecoder, block *blockPointer) {
 	block.block.mustSeqReadFrom(decoder, &pmi.seqReaders, pmi.block.bm)
+	block.removeDeleted(pmi.partID, pmi.tombstones)
+	if pmi.keep != nil && len(block.timestamps) > 0 {
+		sid := pmi.block.bm.seriesID
+		block.retain(func(i int) bool {
+			return pmi.keep(sid, block.timestamps[i], block.elementIDs[i])
+		})
+	}
 }
 
 func generatePartMergeIter() *partMergeIter {
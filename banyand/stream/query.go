@@ -254,6 +254,7 @@ func (s *stream) processSegmentsAndBuildFilters(
 
 type queryOptions struct {
 	elementFilter  posting.List
+	tombstones     *storage.Tombstones
 	seriesToEntity map[common.SeriesID][]*modelv1.TagValue
 	sortedSids     []common.SeriesID
 	model.StreamQueryOptions
@@ -264,6 +265,7 @@ type queryOptions struct {
 func (qo *queryOptions) reset() {
 	qo.StreamQueryOptions.Reset()
 	qo.elementFilter = nil
+	qo.tombstones = nil
 	qo.seriesToEntity = nil
 	qo.sortedSids = nil
 	qo.minTimestamp = 0
@@ -273,6 +275,7 @@ func (qo *queryOptions) reset() {
 func (qo *queryOptions) copyFrom(other *queryOptions) {
 	qo.StreamQueryOptions.CopyFrom(&other.StreamQueryOptions)
 	qo.elementFilter = other.elementFilter
+	qo.tombstones = other.tombstones
 	qo.seriesToEntity = other.seriesToEntity
 	qo.sortedSids = other.sortedSids
 	qo.minTimestamp = other.minTimestamp
@@ -366,6 +369,8 @@ func mustDecodeTagValue(valueType pbv1.ValueType, value []byte) *modelv1.TagValu
 	switch valueType {
 	case pbv1.ValueTypeInt64:
 		return int64TagValue(convert.BytesToInt64(value))
+	case pbv1.ValueTypeFloat64:
+		return float64TagValue(convert.BytesToFloat64(value))
 	case pbv1.ValueTypeStr:
 		return strTagValue(string(value))
 	case pbv1.ValueTypeBinaryData:
@@ -395,6 +400,16 @@ func mustDecodeTagValue(valueType pbv1.ValueType, value []byte) *modelv1.TagValu
 	}
 }
 
+func float64TagValue(value float64) *modelv1.TagValue {
+	return &modelv1.TagValue{
+		Value: &modelv1.TagValue_Float{
+			Float: &modelv1.Float{
+				Value: value,
+			},
+		},
+	}
+}
+
 func int64TagValue(value int64) *modelv1.TagValue {
 	return &modelv1.TagValue{
 		Value: &modelv1.TagValue_Int{
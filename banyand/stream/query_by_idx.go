@@ -64,6 +64,7 @@ func (qr *idxResult) Pull(ctx context.Context) *model.StreamResult {
 func (qr *idxResult) scanParts(ctx context.Context, qo queryOptions) error {
 	var parts []*part
 	var n int
+	tombstoneIndex := make(map[*part]*storage.Tombstones)
 	for i := range qr.tabs {
 		s := qr.tabs[i].currentSnapshot()
 		if s == nil {
@@ -74,6 +75,11 @@ func (qr *idxResult) scanParts(ctx context.Context, qo queryOptions) error {
 			s.decRef()
 			continue
 		}
+		if s.tombstones.Len() > 0 {
+			for _, p := range parts[len(parts)-n:] {
+				tombstoneIndex[p] = s.tombstones
+			}
+		}
 		qr.snapshots = append(qr.snapshots, s)
 	}
 	bma := generateBlockMetadataArray()
@@ -101,6 +107,7 @@ func (qr *idxResult) scanParts(ctx context.Context, qo queryOptions) error {
 		hit++
 		bc := generateBlockCursor()
 		p := ti.piHeap[0]
+		qo.tombstones = tombstoneIndex[p.p]
 		bc.init(p.p, p.curBlock, qo)
 		qr.data = append(qr.data, bc)
 		totalBlockBytes += bc.bm.uncompressedSizeBytes
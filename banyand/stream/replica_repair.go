@@ -0,0 +1,346 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/api/data"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/banyand/queue/pub"
+	"github.com/apache/skywalking-banyandb/banyand/repair"
+	"github.com/apache/skywalking-banyandb/pkg/bytes"
+	"github.com/apache/skywalking-banyandb/pkg/compress/zstd"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+const replicaRepairDir = "replica-repair"
+
+type repairCatalog struct {
+	s *standalone
+}
+
+// NewRepairCatalog returns the catalog of the stream shards for the replica repair.
+func NewRepairCatalog(svc Service) repair.Catalog {
+	return &repairCatalog{s: svc.(*standalone)}
+}
+
+func (c *repairCatalog) Snapshot(_ context.Context, group string, shardID uint32) (repair.Snapshot, error) {
+	g, ok := c.s.schemaRepo.LoadGroup(group)
+	if !ok {
+		return nil, errors.Errorf("group %s not found", group)
+	}
+	db, err := c.s.schemaRepo.loadTSDB(group)
+	if err != nil {
+		return nil, err
+	}
+	segments, err := db.SelectSegments(timestamp.NewInclusiveTimeRange(time.Unix(0, 0), time.Unix(0, timestamp.MaxNanoTime)))
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to select segments")
+	}
+	// The segments close to their expiration might have been removed by a replica.
+	expiring := storage.MustToIntervalRule(g.GetSchema().ResourceOpts.Ttl).PreviousTime(time.Now()).Add(repair.RetentionMargin)
+	rs := &repairSnapshot{s: c.s, group: group, shardID: shardID}
+	for _, seg := range segments {
+		tst, ok := seg.Table(common.ShardID(shardID))
+		if !ok || seg.GetTimeRange().End.Before(expiring) {
+			seg.DecRef()
+			continue
+		}
+		snp := tst.currentSnapshot()
+		if snp == nil {
+			seg.DecRef()
+			continue
+		}
+		rs.segments = append(rs.segments, repairSegment{segment: seg, snapshot: snp})
+	}
+	return rs, nil
+}
+
+type repairSegment struct {
+	segment  storage.Segment[*tsTable, option]
+	snapshot *snapshot
+}
+
+type repairSnapshot struct {
+	s        *standalone
+	group    string
+	segments []repairSegment
+	shardID  uint32
+}
+
+func (rs *repairSnapshot) Digests() ([]repair.SegmentDigests, error) {
+	result := make([]repair.SegmentDigests, 0, len(rs.segments))
+	for _, rseg := range rs.segments {
+		tr := rseg.segment.GetTimeRange()
+		sd := repair.SegmentDigests{Start: tr.Start.UnixNano(), End: tr.End.UnixNano()}
+		for _, pw := range rseg.snapshot.parts {
+			digests, err := pw.p.repairDigestsOf(sd.Start, sd.End, rseg.snapshot.tombstones)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "failed to compute the digests of %s", pw.p)
+			}
+			sd.Slots.Merge(digests)
+		}
+		result = append(result, sd)
+	}
+	return result, nil
+}
+
+// Send merges the elements in the slots of the parts into a file part for each segment, and sends them to the node.
+func (rs *repairSnapshot) Send(ctx context.Context, node *databasev1.Node, slots []repair.SegmentSlots) (uint64, error) {
+	segments := make(map[int64]repairSegment, len(rs.segments))
+	for _, rseg := range rs.segments {
+		segments[rseg.segment.GetTimeRange().Start.UnixNano()] = rseg
+	}
+	client := pub.NewWithoutMetadata()
+	defer client.GracefulStop()
+	client.OnAddOrUpdate(schema.Metadata{
+		TypeMeta: schema.TypeMeta{
+			Kind: schema.KindNode,
+		},
+		Spec: node,
+	})
+	chunkedClient, err := client.NewChunkedSyncClient(node.GetMetadata().GetName(), shardMoveChunkSize)
+	if err != nil {
+		return 0, errors.WithMessagef(err, "failed to create the chunked sync client for %s", node.GetMetadata().GetName())
+	}
+	defer chunkedClient.Close()
+
+	rs.s.lfs.MkdirIfNotExist(rs.s.repairDir, storage.DirPerm)
+	var sent uint64
+	for i, ss := range slots {
+		rseg, ok := segments[ss.Start]
+		if !ok {
+			continue
+		}
+		n, sendErr := rs.sendSlots(ctx, chunkedClient, rseg, ss, uint64(i+1))
+		if sendErr != nil {
+			return sent, sendErr
+		}
+		sent += n
+	}
+	return sent, nil
+}
+
+func (rs *repairSnapshot) sendSlots(ctx context.Context, client queue.ChunkedSyncClient, rseg repairSegment, ss repair.SegmentSlots, id uint64) (uint64, error) {
+	var pii []*partMergeIter
+	defer func() {
+		for _, pmi := range pii {
+			releasePartMergeIter(pmi)
+		}
+	}()
+	for _, pw := range rseg.snapshot.parts {
+		pm := pw.p.partMetadata
+		overlapped := false
+		for _, slot := range ss.Slots {
+			begin, end := repair.SlotRange(ss.Start, ss.End, slot)
+			if pm.MinTimestamp < end && pm.MaxTimestamp >= begin {
+				overlapped = true
+				break
+			}
+		}
+		if !overlapped {
+			continue
+		}
+		pmi := generatePartMergeIter()
+		pmi.mustInitFromPart(pw.p)
+		pmi.tombstones = rseg.snapshot.tombstones
+		pmi.keep = func(_ common.SeriesID, timestamp int64, _ uint64) bool {
+			return ss.Contains(timestamp)
+		}
+		pii = append(pii, pmi)
+	}
+	if len(pii) == 0 {
+		return 0, nil
+	}
+
+	dir := filepath.Join(rs.s.repairDir, fmt.Sprintf("%s-%d-%d", rs.group, rs.shardID, time.Now().UnixNano()))
+	defer rs.s.lfs.MustRMAll(dir)
+	br := generateBlockReader()
+	br.init(pii)
+	bw := generateBlockWriter()
+	bw.mustInitForFilePart(rs.s.lfs, dir, false)
+	pm, err := mergeBlocks(nil, bw, br)
+	releaseBlockWriter(bw)
+	releaseBlockReader(br)
+	if err != nil {
+		return 0, err
+	}
+	if pm.TotalCount == 0 {
+		return 0, nil
+	}
+	pm.mustWriteMetadata(rs.s.lfs, dir)
+	rs.s.lfs.SyncPath(dir)
+
+	partData, err := ParsePartMetadata(rs.s.lfs, dir)
+	if err != nil {
+		return 0, errors.WithMessagef(err, "failed to parse the metadata of part %s", dir)
+	}
+	files, release := CreatePartFileReaderFromPath(dir, rs.s.lfs)
+	defer release()
+	partData.ID = id
+	partData.Group = rs.group
+	partData.ShardID = rs.shardID
+	partData.Topic = data.TopicStreamPartRepair.String()
+	partData.Files = files
+	result, err := client.SyncStreamingParts(ctx, []queue.StreamingPartData{partData})
+	if err != nil {
+		return 0, errors.WithMessage(err, "failed to sync the streaming parts")
+	}
+	if !result.Success {
+		return 0, errors.Errorf("failed to sync the streaming parts: %s", result.ErrorMessage)
+	}
+	return pm.TotalCount, nil
+}
+
+func (rs *repairSnapshot) Release() {
+	for _, rseg := range rs.segments {
+		rseg.snapshot.decRef()
+		rseg.segment.DecRef()
+	}
+	rs.segments = nil
+}
+
+// repairDigestsOf returns the digests of the elements in the part which aren't deleted by the tombstones.
+// The digests are cached in the part unless the tombstones might delete some of its elements.
+func (p *part) repairDigestsOf(start, end int64, tombstones *storage.Tombstones) (*repair.SlotDigests, error) {
+	deleting := tombstones.Overlaps(p.partMetadata.MinTimestamp, p.partMetadata.MaxTimestamp)
+	if !deleting {
+		if digests := p.repairDigests.Load(); digests != nil {
+			return digests, nil
+		}
+	}
+	var digests repair.SlotDigests
+	err := p.visitElements(func(sid common.SeriesID, timestamp int64, elementID uint64) {
+		if deleting && tombstones.IsDeleted(p.partMetadata.ID, elementID, timestamp) {
+			return
+		}
+		digests[repair.Slot(start, end, timestamp)].Add(repair.Hash(uint64(sid), timestamp, elementID))
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !deleting {
+		p.repairDigests.Store(&digests)
+	}
+	return &digests, nil
+}
+
+// visitElements visits the series IDs, the timestamps and the IDs of the elements in the part.
+func (p *part) visitElements(visit func(sid common.SeriesID, timestamp int64, elementID uint64)) error {
+	var compressedBuf, buf []byte
+	var bms []blockMetadata
+	var timestamps []int64
+	var elementIDs []uint64
+	for i := range p.primaryBlockMetadata {
+		pbm := &p.primaryBlockMetadata[i]
+		compressedBuf = bytes.ResizeOver(compressedBuf, int(pbm.size))
+		fs.MustReadData(p.primary, int64(pbm.offset), compressedBuf)
+		var err error
+		buf, err = zstd.Decompress(buf[:0], compressedBuf)
+		if err != nil {
+			return fmt.Errorf("cannot decompress primary block: %w", err)
+		}
+		bms, err = unmarshalBlockMetadata(bms[:0], buf)
+		if err != nil {
+			return fmt.Errorf("cannot unmarshal primary block: %w", err)
+		}
+		for j := range bms {
+			bm := &bms[j]
+			timestamps, elementIDs = mustReadTimestampsFrom(timestamps[:0], elementIDs[:0], &bm.timestamps, int(bm.count), p.timestamps)
+			for k := range timestamps {
+				visit(bm.seriesID, timestamps[k], elementIDs[k])
+			}
+		}
+	}
+	return nil
+}
+
+// elementHashes returns the hashes of the elements in the parts overlapping the time range.
+func (tst *tsTable) elementHashes(minTimestamp, maxTimestamp int64) (map[uint64]struct{}, error) {
+	snp := tst.currentSnapshot()
+	if snp == nil {
+		return nil, nil
+	}
+	defer snp.decRef()
+	parts, _ := snp.getParts(nil, minTimestamp, maxTimestamp)
+	hashes := make(map[uint64]struct{})
+	for _, p := range parts {
+		err := p.visitElements(func(sid common.SeriesID, timestamp int64, elementID uint64) {
+			if timestamp >= minTimestamp && timestamp <= maxTimestamp {
+				hashes[repair.Hash(uint64(sid), timestamp, elementID)] = struct{}{}
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return hashes, nil
+}
+
+// finishRepair introduces the elements in the repaired part which the table doesn't have,
+// so that the elements sent by several replicas aren't duplicated.
+func (s *syncPartContext) finishRepair() error {
+	mp, tst := s.memPart, s.tsTable
+	if err := s.Close(); err != nil {
+		releaseMemPart(mp)
+		return err
+	}
+	existing, err := tst.elementHashes(mp.partMetadata.MinTimestamp, mp.partMetadata.MaxTimestamp)
+	if err != nil {
+		releaseMemPart(mp)
+		return err
+	}
+	if len(existing) == 0 {
+		tst.mustAddMemPart(mp)
+		return nil
+	}
+	pmi := generatePartMergeIter()
+	pmi.mustInitFromPart(openMemPart(mp))
+	pmi.keep = func(sid common.SeriesID, timestamp int64, elementID uint64) bool {
+		_, ok := existing[repair.Hash(uint64(sid), timestamp, elementID)]
+		return !ok
+	}
+	br := generateBlockReader()
+	br.init([]*partMergeIter{pmi})
+	filtered := generateMemPart()
+	bw := generateBlockWriter()
+	bw.MustInitForMemPart(filtered)
+	pm, err := mergeBlocks(tst.loopCloser.CloseNotify(), bw, br)
+	releaseBlockWriter(bw)
+	releaseBlockReader(br)
+	releasePartMergeIter(pmi)
+	releaseMemPart(mp)
+	if err != nil || pm.TotalCount == 0 {
+		releaseMemPart(filtered)
+		return err
+	}
+	filtered.partMetadata = *pm
+	tst.mustAddMemPart(filtered)
+	return nil
+}
@@ -0,0 +1,439 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/api/data"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/banyand/metadata/schema"
+	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/banyand/queue/pub"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+const (
+	shardMoveDir       = "shard-move"
+	shardMoveChunkSize = 1024 * 1024
+	// shardMoveFlushWait is the extra time to wait for the memory parts to be flushed before a shard is moved.
+	shardMoveFlushWait = time.Minute
+)
+
+// SetClientTLS sets the TLS settings of the clients connecting to the other data nodes, like the clients moving the shards.
+func SetClientTLS(svc Service, tls *pub.ClientTLS) {
+	if s, ok := svc.(*standalone); ok {
+		s.clientTLS = tls
+	}
+}
+
+// shardMoveKey identifies a copy of a shard moved to a target node.
+type shardMoveKey struct {
+	group   string
+	target  string
+	shardID uint32
+}
+
+// movedParts is the parts sent to the targets by the start of their segments. They're kept on the source
+// until the routing is switched to the targets, then the catch-up of the move removes them.
+type movedParts struct {
+	sent map[shardMoveKey]map[int64]map[uint64]struct{}
+	mu   sync.Mutex
+}
+
+func (mp *movedParts) store(key shardMoveKey, sent map[int64]map[uint64]struct{}) {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+	if mp.sent == nil {
+		mp.sent = make(map[shardMoveKey]map[int64]map[uint64]struct{})
+	}
+	mp.sent[key] = sent
+}
+
+// take returns the parts sent to the target and forgets them.
+func (mp *movedParts) take(key shardMoveKey) (map[int64]map[uint64]struct{}, bool) {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+	sent, ok := mp.sent[key]
+	delete(mp.sent, key)
+	return sent, ok
+}
+
+type moveShardsListener struct {
+	*bus.UnImplementedHealthyListener
+	s *standalone
+}
+
+func (l *moveShardsListener) Rev(ctx context.Context, message bus.Message) bus.Message {
+	now := time.Now().UnixNano()
+	req, ok := message.Data().(*databasev1.InternalMoveShardsRequest)
+	if !ok || req == nil {
+		return bus.NewMessage(bus.MessageID(now), common.NewError("invalid move shards request"))
+	}
+	targets := make(map[string]*databasev1.Node, len(req.Targets))
+	for _, n := range req.Targets {
+		targets[n.GetMetadata().GetName()] = n
+	}
+	resp := &databasev1.InternalMoveShardsResponse{}
+	for _, m := range req.Moves {
+		// Every data node receives the request, and moves the copies it's the source of.
+		if m.Source != l.s.nodeID {
+			continue
+		}
+		move := proto.Clone(m).(*databasev1.ShardMove)
+		if err := l.s.moveShard(ctx, move, targets[move.Target]); err != nil {
+			l.s.l.Error().Err(err).Str("group", move.Group).Uint32("shard", move.ShardId).
+				Str("target", move.Target).Msg("failed to move the shard")
+			move.Error = err.Error()
+		}
+		resp.Moves = append(resp.Moves, move)
+	}
+	return bus.NewMessage(bus.MessageID(now), resp)
+}
+
+// moveShard sends the parts and the indexes of the shard to the target node,
+// and keeps the sent parts on the source, where they aren't merged, until the routing is switched to the target.
+// The catch-up of the move sends the parts written since, then removes all the sent parts unless the move keeps the source.
+func (s *standalone) moveShard(ctx context.Context, move *databasev1.ShardMove, target *databasev1.Node) error {
+	if target == nil {
+		return errors.Errorf("target node %s is unknown", move.Target)
+	}
+	g, ok := s.schemaRepo.LoadGroup(move.Group)
+	if !ok {
+		return errors.Errorf("group %s not found", move.Group)
+	}
+	db, err := s.schemaRepo.loadTSDB(move.Group)
+	if err != nil {
+		return err
+	}
+	shardID := common.ShardID(move.ShardId)
+	segments, err := db.SelectSegments(timestamp.NewInclusiveTimeRange(time.Unix(0, 0), time.Unix(0, timestamp.MaxNanoTime)))
+	if err != nil {
+		return errors.WithMessage(err, "failed to select segments")
+	}
+	defer func() {
+		for _, seg := range segments {
+			seg.DecRef()
+		}
+	}()
+	tables := make(map[int64]*tsTable)
+	for _, seg := range segments {
+		if tst, ok := seg.Table(shardID); ok {
+			tables[seg.GetTimeRange().Start.UnixNano()] = tst
+		}
+	}
+	key := shardMoveKey{group: move.Group, shardID: move.ShardId, target: move.Target}
+	var moved map[int64]map[uint64]struct{}
+	if move.CatchUp {
+		if moved, ok = s.movedParts.take(key); !ok {
+			return errors.Errorf("the parts moved to %s aren't found, the node might have restarted since the move", move.Target)
+		}
+	}
+	if len(tables) == 0 {
+		return nil
+	}
+	// The parts sent to the target aren't merged until they're removed.
+	for _, tst := range tables {
+		tst.mergeMu.Lock()
+	}
+	defer func() {
+		for _, tst := range tables {
+			tst.mergeMu.Unlock()
+		}
+	}()
+	if !move.CatchUp {
+		// The parts of a previous move which isn't caught up, like the one of an interrupted rebalancing, can be merged again.
+		if stale, found := s.movedParts.take(key); found {
+			for start, ids := range stale {
+				if tst, ok := tables[start]; ok {
+					tst.unpinParts(ids)
+				}
+			}
+		}
+	} else {
+		// The moved parts can be merged again if the catch-up fails, they're left on the source.
+		defer func() {
+			for start, ids := range moved {
+				if tst, ok := tables[start]; ok {
+					tst.unpinParts(ids)
+				}
+			}
+		}()
+	}
+	for _, tst := range tables {
+		if err = tst.waitForFlushed(ctx, s.option.flushTimeout+shardMoveFlushWait); err != nil {
+			return err
+		}
+	}
+
+	dir := filepath.Join(s.moveDir, fmt.Sprintf("%s-%d-%d", move.Group, move.ShardId, time.Now().UnixNano()))
+	s.lfs.MkdirIfNotExist(dir, storage.DirPerm)
+	defer s.lfs.MustRMAll(dir)
+	if err = db.TakeShardFileSnapshot(dir, shardID); err != nil {
+		return errors.WithMessage(err, "failed to take the file snapshot")
+	}
+
+	client := pub.NewWithTLS(s.clientTLS)
+	defer client.GracefulStop()
+	client.OnAddOrUpdate(schema.Metadata{
+		TypeMeta: schema.TypeMeta{
+			Kind: schema.KindNode,
+		},
+		Spec: target,
+	})
+	chunkedClient, err := client.NewChunkedSyncClient(move.Target, shardMoveChunkSize)
+	if err != nil {
+		return errors.WithMessagef(err, "failed to create the chunked sync client for %s", move.Target)
+	}
+	defer chunkedClient.Close()
+
+	v := &shardMoveVisitor{
+		ctx:     ctx,
+		s:       s,
+		client:  chunkedClient,
+		group:   move.Group,
+		shardID: shardID,
+		moved:   moved,
+		sent:    make(map[int64]map[uint64]struct{}),
+	}
+	intervalRule := storage.MustToIntervalRule(g.GetSchema().ResourceOpts.SegmentInterval)
+	if err = VisitStreamsInTimeRange(dir, timestamp.NewInclusiveTimeRange(time.Unix(0, 0), time.Unix(0, timestamp.MaxNanoTime)),
+		v, intervalRule); err != nil {
+		return err
+	}
+	if move.KeepSource {
+		return nil
+	}
+	if !move.CatchUp {
+		for start, ids := range v.sent {
+			if tst, ok := tables[start]; ok {
+				tst.pinParts(ids)
+			}
+		}
+		s.movedParts.store(key, v.sent)
+		return nil
+	}
+	for start, ids := range v.sent {
+		if moved[start] == nil {
+			moved[start] = make(map[uint64]struct{}, len(ids))
+		}
+		for id := range ids {
+			moved[start][id] = struct{}{}
+		}
+	}
+	for start, ids := range moved {
+		tst, ok := tables[start]
+		if !ok {
+			s.l.Warn().Str("group", move.Group).Uint32("shard", move.ShardId).Time("segment", time.Unix(0, start)).
+				Msg("the moved parts are left on the source since their segment isn't found")
+			continue
+		}
+		tst.removeParts(ids)
+	}
+	return nil
+}
+
+// pinParts keeps the parts from being merged until they're unpinned. The caller must hold mergeMu.
+func (tst *tsTable) pinParts(ids map[uint64]struct{}) {
+	if tst.pinned == nil {
+		tst.pinned = make(map[uint64]struct{}, len(ids))
+	}
+	for id := range ids {
+		tst.pinned[id] = struct{}{}
+	}
+}
+
+// unpinParts allows the parts to be merged again. The caller must hold mergeMu.
+func (tst *tsTable) unpinParts(ids map[uint64]struct{}) {
+	for id := range ids {
+		delete(tst.pinned, id)
+	}
+}
+
+// waitForFlushed waits until the memory parts in the current snapshot are flushed.
+func (tst *tsTable) waitForFlushed(ctx context.Context, timeout time.Duration) error {
+	memParts := make(map[uint64]struct{})
+	snp := tst.currentSnapshot()
+	if snp == nil {
+		return nil
+	}
+	for _, pw := range snp.parts {
+		if pw.mp != nil {
+			memParts[pw.ID()] = struct{}{}
+		}
+	}
+	snp.decRef()
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	deadline := time.Now().Add(timeout)
+	for len(memParts) > 0 {
+		if time.Now().After(deadline) {
+			return errors.Errorf("%d memory parts aren't flushed in %s", len(memParts), timeout)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tst.loopCloser.CloseNotify():
+			return errClosed
+		case <-ticker.C:
+		}
+		snp = tst.currentSnapshot()
+		if snp == nil {
+			return nil
+		}
+		inMemory := make(map[uint64]struct{}, len(memParts))
+		for _, pw := range snp.parts {
+			if _, ok := memParts[pw.ID()]; ok && pw.mp != nil {
+				inMemory[pw.ID()] = struct{}{}
+			}
+		}
+		snp.decRef()
+		// The memory parts are either flushed with the same IDs, or merged into a file part by the flusher.
+		memParts = inMemory
+	}
+	return nil
+}
+
+// removeParts removes the parts from the snapshot, and their files once they aren't referenced.
+func (tst *tsTable) removeParts(ids map[uint64]struct{}) {
+	ind := generateIntroduction()
+	defer releaseIntroduction(ind)
+	ind.removed = ids
+	ind.applied = make(chan struct{})
+	select {
+	case tst.introductions <- ind:
+	case <-tst.loopCloser.CloseNotify():
+		return
+	}
+	select {
+	case <-ind.applied:
+	case <-tst.loopCloser.CloseNotify():
+	}
+}
+
+// shardMoveVisitor sends the series index, the parts and the element index of a shard in a file snapshot.
+type shardMoveVisitor struct {
+	ctx    context.Context
+	s      *standalone
+	client queue.ChunkedSyncClient
+	// moved is the IDs of the parts sent before the catch-up by the start of their segments, which are skipped.
+	moved map[int64]map[uint64]struct{}
+	// sent is the IDs of the sent parts by the start of their segments.
+	sent    map[int64]map[uint64]struct{}
+	group   string
+	shardID common.ShardID
+}
+
+func (v *shardMoveVisitor) VisitSeries(segmentTR *timestamp.TimeRange, seriesIndexPath string, shardIDs []common.ShardID) error {
+	if !slices.Contains(shardIDs, v.shardID) {
+		return nil
+	}
+	return v.syncSegmentFiles(segmentTR, seriesIndexPath, data.TopicStreamSeriesSync.String())
+}
+
+func (v *shardMoveVisitor) VisitPart(segmentTR *timestamp.TimeRange, shardID common.ShardID, partPath string) error {
+	if shardID != v.shardID {
+		return nil
+	}
+	partData, err := ParsePartMetadata(v.s.lfs, partPath)
+	if err != nil {
+		return errors.WithMessagef(err, "failed to parse the metadata of part %s", partPath)
+	}
+	// The ID isn't in the metadata, it's the name of the part directory.
+	if partData.ID, err = strconv.ParseUint(filepath.Base(partPath), 16, 64); err != nil {
+		return errors.WithMessagef(err, "failed to parse the ID of part %s", partPath)
+	}
+	start := segmentTR.Start.UnixNano()
+	if _, ok := v.moved[start][partData.ID]; ok {
+		return nil
+	}
+	files, release := CreatePartFileReaderFromPath(partPath, v.s.lfs)
+	defer release()
+	partData.Group = v.group
+	partData.ShardID = uint32(v.shardID)
+	partData.Topic = data.TopicStreamPartSync.String()
+	partData.Files = files
+	if err = v.sync(partData); err != nil {
+		return err
+	}
+	if v.sent[start] == nil {
+		v.sent[start] = make(map[uint64]struct{})
+	}
+	v.sent[start][partData.ID] = struct{}{}
+	return nil
+}
+
+func (v *shardMoveVisitor) VisitElementIndex(segmentTR *timestamp.TimeRange, shardID common.ShardID, indexPath string) error {
+	if shardID != v.shardID {
+		return nil
+	}
+	return v.syncSegmentFiles(segmentTR, indexPath, data.TopicStreamElementIndexSync.String())
+}
+
+func (v *shardMoveVisitor) syncSegmentFiles(segmentTR *timestamp.TimeRange, dir, topic string) error {
+	var files []queue.FileInfo
+	for _, entry := range v.s.lfs.ReadDir(dir) {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".seg") {
+			continue
+		}
+		f, err := v.s.lfs.OpenFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return errors.WithMessagef(err, "failed to open %s", entry.Name())
+		}
+		defer f.Close()
+		files = append(files, queue.FileInfo{
+			Name:   entry.Name(),
+			Reader: f.SequentialRead(),
+		})
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	return v.sync(queue.StreamingPartData{
+		Group:        v.group,
+		ShardID:      uint32(v.shardID),
+		Topic:        topic,
+		Files:        files,
+		MinTimestamp: segmentTR.Start.UnixNano(),
+		MaxTimestamp: segmentTR.End.UnixNano(),
+	})
+}
+
+func (v *shardMoveVisitor) sync(partData queue.StreamingPartData) error {
+	result, err := v.client.SyncStreamingParts(v.ctx, []queue.StreamingPartData{partData})
+	if err != nil {
+		return errors.WithMessage(err, "failed to sync the streaming parts")
+	}
+	if !result.Success {
+		return errors.Errorf("failed to sync the streaming parts: %s", result.ErrorMessage)
+	}
+	return nil
+}
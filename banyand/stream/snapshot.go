@@ -60,9 +60,11 @@ const (
 )
 
 type snapshot struct {
-	parts   []*partWrapper
-	epoch   uint64
-	creator snapshotCreator
+	// tombstones are the union of the tombstones held by the parts.
+	tombstones *storage.Tombstones
+	parts      []*partWrapper
+	epoch      uint64
+	creator    snapshotCreator
 
 	ref int32
 }
@@ -80,6 +82,39 @@ func (s *snapshot) getParts(dst []*part, minTimestamp, maxTimestamp int64) ([]*p
 	return dst, count
 }
 
+func (s *snapshot) unionTombstones() *storage.Tombstones {
+	var sets []*storage.Tombstones
+	for _, pw := range s.parts {
+		if pw.p.tombstones.Len() > 0 {
+			sets = append(sets, pw.p.tombstones)
+		}
+	}
+	return storage.UnionTombstones(sets...)
+}
+
+// carriedTombstones returns the tombstones of the merged parts which still delete the rows of the other parts.
+// The rows of the merged parts are removed by the merger, so the other tombstones are dropped.
+func (s *snapshot) carriedTombstones(merged map[uint64]struct{}) *storage.Tombstones {
+	var sets []*storage.Tombstones
+	for _, pw := range s.parts {
+		if _, ok := merged[pw.ID()]; ok && pw.p.tombstones.Len() > 0 {
+			sets = append(sets, pw.p.tombstones)
+		}
+	}
+	return storage.UnionTombstones(sets...).Retain(func(t storage.Tombstone) bool {
+		for _, pw := range s.parts {
+			if _, ok := merged[pw.ID()]; ok {
+				continue
+			}
+			pm := pw.p.partMetadata
+			if pm.TotalCount > 0 && pm.ID <= t.PartID && pm.MinTimestamp <= t.Timestamp && t.Timestamp <= pm.MaxTimestamp {
+				return true
+			}
+		}
+		return false
+	})
+}
+
 func (s *snapshot) incRef() {
 	atomic.AddInt32(&s.ref, 1)
 }
@@ -136,6 +171,16 @@ func (s *snapshot) remove(nextEpoch uint64, merged map[uint64]struct{}) snapshot
 	return result
 }
 
+func (s *snapshot) containsAll(ids map[uint64]struct{}) bool {
+	n := 0
+	for i := range s.parts {
+		if _, ok := ids[s.parts[i].ID()]; ok {
+			n++
+		}
+	}
+	return n == len(ids)
+}
+
 func getDisjointParts(parts []*part, asc bool) [][]*part {
 	if len(parts) == 0 {
 		return nil
@@ -0,0 +1,69 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"context"
+
+	"github.com/apache/skywalking-banyandb/pkg/query/executor"
+	"github.com/apache/skywalking-banyandb/pkg/query/model"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+var _ executor.StatisticsProvider = (*stream)(nil)
+
+// Statistics returns the statistics of the segments in the time range.
+// The segments are referred until the statistics are released.
+func (s *stream) Statistics(_ context.Context, timeRange timestamp.TimeRange) (*model.Statistics, error) {
+	tsdb, err := s.getTSDB()
+	if err != nil {
+		return nil, err
+	}
+	segments, err := tsdb.SelectSegments(timeRange)
+	if err != nil {
+		return nil, err
+	}
+	release := func() {
+		for i := range segments {
+			segments[i].DecRef()
+		}
+	}
+	ss := make([]model.SegmentStatistics, 0, len(segments))
+	for _, segment := range segments {
+		var st model.SegmentStatistics
+		if st.Series, err = segment.IndexDB().Statistics().DocCount(); err != nil {
+			release()
+			return nil, err
+		}
+		tabs, _ := segment.Tables()
+		for _, tab := range tabs {
+			st.Indexes = append(st.Indexes, tab.Index().Statistics())
+			snp := tab.currentSnapshot()
+			if snp == nil {
+				continue
+			}
+			for _, pw := range snp.parts {
+				st.Rows += pw.p.partMetadata.TotalCount
+				st.Parts++
+			}
+			snp.decRef()
+		}
+		ss = append(ss, st)
+	}
+	return model.NewStatistics(ss, release), nil
+}
@@ -34,6 +34,7 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/run"
 	"github.com/apache/skywalking-banyandb/pkg/schema"
 	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+	"github.com/apache/skywalking-banyandb/pkg/wal"
 )
 
 const (
@@ -44,6 +45,8 @@ const (
 
 	defaultFlushTimeout = time.Second
 	defaultSyncInterval = 30 * time.Second
+
+	defaultWALSyncInterval = time.Second
 )
 
 type option struct {
@@ -51,9 +54,11 @@ type option struct {
 	protector                protector.Memory
 	tire2Client              queue.Client
 	seriesCacheMaxSize       run.Bytes
+	walOptions               wal.Options
 	flushTimeout             time.Duration
 	elementIndexFlushTimeout time.Duration
 	syncInterval             time.Duration
+	walEnabled               bool
 }
 
 // Query allow to retrieve elements in a series of streams.
@@ -36,6 +36,7 @@ import (
 	"github.com/apache/skywalking-banyandb/banyand/observability"
 	"github.com/apache/skywalking-banyandb/banyand/protector"
 	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/banyand/queue/pub"
 	"github.com/apache/skywalking-banyandb/pkg/bus"
 	"github.com/apache/skywalking-banyandb/pkg/fs"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
@@ -70,7 +71,12 @@ type standalone struct {
 	metadata              metadata.Repo
 	l                     *logger.Logger
 	schemaRepo            schemaRepo
+	clientTLS             *pub.ClientTLS
+	movedParts            movedParts
 	snapshotDir           string
+	moveDir               string
+	repairDir             string
+	nodeID                string
 	root                  string
 	dataPath              string
 	option                option
@@ -99,6 +105,10 @@ func (s *standalone) FlagSet() *run.FlagSet {
 	flagS.StringVar(&s.root, "stream-root-path", "/tmp", "the root path of stream")
 	flagS.StringVar(&s.dataPath, "stream-data-path", "", "the data directory path of stream. If not set, <stream-root-path>/stream/data will be used")
 	flagS.DurationVar(&s.option.flushTimeout, "stream-flush-timeout", defaultFlushTimeout, "the memory data timeout of stream")
+	flagS.BoolVar(&s.option.walEnabled, "stream-wal-enabled", false, "enable the write-ahead log of stream to recover the unflushed data after a crash")
+	flagS.Var(&s.option.walOptions.SyncPolicy, "stream-wal-sync-policy", "the sync policy of the stream write-ahead log: always, interval or none")
+	flagS.DurationVar(&s.option.walOptions.SyncInterval, "stream-wal-sync-interval", defaultWALSyncInterval,
+		"the sync interval of the stream write-ahead log if the sync policy is interval")
 	flagS.DurationVar(&s.option.elementIndexFlushTimeout, "element-index-flush-timeout", defaultFlushTimeout, "the elementIndex timeout of stream")
 	s.option.mergePolicy = newDefaultMergePolicy()
 	flagS.VarP(&s.option.mergePolicy.maxFanOutSize, "stream-max-fan-out-size", "", "the upper bound of a single file size after merge of stream")
@@ -142,6 +152,9 @@ func (s *standalone) PreRun(ctx context.Context) error {
 		return errors.New("node id is empty")
 	}
 	node := val.(common.Node)
+	s.nodeID = node.NodeID
+	s.moveDir = filepath.Join(path, shardMoveDir)
+	s.repairDir = filepath.Join(path, replicaRepairDir)
 	if s.dataPath == "" {
 		s.dataPath = filepath.Join(path, storage.DataDir)
 	}
@@ -160,12 +173,19 @@ func (s *standalone) PreRun(ctx context.Context) error {
 	if err := s.pipeline.Subscribe(data.TopicDeleteExpiredStreamSegments, &deleteStreamSegmentsListener{s: s}); err != nil {
 		return err
 	}
+	if err := s.pipeline.Subscribe(data.TopicStreamDelete, &deleteElementsListener{s: s}); err != nil {
+		return err
+	}
+	if err := s.pipeline.Subscribe(data.TopicStreamShardMove, &moveShardsListener{s: s}); err != nil {
+		return err
+	}
 	writeListener := setUpWriteCallback(s.l, &s.schemaRepo, s.maxDiskUsagePercent)
 	err := s.pipeline.Subscribe(data.TopicStreamWrite, writeListener)
 	if err != nil {
 		return err
 	}
 	s.pipeline.RegisterChunkedSyncHandler(data.TopicStreamPartSync, setUpChunkedSyncCallback(s.l, &s.schemaRepo))
+	s.pipeline.RegisterChunkedSyncHandler(data.TopicStreamPartRepair, setUpRepairPartCallback(s.l, &s.schemaRepo))
 	// Register chunked sync handler for stream series index
 	s.pipeline.RegisterChunkedSyncHandler(data.TopicStreamSeriesSync, setUpSyncSeriesCallback(s.l, &s.schemaRepo))
 	// Register chunked sync handler for stream element index
@@ -246,3 +266,26 @@ func (d *deleteStreamSegmentsListener) Rev(_ context.Context, message bus.Messag
 	deleted := db.DeleteExpiredSegments(timestamp.NewSectionTimeRange(req.TimeRange.Begin.AsTime(), req.TimeRange.End.AsTime()))
 	return bus.NewMessage(bus.MessageID(time.Now().UnixNano()), deleted)
 }
+
+type deleteElementsListener struct {
+	*bus.UnImplementedHealthyListener
+	s *standalone
+}
+
+func (d *deleteElementsListener) Rev(_ context.Context, message bus.Message) bus.Message {
+	now := time.Now().UnixNano()
+	req, ok := message.Data().(*streamv1.InternalDeleteRequest)
+	if !ok || req == nil {
+		return bus.NewMessage(bus.MessageID(now), common.NewError("invalid delete request"))
+	}
+	db, err := d.s.schemaRepo.loadTSDB(req.Group)
+	if err != nil {
+		d.s.l.Error().Err(err).Str("group", req.Group).Msg("failed to load tsdb")
+		return bus.NewMessage(bus.MessageID(now), common.NewError("failed to load tsdb of group %s: %v", req.Group, err))
+	}
+	deleted, err := deleteElements(db, req.Tombstones)
+	if err != nil {
+		return bus.NewMessage(bus.MessageID(now), common.NewError("failed to delete elements of %s/%s: %v", req.Group, req.Name, err))
+	}
+	return bus.NewMessage(bus.MessageID(now), &streamv1.DeleteResponse{Deleted: deleted})
+}
@@ -152,7 +152,7 @@ func (tst *tsTable) syncSnapshot(curSnapshot *snapshot, syncCh chan *syncIntrodu
 	// Get all parts from the current snapshot
 	var partsToSync []*part
 	for _, pw := range curSnapshot.parts {
-		if pw.mp == nil && pw.p.partMetadata.TotalCount > 0 {
+		if pw.mp == nil && (pw.p.partMetadata.TotalCount > 0 || pw.p.tombstones.Len() > 0) {
 			partsToSync = append(partsToSync, pw.p)
 		}
 	}
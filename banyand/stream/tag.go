@@ -78,7 +78,7 @@ func (t *tag) mustWriteTo(tm *tagMetadata, tagWriter *writer, tagFilterWriter *w
 		bb := bigValuePool.Generate()
 		defer bigValuePool.Release(bb)
 		bb.Buf = encodeBloomFilter(bb.Buf[:0], t.filter)
-		if tm.valueType == pbv1.ValueTypeInt64 {
+		if tm.valueType == pbv1.ValueTypeInt64 || tm.valueType == pbv1.ValueTypeFloat64 {
 			tm.min = t.min
 			tm.max = t.max
 		}
@@ -20,8 +20,10 @@ package stream
 import (
 	"bytes"
 	"fmt"
+	"strconv"
 
 	pkgbytes "github.com/apache/skywalking-banyandb/pkg/bytes"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
 	"github.com/apache/skywalking-banyandb/pkg/encoding"
 	"github.com/apache/skywalking-banyandb/pkg/filter"
 	"github.com/apache/skywalking-banyandb/pkg/fs"
@@ -68,13 +70,15 @@ func releaseBloomFilter(bf *filter.BloomFilter) {
 var bloomFilterPool = pool.Register[*filter.BloomFilter]("stream-bloomFilter")
 
 type tagFilter struct {
-	filter *filter.BloomFilter
-	min    []byte
-	max    []byte
+	filter    *filter.BloomFilter
+	min       []byte
+	max       []byte
+	valueType pbv1.ValueType
 }
 
 func (tf *tagFilter) reset() {
 	tf.filter = nil
+	tf.valueType = pbv1.ValueTypeUnknown
 	tf.min = tf.min[:0]
 	tf.max = tf.max[:0]
 }
@@ -122,7 +126,8 @@ func (tff tagFamilyFilter) unmarshal(tagFamilyMetadataBlock *dataBlock, metaRead
 		bf = decodeBloomFilter(bb.Buf, bf)
 		tf := generateTagFilter()
 		tf.filter = bf
-		if tm.valueType == pbv1.ValueTypeInt64 {
+		tf.valueType = tm.valueType
+		if tm.valueType == pbv1.ValueTypeInt64 || tm.valueType == pbv1.ValueTypeFloat64 {
 			tf.min = tm.min
 			tf.max = tm.max
 		}
@@ -167,6 +172,13 @@ func (tfs *tagFamilyFilters) unmarshal(tagFamilies map[string]*dataBlock, metaRe
 func (tfs *tagFamilyFilters) Eq(tagName string, tagValue string) bool {
 	for _, tff := range tfs.tagFamilyFilters {
 		if tf, ok := (*tff)[tagName]; ok {
+			if tf.valueType == pbv1.ValueTypeFloat64 {
+				f, err := strconv.ParseFloat(tagValue, 64)
+				if err != nil {
+					return true
+				}
+				return tf.filter.MightContain(convert.Float64ToBytes(f))
+			}
 			return tf.filter.MightContain([]byte(tagValue))
 		}
 	}
@@ -176,6 +188,13 @@ func (tfs *tagFamilyFilters) Eq(tagName string, tagValue string) bool {
 func (tfs *tagFamilyFilters) Range(tagName string, rangeOpts index.RangeOpts) (bool, error) {
 	for _, tff := range tfs.tagFamilyFilters {
 		if tf, ok := (*tff)[tagName]; ok {
+			if tf.valueType == pbv1.ValueTypeFloat64 {
+				mightContain, err := tf.floatRange(rangeOpts)
+				if err != nil || !mightContain {
+					return false, err
+				}
+				continue
+			}
 			if rangeOpts.Lower != nil {
 				lower, ok := rangeOpts.Lower.(*index.FloatTermValue)
 				if !ok {
@@ -220,3 +239,46 @@ func releaseTagFamilyFilters(tfs *tagFamilyFilters) {
 }
 
 var tagFamilyFiltersPool = pool.Register[*tagFamilyFilters]("stream-tagFamilyFilters")
+
+// floatRange returns false if the float values between min and max are out of the range.
+func (tf *tagFilter) floatRange(rangeOpts index.RangeOpts) (bool, error) {
+	if len(tf.min) == 0 || len(tf.max) == 0 {
+		return true, nil
+	}
+	if rangeOpts.Lower != nil {
+		lower, ok := rangeOpts.Lower.(*index.FloatTermValue)
+		if !ok {
+			return false, fmt.Errorf("lower is not a float value: %v", rangeOpts.Lower)
+		}
+		maxValue := convert.BytesToFloat64(tf.max)
+		if maxValue < lower.Value || !rangeOpts.IncludesLower && maxValue == lower.Value {
+			return false, nil
+		}
+	}
+	if rangeOpts.Upper != nil {
+		upper, ok := rangeOpts.Upper.(*index.FloatTermValue)
+		if !ok {
+			return false, fmt.Errorf("upper is not a float value: %v", rangeOpts.Upper)
+		}
+		minValue := convert.BytesToFloat64(tf.min)
+		if minValue > upper.Value || !rangeOpts.IncludesUpper && minValue == upper.Value {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// compareTagValue compares two encoded tag values of the same value type.
+func compareTagValue(valueType pbv1.ValueType, a, b []byte) int {
+	if valueType == pbv1.ValueTypeFloat64 {
+		fa, fb := convert.BytesToFloat64(a), convert.BytesToFloat64(b)
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	}
+	return bytes.Compare(a, b)
+}
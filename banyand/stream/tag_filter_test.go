@@ -21,12 +21,15 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/apache/skywalking-banyandb/pkg/convert"
 	"github.com/apache/skywalking-banyandb/pkg/filter"
 	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/index"
 	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
 )
 
@@ -63,6 +66,44 @@ func TestEncodeAndDecodeBloomFilter(t *testing.T) {
 	}
 }
 
+func TestTagFamilyFiltersFloat(t *testing.T) {
+	bf := filter.NewBloomFilter(3)
+	for _, v := range []float64{-1.5, 2.25, 10} {
+		bf.Add(convert.Float64ToBytes(v))
+	}
+	tff := tagFamilyFilter{"latency": &tagFilter{
+		filter:    bf,
+		valueType: pbv1.ValueTypeFloat64,
+		min:       convert.Float64ToBytes(-1.5),
+		max:       convert.Float64ToBytes(10),
+	}}
+	tfs := &tagFamilyFilters{tagFamilyFilters: []*tagFamilyFilter{&tff}}
+
+	assert.True(t, tfs.Eq("latency", "2.25"))
+	assert.False(t, tfs.Eq("latency", "3.5"))
+
+	tests := []struct {
+		name      string
+		rangeOpts index.RangeOpts
+		want      bool
+	}{
+		{name: "overlap", rangeOpts: index.NewFloatRangeOpts(5, 20, true, true), want: true},
+		{name: "below min", rangeOpts: index.NewFloatRangeOpts(-10, -2, true, true), want: false},
+		{name: "above max", rangeOpts: index.NewFloatRangeOpts(10.5, math.MaxFloat64, true, true), want: false},
+		{name: "include max", rangeOpts: index.NewFloatRangeOpts(10, math.MaxFloat64, true, true), want: true},
+		{name: "exclude max", rangeOpts: index.NewFloatRangeOpts(10, math.MaxFloat64, false, true), want: false},
+		{name: "exclude min", rangeOpts: index.NewFloatRangeOpts(-math.MaxFloat64, -1.5, true, false), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tfs.Range("latency", tt.rangeOpts)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+	assert.Equal(t, -1, compareTagValue(pbv1.ValueTypeFloat64, convert.Float64ToBytes(-2), convert.Float64ToBytes(1)))
+}
+
 type mockReader struct {
 	data []byte
 }
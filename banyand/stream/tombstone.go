@@ -0,0 +1,87 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"sync/atomic"
+	"time"
+
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+func (tst *tsTable) nextPartID() uint64 {
+	return atomic.AddUint64(&tst.curPartID, 1)
+}
+
+// deleteElements marks the elements in the existing parts as deleted.
+// The tombstones are held by a mem part, which is persisted as the written elements are,
+// and visible to the queries before it returns.
+func (tst *tsTable) deleteElements(tombstones []storage.Tombstone) {
+	if len(tombstones) == 0 {
+		return
+	}
+	mp := generateMemPart()
+	mp.mustInitFromTombstones(tombstones)
+	tst.mustAddMemPart(mp)
+}
+
+// mustInitFromTombstones initializes the mem part which only holds the tombstones.
+func (mp *memPart) mustInitFromTombstones(tombstones []storage.Tombstone) {
+	mp.reset()
+	mp.tombstones = mp.tombstones.Add(tombstones)
+	mp.partMetadata.MinTimestamp, mp.partMetadata.MaxTimestamp = mp.tombstones.TimeRange()
+}
+
+// deleteElements applies the tombstones to the tables whose segments cover their timestamps.
+// It returns the number of the applied tombstones.
+func deleteElements(db storage.TSDB[*tsTable, option], tombstones []*streamv1.ElementTombstone) (int64, error) {
+	if len(tombstones) == 0 {
+		return 0, nil
+	}
+	minTS, maxTS := tombstones[0].Timestamp, tombstones[0].Timestamp
+	for _, t := range tombstones {
+		minTS = min(minTS, t.Timestamp)
+		maxTS = max(maxTS, t.Timestamp)
+	}
+	segments, err := db.SelectSegments(timestamp.NewInclusiveTimeRange(time.Unix(0, minTS), time.Unix(0, maxTS)))
+	if err != nil {
+		return 0, err
+	}
+	var applied int64
+	for _, s := range segments {
+		tr := s.GetTimeRange()
+		var items []storage.Tombstone
+		for _, t := range tombstones {
+			if tr.Contains(t.Timestamp) {
+				items = append(items, storage.Tombstone{ID: t.ElementId, Timestamp: t.Timestamp})
+			}
+		}
+		if len(items) > 0 {
+			// The shard of an element is unknown, every table of the segment keeps the tombstones.
+			tables, _ := s.Tables()
+			for _, tst := range tables {
+				tst.deleteElements(items)
+			}
+			applied += int64(len(items))
+		}
+		s.DecRef()
+	}
+	return applied, nil
+}
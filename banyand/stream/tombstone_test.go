@@ -0,0 +1,133 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/banyand/protector"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/test"
+)
+
+func Test_mergeParts_tombstones(t *testing.T) {
+	tmpPath, defFn := test.Space(require.New(t))
+	defer defFn()
+	var pp []*partWrapper
+	defer func() {
+		for _, pw := range pp {
+			pw.decRef()
+		}
+	}()
+	for i, es := range []*elements{esTS1, esTS2} {
+		mp := generateMemPart()
+		mp.mustInitFromElements(es)
+		p := openMemPart(mp)
+		p.partMetadata.ID = uint64(i + 1)
+		pp = append(pp, newPartWrapper(mp, p))
+	}
+	// The tombstone of element 12 is added before its part, so the element is kept.
+	tombstones := (*storage.Tombstones)(nil).Add([]storage.Tombstone{
+		{Timestamp: 1, ID: 21, PartID: 1},
+		{Timestamp: 2, ID: 12, PartID: 1},
+		{Timestamp: 1, ID: 31, PartID: 1},
+		{Timestamp: 2, ID: 32, PartID: 2},
+	})
+	carried := tombstones.Retain(func(t storage.Tombstone) bool { return t.ID == 32 })
+
+	closeCh := make(chan struct{})
+	defer close(closeCh)
+	tst := &tsTable{pm: protector.Nop{}}
+	p, err := tst.mergeParts(fs.NewLocalFileSystem(), closeCh, pp, 3, tombstones, carried, tmpPath)
+	require.NoError(t, err)
+	defer p.decRef()
+	assert.Equal(t, carried, p.p.tombstones, "the merged part carries the tombstones")
+
+	pmi := &partMergeIter{}
+	pmi.mustInitFromPart(p.p)
+	reader := &blockReader{}
+	reader.init([]*partMergeIter{pmi})
+	got := make(map[common.SeriesID]uint64)
+	for reader.nextBlockMetadata() {
+		got[reader.block.bm.seriesID] += reader.block.bm.count
+	}
+	require.NoError(t, reader.error())
+	assert.Equal(t, map[common.SeriesID]uint64{1: 2, 2: 1}, got)
+}
+
+func Test_snapshot_carriedTombstones(t *testing.T) {
+	newPart := func(id uint64, minTimestamp, maxTimestamp int64, tombstones *storage.Tombstones) *partWrapper {
+		p := &part{tombstones: tombstones}
+		p.partMetadata.ID = id
+		p.partMetadata.MinTimestamp = minTimestamp
+		p.partMetadata.MaxTimestamp = maxTimestamp
+		if tombstones == nil {
+			p.partMetadata.TotalCount = 1
+		}
+		return &partWrapper{p: p}
+	}
+	tombstones := (*storage.Tombstones)(nil).Add([]storage.Tombstone{
+		{Timestamp: 10, ID: 1, PartID: 4},
+		{Timestamp: 30, ID: 2, PartID: 4},
+	})
+	// The part 7 is written after the tombstones, only the part 4 holds the deleted elements.
+	snp := &snapshot{parts: []*partWrapper{newPart(4, 1, 20, nil), newPart(5, 10, 30, tombstones), newPart(7, 1, 40, nil)}}
+	snp.tombstones = snp.unionTombstones()
+	assert.Same(t, tombstones, snp.tombstones)
+
+	carried := snp.carriedTombstones(map[uint64]struct{}{5: {}, 7: {}})
+	assert.Equal(t, 1, carried.Len())
+	assert.True(t, carried.IsDeleted(4, 1, 10))
+
+	assert.Equal(t, 0, snp.carriedTombstones(map[uint64]struct{}{4: {}, 5: {}}).Len())
+}
+
+func Test_memPart_tombstones(t *testing.T) {
+	tmpPath, defFn := test.Space(require.New(t))
+	defer defFn()
+	mp := generateMemPart()
+	defer releaseMemPart(mp)
+	mp.mustInitFromTombstones([]storage.Tombstone{{Timestamp: 30, ID: 2}, {Timestamp: 10, ID: 1}})
+	assert.Equal(t, int64(10), mp.partMetadata.MinTimestamp)
+	assert.Equal(t, int64(30), mp.partMetadata.MaxTimestamp)
+	mp.tombstones = mp.tombstones.Rebase(4)
+
+	unmarshaled := generateMemPart()
+	defer releaseMemPart(unmarshaled)
+	require.NoError(t, unmarshaled.unmarshal(mp.marshal(nil)))
+	assert.Equal(t, mp.tombstones, unmarshaled.tombstones)
+
+	fileSystem := fs.NewLocalFileSystem()
+	mp.mustFlush(fileSystem, partPath(tmpPath, 5))
+	p := mustOpenFilePart(5, tmpPath, fileSystem)
+	defer p.close()
+	assert.Equal(t, mp.tombstones, p.tombstones)
+	assert.Empty(t, p.primaryBlockMetadata)
+	files, release := CreatePartFileReaderFromPath(partPath(tmpPath, 5), fileSystem)
+	defer release()
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name)
+	}
+	assert.Contains(t, names, streamTombstonesName)
+}
@@ -51,9 +51,13 @@ func (bc *blockCursor) String() string {
 }
 
 func startBlockScanSpan(ctx context.Context, sids int, parts []*part, qr *idxResult) func() {
+	stats := query.GetOperatorStats(ctx)
+	stats.AddParts(len(parts))
 	tracer := query.GetTracer(ctx)
 	if tracer == nil {
-		return func() {}
+		return func() {
+			stats.AddBlocks(len(qr.data))
+		}
 	}
 
 	span, _ := tracer.StartSpan(ctx, "scan-blocks")
@@ -69,6 +73,7 @@ func startBlockScanSpan(ctx context.Context, sids int, parts []*part, qr *idxRes
 	}
 
 	return func() {
+		stats.AddBlocks(len(qr.data))
 		span.Tag("block_header", blockHeader)
 		for i := range qr.data {
 			span.Tag(fmt.Sprintf("block_%d", i), qr.data[i].String())
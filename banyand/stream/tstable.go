@@ -41,6 +41,7 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/pool"
 	"github.com/apache/skywalking-banyandb/pkg/run"
 	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+	"github.com/apache/skywalking-banyandb/pkg/wal"
 	"github.com/apache/skywalking-banyandb/pkg/watcher"
 )
 
@@ -58,6 +59,10 @@ type tsTable struct {
 	getNodes      func() []string
 	l             *logger.Logger
 	introductions chan *introduction
+	wal           *wal.WAL
+	walPending    map[uint64]uint64
+	pinned        map[uint64]struct{}
+	walMark       wal.Watermark
 	p             common.Position
 	group         string
 	root          string
@@ -65,11 +70,19 @@ type tsTable struct {
 	option        option
 	curPartID     uint64
 	sync.RWMutex
+	walMu sync.Mutex
+	// tombstoneMu keeps the merger from allocating a part ID while the parts with smaller IDs are being introduced,
+	// so that the merged parts observe all tombstones which delete their rows.
+	tombstoneMu sync.RWMutex
+	// mergeMu keeps the file parts from being merged while they're moved to another node.
+	// It guards pinned, the parts moved to another node which aren't merged until they're removed.
+	mergeMu sync.Mutex
 	shardID common.ShardID
 }
 
 func (tst *tsTable) loadSnapshot(epoch uint64, loadedParts []uint64) {
-	parts := tst.mustReadSnapshot(epoch)
+	parts, walMark := tst.mustReadSnapshot(epoch)
+	tst.walMark = walMark
 	snp := snapshot{
 		epoch: epoch,
 	}
@@ -100,6 +113,7 @@ func (tst *tsTable) loadSnapshot(epoch uint64, loadedParts []uint64) {
 			tst.curPartID = id
 		}
 	}
+	snp.tombstones = snp.unionTombstones()
 	tst.gc.registerSnapshot(&snp)
 	tst.gc.clean()
 	if len(snp.parts) < 1 {
@@ -145,8 +159,22 @@ func parseEpoch(epochStr string) (uint64, error) {
 	return p, nil
 }
 
-func (tst *tsTable) mustWriteSnapshot(snapshot uint64, partNames []string) {
-	data, err := json.Marshal(partNames)
+// snapshotFile is the content of a snapshot file if the write-ahead log is enabled.
+// The watermark is persisted along with the part names, so that the records of the persisted mem parts
+// are skipped by the replay even if the write-ahead log isn't truncated before a crash.
+type snapshotFile struct {
+	WAL   *wal.Watermark `json:"wal"`
+	Parts []string       `json:"parts"`
+}
+
+func (tst *tsTable) mustWriteSnapshot(snapshot uint64, partNames []string, walMark *wal.Watermark) {
+	var data []byte
+	var err error
+	if walMark == nil {
+		data, err = json.Marshal(partNames)
+	} else {
+		data, err = json.Marshal(snapshotFile{Parts: partNames, WAL: walMark})
+	}
 	if err != nil {
 		logger.Panicf("cannot marshal partNames to JSON: %s", err)
 	}
@@ -164,16 +192,22 @@ func (tst *tsTable) mustWriteSnapshot(snapshot uint64, partNames []string) {
 	}
 }
 
-func (tst *tsTable) mustReadSnapshot(snapshot uint64) []uint64 {
+func (tst *tsTable) mustReadSnapshot(snapshot uint64) ([]uint64, wal.Watermark) {
 	snapshotPath := filepath.Join(tst.root, snapshotName(snapshot))
 	data, err := tst.fileSystem.Read(snapshotPath)
 	if err != nil {
 		logger.Panicf("cannot read %s: %s", snapshotPath, err)
 	}
-	var partNames []string
-	if err := json.Unmarshal(data, &partNames); err != nil {
+	var sf snapshotFile
+	if len(data) > 0 && data[0] == '{' {
+		err = json.Unmarshal(data, &sf)
+	} else {
+		err = json.Unmarshal(data, &sf.Parts)
+	}
+	if err != nil {
 		logger.Panicf("cannot parse %s: %s", snapshotPath, err)
 	}
+	partNames := sf.Parts
 	var result []uint64
 	for i := range partNames {
 		e, err := parseEpoch(partNames[i])
@@ -182,7 +216,11 @@ func (tst *tsTable) mustReadSnapshot(snapshot uint64) []uint64 {
 		}
 		result = append(result, e)
 	}
-	return result
+	var walMark wal.Watermark
+	if sf.WAL != nil {
+		walMark = *sf.WAL
+	}
+	return result, walMark
 }
 
 // initTSTable initializes a tsTable and loads parts/snapshots, but does not start any background loops.
@@ -230,6 +268,9 @@ func initTSTable(fileSystem fs.FileSystem, rootPath string, p common.Position,
 			if ee[i].Name() == inverted.ExternalSegmentTempDirName {
 				continue
 			}
+			if ee[i].Name() == walDirName {
+				continue
+			}
 			p, err := parseEpoch(ee[i].Name())
 			if err != nil {
 				l.Info().Err(err).Msg("cannot parse part file name. skip and delete it")
@@ -260,13 +301,18 @@ func initTSTable(fileSystem fs.FileSystem, rootPath string, p common.Position,
 		l.Info().Str("path", filepath.Join(rootPath, needToDelete[i])).Msg("delete invalid directory or file")
 		fileSystem.MustRMAll(filepath.Join(rootPath, needToDelete[i]))
 	}
-	if len(loadedParts) == 0 || len(loadedSnapshots) == 0 {
+	if len(loadedSnapshots) == 0 {
 		return &tst, uint64(time.Now().UnixNano()), nil
 	}
 	sort.Slice(loadedSnapshots, func(i, j int) bool {
 		return loadedSnapshots[i] > loadedSnapshots[j]
 	})
 	epoch := loadedSnapshots[0]
+	if len(loadedParts) == 0 {
+		// The watermark of the write-ahead log is kept even if all the parts are removed.
+		_, tst.walMark = tst.mustReadSnapshot(epoch)
+		return &tst, uint64(time.Now().UnixNano()), nil
+	}
 	tst.loadSnapshot(epoch, loadedParts)
 	return &tst, epoch, nil
 }
@@ -279,6 +325,9 @@ func newTSTable(fileSystem fs.FileSystem, rootPath string, p common.Position,
 		return nil, err
 	}
 	t.startLoop(epoch)
+	if option.walEnabled {
+		t.mustOpenWAL()
+	}
 	return t, nil
 }
 
@@ -294,6 +343,7 @@ func (tst *tsTable) Close() error {
 		tst.loopCloser.Done()
 		tst.loopCloser.CloseThenWait()
 	}
+	tst.closeWAL()
 	tst.Lock()
 	defer tst.Unlock()
 	tst.deleteMetrics()
@@ -312,13 +362,30 @@ func (tst *tsTable) Close() error {
 }
 
 func (tst *tsTable) mustAddMemPart(mp *memPart) {
+	if mp.tombstones.Len() > 0 {
+		tst.tombstoneMu.Lock()
+		defer tst.tombstoneMu.Unlock()
+	} else {
+		tst.tombstoneMu.RLock()
+		defer tst.tombstoneMu.RUnlock()
+	}
+	partID := atomic.AddUint64(&tst.curPartID, 1)
+	// The tombstones delete the rows in the parts added before the mem part, including the ones synced from another node.
+	mp.tombstones = mp.tombstones.Rebase(partID - 1)
+	if !tst.mustWriteWAL(partID, mp) {
+		return
+	}
+	tst.introduceNewMemPart(mp, partID)
+}
+
+func (tst *tsTable) introduceNewMemPart(mp *memPart, partID uint64) {
 	p := openMemPart(mp)
 
 	ind := generateIntroduction()
 	defer releaseIntroduction(ind)
 	ind.applied = make(chan struct{})
 	ind.memPart = newPartWrapper(mp, p)
-	ind.memPart.p.partMetadata.ID = atomic.AddUint64(&tst.curPartID, 1)
+	ind.memPart.p.partMetadata.ID = partID
 	startTime := time.Now()
 	totalCount := mp.partMetadata.TotalCount
 	select {
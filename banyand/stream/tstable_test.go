@@ -20,6 +20,8 @@ package stream
 import (
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -29,11 +31,15 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/banyand/protector"
 	"github.com/apache/skywalking-banyandb/pkg/convert"
 	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
 	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
 	"github.com/apache/skywalking-banyandb/pkg/run"
 	"github.com/apache/skywalking-banyandb/pkg/test"
+	"github.com/apache/skywalking-banyandb/pkg/test/flags"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
 	"github.com/apache/skywalking-banyandb/pkg/watcher"
 )
 
@@ -122,6 +128,101 @@ func Test_tsTable_mustAddElements(t *testing.T) {
 	}
 }
 
+func Test_tsTable_walReplay(t *testing.T) {
+	tmpPath, defFn := test.Space(require.New(t))
+	defer defFn()
+	fileSystem := fs.NewLocalFileSystem()
+	opt := option{flushTimeout: 0, mergePolicy: newDefaultMergePolicyForTesting(), protector: protector.Nop{}, walEnabled: true}
+	// openWithoutFlusher opens a tsTable which never flushes its mem parts,
+	// they are dropped when the table is closed.
+	openWithoutFlusher := func() *tsTable {
+		tst, epoch, err := initTSTable(fileSystem, tmpPath, common.Position{}, logger.GetLogger("test"), opt, nil, false)
+		require.NoError(t, err)
+		tst.loopCloser = run.NewCloser(2)
+		tst.introductions = make(chan *introduction)
+		go tst.introducerLoop(make(chan *flusherIntroduction), make(chan *mergerIntroduction), make(watcher.Channel, 1), epoch+1)
+		tst.mustOpenWAL()
+		return tst
+	}
+	countElements := func(tst *tsTable) (total uint64, memParts int) {
+		s := tst.currentSnapshot()
+		if s == nil {
+			return 0, 0
+		}
+		defer s.decRef()
+		for _, pw := range s.parts {
+			total += pw.p.partMetadata.TotalCount
+			if pw.mp != nil {
+				memParts++
+			}
+		}
+		return total, memParts
+	}
+
+	tst := openWithoutFlusher()
+	tst.mustAddElements(esTS1)
+	tst.mustAddElements(esTS2)
+	want, memParts := countElements(tst)
+	require.Positive(t, want)
+	require.Equal(t, 2, memParts)
+	require.NoError(t, tst.Close())
+
+	tst = openWithoutFlusher()
+	total, memParts := countElements(tst)
+	assert.Equal(t, want, total)
+	assert.Equal(t, 2, memParts)
+	require.NoError(t, tst.Close())
+
+	// Keep the records of the mem parts to simulate a crash after they're persisted but before the WAL is truncated.
+	walDir := filepath.Join(tmpPath, walDirName)
+	walBackup := t.TempDir()
+	require.NoError(t, os.CopyFS(walBackup, os.DirFS(walDir)))
+
+	// The replayed mem parts are flushed, then the WAL is truncated.
+	tst, err := newTSTable(fileSystem, tmpPath, common.Position{}, logger.GetLogger("test"), timestamp.TimeRange{}, opt, nil)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		total, memParts = countElements(tst)
+		return total == want && memParts == 0
+	}, flags.EventuallyTimeout, 100*time.Millisecond)
+	require.NoError(t, tst.Close())
+
+	tst = openWithoutFlusher()
+	total, memParts = countElements(tst)
+	assert.Equal(t, want, total)
+	assert.Zero(t, memParts)
+	require.NoError(t, tst.Close())
+
+	// The mem parts are merged into a part with another ID, the watermark of the snapshot skips their records.
+	require.NoError(t, os.RemoveAll(walDir))
+	require.NoError(t, os.CopyFS(walDir, os.DirFS(walBackup)))
+	tst = openWithoutFlusher()
+	defer tst.Close()
+	total, memParts = countElements(tst)
+	assert.Equal(t, want, total)
+	assert.Zero(t, memParts)
+}
+
+func Test_memPart_marshal(t *testing.T) {
+	mp := generateMemPart()
+	defer releaseMemPart(mp)
+	mp.mustInitFromElements(esTS1)
+	got := generateMemPart()
+	defer releaseMemPart(got)
+	require.NoError(t, got.unmarshal(mp.marshal(nil)))
+	assert.Equal(t, mp.partMetadata, got.partMetadata)
+	assert.Equal(t, mp.meta.Buf, got.meta.Buf)
+	assert.Equal(t, mp.primary.Buf, got.primary.Buf)
+	assert.Equal(t, mp.timestamps.Buf, got.timestamps.Buf)
+	require.Len(t, got.tagFamilies, len(mp.tagFamilies))
+	for name, tf := range mp.tagFamilies {
+		assert.Equal(t, tf.Buf, got.tagFamilies[name].Buf)
+		assert.Equal(t, mp.tagFamilyMetadata[name].Buf, got.tagFamilyMetadata[name].Buf)
+		assert.Equal(t, mp.tagFamilyFilter[name].Buf, got.tagFamilyFilter[name].Buf)
+	}
+	assert.Error(t, got.unmarshal(mp.marshal(nil)[:10]))
+}
+
 func Test_tstIter(t *testing.T) {
 	type testCtx struct {
 		wantErr      error
@@ -0,0 +1,252 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
+	"github.com/apache/skywalking-banyandb/pkg/bytes"
+	"github.com/apache/skywalking-banyandb/pkg/encoding"
+	"github.com/apache/skywalking-banyandb/pkg/wal"
+)
+
+const walDirName = "wal"
+
+// mustOpenWAL opens the write-ahead log of the tsTable and introduces the mem parts
+// which were not persisted before the last shutdown, according to the watermark of the loaded snapshot.
+// The mem parts keep their IDs, so that the tombstones they hold still delete the rows of the parts added before them.
+func (tst *tsTable) mustOpenWAL() {
+	w, err := wal.Open(filepath.Join(tst.root, walDirName), tst.option.walOptions)
+	if err != nil {
+		tst.l.Panic().Err(err).Str("root", tst.root).Msg("cannot open the write-ahead log")
+	}
+	tst.wal = w
+	tst.walPending = make(map[uint64]uint64)
+	type record struct {
+		data   []byte
+		lsn    uint64
+		partID uint64
+	}
+	// Collect the records first, the introducer might truncate the WAL while introducing them.
+	var records []record
+	var maxPartID uint64
+	if err = w.Replay(func(lsn uint64, data []byte) error {
+		data, partID := encoding.BytesToVarUint64(data)
+		records = append(records, record{lsn: lsn, partID: partID, data: append([]byte(nil), data...)})
+		maxPartID = max(maxPartID, partID)
+		return nil
+	}); err != nil {
+		tst.l.Panic().Err(err).Str("root", tst.root).Msg("cannot replay the write-ahead log")
+	}
+	tst.walMu.Lock()
+	mark := tst.walMark
+	tst.walMu.Unlock()
+	// The merger doesn't allocate part IDs before the first introduction.
+	if atomic.LoadUint64(&tst.curPartID) < maxPartID {
+		atomic.StoreUint64(&tst.curPartID, maxPartID)
+	}
+	var replayed int
+	for i := range records {
+		if mark.Covers(records[i].lsn) {
+			continue
+		}
+		partID := records[i].partID
+		mp := generateMemPart()
+		if err = mp.unmarshal(records[i].data); err != nil {
+			tst.l.Panic().Err(err).Uint64("lsn", records[i].lsn).Msg("cannot unmarshal the mem part in the write-ahead log")
+		}
+		tst.walMu.Lock()
+		tst.walPending[partID] = records[i].lsn
+		tst.walMu.Unlock()
+		tst.introduceNewMemPart(mp, partID)
+		replayed++
+	}
+	if replayed > 0 {
+		tst.l.Info().Int("count", replayed).Str("root", tst.root).Msg("replayed the write-ahead log")
+	}
+}
+
+// mustWriteWAL appends the mem part to the write-ahead log.
+// It returns false if the WAL is closed.
+func (tst *tsTable) mustWriteWAL(partID uint64, mp *memPart) bool {
+	if tst.wal == nil {
+		return true
+	}
+	// Register a lower bound of the LSN, so that the record can't be truncated before it's persisted.
+	tst.walMu.Lock()
+	tst.walPending[partID] = tst.wal.NextLSN()
+	tst.walMu.Unlock()
+
+	bb := bigValuePool.Generate()
+	defer bigValuePool.Release(bb)
+	bb.Buf = encoding.VarUint64ToBytes(bb.Buf[:0], partID)
+	bb.Buf = mp.marshal(bb.Buf)
+	lsn, err := tst.wal.Write(bb.Buf)
+
+	tst.walMu.Lock()
+	defer tst.walMu.Unlock()
+	if err != nil {
+		delete(tst.walPending, partID)
+		if errors.Is(err, wal.ErrClosed) {
+			return false
+		}
+		tst.l.Panic().Err(err).Str("root", tst.root).Msg("cannot write the write-ahead log")
+	}
+	tst.walPending[partID] = lsn
+	return true
+}
+
+// advanceWALMark removes the persisted mem parts from the pending ones, and advances the watermark
+// which is persisted along with the next snapshot. It returns false if no mem part is persisted.
+// The mem parts might be merged into a part with another ID, so the watermark tracks their LSNs instead of their IDs.
+func (tst *tsTable) advanceWALMark(isPersisted func(partID uint64) bool) bool {
+	if tst.wal == nil {
+		return false
+	}
+	tst.walMu.Lock()
+	defer tst.walMu.Unlock()
+	var persisted []uint64
+	for partID, lsn := range tst.walPending {
+		if isPersisted(partID) {
+			delete(tst.walPending, partID)
+			persisted = append(persisted, lsn)
+		}
+	}
+	if len(persisted) == 0 {
+		return false
+	}
+	lowest := tst.wal.NextLSN()
+	for _, lsn := range tst.walPending {
+		lowest = min(lowest, lsn)
+	}
+	tst.walMark = tst.walMark.Advance(persisted, lowest)
+	return true
+}
+
+// walMarkToPersist returns the watermark persisted along with the snapshots, or nil if the write-ahead log is disabled.
+func (tst *tsTable) walMarkToPersist() *wal.Watermark {
+	if !tst.option.walEnabled {
+		return nil
+	}
+	tst.walMu.Lock()
+	defer tst.walMu.Unlock()
+	mark := tst.walMark
+	return &mark
+}
+
+// truncateWAL removes the records below the watermark from the write-ahead log.
+// The snapshot of epoch must hold the watermark, which is synced before the truncation.
+// A crash before the truncation leaves the records, which are skipped by the replay.
+func (tst *tsTable) truncateWAL(epoch uint64) {
+	tst.fileSystem.SyncPath(filepath.Join(tst.root, snapshotName(epoch)))
+	tst.fileSystem.SyncPath(tst.root)
+	tst.walMu.Lock()
+	bound := tst.walMark.LSN
+	tst.walMu.Unlock()
+	if err := tst.wal.Truncate(bound); err != nil {
+		tst.l.Warn().Err(err).Str("root", tst.root).Msg("cannot truncate the write-ahead log")
+	}
+}
+
+func (tst *tsTable) closeWAL() {
+	if tst.wal == nil {
+		return
+	}
+	if err := tst.wal.Close(); err != nil {
+		tst.l.Warn().Err(err).Str("root", tst.root).Msg("cannot close the write-ahead log")
+	}
+}
+
+func (mp *memPart) marshal(dst []byte) []byte {
+	dst = encoding.VarUint64ToBytes(dst, mp.partMetadata.CompressedSizeBytes)
+	dst = encoding.VarUint64ToBytes(dst, mp.partMetadata.UncompressedSizeBytes)
+	dst = encoding.VarUint64ToBytes(dst, mp.partMetadata.TotalCount)
+	dst = encoding.VarUint64ToBytes(dst, mp.partMetadata.BlocksCount)
+	dst = encoding.VarInt64ToBytes(dst, mp.partMetadata.MinTimestamp)
+	dst = encoding.VarInt64ToBytes(dst, mp.partMetadata.MaxTimestamp)
+	dst = encoding.EncodeBytes(dst, mp.meta.Buf)
+	dst = encoding.EncodeBytes(dst, mp.primary.Buf)
+	dst = encoding.EncodeBytes(dst, mp.timestamps.Buf)
+	dst = encoding.VarUint64ToBytes(dst, uint64(len(mp.tagFamilies)))
+	for name, tf := range mp.tagFamilies {
+		dst = encoding.EncodeBytes(dst, []byte(name))
+		dst = encoding.EncodeBytes(dst, mp.tagFamilyMetadata[name].Buf)
+		dst = encoding.EncodeBytes(dst, tf.Buf)
+		dst = encoding.EncodeBytes(dst, mp.tagFamilyFilter[name].Buf)
+	}
+	dst = encoding.EncodeBytes(dst, mp.tombstones.Marshal(nil))
+	return dst
+}
+
+func (mp *memPart) unmarshal(src []byte) error {
+	mp.reset()
+	var err error
+	src, mp.partMetadata.CompressedSizeBytes = encoding.BytesToVarUint64(src)
+	src, mp.partMetadata.UncompressedSizeBytes = encoding.BytesToVarUint64(src)
+	src, mp.partMetadata.TotalCount = encoding.BytesToVarUint64(src)
+	src, mp.partMetadata.BlocksCount = encoding.BytesToVarUint64(src)
+	if src, mp.partMetadata.MinTimestamp, err = encoding.BytesToVarInt64(src); err != nil {
+		return fmt.Errorf("cannot unmarshal min timestamp: %w", err)
+	}
+	if src, mp.partMetadata.MaxTimestamp, err = encoding.BytesToVarInt64(src); err != nil {
+		return fmt.Errorf("cannot unmarshal max timestamp: %w", err)
+	}
+	for _, b := range []*bytes.Buffer{&mp.meta, &mp.primary, &mp.timestamps} {
+		var data []byte
+		if src, data, err = encoding.DecodeBytes(src); err != nil {
+			return fmt.Errorf("cannot unmarshal mem part: %w", err)
+		}
+		b.Buf = append(b.Buf[:0], data...)
+	}
+	var count uint64
+	src, count = encoding.BytesToVarUint64(src)
+	for i := uint64(0); i < count; i++ {
+		var name, metadata, data, filter []byte
+		if src, name, err = encoding.DecodeBytes(src); err != nil {
+			return fmt.Errorf("cannot unmarshal tag family name: %w", err)
+		}
+		if src, metadata, err = encoding.DecodeBytes(src); err != nil {
+			return fmt.Errorf("cannot unmarshal the metadata of tag family %s: %w", name, err)
+		}
+		if src, data, err = encoding.DecodeBytes(src); err != nil {
+			return fmt.Errorf("cannot unmarshal tag family %s: %w", name, err)
+		}
+		if src, filter, err = encoding.DecodeBytes(src); err != nil {
+			return fmt.Errorf("cannot unmarshal the filter of tag family %s: %w", name, err)
+		}
+		mp.mustCreateMemTagFamilyWriters(string(name))
+		mp.tagFamilyMetadata[string(name)].Buf = append(mp.tagFamilyMetadata[string(name)].Buf, metadata...)
+		mp.tagFamilies[string(name)].Buf = append(mp.tagFamilies[string(name)].Buf, data...)
+		mp.tagFamilyFilter[string(name)].Buf = append(mp.tagFamilyFilter[string(name)].Buf, filter...)
+	}
+	var tombstones []byte
+	if src, tombstones, err = encoding.DecodeBytes(src); err != nil {
+		return fmt.Errorf("cannot unmarshal tombstones: %w", err)
+	}
+	if mp.tombstones, err = storage.UnmarshalTombstones(tombstones); err != nil {
+		return err
+	}
+	if len(src) > 0 {
+		return fmt.Errorf("unexpected %d bytes left after unmarshaling mem part", len(src))
+	}
+	return nil
+}
@@ -34,9 +34,24 @@ type syncPartContext struct {
 	tsTable *tsTable
 	writers *writers
 	memPart *memPart
+	// tombstones buffers the marshaled tombstones of the part until it's complete.
+	tombstones []byte
+	repair     bool
 }
 
 func (s *syncPartContext) FinishSync() error {
+	if s.repair {
+		return s.finishRepair()
+	}
+	if len(s.tombstones) > 0 {
+		tombstones, err := storage.UnmarshalTombstones(s.tombstones)
+		if err != nil {
+			releaseMemPart(s.memPart)
+			_ = s.Close()
+			return fmt.Errorf("cannot unmarshal the tombstones of the synced part: %w", err)
+		}
+		s.memPart.tombstones = tombstones
+	}
 	s.tsTable.mustAddMemPart(s.memPart)
 	return s.Close()
 }
@@ -47,12 +62,14 @@ func (s *syncPartContext) Close() error {
 	s.writers = nil
 	s.memPart = nil
 	s.tsTable = nil
+	s.tombstones = nil
 	return nil
 }
 
 type syncCallback struct {
 	l          *logger.Logger
 	schemaRepo *schemaRepo
+	repair     bool
 }
 
 func setUpChunkedSyncCallback(l *logger.Logger, schemaRepo *schemaRepo) queue.ChunkedSyncHandler {
@@ -62,6 +79,15 @@ func setUpChunkedSyncCallback(l *logger.Logger, schemaRepo *schemaRepo) queue.Ch
 	}
 }
 
+// setUpRepairPartCallback receives the parts sent by the replica repair, which only introduces the missing elements.
+func setUpRepairPartCallback(l *logger.Logger, schemaRepo *schemaRepo) queue.ChunkedSyncHandler {
+	return &syncCallback{
+		l:          l,
+		schemaRepo: schemaRepo,
+		repair:     true,
+	}
+}
+
 func (s *syncCallback) CheckHealth() *common.Error {
 	return nil
 }
@@ -100,6 +126,7 @@ func (s *syncCallback) CreatePartHandler(ctx *queue.ChunkedSyncPartContext) (que
 		tsTable: tsTable,
 		writers: writers,
 		memPart: memPart,
+		repair:  s.repair,
 	}, nil
 }
 
@@ -119,6 +146,8 @@ func (s *syncCallback) HandleFileChunk(ctx *queue.ChunkedSyncPartContext, chunk
 		partCtx.writers.primaryWriter.MustWrite(chunk)
 	case fileName == streamTimestampsName:
 		partCtx.writers.timestampsWriter.MustWrite(chunk)
+	case fileName == streamTombstonesName:
+		partCtx.tombstones = append(partCtx.tombstones, chunk...)
 	case strings.HasPrefix(fileName, streamTagFamiliesPrefix):
 		tagName := fileName[len(streamTagFamiliesPrefix):]
 		_, tagWriter, _ := partCtx.writers.getWriters(tagName)
@@ -224,13 +224,21 @@ func processElements(schemaRepo *schemaRepo, elements *elements, writeEvent *str
 			t := tagFamilySpec.Tags[j]
 			indexed := false
 			if r, ok := tfr[t.Name]; ok && tagValue != pbv1.NullTagValue {
-				if r.GetType() == databasev1.IndexRule_TYPE_INVERTED {
+				switch r.GetType() {
+				case databasev1.IndexRule_TYPE_INVERTED:
 					fields = appendField(fields, index.FieldKey{
 						IndexRuleID: r.GetMetadata().GetId(),
 						Analyzer:    r.Analyzer,
 						SeriesID:    series.ID,
 					}, t.Type, tagValue, r.GetNoSort())
-				} else if r.GetType() == databasev1.IndexRule_TYPE_SKIPPING {
+				case databasev1.IndexRule_TYPE_TREE:
+					fieldKey := index.FieldKey{
+						IndexRuleID: r.GetMetadata().GetId(),
+						SeriesID:    series.ID,
+					}
+					fields = appendField(fields, fieldKey, t.Type, tagValue, r.GetNoSort())
+					fields = appendTreeFields(fields, fieldKey, tagValue, index.TreeSeparator(r))
+				case databasev1.IndexRule_TYPE_SKIPPING:
 					indexed = true
 				}
 			}
@@ -336,6 +344,11 @@ func encodeTagValue(name string, tagType databasev1.TagType, tagVal *modelv1.Tag
 		if tagVal.GetInt() != nil {
 			tv.value = convert.Int64ToBytes(tagVal.GetInt().GetValue())
 		}
+	case databasev1.TagType_TAG_TYPE_FLOAT:
+		tv.valueType = pbv1.ValueTypeFloat64
+		if tagVal.GetFloat() != nil {
+			tv.value = convert.Float64ToBytes(tagVal.GetFloat().GetValue())
+		}
 	case databasev1.TagType_TAG_TYPE_STRING:
 		tv.valueType = pbv1.ValueTypeStr
 		if tagVal.GetStr() != nil {
@@ -370,6 +383,19 @@ func encodeTagValue(name string, tagType databasev1.TagType, tagVal *modelv1.Tag
 	return tv
 }
 
+// appendTreeFields indexes the ancestors of string values, the other types are only indexed by appendField.
+func appendTreeFields(dest []index.Field, fieldKey index.FieldKey, tagVal *modelv1.TagValue, sep string) []index.Field {
+	if v := tagVal.GetStr(); v != nil {
+		return index.AppendTreeFields(dest, fieldKey, v.Value, sep)
+	}
+	if tagVal.GetStrArray() != nil {
+		for _, v := range tagVal.GetStrArray().Value {
+			dest = index.AppendTreeFields(dest, fieldKey, v, sep)
+		}
+	}
+	return dest
+}
+
 func appendField(dest []index.Field, fieldKey index.FieldKey, tagType databasev1.TagType, tagVal *modelv1.TagValue, noSort bool) []index.Field {
 	switch tagType {
 	case databasev1.TagType_TAG_TYPE_INT:
@@ -380,6 +406,14 @@ func appendField(dest []index.Field, fieldKey index.FieldKey, tagType databasev1
 		f := index.NewIntField(fieldKey, v.Value)
 		f.NoSort = noSort
 		dest = append(dest, f)
+	case databasev1.TagType_TAG_TYPE_FLOAT:
+		v := tagVal.GetFloat()
+		if v == nil {
+			return dest
+		}
+		f := index.NewFloatField(fieldKey, v.Value)
+		f.NoSort = noSort
+		dest = append(dest, f)
 	case databasev1.TagType_TAG_TYPE_STRING:
 		v := tagVal.GetStr()
 		if v == nil {
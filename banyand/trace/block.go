@@ -323,6 +323,9 @@ func resizeSpans(spans [][]byte, spansLen int) [][]byte {
 		spans = append(spans[:cap(spans)], make([][]byte, n)...)
 	}
 	spans = spans[:spansLen]
+	for i := range spans {
+		spans[i] = spans[i][:0]
+	}
 	return spans
 }
 
@@ -53,9 +53,4 @@ func generateBloomFilter() *filter.BloomFilter {
 	return v
 }
 
-func releaseBloomFilter(bf *filter.BloomFilter) {
-	bf.Reset()
-	bloomFilterPool.Put(bf)
-}
-
 var bloomFilterPool = pool.Register[*filter.BloomFilter]("trace-bloomFilter")
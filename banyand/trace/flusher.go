@@ -225,6 +225,6 @@ func (tst *tsTable) persistSnapshot(snapshot *snapshot) {
 	for i := range snapshot.parts {
 		partNames = append(partNames, partName(snapshot.parts[i].ID()))
 	}
-	tst.mustWriteSnapshot(snapshot.epoch, partNames)
+	tst.mustWriteSnapshot(snapshot.epoch, partNames, tst.walMarkToPersist())
 	tst.gc.registerSnapshot(snapshot)
 }
@@ -0,0 +1,265 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package trace
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"slices"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	tracev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/trace/v1"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query"
+	"github.com/apache/skywalking-banyandb/pkg/query/model"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+// lookupTraces collects the spans of the given traces from the table into dst.
+// traceIDs must be sorted in ascending order without duplicates.
+// Parts whose trace ID bloom filter rules out all the IDs are skipped without being read.
+func (tst *tsTable) lookupTraces(dst map[string][]*tracev1.Span, traceIDs []string, tagNames []string, minTimestamp, maxTimestamp int64) error {
+	s := tst.currentSnapshot()
+	if s == nil {
+		return nil
+	}
+	defer s.decRef()
+	parts, n := s.getParts(nil, minTimestamp, maxTimestamp)
+	if n < 1 {
+		return nil
+	}
+
+	bma := generateBlockMetadataArray()
+	defer releaseBlockMetadataArray(bma)
+	tmpBlock := generateBlock()
+	defer releaseBlock(tmpBlock)
+	decoder := generateColumnValuesDecoder()
+	defer releaseColumnValuesDecoder(decoder)
+	projection := &model.TagProjection{Names: tagNames}
+
+	var pi partIter
+	var tids []string
+	for _, p := range parts {
+		tids = p.traceIDFilter.filterTraceIDs(tids[:0], traceIDs)
+		if len(tids) == 0 {
+			continue
+		}
+		pi.init(bma, p, tids, minTimestamp, maxTimestamp)
+		for pi.nextBlock() {
+			bm := *pi.curBlock
+			if bm.timestamps.max < minTimestamp || bm.timestamps.min > maxTimestamp {
+				continue
+			}
+			bm.tagProjection = projection
+			tmpBlock.mustReadFrom(decoder, p, bm)
+			for i := range tmpBlock.spans {
+				span := &tracev1.Span{
+					Span: bytes.Clone(tmpBlock.spans[i]),
+					Tags: make([]*modelv1.Tag, 0, len(tmpBlock.tags)),
+				}
+				for _, t := range tmpBlock.tags {
+					var value []byte
+					if i < len(t.values) {
+						value = t.values[i]
+					}
+					span.Tags = append(span.Tags, &modelv1.Tag{
+						Key:   t.name,
+						Value: mustDecodeTagValue(t.valueType, value),
+					})
+				}
+				dst[bm.traceID] = append(dst[bm.traceID], span)
+			}
+		}
+		if err := pi.error(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getTraces returns the traces of the given IDs in the order of the IDs.
+// The traces whose spans are not found are omitted.
+func (sr *schemaRepo) getTraces(req *tracev1.GetTracesRequest) ([]*tracev1.Trace, error) {
+	traceIDs := slices.Clone(req.GetTraceIds())
+	slices.Sort(traceIDs)
+	traceIDs = slices.Compact(traceIDs)
+	tr := timestamp.NewInclusiveTimeRange(time.Unix(0, timestamp.MinNanoTime), time.Unix(0, timestamp.MaxNanoTime))
+	if req.GetTimeRange() != nil {
+		tr = timestamp.NewInclusiveTimeRange(req.GetTimeRange().GetBegin().AsTime(), req.GetTimeRange().GetEnd().AsTime())
+	}
+
+	spans := make(map[string][]*tracev1.Span, len(traceIDs))
+	for _, group := range req.GetGroups() {
+		t, ok := sr.loadTrace(&commonv1.Metadata{Name: req.GetName(), Group: group})
+		if !ok {
+			return nil, fmt.Errorf("trace %s is not found in group %s", req.GetName(), group)
+		}
+		tagNames := make([]string, 0, len(req.GetTagProjection()))
+		for _, name := range req.GetTagProjection() {
+			if name != t.schema.GetTraceIdTagName() {
+				tagNames = append(tagNames, name)
+			}
+		}
+		found := make(map[string][]*tracev1.Span)
+		if err := sr.lookupTracesInGroup(found, group, traceIDs, tagNames, tr); err != nil {
+			return nil, err
+		}
+		for traceID, ss := range found {
+			for _, s := range ss {
+				s.Tags = projectTags(t.schema, traceID, req.GetTagProjection(), s.Tags)
+			}
+			spans[traceID] = append(spans[traceID], ss...)
+		}
+	}
+
+	result := make([]*tracev1.Trace, 0, len(spans))
+	for _, traceID := range req.GetTraceIds() {
+		ss, ok := spans[traceID]
+		if !ok {
+			continue
+		}
+		// Each trace is returned once even if its ID is requested several times.
+		delete(spans, traceID)
+		result = append(result, &tracev1.Trace{TraceId: traceID, Spans: ss})
+	}
+	return result, nil
+}
+
+func (sr *schemaRepo) lookupTracesInGroup(dst map[string][]*tracev1.Span, group string, traceIDs, tagNames []string, tr timestamp.TimeRange) error {
+	db, err := sr.loadTSDB(group)
+	if err != nil {
+		return err
+	}
+	segments, err := db.SelectSegments(tr)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, segment := range segments {
+			segment.DecRef()
+		}
+	}()
+	for _, segment := range segments {
+		tables, _ := segment.Tables()
+		for _, tst := range tables {
+			if err = tst.lookupTraces(dst, traceIDs, tagNames, tr.Start.UnixNano(), tr.End.UnixNano()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// projectTags arranges the stored tags in the order of the projection.
+// The trace ID tag is not stored along with the span, so it's rebuilt from the trace ID.
+func projectTags(schema *databasev1.Trace, traceID string, projection []string, stored []*modelv1.Tag) []*modelv1.Tag {
+	tags := make([]*modelv1.Tag, 0, len(projection))
+	for _, name := range projection {
+		if name == schema.GetTraceIdTagName() {
+			tags = append(tags, &modelv1.Tag{Key: name, Value: strTagValue(traceID)})
+			continue
+		}
+		value := pbv1.NullTagValue
+		for _, t := range stored {
+			if t.GetKey() == name {
+				value = t.GetValue()
+				break
+			}
+		}
+		for _, spec := range schema.GetTags() {
+			if spec.GetName() == name && spec.GetType() == databasev1.TagType_TAG_TYPE_TIMESTAMP && value.GetInt() != nil {
+				value = &modelv1.TagValue{Value: &modelv1.TagValue_Timestamp{
+					Timestamp: timestamppb.New(time.Unix(0, value.GetInt().GetValue())),
+				}}
+				break
+			}
+		}
+		tags = append(tags, &modelv1.Tag{Key: name, Value: value})
+	}
+	return tags
+}
+
+type getTracesCallback struct {
+	*bus.UnImplementedHealthyListener
+	l          *logger.Logger
+	schemaRepo *schemaRepo
+}
+
+func setUpGetTracesCallback(l *logger.Logger, schemaRepo *schemaRepo) bus.MessageListener {
+	return &getTracesCallback{
+		l:          l,
+		schemaRepo: schemaRepo,
+	}
+}
+
+func (g *getTracesCallback) Rev(ctx context.Context, message bus.Message) (resp bus.Message) {
+	n := time.Now()
+	now := n.UnixNano()
+	req, ok := message.Data().(*tracev1.GetTracesRequest)
+	if !ok {
+		resp = bus.NewMessage(bus.MessageID(now), common.NewError("invalid event data type"))
+		return
+	}
+	if g.l.Debug().Enabled() {
+		g.l.Debug().RawJSON("req", logger.Proto(req)).Msg("received a get traces request")
+	}
+	defer func() {
+		if err := recover(); err != nil {
+			g.l.Error().Interface("err", err).RawJSON("req", logger.Proto(req)).Str("stack", string(debug.Stack())).Msg("panic")
+			resp = bus.NewMessage(bus.MessageID(time.Now().UnixNano()), common.NewError("panic"))
+		}
+	}()
+	if req.Trace {
+		tracer, tracerCtx := query.NewTracer(ctx, n.Format(time.RFC3339Nano))
+		span, _ := tracer.StartSpan(tracerCtx, "data-get-traces")
+		span.Tagf("trace_ids", "%v", req.GetTraceIds())
+		defer func() {
+			switch d := resp.Data().(type) {
+			case *tracev1.GetTracesResponse:
+				span.Tagf("found", "%d", len(d.Traces))
+				span.Stop()
+				d.TraceQueryResult = tracer.ToProto()
+			case *common.Error:
+				span.Error(errors.New(d.Error()))
+				span.Stop()
+				resp = bus.NewMessage(bus.MessageID(now), &tracev1.GetTracesResponse{TraceQueryResult: tracer.ToProto()})
+			default:
+				panic("unexpected data type")
+			}
+		}()
+	}
+	traces, err := g.schemaRepo.getTraces(req)
+	if err != nil {
+		g.l.Error().Err(err).RawJSON("req", logger.Proto(req)).Msg("fail to get traces")
+		resp = bus.NewMessage(bus.MessageID(now), common.NewError("fail to get traces from trace %s: %v", req.GetName(), err))
+		return
+	}
+	resp = bus.NewMessage(bus.MessageID(now), &tracev1.GetTracesResponse{Traces: traces})
+	return
+}
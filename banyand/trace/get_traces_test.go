@@ -0,0 +1,110 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package trace
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tracev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/trace/v1"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+	"github.com/apache/skywalking-banyandb/pkg/run"
+	"github.com/apache/skywalking-banyandb/pkg/test"
+	"github.com/apache/skywalking-banyandb/pkg/watcher"
+)
+
+func Test_tsTable_lookupTraces(t *testing.T) {
+	type span struct {
+		strTag string
+		span   string
+	}
+	tests := []struct {
+		want         map[string][]span
+		name         string
+		traceIDs     []string
+		minTimestamp int64
+		maxTimestamp int64
+	}{
+		{
+			name:         "Test with traces in multiple parts",
+			traceIDs:     []string{"trace1", "trace3", "trace9"},
+			minTimestamp: 1,
+			maxTimestamp: 2,
+			want: map[string][]span{
+				"trace1": {{strTag: "value1", span: "span1"}, {strTag: "value4", span: "span4"}},
+				"trace3": {{strTag: "value3", span: "span3"}, {strTag: "value6", span: "span6"}},
+			},
+		},
+		{
+			name:         "Test with a time range covering one part",
+			traceIDs:     []string{"trace2"},
+			minTimestamp: 2,
+			maxTimestamp: 2,
+			want: map[string][]span{
+				"trace2": {{strTag: "value5", span: "span5"}},
+			},
+		},
+		{
+			name:         "Test with unknown traces",
+			traceIDs:     []string{"trace8", "trace9"},
+			minTimestamp: 1,
+			maxTimestamp: 2,
+			want:         map[string][]span{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpPath, defFn := test.Space(require.New(t))
+			defer defFn()
+			tst := &tsTable{
+				loopCloser:    run.NewCloser(2),
+				introductions: make(chan *introduction),
+				fileSystem:    fs.NewLocalFileSystem(),
+				root:          tmpPath,
+			}
+			tst.gc.init(tst)
+			flushCh := make(chan *flusherIntroduction)
+			mergeCh := make(chan *mergerIntroduction)
+			introducerWatcher := make(watcher.Channel, 1)
+			go tst.introducerLoop(flushCh, mergeCh, introducerWatcher, 1)
+			defer tst.Close()
+			for _, ts := range []*traces{tsTS1, tsTS2} {
+				tst.mustAddTraces(ts)
+				time.Sleep(100 * time.Millisecond)
+			}
+
+			got := make(map[string][]*tracev1.Span)
+			require.NoError(t, tst.lookupTraces(got, tt.traceIDs, []string{"strTag", "unknownTag"}, tt.minTimestamp, tt.maxTimestamp))
+			require.Len(t, got, len(tt.want))
+			for traceID, want := range tt.want {
+				var spans []span
+				for _, s := range got[traceID] {
+					require.Len(t, s.Tags, 2)
+					assert.Equal(t, "strTag", s.Tags[0].Key)
+					assert.Equal(t, pbv1.NullTagValue, s.Tags[1].Value)
+					spans = append(spans, span{strTag: s.Tags[0].Value.GetStr().GetValue(), span: string(s.Span)})
+				}
+				assert.ElementsMatch(t, want, spans)
+			}
+		})
+	}
+}
@@ -230,8 +230,15 @@ func (tst *tsTable) introduceFlushed(nextIntroduction *flusherIntroduction, epoc
 	defer cur.decRef()
 	nextSnp := cur.merge(epoch, nextIntroduction.flushed)
 	nextSnp.creator = snapshotCreatorFlusher
+	advanced := tst.advanceWALMark(func(partID uint64) bool {
+		_, ok := nextIntroduction.flushed[partID]
+		return ok
+	})
 	tst.replaceSnapshot(&nextSnp)
 	tst.persistSnapshot(&nextSnp)
+	if advanced {
+		tst.truncateWAL(epoch)
+	}
 	if nextIntroduction.applied != nil {
 		close(nextIntroduction.applied)
 	}
@@ -247,8 +254,15 @@ func (tst *tsTable) introduceMerged(nextIntroduction *mergerIntroduction, epoch
 	nextSnp := cur.remove(epoch, nextIntroduction.merged)
 	nextSnp.parts = append(nextSnp.parts, nextIntroduction.newPart)
 	nextSnp.creator = nextIntroduction.creator
+	advanced := tst.advanceWALMark(func(partID uint64) bool {
+		_, ok := nextIntroduction.merged[partID]
+		return ok
+	})
 	tst.replaceSnapshot(&nextSnp)
 	tst.persistSnapshot(&nextSnp)
+	if advanced {
+		tst.truncateWAL(epoch)
+	}
 	if nextIntroduction.applied != nil {
 		close(nextIntroduction.applied)
 	}
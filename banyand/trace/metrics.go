@@ -20,15 +20,13 @@ package trace
 import (
 	"github.com/apache/skywalking-banyandb/api/common"
 	"github.com/apache/skywalking-banyandb/banyand/internal/storage"
-	"github.com/apache/skywalking-banyandb/banyand/observability"
 	"github.com/apache/skywalking-banyandb/pkg/index/inverted"
 	"github.com/apache/skywalking-banyandb/pkg/meter"
 )
 
 var (
-	streamScope  = observability.RootScope.SubScope("stream")
-	tbScope      = streamScope.SubScope("tst")
-	storageScope = streamScope.SubScope("storage")
+	tbScope      = traceScope.SubScope("tst")
+	storageScope = traceScope.SubScope("storage")
 )
 
 type metrics struct {
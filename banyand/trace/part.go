@@ -272,9 +272,7 @@ func (mp *memPart) Unmarshal(data []byte) error {
 		}
 		filterBytes := tail[:filterLen]
 		tail = tail[filterLen:]
-		bf := generateBloomFilter()
-		defer releaseBloomFilter(bf)
-		mp.traceIDFilter.filter = decodeBloomFilter(filterBytes, bf)
+		mp.traceIDFilter.filter = decodeBloomFilter(filterBytes, generateBloomFilter())
 	} else {
 		mp.traceIDFilter.filter = nil
 	}
@@ -207,6 +207,19 @@ func (tf *traceIDFilter) reset() {
 	tf.filter = nil
 }
 
+// filterTraceIDs appends the trace IDs that might be stored in the part to dst.
+func (tf *traceIDFilter) filterTraceIDs(dst, traceIDs []string) []string {
+	if tf.filter == nil {
+		return append(dst, traceIDs...)
+	}
+	for _, tid := range traceIDs {
+		if tf.filter.MightContain(convert.StringToBytes(tid)) {
+			dst = append(dst, tid)
+		}
+	}
+	return dst
+}
+
 func (tf *traceIDFilter) mustReadTraceIDFilter(fileSystem fs.FileSystem, partPath string) {
 	traceIDFilterPath := filepath.Join(partPath, traceIDFilterFilename)
 	data, err := fileSystem.Read(traceIDFilterPath)
@@ -225,9 +238,7 @@ func (tf *traceIDFilter) mustReadTraceIDFilter(fileSystem fs.FileSystem, partPat
 		return
 	}
 
-	bf := generateBloomFilter()
-	defer releaseBloomFilter(bf)
-	tf.filter = decodeBloomFilter(data, bf)
+	tf.filter = decodeBloomFilter(data, generateBloomFilter())
 }
 
 func (tf *traceIDFilter) mustWriteTraceIDFilter(fileSystem fs.FileSystem, partPath string) {
@@ -0,0 +1,380 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package trace
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/skywalking-banyandb/api/common"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	tracev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/trace/v1"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+	"github.com/apache/skywalking-banyandb/pkg/query/model"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+const defaultQueryLimit = 20
+
+// scanStats are the numbers of the parts and blocks read by a span scan.
+type scanStats struct {
+	parts  int64
+	blocks int64
+}
+
+// scanSpans visits the spans stored in the table within the time range.
+// The tags passed to visit are in the order of tagNames and are only valid during the call.
+func (tst *tsTable) scanSpans(stats *scanStats, tagNames []string, minTimestamp, maxTimestamp int64,
+	visit func(traceID string, span []byte, tags []*modelv1.Tag),
+) error {
+	s := tst.currentSnapshot()
+	if s == nil {
+		return nil
+	}
+	defer s.decRef()
+	parts, n := s.getParts(nil, minTimestamp, maxTimestamp)
+	if n < 1 {
+		return nil
+	}
+
+	tmpBlock := generateBlock()
+	defer releaseBlock(tmpBlock)
+	decoder := generateColumnValuesDecoder()
+	defer releaseColumnValuesDecoder(decoder)
+	projection := &model.TagProjection{Names: tagNames}
+	tags := make([]*modelv1.Tag, len(tagNames))
+
+	var pi partIter
+	var bms []blockMetadata
+	for _, p := range parts {
+		stats.parts++
+		pi.reset()
+		pi.p = p
+		for i := range p.primaryBlockMetadata {
+			var err error
+			if bms, err = pi.readPrimaryBlock(bms[:0], &p.primaryBlockMetadata[i]); err != nil {
+				return fmt.Errorf("cannot read primary block for part %q: %w", &p.partMetadata, err)
+			}
+			for j := range bms {
+				bm := bms[j]
+				if bm.timestamps.max < minTimestamp || bm.timestamps.min > maxTimestamp {
+					continue
+				}
+				stats.blocks++
+				bm.tagProjection = projection
+				tmpBlock.mustReadFrom(decoder, p, bm)
+				for k := range tmpBlock.spans {
+					for ti, t := range tmpBlock.tags {
+						var value []byte
+						if k < len(t.values) {
+							value = t.values[k]
+						}
+						tags[ti] = &modelv1.Tag{Key: t.name, Value: mustDecodeTagValue(t.valueType, value)}
+					}
+					visit(bm.traceID, tmpBlock.spans[k], tags)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+type matchedSpan struct {
+	span    *tracev1.Span
+	sortKey []byte
+}
+
+// querySpans returns the spans matching the criteria of the request.
+// The spans are sorted by the tag the request orders by, which is the timestamp tag by default,
+// and cut by the offset and the limit of the request.
+func (sr *schemaRepo) querySpans(req *tracev1.QueryRequest, stats *scanStats) ([]*tracev1.Span, error) {
+	filter, err := logical.BuildSimpleTagFilter(req.GetCriteria())
+	if err != nil {
+		return nil, err
+	}
+	tr := timestamp.NewInclusiveTimeRange(time.Unix(0, timestamp.MinNanoTime), time.Unix(0, timestamp.MaxNanoTime))
+	if req.GetTimeRange() != nil {
+		tr = timestamp.NewInclusiveTimeRange(req.GetTimeRange().GetBegin().AsTime(), req.GetTimeRange().GetEnd().AsTime())
+	}
+
+	var matched []matchedSpan
+	for _, group := range req.GetGroups() {
+		t, ok := sr.loadTrace(&commonv1.Metadata{Name: req.GetName(), Group: group})
+		if !ok {
+			return nil, fmt.Errorf("trace %s is not found in group %s", req.GetName(), group)
+		}
+		sortTagName, sortErr := sortTagNameOf(t, req.GetOrderBy())
+		if sortErr != nil {
+			return nil, sortErr
+		}
+		registry := newTagSpecRegistry(t.schema)
+		// Every tag is read, the filter and the sort tag might not be in the projection.
+		allTagNames := make([]string, 0, len(t.schema.GetTags()))
+		storedTagNames := make([]string, 0, len(t.schema.GetTags()))
+		for _, spec := range t.schema.GetTags() {
+			allTagNames = append(allTagNames, spec.GetName())
+			if spec.GetName() != t.schema.GetTraceIdTagName() {
+				storedTagNames = append(storedTagNames, spec.GetName())
+			}
+		}
+		projection := req.GetTagProjection()
+		if len(projection) == 0 {
+			projection = allTagNames
+		}
+		var visitErr error
+		visit := func(traceID string, span []byte, stored []*modelv1.Tag) {
+			if visitErr != nil {
+				return
+			}
+			tags := projectTags(t.schema, traceID, allTagNames, stored)
+			valueOf := func(name string) *modelv1.TagValue {
+				if idx := registry.tagIdx(name); idx >= 0 {
+					return tags[idx].GetValue()
+				}
+				return nil
+			}
+			if ts := valueOf(t.schema.GetTimestampTagName()).GetTimestamp(); ts != nil && !tr.Contains(ts.AsTime().UnixNano()) {
+				return
+			}
+			matches, matchErr := filter.Match(logical.TagFamilies{{Tags: tags}}, registry)
+			if matchErr != nil {
+				visitErr = matchErr
+				return
+			}
+			if !matches {
+				return
+			}
+			ms := matchedSpan{
+				span: &tracev1.Span{
+					Span: bytes.Clone(span),
+					Tags: make([]*modelv1.Tag, 0, len(projection)),
+				},
+			}
+			for _, name := range projection {
+				if idx := registry.tagIdx(name); idx >= 0 {
+					ms.span.Tags = append(ms.span.Tags, tags[idx])
+					continue
+				}
+				ms.span.Tags = append(ms.span.Tags, &modelv1.Tag{Key: name, Value: pbv1.NullTagValue})
+			}
+			var keyErr error
+			if ms.sortKey, keyErr = sortKeyOf(valueOf(sortTagName)); keyErr != nil {
+				visitErr = keyErr
+				return
+			}
+			matched = append(matched, ms)
+		}
+		if err = sr.scanSpansInGroup(stats, group, storedTagNames, tr, visit); err != nil {
+			return nil, err
+		}
+		if visitErr != nil {
+			return nil, visitErr
+		}
+	}
+
+	desc := req.GetOrderBy().GetSort() == modelv1.Sort_SORT_DESC
+	slices.SortStableFunc(matched, func(a, b matchedSpan) int {
+		if desc {
+			return bytes.Compare(b.sortKey, a.sortKey)
+		}
+		return bytes.Compare(a.sortKey, b.sortKey)
+	})
+	limit := req.GetLimit()
+	if limit == 0 {
+		limit = defaultQueryLimit
+	}
+	start := min(int(req.GetOffset()), len(matched))
+	end := min(start+int(limit), len(matched))
+	result := make([]*tracev1.Span, 0, end-start)
+	for _, ms := range matched[start:end] {
+		result = append(result, ms.span)
+	}
+	return result, nil
+}
+
+func (sr *schemaRepo) scanSpansInGroup(stats *scanStats, group string, tagNames []string, tr timestamp.TimeRange,
+	visit func(traceID string, span []byte, tags []*modelv1.Tag),
+) error {
+	db, err := sr.loadTSDB(group)
+	if err != nil {
+		return err
+	}
+	segments, err := db.SelectSegments(tr)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, segment := range segments {
+			segment.DecRef()
+		}
+	}()
+	for _, segment := range segments {
+		tables, _ := segment.Tables()
+		for _, tst := range tables {
+			if err = tst.scanSpans(stats, tagNames, tr.Start.UnixNano(), tr.End.UnixNano(), visit); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sortTagNameOf returns the tag the spans are sorted by.
+// It's the tag of the index rule the query orders by, or the timestamp tag if the order is absent.
+func sortTagNameOf(t *trace, orderBy *modelv1.QueryOrder) (string, error) {
+	if orderBy.GetIndexRuleName() == "" {
+		return t.schema.GetTimestampTagName(), nil
+	}
+	for _, rule := range t.GetIndexRules() {
+		if rule.GetMetadata().GetName() != orderBy.GetIndexRuleName() {
+			continue
+		}
+		if len(rule.GetTags()) != 1 {
+			return "", fmt.Errorf("index rule %s should have only one tag", rule.GetMetadata().GetName())
+		}
+		return rule.GetTags()[0], nil
+	}
+	return "", fmt.Errorf("index rule %s not found", orderBy.GetIndexRuleName())
+}
+
+// sortKeyOf encodes the value of the sort tag in the same way as the liaison merging the spans of data nodes.
+func sortKeyOf(value *modelv1.TagValue) ([]byte, error) {
+	if value == nil || value == pbv1.NullTagValue {
+		return nil, nil
+	}
+	if ts := value.GetTimestamp(); ts != nil {
+		return convert.Uint64ToBytes(uint64(ts.AsTime().UnixNano())), nil
+	}
+	return pbv1.MarshalTagValue(value)
+}
+
+// tagSpecRegistry locates the tags of a span, which are in the order of the trace schema,
+// in the only tag family of the span.
+type tagSpecRegistry map[string]*logical.TagSpec
+
+func newTagSpecRegistry(schema *databasev1.Trace) tagSpecRegistry {
+	r := make(tagSpecRegistry, len(schema.GetTags()))
+	for i, spec := range schema.GetTags() {
+		r[spec.GetName()] = &logical.TagSpec{
+			Spec:   &databasev1.TagSpec{Name: spec.GetName(), Type: spec.GetType()},
+			TagIdx: i,
+		}
+	}
+	return r
+}
+
+func (r tagSpecRegistry) FindTagSpecByName(name string) *logical.TagSpec {
+	return r[name]
+}
+
+func (r tagSpecRegistry) tagIdx(name string) int {
+	if spec, ok := r[name]; ok {
+		return spec.TagIdx
+	}
+	return -1
+}
+
+type queryCallback struct {
+	*bus.UnImplementedHealthyListener
+	l          *logger.Logger
+	schemaRepo *schemaRepo
+}
+
+func setUpQueryCallback(l *logger.Logger, schemaRepo *schemaRepo) bus.MessageListener {
+	return &queryCallback{
+		l:          l,
+		schemaRepo: schemaRepo,
+	}
+}
+
+func (q *queryCallback) Rev(ctx context.Context, message bus.Message) (resp bus.Message) {
+	n := time.Now()
+	now := n.UnixNano()
+	req, ok := message.Data().(*tracev1.QueryRequest)
+	if !ok {
+		resp = bus.NewMessage(bus.MessageID(now), common.NewError("invalid event data type"))
+		return
+	}
+	if q.l.Debug().Enabled() {
+		q.l.Debug().RawJSON("req", logger.Proto(req)).Msg("received a query request")
+	}
+	defer func() {
+		if err := recover(); err != nil {
+			q.l.Error().Interface("err", err).RawJSON("req", logger.Proto(req)).Str("stack", string(debug.Stack())).Msg("panic")
+			resp = bus.NewMessage(bus.MessageID(time.Now().UnixNano()), common.NewError("panic"))
+		}
+	}()
+	if req.Trace {
+		tracer, tracerCtx := query.NewTracer(ctx, n.Format(time.RFC3339Nano))
+		span, _ := tracer.StartSpan(tracerCtx, "data-query-spans")
+		span.Tag("request", convert.BytesToString(logger.Proto(req)))
+		defer func() {
+			switch d := resp.Data().(type) {
+			case *tracev1.QueryResponse:
+				span.Tagf("found", "%d", len(d.Spans))
+				span.Stop()
+				d.TraceQueryResult = tracer.ToProto()
+			case *common.Error:
+				span.Error(errors.New(d.Error()))
+				span.Stop()
+				resp = bus.NewMessage(bus.MessageID(now), &tracev1.QueryResponse{TraceQueryResult: tracer.ToProto()})
+			default:
+				panic("unexpected data type")
+			}
+		}()
+	}
+	plan := logical.NewPlanNode("SpanScan", "groups", strings.Join(req.GetGroups(), ","), "name", req.GetName(),
+		"offset", strconv.FormatUint(uint64(req.GetOffset()), 10), "limit", strconv.FormatUint(uint64(req.GetLimit()), 10))
+	if req.GetExplain() == commonv1.ExplainMode_EXPLAIN_MODE_PLAN {
+		resp = bus.NewMessage(bus.MessageID(now), &tracev1.QueryResponse{Plan: plan})
+		return
+	}
+	var stats scanStats
+	spans, err := q.schemaRepo.querySpans(req, &stats)
+	if err != nil {
+		q.l.Error().Err(err).RawJSON("req", logger.Proto(req)).Msg("fail to query spans")
+		resp = bus.NewMessage(bus.MessageID(now), common.NewError("fail to query spans of trace %s: %v", req.GetName(), err))
+		return
+	}
+	queryResp := &tracev1.QueryResponse{Spans: spans}
+	if req.GetExplain() == commonv1.ExplainMode_EXPLAIN_MODE_ANALYZE {
+		plan.Stats = &commonv1.PlanStats{
+			Rows:     int64(len(spans)),
+			Blocks:   stats.blocks,
+			Parts:    stats.parts,
+			Duration: time.Since(n).Nanoseconds(),
+		}
+		queryResp.Plan = plan
+	}
+	resp = bus.NewMessage(bus.MessageID(now), queryResp)
+	return
+}
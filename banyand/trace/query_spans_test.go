@@ -0,0 +1,111 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package trace
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/run"
+	"github.com/apache/skywalking-banyandb/pkg/test"
+	"github.com/apache/skywalking-banyandb/pkg/watcher"
+)
+
+func Test_tsTable_scanSpans(t *testing.T) {
+	type span struct {
+		traceID string
+		span    string
+		intTag  int64
+	}
+	tests := []struct {
+		name         string
+		want         []span
+		minTimestamp int64
+		maxTimestamp int64
+		wantParts    int64
+	}{
+		{
+			name:         "Test with all parts",
+			minTimestamp: 1,
+			maxTimestamp: 2,
+			want: []span{
+				{traceID: "trace1", span: "span1", intTag: 10},
+				{traceID: "trace2", span: "span2", intTag: 20},
+				{traceID: "trace3", span: "span3", intTag: 30},
+				{traceID: "trace1", span: "span4", intTag: 40},
+				{traceID: "trace2", span: "span5", intTag: 50},
+				{traceID: "trace3", span: "span6", intTag: 60},
+			},
+			wantParts: 2,
+		},
+		{
+			name:         "Test with a time range covering one part",
+			minTimestamp: 2,
+			maxTimestamp: 3,
+			want: []span{
+				{traceID: "trace1", span: "span4", intTag: 40},
+				{traceID: "trace2", span: "span5", intTag: 50},
+				{traceID: "trace3", span: "span6", intTag: 60},
+			},
+			wantParts: 1,
+		},
+		{
+			name:         "Test with a time range covering no part",
+			minTimestamp: 3,
+			maxTimestamp: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpPath, defFn := test.Space(require.New(t))
+			defer defFn()
+			tst := &tsTable{
+				loopCloser:    run.NewCloser(2),
+				introductions: make(chan *introduction),
+				fileSystem:    fs.NewLocalFileSystem(),
+				root:          tmpPath,
+			}
+			tst.gc.init(tst)
+			flushCh := make(chan *flusherIntroduction)
+			mergeCh := make(chan *mergerIntroduction)
+			introducerWatcher := make(watcher.Channel, 1)
+			go tst.introducerLoop(flushCh, mergeCh, introducerWatcher, 1)
+			defer tst.Close()
+			for _, ts := range []*traces{tsTS1, tsTS2} {
+				tst.mustAddTraces(ts)
+				time.Sleep(100 * time.Millisecond)
+			}
+
+			var stats scanStats
+			var got []span
+			require.NoError(t, tst.scanSpans(&stats, []string{"intTag", "unknownTag"}, tt.minTimestamp, tt.maxTimestamp,
+				func(traceID string, s []byte, tags []*modelv1.Tag) {
+					require.Len(t, tags, 2)
+					assert.Equal(t, "unknownTag", tags[1].Key)
+					got = append(got, span{traceID: traceID, span: string(s), intTag: tags[0].Value.GetInt().GetValue()})
+				}))
+			assert.ElementsMatch(t, tt.want, got)
+			assert.Equal(t, tt.wantParts, stats.parts)
+		})
+	}
+}
@@ -23,6 +23,7 @@ import (
 
 	"github.com/pkg/errors"
 
+	"github.com/apache/skywalking-banyandb/api/data"
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
 	"github.com/apache/skywalking-banyandb/banyand/liaison/grpc"
@@ -113,8 +114,8 @@ func (l *liaison) PreRun(_ context.Context) error {
 		l.dataPath = path.Join(l.root, "trace-data")
 	}
 
-	// Initialize data node registry - this would be provided by the system
-	var traceDataNodeRegistry grpc.NodeRegistry
+	// Initialize data node registry
+	traceDataNodeRegistry := grpc.NewClusterNodeRegistry(data.TopicTracePartSync, l.option.tire2Client, l.dataNodeSelector)
 
 	// Initialize schema repository
 	l.schemaRepo = newLiaisonSchemaRepo(l.dataPath, l, traceDataNodeRegistry)
@@ -124,16 +125,13 @@ func (l *liaison) PreRun(_ context.Context) error {
 		Str("dataPath", l.dataPath).
 		Msg("trace liaison service initialized")
 
-	return nil
+	l.pipeline.RegisterChunkedSyncHandler(data.TopicTracePartSync, setUpChunkedSyncCallback(l.l, &l.schemaRepo))
+	return l.pipeline.Subscribe(data.TopicTraceWrite, setUpWriteQueueCallback(l.l, &l.schemaRepo, l.maxDiskUsagePercent, l.option.tire2Client))
 }
 
 func (l *liaison) Serve() run.StopNotify {
-	// As specified in the plan, no pipeline listeners should be implemented
 	l.l.Info().Msg("trace liaison service started")
-
-	// Return a channel that never closes since this service runs indefinitely
-	stopCh := make(chan struct{})
-	return stopCh
+	return l.schemaRepo.StopCh()
 }
 
 func (l *liaison) GracefulStop() {
@@ -188,3 +186,19 @@ func (l *liaison) SetFileSystem(lfs fs.FileSystem) {
 func (l *liaison) SetDataNodeSelector(selector node.Selector) {
 	l.dataNodeSelector = selector
 }
+
+// NewLiaison creates a new trace liaison service with the given dependencies.
+func NewLiaison(metadata metadata.Repo, pipeline queue.Server, omr observability.MetricsRegistry, pm protector.Memory,
+	dataNodeSelector node.Selector, tire2Client queue.Client,
+) (Service, error) {
+	return &liaison{
+		metadata:         metadata,
+		pipeline:         pipeline,
+		omr:              omr,
+		pm:               pm,
+		dataNodeSelector: dataNodeSelector,
+		option: option{
+			tire2Client: tire2Client,
+		},
+	}, nil
+}
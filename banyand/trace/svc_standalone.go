@@ -24,6 +24,8 @@ import (
 
 	"github.com/pkg/errors"
 
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/api/data"
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
 	"github.com/apache/skywalking-banyandb/banyand/metadata"
@@ -72,6 +74,14 @@ func (s *standalone) FlagSet() *run.FlagSet {
 	fs.StringVar(&s.root, "trace-root-path", "/tmp", "the root path for trace data")
 	fs.StringVar(&s.dataPath, "trace-data-path", "", "the path for trace data (optional)")
 	fs.DurationVar(&s.option.flushTimeout, "trace-flush-timeout", defaultFlushTimeout, "the timeout for trace data flush")
+	fs.BoolVar(&s.option.walEnabled, "trace-wal-enabled", false, "enable the write-ahead log of trace to recover the unflushed data after a crash")
+	fs.Var(&s.option.walOptions.SyncPolicy, "trace-wal-sync-policy", "the sync policy of the trace write-ahead log: always, interval or none")
+	fs.DurationVar(&s.option.walOptions.SyncInterval, "trace-wal-sync-interval", defaultWALSyncInterval,
+		"the sync interval of the trace write-ahead log if the sync policy is interval")
+	s.option.mergePolicy = newDefaultMergePolicy()
+	fs.VarP(&s.option.mergePolicy.maxFanOutSize, "trace-max-fan-out-size", "", "the upper bound of a single file size after merge of trace")
+	s.option.seriesCacheMaxSize = run.Bytes(32 << 20)
+	fs.VarP(&s.option.seriesCacheMaxSize, "trace-series-cache-max-size", "", "the max size of series cache in each group")
 	fs.IntVar(&s.maxDiskUsagePercent, "trace-max-disk-usage-percent", 95, "the maximum disk usage percentage")
 	fs.IntVar(&s.maxFileSnapshotNum, "trace-max-file-snapshot-num", 2, "the maximum number of file snapshots")
 	// Additional flags can be added here
@@ -93,7 +103,7 @@ func (s *standalone) Role() databasev1.Role {
 	return databasev1.Role_ROLE_DATA
 }
 
-func (s *standalone) PreRun(_ context.Context) error {
+func (s *standalone) PreRun(ctx context.Context) error {
 	s.l = logger.GetLogger("trace")
 
 	// Initialize metadata
@@ -123,6 +133,9 @@ func (s *standalone) PreRun(_ context.Context) error {
 
 	// Initialize schema repository
 	var nodeLabels map[string]string
+	if val := ctx.Value(common.ContextNodeKey); val != nil {
+		nodeLabels = val.(common.Node).Labels
+	}
 	s.schemaRepo = newSchemaRepo(s.dataPath, s, nodeLabels)
 
 	// Initialize snapshot directory
@@ -134,16 +147,19 @@ func (s *standalone) PreRun(_ context.Context) error {
 		Str("snapshotDir", s.snapshotDir).
 		Msg("trace standalone service initialized")
 
-	return nil
+	s.pipeline.RegisterChunkedSyncHandler(data.TopicTracePartSync, setUpChunkedSyncCallback(s.l, &s.schemaRepo))
+	if err := s.pipeline.Subscribe(data.TopicTraceWrite, setUpWriteCallback(s.l, &s.schemaRepo, s.maxDiskUsagePercent)); err != nil {
+		return err
+	}
+	if err := s.pipeline.Subscribe(data.TopicTraceQuery, setUpQueryCallback(s.l, &s.schemaRepo)); err != nil {
+		return err
+	}
+	return s.pipeline.Subscribe(data.TopicTraceGet, setUpGetTracesCallback(s.l, &s.schemaRepo))
 }
 
 func (s *standalone) Serve() run.StopNotify {
-	// As specified in the plan, no pipeline listeners should be implemented
 	s.l.Info().Msg("trace standalone service started")
-
-	// Return a channel that never closes since this service runs indefinitely
-	stopCh := make(chan struct{})
-	return stopCh
+	return s.schemaRepo.StopCh()
 }
 
 func (s *standalone) GracefulStop() {
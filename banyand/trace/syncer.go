@@ -180,7 +180,7 @@ func (tst *tsTable) syncSnapshot(curSnapshot *snapshot, syncCh chan *syncIntrodu
 				ID:                    part.partMetadata.ID,
 				Group:                 tst.group,
 				ShardID:               uint32(tst.shardID),
-				Topic:                 data.TopicStreamPartSync.String(),
+				Topic:                 data.TopicTracePartSync.String(),
 				Files:                 files,
 				CompressedSizeBytes:   part.partMetadata.CompressedSizeBytes,
 				UncompressedSizeBytes: part.partMetadata.UncompressedSpanSizeBytes,
@@ -22,6 +22,7 @@
 package trace
 
 import (
+	"slices"
 	"sync/atomic"
 	"time"
 
@@ -34,6 +35,7 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/run"
 	"github.com/apache/skywalking-banyandb/pkg/schema"
 	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+	"github.com/apache/skywalking-banyandb/pkg/wal"
 )
 
 const (
@@ -43,6 +45,8 @@ const (
 	maxUncompressedPrimaryBlockSize = 128 * 1024
 
 	defaultFlushTimeout = time.Second
+
+	defaultWALSyncInterval = time.Second
 )
 
 var traceScope = observability.RootScope.SubScope("trace")
@@ -52,8 +56,10 @@ type option struct {
 	protector                protector.Memory
 	tire2Client              queue.Client
 	seriesCacheMaxSize       run.Bytes
+	walOptions               wal.Options
 	flushTimeout             time.Duration
 	elementIndexFlushTimeout time.Duration
+	walEnabled               bool
 }
 
 // Service allows inspecting the trace data.
@@ -84,11 +90,19 @@ type indexSchema struct {
 }
 
 func (i *indexSchema) parse(schema *databasev1.Trace) {
-	// Note: This will need proper implementation when trace partition support is added
-	// For now, we'll just parse the tag map
 	i.tagMap = make(map[string]*databasev1.TraceTagSpec)
+	i.indexRuleLocators = make(map[string]*databasev1.IndexRule)
 	for _, tag := range schema.GetTags() {
 		i.tagMap[tag.GetName()] = tag
+		// Every tag owns a locator so that a written span can be checked against the schema,
+		// the locator is nil if the tag is not indexed.
+		i.indexRuleLocators[tag.GetName()] = nil
+		for _, rule := range i.indexRules {
+			if slices.Contains(rule.GetTags(), tag.GetName()) {
+				i.indexRuleLocators[tag.GetName()] = rule
+				break
+			}
+		}
 	}
 }
 
@@ -113,7 +127,7 @@ func (t *trace) GetSchema() *databasev1.Trace {
 
 func (t *trace) GetIndexRules() []*databasev1.IndexRule {
 	if is := t.indexSchema.Load(); is != nil {
-		return is.(*indexSchema).indexRules
+		return is.(indexSchema).indexRules
 	}
 	return nil
 }
@@ -38,6 +38,7 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/pool"
 	"github.com/apache/skywalking-banyandb/pkg/run"
 	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+	"github.com/apache/skywalking-banyandb/pkg/wal"
 	"github.com/apache/skywalking-banyandb/pkg/watcher"
 )
 
@@ -55,17 +56,22 @@ type tsTable struct {
 	getNodes      func() []string
 	option        option
 	introductions chan *introduction
+	wal           *wal.WAL
+	walPending    map[uint64]uint64
+	walMark       wal.Watermark
 	p             common.Position
 	root          string
 	group         string
 	gc            garbageCleaner
 	curPartID     uint64
 	sync.RWMutex
+	walMu   sync.Mutex
 	shardID common.ShardID
 }
 
 func (tst *tsTable) loadSnapshot(epoch uint64, loadedParts []uint64) {
-	parts := tst.mustReadSnapshot(epoch)
+	parts, walMark := tst.mustReadSnapshot(epoch)
+	tst.walMark = walMark
 	snp := snapshot{
 		epoch: epoch,
 	}
@@ -140,8 +146,22 @@ func parseEpoch(epochStr string) (uint64, error) {
 	return p, nil
 }
 
-func (tst *tsTable) mustWriteSnapshot(snapshot uint64, partNames []string) {
-	data, err := json.Marshal(partNames)
+// snapshotFile is the content of a snapshot file if the write-ahead log is enabled.
+// The watermark is persisted along with the part names, so that the records of the persisted mem parts
+// are skipped by the replay even if the write-ahead log isn't truncated before a crash.
+type snapshotFile struct {
+	WAL   *wal.Watermark `json:"wal"`
+	Parts []string       `json:"parts"`
+}
+
+func (tst *tsTable) mustWriteSnapshot(snapshot uint64, partNames []string, walMark *wal.Watermark) {
+	var data []byte
+	var err error
+	if walMark == nil {
+		data, err = json.Marshal(partNames)
+	} else {
+		data, err = json.Marshal(snapshotFile{Parts: partNames, WAL: walMark})
+	}
 	if err != nil {
 		logger.Panicf("cannot marshal partNames to JSON: %s", err)
 	}
@@ -159,16 +179,22 @@ func (tst *tsTable) mustWriteSnapshot(snapshot uint64, partNames []string) {
 	}
 }
 
-func (tst *tsTable) mustReadSnapshot(snapshot uint64) []uint64 {
+func (tst *tsTable) mustReadSnapshot(snapshot uint64) ([]uint64, wal.Watermark) {
 	snapshotPath := filepath.Join(tst.root, snapshotName(snapshot))
 	data, err := tst.fileSystem.Read(snapshotPath)
 	if err != nil {
 		logger.Panicf("cannot read %s: %s", snapshotPath, err)
 	}
-	var partNames []string
-	if err := json.Unmarshal(data, &partNames); err != nil {
+	var sf snapshotFile
+	if len(data) > 0 && data[0] == '{' {
+		err = json.Unmarshal(data, &sf)
+	} else {
+		err = json.Unmarshal(data, &sf.Parts)
+	}
+	if err != nil {
 		logger.Panicf("cannot parse %s: %s", snapshotPath, err)
 	}
+	partNames := sf.Parts
 	var result []uint64
 	for i := range partNames {
 		e, err := parseEpoch(partNames[i])
@@ -177,7 +203,11 @@ func (tst *tsTable) mustReadSnapshot(snapshot uint64) []uint64 {
 		}
 		result = append(result, e)
 	}
-	return result
+	var walMark wal.Watermark
+	if sf.WAL != nil {
+		walMark = *sf.WAL
+	}
+	return result, walMark
 }
 
 // initTSTable initializes a tsTable and loads parts/snapshots, but does not start any background loops.
@@ -210,6 +240,9 @@ func initTSTable(fileSystem fs.FileSystem, rootPath string, p common.Position,
 	var needToDelete []string
 	for i := range ee {
 		if ee[i].IsDir() {
+			if ee[i].Name() == walDirName {
+				continue
+			}
 			p, err := parseEpoch(ee[i].Name())
 			if err != nil {
 				l.Info().Err(err).Msg("cannot parse part file name. skip and delete it")
@@ -240,13 +273,18 @@ func initTSTable(fileSystem fs.FileSystem, rootPath string, p common.Position,
 		l.Info().Str("path", filepath.Join(rootPath, needToDelete[i])).Msg("delete invalid directory or file")
 		fileSystem.MustRMAll(filepath.Join(rootPath, needToDelete[i]))
 	}
-	if len(loadedParts) == 0 || len(loadedSnapshots) == 0 {
+	if len(loadedSnapshots) == 0 {
 		return &tst, uint64(time.Now().UnixNano())
 	}
 	sort.Slice(loadedSnapshots, func(i, j int) bool {
 		return loadedSnapshots[i] > loadedSnapshots[j]
 	})
 	epoch := loadedSnapshots[0]
+	if len(loadedParts) == 0 {
+		// The watermark of the write-ahead log is kept even if all the parts are removed.
+		_, tst.walMark = tst.mustReadSnapshot(epoch)
+		return &tst, uint64(time.Now().UnixNano())
+	}
 	tst.loadSnapshot(epoch, loadedParts)
 	return &tst, epoch
 }
@@ -256,6 +294,9 @@ func newTSTable(fileSystem fs.FileSystem, rootPath string, p common.Position,
 ) (*tsTable, error) {
 	t, epoch := initTSTable(fileSystem, rootPath, p, l, option, m)
 	t.startLoop(epoch)
+	if option.walEnabled {
+		t.mustOpenWAL()
+	}
 	return t, nil
 }
 
@@ -264,6 +305,7 @@ func (tst *tsTable) Close() error {
 		tst.loopCloser.Done()
 		tst.loopCloser.CloseThenWait()
 	}
+	tst.closeWAL()
 	tst.Lock()
 	defer tst.Unlock()
 	tst.deleteMetrics()
@@ -276,13 +318,21 @@ func (tst *tsTable) Close() error {
 }
 
 func (tst *tsTable) mustAddMemPart(mp *memPart) {
+	partID := atomic.AddUint64(&tst.curPartID, 1)
+	if !tst.mustWriteWAL(partID, mp) {
+		return
+	}
+	tst.introduceNewMemPart(mp, partID)
+}
+
+func (tst *tsTable) introduceNewMemPart(mp *memPart, partID uint64) {
 	p := openMemPart(mp)
 
 	ind := generateIntroduction()
 	defer releaseIntroduction(ind)
 	ind.applied = make(chan struct{})
 	ind.memPart = newPartWrapper(mp, p)
-	ind.memPart.p.partMetadata.ID = atomic.AddUint64(&tst.curPartID, 1)
+	ind.memPart.p.partMetadata.ID = partID
 	startTime := time.Now()
 	totalCount := mp.partMetadata.TotalCount
 	select {
@@ -19,6 +19,8 @@ package trace
 
 import (
 	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -27,11 +29,16 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/apache/skywalking-banyandb/api/common"
+	"github.com/apache/skywalking-banyandb/banyand/protector"
 	"github.com/apache/skywalking-banyandb/pkg/convert"
 	"github.com/apache/skywalking-banyandb/pkg/fs"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
 	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
 	"github.com/apache/skywalking-banyandb/pkg/run"
 	"github.com/apache/skywalking-banyandb/pkg/test"
+	"github.com/apache/skywalking-banyandb/pkg/test/flags"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
 	"github.com/apache/skywalking-banyandb/pkg/watcher"
 )
 
@@ -113,6 +120,81 @@ func Test_tsTable_mustAddTraces(t *testing.T) {
 	}
 }
 
+func Test_tsTable_walReplay(t *testing.T) {
+	tmpPath, defFn := test.Space(require.New(t))
+	defer defFn()
+	fileSystem := fs.NewLocalFileSystem()
+	opt := option{flushTimeout: 0, mergePolicy: newDefaultMergePolicyForTesting(), protector: protector.Nop{}, walEnabled: true}
+	// openWithoutFlusher opens a tsTable which never flushes its mem parts,
+	// they are dropped when the table is closed.
+	openWithoutFlusher := func() *tsTable {
+		tst, epoch := initTSTable(fileSystem, tmpPath, common.Position{}, logger.GetLogger("test"), opt, nil)
+		tst.loopCloser = run.NewCloser(2)
+		tst.introductions = make(chan *introduction)
+		go tst.introducerLoop(make(chan *flusherIntroduction), make(chan *mergerIntroduction), make(watcher.Channel, 1), epoch+1)
+		tst.mustOpenWAL()
+		return tst
+	}
+	countSpans := func(tst *tsTable) (total uint64, memParts int) {
+		s := tst.currentSnapshot()
+		if s == nil {
+			return 0, 0
+		}
+		defer s.decRef()
+		for _, pw := range s.parts {
+			total += pw.p.partMetadata.TotalCount
+			if pw.mp != nil {
+				memParts++
+			}
+		}
+		return total, memParts
+	}
+
+	tst := openWithoutFlusher()
+	tst.mustAddTraces(tsTS1)
+	tst.mustAddTraces(tsTS2)
+	want, memParts := countSpans(tst)
+	require.Positive(t, want)
+	require.Equal(t, 2, memParts)
+	require.NoError(t, tst.Close())
+
+	// The replayed mem parts are introduced with new IDs.
+	tst = openWithoutFlusher()
+	total, memParts := countSpans(tst)
+	assert.Equal(t, want, total)
+	assert.Equal(t, 2, memParts)
+	require.NoError(t, tst.Close())
+
+	// Keep the records of the mem parts to simulate a crash after they're persisted but before the WAL is truncated.
+	walDir := filepath.Join(tmpPath, walDirName)
+	walBackup := t.TempDir()
+	require.NoError(t, os.CopyFS(walBackup, os.DirFS(walDir)))
+
+	// The replayed mem parts are flushed, then the WAL is truncated.
+	tst, err := newTSTable(fileSystem, tmpPath, common.Position{}, logger.GetLogger("test"), timestamp.TimeRange{}, opt, nil)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		total, memParts = countSpans(tst)
+		return total == want && memParts == 0
+	}, flags.EventuallyTimeout, 100*time.Millisecond)
+	require.NoError(t, tst.Close())
+
+	tst = openWithoutFlusher()
+	total, memParts = countSpans(tst)
+	assert.Equal(t, want, total)
+	assert.Zero(t, memParts)
+	require.NoError(t, tst.Close())
+
+	// The watermark of the snapshot skips the records of the persisted mem parts.
+	require.NoError(t, os.RemoveAll(walDir))
+	require.NoError(t, os.CopyFS(walDir, os.DirFS(walBackup)))
+	tst = openWithoutFlusher()
+	defer tst.Close()
+	total, memParts = countSpans(tst)
+	assert.Equal(t, want, total)
+	assert.Zero(t, memParts)
+}
+
 func Test_tstIter(t *testing.T) {
 	type testCtx struct {
 		wantErr      error
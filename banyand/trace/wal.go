@@ -0,0 +1,164 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package trace
+
+import (
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/apache/skywalking-banyandb/pkg/wal"
+)
+
+const walDirName = "wal"
+
+// mustOpenWAL opens the write-ahead log of the tsTable and introduces the mem parts
+// which were not persisted before the last shutdown, according to the watermark of the loaded snapshot.
+func (tst *tsTable) mustOpenWAL() {
+	w, err := wal.Open(filepath.Join(tst.root, walDirName), tst.option.walOptions)
+	if err != nil {
+		tst.l.Panic().Err(err).Str("root", tst.root).Msg("cannot open the write-ahead log")
+	}
+	tst.wal = w
+	tst.walPending = make(map[uint64]uint64)
+	type record struct {
+		data []byte
+		lsn  uint64
+	}
+	// Collect the records first, the introducer might truncate the WAL while introducing them.
+	var records []record
+	if err = w.Replay(func(lsn uint64, data []byte) error {
+		records = append(records, record{lsn: lsn, data: append([]byte(nil), data...)})
+		return nil
+	}); err != nil {
+		tst.l.Panic().Err(err).Str("root", tst.root).Msg("cannot replay the write-ahead log")
+	}
+	tst.walMu.Lock()
+	mark := tst.walMark
+	tst.walMu.Unlock()
+	var replayed int
+	for i := range records {
+		if mark.Covers(records[i].lsn) {
+			continue
+		}
+		mp := generateMemPart()
+		if err = mp.Unmarshal(records[i].data); err != nil {
+			tst.l.Panic().Err(err).Uint64("lsn", records[i].lsn).Msg("cannot unmarshal the mem part in the write-ahead log")
+		}
+		partID := atomic.AddUint64(&tst.curPartID, 1)
+		tst.walMu.Lock()
+		tst.walPending[partID] = records[i].lsn
+		tst.walMu.Unlock()
+		tst.introduceNewMemPart(mp, partID)
+		replayed++
+	}
+	if replayed > 0 {
+		tst.l.Info().Int("count", replayed).Str("root", tst.root).Msg("replayed the write-ahead log")
+	}
+}
+
+// mustWriteWAL appends the mem part to the write-ahead log.
+// It returns false if the WAL is closed.
+func (tst *tsTable) mustWriteWAL(partID uint64, mp *memPart) bool {
+	if tst.wal == nil {
+		return true
+	}
+	// Register a lower bound of the LSN, so that the record can't be truncated before it's persisted.
+	tst.walMu.Lock()
+	tst.walPending[partID] = tst.wal.NextLSN()
+	tst.walMu.Unlock()
+
+	data, err := mp.Marshal()
+	if err != nil {
+		tst.l.Panic().Err(err).Msg("cannot marshal the mem part")
+	}
+	lsn, err := tst.wal.Write(data)
+
+	tst.walMu.Lock()
+	defer tst.walMu.Unlock()
+	if err != nil {
+		delete(tst.walPending, partID)
+		if errors.Is(err, wal.ErrClosed) {
+			return false
+		}
+		tst.l.Panic().Err(err).Str("root", tst.root).Msg("cannot write the write-ahead log")
+	}
+	tst.walPending[partID] = lsn
+	return true
+}
+
+// advanceWALMark removes the persisted mem parts from the pending ones, and advances the watermark
+// which is persisted along with the next snapshot. It returns false if no mem part is persisted.
+// The mem parts might be merged into a part with another ID, so the watermark tracks their LSNs instead of their IDs.
+func (tst *tsTable) advanceWALMark(isPersisted func(partID uint64) bool) bool {
+	if tst.wal == nil {
+		return false
+	}
+	tst.walMu.Lock()
+	defer tst.walMu.Unlock()
+	var persisted []uint64
+	for partID, lsn := range tst.walPending {
+		if isPersisted(partID) {
+			delete(tst.walPending, partID)
+			persisted = append(persisted, lsn)
+		}
+	}
+	if len(persisted) == 0 {
+		return false
+	}
+	lowest := tst.wal.NextLSN()
+	for _, lsn := range tst.walPending {
+		lowest = min(lowest, lsn)
+	}
+	tst.walMark = tst.walMark.Advance(persisted, lowest)
+	return true
+}
+
+// walMarkToPersist returns the watermark persisted along with the snapshots, or nil if the write-ahead log is disabled.
+func (tst *tsTable) walMarkToPersist() *wal.Watermark {
+	if !tst.option.walEnabled {
+		return nil
+	}
+	tst.walMu.Lock()
+	defer tst.walMu.Unlock()
+	mark := tst.walMark
+	return &mark
+}
+
+// truncateWAL removes the records below the watermark from the write-ahead log.
+// The snapshot of epoch must hold the watermark, which is synced before the truncation.
+// A crash before the truncation leaves the records, which are skipped by the replay.
+func (tst *tsTable) truncateWAL(epoch uint64) {
+	tst.fileSystem.SyncPath(filepath.Join(tst.root, snapshotName(epoch)))
+	tst.fileSystem.SyncPath(tst.root)
+	tst.walMu.Lock()
+	bound := tst.walMark.LSN
+	tst.walMu.Unlock()
+	if err := tst.wal.Truncate(bound); err != nil {
+		tst.l.Warn().Err(err).Str("root", tst.root).Msg("cannot truncate the write-ahead log")
+	}
+}
+
+func (tst *tsTable) closeWAL() {
+	if tst.wal == nil {
+		return
+	}
+	if err := tst.wal.Close(); err != nil {
+		tst.l.Warn().Err(err).Str("root", tst.root).Msg("cannot close the write-ahead log")
+	}
+}
@@ -274,11 +274,22 @@ func encodeTagValue(name string, tagType databasev1.TagType, tagVal *modelv1.Tag
 		if tagVal.GetInt() != nil {
 			tv.value = convert.Int64ToBytes(tagVal.GetInt().GetValue())
 		}
+	case databasev1.TagType_TAG_TYPE_FLOAT:
+		tv.valueType = pbv1.ValueTypeFloat64
+		if tagVal.GetFloat() != nil {
+			tv.value = convert.Float64ToBytes(tagVal.GetFloat().GetValue())
+		}
 	case databasev1.TagType_TAG_TYPE_STRING:
 		tv.valueType = pbv1.ValueTypeStr
 		if tagVal.GetStr() != nil {
 			tv.value = convert.StringToBytes(tagVal.GetStr().GetValue())
 		}
+	case databasev1.TagType_TAG_TYPE_TIMESTAMP:
+		// The trace storage has no value type for timestamps, so they are stored as nanoseconds.
+		tv.valueType = pbv1.ValueTypeInt64
+		if tagVal.GetTimestamp() != nil {
+			tv.value = convert.Int64ToBytes(tagVal.GetTimestamp().AsTime().UnixNano())
+		}
 	case databasev1.TagType_TAG_TYPE_DATA_BINARY:
 		tv.valueType = pbv1.ValueTypeBinaryData
 		if tagVal.GetBinaryData() != nil {
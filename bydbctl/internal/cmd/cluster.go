@@ -0,0 +1,124 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"github.com/go-resty/resty/v2"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/pkg/version"
+)
+
+const (
+	clusterShardsPath    = "/api/v1/cluster/shards"
+	clusterRebalancePath = "/api/v1/cluster/rebalance"
+	clusterRepairPath    = "/api/v1/cluster/repair"
+)
+
+func newClusterCmd() *cobra.Command {
+	clusterCmd := &cobra.Command{
+		Use:     "cluster",
+		Version: version.Build(),
+		Short:   "Cluster management",
+	}
+
+	shardsCmd := &cobra.Command{
+		Use:     "shards [group]",
+		Version: version.Build(),
+		Short:   "List the data nodes the shards of the stream and measure groups are assigned to",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return rest(nil, func(request request) (*resty.Response, error) {
+				if len(args) > 0 {
+					request.req.SetQueryParam("group", args[0])
+				}
+				return request.req.Get(getPath(clusterShardsPath))
+			}, yamlPrinter, enableTLS, insecure, cert)
+		},
+	}
+
+	var groups []string
+	var dryRun bool
+	rebalance := func(drainNodes []string) error {
+		return rest(nil, func(request request) (*resty.Response, error) {
+			b, err := protojson.Marshal(&databasev1.ClusterServiceRebalanceRequest{
+				Groups:     groups,
+				DrainNodes: drainNodes,
+				DryRun:     dryRun,
+			})
+			if err != nil {
+				return nil, err
+			}
+			return request.req.SetBody(b).Post(getPath(clusterRebalancePath))
+		}, yamlPrinter, enableTLS, insecure, cert)
+	}
+	rebalanceCmd := &cobra.Command{
+		Use:     "rebalance",
+		Version: version.Build(),
+		Short:   "Move the shards to balance them across the data nodes",
+		Long: "Move the copies of the shards to balance them across the available data nodes with the minimal movement. " +
+			"The moves are printed with the errors of the failed ones, which are planned again by the next rebalancing.",
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return rebalance(nil)
+		},
+	}
+	drainCmd := &cobra.Command{
+		Use:     "drain <node>...",
+		Version: version.Build(),
+		Short:   "Move the shards off the data nodes",
+		Long: "Move the copies of the shards off the data nodes to the other available data nodes, " +
+			"so that the drained nodes can be removed from the cluster.",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return rebalance(args)
+		},
+	}
+	for _, c := range []*cobra.Command{rebalanceCmd, drainCmd} {
+		c.Flags().StringSliceVar(&groups, "groups", nil, "The groups to rebalance. All stream and measure groups are rebalanced if absent")
+		c.Flags().BoolVar(&dryRun, "dry-run", false, "Print the moves without applying them")
+	}
+
+	var repairGroups []string
+	repairCmd := &cobra.Command{
+		Use:     "repair",
+		Version: version.Build(),
+		Short:   "Repair the replicas of the shards",
+		Long: "Compare the data of the available copies of the shards and send the missing data to each other. " +
+			"The repairs run in the background on the data nodes, the errors of the ones failing to start are printed.",
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return rest(nil, func(request request) (*resty.Response, error) {
+				b, err := protojson.Marshal(&databasev1.ClusterServiceRepairRequest{
+					Groups: repairGroups,
+				})
+				if err != nil {
+					return nil, err
+				}
+				return request.req.SetBody(b).Post(getPath(clusterRepairPath))
+			}, yamlPrinter, enableTLS, insecure, cert)
+		},
+	}
+	repairCmd.Flags().StringSliceVar(&repairGroups, "groups", nil, "The groups to repair. All stream and measure groups are repaired if absent")
+
+	clusterCmd.AddCommand(shardsCmd, rebalanceCmd, drainCmd, repairCmd)
+	bindTLSRelatedFlag(shardsCmd, rebalanceCmd, drainCmd, repairCmd)
+	return clusterCmd
+}
@@ -0,0 +1,75 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/apache/skywalking-banyandb/pkg/auth"
+	"github.com/apache/skywalking-banyandb/pkg/version"
+)
+
+func newPasswordCmd() *cobra.Command {
+	passwordCmd := &cobra.Command{
+		Use:     "password",
+		Version: version.Build(),
+		Short:   "Password management",
+	}
+
+	var algorithm string
+	hashCmd := &cobra.Command{
+		Use:     "hash [password]",
+		Version: version.Build(),
+		Short:   "Hash a password for the server's auth config file",
+		Long: "Hash a password for the server's auth config file. " +
+			"The password is read from the standard input if it's not provided as an argument, " +
+			"which keeps it out of the shell history.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var plain string
+			if len(args) > 0 {
+				plain = args[0]
+			} else {
+				line, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+				if err != nil && line == "" {
+					return fmt.Errorf("failed to read the password: %w", err)
+				}
+				plain = strings.TrimRight(line, "\r\n")
+			}
+			if plain == "" {
+				return errors.New("password is empty")
+			}
+			hash, err := auth.HashPassword(plain, algorithm)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), hash)
+			return nil
+		},
+	}
+	hashCmd.Flags().StringVar(&algorithm, "algorithm", auth.AlgorithmBcrypt,
+		fmt.Sprintf("the hash algorithm, either %s or %s", auth.AlgorithmBcrypt, auth.AlgorithmArgon2id))
+
+	passwordCmd.AddCommand(hashCmd)
+	return passwordCmd
+}
@@ -0,0 +1,52 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"github.com/go-resty/resty/v2"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	bydbqlv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/bydbql/v1"
+	"github.com/apache/skywalking-banyandb/pkg/version"
+)
+
+const bydbQLQueryPath = "/api/v1/bydbql/query"
+
+func newQueryCmd() *cobra.Command {
+	queryCmd := &cobra.Command{
+		Use:     "query \"<BydbQL>\"",
+		Version: version.Build(),
+		Short:   "Query data by a BydbQL statement",
+		Long: "Query the streams, measures, top-n aggregations, traces and properties by a BydbQL statement. " +
+			"The time range is the last 30 minutes if the statement has no TIME clause. For example:\n\n" +
+			"  bydbctl query \"SELECT id, SUM(total) FROM MEASURE service_cpm_minute IN sw_metric TIME > '-1h' GROUP BY id\"",
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return rest(nil, func(request request) (*resty.Response, error) {
+				b, err := protojson.Marshal(&bydbqlv1.QueryRequest{Query: args[0]})
+				if err != nil {
+					return nil, err
+				}
+				return request.req.SetBody(b).Post(getPath(bydbQLQueryPath))
+			}, yamlPrinter, enableTLS, insecure, cert)
+		},
+	}
+	bindTLSRelatedFlag(queryCmd)
+	return queryCmd
+}
@@ -0,0 +1,89 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd_test
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+	"github.com/zenizh/go-capturer"
+	grpclib "google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	bydbqlv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/bydbql/v1"
+	"github.com/apache/skywalking-banyandb/bydbctl/internal/cmd"
+	"github.com/apache/skywalking-banyandb/pkg/test/flags"
+	"github.com/apache/skywalking-banyandb/pkg/test/helpers"
+	"github.com/apache/skywalking-banyandb/pkg/test/setup"
+	cases_measure_data "github.com/apache/skywalking-banyandb/test/cases/measure/data"
+)
+
+var _ = Describe("BydbQL Query", func() {
+	var addr string
+	var deferFunc func()
+	var rootCmd *cobra.Command
+	var timeClause string
+	BeforeEach(func() {
+		now, err := time.ParseInLocation("2006-01-02T15:04:05", "2021-09-01T23:30:00", time.Local)
+		Expect(err).NotTo(HaveOccurred())
+		timeClause = fmt.Sprintf("TIME BETWEEN '%s' AND '%s'",
+			now.Add(-20*time.Minute).Format(time.RFC3339), now.Add(5*time.Minute).Format(time.RFC3339))
+		var grpcAddr string
+		grpcAddr, addr, deferFunc = setup.Standalone()
+		addr = httpSchema + addr
+		rootCmd = &cobra.Command{Use: "root"}
+		cmd.RootCmdFlags(rootCmd)
+		conn, err := grpclib.NewClient(
+			grpcAddr,
+			grpclib.WithTransportCredentials(insecure.NewCredentials()),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		cases_measure_data.Write(conn, "service_cpm_minute", "sw_metric", "service_cpm_minute_data.json", now, time.Millisecond)
+	})
+
+	query := func(ql string) func() int {
+		return func() int {
+			rootCmd.SetArgs([]string{"query", "-a", addr, ql})
+			out := capturer.CaptureStdout(func() {
+				err := rootCmd.Execute()
+				Expect(err).NotTo(HaveOccurred())
+			})
+			GinkgoWriter.Println(out)
+			resp := new(bydbqlv1.QueryResponse)
+			helpers.UnmarshalYAML([]byte(out), resp)
+			return len(resp.GetMeasureResult().GetDataPoints())
+		}
+	}
+
+	It("queries the data points of a measure", func() {
+		Eventually(query("SELECT id, total FROM MEASURE service_cpm_minute IN sw_metric "+timeClause),
+			flags.EventuallyTimeout).Should(Equal(6))
+	})
+
+	It("aggregates the data points of a measure by a tag", func() {
+		Eventually(query("SELECT id, SUM(total) FROM MEASURE service_cpm_minute IN sw_metric "+timeClause+" GROUP BY id"),
+			flags.EventuallyTimeout).Should(Equal(3))
+	})
+
+	AfterEach(func() {
+		deferFunc()
+	})
+})
@@ -0,0 +1,66 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashPassword(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmBcrypt, AlgorithmArgon2id} {
+		t.Run(algorithm, func(t *testing.T) {
+			hash, err := HashPassword("secret", algorithm)
+			require.NoError(t, err)
+			assert.True(t, IsHashedPassword(hash))
+
+			ok, err := VerifyPassword(hash, "secret")
+			require.NoError(t, err)
+			assert.True(t, ok)
+			ok, err = VerifyPassword(hash, "wrong")
+			require.NoError(t, err)
+			assert.False(t, ok)
+
+			another, err := HashPassword("secret", algorithm)
+			require.NoError(t, err)
+			assert.NotEqual(t, hash, another, "the hashes should be salted")
+		})
+	}
+}
+
+func TestHashPasswordUnsupportedAlgorithm(t *testing.T) {
+	_, err := HashPassword("secret", "md5")
+	assert.Error(t, err)
+}
+
+func TestVerifyPlaintextPassword(t *testing.T) {
+	assert.False(t, IsHashedPassword("secret"))
+	ok, err := VerifyPassword("secret", "secret")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = VerifyPassword("secret", "secre")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestVerifyInvalidArgon2idHash(t *testing.T) {
+	_, err := VerifyPassword("$argon2id$v=19$m=65536,t=1,p=4$invalid", "secret")
+	assert.Error(t, err)
+}
@@ -0,0 +1,211 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package bydbql implements BydbQL, a SQL-like query language which is compiled into
+// the query requests of streams, measures, top-n aggregations, traces and properties.
+package bydbql
+
+import (
+	"strconv"
+	"time"
+)
+
+// Kind is the kind of the resource a query selects from.
+type Kind string
+
+// The kinds of the resources.
+const (
+	KindStream   Kind = "STREAM"
+	KindMeasure  Kind = "MEASURE"
+	KindTopN     Kind = "TOPN"
+	KindTrace    Kind = "TRACE"
+	KindProperty Kind = "PROPERTY"
+)
+
+// Explain is how a query is explained.
+type Explain string
+
+// The explain modes, which are empty if the query isn't explained.
+const (
+	// ExplainPlan returns the plan of the query without executing it.
+	ExplainPlan Explain = "EXPLAIN"
+	// ExplainAnalyze executes the query, and returns the plan with the runtime statistics of each operator.
+	ExplainAnalyze Explain = "EXPLAIN ANALYZE"
+)
+
+// Query is a parsed BydbQL statement.
+type Query struct {
+	Where   Expr
+	Having  Expr
+	Time    *TimeCondition
+	GroupBy *GroupBy
+	OrderBy *OrderBy
+	Kind    Kind
+	Explain Explain
+	Name    string
+	// AggregateBy is the aggregation function of a top-n query.
+	AggregateBy string
+	Groups      []string
+	// Projection is empty if all tags and fields are selected by "*".
+	Projection []Projection
+	// TopN is the number of the items a top-n query selects.
+	TopN   int32
+	Limit  uint32
+	Offset uint32
+	// Bottom indicates a top-n query selects the smallest items.
+	Bottom bool
+	// Trace enables the tracing of the query execution.
+	Trace bool
+}
+
+// countAll is the name of the projection COUNT(*), which counts the data points.
+const countAll = "*"
+
+// Projection is a selected tag or field, or an aggregation of a field.
+type Projection struct {
+	Name string
+	// Func is the aggregation function, which is empty for a tag or a field.
+	Func  string
+	Alias string
+}
+
+// TimePoint is an absolute time, or a time relative to the time the query is compiled.
+type TimePoint struct {
+	Time     time.Time
+	Offset   time.Duration
+	Relative bool
+}
+
+// Resolve returns the absolute time.
+func (t TimePoint) Resolve(now time.Time) time.Time {
+	if t.Relative {
+		return now.Add(t.Offset)
+	}
+	return t.Time
+}
+
+// TimeCondition is the time range of a query.
+// A nil Begin or End is bounded by the default window of the other side.
+type TimeCondition struct {
+	Begin *TimePoint
+	End   *TimePoint
+	// BeginExclusive is true for "TIME > t".
+	BeginExclusive bool
+	// EndExclusive is true for "TIME < t".
+	EndExclusive bool
+}
+
+// GroupBy groups the data points by tags and an optional time bucket.
+type GroupBy struct {
+	// TimeBucket is the duration of the time buckets, like "5m".
+	TimeBucket string
+	Tags       []string
+}
+
+// OrderBy sorts the results by the time, a tag, a field or an aggregation.
+type OrderBy struct {
+	// Name is empty if the results are sorted by the time.
+	Name string
+	Desc bool
+	// Asc and Desc are both false if the direction is absent.
+	Asc bool
+}
+
+// Expr is a filter expression.
+type Expr interface {
+	expr()
+}
+
+// LogicalOp combines two expressions.
+type LogicalOp string
+
+// The logical operators.
+const (
+	OpAnd LogicalOp = "AND"
+	OpOr  LogicalOp = "OR"
+)
+
+// Logical is a logical combination of two expressions.
+type Logical struct {
+	Left  Expr
+	Right Expr
+	Op    LogicalOp
+}
+
+// The comparison operators of the conditions.
+const (
+	CmpEQ        = "="
+	CmpNE        = "!="
+	CmpLT        = "<"
+	CmpGT        = ">"
+	CmpLE        = "<="
+	CmpGE        = ">="
+	CmpIn        = "IN"
+	CmpNotIn     = "NOT IN"
+	CmpHaving    = "HAVING"
+	CmpNotHaving = "NOT HAVING"
+	CmpMatch     = "MATCH"
+	CmpPrefix    = "PREFIX"
+	CmpWildcard  = "WILDCARD"
+	CmpRegex     = "REGEX"
+	CmpSubtree   = "SUBTREE"
+	CmpAncestors = "ANCESTORS"
+)
+
+// Condition compares a tag, or an aggregation in the HAVING clause, with the values.
+type Condition struct {
+	Name string
+	Op   string
+	// Values has a single value unless Op takes a list.
+	Values []Value
+}
+
+func (*Logical) expr() {}
+
+func (*Condition) expr() {}
+
+// ValueKind is the kind of a literal value.
+type ValueKind int
+
+// The kinds of the literal values.
+const (
+	ValueString ValueKind = iota
+	ValueInt
+	ValueFloat
+	ValueNull
+)
+
+// Value is a literal value.
+type Value struct {
+	Str   string
+	Int   int64
+	Float float64
+	Kind  ValueKind
+}
+
+// String returns the text of the value.
+func (v Value) String() string {
+	switch v.Kind {
+	case ValueInt:
+		return strconv.FormatInt(v.Int, 10)
+	case ValueFloat:
+		return strconv.FormatFloat(v.Float, 'g', -1, 64)
+	case ValueNull:
+		return "NULL"
+	}
+	return v.Str
+}
@@ -0,0 +1,771 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bydbql
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	propertyv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/property/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	tracev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/trace/v1"
+)
+
+// DefaultTimeRange is the length of the time range bounded on one side or not bounded.
+const DefaultTimeRange = 30 * time.Minute
+
+// propertyIDName is the pseudo tag selecting the properties by their IDs.
+const propertyIDName = "id"
+
+// ErrCompile indicates the query doesn't match the schema of the resource.
+var ErrCompile = errors.New("failed to compile the query")
+
+var aggregationFunctions = map[string]modelv1.AggregationFunction{
+	"SUM":            modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM,
+	"MEAN":           modelv1.AggregationFunction_AGGREGATION_FUNCTION_MEAN,
+	"AVG":            modelv1.AggregationFunction_AGGREGATION_FUNCTION_MEAN,
+	"MAX":            modelv1.AggregationFunction_AGGREGATION_FUNCTION_MAX,
+	"MIN":            modelv1.AggregationFunction_AGGREGATION_FUNCTION_MIN,
+	"COUNT":          modelv1.AggregationFunction_AGGREGATION_FUNCTION_COUNT,
+	"P50":            modelv1.AggregationFunction_AGGREGATION_FUNCTION_P50,
+	"P90":            modelv1.AggregationFunction_AGGREGATION_FUNCTION_P90,
+	"P99":            modelv1.AggregationFunction_AGGREGATION_FUNCTION_P99,
+	"DISTINCT_COUNT": modelv1.AggregationFunction_AGGREGATION_FUNCTION_DISTINCT_COUNT,
+}
+
+var binaryOps = map[string]modelv1.Condition_BinaryOp{
+	CmpEQ:        modelv1.Condition_BINARY_OP_EQ,
+	CmpNE:        modelv1.Condition_BINARY_OP_NE,
+	CmpLT:        modelv1.Condition_BINARY_OP_LT,
+	CmpGT:        modelv1.Condition_BINARY_OP_GT,
+	CmpLE:        modelv1.Condition_BINARY_OP_LE,
+	CmpGE:        modelv1.Condition_BINARY_OP_GE,
+	CmpIn:        modelv1.Condition_BINARY_OP_IN,
+	CmpNotIn:     modelv1.Condition_BINARY_OP_NOT_IN,
+	CmpHaving:    modelv1.Condition_BINARY_OP_HAVING,
+	CmpNotHaving: modelv1.Condition_BINARY_OP_NOT_HAVING,
+	CmpMatch:     modelv1.Condition_BINARY_OP_MATCH,
+	CmpPrefix:    modelv1.Condition_BINARY_OP_PREFIX,
+	CmpWildcard:  modelv1.Condition_BINARY_OP_WILDCARD,
+	CmpRegex:     modelv1.Condition_BINARY_OP_REGEX,
+	CmpSubtree:   modelv1.Condition_BINARY_OP_SUBTREE,
+	CmpAncestors: modelv1.Condition_BINARY_OP_ANCESTORS,
+}
+
+// tagLocator locates a tag in the tag families.
+type tagLocator struct {
+	family string
+	typ    databasev1.TagType
+}
+
+type tagSchema struct {
+	tags     map[string]tagLocator
+	families []string
+}
+
+func newTagSchema(families []*databasev1.TagFamilySpec) *tagSchema {
+	s := &tagSchema{tags: make(map[string]tagLocator)}
+	for _, f := range families {
+		s.families = append(s.families, f.GetName())
+		for _, t := range f.GetTags() {
+			s.tags[t.GetName()] = tagLocator{family: f.GetName(), typ: t.GetType()}
+		}
+	}
+	return s
+}
+
+func (s *tagSchema) tagType(name string) (databasev1.TagType, bool) {
+	l, ok := s.tags[name]
+	return l.typ, ok
+}
+
+// projection returns the projection of the tags in the order of the families, or all tags if names is empty.
+func (s *tagSchema) projection(names []string, all []*databasev1.TagFamilySpec) *modelv1.TagProjection {
+	tp := &modelv1.TagProjection{}
+	byFamily := make(map[string]*modelv1.TagProjection_TagFamily)
+	add := func(family, tag string) {
+		f, ok := byFamily[family]
+		if !ok {
+			f = &modelv1.TagProjection_TagFamily{Name: family}
+			byFamily[family] = f
+		}
+		for _, t := range f.Tags {
+			if t == tag {
+				return
+			}
+		}
+		f.Tags = append(f.Tags, tag)
+	}
+	if len(names) == 0 {
+		for _, f := range all {
+			for _, t := range f.GetTags() {
+				add(f.GetName(), t.GetName())
+			}
+		}
+	}
+	for _, n := range names {
+		add(s.tags[n].family, n)
+	}
+	for _, family := range s.families {
+		if f, ok := byFamily[family]; ok {
+			tp.TagFamilies = append(tp.TagFamilies, f)
+		}
+	}
+	return tp
+}
+
+func (q *Query) expectKind(kind Kind) error {
+	if q.Kind != kind {
+		return errors.WithMessagef(ErrCompile, "the query selects from a %s rather than a %s", q.Kind, kind)
+	}
+	return nil
+}
+
+// unsupported returns an error if the query has one of the clauses.
+func (q *Query) unsupported(clauses ...string) error {
+	present := map[string]bool{
+		"TIME":         q.Time != nil,
+		"GROUP BY":     q.GroupBy != nil,
+		"HAVING":       q.Having != nil,
+		"ORDER BY":     q.OrderBy != nil,
+		"OFFSET":       q.Offset > 0,
+		"AGGREGATE BY": q.AggregateBy != "",
+		"EXPLAIN":      q.Explain != "",
+	}
+	for _, c := range clauses {
+		if present[c] {
+			return errors.WithMessagef(ErrCompile, "a %s query doesn't support the %s clause", q.Kind, c)
+		}
+	}
+	return nil
+}
+
+// explainMode returns the explain mode of the compiled request.
+func (q *Query) explainMode() commonv1.ExplainMode {
+	switch q.Explain {
+	case ExplainPlan:
+		return commonv1.ExplainMode_EXPLAIN_MODE_PLAN
+	case ExplainAnalyze:
+		return commonv1.ExplainMode_EXPLAIN_MODE_ANALYZE
+	}
+	return commonv1.ExplainMode_EXPLAIN_MODE_UNSPECIFIED
+}
+
+// TimeRange returns the time range of the query, which is [begin, end).
+// The range bounded only by the beginning ends at now, and the range bounded only by the end
+// or not bounded lasts DefaultTimeRange.
+func (q *Query) TimeRange(now time.Time) *modelv1.TimeRange {
+	begin, end := now.Add(-DefaultTimeRange), now
+	if tc := q.Time; tc != nil {
+		switch {
+		case tc.Begin != nil && tc.End != nil:
+			begin, end = tc.Begin.Resolve(now), tc.End.Resolve(now)
+		case tc.Begin != nil:
+			begin = tc.Begin.Resolve(now)
+		case tc.End != nil:
+			end = tc.End.Resolve(now)
+			begin = end.Add(-DefaultTimeRange)
+		}
+		if tc.BeginExclusive {
+			begin = begin.Add(time.Nanosecond)
+		}
+		// The end is exclusive in the time range, so that an inclusive end is moved forward.
+		if tc.End != nil && !tc.EndExclusive {
+			end = end.Add(time.Nanosecond)
+		}
+	}
+	return &modelv1.TimeRange{Begin: timestamppb.New(begin), End: timestamppb.New(end)}
+}
+
+func (q *Query) plainProjection() ([]string, error) {
+	names := make([]string, 0, len(q.Projection))
+	for _, p := range q.Projection {
+		if p.Func != "" {
+			return nil, errors.WithMessagef(ErrCompile, "a %s query doesn't support the aggregation %s(%s)", q.Kind, p.Func, p.Name)
+		}
+		names = append(names, p.Name)
+	}
+	return names, nil
+}
+
+func checkTags(names []string, tagType func(string) (databasev1.TagType, bool)) error {
+	for _, n := range names {
+		if _, ok := tagType(n); !ok {
+			return errors.WithMessagef(ErrCompile, "unknown tag %s", n)
+		}
+	}
+	return nil
+}
+
+// queryOrder sorts by the index rule containing the tag, or by the time if the name is empty.
+func (q *Query) queryOrder(rules []*databasev1.IndexRule) (*modelv1.QueryOrder, error) {
+	ob := q.OrderBy
+	if ob == nil {
+		return nil, nil
+	}
+	order := &modelv1.QueryOrder{Sort: sortOf(ob, modelv1.Sort_SORT_UNSPECIFIED)}
+	if ob.Name == "" {
+		return order, nil
+	}
+	for _, r := range rules {
+		for _, t := range r.GetTags() {
+			if t == ob.Name {
+				order.IndexRuleName = r.GetMetadata().GetName()
+				return order, nil
+			}
+		}
+	}
+	return nil, errors.WithMessagef(ErrCompile, "%s can't be sorted, since no index rule contains it", ob.Name)
+}
+
+func sortOf(ob *OrderBy, absent modelv1.Sort) modelv1.Sort {
+	switch {
+	case ob.Asc:
+		return modelv1.Sort_SORT_ASC
+	case ob.Desc:
+		return modelv1.Sort_SORT_DESC
+	}
+	return absent
+}
+
+// StreamRequest compiles the query into a stream query request.
+func (q *Query) StreamRequest(stream *databasev1.Stream, rules []*databasev1.IndexRule, now time.Time) (*streamv1.QueryRequest, error) {
+	if err := q.expectKind(KindStream); err != nil {
+		return nil, err
+	}
+	if err := q.unsupported("GROUP BY", "HAVING", "AGGREGATE BY"); err != nil {
+		return nil, err
+	}
+	names, err := q.plainProjection()
+	if err != nil {
+		return nil, err
+	}
+	schema := newTagSchema(stream.GetTagFamilies())
+	if err = checkTags(names, schema.tagType); err != nil {
+		return nil, err
+	}
+	req := &streamv1.QueryRequest{
+		Groups:     q.Groups,
+		Name:       q.Name,
+		TimeRange:  q.TimeRange(now),
+		Offset:     q.Offset,
+		Limit:      q.Limit,
+		Projection: schema.projection(names, stream.GetTagFamilies()),
+		Trace:      q.Trace,
+		Explain:    q.explainMode(),
+	}
+	if req.Criteria, err = criteria(q.Where, schema.tagType); err != nil {
+		return nil, err
+	}
+	if req.OrderBy, err = q.queryOrder(rules); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// MeasureRequest compiles the query into a measure query request.
+//
+// A single aggregation without an alias is compiled into the agg, whose result is named after the field.
+// Otherwise, the aggregations are compiled into the aggs named after their aliases, or "<function>_<field>" by default.
+// Ordering by a field or an aggregation is compiled into the top of LIMIT items, which are 100 by default.
+func (q *Query) MeasureRequest(measure *databasev1.Measure, rules []*databasev1.IndexRule, now time.Time) (*measurev1.QueryRequest, error) {
+	if err := q.expectKind(KindMeasure); err != nil {
+		return nil, err
+	}
+	if err := q.unsupported("AGGREGATE BY"); err != nil {
+		return nil, err
+	}
+	schema := newTagSchema(measure.GetTagFamilies())
+	fields := make(map[string]bool, len(measure.GetFields()))
+	for _, f := range measure.GetFields() {
+		fields[f.GetName()] = true
+	}
+	req := &measurev1.QueryRequest{
+		Groups:    q.Groups,
+		Name:      q.Name,
+		TimeRange: q.TimeRange(now),
+		Offset:    q.Offset,
+		Limit:     q.Limit,
+		Trace:     q.Trace,
+		Explain:   q.explainMode(),
+	}
+	var tags, fieldNames []string
+	addField := func(name string) {
+		for _, f := range fieldNames {
+			if f == name {
+				return
+			}
+		}
+		fieldNames = append(fieldNames, name)
+	}
+	var aggs []*measurev1.QueryRequest_Aggregation
+	// needsAggs is set by the aggregations agg can't express, which are the aliased ones and COUNT(*).
+	var needsAggs bool
+	for _, p := range q.Projection {
+		switch {
+		case p.Func != "" && p.Name == countAll:
+			name := p.Alias
+			if name == "" {
+				name = strings.ToLower(p.Func)
+			}
+			aggs = append(aggs, &measurev1.QueryRequest_Aggregation{Function: aggregationFunctions[p.Func], FieldName: countAll, Name: name})
+			needsAggs = true
+		case p.Func != "":
+			if !fields[p.Name] {
+				return nil, errors.WithMessagef(ErrCompile, "unknown field %s", p.Name)
+			}
+			name := p.Alias
+			if name == "" {
+				name = strings.ToLower(p.Func) + "_" + p.Name
+			} else {
+				needsAggs = true
+			}
+			aggs = append(aggs, &measurev1.QueryRequest_Aggregation{Function: aggregationFunctions[p.Func], FieldName: p.Name, Name: name})
+			addField(p.Name)
+		case fields[p.Name]:
+			addField(p.Name)
+		default:
+			if _, ok := schema.tags[p.Name]; !ok {
+				return nil, errors.WithMessagef(ErrCompile, "unknown tag or field %s", p.Name)
+			}
+			tags = append(tags, p.Name)
+		}
+	}
+	if len(q.Projection) == 0 {
+		for _, f := range measure.GetFields() {
+			addField(f.GetName())
+		}
+	}
+	if gb := q.GroupBy; gb != nil {
+		if err := checkTags(gb.Tags, schema.tagType); err != nil {
+			return nil, err
+		}
+		req.GroupBy = &measurev1.QueryRequest_GroupBy{
+			TagProjection: schema.projection(gb.Tags, nil),
+			TimeBucket:    gb.TimeBucket,
+		}
+		if len(q.Projection) > 0 {
+			tags = append(tags, gb.Tags...)
+		}
+	}
+	if len(q.Projection) == 0 || len(tags) > 0 {
+		req.TagProjection = schema.projection(tags, measure.GetTagFamilies())
+	}
+	if len(fieldNames) > 0 {
+		req.FieldProjection = &measurev1.QueryRequest_FieldProjection{Names: fieldNames}
+	}
+	// results maps the names referring to the aggregations and the fields to the names of the result fields.
+	results := make(map[string]string, len(fields)+len(aggs))
+	for f := range fields {
+		results[f] = f
+	}
+	switch {
+	case len(aggs) == 1 && !needsAggs:
+		req.Agg = &measurev1.QueryRequest_Aggregation{Function: aggs[0].Function, FieldName: aggs[0].FieldName}
+		if req.GroupBy != nil {
+			req.GroupBy.FieldName = aggs[0].FieldName
+		}
+		results[aggs[0].Name] = aggs[0].FieldName
+	case len(aggs) > 0:
+		req.Aggs = aggs
+		for _, a := range aggs {
+			results[a.Name] = a.Name
+		}
+	}
+	var err error
+	if req.Criteria, err = criteria(q.Where, schema.tagType); err != nil {
+		return nil, err
+	}
+	if req.Having, err = having(q.Having, results); err != nil {
+		return nil, err
+	}
+	if ob := q.OrderBy; ob != nil && ob.Name != "" {
+		if result, ok := results[ob.Name]; ok {
+			number := int32(q.Limit)
+			if number == 0 {
+				number = 100
+			}
+			req.Top = &measurev1.QueryRequest_Top{
+				Number:         number,
+				FieldName:      result,
+				FieldValueSort: sortOf(ob, modelv1.Sort_SORT_DESC),
+			}
+			return req, nil
+		}
+	}
+	if req.OrderBy, err = q.queryOrder(rules); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// TopNRequest compiles the query into a top-n query request.
+// The conditions are the equalities on the tags the top-n aggregation groups by.
+func (q *Query) TopNRequest(now time.Time) (*measurev1.TopNRequest, error) {
+	if err := q.expectKind(KindTopN); err != nil {
+		return nil, err
+	}
+	if err := q.unsupported("GROUP BY", "HAVING", "ORDER BY", "OFFSET", "EXPLAIN"); err != nil {
+		return nil, err
+	}
+	req := &measurev1.TopNRequest{
+		Groups:         q.Groups,
+		Name:           q.Name,
+		TimeRange:      q.TimeRange(now),
+		TopN:           q.TopN,
+		FieldValueSort: modelv1.Sort_SORT_DESC,
+		Trace:          q.Trace,
+	}
+	if q.Bottom {
+		req.FieldValueSort = modelv1.Sort_SORT_ASC
+	}
+	if q.AggregateBy != "" {
+		req.Agg = aggregationFunctions[q.AggregateBy]
+	}
+	var collect func(e Expr) error
+	collect = func(e Expr) error {
+		switch x := e.(type) {
+		case *Logical:
+			if x.Op != OpAnd {
+				return errors.WithMessage(ErrCompile, "the conditions of a TOPN query should be combined by AND")
+			}
+			if err := collect(x.Left); err != nil {
+				return err
+			}
+			return collect(x.Right)
+		case *Condition:
+			if x.Op != CmpEQ {
+				return errors.WithMessagef(ErrCompile, "the condition on %s of a TOPN query should be an equality", x.Name)
+			}
+			v, err := literalTagValue(x.Values[0])
+			if err != nil {
+				return err
+			}
+			req.Conditions = append(req.Conditions, &modelv1.Condition{Name: x.Name, Op: modelv1.Condition_BINARY_OP_EQ, Value: v})
+		}
+		return nil
+	}
+	if err := collect(q.Where); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// TraceRequest compiles the query into a trace query request.
+func (q *Query) TraceRequest(trace *databasev1.Trace, rules []*databasev1.IndexRule, now time.Time) (*tracev1.QueryRequest, error) {
+	if err := q.expectKind(KindTrace); err != nil {
+		return nil, err
+	}
+	if err := q.unsupported("GROUP BY", "HAVING", "AGGREGATE BY"); err != nil {
+		return nil, err
+	}
+	names, err := q.plainProjection()
+	if err != nil {
+		return nil, err
+	}
+	types := make(map[string]databasev1.TagType, len(trace.GetTags()))
+	for _, t := range trace.GetTags() {
+		types[t.GetName()] = t.GetType()
+	}
+	tagType := func(name string) (databasev1.TagType, bool) {
+		t, ok := types[name]
+		return t, ok
+	}
+	if err = checkTags(names, tagType); err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		for _, t := range trace.GetTags() {
+			names = append(names, t.GetName())
+		}
+	}
+	req := &tracev1.QueryRequest{
+		Groups:        q.Groups,
+		Name:          q.Name,
+		TimeRange:     q.TimeRange(now),
+		Offset:        q.Offset,
+		Limit:         q.Limit,
+		TagProjection: names,
+		Trace:         q.Trace,
+		Explain:       q.explainMode(),
+	}
+	if req.Criteria, err = criteria(q.Where, tagType); err != nil {
+		return nil, err
+	}
+	if req.OrderBy, err = q.queryOrder(rules); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// PropertyRequest compiles the query into a property query request.
+// The equalities on "id" combined by AND with the other conditions select the properties by their IDs.
+func (q *Query) PropertyRequest(property *databasev1.Property) (*propertyv1.QueryRequest, error) {
+	if err := q.expectKind(KindProperty); err != nil {
+		return nil, err
+	}
+	if err := q.unsupported("TIME", "GROUP BY", "HAVING", "ORDER BY", "OFFSET", "AGGREGATE BY", "EXPLAIN"); err != nil {
+		return nil, err
+	}
+	names, err := q.plainProjection()
+	if err != nil {
+		return nil, err
+	}
+	types := make(map[string]databasev1.TagType, len(property.GetTags()))
+	for _, t := range property.GetTags() {
+		types[t.GetName()] = t.GetType()
+	}
+	tagType := func(name string) (databasev1.TagType, bool) {
+		t, ok := types[name]
+		return t, ok
+	}
+	if err = checkTags(names, tagType); err != nil {
+		return nil, err
+	}
+	req := &propertyv1.QueryRequest{
+		Groups:        q.Groups,
+		Name:          q.Name,
+		TagProjection: names,
+		Limit:         q.Limit,
+		Trace:         q.Trace,
+	}
+	where := q.Where
+	if _, isTag := types[propertyIDName]; !isTag {
+		if req.Ids, where, err = propertyIDs(where); err != nil {
+			return nil, err
+		}
+	}
+	if req.Criteria, err = criteria(where, tagType); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// propertyIDs extracts the IDs from the conditions on "id" combined by AND, and returns the rest of the expression.
+func propertyIDs(e Expr) ([]string, Expr, error) {
+	switch x := e.(type) {
+	case *Logical:
+		if x.Op != OpAnd {
+			return nil, e, nil
+		}
+		leftIDs, left, err := propertyIDs(x.Left)
+		if err != nil {
+			return nil, nil, err
+		}
+		rightIDs, right, err := propertyIDs(x.Right)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(leftIDs) > 0 && len(rightIDs) > 0 {
+			return nil, nil, errors.WithMessage(ErrCompile, "the IDs of the properties are selected more than once")
+		}
+		ids := append(leftIDs, rightIDs...)
+		switch {
+		case left == nil:
+			return ids, right, nil
+		case right == nil:
+			return ids, left, nil
+		}
+		return ids, &Logical{Op: OpAnd, Left: left, Right: right}, nil
+	case *Condition:
+		if x.Name != propertyIDName {
+			return nil, e, nil
+		}
+		if x.Op != CmpEQ && x.Op != CmpIn {
+			return nil, nil, errors.WithMessagef(ErrCompile, "the IDs of the properties should be selected by = or IN rather than %s", x.Op)
+		}
+		ids := make([]string, 0, len(x.Values))
+		for _, v := range x.Values {
+			ids = append(ids, v.String())
+		}
+		return ids, nil, nil
+	}
+	return nil, e, nil
+}
+
+func criteria(e Expr, tagType func(string) (databasev1.TagType, bool)) (*modelv1.Criteria, error) {
+	switch x := e.(type) {
+	case *Logical:
+		left, err := criteria(x.Left, tagType)
+		if err != nil {
+			return nil, err
+		}
+		right, err := criteria(x.Right, tagType)
+		if err != nil {
+			return nil, err
+		}
+		op := modelv1.LogicalExpression_LOGICAL_OP_AND
+		if x.Op == OpOr {
+			op = modelv1.LogicalExpression_LOGICAL_OP_OR
+		}
+		return &modelv1.Criteria{Exp: &modelv1.Criteria_Le{Le: &modelv1.LogicalExpression{Op: op, Left: left, Right: right}}}, nil
+	case *Condition:
+		t, ok := tagType(x.Name)
+		if !ok {
+			return nil, errors.WithMessagef(ErrCompile, "unknown tag %s", x.Name)
+		}
+		v, err := tagValue(x, t)
+		if err != nil {
+			return nil, err
+		}
+		return &modelv1.Criteria{Exp: &modelv1.Criteria_Condition{Condition: &modelv1.Condition{
+			Name: x.Name, Op: binaryOps[x.Op], Value: v,
+		}}}, nil
+	}
+	return nil, nil
+}
+
+// tagValue converts the values of the condition to the type of the tag.
+// The list operators take an array, and the others take a single value.
+func tagValue(c *Condition, t databasev1.TagType) (*modelv1.TagValue, error) {
+	list := c.Op == CmpIn || c.Op == CmpNotIn || c.Op == CmpHaving || c.Op == CmpNotHaving
+	if !list {
+		return scalarTagValue(c.Name, c.Values[0], t)
+	}
+	switch t {
+	case databasev1.TagType_TAG_TYPE_INT, databasev1.TagType_TAG_TYPE_INT_ARRAY:
+		arr := make([]int64, 0, len(c.Values))
+		for _, v := range c.Values {
+			i, err := intOf(c.Name, v)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, i)
+		}
+		return &modelv1.TagValue{Value: &modelv1.TagValue_IntArray{IntArray: &modelv1.IntArray{Value: arr}}}, nil
+	case databasev1.TagType_TAG_TYPE_STRING, databasev1.TagType_TAG_TYPE_STRING_ARRAY:
+		arr := make([]string, 0, len(c.Values))
+		for _, v := range c.Values {
+			arr = append(arr, v.String())
+		}
+		return &modelv1.TagValue{Value: &modelv1.TagValue_StrArray{StrArray: &modelv1.StrArray{Value: arr}}}, nil
+	}
+	return nil, errors.WithMessagef(ErrCompile, "%s doesn't support a list of values on the tag %s of %s", c.Op, c.Name, t)
+}
+
+func scalarTagValue(name string, v Value, t databasev1.TagType) (*modelv1.TagValue, error) {
+	if v.Kind == ValueNull {
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Null{Null: structpb.NullValue_NULL_VALUE}}, nil
+	}
+	switch t {
+	case databasev1.TagType_TAG_TYPE_INT, databasev1.TagType_TAG_TYPE_INT_ARRAY:
+		i, err := intOf(name, v)
+		if err != nil {
+			return nil, err
+		}
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{Value: i}}}, nil
+	case databasev1.TagType_TAG_TYPE_FLOAT:
+		f := v.Float
+		switch v.Kind {
+		case ValueInt:
+			f = float64(v.Int)
+		case ValueString:
+			var err error
+			if f, err = strconv.ParseFloat(v.Str, 64); err != nil {
+				return nil, errors.WithMessagef(ErrCompile, "the tag %s expects a float, but got %q", name, v.Str)
+			}
+		}
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Float{Float: &modelv1.Float{Value: f}}}, nil
+	case databasev1.TagType_TAG_TYPE_TIMESTAMP:
+		ts, err := time.Parse(time.RFC3339Nano, v.String())
+		if err != nil {
+			return nil, errors.WithMessagef(ErrCompile, "the tag %s expects an RFC3339 time, but got %s", name, v)
+		}
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Timestamp{Timestamp: timestamppb.New(ts)}}, nil
+	case databasev1.TagType_TAG_TYPE_DATA_BINARY:
+		return &modelv1.TagValue{Value: &modelv1.TagValue_BinaryData{BinaryData: []byte(v.String())}}, nil
+	}
+	return &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: v.String()}}}, nil
+}
+
+func intOf(name string, v Value) (int64, error) {
+	switch v.Kind {
+	case ValueInt:
+		return v.Int, nil
+	case ValueString:
+		if i, err := strconv.ParseInt(v.Str, 10, 64); err == nil {
+			return i, nil
+		}
+	}
+	return 0, errors.WithMessagef(ErrCompile, "the tag %s expects an integer, but got %s", name, v)
+}
+
+// literalTagValue converts a value to the tag value of its literal type, as the type of the tag is unknown.
+func literalTagValue(v Value) (*modelv1.TagValue, error) {
+	switch v.Kind {
+	case ValueInt:
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{Value: v.Int}}}, nil
+	case ValueString:
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: v.Str}}}, nil
+	case ValueNull:
+		return &modelv1.TagValue{Value: &modelv1.TagValue_Null{Null: structpb.NullValue_NULL_VALUE}}, nil
+	}
+	return nil, errors.WithMessagef(ErrCompile, "the value %s should be a string or an integer", v)
+}
+
+// having compiles the conditions on the aggregations, whose values are integers or floats.
+func having(e Expr, results map[string]string) (*measurev1.Having, error) {
+	switch x := e.(type) {
+	case *Logical:
+		left, err := having(x.Left, results)
+		if err != nil {
+			return nil, err
+		}
+		right, err := having(x.Right, results)
+		if err != nil {
+			return nil, err
+		}
+		op := modelv1.LogicalExpression_LOGICAL_OP_AND
+		if x.Op == OpOr {
+			op = modelv1.LogicalExpression_LOGICAL_OP_OR
+		}
+		return &measurev1.Having{Exp: &measurev1.Having_Le{Le: &measurev1.HavingExpression{Op: op, Left: left, Right: right}}}, nil
+	case *Condition:
+		switch x.Op {
+		case CmpEQ, CmpNE, CmpLT, CmpGT, CmpLE, CmpGE:
+		default:
+			return nil, errors.WithMessagef(ErrCompile, "HAVING doesn't support the operator %s", x.Op)
+		}
+		name, ok := results[x.Name]
+		if !ok {
+			return nil, errors.WithMessagef(ErrCompile, "unknown aggregation or field %s", x.Name)
+		}
+		var fv *modelv1.FieldValue
+		switch v := x.Values[0]; v.Kind {
+		case ValueInt:
+			fv = &modelv1.FieldValue{Value: &modelv1.FieldValue_Int{Int: &modelv1.Int{Value: v.Int}}}
+		case ValueFloat:
+			fv = &modelv1.FieldValue{Value: &modelv1.FieldValue_Float{Float: &modelv1.Float{Value: v.Float}}}
+		default:
+			return nil, errors.WithMessagef(ErrCompile, "HAVING compares %s with a number rather than %s", x.Name, v)
+		}
+		return &measurev1.Having{Exp: &measurev1.Having_Condition{Condition: &measurev1.HavingCondition{
+			Name: name, Op: binaryOps[x.Op], Value: fv,
+		}}}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,285 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bydbql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	propertyv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/property/v1"
+	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+)
+
+var now = time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC)
+
+func timeRange(begin, end time.Time) *modelv1.TimeRange {
+	return &modelv1.TimeRange{Begin: timestamppb.New(begin), End: timestamppb.New(end)}
+}
+
+func strCondition(name string, op modelv1.Condition_BinaryOp, value string) *modelv1.Criteria {
+	return &modelv1.Criteria{Exp: &modelv1.Criteria_Condition{Condition: &modelv1.Condition{
+		Name: name, Op: op, Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: value}}},
+	}}}
+}
+
+func mustParse(t *testing.T, query string) *Query {
+	q, err := Parse(query)
+	require.NoError(t, err, query)
+	return q
+}
+
+func TestTimeRange(t *testing.T) {
+	tests := []struct {
+		want  *modelv1.TimeRange
+		query string
+	}{
+		{query: "SELECT * FROM STREAM s IN g", want: timeRange(now.Add(-30*time.Minute), now)},
+		{query: "SELECT * FROM STREAM s IN g TIME >= NOW() - 1h", want: timeRange(now.Add(-time.Hour), now)},
+		{query: "SELECT * FROM STREAM s IN g TIME < '2024-01-01T00:40:00Z'", want: timeRange(now.Add(-50*time.Minute), now.Add(-20*time.Minute))},
+		{
+			query: "SELECT * FROM STREAM s IN g TIME BETWEEN '2024-01-01T00:10:00Z' AND '-10m'",
+			want:  timeRange(now.Add(-50*time.Minute), now.Add(-10*time.Minute+time.Nanosecond)),
+		},
+		{
+			query: "SELECT * FROM STREAM s IN g TIME > '-20m' AND TIME < '-10m'",
+			want:  timeRange(now.Add(-20*time.Minute+time.Nanosecond), now.Add(-10*time.Minute)),
+		},
+	}
+	for _, tt := range tests {
+		if diff := cmp.Diff(tt.want, mustParse(t, tt.query).TimeRange(now), protocmp.Transform()); diff != "" {
+			t.Errorf("%s: time range mismatch (-want +got):\n%s", tt.query, diff)
+		}
+	}
+}
+
+func TestStreamRequest(t *testing.T) {
+	stream := &databasev1.Stream{
+		Metadata: &commonv1.Metadata{Name: "sw", Group: "default"},
+		TagFamilies: []*databasev1.TagFamilySpec{
+			{Name: "searchable", Tags: []*databasev1.TagSpec{
+				{Name: "trace_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+				{Name: "duration", Type: databasev1.TagType_TAG_TYPE_INT},
+			}},
+			{Name: "data", Tags: []*databasev1.TagSpec{{Name: "data_binary", Type: databasev1.TagType_TAG_TYPE_DATA_BINARY}}},
+		},
+	}
+	rules := []*databasev1.IndexRule{{Metadata: &commonv1.Metadata{Name: "duration"}, Tags: []string{"duration"}}}
+	q := mustParse(t, "EXPLAIN ANALYZE SELECT data_binary, trace_id FROM STREAM sw IN default "+
+		"WHERE trace_id = '1' OR duration IN ('100', 200) ORDER BY duration DESC LIMIT 10")
+	got, err := q.StreamRequest(stream, rules, now)
+	require.NoError(t, err)
+	want := &streamv1.QueryRequest{
+		Groups:    []string{"default"},
+		Name:      "sw",
+		TimeRange: timeRange(now.Add(-30*time.Minute), now),
+		Limit:     10,
+		Projection: &modelv1.TagProjection{TagFamilies: []*modelv1.TagProjection_TagFamily{
+			{Name: "searchable", Tags: []string{"trace_id"}},
+			{Name: "data", Tags: []string{"data_binary"}},
+		}},
+		Criteria: &modelv1.Criteria{Exp: &modelv1.Criteria_Le{Le: &modelv1.LogicalExpression{
+			Op:   modelv1.LogicalExpression_LOGICAL_OP_OR,
+			Left: strCondition("trace_id", modelv1.Condition_BINARY_OP_EQ, "1"),
+			Right: &modelv1.Criteria{Exp: &modelv1.Criteria_Condition{Condition: &modelv1.Condition{
+				Name:  "duration",
+				Op:    modelv1.Condition_BINARY_OP_IN,
+				Value: &modelv1.TagValue{Value: &modelv1.TagValue_IntArray{IntArray: &modelv1.IntArray{Value: []int64{100, 200}}}},
+			}}},
+		}}},
+		OrderBy: &modelv1.QueryOrder{IndexRuleName: "duration", Sort: modelv1.Sort_SORT_DESC},
+		Explain: commonv1.ExplainMode_EXPLAIN_MODE_ANALYZE,
+	}
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Errorf("stream request mismatch (-want +got):\n%s", diff)
+	}
+
+	for query, msg := range map[string]string{
+		"SELECT unknown FROM STREAM sw IN default":                      "unknown tag unknown",
+		"SELECT * FROM STREAM sw IN default WHERE duration = 'x'":       "expects an integer",
+		"SELECT * FROM STREAM sw IN default ORDER BY trace_id":          "no index rule contains it",
+		"SELECT SUM(duration) FROM STREAM sw IN default":                "doesn't support the aggregation",
+		"SELECT * FROM STREAM sw IN default GROUP BY trace_id":          "doesn't support the GROUP BY clause",
+		"SELECT * FROM MEASURE sw IN default WHERE duration = 'x'":      "rather than a STREAM",
+		"SELECT * FROM STREAM sw IN default WHERE data_binary IN ('a')": "doesn't support a list of values",
+	} {
+		_, err = mustParse(t, query).StreamRequest(stream, rules, now)
+		require.ErrorIs(t, err, ErrCompile, query)
+		require.Contains(t, err.Error(), msg, query)
+	}
+}
+
+func TestMeasureRequest(t *testing.T) {
+	measure := &databasev1.Measure{
+		Metadata: &commonv1.Metadata{Name: "service_cpm_minute", Group: "sw_metric"},
+		TagFamilies: []*databasev1.TagFamilySpec{{Name: "default", Tags: []*databasev1.TagSpec{
+			{Name: "id", Type: databasev1.TagType_TAG_TYPE_STRING},
+			{Name: "entity_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+		}}},
+		Fields: []*databasev1.FieldSpec{{Name: "total"}, {Name: "value"}},
+	}
+	defaultProjection := &modelv1.TagProjection{TagFamilies: []*modelv1.TagProjection_TagFamily{{Name: "default", Tags: []string{"id"}}}}
+	tests := []struct {
+		want  *measurev1.QueryRequest
+		query string
+	}{
+		{
+			query: "SELECT * FROM MEASURE service_cpm_minute IN sw_metric ORDER BY TIME DESC",
+			want: &measurev1.QueryRequest{
+				TagProjection: &modelv1.TagProjection{TagFamilies: []*modelv1.TagProjection_TagFamily{
+					{Name: "default", Tags: []string{"id", "entity_id"}},
+				}},
+				FieldProjection: &measurev1.QueryRequest_FieldProjection{Names: []string{"total", "value"}},
+				OrderBy:         &modelv1.QueryOrder{Sort: modelv1.Sort_SORT_DESC},
+			},
+		},
+		{
+			query: "SELECT SUM(total) FROM MEASURE service_cpm_minute IN sw_metric GROUP BY id HAVING sum_total > 10 ORDER BY sum_total LIMIT 5",
+			want: &measurev1.QueryRequest{
+				TagProjection:   defaultProjection,
+				FieldProjection: &measurev1.QueryRequest_FieldProjection{Names: []string{"total"}},
+				GroupBy:         &measurev1.QueryRequest_GroupBy{TagProjection: defaultProjection, FieldName: "total"},
+				Agg: &measurev1.QueryRequest_Aggregation{
+					Function:  modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM,
+					FieldName: "total",
+				},
+				Having: &measurev1.Having{Exp: &measurev1.Having_Condition{Condition: &measurev1.HavingCondition{
+					Name:  "total",
+					Op:    modelv1.Condition_BINARY_OP_GT,
+					Value: &modelv1.FieldValue{Value: &modelv1.FieldValue_Int{Int: &modelv1.Int{Value: 10}}},
+				}}},
+				Top:   &measurev1.QueryRequest_Top{Number: 5, FieldName: "total", FieldValueSort: modelv1.Sort_SORT_DESC},
+				Limit: 5,
+			},
+		},
+		{
+			query: "SELECT id, MAX(value), COUNT(value) AS n FROM MEASURE service_cpm_minute IN sw_metric " +
+				"WHERE entity_id = 'e' GROUP BY id, TIME(5m) ORDER BY n ASC",
+			want: &measurev1.QueryRequest{
+				TagProjection:   defaultProjection,
+				FieldProjection: &measurev1.QueryRequest_FieldProjection{Names: []string{"value"}},
+				GroupBy:         &measurev1.QueryRequest_GroupBy{TagProjection: defaultProjection, TimeBucket: "5m"},
+				Aggs: []*measurev1.QueryRequest_Aggregation{
+					{Function: modelv1.AggregationFunction_AGGREGATION_FUNCTION_MAX, FieldName: "value", Name: "max_value"},
+					{Function: modelv1.AggregationFunction_AGGREGATION_FUNCTION_COUNT, FieldName: "value", Name: "n"},
+				},
+				Criteria: strCondition("entity_id", modelv1.Condition_BINARY_OP_EQ, "e"),
+				Top:      &measurev1.QueryRequest_Top{Number: 100, FieldName: "n", FieldValueSort: modelv1.Sort_SORT_ASC},
+			},
+		},
+		{
+			query: "SELECT id, COUNT(*) FROM MEASURE service_cpm_minute IN sw_metric GROUP BY id HAVING count > 1",
+			want: &measurev1.QueryRequest{
+				TagProjection: defaultProjection,
+				GroupBy:       &measurev1.QueryRequest_GroupBy{TagProjection: defaultProjection},
+				Aggs: []*measurev1.QueryRequest_Aggregation{
+					{Function: modelv1.AggregationFunction_AGGREGATION_FUNCTION_COUNT, FieldName: "*", Name: "count"},
+				},
+				Having: &measurev1.Having{Exp: &measurev1.Having_Condition{Condition: &measurev1.HavingCondition{
+					Name:  "count",
+					Op:    modelv1.Condition_BINARY_OP_GT,
+					Value: &modelv1.FieldValue{Value: &modelv1.FieldValue_Int{Int: &modelv1.Int{Value: 1}}},
+				}}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		got, err := mustParse(t, tt.query).MeasureRequest(measure, nil, now)
+		require.NoError(t, err, tt.query)
+		tt.want.Groups = []string{"sw_metric"}
+		tt.want.Name = "service_cpm_minute"
+		tt.want.TimeRange = timeRange(now.Add(-30*time.Minute), now)
+		if diff := cmp.Diff(tt.want, got, protocmp.Transform()); diff != "" {
+			t.Errorf("%s: measure request mismatch (-want +got):\n%s", tt.query, diff)
+		}
+	}
+
+	for query, msg := range map[string]string{
+		"SELECT SUM(unknown) FROM MEASURE service_cpm_minute IN sw_metric":                       "unknown field unknown",
+		"SELECT SUM(total) FROM MEASURE service_cpm_minute IN sw_metric HAVING avg_total > 1":    "unknown aggregation or field avg_total",
+		"SELECT SUM(total) FROM MEASURE service_cpm_minute IN sw_metric HAVING sum_total IN (1)": "HAVING doesn't support the operator IN",
+		"SELECT SUM(total) FROM MEASURE service_cpm_minute IN sw_metric HAVING sum_total > 'a'":  "with a number",
+		"SELECT * FROM MEASURE service_cpm_minute IN sw_metric AGGREGATE BY SUM":                 "doesn't support the AGGREGATE BY clause",
+	} {
+		_, err := mustParse(t, query).MeasureRequest(measure, nil, now)
+		require.ErrorIs(t, err, ErrCompile, query)
+		require.Contains(t, err.Error(), msg, query)
+	}
+}
+
+func TestTopNRequest(t *testing.T) {
+	got, err := mustParse(t, "SELECT TOP 3 FROM TOPN endpoint_cpm_top IN sw_metric WHERE service_id = 'svc' AGGREGATE BY MAX").TopNRequest(now)
+	require.NoError(t, err)
+	want := &measurev1.TopNRequest{
+		Groups:         []string{"sw_metric"},
+		Name:           "endpoint_cpm_top",
+		TimeRange:      timeRange(now.Add(-30*time.Minute), now),
+		TopN:           3,
+		Agg:            modelv1.AggregationFunction_AGGREGATION_FUNCTION_MAX,
+		FieldValueSort: modelv1.Sort_SORT_DESC,
+		Conditions: []*modelv1.Condition{{
+			Name:  "service_id",
+			Op:    modelv1.Condition_BINARY_OP_EQ,
+			Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "svc"}}},
+		}},
+	}
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Errorf("top-n request mismatch (-want +got):\n%s", diff)
+	}
+
+	_, err = mustParse(t, "SELECT BOTTOM 3 FROM TOPN t IN g WHERE a = 1 OR b = 2").TopNRequest(now)
+	require.ErrorIs(t, err, ErrCompile)
+	_, err = mustParse(t, "EXPLAIN SELECT TOP 3 FROM TOPN t IN g").TopNRequest(now)
+	require.ErrorIs(t, err, ErrCompile)
+}
+
+func TestPropertyRequest(t *testing.T) {
+	property := &databasev1.Property{
+		Metadata: &commonv1.Metadata{Name: "ui_template", Group: "sw"},
+		Tags: []*databasev1.TagSpec{
+			{Name: "name", Type: databasev1.TagType_TAG_TYPE_STRING},
+			{Name: "version", Type: databasev1.TagType_TAG_TYPE_INT},
+		},
+	}
+	got, err := mustParse(t, "SELECT name FROM PROPERTY ui_template IN sw WHERE id IN ('a', 'b') AND name = 'n' LIMIT 2").PropertyRequest(property)
+	require.NoError(t, err)
+	want := &propertyv1.QueryRequest{
+		Groups:        []string{"sw"},
+		Name:          "ui_template",
+		Ids:           []string{"a", "b"},
+		TagProjection: []string{"name"},
+		Criteria:      strCondition("name", modelv1.Condition_BINARY_OP_EQ, "n"),
+		Limit:         2,
+	}
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Errorf("property request mismatch (-want +got):\n%s", diff)
+	}
+
+	_, err = mustParse(t, "SELECT * FROM PROPERTY ui_template IN sw TIME > NOW()").PropertyRequest(property)
+	require.ErrorIs(t, err, ErrCompile)
+	_, err = mustParse(t, "SELECT * FROM PROPERTY ui_template IN sw WHERE id != 'a'").PropertyRequest(property)
+	require.ErrorIs(t, err, ErrCompile)
+}
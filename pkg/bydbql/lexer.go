@@ -0,0 +1,161 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bydbql
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrParse indicates the query is invalid.
+var ErrParse = errors.New("invalid query")
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	// tokIdent is a bare word, which is either a keyword or a name.
+	tokIdent
+	// tokQuotedIdent is a name quoted by backticks, which is never a keyword.
+	tokQuotedIdent
+	tokString
+	tokNumber
+	// tokDuration is a number followed by the units, like "5m" or "1h30m".
+	tokDuration
+	tokSymbol
+)
+
+type token struct {
+	text string
+	kind tokenKind
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "the end of the query"
+	case tokString:
+		return "'" + t.text + "'"
+	case tokQuotedIdent:
+		return "`" + t.text + "`"
+	}
+	return t.text
+}
+
+// is reports whether the token is the keyword or the symbol, ignoring the case of the keyword.
+func (t token) is(s string) bool {
+	switch t.kind {
+	case tokIdent:
+		return strings.EqualFold(t.text, s)
+	case tokSymbol:
+		return t.text == s
+	}
+	return false
+}
+
+func isLetter(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// lex splits the query into the tokens, which end with a tokEOF.
+func lex(query string) ([]token, error) {
+	var tokens []token
+	pos := 0
+	for pos < len(query) {
+		c := query[pos]
+		start := pos
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			pos++
+			continue
+		case isLetter(c):
+			for pos < len(query) && (isLetter(query[pos]) || isDigit(query[pos]) || query[pos] == '.') {
+				pos++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: query[start:pos], pos: start})
+		case isDigit(c):
+			for pos < len(query) && (isDigit(query[pos]) || query[pos] == '.') {
+				pos++
+			}
+			kind := tokNumber
+			for pos < len(query) && (isLetter(query[pos]) || isDigit(query[pos])) {
+				kind = tokDuration
+				pos++
+			}
+			tokens = append(tokens, token{kind: kind, text: query[start:pos], pos: start})
+		case c == '\'' || c == '"' || c == '`':
+			text, end, err := lexQuoted(query, pos)
+			if err != nil {
+				return nil, err
+			}
+			kind := tokString
+			if c == '`' {
+				kind = tokQuotedIdent
+			}
+			tokens = append(tokens, token{kind: kind, text: text, pos: start})
+			pos = end
+		default:
+			if pos+1 < len(query) {
+				switch two := query[pos : pos+2]; two {
+				case "!=", "<>", "<=", ">=":
+					if two == "<>" {
+						two = CmpNE
+					}
+					tokens = append(tokens, token{kind: tokSymbol, text: two, pos: start})
+					pos += 2
+					continue
+				}
+			}
+			if !strings.ContainsRune("=<>(),*+-;", rune(c)) {
+				return nil, errors.WithMessagef(ErrParse, "position %d: unexpected %q", pos, c)
+			}
+			tokens = append(tokens, token{kind: tokSymbol, text: string(c), pos: start})
+			pos++
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(query)}), nil
+}
+
+// lexQuoted reads the quoted text starting at pos, and returns it with the position after the closing quote.
+// A quote is escaped by doubling it or by a backslash.
+func lexQuoted(query string, pos int) (string, int, error) {
+	quote := query[pos]
+	var sb strings.Builder
+	for i := pos + 1; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\\' && i+1 < len(query) && (query[i+1] == quote || query[i+1] == '\\'):
+			i++
+			sb.WriteByte(query[i])
+		case c == quote && i+1 < len(query) && query[i+1] == quote:
+			i++
+			sb.WriteByte(quote)
+		case c == quote:
+			return sb.String(), i + 1, nil
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", 0, errors.WithMessagef(ErrParse, "position %d: the quoted text is not closed", pos)
+}
@@ -0,0 +1,594 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bydbql
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+// aggregateFuncs are the aggregation functions of the projection and the top-n queries.
+var aggregateFuncs = map[string]bool{
+	"SUM": true, "MEAN": true, "AVG": true, "MAX": true, "MIN": true, "COUNT": true,
+	"P50": true, "P90": true, "P99": true, "DISTINCT_COUNT": true,
+}
+
+// Parse parses a BydbQL statement:
+//
+//	SELECT <* | tag, field, FN(field) [AS alias], ...>
+//	  FROM <STREAM | MEASURE | TRACE | PROPERTY> name IN group[, group...]
+//	  [TIME <BETWEEN t1 AND t2 | > t | >= t | < t | <= t>]
+//	  [WHERE condition [AND | OR condition]...]
+//	  [GROUP BY tag[, tag...][, TIME(duration)]]
+//	  [HAVING condition [AND | OR condition]...]
+//	  [ORDER BY <TIME | tag | field | alias> [ASC | DESC]]
+//	  [LIMIT n] [OFFSET n] [WITH TRACE]
+//
+//	SELECT <TOP | BOTTOM> n FROM TOPN name IN group[, group...]
+//	  [TIME ...] [WHERE tag = value [AND tag = value]...] [AGGREGATE BY FN] [WITH TRACE]
+//
+// The keywords are case-insensitive. The clauses after the groups can be in any order.
+func Parse(query string) (*Query, error) {
+	tokens, err := lex(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	q, err := p.parseQuery()
+	if err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+// peekAt returns the token n tokens ahead.
+func (p *parser) peekAt(n int) token {
+	if p.pos+n >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+n]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// unread moves back to the token returned by next.
+func (p *parser) unread(t token) {
+	if t.kind != tokEOF {
+		p.pos--
+	}
+}
+
+// accept consumes the next token if it's the keyword or the symbol.
+func (p *parser) accept(s string) bool {
+	if p.peek().is(s) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(s string) error {
+	if !p.accept(s) {
+		return p.errorf("expect %s, but got %s", s, p.peek())
+	}
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return errors.WithMessagef(ErrParse, "position %d: "+format, append([]interface{}{p.peek().pos}, args...)...)
+}
+
+// name reads the name of a resource, a group, a tag or a field.
+func (p *parser) name(what string) (string, error) {
+	t := p.peek()
+	if t.kind != tokIdent && t.kind != tokQuotedIdent {
+		return "", p.errorf("expect the %s name, but got %s", what, t)
+	}
+	p.pos++
+	return t.text, nil
+}
+
+func (p *parser) parseQuery() (*Query, error) {
+	q := &Query{}
+	if p.accept("EXPLAIN") {
+		q.Explain = ExplainPlan
+		if p.accept("ANALYZE") {
+			q.Explain = ExplainAnalyze
+		}
+	}
+	if err := p.expect("SELECT"); err != nil {
+		return nil, err
+	}
+	switch {
+	case (p.peek().is("TOP") || p.peek().is("BOTTOM")) && p.peekAt(1).kind == tokNumber:
+		q.Bottom = p.next().is("BOTTOM")
+		n, err := p.uint(math.MaxInt32)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			return nil, p.errorf("the number of the top items should be positive")
+		}
+		q.TopN = int32(n)
+	case p.accept("*"):
+	default:
+		if err := p.parseProjection(q); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expect("FROM"); err != nil {
+		return nil, err
+	}
+	kindToken := p.next()
+	for _, k := range []Kind{KindStream, KindMeasure, KindTopN, KindTrace, KindProperty} {
+		if kindToken.is(string(k)) {
+			q.Kind = k
+		}
+	}
+	if q.Kind == "" {
+		p.unread(kindToken)
+		return nil, p.errorf("expect one of STREAM, MEASURE, TOPN, TRACE and PROPERTY, but got %s", kindToken)
+	}
+	if (q.Kind == KindTopN) != (q.TopN > 0) {
+		p.unread(kindToken)
+		return nil, p.errorf("SELECT TOP n or SELECT BOTTOM n is only used to query a TOPN")
+	}
+	var err error
+	if q.Name, err = p.name(strings.ToLower(string(q.Kind))); err != nil {
+		return nil, err
+	}
+	if err = p.expect("IN"); err != nil {
+		return nil, err
+	}
+	if q.Groups, err = p.parseGroups(); err != nil {
+		return nil, err
+	}
+	if err = p.parseClauses(q); err != nil {
+		return nil, err
+	}
+	p.accept(";")
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.errorf("unexpected %s", t)
+	}
+	return q, nil
+}
+
+func (p *parser) parseProjection(q *Query) error {
+	for {
+		var item Projection
+		t := p.peek()
+		if t.kind == tokIdent && aggregateFuncs[strings.ToUpper(t.text)] && p.peekAt(1).is("(") {
+			p.pos += 2
+			item.Func = strings.ToUpper(t.text)
+			var err error
+			if item.Func == "COUNT" && p.accept("*") {
+				item.Name = countAll
+			} else if item.Name, err = p.name("field"); err != nil {
+				return err
+			}
+			if err = p.expect(")"); err != nil {
+				return err
+			}
+			if p.accept("AS") {
+				if item.Alias, err = p.name("alias"); err != nil {
+					return err
+				}
+			}
+		} else {
+			var err error
+			if item.Name, err = p.name("tag or field"); err != nil {
+				return err
+			}
+		}
+		q.Projection = append(q.Projection, item)
+		if !p.accept(",") {
+			return nil
+		}
+	}
+}
+
+func (p *parser) parseGroups() ([]string, error) {
+	parenthesized := p.accept("(")
+	var groups []string
+	for {
+		g, err := p.name("group")
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+		if !p.accept(",") {
+			break
+		}
+	}
+	if parenthesized {
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+	}
+	return groups, nil
+}
+
+func (p *parser) parseClauses(q *Query) error {
+	seen := make(map[string]bool)
+	for {
+		t := p.peek()
+		if t.kind != tokIdent {
+			return nil
+		}
+		clause := strings.ToUpper(t.text)
+		switch clause {
+		case "TIME", "WHERE", "GROUP", "HAVING", "ORDER", "LIMIT", "OFFSET", "WITH", "AGGREGATE":
+		default:
+			return nil
+		}
+		if seen[clause] {
+			return p.errorf("duplicate %s clause", clause)
+		}
+		seen[clause] = true
+		p.pos++
+		var err error
+		switch clause {
+		case "TIME":
+			q.Time, err = p.parseTime()
+		case "WHERE":
+			q.Where, err = p.parseExpr()
+		case "GROUP":
+			q.GroupBy, err = p.parseGroupBy()
+		case "HAVING":
+			q.Having, err = p.parseExpr()
+		case "ORDER":
+			q.OrderBy, err = p.parseOrderBy()
+		case "LIMIT":
+			var n uint64
+			n, err = p.uint(math.MaxUint32)
+			q.Limit = uint32(n)
+		case "OFFSET":
+			var n uint64
+			n, err = p.uint(math.MaxUint32)
+			q.Offset = uint32(n)
+		case "WITH":
+			err = p.expect("TRACE")
+			q.Trace = true
+		case "AGGREGATE":
+			if err = p.expect("BY"); err == nil {
+				fn := p.next()
+				if fn.kind != tokIdent || !aggregateFuncs[strings.ToUpper(fn.text)] {
+					p.unread(fn)
+					err = p.errorf("expect an aggregation function, but got %s", fn)
+				}
+				q.AggregateBy = strings.ToUpper(fn.text)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (p *parser) uint(limit uint64) (uint64, error) {
+	t := p.peek()
+	if t.kind != tokNumber {
+		return 0, p.errorf("expect a number, but got %s", t)
+	}
+	n, err := strconv.ParseUint(t.text, 10, 64)
+	if err != nil || n > limit {
+		return 0, p.errorf("invalid number %s", t)
+	}
+	p.pos++
+	return n, nil
+}
+
+func (p *parser) parseTime() (*TimeCondition, error) {
+	tc := &TimeCondition{}
+	if p.accept("BETWEEN") {
+		begin, err := p.parseTimePoint()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expect("AND"); err != nil {
+			return nil, err
+		}
+		end, err := p.parseTimePoint()
+		if err != nil {
+			return nil, err
+		}
+		tc.Begin, tc.End = &begin, &end
+		return tc, nil
+	}
+	for {
+		op := p.peek()
+		lower := op.is(">") || op.is(">=")
+		upper := op.is("<") || op.is("<=")
+		if !(lower && tc.Begin == nil) && !(upper && tc.End == nil) {
+			return nil, p.errorf("expect one of >, >=, < and <= bounding each side of the time once, but got %s", op)
+		}
+		p.pos++
+		tp, err := p.parseTimePoint()
+		if err != nil {
+			return nil, err
+		}
+		if lower {
+			tc.Begin, tc.BeginExclusive = &tp, op.is(">")
+		} else {
+			tc.End, tc.EndExclusive = &tp, op.is("<")
+		}
+		// "TIME > t1 AND TIME < t2" bounds both sides.
+		if !p.peek().is("AND") || !p.peekAt(1).is("TIME") {
+			return tc, nil
+		}
+		p.pos += 2
+	}
+}
+
+// parseTimePoint reads an RFC3339 time, a duration relative to now like '-30m', or NOW() [+|- duration].
+func (p *parser) parseTimePoint() (TimePoint, error) {
+	t := p.peek()
+	if t.kind == tokString {
+		p.pos++
+		if abs, err := time.Parse(time.RFC3339Nano, t.text); err == nil {
+			return TimePoint{Time: abs}, nil
+		}
+		d, err := timestamp.ParseDuration(t.text)
+		if err != nil {
+			p.pos--
+			return TimePoint{}, p.errorf("the time %s is neither an RFC3339 time nor a relative duration", t)
+		}
+		return TimePoint{Offset: d, Relative: true}, nil
+	}
+	if !t.is("NOW") {
+		return TimePoint{}, p.errorf("expect a time, but got %s", t)
+	}
+	p.pos++
+	if err := p.expect("("); err != nil {
+		return TimePoint{}, err
+	}
+	if err := p.expect(")"); err != nil {
+		return TimePoint{}, err
+	}
+	tp := TimePoint{Relative: true}
+	if sign := p.peek(); sign.is("+") || sign.is("-") {
+		p.pos++
+		d, err := p.duration()
+		if err != nil {
+			return TimePoint{}, err
+		}
+		if sign.is("-") {
+			d = -d
+		}
+		tp.Offset = d
+	}
+	return tp, nil
+}
+
+func (p *parser) duration() (time.Duration, error) {
+	t := p.peek()
+	if t.kind != tokDuration {
+		return 0, p.errorf("expect a duration, but got %s", t)
+	}
+	d, err := timestamp.ParseDuration(t.text)
+	if err != nil {
+		return 0, p.errorf("invalid duration %s", t)
+	}
+	p.pos++
+	return d, nil
+}
+
+func (p *parser) parseGroupBy() (*GroupBy, error) {
+	if err := p.expect("BY"); err != nil {
+		return nil, err
+	}
+	gb := &GroupBy{}
+	for {
+		if p.peek().is("TIME") && p.peekAt(1).is("(") {
+			if gb.TimeBucket != "" {
+				return nil, p.errorf("duplicate time bucket")
+			}
+			p.pos += 2
+			t := p.peek()
+			if _, err := p.duration(); err != nil {
+				return nil, err
+			}
+			gb.TimeBucket = t.text
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+		} else {
+			tag, err := p.name("tag")
+			if err != nil {
+				return nil, err
+			}
+			gb.Tags = append(gb.Tags, tag)
+		}
+		if !p.accept(",") {
+			return gb, nil
+		}
+	}
+}
+
+func (p *parser) parseOrderBy() (*OrderBy, error) {
+	if err := p.expect("BY"); err != nil {
+		return nil, err
+	}
+	ob := &OrderBy{}
+	if !p.accept("TIME") {
+		var err error
+		if ob.Name, err = p.name("tag, field or alias"); err != nil {
+			return nil, err
+		}
+	}
+	switch {
+	case p.accept("ASC"):
+		ob.Asc = true
+	case p.accept("DESC"):
+		ob.Desc = true
+	}
+	return ob, nil
+}
+
+// parseExpr parses the conditions combined by AND and OR, where AND binds tighter than OR.
+func (p *parser) parseExpr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &Logical{Op: OpOr, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("AND") {
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		left = &Logical{Op: OpAnd, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	if p.accept("(") {
+		e, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expect(")"); err != nil {
+			return nil, err
+		}
+		return e, nil
+	}
+	return p.parseCondition()
+}
+
+func (p *parser) parseCondition() (*Condition, error) {
+	c := &Condition{}
+	var err error
+	if c.Name, err = p.name("tag"); err != nil {
+		return nil, err
+	}
+	op := p.next()
+	list := false
+	switch {
+	case op.kind == tokSymbol && (op.text == CmpEQ || op.text == CmpNE || op.text == CmpLT ||
+		op.text == CmpGT || op.text == CmpLE || op.text == CmpGE):
+		c.Op = op.text
+	case op.is("NOT"):
+		switch next := p.next(); {
+		case next.is("IN"):
+			c.Op = CmpNotIn
+		case next.is("HAVING"):
+			c.Op = CmpNotHaving
+		default:
+			p.unread(next)
+			return nil, p.errorf("expect IN or HAVING after NOT, but got %s", next)
+		}
+		list = true
+	case op.is(CmpIn), op.is(CmpHaving):
+		c.Op, list = strings.ToUpper(op.text), true
+	case op.is(CmpMatch), op.is(CmpPrefix), op.is(CmpWildcard), op.is(CmpRegex), op.is(CmpSubtree), op.is(CmpAncestors):
+		c.Op = strings.ToUpper(op.text)
+	default:
+		p.unread(op)
+		return nil, p.errorf("expect a comparison operator, but got %s", op)
+	}
+	if !list {
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		c.Values = []Value{v}
+		return c, nil
+	}
+	if err = p.expect("("); err != nil {
+		return nil, err
+	}
+	for {
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		c.Values = append(c.Values, v)
+		if !p.accept(",") {
+			break
+		}
+	}
+	if err = p.expect(")"); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (p *parser) value() (Value, error) {
+	neg := p.accept("-")
+	t := p.peek()
+	switch {
+	case t.kind == tokString && !neg:
+		p.pos++
+		return Value{Kind: ValueString, Str: t.text}, nil
+	case t.is("NULL") && !neg:
+		p.pos++
+		return Value{Kind: ValueNull}, nil
+	case t.kind == tokNumber:
+		text := t.text
+		if neg {
+			text = "-" + text
+		}
+		if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+			p.pos++
+			return Value{Kind: ValueInt, Int: i}, nil
+		}
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return Value{}, p.errorf("invalid number %s", text)
+		}
+		p.pos++
+		return Value{Kind: ValueFloat, Float: f}, nil
+	}
+	return Value{}, p.errorf("expect a value, but got %s", t)
+}
@@ -0,0 +1,175 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bydbql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	begin := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		want  *Query
+		query string
+	}{
+		{
+			query: "SELECT * FROM STREAM sw IN default",
+			want:  &Query{Kind: KindStream, Name: "sw", Groups: []string{"default"}},
+		},
+		{
+			query: "select trace_id, duration from stream sw in (g1, `g-2`) " +
+				"where service_id = 'svc''1' and (duration >= 100 or state != 0) order by duration desc limit 10 offset 5 with trace;",
+			want: &Query{
+				Kind:       KindStream,
+				Name:       "sw",
+				Groups:     []string{"g1", "g-2"},
+				Projection: []Projection{{Name: "trace_id"}, {Name: "duration"}},
+				Where: &Logical{
+					Op:   OpAnd,
+					Left: &Condition{Name: "service_id", Op: CmpEQ, Values: []Value{{Kind: ValueString, Str: "svc'1"}}},
+					Right: &Logical{
+						Op:    OpOr,
+						Left:  &Condition{Name: "duration", Op: CmpGE, Values: []Value{{Kind: ValueInt, Int: 100}}},
+						Right: &Condition{Name: "state", Op: CmpNE, Values: []Value{{Kind: ValueInt, Int: 0}}},
+					},
+				},
+				OrderBy: &OrderBy{Name: "duration", Desc: true},
+				Limit:   10,
+				Offset:  5,
+				Trace:   true,
+			},
+		},
+		{
+			query: "SELECT id, SUM(total), AVG(value) AS avg_value FROM MEASURE service_cpm IN sw_metric " +
+				"TIME BETWEEN '2024-01-01T00:00:00Z' AND NOW() " +
+				"WHERE entity_id IN ('a', 'b') OR layer NOT IN (1, -2) GROUP BY id, TIME(5m) HAVING avg_value > 1.5 ORDER BY avg_value",
+			want: &Query{
+				Kind:   KindMeasure,
+				Name:   "service_cpm",
+				Groups: []string{"sw_metric"},
+				Projection: []Projection{
+					{Name: "id"},
+					{Name: "total", Func: "SUM"},
+					{Name: "value", Func: "AVG", Alias: "avg_value"},
+				},
+				Time: &TimeCondition{Begin: &TimePoint{Time: begin}, End: &TimePoint{Relative: true}},
+				Where: &Logical{
+					Op: OpOr,
+					Left: &Condition{Name: "entity_id", Op: CmpIn, Values: []Value{
+						{Kind: ValueString, Str: "a"}, {Kind: ValueString, Str: "b"},
+					}},
+					Right: &Condition{Name: "layer", Op: CmpNotIn, Values: []Value{
+						{Kind: ValueInt, Int: 1}, {Kind: ValueInt, Int: -2},
+					}},
+				},
+				GroupBy: &GroupBy{Tags: []string{"id"}, TimeBucket: "5m"},
+				Having:  &Condition{Name: "avg_value", Op: CmpGT, Values: []Value{{Kind: ValueFloat, Float: 1.5}}},
+				OrderBy: &OrderBy{Name: "avg_value"},
+			},
+		},
+		{
+			query: "SELECT * FROM TRACE sw IN default TIME > NOW() - 1h AND TIME <= '-10m' WHERE tags HAVING ('a') AND name PREFIX 'get' ORDER BY TIME ASC",
+			want: &Query{
+				Kind:   KindTrace,
+				Name:   "sw",
+				Groups: []string{"default"},
+				Time: &TimeCondition{
+					Begin:          &TimePoint{Relative: true, Offset: -time.Hour},
+					BeginExclusive: true,
+					End:            &TimePoint{Relative: true, Offset: -10 * time.Minute},
+				},
+				Where: &Logical{
+					Op:    OpAnd,
+					Left:  &Condition{Name: "tags", Op: CmpHaving, Values: []Value{{Kind: ValueString, Str: "a"}}},
+					Right: &Condition{Name: "name", Op: CmpPrefix, Values: []Value{{Kind: ValueString, Str: "get"}}},
+				},
+				OrderBy: &OrderBy{Asc: true},
+			},
+		},
+		{
+			query: "explain analyze SELECT * FROM STREAM sw IN default",
+			want:  &Query{Kind: KindStream, Explain: ExplainAnalyze, Name: "sw", Groups: []string{"default"}},
+		},
+		{
+			query: "EXPLAIN SELECT * FROM MEASURE service_cpm IN sw_metric",
+			want:  &Query{Kind: KindMeasure, Explain: ExplainPlan, Name: "service_cpm", Groups: []string{"sw_metric"}},
+		},
+		{
+			query: "SELECT BOTTOM 5 FROM TOPN endpoint_top IN sw_metric WHERE service = 'svc' AGGREGATE BY mean",
+			want: &Query{
+				Kind:        KindTopN,
+				Name:        "endpoint_top",
+				Groups:      []string{"sw_metric"},
+				TopN:        5,
+				Bottom:      true,
+				Where:       &Condition{Name: "service", Op: CmpEQ, Values: []Value{{Kind: ValueString, Str: "svc"}}},
+				AggregateBy: "MEAN",
+			},
+		},
+		{
+			query: "SELECT name, value FROM PROPERTY ui_template IN sw WHERE id = 'a' AND kind = NULL LIMIT 3",
+			want: &Query{
+				Kind:       KindProperty,
+				Name:       "ui_template",
+				Groups:     []string{"sw"},
+				Projection: []Projection{{Name: "name"}, {Name: "value"}},
+				Where: &Logical{
+					Op:    OpAnd,
+					Left:  &Condition{Name: "id", Op: CmpEQ, Values: []Value{{Kind: ValueString, Str: "a"}}},
+					Right: &Condition{Name: "kind", Op: CmpEQ, Values: []Value{{Kind: ValueNull}}},
+				},
+				Limit: 3,
+			},
+		},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.query)
+		require.NoError(t, err, tt.query)
+		assert.Equal(t, tt.want, got, tt.query)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		query string
+		msg   string
+	}{
+		{query: "SELECT * FROM TABLE t IN g", msg: "expect one of STREAM"},
+		{query: "SELECT * FROM STREAM s", msg: "expect IN"},
+		{query: "SELECT TOP 3 FROM MEASURE m IN g", msg: "only used to query a TOPN"},
+		{query: "SELECT * FROM TOPN t IN g", msg: "only used to query a TOPN"},
+		{query: "SELECT * FROM STREAM s IN g LIMIT 1 LIMIT 2", msg: "duplicate LIMIT clause"},
+		{query: "SELECT * FROM STREAM s IN g WHERE a ~ 1", msg: "unexpected '~'"},
+		{query: "SELECT * FROM STREAM s IN g WHERE a NOT LIKE 1", msg: "expect IN or HAVING after NOT"},
+		{query: "SELECT * FROM STREAM s IN g WHERE a = 'b", msg: "not closed"},
+		{query: "SELECT * FROM STREAM s IN g TIME > '2024' ", msg: "neither an RFC3339 time"},
+		{query: "SELECT * FROM STREAM s IN g TIME > NOW() AND TIME > NOW()", msg: "bounding each side of the time once"},
+		{query: "SELECT * FROM STREAM s IN g extra", msg: "unexpected extra"},
+		{query: "SELECT SUM(*) FROM MEASURE m IN g", msg: "expect the field name"},
+		{query: "EXPLAIN ANALYZE ANALYZE SELECT * FROM STREAM s IN g", msg: "expect SELECT"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.query)
+		require.ErrorIs(t, err, ErrParse, tt.query)
+		assert.Contains(t, err.Error(), tt.msg, tt.query)
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/apache/skywalking-banyandb/api/common"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	"github.com/apache/skywalking-banyandb/banyand/measure"
 	"github.com/apache/skywalking-banyandb/banyand/metadata"
 	"github.com/apache/skywalking-banyandb/banyand/observability"
@@ -31,8 +32,11 @@ import (
 	"github.com/apache/skywalking-banyandb/banyand/protector"
 	"github.com/apache/skywalking-banyandb/banyand/query"
 	"github.com/apache/skywalking-banyandb/banyand/queue"
+	"github.com/apache/skywalking-banyandb/banyand/queue/pub"
 	"github.com/apache/skywalking-banyandb/banyand/queue/sub"
+	"github.com/apache/skywalking-banyandb/banyand/repair"
 	"github.com/apache/skywalking-banyandb/banyand/stream"
+	"github.com/apache/skywalking-banyandb/banyand/trace"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	"github.com/apache/skywalking-banyandb/pkg/run"
 	"github.com/apache/skywalking-banyandb/pkg/version"
@@ -62,6 +66,17 @@ func newDataCmd(runners ...run.Unit) *cobra.Command {
 	if err != nil {
 		l.Fatal().Err(err).Msg("failed to initiate measure service")
 	}
+	// The data nodes connect to each other by the same TLS settings as the liaison nodes connect to them.
+	clientTLS := pub.NewClientTLS()
+	stream.SetClientTLS(streamSvc, clientTLS)
+	measure.SetClientTLS(measureSvc, clientTLS)
+	repairSvc := repair.NewService(metaSvc, pipeline, metricSvc, propertySvc.GossipMessenger())
+	repairSvc.Register(commonv1.Catalog_CATALOG_STREAM, stream.NewRepairCatalog(streamSvc))
+	repairSvc.Register(commonv1.Catalog_CATALOG_MEASURE, measure.NewRepairCatalog(measureSvc))
+	traceSvc, err := trace.NewService(metaSvc, pipeline, metricSvc, pm)
+	if err != nil {
+		l.Fatal().Err(err).Msg("failed to initiate trace service")
+	}
 	q, err := query.NewService(ctx, streamSvc, measureSvc, metaSvc, pipeline)
 	if err != nil {
 		l.Fatal().Err(err).Msg("failed to initiate query processor")
@@ -76,10 +91,14 @@ func newDataCmd(runners ...run.Unit) *cobra.Command {
 		metricSvc,
 		pm,
 		pipeline,
+		clientTLS,
 		propertyStreamPipeline,
-		propertySvc,
 		measureSvc,
 		streamSvc,
+		// The replica repair subscribes to the gossip messenger before the property service starts it.
+		repairSvc,
+		propertySvc,
+		traceSvc,
 		q,
 		profSvc,
 	)
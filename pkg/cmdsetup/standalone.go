@@ -35,6 +35,7 @@ import (
 	"github.com/apache/skywalking-banyandb/banyand/query"
 	"github.com/apache/skywalking-banyandb/banyand/queue"
 	"github.com/apache/skywalking-banyandb/banyand/stream"
+	"github.com/apache/skywalking-banyandb/banyand/trace"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	"github.com/apache/skywalking-banyandb/pkg/run"
 	"github.com/apache/skywalking-banyandb/pkg/version"
@@ -65,6 +66,10 @@ func newStandaloneCmd(runners ...run.Unit) *cobra.Command {
 	if err != nil {
 		l.Fatal().Err(err).Msg("failed to initiate measure service")
 	}
+	traceSvc, err := trace.NewService(metaSvc, dataPipeline, metricSvc, pm)
+	if err != nil {
+		l.Fatal().Err(err).Msg("failed to initiate trace service")
+	}
 	q, err := query.NewService(ctx, streamSvc, measureSvc, metaSvc, dataPipeline)
 	if err != nil {
 		l.Fatal().Err(err).Msg("failed to initiate query processor")
@@ -74,6 +79,7 @@ func newStandaloneCmd(runners ...run.Unit) *cobra.Command {
 		MeasureLiaisonNodeRegistry: nr,
 		StreamLiaisonNodeRegistry:  nr,
 		PropertyNodeRegistry:       nr,
+		TraceLiaisonNodeRegistry:   nr,
 	}, metricSvc)
 	profSvc := observability.NewProfService()
 	httpServer := http.NewServer(grpcServer.GetAuthCfg())
@@ -88,6 +94,7 @@ func newStandaloneCmd(runners ...run.Unit) *cobra.Command {
 		propertySvc,
 		measureSvc,
 		streamSvc,
+		traceSvc,
 		q,
 		grpcServer,
 		httpServer,
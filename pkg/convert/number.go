@@ -136,6 +136,30 @@ func BytesToFloat64(b []byte) float64 {
 	return math.Float64frombits(binary.BigEndian.Uint64(b))
 }
 
+// Float64ToSortableBytes converts float64 to bytes whose lexicographic order is the same as the numeric order.
+func Float64ToSortableBytes(f float64) []byte {
+	u := math.Float64bits(f)
+	if u&(1<<63) != 0 {
+		u = ^u
+	} else {
+		u |= 1 << 63
+	}
+	bs := make([]byte, 8)
+	binary.BigEndian.PutUint64(bs, u)
+	return bs
+}
+
+// SortableBytesToFloat64 converts bytes encoded by Float64ToSortableBytes to float64.
+func SortableBytesToFloat64(b []byte) float64 {
+	u := binary.BigEndian.Uint64(b)
+	if u&(1<<63) != 0 {
+		u &^= 1 << 63
+	} else {
+		u = ^u
+	}
+	return math.Float64frombits(u)
+}
+
 // BytesToBool converts bytes to bool.
 func BytesToBool(b []byte) bool {
 	if len(b) == 0 {
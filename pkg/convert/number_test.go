@@ -20,6 +20,7 @@ package convert
 import (
 	"bytes"
 	"fmt"
+	"math"
 	"testing"
 )
 
@@ -47,6 +48,19 @@ func TestInt64ToBytes(t *testing.T) {
 	}
 }
 
+func TestFloat64ToSortableBytes(t *testing.T) {
+	inputs := []float64{math.Inf(-1), -math.MaxFloat64, -100.5, -1, -math.SmallestNonzeroFloat64, 0, math.SmallestNonzeroFloat64, 1, 2.5, 100, math.MaxFloat64, math.Inf(1)}
+	for i, input := range inputs {
+		result := Float64ToSortableBytes(input)
+		if got := SortableBytesToFloat64(result); got != input {
+			t.Errorf("Expected %v, got %v", input, got)
+		}
+		if i > 0 && bytes.Compare(Float64ToSortableBytes(inputs[i-1]), result) >= 0 {
+			t.Errorf("Expected bytes of %v to be less than bytes of %v", inputs[i-1], input)
+		}
+	}
+}
+
 func TestBoolToBytes(t *testing.T) {
 	testCases := []struct {
 		expected []byte
@@ -93,6 +93,14 @@ func NewIntField(key FieldKey, value int64) Field {
 	}
 }
 
+// NewFloatField creates a new float field.
+func NewFloatField(key FieldKey, value float64) Field {
+	return Field{
+		term: &FloatTermValue{Value: value},
+		Key:  key,
+	}
+}
+
 // NewBytesField creates a new bytes field.
 func NewBytesField(key FieldKey, value []byte) Field {
 	return Field{
@@ -414,6 +422,16 @@ func NewIntRangeOpts(lower, upper int64, includesLower, includesUpper bool) Rang
 	}
 }
 
+// NewFloatRangeOpts creates a new float range option.
+func NewFloatRangeOpts(lower, upper float64, includesLower, includesUpper bool) RangeOpts {
+	return RangeOpts{
+		Lower:         &FloatTermValue{Value: lower},
+		Upper:         &FloatTermValue{Value: upper},
+		IncludesLower: includesLower,
+		IncludesUpper: includesUpper,
+	}
+}
+
 // NewBytesRangeOpts creates a new bytes range option.
 func NewBytesRangeOpts(lower, upper []byte, includesLower, includesUpper bool) RangeOpts {
 	if len(upper) == 0 {
@@ -812,6 +830,7 @@ type Searcher interface {
 	Match(fieldKey FieldKey, match []string, opts *modelv1.Condition_MatchOption) (list posting.List, timestamps posting.List, err error)
 	MatchField(fieldKey FieldKey) (list posting.List, timestamps posting.List, err error)
 	MatchTerms(field Field) (list posting.List, timestamps posting.List, err error)
+	MatchPattern(fieldKey FieldKey, pattern *Pattern) (list posting.List, timestamps posting.List, err error)
 	Range(fieldKey FieldKey, opts RangeOpts) (list posting.List, timestamps posting.List, err error)
 }
 
@@ -820,11 +839,22 @@ type Query interface {
 	fmt.Stringer
 }
 
+// Statistics describes how the terms are distributed in an index,
+// by which the query optimizer estimates the selectivity of the filters.
+type Statistics interface {
+	// DocCount returns the number of the documents in the index.
+	DocCount() (uint64, error)
+	// TermDocCount returns the number of the documents whose terms of the field are in the range.
+	// It returns DocCount if the range is invalid.
+	TermDocCount(fieldKey FieldKey, opts RangeOpts) (uint64, error)
+}
+
 // Store is an abstract of an index repository.
 type Store interface {
 	io.Closer
 	Writer
 	Searcher
+	Statistics
 	CollectMetrics(...string)
 	Reset()
 	TakeFileSnapshot(dst string) error
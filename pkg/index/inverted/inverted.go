@@ -473,6 +473,42 @@ func (s *store) Match(fieldKey index.FieldKey, matches []string, opts *modelv1.C
 	return list, timestamps, err
 }
 
+func (s *store) MatchPattern(fieldKey index.FieldKey, pattern *index.Pattern) (list posting.List, timestamps posting.List, err error) {
+	reader, err := s.writer.Reader()
+	if err != nil {
+		return nil, nil, err
+	}
+	query := bluge.NewBooleanQuery()
+	query.AddMust(newPatternQuery(pattern, fieldKey.Marshal()))
+	query.AddMust(bluge.NewTermQuery(string(fieldKey.SeriesID.Marshal())).SetField(seriesIDField))
+	_ = appendTimeRangeToQuery(query, fieldKey)
+	documentMatchIterator, err := reader.Search(context.Background(), bluge.NewAllMatches(query))
+	if err != nil {
+		return nil, nil, err
+	}
+	iter := newBlugeMatchIterator(documentMatchIterator, reader, defaultProjection)
+	defer func() {
+		err = multierr.Append(err, iter.Close())
+	}()
+	list, timestamps = roaring.NewPostingList(), roaring.NewPostingList()
+	for iter.Next() {
+		list.Insert(iter.Val().DocID)
+		timestamps.Insert(uint64(iter.Val().Timestamp))
+	}
+	return list, timestamps, err
+}
+
+func newPatternQuery(pattern *index.Pattern, field string) bluge.Query {
+	switch pattern.Type {
+	case index.PatternTypeWildcard:
+		return bluge.NewWildcardQuery(pattern.Expr).SetField(field)
+	case index.PatternTypeRegex:
+		return bluge.NewRegexpQuery(pattern.Expr).SetField(field)
+	default:
+		return bluge.NewPrefixQuery(pattern.Expr).SetField(field)
+	}
+}
+
 func getMatchOptions(analyzerOnIndexRule string, opts *modelv1.Condition_MatchOption) (*analysis.Analyzer, bluge.MatchQueryOperator) {
 	a := analyzer.Analyzers[analyzerOnIndexRule]
 	operator := bluge.MatchQueryOperatorOr
@@ -22,6 +22,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/blugelabs/bluge"
 	"github.com/blugelabs/bluge/numeric"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -354,6 +355,85 @@ func TestStore_NumericMatch(t *testing.T) {
 	tester.True(roaring.NewPostingListWithInitialData(1).Equal(l))
 }
 
+func TestStore_Statistics(t *testing.T) {
+	tester := require.New(t)
+	path, fn := setUp(tester)
+	s, err := NewStore(StoreOpts{
+		Path:   path,
+		Logger: logger.GetLogger("test"),
+	})
+	tester.NoError(err)
+	defer func() {
+		tester.NoError(s.Close())
+		fn()
+	}()
+	serviceName := index.FieldKey{
+		IndexRuleID: 6,
+	}
+	durationName := index.FieldKey{
+		IndexRuleID: 7,
+	}
+	var batch index.Batch
+	for i, svc := range []string{"svc1", "svc1", "svc1", "svc2", "svc3"} {
+		batch.Documents = append(batch.Documents, index.Document{
+			Fields: []index.Field{
+				index.NewStringField(serviceName, svc),
+				index.NewIntField(durationName, int64(i*100)),
+			},
+			DocID: uint64(i + 1),
+		})
+	}
+	tester.NoError(s.Batch(batch))
+
+	n, err := s.DocCount()
+	tester.NoError(err)
+	tester.Equal(uint64(5), n)
+	for _, tc := range []struct {
+		name string
+		key  index.FieldKey
+		opts index.RangeOpts
+		want uint64
+	}{
+		{name: "term", key: serviceName, opts: index.NewStringRangeOpts("svc1", "svc1", true, true), want: 3},
+		{name: "missing term", key: serviceName, opts: index.NewStringRangeOpts("svc4", "svc4", true, true), want: 0},
+		{name: "exclusive string range", key: serviceName, opts: index.NewStringRangeOpts("svc1", "svc3", false, false), want: 1},
+		{name: "inclusive string range", key: serviceName, opts: index.NewStringRangeOpts("svc1", "svc3", true, true), want: 5},
+		{name: "numeric term", key: durationName, opts: index.NewIntRangeOpts(200, 200, true, true), want: 1},
+		{name: "numeric range", key: durationName, opts: index.NewIntRangeOpts(100, 400, false, true), want: 3},
+		{name: "invalid range", key: durationName, opts: index.RangeOpts{}, want: 5},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := s.TermDocCount(tc.key, tc.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+
+	svc := func(name string) bluge.Query {
+		return bluge.NewTermQuery(name).SetField(serviceName.Marshal())
+	}
+	for _, tc := range []struct {
+		query bluge.Query
+		name  string
+		want  uint64
+		known bool
+	}{
+		{name: "term", query: svc("svc1"), want: 3, known: true},
+		{name: "and", query: bluge.NewBooleanQuery().AddMust(svc("svc1"), svc("svc2")), want: 1, known: true},
+		{name: "or", query: bluge.NewBooleanQuery().AddShould(svc("svc1"), svc("svc2")).SetMinShould(1), want: 4, known: true},
+		{name: "not", query: bluge.NewBooleanQuery().AddMustNot(svc("svc1")), want: 2, known: true},
+		{name: "match all", query: bluge.NewMatchAllQuery(), want: 5, known: true},
+		{name: "pattern", query: bluge.NewBooleanQuery().AddMust(svc("svc1"), bluge.NewWildcardQuery("svc*").SetField(serviceName.Marshal())), want: 3},
+	} {
+		t.Run("estimate "+tc.name, func(t *testing.T) {
+			got, known, err := EstimateDocs(&queryNode{query: tc.query}, s)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+			assert.Equal(t, tc.known, known)
+		})
+	}
+}
+
 func setUp(t *require.Assertions) (tempDir string, deferFunc func()) {
 	t.NoError(logger.Init(logger.Logging{
 		Env:   "dev",
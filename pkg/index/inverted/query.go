@@ -380,6 +380,48 @@ func parseConditionToQuery(cond *modelv1.Condition, indexRule *databasev1.IndexR
 		query.AddMustNot(subQuery)
 		node.SetSubNode(subNode)
 		return &queryNode{query, node}, nil
+	case modelv1.Condition_BINARY_OP_SUBTREE:
+		if indexRule.GetType() != databasev1.IndexRule_TYPE_TREE {
+			return nil, errors.WithMessagef(logical.ErrUnsupportedConditionOp, "tree index rule is mandatory for subtree operation: %s", cond)
+		}
+		path, err := logical.ParseTreePath(cond)
+		if err != nil {
+			return nil, err
+		}
+		path = index.NormalizeTreePath(path, index.TreeSeparator(indexRule))
+		treeKey := index.TreeFieldKey(index.FieldKey{IndexRuleID: indexRule.Metadata.Id}).Marshal()
+		query := bluge.NewTermQuery(path).SetField(treeKey)
+		node := newSubtreeNode(path, indexRule)
+		return &queryNode{query, node}, nil
+	case modelv1.Condition_BINARY_OP_PREFIX, modelv1.Condition_BINARY_OP_WILDCARD, modelv1.Condition_BINARY_OP_REGEX:
+		if indexRule == nil {
+			return nil, errors.WithMessagef(logical.ErrUnsupportedConditionOp, "index rule is mandatory for %s operation: %s", cond.Op, cond)
+		}
+		if indexRule.Analyzer != index.AnalyzerUnspecified && indexRule.Analyzer != index.AnalyzerKeyword {
+			return nil, errors.WithMessagef(logical.ErrUnsupportedConditionOp, "%s doesn't support the index analyzed by %s: %s", cond.Op, indexRule.Analyzer, cond)
+		}
+		pattern, err := logical.ParsePattern(cond)
+		if err != nil {
+			return nil, err
+		}
+		query := newPatternQuery(pattern, fieldKey)
+		node := newPatternNode(pattern, indexRule)
+		return &queryNode{query, node}, nil
+	case modelv1.Condition_BINARY_OP_ANCESTORS:
+		path, err := logical.ParseTreePath(cond)
+		if err != nil {
+			return nil, err
+		}
+		ancestors := logical.AncestorsExpr(path, index.TreeSeparator(indexRule))
+		query, node := bluge.NewBooleanQuery(), newShouldNode()
+		query.SetMinShould(1)
+		for _, b := range ancestors.Bytes() {
+			query.AddShould(bluge.NewTermQuery(string(b)).SetField(fieldKey))
+		}
+		for _, e := range ancestors.Elements() {
+			node.Append(newTermNode(e, indexRule))
+		}
+		return &queryNode{query, node}, nil
 	}
 	return nil, errors.WithMessagef(logical.ErrUnsupportedConditionOp, "index filter parses %v", cond)
 }
@@ -574,6 +616,56 @@ func (m *matchNode) String() string {
 	return convert.JSONToString(m)
 }
 
+type subtreeNode struct {
+	indexRule *databasev1.IndexRule
+	path      string
+}
+
+func newSubtreeNode(path string, indexRule *databasev1.IndexRule) *subtreeNode {
+	return &subtreeNode{
+		indexRule: indexRule,
+		path:      path,
+	}
+}
+
+func (s *subtreeNode) MarshalJSON() ([]byte, error) {
+	inner := make(map[string]interface{}, 1)
+	inner["index"] = s.indexRule.Metadata.Name + ":" + s.indexRule.Metadata.Group
+	inner["path"] = s.path
+	data := make(map[string]interface{}, 1)
+	data["subtree"] = inner
+	return json.Marshal(data)
+}
+
+func (s *subtreeNode) String() string {
+	return convert.JSONToString(s)
+}
+
+type patternNode struct {
+	indexRule *databasev1.IndexRule
+	pattern   *index.Pattern
+}
+
+func newPatternNode(pattern *index.Pattern, indexRule *databasev1.IndexRule) *patternNode {
+	return &patternNode{
+		indexRule: indexRule,
+		pattern:   pattern,
+	}
+}
+
+func (p *patternNode) MarshalJSON() ([]byte, error) {
+	inner := make(map[string]interface{}, 1)
+	inner["index"] = p.indexRule.Metadata.Name + ":" + p.indexRule.Metadata.Group
+	inner["value"] = p.pattern.Expr
+	data := make(map[string]interface{}, 1)
+	data[p.pattern.Type.String()] = inner
+	return json.Marshal(data)
+}
+
+func (p *patternNode) String() string {
+	return convert.JSONToString(p)
+}
+
 type prefixNode struct {
 	prefix string
 }
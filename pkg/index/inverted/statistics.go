@@ -0,0 +1,166 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package inverted
+
+import (
+	"bytes"
+
+	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/numeric"
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+
+	"github.com/apache/skywalking-banyandb/pkg/index"
+)
+
+// maxStatisticsTerms bounds the terms TermDocCount visits. A range covering more terms
+// is regarded as matching all documents, which is rarely selective enough to be worth estimating.
+const maxStatisticsTerms = 1 << 16
+
+var _ index.Statistics = (*store)(nil)
+
+// DocCount implements index.Statistics.
+func (s *store) DocCount() (n uint64, err error) {
+	reader, err := s.writer.Reader()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		err = multierr.Append(err, reader.Close())
+	}()
+	return reader.Count()
+}
+
+// TermDocCount implements index.Statistics.
+// It sums up the document frequencies in the term dictionary of the field, which include the deleted documents.
+func (s *store) TermDocCount(fieldKey index.FieldKey, opts index.RangeOpts) (n uint64, err error) {
+	if !opts.Valid() {
+		return s.DocCount()
+	}
+	var lower, upper []byte
+	switch l := opts.Lower.(type) {
+	case *index.BytesTermValue:
+		lower, upper = l.Value, opts.Upper.(*index.BytesTermValue).Value
+	case *index.FloatTermValue:
+		// The numeric terms are prefix coded, and the ones shifted by zero keep the full precision.
+		lower = numeric.MustNewPrefixCodedInt64(numeric.Float64ToInt64(l.Value), 0)
+		upper = numeric.MustNewPrefixCodedInt64(numeric.Float64ToInt64(opts.Upper.(*index.FloatTermValue).Value), 0)
+	default:
+		return 0, errors.Errorf("unexpected term type: %T", opts.Lower)
+	}
+	// The end of the dictionary iterator is exclusive.
+	end := upper
+	if opts.IncludesUpper {
+		end = append(bytes.Clone(upper), 0)
+	}
+	reader, err := s.writer.Reader()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		err = multierr.Append(err, reader.Close())
+	}()
+	dict, err := reader.DictionaryIterator(fieldKey.Marshal(), nil, lower, end)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		err = multierr.Append(err, dict.Close())
+	}()
+	for i := 0; ; i++ {
+		if i >= maxStatisticsTerms {
+			return reader.Count()
+		}
+		de, nextErr := dict.Next()
+		if nextErr != nil {
+			return 0, nextErr
+		}
+		if de == nil {
+			return n, nil
+		}
+		if !opts.IncludesLower && de.Term() == string(lower) {
+			continue
+		}
+		n += de.Count()
+	}
+}
+
+// EstimateDocs estimates the number of the documents in the index which the query built by BuildQuery matches.
+// The estimation isn't known if the query has the clauses the term dictionary can't count,
+// like the ones matching the analyzed text or a pattern.
+func EstimateDocs(query index.Query, stats index.Statistics) (docs uint64, known bool, err error) {
+	qn, ok := query.(*queryNode)
+	if !ok {
+		return 0, false, nil
+	}
+	total, err := stats.DocCount()
+	if err != nil {
+		return 0, false, err
+	}
+	return estimateDocs(qn.query, stats, total)
+}
+
+func estimateDocs(query bluge.Query, stats index.Statistics, total uint64) (uint64, bool, error) {
+	switch q := query.(type) {
+	case *bluge.MatchAllQuery:
+		return total, true, nil
+	case *bluge.TermQuery:
+		n, err := stats.TermDocCount(index.FieldKey{TagName: q.Field()}, index.NewStringRangeOpts(q.Term(), q.Term(), true, true))
+		return n, err == nil, err
+	case *bluge.TermRangeQuery:
+		lower, includesLower := q.Min()
+		upper, includesUpper := q.Max()
+		n, err := stats.TermDocCount(index.FieldKey{TagName: q.Field()}, index.NewStringRangeOpts(lower, upper, includesLower, includesUpper))
+		return n, err == nil, err
+	case *bluge.BooleanQuery:
+		// A boolean query matches no more documents than its most selective clause.
+		docs, known := total, true
+		for _, m := range q.Musts() {
+			n, k, err := estimateDocs(m, stats, total)
+			if err != nil {
+				return 0, false, err
+			}
+			docs, known = min(docs, n), known && k
+		}
+		if len(q.Shoulds()) > 0 && (q.MinShould() > 0 || len(q.Musts()) == 0) {
+			var sum uint64
+			for _, s := range q.Shoulds() {
+				n, k, err := estimateDocs(s, stats, total)
+				if err != nil {
+					return 0, false, err
+				}
+				sum, known = sum+n, known && k
+			}
+			docs = min(docs, sum, total)
+		}
+		for _, mn := range q.MustNots() {
+			n, k, err := estimateDocs(mn, stats, total)
+			if err != nil {
+				return 0, false, err
+			}
+			if !k {
+				known = false
+				continue
+			}
+			docs = min(docs, total-min(n, total))
+		}
+		return docs, known, nil
+	}
+	// The clauses the term dictionary can't count are regarded as matching all documents.
+	return total, false, nil
+}
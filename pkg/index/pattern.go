@@ -0,0 +1,148 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package index
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// PatternType is the type of a Pattern.
+type PatternType int
+
+// PatternType values.
+const (
+	PatternTypePrefix PatternType = iota
+	PatternTypeWildcard
+	PatternTypeRegex
+)
+
+// String returns the name of the pattern type.
+func (pt PatternType) String() string {
+	switch pt {
+	case PatternTypePrefix:
+		return "prefix"
+	case PatternTypeWildcard:
+		return "wildcard"
+	case PatternTypeRegex:
+		return "regex"
+	}
+	return fmt.Sprintf("PatternType(%d)", int(pt))
+}
+
+// ErrInvalidPattern is returned when a pattern can't be compiled.
+var ErrInvalidPattern = errors.New("invalid pattern")
+
+// wildcardReplacer converts a wildcard to a regular expression the same way as the inverted index does.
+var wildcardReplacer = strings.NewReplacer(
+	"+", `\+`,
+	"(", `\(`,
+	")", `\)`,
+	"^", `\^`,
+	"$", `\$`,
+	".", `\.`,
+	"{", `\{`,
+	"}", `\}`,
+	"[", `\[`,
+	"]", `\]`,
+	"|", `\|`,
+	`\`, `\\`,
+	"*", ".*",
+	"?", ".",
+)
+
+// Pattern matches string terms by a prefix, a wildcard or a regular expression.
+// Wildcards and regular expressions match the whole term.
+type Pattern struct {
+	re   *regexp.Regexp
+	Expr string
+	Type PatternType
+}
+
+// NewPattern compiles a pattern.
+// A wildcard supports "*" which matches any sequence of characters and "?" which matches a single character.
+// A regular expression follows the RE2 syntax without the empty-width assertions like "^", "$" and "\b",
+// which the inverted index can't evaluate.
+func NewPattern(patternType PatternType, expr string) (*Pattern, error) {
+	p := &Pattern{Type: patternType, Expr: expr}
+	var re string
+	switch patternType {
+	case PatternTypePrefix:
+		return p, nil
+	case PatternTypeWildcard:
+		re = wildcardReplacer.Replace(expr)
+	case PatternTypeRegex:
+		parsed, err := syntax.Parse(expr, syntax.Perl)
+		if err != nil {
+			return nil, errors.WithMessagef(ErrInvalidPattern, "%s: %v", expr, err)
+		}
+		if hasEmptyWidth(parsed) {
+			return nil, errors.WithMessagef(ErrInvalidPattern, "%s: empty-width assertions are not supported", expr)
+		}
+		re = expr
+	default:
+		return nil, errors.WithMessagef(ErrInvalidPattern, "unknown pattern type %s", patternType)
+	}
+	compiled, err := regexp.Compile(`^(?:` + re + `)$`)
+	if err != nil {
+		return nil, errors.WithMessagef(ErrInvalidPattern, "%s: %v", expr, err)
+	}
+	p.re = compiled
+	return p, nil
+}
+
+func hasEmptyWidth(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText,
+		syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return true
+	}
+	for _, sub := range re.Sub {
+		if hasEmptyWidth(sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match reports whether the term matches the pattern.
+func (p *Pattern) Match(term string) bool {
+	if p.Type == PatternTypePrefix {
+		return strings.HasPrefix(term, p.Expr)
+	}
+	return p.re.MatchString(term)
+}
+
+// Literal returns the only term which matches the pattern.
+// It returns false if the pattern matches more than one term.
+func (p *Pattern) Literal() (string, bool) {
+	if p.Type == PatternTypePrefix {
+		return "", false
+	}
+	prefix, complete := p.re.LiteralPrefix()
+	return prefix, complete
+}
+
+// String returns the type and the expression of the pattern.
+func (p *Pattern) String() string {
+	return p.Type.String() + ":" + p.Expr
+}
@@ -0,0 +1,94 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package index
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatternMatch(t *testing.T) {
+	tests := []struct {
+		expr        string
+		matched     []string
+		unmatched   []string
+		patternType PatternType
+	}{
+		{
+			patternType: PatternTypePrefix,
+			expr:        "/api/v1/",
+			matched:     []string{"/api/v1/", "/api/v1/users"},
+			unmatched:   []string{"/api/v1", "/api/v10/users"},
+		},
+		{
+			patternType: PatternTypeWildcard,
+			expr:        "/api/v?/users*",
+			matched:     []string{"/api/v1/users", "/api/v2/users/1"},
+			unmatched:   []string{"/api/v10/users", "x/api/v1/users"},
+		},
+		{
+			patternType: PatternTypeWildcard,
+			expr:        "a.b(c)",
+			matched:     []string{"a.b(c)"},
+			unmatched:   []string{"axb(c)", "a.bc"},
+		},
+		{
+			patternType: PatternTypeRegex,
+			expr:        "/api/v[0-9]+/users",
+			matched:     []string{"/api/v1/users", "/api/v10/users"},
+			unmatched:   []string{"/api/v1/users/1", "x/api/v1/users"},
+		},
+	}
+	for _, tt := range tests {
+		p, err := NewPattern(tt.patternType, tt.expr)
+		require.NoError(t, err)
+		for _, m := range tt.matched {
+			assert.True(t, p.Match(m), "%s should match %s", p, m)
+		}
+		for _, m := range tt.unmatched {
+			assert.False(t, p.Match(m), "%s shouldn't match %s", p, m)
+		}
+	}
+}
+
+func TestPatternInvalid(t *testing.T) {
+	for _, expr := range []string{"[a-", "^/api", "/api$", `\bapi`} {
+		_, err := NewPattern(PatternTypeRegex, expr)
+		assert.ErrorIs(t, err, ErrInvalidPattern, expr)
+	}
+}
+
+func TestPatternLiteral(t *testing.T) {
+	p, err := NewPattern(PatternTypeWildcard, "/api/v1")
+	require.NoError(t, err)
+	literal, ok := p.Literal()
+	assert.True(t, ok)
+	assert.Equal(t, "/api/v1", literal)
+
+	p, err = NewPattern(PatternTypeWildcard, "/api/*")
+	require.NoError(t, err)
+	_, ok = p.Literal()
+	assert.False(t, ok)
+
+	p, err = NewPattern(PatternTypePrefix, "/api")
+	require.NoError(t, err)
+	_, ok = p.Literal()
+	assert.False(t, ok)
+}
@@ -0,0 +1,93 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package index
+
+import (
+	"strings"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+)
+
+// DefaultTreeSeparator is the separator of a tree index if the index rule doesn't specify one.
+const DefaultTreeSeparator = "/"
+
+const treeFieldPrefix = "_tree_"
+
+// TreeSeparator returns the separator which splits the tag values of a tree index rule.
+func TreeSeparator(indexRule *databasev1.IndexRule) string {
+	if sep := indexRule.GetSeparator(); sep != "" {
+		return sep
+	}
+	return DefaultTreeSeparator
+}
+
+// NormalizeTreePath removes the trailing separators of a path.
+// The root of the tree is represented by the separator.
+func NormalizeTreePath(path, sep string) string {
+	for path != "" && strings.HasSuffix(path, sep) {
+		path = path[:len(path)-len(sep)]
+	}
+	if path == "" {
+		return sep
+	}
+	return path
+}
+
+// TreePaths returns the root, the ancestors and the path itself.
+// For example, "/a/b/c" returns "/", "/a", "/a/b" and "/a/b/c".
+func TreePaths(path, sep string) []string {
+	path = NormalizeTreePath(path, sep)
+	paths := []string{sep}
+	if path == sep {
+		return paths
+	}
+	for i := 1; i < len(path); i++ {
+		if strings.HasPrefix(path[i:], sep) && !strings.HasSuffix(path[:i], sep) {
+			paths = append(paths, path[:i])
+		}
+	}
+	return append(paths, path)
+}
+
+// InSubtree reports whether the path is the root or one of its descendants.
+func InSubtree(path, root, sep string) bool {
+	path, root = NormalizeTreePath(path, sep), NormalizeTreePath(root, sep)
+	return root == sep || path == root || strings.HasPrefix(path, root+sep)
+}
+
+// TreeFieldKey returns the key of the field which indexes the ancestors of the values in key.
+func TreeFieldKey(key FieldKey) FieldKey {
+	return FieldKey{
+		TimeRange: key.TimeRange,
+		TagName:   treeFieldPrefix + key.Marshal(),
+		SeriesID:  key.SeriesID,
+	}
+}
+
+// AppendTreeFields appends the fields of a tree index to dest.
+// Every path in TreePaths of the value is indexed, so that a subtree is found by a term query.
+func AppendTreeFields(dest []Field, key FieldKey, value, sep string) []Field {
+	treeKey := TreeFieldKey(key)
+	for _, p := range TreePaths(value, sep) {
+		f := NewStringField(treeKey, p)
+		f.NoSort = true
+		f.Index = true
+		dest = append(dest, f)
+	}
+	return dest
+}
@@ -0,0 +1,76 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package index
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+)
+
+func TestTreePaths(t *testing.T) {
+	tests := []struct {
+		path string
+		sep  string
+		want []string
+	}{
+		{path: "/api/v1/users", sep: "/", want: []string{"/", "/api", "/api/v1", "/api/v1/users"}},
+		{path: "/api/v1/users/", sep: "/", want: []string{"/", "/api", "/api/v1", "/api/v1/users"}},
+		{path: "cluster/ns/pod", sep: "/", want: []string{"/", "cluster", "cluster/ns", "cluster/ns/pod"}},
+		{path: "com.example.app", sep: ".", want: []string{".", "com", "com.example", "com.example.app"}},
+		{path: "a::b", sep: "::", want: []string{"::", "a", "a::b"}},
+		{path: "/", sep: "/", want: []string{"/"}},
+		{path: "", sep: "/", want: []string{"/"}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, TreePaths(tt.path, tt.sep), tt.path)
+	}
+}
+
+func TestInSubtree(t *testing.T) {
+	assert.True(t, InSubtree("/api/v1/users", "/api/v1", "/"))
+	assert.True(t, InSubtree("/api/v1", "/api/v1/", "/"))
+	assert.True(t, InSubtree("/api/v1", "/", "/"))
+	assert.False(t, InSubtree("/api/v10/users", "/api/v1", "/"))
+	assert.False(t, InSubtree("/api", "/api/v1", "/"))
+	for _, p := range TreePaths("/api/v1/users", "/") {
+		assert.True(t, InSubtree("/api/v1/users", p, "/"), p)
+	}
+}
+
+func TestAppendTreeFields(t *testing.T) {
+	key := FieldKey{IndexRuleID: 1, SeriesID: 2, Analyzer: AnalyzerKeyword}
+	fields := AppendTreeFields(nil, key, "/a/b", "/")
+	assert.Len(t, fields, 3)
+	for _, f := range fields {
+		assert.Equal(t, treeFieldPrefix+key.Marshal(), f.Key.Marshal())
+		assert.Equal(t, key.SeriesID, f.Key.SeriesID)
+		assert.Equal(t, AnalyzerUnspecified, f.Key.Analyzer)
+		assert.True(t, f.NoSort)
+		assert.True(t, f.Index)
+		assert.False(t, f.Store)
+	}
+	assert.Equal(t, "/a", string(fields[1].GetBytes()))
+}
+
+func TestTreeSeparator(t *testing.T) {
+	assert.Equal(t, DefaultTreeSeparator, TreeSeparator(&databasev1.IndexRule{}))
+	assert.Equal(t, ".", TreeSeparator(&databasev1.IndexRule{Separator: "."}))
+}
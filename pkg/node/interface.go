@@ -52,6 +52,15 @@ type Selector interface {
 	fmt.Stringer
 }
 
+// ShardRouter exposes the nodes a selector routes the copies of the shards to, which the rebalancing of the cluster plans from.
+type ShardRouter interface {
+	// Nodes returns the sorted names of the available nodes.
+	Nodes() []string
+	// Shards returns the nodes of the copies of the group's shards, indexed by the shard id and the replica id.
+	// The node a shard is assigned to is returned even if it's unavailable.
+	Shards(group string) ([][]string, bool)
+}
+
 // NewPickFirstSelector returns a simple selector that always returns the first node if exists.
 func NewPickFirstSelector() (Selector, error) {
 	return &pickFirstSelector{
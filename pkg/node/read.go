@@ -0,0 +1,64 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package node
+
+import "slices"
+
+// PlanRead chooses the nodes to read the shards from, so that each shard is read from as many of its copies as the read requires.
+// The shards are indexed by the shard id and the replica id, like ShardRouter.Shards returns.
+//
+// The reading nodes, which are read or being read, count towards the copies they hold. The other available nodes are chosen
+// in the order of the replicas, and a node chosen for a shard counts towards the other shards it holds a copy of.
+// It returns the chosen nodes, and the ids of the shards without enough available copies.
+func PlanRead(shards [][]string, required func(copies int) int, reading []string, available func(node string) bool) ([]string, []uint32) {
+	read := make(map[string]struct{}, len(reading))
+	for _, n := range reading {
+		read[n] = struct{}{}
+	}
+	var chosen []string
+	var short []uint32
+	for shardID, copies := range shards {
+		distinct := make([]string, 0, len(copies))
+		for _, n := range copies {
+			if n != "" && !slices.Contains(distinct, n) {
+				distinct = append(distinct, n)
+			}
+		}
+		need := required(len(distinct))
+		for _, n := range distinct {
+			if _, ok := read[n]; ok {
+				need--
+			}
+		}
+		for _, n := range distinct {
+			if need <= 0 {
+				break
+			}
+			if _, ok := read[n]; ok || !available(n) {
+				continue
+			}
+			read[n] = struct{}{}
+			chosen = append(chosen, n)
+			need--
+		}
+		if need > 0 || len(distinct) == 0 {
+			short = append(short, uint32(shardID))
+		}
+	}
+	return chosen, short
+}
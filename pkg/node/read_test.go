@@ -0,0 +1,87 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func one(int) int { return 1 }
+
+func quorum(copies int) int { return copies/2 + 1 }
+
+func all(copies int) int { return copies }
+
+func except(nodes ...string) func(string) bool {
+	return func(n string) bool {
+		for _, e := range nodes {
+			if n == e {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func TestPlanReadAny(t *testing.T) {
+	shards := [][]string{{"node1", "node2"}, {"node2", "node3"}, {"node3", "node1"}}
+	chosen, short := PlanRead(shards, one, nil, except())
+	assert.Equal(t, []string{"node1", "node2"}, chosen)
+	assert.Empty(t, short)
+
+	shards = [][]string{{"node1", "node2"}, {"node2", "node1"}}
+	chosen, short = PlanRead(shards, one, nil, except())
+	assert.Equal(t, []string{"node1"}, chosen, "the chosen node counts towards the other shards it holds")
+	assert.Empty(t, short)
+}
+
+func TestPlanReadQuorumAndAll(t *testing.T) {
+	shards := [][]string{{"node1", "node2", "node3"}, {"node2", "node3", "node1"}}
+	chosen, short := PlanRead(shards, quorum, nil, except())
+	assert.Equal(t, []string{"node1", "node2"}, chosen)
+	assert.Empty(t, short)
+
+	chosen, short = PlanRead(shards, all, nil, except())
+	assert.Equal(t, []string{"node1", "node2", "node3"}, chosen)
+	assert.Empty(t, short)
+
+	chosen, short = PlanRead(shards, all, nil, except("node3"))
+	assert.Equal(t, []string{"node1", "node2"}, chosen)
+	assert.Equal(t, []uint32{0, 1}, short)
+}
+
+func TestPlanReadRetry(t *testing.T) {
+	shards := [][]string{{"node1", "node2"}, {"node2", "node3"}}
+	// node1 timed out, and node2 is being read.
+	chosen, short := PlanRead(shards, one, []string{"node2"}, except("node1"))
+	assert.Empty(t, chosen)
+	assert.Empty(t, short)
+
+	// node2 timed out too.
+	chosen, short = PlanRead(shards, one, nil, except("node1", "node2"))
+	assert.Equal(t, []string{"node3"}, chosen)
+	assert.Equal(t, []uint32{0}, short)
+}
+
+func TestPlanReadUnassignedCopies(t *testing.T) {
+	chosen, short := PlanRead([][]string{{"node1", "node1"}, {"", ""}}, all, nil, except())
+	assert.Equal(t, []string{"node1"}, chosen, "the copies on the same node are read once")
+	assert.Equal(t, []uint32{1}, short)
+}
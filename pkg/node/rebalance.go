@@ -0,0 +1,100 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package node
+
+import (
+	"slices"
+
+	"github.com/pkg/errors"
+)
+
+// ShardMove moves a copy of a shard from a node to another.
+type ShardMove struct {
+	Source    string
+	Target    string
+	ShardID   uint32
+	ReplicaID uint32
+}
+
+// PlanRebalance assigns the copies of the shards to the nodes with the minimal movement.
+// The shards are indexed by the shard id and the replica id, like ShardRouter.Shards returns.
+//
+// A copy stays on its node if the node is still in the nodes, and the node holds neither another copy of the shard
+// nor more copies than its share, which is the number of the copies divided by the number of the nodes, rounded up.
+// The other copies are moved to the nodes holding the fewest copies.
+func PlanRebalance(shards [][]string, nodes []string) ([][]string, []ShardMove, error) {
+	if len(nodes) == 0 {
+		return nil, nil, errors.New("no nodes to assign the shards to")
+	}
+	sorted := slices.Clone(nodes)
+	slices.Sort(sorted)
+	sorted = slices.Compact(sorted)
+	total := 0
+	for shardID, copies := range shards {
+		if len(copies) > len(sorted) {
+			return nil, nil, errors.Errorf("shard %d has %d copies, which need as many nodes, but there are %d nodes",
+				shardID, len(copies), len(sorted))
+		}
+		total += len(copies)
+	}
+	share := (total + len(sorted) - 1) / len(sorted)
+	load := make(map[string]int, len(sorted))
+	for _, n := range sorted {
+		load[n] = 0
+	}
+
+	assignment := make([][]string, len(shards))
+	for shardID, copies := range shards {
+		assignment[shardID] = make([]string, len(copies))
+		for replicaID, n := range copies {
+			l, ok := load[n]
+			if !ok || l >= share || slices.Contains(assignment[shardID], n) {
+				continue
+			}
+			assignment[shardID][replicaID] = n
+			load[n]++
+		}
+	}
+
+	var moves []ShardMove
+	for shardID, copies := range shards {
+		for replicaID, n := range copies {
+			if assignment[shardID][replicaID] != "" {
+				continue
+			}
+			target := ""
+			for _, candidate := range sorted {
+				if slices.Contains(assignment[shardID], candidate) {
+					continue
+				}
+				if target == "" || load[candidate] < load[target] {
+					target = candidate
+				}
+			}
+			assignment[shardID][replicaID] = target
+			load[target]++
+			moves = append(moves, ShardMove{
+				Source:    n,
+				Target:    target,
+				ShardID:   uint32(shardID),
+				ReplicaID: uint32(replicaID),
+			})
+		}
+	}
+	return assignment, moves, nil
+}
@@ -0,0 +1,79 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanRebalanceBalanced(t *testing.T) {
+	shards := [][]string{{"node1", "node2"}, {"node2", "node1"}}
+	assignment, moves, err := PlanRebalance(shards, []string{"node1", "node2"})
+	require.NoError(t, err)
+	assert.Equal(t, shards, assignment)
+	assert.Empty(t, moves)
+}
+
+func TestPlanRebalanceAddNode(t *testing.T) {
+	shards := [][]string{{"node1"}, {"node2"}, {"node1"}, {"node2"}}
+	assignment, moves, err := PlanRebalance(shards, []string{"node1", "node2", "node3"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"node1"}, {"node2"}, {"node1"}, {"node2"}}, assignment)
+	assert.Empty(t, moves, "every node holds no more than its share")
+
+	shards = [][]string{{"node1"}, {"node2"}, {"node1"}, {"node2"}, {"node1"}, {"node2"}}
+	assignment, moves, err = PlanRebalance(shards, []string{"node1", "node2", "node3"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"node1"}, {"node2"}, {"node1"}, {"node2"}, {"node3"}, {"node3"}}, assignment)
+	assert.Equal(t, []ShardMove{
+		{Source: "node1", Target: "node3", ShardID: 4},
+		{Source: "node2", Target: "node3", ShardID: 5},
+	}, moves)
+}
+
+func TestPlanRebalanceDrainNode(t *testing.T) {
+	shards := [][]string{{"node1", "node2"}, {"node2", "node3"}, {"node3", "node1"}}
+	assignment, moves, err := PlanRebalance(shards, []string{"node1", "node3"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"node1", "node3"}, {"node1", "node3"}, {"node3", "node1"}}, assignment)
+	assert.Equal(t, []ShardMove{
+		{Source: "node2", Target: "node3", ShardID: 0, ReplicaID: 1},
+		{Source: "node2", Target: "node1", ShardID: 1, ReplicaID: 0},
+	}, moves)
+	for _, copies := range assignment {
+		assert.NotEqual(t, copies[0], copies[1], "the copies of a shard are on different nodes")
+	}
+}
+
+func TestPlanRebalanceDuplicatedCopies(t *testing.T) {
+	shards := [][]string{{"node1", "node1"}}
+	assignment, moves, err := PlanRebalance(shards, []string{"node1", "node2"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"node1", "node2"}}, assignment)
+	assert.Equal(t, []ShardMove{{Source: "node1", Target: "node2", ReplicaID: 1}}, moves)
+}
+
+func TestPlanRebalanceNotEnoughNodes(t *testing.T) {
+	_, _, err := PlanRebalance([][]string{{"node1", "node2"}}, []string{"node1"})
+	assert.Error(t, err)
+	_, _, err = PlanRebalance([][]string{{"node1"}}, nil)
+	assert.Error(t, err)
+}
@@ -37,6 +37,8 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/convert"
 )
 
+var _ ShardRouter = (*roundRobinSelector)(nil)
+
 type roundRobinSelector struct {
 	schemaRegistry metadata.Repo
 	nodeSelector   *pub.LabelSelector
@@ -107,10 +109,20 @@ func (r *roundRobinSelector) OnAddOrUpdate(schemaMetadata schema.Metadata) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.removeGroup(group.Metadata.Name)
+	r.addGroup(group)
+	r.sortEntries()
+}
+
+func (r *roundRobinSelector) addGroup(group *commonv1.Group) {
+	assignments := make(map[uint32][]string, len(group.ResourceOpts.ShardAssignments))
+	for _, sa := range group.ResourceOpts.ShardAssignments {
+		assignments[sa.ShardId] = sa.Nodes
+	}
 	for i := uint32(0); i < group.ResourceOpts.ShardNum; i++ {
-		r.lookupTable = append(r.lookupTable, newKey(group.Metadata.Name, i, group.ResourceOpts.Replicas))
+		k := newKey(group.Metadata.Name, i, group.ResourceOpts.Replicas)
+		k.assignment = assignments[i]
+		r.lookupTable = append(r.lookupTable, k)
 	}
-	r.sortEntries()
 }
 
 func (r *roundRobinSelector) removeGroup(group string) {
@@ -158,9 +170,7 @@ func (r *roundRobinSelector) OnInit(kinds []schema.Kind) (bool, []int64) {
 		if g.Metadata.ModRevision > revision {
 			revision = g.Metadata.ModRevision
 		}
-		for i := uint32(0); i < g.ResourceOpts.ShardNum; i++ {
-			r.lookupTable = append(r.lookupTable, newKey(g.Metadata.Name, i, g.ResourceOpts.Replicas))
-		}
+		r.addGroup(g)
 	}
 	r.sortEntries()
 	return true, []int64{revision}
@@ -190,20 +200,58 @@ func (r *roundRobinSelector) RemoveNode(node *databasev1.Node) {
 func (r *roundRobinSelector) Pick(group, _ string, shardID, replicaID uint32) (string, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	k := key{group: group, shardID: shardID}
 	if len(r.nodes) == 0 {
 		return "", errors.New("no nodes available")
 	}
+	i, ok := r.search(group, shardID)
+	if ok {
+		return r.selectNode(i, replicaID), nil
+	}
+	return "", fmt.Errorf("%s-%d is a unknown shard", group, shardID)
+}
+
+func (r *roundRobinSelector) search(group string, shardID uint32) (int, bool) {
+	k := key{group: group, shardID: shardID}
 	i := sort.Search(len(r.lookupTable), func(i int) bool {
 		if r.lookupTable[i].group == group {
 			return r.lookupTable[i].shardID >= shardID
 		}
 		return r.lookupTable[i].group > group
 	})
-	if i < len(r.lookupTable) && r.lookupTable[i].equal(k) {
-		return r.selectNode(i, replicaID), nil
+	return i, i < len(r.lookupTable) && r.lookupTable[i].equal(k)
+}
+
+// Nodes implements ShardRouter.
+func (r *roundRobinSelector) Nodes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.nodes)
+}
+
+// Shards implements ShardRouter.
+func (r *roundRobinSelector) Shards(group string) ([][]string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	i, ok := r.search(group, 0)
+	if !ok {
+		return nil, false
 	}
-	return "", fmt.Errorf("%s-%d is a unknown shard", group, shardID)
+	var shards [][]string
+	for ; i < len(r.lookupTable) && r.lookupTable[i].group == group; i++ {
+		entry := r.lookupTable[i]
+		copies := make([]string, entry.replicas+1)
+		for j := range copies {
+			switch {
+			case j < len(entry.assignment):
+				// The assigned node is reported even if it has left, so that its copy is moved by the rebalancing.
+				copies[j] = entry.assignment[j]
+			case len(r.nodes) > 0:
+				copies[j] = r.selectNode(i, uint32(j))
+			}
+		}
+		shards = append(shards, copies)
+	}
+	return shards, true
 }
 
 func (r *roundRobinSelector) sortEntries() {
@@ -217,6 +265,13 @@ func (r *roundRobinSelector) sortEntries() {
 }
 
 func (r *roundRobinSelector) selectNode(index int, replicasID uint32) string {
+	assignment := r.lookupTable[index].assignment
+	if int(replicasID) < len(assignment) {
+		// The shard falls back to round robin if the node it's assigned to is unavailable.
+		if _, ok := slices.BinarySearch(r.nodes, assignment[replicasID]); ok {
+			return assignment[replicasID]
+		}
+	}
 	adjustedIndex := index + int(replicasID)
 	return r.nodes[adjustedIndex%len(r.nodes)]
 }
@@ -232,9 +287,11 @@ func validateGroup(group *commonv1.Group) bool {
 }
 
 type key struct {
-	group    string
-	shardID  uint32
-	replicas uint32
+	group string
+	// assignment is the nodes the copies of the shard are pinned to, ordered by the replica id.
+	assignment []string
+	shardID    uint32
+	replicas   uint32
 }
 
 func (k key) equal(other key) bool {
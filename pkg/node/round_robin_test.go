@@ -158,7 +158,57 @@ func TestChangeShard(t *testing.T) {
 	assert.NotEqual(t, node1, node2)
 }
 
+func TestPickAssignedShard(t *testing.T) {
+	selector := NewRoundRobinSelector("test", nil)
+	selector.(*roundRobinSelector).OnAddOrUpdate(assignedGroupSchema)
+	selector.AddNode(&databasev1.Node{Metadata: &commonv1.Metadata{Name: "node1"}})
+	selector.AddNode(&databasev1.Node{Metadata: &commonv1.Metadata{Name: "node2"}})
+	node, err := selector.Pick("group1", "", 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "node2", node)
+	// A new node doesn't remap the assigned shard.
+	selector.AddNode(&databasev1.Node{Metadata: &commonv1.Metadata{Name: "node0"}})
+	node, err = selector.Pick("group1", "", 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "node2", node)
+	// The shard falls back to round robin if the node it's assigned to is unavailable.
+	selector.RemoveNode(&databasev1.Node{Metadata: &commonv1.Metadata{Name: "node2"}})
+	node, err = selector.Pick("group1", "", 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "node0", node)
+}
+
+func TestShards(t *testing.T) {
+	selector := NewRoundRobinSelector("test", nil)
+	selector.(*roundRobinSelector).OnAddOrUpdate(assignedGroupSchema)
+	selector.AddNode(&databasev1.Node{Metadata: &commonv1.Metadata{Name: "node1"}})
+	router := selector.(ShardRouter)
+	shards, ok := router.Shards("group1")
+	assert.True(t, ok)
+	assert.Equal(t, [][]string{{"node2"}, {"node1"}}, shards)
+	assert.Equal(t, []string{"node1"}, router.Nodes())
+	_, ok = router.Shards("group2")
+	assert.False(t, ok)
+}
+
 var (
+	assignedGroupSchema = schema.Metadata{
+		TypeMeta: schema.TypeMeta{
+			Kind: schema.KindGroup,
+		},
+		Spec: &commonv1.Group{
+			Metadata: &commonv1.Metadata{
+				Name: "group1",
+			},
+			Catalog: commonv1.Catalog_CATALOG_MEASURE,
+			ResourceOpts: &commonv1.ResourceOpts{
+				ShardNum: 2,
+				ShardAssignments: []*commonv1.ShardAssignment{
+					{ShardId: 0, Nodes: []string{"node2"}},
+				},
+			},
+		},
+	}
 	groupSchema = schema.Metadata{
 		TypeMeta: schema.TypeMeta{
 			Kind: schema.KindGroup,
@@ -40,6 +40,8 @@ func tagValueTypeConv(tagValue *modelv1.TagValue) (tagType databasev1.TagType, i
 	switch tagValue.GetValue().(type) {
 	case *modelv1.TagValue_Int:
 		return databasev1.TagType_TAG_TYPE_INT, false
+	case *modelv1.TagValue_Float:
+		return databasev1.TagType_TAG_TYPE_FLOAT, false
 	case *modelv1.TagValue_Str:
 		return databasev1.TagType_TAG_TYPE_STRING, false
 	case *modelv1.TagValue_IntArray:
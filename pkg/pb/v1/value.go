@@ -54,6 +54,8 @@ func MustTagValueToValueType(tag *modelv1.TagValue) ValueType {
 		return ValueTypeStr
 	case *modelv1.TagValue_Int:
 		return ValueTypeInt64
+	case *modelv1.TagValue_Float:
+		return ValueTypeFloat64
 	case *modelv1.TagValue_BinaryData:
 		return ValueTypeBinaryData
 	case *modelv1.TagValue_StrArray:
@@ -72,6 +74,8 @@ func MustTagValueSpecToValueType(tag databasev1.TagType) ValueType {
 		return ValueTypeStr
 	case databasev1.TagType_TAG_TYPE_INT:
 		return ValueTypeInt64
+	case databasev1.TagType_TAG_TYPE_FLOAT:
+		return ValueTypeFloat64
 	case databasev1.TagType_TAG_TYPE_DATA_BINARY:
 		return ValueTypeBinaryData
 	case databasev1.TagType_TAG_TYPE_STRING_ARRAY:
@@ -90,6 +94,8 @@ func MustTagValueToStr(tag *modelv1.TagValue) string {
 		return `"` + tag.GetStr().Value + `"`
 	case *modelv1.TagValue_Int:
 		return strconv.FormatInt(tag.GetInt().Value, 10)
+	case *modelv1.TagValue_Float:
+		return strconv.FormatFloat(tag.GetFloat().Value, 'f', -1, 64)
 	case *modelv1.TagValue_BinaryData:
 		return fmt.Sprintf("%x", tag.GetBinaryData())
 	default:
@@ -133,6 +139,8 @@ func marshalTagValue(dest []byte, tv *modelv1.TagValue) ([]byte, error) {
 		dest = marshalEntityValue(dest, []byte(tv.GetStr().Value))
 	case *modelv1.TagValue_Int:
 		dest = marshalEntityValue(dest, encoding.Int64ToBytes(nil, tv.GetInt().Value))
+	case *modelv1.TagValue_Float:
+		dest = marshalEntityValue(dest, convert.Float64ToBytes(tv.GetFloat().Value))
 	case *modelv1.TagValue_BinaryData:
 		dest = marshalEntityValue(dest, tv.GetBinaryData())
 	default:
@@ -184,6 +192,17 @@ func unmarshalTagValue(dest []byte, src []byte) ([]byte, []byte, *modelv1.TagVal
 				},
 			},
 		}, nil
+	case ValueTypeFloat64:
+		if dest, src, err = unmarshalEntityValue(dest, src[1:]); err != nil {
+			return nil, nil, nil, errors.WithMessage(err, "unmarshal float tag value")
+		}
+		return dest, src, &modelv1.TagValue{
+			Value: &modelv1.TagValue_Float{
+				Float: &modelv1.Float{
+					Value: convert.BytesToFloat64(dest),
+				},
+			},
+		}, nil
 	case ValueTypeBinaryData:
 		if dest, src, err = unmarshalEntityValue(dest, src[1:]); err != nil {
 			return nil, nil, nil, errors.WithMessage(err, "unmarshal binary tag value")
@@ -284,6 +303,14 @@ func MustCompareTagValue(tv1, tv2 *modelv1.TagValue) int {
 		return bytes.Compare(convert.StringToBytes(tv1.GetStr().Value), convert.StringToBytes(tv2.GetStr().Value))
 	case ValueTypeInt64:
 		return int(tv1.GetInt().Value - tv2.GetInt().Value)
+	case ValueTypeFloat64:
+		switch f1, f2 := tv1.GetFloat().Value, tv2.GetFloat().Value; {
+		case f1 < f2:
+			return -1
+		case f1 > f2:
+			return 1
+		}
+		return 0
 	case ValueTypeBinaryData:
 		return bytes.Compare(tv1.GetBinaryData(), tv2.GetBinaryData())
 	default:
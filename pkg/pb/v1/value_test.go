@@ -38,6 +38,10 @@ func TestMarshalAndUnmarshalTagValue(t *testing.T) {
 			name: "int value",
 			src:  &modelv1.TagValue{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{Value: 123}}},
 		},
+		{
+			name: "float value",
+			src:  &modelv1.TagValue{Value: &modelv1.TagValue_Float{Float: &modelv1.Float{Value: -12.5}}},
+		},
 		{
 			name: "binary data",
 			src:  &modelv1.TagValue{Value: &modelv1.TagValue_BinaryData{BinaryData: []byte("binaryData")}},
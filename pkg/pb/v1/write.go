@@ -134,6 +134,8 @@ func ParseTagValue(tagValue *modelv1.TagValue) (TagValue, error) {
 		return newValue([]byte(x.Str.GetValue())), nil
 	case *modelv1.TagValue_Int:
 		return newValue(convert.Int64ToBytes(x.Int.GetValue())), nil
+	case *modelv1.TagValue_Float:
+		return newValue(convert.Float64ToSortableBytes(x.Float.GetValue())), nil
 	case *modelv1.TagValue_StrArray:
 		fv := newValueWithSplitter(strDelimiter)
 		for _, v := range x.StrArray.GetValue() {
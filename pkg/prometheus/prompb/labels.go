@@ -0,0 +1,69 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package prompb
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// SortLabels sorts the labels by their names.
+func SortLabels(labels []Label) {
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+}
+
+// LabelsString formats the sorted labels like {a="1",b="2"}, which identifies a series.
+func LabelsString(labels []Label) string {
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, l := range labels {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(l.Name)
+		sb.WriteByte('=')
+		sb.WriteString(strconv.Quote(l.Value))
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+// Matcher returns the function which reports whether a label value matches.
+// A regular expression matches the whole value, and an absent label has an empty value.
+func (m LabelMatcher) Matcher() (func(value string) bool, error) {
+	switch m.Type {
+	case MatchEqual:
+		return func(value string) bool { return value == m.Value }, nil
+	case MatchNotEqual:
+		return func(value string) bool { return value != m.Value }, nil
+	case MatchRegexp, MatchNotRegexp:
+		re, err := regexp.Compile("^(?:" + m.Value + ")$")
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid regular expression of label %s", m.Name)
+		}
+		if m.Type == MatchRegexp {
+			return re.MatchString, nil
+		}
+		return func(value string) bool { return !re.MatchString(value) }, nil
+	}
+	return nil, errors.Errorf("unknown matcher type %d of label %s", m.Type, m.Name)
+}
@@ -0,0 +1,455 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package prompb implements the protobuf messages of the Prometheus remote write and remote read protocols.
+// Only the fields used to store and load the samples are decoded, the others are skipped.
+package prompb
+
+import (
+	"math"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// MetricNameLabel is the label holding the metric name.
+const MetricNameLabel = "__name__"
+
+var errMalformed = errors.New("malformed protobuf message")
+
+// ErrTooLarge is returned if the decompressed body is larger than the maximum size.
+var ErrTooLarge = errors.New("the decompressed body is too large")
+
+// MatchType is the type of a label matcher.
+type MatchType int32
+
+// The label matcher types.
+const (
+	MatchEqual MatchType = iota
+	MatchNotEqual
+	MatchRegexp
+	MatchNotRegexp
+)
+
+// ResponseType is the type of the remote read response.
+type ResponseType int32
+
+// The remote read response types.
+const (
+	ResponseTypeSamples ResponseType = iota
+	ResponseTypeStreamedXORChunks
+)
+
+// Label is a label of a time series.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is a value of a time series at a timestamp in milliseconds.
+type Sample struct {
+	Value     float64
+	Timestamp int64
+}
+
+// TimeSeries is a series of samples identified by the labels.
+type TimeSeries struct {
+	Labels  []Label
+	Samples []Sample
+}
+
+// WriteRequest is the request of the remote write protocol.
+type WriteRequest struct {
+	Timeseries []TimeSeries
+}
+
+// LabelMatcher selects the series by a label.
+type LabelMatcher struct {
+	Name  string
+	Value string
+	Type  MatchType
+}
+
+// Query selects the series by the matchers in a time range.
+type Query struct {
+	Matchers         []LabelMatcher
+	StartTimestampMs int64
+	EndTimestampMs   int64
+}
+
+// ReadRequest is the request of the remote read protocol.
+type ReadRequest struct {
+	Queries               []Query
+	AcceptedResponseTypes []ResponseType
+}
+
+// QueryResult is the result of a query.
+type QueryResult struct {
+	Timeseries []TimeSeries
+}
+
+// ReadResponse is the response of the remote read protocol, whose results are in the order of the queries.
+type ReadResponse struct {
+	Results []QueryResult
+}
+
+// DecodeSnappy decompresses the body of a remote request, which is compressed by the Snappy block format.
+// The decompressed length in the header is checked against maxSize before the body is allocated.
+func DecodeSnappy(src []byte, maxSize int) ([]byte, error) {
+	n, err := s2.DecodedLen(src)
+	if err != nil {
+		return nil, err
+	}
+	if n > maxSize {
+		return nil, errors.Wrapf(ErrTooLarge, "%d bytes exceed %d bytes", n, maxSize)
+	}
+	return s2.Decode(nil, src)
+}
+
+// EncodeSnappy compresses the body of a remote response by the Snappy block format.
+func EncodeSnappy(src []byte) []byte {
+	return s2.EncodeSnappy(nil, src)
+}
+
+// Unmarshal decodes the request.
+func (w *WriteRequest) Unmarshal(data []byte) error {
+	w.Timeseries = w.Timeseries[:0]
+	return consumeFields(data, func(num protowire.Number, typ protowire.Type, data []byte) (int, error) {
+		if num != 1 || typ != protowire.BytesType {
+			return skipField(num, typ, data)
+		}
+		v, n := protowire.ConsumeBytes(data)
+		if n < 0 {
+			return 0, errMalformed
+		}
+		var ts TimeSeries
+		if err := ts.unmarshal(v); err != nil {
+			return 0, err
+		}
+		w.Timeseries = append(w.Timeseries, ts)
+		return n, nil
+	})
+}
+
+// Marshal encodes the request.
+func (w *WriteRequest) Marshal() []byte {
+	var dst []byte
+	for i := range w.Timeseries {
+		dst = appendMessage(dst, 1, w.Timeseries[i].marshal(nil))
+	}
+	return dst
+}
+
+// Unmarshal decodes the request.
+func (r *ReadRequest) Unmarshal(data []byte) error {
+	r.Queries = r.Queries[:0]
+	r.AcceptedResponseTypes = r.AcceptedResponseTypes[:0]
+	return consumeFields(data, func(num protowire.Number, typ protowire.Type, data []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return 0, errMalformed
+			}
+			var q Query
+			if err := q.unmarshal(v); err != nil {
+				return 0, err
+			}
+			r.Queries = append(r.Queries, q)
+			return n, nil
+		case num == 2 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				return 0, errMalformed
+			}
+			r.AcceptedResponseTypes = append(r.AcceptedResponseTypes, ResponseType(v))
+			return n, nil
+		case num == 2 && typ == protowire.BytesType:
+			packed, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return 0, errMalformed
+			}
+			for len(packed) > 0 {
+				v, m := protowire.ConsumeVarint(packed)
+				if m < 0 {
+					return 0, errMalformed
+				}
+				r.AcceptedResponseTypes = append(r.AcceptedResponseTypes, ResponseType(v))
+				packed = packed[m:]
+			}
+			return n, nil
+		}
+		return skipField(num, typ, data)
+	})
+}
+
+// Marshal encodes the request.
+func (r *ReadRequest) Marshal() []byte {
+	var dst []byte
+	for i := range r.Queries {
+		dst = appendMessage(dst, 1, r.Queries[i].marshal(nil))
+	}
+	if len(r.AcceptedResponseTypes) > 0 {
+		var packed []byte
+		for _, t := range r.AcceptedResponseTypes {
+			packed = protowire.AppendVarint(packed, uint64(t))
+		}
+		dst = appendMessage(dst, 2, packed)
+	}
+	return dst
+}
+
+// Unmarshal decodes the response.
+func (r *ReadResponse) Unmarshal(data []byte) error {
+	r.Results = r.Results[:0]
+	return consumeFields(data, func(num protowire.Number, typ protowire.Type, data []byte) (int, error) {
+		if num != 1 || typ != protowire.BytesType {
+			return skipField(num, typ, data)
+		}
+		v, n := protowire.ConsumeBytes(data)
+		if n < 0 {
+			return 0, errMalformed
+		}
+		var qr QueryResult
+		err := consumeFields(v, func(num protowire.Number, typ protowire.Type, data []byte) (int, error) {
+			if num != 1 || typ != protowire.BytesType {
+				return skipField(num, typ, data)
+			}
+			tsData, m := protowire.ConsumeBytes(data)
+			if m < 0 {
+				return 0, errMalformed
+			}
+			var ts TimeSeries
+			if err := ts.unmarshal(tsData); err != nil {
+				return 0, err
+			}
+			qr.Timeseries = append(qr.Timeseries, ts)
+			return m, nil
+		})
+		if err != nil {
+			return 0, err
+		}
+		r.Results = append(r.Results, qr)
+		return n, nil
+	})
+}
+
+// Marshal encodes the response.
+func (r *ReadResponse) Marshal() []byte {
+	var dst []byte
+	for i := range r.Results {
+		var result []byte
+		for j := range r.Results[i].Timeseries {
+			result = appendMessage(result, 1, r.Results[i].Timeseries[j].marshal(nil))
+		}
+		dst = appendMessage(dst, 1, result)
+	}
+	return dst
+}
+
+func (ts *TimeSeries) unmarshal(data []byte) error {
+	return consumeFields(data, func(num protowire.Number, typ protowire.Type, data []byte) (int, error) {
+		if typ != protowire.BytesType || (num != 1 && num != 2) {
+			return skipField(num, typ, data)
+		}
+		v, n := protowire.ConsumeBytes(data)
+		if n < 0 {
+			return 0, errMalformed
+		}
+		if num == 1 {
+			var l Label
+			if err := l.unmarshal(v); err != nil {
+				return 0, err
+			}
+			ts.Labels = append(ts.Labels, l)
+			return n, nil
+		}
+		var s Sample
+		if err := s.unmarshal(v); err != nil {
+			return 0, err
+		}
+		ts.Samples = append(ts.Samples, s)
+		return n, nil
+	})
+}
+
+func (ts *TimeSeries) marshal(dst []byte) []byte {
+	for i := range ts.Labels {
+		var l []byte
+		l = appendString(l, 1, ts.Labels[i].Name)
+		l = appendString(l, 2, ts.Labels[i].Value)
+		dst = appendMessage(dst, 1, l)
+	}
+	for i := range ts.Samples {
+		var s []byte
+		s = protowire.AppendTag(s, 1, protowire.Fixed64Type)
+		s = protowire.AppendFixed64(s, math.Float64bits(ts.Samples[i].Value))
+		s = protowire.AppendTag(s, 2, protowire.VarintType)
+		s = protowire.AppendVarint(s, uint64(ts.Samples[i].Timestamp))
+		dst = appendMessage(dst, 2, s)
+	}
+	return dst
+}
+
+func (l *Label) unmarshal(data []byte) error {
+	return consumeFields(data, func(num protowire.Number, typ protowire.Type, data []byte) (int, error) {
+		if typ != protowire.BytesType || (num != 1 && num != 2) {
+			return skipField(num, typ, data)
+		}
+		v, n := protowire.ConsumeBytes(data)
+		if n < 0 {
+			return 0, errMalformed
+		}
+		if num == 1 {
+			l.Name = string(v)
+		} else {
+			l.Value = string(v)
+		}
+		return n, nil
+	})
+}
+
+func (s *Sample) unmarshal(data []byte) error {
+	return consumeFields(data, func(num protowire.Number, typ protowire.Type, data []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.Fixed64Type:
+			v, n := protowire.ConsumeFixed64(data)
+			if n < 0 {
+				return 0, errMalformed
+			}
+			s.Value = math.Float64frombits(v)
+			return n, nil
+		case num == 2 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				return 0, errMalformed
+			}
+			s.Timestamp = int64(v)
+			return n, nil
+		}
+		return skipField(num, typ, data)
+	})
+}
+
+func (q *Query) unmarshal(data []byte) error {
+	return consumeFields(data, func(num protowire.Number, typ protowire.Type, data []byte) (int, error) {
+		switch {
+		case (num == 1 || num == 2) && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				return 0, errMalformed
+			}
+			if num == 1 {
+				q.StartTimestampMs = int64(v)
+			} else {
+				q.EndTimestampMs = int64(v)
+			}
+			return n, nil
+		case num == 3 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return 0, errMalformed
+			}
+			var m LabelMatcher
+			if err := m.unmarshal(v); err != nil {
+				return 0, err
+			}
+			q.Matchers = append(q.Matchers, m)
+			return n, nil
+		}
+		return skipField(num, typ, data)
+	})
+}
+
+func (q *Query) marshal(dst []byte) []byte {
+	dst = protowire.AppendTag(dst, 1, protowire.VarintType)
+	dst = protowire.AppendVarint(dst, uint64(q.StartTimestampMs))
+	dst = protowire.AppendTag(dst, 2, protowire.VarintType)
+	dst = protowire.AppendVarint(dst, uint64(q.EndTimestampMs))
+	for i := range q.Matchers {
+		var m []byte
+		m = protowire.AppendTag(m, 1, protowire.VarintType)
+		m = protowire.AppendVarint(m, uint64(q.Matchers[i].Type))
+		m = appendString(m, 2, q.Matchers[i].Name)
+		m = appendString(m, 3, q.Matchers[i].Value)
+		dst = appendMessage(dst, 3, m)
+	}
+	return dst
+}
+
+func (m *LabelMatcher) unmarshal(data []byte) error {
+	return consumeFields(data, func(num protowire.Number, typ protowire.Type, data []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				return 0, errMalformed
+			}
+			m.Type = MatchType(v)
+			return n, nil
+		case (num == 2 || num == 3) && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return 0, errMalformed
+			}
+			if num == 2 {
+				m.Name = string(v)
+			} else {
+				m.Value = string(v)
+			}
+			return n, nil
+		}
+		return skipField(num, typ, data)
+	})
+}
+
+// consumeFields calls fn with the number, the type and the value of each field in data.
+// fn returns the length of the value it consumes.
+func consumeFields(data []byte, fn func(num protowire.Number, typ protowire.Type, data []byte) (int, error)) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return errMalformed
+		}
+		data = data[n:]
+		m, err := fn(num, typ, data)
+		if err != nil {
+			return err
+		}
+		data = data[m:]
+	}
+	return nil
+}
+
+func skipField(num protowire.Number, typ protowire.Type, data []byte) (int, error) {
+	n := protowire.ConsumeFieldValue(num, typ, data)
+	if n < 0 {
+		return 0, errMalformed
+	}
+	return n, nil
+}
+
+func appendMessage(dst []byte, num protowire.Number, msg []byte) []byte {
+	dst = protowire.AppendTag(dst, num, protowire.BytesType)
+	return protowire.AppendBytes(dst, msg)
+}
+
+func appendString(dst []byte, num protowire.Number, s string) []byte {
+	dst = protowire.AppendTag(dst, num, protowire.BytesType)
+	return protowire.AppendString(dst, s)
+}
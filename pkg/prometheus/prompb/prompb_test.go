@@ -0,0 +1,134 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package prompb
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestWriteRequest(t *testing.T) {
+	req := &WriteRequest{Timeseries: []TimeSeries{
+		{
+			Labels:  []Label{{Name: MetricNameLabel, Value: "http_requests_total"}, {Name: "job", Value: "api"}},
+			Samples: []Sample{{Value: 1, Timestamp: 1000}, {Value: math.Inf(1), Timestamp: -1}},
+		},
+		{
+			Labels:  []Label{{Name: MetricNameLabel, Value: "up"}},
+			Samples: []Sample{{Value: 0.5, Timestamp: 2000}},
+		},
+	}}
+	data := req.Marshal()
+	// The metadata and the exemplars are skipped.
+	data = appendMessage(data, 3, appendString(nil, 4, "help"))
+
+	body, err := DecodeSnappy(EncodeSnappy(data), len(data))
+	require.NoError(t, err)
+	got := &WriteRequest{}
+	require.NoError(t, got.Unmarshal(body))
+	assert.Equal(t, req, got)
+
+	assert.Error(t, got.Unmarshal(data[:len(data)-1]))
+	_, err = DecodeSnappy([]byte("not snappy"), 1<<20)
+	assert.Error(t, err)
+	_, err = DecodeSnappy(EncodeSnappy(data), len(data)-1)
+	assert.ErrorIs(t, err, ErrTooLarge)
+}
+
+func TestDecodeSnappyForgedLength(t *testing.T) {
+	// The header claims a 4 GiB body, which is rejected before it's allocated.
+	src := binary.AppendUvarint(nil, math.MaxUint32)
+	_, err := DecodeSnappy(src, 32<<20)
+	assert.ErrorIs(t, err, ErrTooLarge)
+}
+
+func TestReadRequest(t *testing.T) {
+	req := &ReadRequest{
+		Queries: []Query{{
+			StartTimestampMs: 1000,
+			EndTimestampMs:   2000,
+			Matchers: []LabelMatcher{
+				{Type: MatchEqual, Name: MetricNameLabel, Value: "up"},
+				{Type: MatchNotRegexp, Name: "job", Value: "api.*"},
+			},
+		}},
+		AcceptedResponseTypes: []ResponseType{ResponseTypeSamples},
+	}
+	got := &ReadRequest{}
+	require.NoError(t, got.Unmarshal(req.Marshal()))
+	assert.Equal(t, req, got)
+
+	// The unpacked response types are accepted too.
+	data := protowire.AppendTag(nil, 2, protowire.VarintType)
+	data = protowire.AppendVarint(data, uint64(ResponseTypeStreamedXORChunks))
+	require.NoError(t, got.Unmarshal(data))
+	assert.Empty(t, got.Queries)
+	assert.Equal(t, []ResponseType{ResponseTypeStreamedXORChunks}, got.AcceptedResponseTypes)
+}
+
+func TestReadResponse(t *testing.T) {
+	resp := &ReadResponse{Results: []QueryResult{
+		{Timeseries: []TimeSeries{{
+			Labels:  []Label{{Name: MetricNameLabel, Value: "up"}},
+			Samples: []Sample{{Value: 1, Timestamp: 1000}},
+		}}},
+		{},
+	}}
+	got := &ReadResponse{}
+	require.NoError(t, got.Unmarshal(resp.Marshal()))
+	assert.Equal(t, resp, got)
+}
+
+func TestLabels(t *testing.T) {
+	labels := []Label{{Name: "job", Value: "api"}, {Name: "instance", Value: `a"b`}}
+	SortLabels(labels)
+	assert.Equal(t, `{instance="a\"b",job="api"}`, LabelsString(labels))
+	assert.Equal(t, "{}", LabelsString(nil))
+}
+
+func TestLabelMatcher(t *testing.T) {
+	tests := []struct {
+		matcher LabelMatcher
+		matched []string
+		missed  []string
+	}{
+		{matcher: LabelMatcher{Type: MatchEqual, Value: "api"}, matched: []string{"api"}, missed: []string{"", "api2"}},
+		{matcher: LabelMatcher{Type: MatchNotEqual, Value: "api"}, matched: []string{"", "web"}, missed: []string{"api"}},
+		{matcher: LabelMatcher{Type: MatchRegexp, Value: "api|web"}, matched: []string{"api", "web"}, missed: []string{"", "apiweb", "xapi"}},
+		{matcher: LabelMatcher{Type: MatchNotRegexp, Value: "a.*"}, matched: []string{"", "web"}, missed: []string{"api"}},
+	}
+	for _, tt := range tests {
+		match, err := tt.matcher.Matcher()
+		require.NoError(t, err)
+		for _, v := range tt.matched {
+			assert.True(t, match(v), "%v should match %q", tt.matcher, v)
+		}
+		for _, v := range tt.missed {
+			assert.False(t, match(v), "%v should not match %q", tt.matcher, v)
+		}
+	}
+	_, err := LabelMatcher{Type: MatchRegexp, Value: "("}.Matcher()
+	assert.Error(t, err)
+	_, err = LabelMatcher{Type: 9}.Matcher()
+	assert.Error(t, err)
+}
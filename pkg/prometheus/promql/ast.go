@@ -0,0 +1,126 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package promql implements a subset of the Prometheus query language over the measures.
+package promql
+
+import (
+	"time"
+
+	"github.com/apache/skywalking-banyandb/pkg/prometheus/prompb"
+)
+
+// Function is a function on the range vectors.
+type Function string
+
+// The functions on the range vectors.
+const (
+	FuncRate          Function = "rate"
+	FuncIncrease      Function = "increase"
+	FuncAvgOverTime   Function = "avg_over_time"
+	FuncSumOverTime   Function = "sum_over_time"
+	FuncMinOverTime   Function = "min_over_time"
+	FuncMaxOverTime   Function = "max_over_time"
+	FuncCountOverTime Function = "count_over_time"
+)
+
+var functions = map[string]Function{
+	string(FuncRate):          FuncRate,
+	string(FuncIncrease):      FuncIncrease,
+	string(FuncAvgOverTime):   FuncAvgOverTime,
+	string(FuncSumOverTime):   FuncSumOverTime,
+	string(FuncMinOverTime):   FuncMinOverTime,
+	string(FuncMaxOverTime):   FuncMaxOverTime,
+	string(FuncCountOverTime): FuncCountOverTime,
+}
+
+// AggregateOp is an aggregation operator across the series.
+type AggregateOp string
+
+// The aggregation operators.
+const (
+	AggSum     AggregateOp = "sum"
+	AggAvg     AggregateOp = "avg"
+	AggMin     AggregateOp = "min"
+	AggMax     AggregateOp = "max"
+	AggCount   AggregateOp = "count"
+	AggTopK    AggregateOp = "topk"
+	AggBottomK AggregateOp = "bottomk"
+)
+
+var aggregateOps = map[string]AggregateOp{
+	string(AggSum):     AggSum,
+	string(AggAvg):     AggAvg,
+	string(AggMin):     AggMin,
+	string(AggMax):     AggMax,
+	string(AggCount):   AggCount,
+	string(AggTopK):    AggTopK,
+	string(AggBottomK): AggBottomK,
+}
+
+// Expr is an expression which evaluates to an instant vector.
+type Expr interface {
+	expr()
+}
+
+// VectorSelector selects the series of a metric.
+// It's a range vector selector if Range is positive, which is only accepted by the functions.
+type VectorSelector struct {
+	// Name is the metric name.
+	Name string
+	// Matchers are the label matchers except the one on the metric name.
+	Matchers []prompb.LabelMatcher
+	Range    time.Duration
+}
+
+// Call applies a function to a range vector.
+type Call struct {
+	Arg  *VectorSelector
+	Func Function
+}
+
+// AggregateExpr aggregates the series of an expression.
+type AggregateExpr struct {
+	Expr Expr
+	Op   AggregateOp
+	// Grouping are the labels of the "by" clause.
+	Grouping []string
+	// Param is the number of series selected by topk and bottomk.
+	Param int
+}
+
+func (*VectorSelector) expr() {}
+
+func (*Call) expr() {}
+
+func (*AggregateExpr) expr() {}
+
+// PushDownFunction returns the range function whose results can be aggregated across the series by the measure,
+// which means aggregating the samples of all series in a window produces the same result.
+func (a *AggregateExpr) PushDownFunction() (Function, bool) {
+	call, ok := a.Expr.(*Call)
+	if !ok || len(a.Grouping) == 0 {
+		return "", false
+	}
+	switch {
+	case a.Op == AggSum && (call.Func == FuncSumOverTime || call.Func == FuncCountOverTime),
+		a.Op == AggMin && call.Func == FuncMinOverTime,
+		a.Op == AggMax && call.Func == FuncMaxOverTime:
+		return call.Func, true
+	}
+	return "", false
+}
@@ -0,0 +1,319 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package promql
+
+import (
+	"math"
+	"sort"
+
+	"github.com/apache/skywalking-banyandb/pkg/prometheus/prompb"
+)
+
+// Point is the value of a series at a timestamp in milliseconds.
+type Point struct {
+	T int64
+	V float64
+}
+
+// Series is a series of the evaluated points.
+type Series struct {
+	Labels []prompb.Label
+	Points []Point
+}
+
+// Bucket is the aggregation of the samples in the time bucket starting at T.
+// The buckets are aggregated by the measure, and merged into the windows of the range functions.
+type Bucket struct {
+	T     int64
+	Sum   float64
+	Count float64
+	Min   float64
+	Max   float64
+}
+
+// Steps returns the evaluation timestamps between start and end.
+func Steps(start, end, step int64) []int64 {
+	if step <= 0 {
+		return []int64{start}
+	}
+	steps := make([]int64, 0, (end-start)/step+1)
+	for t := start; t <= end; t += step {
+		steps = append(steps, t)
+	}
+	return steps
+}
+
+// BucketSize returns the size of the buckets which compose the windows of all steps exactly,
+// that's the greatest common divisor of the step and the range.
+// The buckets are aligned to the Unix epoch, so they compose the windows only if the first step is aligned too,
+// which is reported by aligned. Otherwise, the windows should be evaluated on the samples by SampleBuckets.
+func BucketSize(start, step, rng int64) (size int64, aligned bool) {
+	size = gcd(step, rng)
+	return size, size > 0 && start%size == 0
+}
+
+// SampleBuckets returns a bucket of each sample, so that the windows are evaluated on the samples by EvalRange.
+func SampleBuckets(samples []Point) []Bucket {
+	buckets := make([]Bucket, len(samples))
+	for i, s := range samples {
+		buckets[i] = Bucket{T: s.T, Sum: s.V, Count: 1, Min: s.V, Max: s.V}
+	}
+	return buckets
+}
+
+func gcd(a, b int64) int64 {
+	if a < 0 {
+		a = -a
+	}
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// SelectInstant returns the latest sample of each step within the lookback, that's in (t-lookback, t].
+// The samples should be sorted by the timestamps.
+func SelectInstant(samples []Point, steps []int64, lookback int64) []Point {
+	var points []Point
+	for _, t := range steps {
+		i := sort.Search(len(samples), func(i int) bool { return samples[i].T > t }) - 1
+		if i >= 0 && samples[i].T > t-lookback {
+			points = append(points, Point{T: t, V: samples[i].V})
+		}
+	}
+	return points
+}
+
+// EvalRange applies the function to the window of each step, which is composed of the buckets in [t-rng, t).
+// The buckets should be sorted by the timestamps. rate and increase are evaluated by EvalCounter instead,
+// since they depend on the order of the samples.
+func EvalRange(f Function, rng int64, buckets []Bucket, steps []int64) []Point {
+	var points []Point
+	for _, t := range steps {
+		from := sort.Search(len(buckets), func(i int) bool { return buckets[i].T >= t-rng })
+		to := sort.Search(len(buckets), func(i int) bool { return buckets[i].T >= t })
+		if from >= to {
+			continue
+		}
+		if v, ok := evalWindow(f, buckets[from:to]); ok {
+			points = append(points, Point{T: t, V: v})
+		}
+	}
+	return points
+}
+
+func evalWindow(f Function, window []Bucket) (float64, bool) {
+	var sum, count float64
+	minV, maxV := math.Inf(1), math.Inf(-1)
+	for _, b := range window {
+		sum += b.Sum
+		count += b.Count
+		minV = math.Min(minV, b.Min)
+		maxV = math.Max(maxV, b.Max)
+	}
+	if count == 0 {
+		return 0, false
+	}
+	switch f {
+	case FuncSumOverTime:
+		return sum, true
+	case FuncCountOverTime:
+		return count, true
+	case FuncAvgOverTime:
+		return sum / count, true
+	case FuncMinOverTime:
+		return minV, true
+	case FuncMaxOverTime:
+		return maxV, true
+	}
+	return 0, false
+}
+
+// EvalCounter applies rate or increase to the samples of a counter in the window [t-rng, t) of each step,
+// as Prometheus does. The samples should be sorted by the timestamps.
+//
+// A decrease of the value is taken as a counter reset, so the increase is the sum of the positive deltas.
+// The increase is extrapolated to the window boundaries which are close to the first or the last sample,
+// and to half of the average interval between the samples otherwise. The counter isn't extrapolated below zero.
+func EvalCounter(f Function, rng int64, samples []Point, steps []int64) []Point {
+	var points []Point
+	for _, t := range steps {
+		from := sort.Search(len(samples), func(i int) bool { return samples[i].T >= t-rng })
+		to := sort.Search(len(samples), func(i int) bool { return samples[i].T >= t })
+		if to-from < 2 {
+			continue
+		}
+		increase := extrapolatedIncrease(t-rng, t, samples[from:to])
+		if f == FuncRate {
+			increase /= float64(rng) / 1000
+		}
+		points = append(points, Point{T: t, V: increase})
+	}
+	return points
+}
+
+func extrapolatedIncrease(start, end int64, window []Point) float64 {
+	first, last := window[0], window[len(window)-1]
+	increase := last.V - first.V
+	for i := 1; i < len(window); i++ {
+		if window[i].V < window[i-1].V {
+			// The counter restarts from zero, the value before the reset is the increase until then.
+			increase += window[i-1].V
+		}
+	}
+	sampled := float64(last.T - first.T)
+	average := sampled / float64(len(window)-1)
+	threshold := average * 1.1
+	toStart, toEnd := float64(first.T-start), float64(end-last.T)
+	if toStart >= threshold {
+		toStart = average / 2
+	}
+	if increase > 0 && first.V >= 0 {
+		toStart = math.Min(toStart, sampled*first.V/increase)
+	}
+	if toEnd >= threshold {
+		toEnd = average / 2
+	}
+	return increase * (sampled + toStart + toEnd) / sampled
+}
+
+// DropMetricName removes the metric name from the labels, as the functions do.
+func DropMetricName(labels []prompb.Label) []prompb.Label {
+	result := make([]prompb.Label, 0, len(labels))
+	for _, l := range labels {
+		if l.Name != prompb.MetricNameLabel {
+			result = append(result, l)
+		}
+	}
+	return result
+}
+
+// GroupLabels returns the labels of the grouping.
+func GroupLabels(labels []prompb.Label, grouping []string) []prompb.Label {
+	result := make([]prompb.Label, 0, len(grouping))
+	for _, l := range labels {
+		for _, g := range grouping {
+			if l.Name == g {
+				result = append(result, l)
+				break
+			}
+		}
+	}
+	prompb.SortLabels(result)
+	return result
+}
+
+// Aggregate aggregates the points of the series in the same group at each timestamp.
+// topk and bottomk select the series of the largest or smallest values in each group, which keep their labels.
+func Aggregate(op AggregateOp, param int, grouping []string, series []Series) []Series {
+	type group struct {
+		labels []prompb.Label
+		series []int
+	}
+	groups := make(map[string]*group)
+	var keys []string
+	for i, s := range series {
+		labels := GroupLabels(s.Labels, grouping)
+		key := prompb.LabelsString(labels)
+		g, ok := groups[key]
+		if !ok {
+			g = &group{labels: labels}
+			groups[key] = g
+			keys = append(keys, key)
+		}
+		g.series = append(g.series, i)
+	}
+	sort.Strings(keys)
+
+	var result []Series
+	for _, key := range keys {
+		g := groups[key]
+		byTime := make(map[int64][]int)
+		var timestamps []int64
+		pointsOf := make([]map[int64]float64, len(g.series))
+		for j, i := range g.series {
+			pointsOf[j] = make(map[int64]float64, len(series[i].Points))
+			for _, p := range series[i].Points {
+				if math.IsNaN(p.V) {
+					continue
+				}
+				pointsOf[j][p.T] = p.V
+				if _, ok := byTime[p.T]; !ok {
+					timestamps = append(timestamps, p.T)
+				}
+				byTime[p.T] = append(byTime[p.T], j)
+			}
+		}
+		sort.Slice(timestamps, func(a, b int) bool { return timestamps[a] < timestamps[b] })
+
+		if op == AggTopK || op == AggBottomK {
+			selected := make([][]Point, len(g.series))
+			for _, t := range timestamps {
+				members := byTime[t]
+				sort.SliceStable(members, func(a, b int) bool {
+					if op == AggTopK {
+						return pointsOf[members[a]][t] > pointsOf[members[b]][t]
+					}
+					return pointsOf[members[a]][t] < pointsOf[members[b]][t]
+				})
+				if len(members) > param {
+					members = members[:param]
+				}
+				for _, j := range members {
+					selected[j] = append(selected[j], Point{T: t, V: pointsOf[j][t]})
+				}
+			}
+			for j, points := range selected {
+				if len(points) > 0 {
+					result = append(result, Series{Labels: series[g.series[j]].Labels, Points: points})
+				}
+			}
+			continue
+		}
+
+		s := Series{Labels: g.labels}
+		for _, t := range timestamps {
+			members := byTime[t]
+			var v float64
+			switch op {
+			case AggSum, AggAvg:
+				for _, j := range members {
+					v += pointsOf[j][t]
+				}
+				if op == AggAvg {
+					v /= float64(len(members))
+				}
+			case AggMin:
+				v = math.Inf(1)
+				for _, j := range members {
+					v = math.Min(v, pointsOf[j][t])
+				}
+			case AggMax:
+				v = math.Inf(-1)
+				for _, j := range members {
+					v = math.Max(v, pointsOf[j][t])
+				}
+			case AggCount:
+				v = float64(len(members))
+			}
+			s.Points = append(s.Points, Point{T: t, V: v})
+		}
+		result = append(result, s)
+	}
+	return result
+}
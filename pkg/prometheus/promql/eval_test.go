@@ -0,0 +1,129 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package promql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apache/skywalking-banyandb/pkg/prometheus/prompb"
+)
+
+func TestStepsAndBucketSize(t *testing.T) {
+	assert.Equal(t, []int64{1000, 1500, 2000}, Steps(1000, 2200, 500))
+	assert.Equal(t, []int64{1000}, Steps(1000, 1000, 0))
+	assertBucketSize := func(size int64, aligned bool, start, step, rng int64) {
+		gotSize, gotAligned := BucketSize(start, step, rng)
+		assert.Equal(t, size, gotSize)
+		assert.Equal(t, aligned, gotAligned)
+	}
+	assertBucketSize(30000, true, 600000, 60000, 90000)
+	// The start isn't in the greatest common divisor, otherwise the bucket collapses to 1ms.
+	assertBucketSize(30000, false, 600001, 60000, 90000)
+	// An instant query has no step.
+	assertBucketSize(60000, true, 600000, 0, 60000)
+	assertBucketSize(60000, false, 630000, 0, 60000)
+}
+
+func TestSelectInstant(t *testing.T) {
+	samples := []Point{{T: 10, V: 1}, {T: 20, V: 2}, {T: 50, V: 5}}
+	assert.Equal(t, []Point{{T: 20, V: 2}, {T: 40, V: 2}, {T: 60, V: 5}},
+		SelectInstant(samples, []int64{0, 20, 40, 60, 80}, 25))
+}
+
+func TestEvalRange(t *testing.T) {
+	// A counter is reset in the third bucket.
+	buckets := []Bucket{
+		{T: 0, Sum: 3, Count: 2, Min: 1, Max: 2},
+		{T: 10, Sum: 9, Count: 2, Min: 4, Max: 5},
+		{T: 20, Sum: 4, Count: 2, Min: 1, Max: 3},
+	}
+	steps := []int64{10, 30}
+	assert.Equal(t, []Point{{T: 10, V: 3}, {T: 30, V: 13}}, EvalRange(FuncSumOverTime, 20, buckets, steps))
+	assert.Equal(t, []Point{{T: 10, V: 2}, {T: 30, V: 4}}, EvalRange(FuncCountOverTime, 20, buckets, steps))
+	assert.Equal(t, []Point{{T: 10, V: 1.5}, {T: 30, V: 3.25}}, EvalRange(FuncAvgOverTime, 20, buckets, steps))
+	assert.Equal(t, []Point{{T: 10, V: 1}, {T: 30, V: 1}}, EvalRange(FuncMinOverTime, 20, buckets, steps))
+	assert.Equal(t, []Point{{T: 10, V: 2}, {T: 30, V: 5}}, EvalRange(FuncMaxOverTime, 20, buckets, steps))
+}
+
+func TestEvalRangeUnalignedStart(t *testing.T) {
+	samples := []Point{{T: 0, V: 1}, {T: 10, V: 2}, {T: 15, V: 3}, {T: 25, V: 4}, {T: 35, V: 5}}
+	// The windows [5, 25) and [15, 35) don't start at the bucket of 10, so they're evaluated on the samples.
+	steps := Steps(25, 35, 10)
+	size, aligned := BucketSize(steps[0], 10, 20)
+	assert.Equal(t, int64(10), size)
+	assert.False(t, aligned)
+	buckets := SampleBuckets(samples)
+	assert.Equal(t, []Point{{T: 25, V: 5}, {T: 35, V: 7}}, EvalRange(FuncSumOverTime, 20, buckets, steps))
+	assert.Equal(t, []Point{{T: 25, V: 2}, {T: 35, V: 2}}, EvalRange(FuncCountOverTime, 20, buckets, steps))
+	assert.Equal(t, []Point{{T: 25, V: 2}, {T: 35, V: 3}}, EvalRange(FuncMinOverTime, 20, buckets, steps))
+}
+
+func TestEvalCounter(t *testing.T) {
+	// The counter is reset after 5, the positive deltas are 2, 2, 1 and 2.
+	samples := []Point{{T: 0, V: 1}, {T: 10, V: 3}, {T: 20, V: 5}, {T: 30, V: 1}, {T: 40, V: 3}}
+	// The first sample is at the start of [0, 50), the last one is 10ms before the end, which is extrapolated.
+	assert.Equal(t, []Point{{T: 50, V: 8.75}}, EvalCounter(FuncIncrease, 50, samples, []int64{50}))
+	assert.Equal(t, []Point{{T: 50, V: 175}}, EvalCounter(FuncRate, 50, samples, []int64{50}))
+	// A single sample has no rate.
+	assert.Empty(t, EvalCounter(FuncRate, 10, samples, []int64{10, 20}))
+
+	// The last sample is far from the window end, the increase is extrapolated by half of the interval there.
+	// At the start, it's extrapolated until the counter reaches zero, which is after the window start.
+	sparse := []Point{{T: 100, V: 5}, {T: 200, V: 20}}
+	got := EvalCounter(FuncIncrease, 1000, sparse, []int64{1000})
+	assert.Len(t, got, 1)
+	assert.InDelta(t, 15*(100+100.0/3+50)/100, got[0].V, 1e-9)
+	got = EvalCounter(FuncRate, 2000, sparse, []int64{1000})
+	assert.Len(t, got, 1)
+	// The window [-1000, 1000) lasts 2 seconds.
+	assert.InDelta(t, 15*(100+100.0/3+50)/100/2, got[0].V, 1e-9)
+}
+
+func TestAggregate(t *testing.T) {
+	series := []Series{
+		{Labels: []prompb.Label{{Name: "instance", Value: "a"}, {Name: "job", Value: "api"}}, Points: []Point{{T: 10, V: 1}, {T: 20, V: 4}}},
+		{Labels: []prompb.Label{{Name: "instance", Value: "b"}, {Name: "job", Value: "api"}}, Points: []Point{{T: 10, V: 3}}},
+		{Labels: []prompb.Label{{Name: "instance", Value: "c"}, {Name: "job", Value: "web"}}, Points: []Point{{T: 20, V: 2}}},
+	}
+	api := []prompb.Label{{Name: "job", Value: "api"}}
+	web := []prompb.Label{{Name: "job", Value: "web"}}
+	assert.Equal(t, []Series{
+		{Labels: api, Points: []Point{{T: 10, V: 4}, {T: 20, V: 4}}},
+		{Labels: web, Points: []Point{{T: 20, V: 2}}},
+	}, Aggregate(AggSum, 0, []string{"job"}, series))
+	assert.Equal(t, []Series{
+		{Labels: []prompb.Label{}, Points: []Point{{T: 10, V: 2}, {T: 20, V: 3}}},
+	}, Aggregate(AggAvg, 0, nil, series))
+	assert.Equal(t, []Series{
+		{Labels: []prompb.Label{}, Points: []Point{{T: 10, V: 2}, {T: 20, V: 2}}},
+	}, Aggregate(AggCount, 0, nil, series))
+	assert.Equal(t, []Series{
+		{Labels: api, Points: []Point{{T: 10, V: 1}, {T: 20, V: 4}}},
+		{Labels: web, Points: []Point{{T: 20, V: 2}}},
+	}, Aggregate(AggMin, 0, []string{"job"}, series))
+	assert.Equal(t, []Series{
+		{Labels: series[0].Labels, Points: []Point{{T: 20, V: 4}}},
+		{Labels: series[1].Labels, Points: []Point{{T: 10, V: 3}}},
+	}, Aggregate(AggTopK, 1, nil, series))
+	assert.Equal(t, []Series{
+		{Labels: series[0].Labels, Points: []Point{{T: 10, V: 1}}},
+		{Labels: series[2].Labels, Points: []Point{{T: 20, V: 2}}},
+	}, Aggregate(AggBottomK, 1, nil, series))
+}
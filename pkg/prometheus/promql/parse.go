@@ -0,0 +1,425 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package promql
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/apache/skywalking-banyandb/pkg/prometheus/prompb"
+)
+
+// ErrParse indicates the query is invalid or out of the supported subset.
+var ErrParse = errors.New("invalid query")
+
+var durationUnits = []struct {
+	unit string
+	d    time.Duration
+}{
+	// "ms" is matched before "m".
+	{"ms", time.Millisecond},
+	{"s", time.Second},
+	{"m", time.Minute},
+	{"h", time.Hour},
+	{"d", 24 * time.Hour},
+	{"w", 7 * 24 * time.Hour},
+	{"y", 365 * 24 * time.Hour},
+}
+
+// ParseDuration parses a duration in the Prometheus format, like "5m" or "1h30m".
+func ParseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, errors.WithMessage(ErrParse, "empty duration")
+	}
+	var d time.Duration
+	rest := s
+	for rest != "" {
+		i := 0
+		for i < len(rest) && rest[i] >= '0' && rest[i] <= '9' {
+			i++
+		}
+		if i == 0 {
+			return 0, errors.WithMessagef(ErrParse, "invalid duration %q", s)
+		}
+		n, err := strconv.ParseInt(rest[:i], 10, 64)
+		if err != nil {
+			return 0, errors.WithMessagef(ErrParse, "invalid duration %q", s)
+		}
+		rest = rest[i:]
+		matched := false
+		for _, u := range durationUnits {
+			if strings.HasPrefix(rest, u.unit) {
+				if n > int64(math.MaxInt64/u.d) {
+					return 0, errors.WithMessagef(ErrParse, "duration %q is too large", s)
+				}
+				d += time.Duration(n) * u.d
+				rest = rest[len(u.unit):]
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return 0, errors.WithMessagef(ErrParse, "invalid duration %q", s)
+		}
+	}
+	return d, nil
+}
+
+// Parse parses a query of the supported subset:
+//
+//   - The vector selectors with the label matchers, like `http_requests_total{job="api", code=~"5.."}`.
+//   - The functions on the range vectors: rate, increase and the <aggregation>_over_time functions.
+//   - The aggregation operators sum, avg, min, max, count, topk and bottomk, with an optional "by" clause.
+func Parse(query string) (Expr, error) {
+	p := &parser{input: query}
+	e, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if p.pos < len(p.input) {
+		return nil, p.errorf("unexpected %q", p.input[p.pos:])
+	}
+	if s, ok := e.(*VectorSelector); ok && s.Range > 0 {
+		return nil, p.errorf("the range vector should be the argument of a function")
+	}
+	return e, nil
+}
+
+type parser struct {
+	input string
+	pos   int
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return errors.WithMessagef(ErrParse, "position %d: "+format, append([]interface{}{p.pos}, args...)...)
+}
+
+func (p *parser) skipSpaces() {
+	for p.pos < len(p.input) {
+		switch p.input[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// peek returns the next character after the spaces, or 0 at the end of the input.
+func (p *parser) peek() byte {
+	p.skipSpaces()
+	if p.pos >= len(p.input) {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *parser) expect(c byte) error {
+	if p.peek() != c {
+		if p.pos >= len(p.input) {
+			return p.errorf("expect %q, but the query ends", c)
+		}
+		return p.errorf("expect %q, but got %q", c, p.input[p.pos])
+	}
+	p.pos++
+	return nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
+
+// ident reads a label name, or a metric name if colons are allowed.
+func (p *parser) ident(colon bool) string {
+	p.skipSpaces()
+	start := p.pos
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		if !(isIdentChar(c) || (colon && c == ':')) || (p.pos == start && c >= '0' && c <= '9') {
+			break
+		}
+		p.pos++
+	}
+	return p.input[start:p.pos]
+}
+
+func (p *parser) parseExpr() (Expr, error) {
+	if p.peek() == '{' {
+		return p.parseSelector("")
+	}
+	start := p.pos
+	name := p.ident(true)
+	if name == "" {
+		if p.pos >= len(p.input) {
+			return nil, p.errorf("expect an expression, but the query ends")
+		}
+		return nil, p.errorf("unexpected %q", p.input[p.pos])
+	}
+	if op, ok := aggregateOps[name]; ok && (p.peek() == '(' || p.peekKeyword()) {
+		return p.parseAggregate(op)
+	}
+	if p.peek() == '(' {
+		f, ok := functions[name]
+		if !ok {
+			p.pos = start
+			return nil, p.errorf("unsupported function %s", name)
+		}
+		return p.parseCall(f)
+	}
+	return p.parseSelector(name)
+}
+
+// peekKeyword reports whether a grouping keyword follows.
+func (p *parser) peekKeyword() bool {
+	pos := p.pos
+	defer func() { p.pos = pos }()
+	kw := p.ident(false)
+	return kw == "by" || kw == "without"
+}
+
+func (p *parser) parseGrouping() ([]string, error) {
+	kw := p.ident(false)
+	if kw == "without" {
+		return nil, p.errorf("the without clause is not supported")
+	}
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	grouping := []string{}
+	if p.peek() == ')' {
+		p.pos++
+		return grouping, nil
+	}
+	for {
+		label := p.ident(false)
+		if label == "" {
+			return nil, p.errorf("expect a label name")
+		}
+		grouping = append(grouping, label)
+		if p.peek() == ',' {
+			p.pos++
+			if p.peek() == ')' {
+				p.pos++
+				return grouping, nil
+			}
+			continue
+		}
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+		return grouping, nil
+	}
+}
+
+func (p *parser) parseAggregate(op AggregateOp) (Expr, error) {
+	a := &AggregateExpr{Op: op}
+	var err error
+	if p.peek() != '(' {
+		if a.Grouping, err = p.parseGrouping(); err != nil {
+			return nil, err
+		}
+	}
+	if err = p.expect('('); err != nil {
+		return nil, err
+	}
+	if op == AggTopK || op == AggBottomK {
+		if a.Param, err = p.parseParam(); err != nil {
+			return nil, err
+		}
+		if err = p.expect(','); err != nil {
+			return nil, err
+		}
+	}
+	if a.Expr, err = p.parseInstantExpr(); err != nil {
+		return nil, err
+	}
+	if err = p.expect(')'); err != nil {
+		return nil, err
+	}
+	if a.Grouping == nil && p.peekKeyword() {
+		if a.Grouping, err = p.parseGrouping(); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+func (p *parser) parseParam() (int, error) {
+	p.skipSpaces()
+	start := p.pos
+	for p.pos < len(p.input) && ((p.input[p.pos] >= '0' && p.input[p.pos] <= '9') || p.input[p.pos] == '.') {
+		p.pos++
+	}
+	v, err := strconv.ParseFloat(p.input[start:p.pos], 64)
+	if err != nil || v < 1 || v > math.MaxInt32 {
+		p.pos = start
+		return 0, p.errorf("expect a positive number of series")
+	}
+	return int(v), nil
+}
+
+func (p *parser) parseInstantExpr() (Expr, error) {
+	e, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if s, ok := e.(*VectorSelector); ok && s.Range > 0 {
+		return nil, p.errorf("expect an instant vector, but got the range vector")
+	}
+	return e, nil
+}
+
+func (p *parser) parseCall(f Function) (Expr, error) {
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	e, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	s, ok := e.(*VectorSelector)
+	if !ok || s.Range == 0 {
+		return nil, p.errorf("%s expects a range vector selector", f)
+	}
+	if err = p.expect(')'); err != nil {
+		return nil, err
+	}
+	return &Call{Func: f, Arg: s}, nil
+}
+
+func (p *parser) parseSelector(name string) (Expr, error) {
+	s := &VectorSelector{Name: name}
+	if p.peek() == '{' {
+		p.pos++
+		for p.peek() != '}' {
+			m, err := p.parseMatcher()
+			if err != nil {
+				return nil, err
+			}
+			if m.Name == prompb.MetricNameLabel {
+				if m.Type != prompb.MatchEqual || s.Name != "" {
+					return nil, p.errorf("the metric name should be selected by a single equality matcher")
+				}
+				s.Name = m.Value
+			} else {
+				s.Matchers = append(s.Matchers, m)
+			}
+			if p.peek() != ',' {
+				break
+			}
+			p.pos++
+		}
+		if err := p.expect('}'); err != nil {
+			return nil, err
+		}
+	}
+	if s.Name == "" {
+		return nil, p.errorf("the metric name is absent")
+	}
+	if p.peek() == '[' {
+		p.pos++
+		end := strings.IndexByte(p.input[p.pos:], ']')
+		if end < 0 {
+			return nil, p.errorf("the range is not closed")
+		}
+		d, err := ParseDuration(strings.TrimSpace(p.input[p.pos : p.pos+end]))
+		if err != nil {
+			return nil, err
+		}
+		if d <= 0 {
+			return nil, p.errorf("the range should be positive")
+		}
+		p.pos += end + 1
+		s.Range = d
+	}
+	return s, nil
+}
+
+func (p *parser) parseMatcher() (prompb.LabelMatcher, error) {
+	m := prompb.LabelMatcher{Name: p.ident(false)}
+	if m.Name == "" {
+		return m, p.errorf("expect a label name")
+	}
+	p.skipSpaces()
+	switch rest := p.input[p.pos:]; {
+	case strings.HasPrefix(rest, "=~"):
+		m.Type = prompb.MatchRegexp
+		p.pos += 2
+	case strings.HasPrefix(rest, "!~"):
+		m.Type = prompb.MatchNotRegexp
+		p.pos += 2
+	case strings.HasPrefix(rest, "!="):
+		m.Type = prompb.MatchNotEqual
+		p.pos += 2
+	case strings.HasPrefix(rest, "="):
+		m.Type = prompb.MatchEqual
+		p.pos++
+	default:
+		return m, p.errorf("expect a matching operator")
+	}
+	var err error
+	if m.Value, err = p.parseString(); err != nil {
+		return m, err
+	}
+	if _, err = m.Matcher(); err != nil {
+		return m, p.errorf("%v", err)
+	}
+	return m, nil
+}
+
+func (p *parser) parseString() (string, error) {
+	quote := p.peek()
+	if quote != '"' && quote != '\'' && quote != '`' {
+		return "", p.errorf("expect a string")
+	}
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.input) && p.input[p.pos] != quote {
+		if p.input[p.pos] == '\\' && quote != '`' {
+			p.pos++
+		}
+		p.pos++
+	}
+	if p.pos >= len(p.input) {
+		p.pos = start
+		return "", p.errorf("the string is not closed")
+	}
+	p.pos++
+	raw := p.input[start:p.pos]
+	lit := raw
+	if quote == '\'' {
+		// Convert to a double-quoted literal, so that the escapes are handled by strconv.Unquote.
+		body := strings.ReplaceAll(lit[1:len(lit)-1], `\'`, `'`)
+		lit = `"` + strings.ReplaceAll(body, `"`, `\"`) + `"`
+	}
+	v, err := strconv.Unquote(lit)
+	if err != nil {
+		p.pos = start
+		return "", p.errorf("invalid string %s", raw)
+	}
+	return v, nil
+}
@@ -0,0 +1,124 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package promql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/apache/skywalking-banyandb/pkg/prometheus/prompb"
+)
+
+func TestParse(t *testing.T) {
+	requests := &VectorSelector{
+		Name: "http_requests_total",
+		Matchers: []prompb.LabelMatcher{
+			{Type: prompb.MatchEqual, Name: "job", Value: "api"},
+			{Type: prompb.MatchRegexp, Name: "code", Value: "5.."},
+		},
+		Range: 5 * time.Minute,
+	}
+	tests := []struct {
+		want  Expr
+		query string
+	}{
+		{query: "up", want: &VectorSelector{Name: "up"}},
+		{query: `{__name__="up", job!='a\'b'}`, want: &VectorSelector{
+			Name:     "up",
+			Matchers: []prompb.LabelMatcher{{Type: prompb.MatchNotEqual, Name: "job", Value: "a'b"}},
+		}},
+		{query: "node:cpu:ratio{mode!~`idle|iowait`}", want: &VectorSelector{
+			Name:     "node:cpu:ratio",
+			Matchers: []prompb.LabelMatcher{{Type: prompb.MatchNotRegexp, Name: "mode", Value: "idle|iowait"}},
+		}},
+		{query: `rate(http_requests_total{job="api",code=~"5.."}[5m])`, want: &Call{Func: FuncRate, Arg: requests}},
+		{query: `sum by (job) (rate(http_requests_total{job="api", code=~"5..",}[5m]))`, want: &AggregateExpr{
+			Op:       AggSum,
+			Grouping: []string{"job"},
+			Expr:     &Call{Func: FuncRate, Arg: requests},
+		}},
+		{query: `sum(avg_over_time(up[1h30m])) by (job, instance)`, want: &AggregateExpr{
+			Op:       AggSum,
+			Grouping: []string{"job", "instance"},
+			Expr:     &Call{Func: FuncAvgOverTime, Arg: &VectorSelector{Name: "up", Range: 90 * time.Minute}},
+		}},
+		{query: `topk(3, sum by () (up))`, want: &AggregateExpr{
+			Op:    AggTopK,
+			Param: 3,
+			Expr:  &AggregateExpr{Op: AggSum, Grouping: []string{}, Expr: &VectorSelector{Name: "up"}},
+		}},
+		// A metric can be named after an operator.
+		{query: `sum{job="api"}`, want: &VectorSelector{
+			Name:     "sum",
+			Matchers: []prompb.LabelMatcher{{Type: prompb.MatchEqual, Name: "job", Value: "api"}},
+		}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.query)
+		require.NoError(t, err, tt.query)
+		assert.Equal(t, tt.want, got, tt.query)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, query := range []string{
+		"",
+		"up[5m]",
+		`{job="api"}`,
+		`{__name__=~"up|down"}`,
+		"rate(up)",
+		"sum(up[5m])",
+		"histogram_quantile(0.9, up)",
+		"sum without (job) (up)",
+		"topk(0, up)",
+		`up{job=~"("}`,
+		`up{job="api"`,
+		"rate(up[5x])",
+		"up + 1",
+	} {
+		_, err := Parse(query)
+		assert.ErrorIs(t, err, ErrParse, query)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	d, err := ParseDuration("1d2h3m4s5ms")
+	require.NoError(t, err)
+	assert.Equal(t, 26*time.Hour+3*time.Minute+4*time.Second+5*time.Millisecond, d)
+	d, err = ParseDuration("1w")
+	require.NoError(t, err)
+	assert.Equal(t, 7*24*time.Hour, d)
+	for _, s := range []string{"", "5", "m", "1.5m", "99999999999y"} {
+		_, err = ParseDuration(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestPushDownFunction(t *testing.T) {
+	count := &Call{Func: FuncCountOverTime, Arg: &VectorSelector{Name: "up", Range: time.Minute}}
+	f, ok := (&AggregateExpr{Op: AggSum, Grouping: []string{"job"}, Expr: count}).PushDownFunction()
+	assert.True(t, ok)
+	assert.Equal(t, FuncCountOverTime, f)
+	_, ok = (&AggregateExpr{Op: AggSum, Expr: count}).PushDownFunction()
+	assert.False(t, ok)
+	_, ok = (&AggregateExpr{Op: AggMax, Grouping: []string{"job"}, Expr: count}).PushDownFunction()
+	assert.False(t, ok)
+}
@@ -57,6 +57,14 @@ func NewFunc[N Number](af modelv1.AggregationFunction) (Func[N], error) {
 		result = &minFunc[N]{max: maxOf[N]()}
 	case modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM:
 		result = &sumFunc[N]{zero: zero[N]()}
+	case modelv1.AggregationFunction_AGGREGATION_FUNCTION_P50:
+		result = &percentileFunc[N]{quantile: 0.5, zero: zero[N]()}
+	case modelv1.AggregationFunction_AGGREGATION_FUNCTION_P90:
+		result = &percentileFunc[N]{quantile: 0.9, zero: zero[N]()}
+	case modelv1.AggregationFunction_AGGREGATION_FUNCTION_P99:
+		result = &percentileFunc[N]{quantile: 0.99, zero: zero[N]()}
+	case modelv1.AggregationFunction_AGGREGATION_FUNCTION_DISTINCT_COUNT:
+		result = &distinctCountFunc[N]{}
 	default:
 		return nil, errors.WithMessagef(errUnknownFunc, "unknown function:%s", modelv1.AggregationFunction_name[int32(af)])
 	}
@@ -64,6 +72,31 @@ func NewFunc[N Number](af modelv1.AggregationFunction) (Func[N], error) {
 	return result, nil
 }
 
+// IsMergeable returns true if the function is implemented by a PartialFunc,
+// whose partial states of data nodes are merged by the liaison.
+func IsMergeable(af modelv1.AggregationFunction) bool {
+	switch af {
+	case modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM,
+		modelv1.AggregationFunction_AGGREGATION_FUNCTION_COUNT,
+		modelv1.AggregationFunction_AGGREGATION_FUNCTION_MEAN:
+		return true
+	}
+	return IsPartial(af)
+}
+
+// IsPartial returns true if the function can only be computed from the partial states of data nodes,
+// because it can't be computed from their results, such as percentiles and distinct counts.
+func IsPartial(af modelv1.AggregationFunction) bool {
+	switch af {
+	case modelv1.AggregationFunction_AGGREGATION_FUNCTION_P50,
+		modelv1.AggregationFunction_AGGREGATION_FUNCTION_P90,
+		modelv1.AggregationFunction_AGGREGATION_FUNCTION_P99,
+		modelv1.AggregationFunction_AGGREGATION_FUNCTION_DISTINCT_COUNT:
+		return true
+	}
+	return false
+}
+
 // FromFieldValue transforms modelv1.FieldValue to Number.
 func FromFieldValue[N Number](fieldValue *modelv1.FieldValue) (N, error) {
 	switch fieldValue.GetValue().(type) {
@@ -17,6 +17,12 @@
 
 package aggregation
 
+import (
+	"github.com/pkg/errors"
+
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+)
+
 type meanFunc[N Number] struct {
 	sum   N
 	count N
@@ -44,6 +50,21 @@ func (m *meanFunc[N]) Reset() {
 	m.count = m.zero
 }
 
+// Partial returns the sum and the count, since the means of data nodes can't be averaged.
+func (m *meanFunc[N]) Partial() ([]byte, error) {
+	return appendNumber(appendNumber(nil, m.sum), m.count), nil
+}
+
+func (m *meanFunc[N]) Merge(state []byte) error {
+	numbers, err := decodeNumbers[N](state, 2)
+	if err != nil {
+		return err
+	}
+	m.sum += numbers[0]
+	m.count += numbers[1]
+	return nil
+}
+
 type countFunc[N Number] struct {
 	count N
 	zero  N
@@ -61,6 +82,20 @@ func (c *countFunc[N]) Reset() {
 	c.count = c.zero
 }
 
+func (c *countFunc[N]) Partial() ([]byte, error) {
+	return appendNumber(nil, c.count), nil
+}
+
+// Merge adds the count of a data node, rather than counting it as a value.
+func (c *countFunc[N]) Merge(state []byte) error {
+	numbers, err := decodeNumbers[N](state, 1)
+	if err != nil {
+		return err
+	}
+	c.count += numbers[0]
+	return nil
+}
+
 type sumFunc[N Number] struct {
 	sum  N
 	zero N
@@ -78,6 +113,19 @@ func (s *sumFunc[N]) Reset() {
 	s.sum = s.zero
 }
 
+func (s *sumFunc[N]) Partial() ([]byte, error) {
+	return appendNumber(nil, s.sum), nil
+}
+
+func (s *sumFunc[N]) Merge(state []byte) error {
+	numbers, err := decodeNumbers[N](state, 1)
+	if err != nil {
+		return err
+	}
+	s.sum += numbers[0]
+	return nil
+}
+
 type maxFunc[N Number] struct {
 	val N
 	min N
@@ -115,3 +163,33 @@ func (m minFunc[N]) Val() N {
 func (m *minFunc[N]) Reset() {
 	m.val = m.max
 }
+
+func appendNumber[N Number](dst []byte, v N) []byte {
+	switch x := any(v).(type) {
+	case int64:
+		return append(dst, convert.Uint64ToBytes(uint64(x))...)
+	case float64:
+		return append(dst, convert.Float64ToBytes(x)...)
+	default:
+		panic("unreachable")
+	}
+}
+
+func decodeNumbers[N Number](state []byte, n int) ([]N, error) {
+	if len(state) != n*8 {
+		return nil, errors.Errorf("the partial state should have %d numbers, but got %d bytes", n, len(state))
+	}
+	numbers := make([]N, n)
+	for i := range numbers {
+		b := state[i*8 : (i+1)*8]
+		switch x := any(&numbers[i]).(type) {
+		case *int64:
+			*x = int64(convert.BytesToUint64(b))
+		case *float64:
+			*x = convert.BytesToFloat64(b)
+		default:
+			panic("unreachable")
+		}
+	}
+	return numbers, nil
+}
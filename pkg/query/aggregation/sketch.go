@@ -0,0 +1,126 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package aggregation
+
+import (
+	"bytes"
+	"math"
+
+	"github.com/axiomhq/hyperloglog"
+	"github.com/caio/go-tdigest"
+	"github.com/pkg/errors"
+
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+)
+
+// digestCompression trades the accuracy of percentiles for the size of the t-digest.
+const digestCompression = 100
+
+// PartialFunc is a Func whose partial states are mergeable.
+// Data nodes compute the partial states of their own data, which are merged by the liaison,
+// instead of computing the function on the results of data nodes.
+type PartialFunc[N Number] interface {
+	Func[N]
+	// Partial returns the serialized state of the values taken in.
+	Partial() ([]byte, error)
+	// Merge merges the state returned by Partial into the function.
+	Merge(state []byte) error
+}
+
+// percentileFunc estimates a percentile by a t-digest.
+type percentileFunc[N Number] struct {
+	digest   *tdigest.TDigest
+	quantile float64
+	zero     N
+}
+
+func (p *percentileFunc[N]) In(val N) {
+	// Adding a NaN is the only failure, which doesn't affect the percentile.
+	_ = p.digest.Add(float64(val))
+}
+
+func (p percentileFunc[N]) Val() N {
+	if p.digest.Count() == 0 {
+		return p.zero
+	}
+	v := p.digest.Quantile(p.quantile)
+	if _, ok := any(p.zero).(int64); ok {
+		v = math.Round(v)
+	}
+	return N(v)
+}
+
+func (p *percentileFunc[N]) Reset() {
+	p.digest = newDigest()
+}
+
+func (p *percentileFunc[N]) Partial() ([]byte, error) {
+	return p.digest.AsBytes()
+}
+
+func (p *percentileFunc[N]) Merge(state []byte) error {
+	other, err := tdigest.FromBytes(bytes.NewReader(state), tdigest.Compression(digestCompression))
+	if err != nil {
+		return errors.WithMessage(err, "failed to decode the t-digest")
+	}
+	return p.digest.Merge(other)
+}
+
+func newDigest() *tdigest.TDigest {
+	digest, err := tdigest.New(tdigest.Compression(digestCompression))
+	if err != nil {
+		panic(err)
+	}
+	return digest
+}
+
+// distinctCountFunc estimates the number of distinct values by a HyperLogLog sketch.
+type distinctCountFunc[N Number] struct {
+	sketch *hyperloglog.Sketch
+}
+
+func (d *distinctCountFunc[N]) In(val N) {
+	switch v := any(val).(type) {
+	case int64:
+		d.sketch.Insert(convert.Int64ToBytes(v))
+	case float64:
+		d.sketch.Insert(convert.Float64ToBytes(v))
+	default:
+		d.sketch.Insert(convert.Float64ToBytes(float64(val)))
+	}
+}
+
+func (d distinctCountFunc[N]) Val() N {
+	return N(d.sketch.Estimate())
+}
+
+func (d *distinctCountFunc[N]) Reset() {
+	d.sketch = hyperloglog.New()
+}
+
+func (d *distinctCountFunc[N]) Partial() ([]byte, error) {
+	return d.sketch.MarshalBinary()
+}
+
+func (d *distinctCountFunc[N]) Merge(state []byte) error {
+	other := hyperloglog.New()
+	if err := other.UnmarshalBinary(state); err != nil {
+		return errors.WithMessage(err, "failed to decode the HyperLogLog sketch")
+	}
+	return d.sketch.Merge(other)
+}
@@ -0,0 +1,113 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package aggregation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+)
+
+func TestPercentile(t *testing.T) {
+	tests := []struct {
+		af   modelv1.AggregationFunction
+		want float64
+	}{
+		{af: modelv1.AggregationFunction_AGGREGATION_FUNCTION_P50, want: 5000},
+		{af: modelv1.AggregationFunction_AGGREGATION_FUNCTION_P90, want: 9000},
+		{af: modelv1.AggregationFunction_AGGREGATION_FUNCTION_P99, want: 9900},
+	}
+	for _, tt := range tests {
+		t.Run(tt.af.String(), func(t *testing.T) {
+			f, err := NewFunc[int64](tt.af)
+			require.NoError(t, err)
+			assert.Equal(t, int64(0), f.Val())
+			for i := int64(1); i <= 10000; i++ {
+				f.In(i)
+			}
+			assert.InDelta(t, tt.want, float64(f.Val()), tt.want*0.01)
+			f.Reset()
+			assert.Equal(t, int64(0), f.Val())
+		})
+	}
+}
+
+func TestDistinctCount(t *testing.T) {
+	f, err := NewFunc[float64](modelv1.AggregationFunction_AGGREGATION_FUNCTION_DISTINCT_COUNT)
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		for v := 0; v < 1000; v++ {
+			f.In(float64(v) + 0.5)
+		}
+	}
+	assert.InDelta(t, 1000, f.Val(), 20)
+}
+
+func TestMergePartialStates(t *testing.T) {
+	for _, af := range []modelv1.AggregationFunction{
+		modelv1.AggregationFunction_AGGREGATION_FUNCTION_P90,
+		modelv1.AggregationFunction_AGGREGATION_FUNCTION_DISTINCT_COUNT,
+		modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM,
+		modelv1.AggregationFunction_AGGREGATION_FUNCTION_COUNT,
+		modelv1.AggregationFunction_AGGREGATION_FUNCTION_MEAN,
+	} {
+		t.Run(af.String(), func(t *testing.T) {
+			require.True(t, IsMergeable(af))
+			all, err := NewFunc[int64](af)
+			require.NoError(t, err)
+			merged, err := NewFunc[int64](af)
+			require.NoError(t, err)
+			// Each node takes in a part of the values, whose ranges are skewed.
+			for node := int64(0); node < 3; node++ {
+				f, newErr := NewFunc[int64](af)
+				require.NoError(t, newErr)
+				for v := int64(0); v < 1000*(node+1); v++ {
+					f.In(node*1000 + v)
+					all.In(node*1000 + v)
+				}
+				state, partialErr := f.(PartialFunc[int64]).Partial()
+				require.NoError(t, partialErr)
+				require.NoError(t, merged.(PartialFunc[int64]).Merge(state))
+			}
+			assert.InDelta(t, float64(all.Val()), float64(merged.Val()), float64(all.Val())*0.02)
+		})
+	}
+	assert.False(t, IsPartial(modelv1.AggregationFunction_AGGREGATION_FUNCTION_MEAN))
+	assert.False(t, IsMergeable(modelv1.AggregationFunction_AGGREGATION_FUNCTION_MAX))
+}
+
+func TestMergeMean(t *testing.T) {
+	merged, err := NewFunc[float64](modelv1.AggregationFunction_AGGREGATION_FUNCTION_MEAN)
+	require.NoError(t, err)
+	// The mean of the means of the nodes is 3.5, while the mean of all the values is 4.5.
+	for _, values := range [][]float64{{1, 2}, {4, 5, 6, 7, 8, 3}} {
+		f, newErr := NewFunc[float64](modelv1.AggregationFunction_AGGREGATION_FUNCTION_MEAN)
+		require.NoError(t, newErr)
+		for _, v := range values {
+			f.In(v)
+		}
+		state, partialErr := f.(PartialFunc[float64]).Partial()
+		require.NoError(t, partialErr)
+		require.NoError(t, merged.(PartialFunc[float64]).Merge(state))
+	}
+	assert.Equal(t, 4.5, merged.Val())
+	assert.Error(t, merged.(PartialFunc[float64]).Merge([]byte{1}))
+}
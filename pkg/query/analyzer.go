@@ -0,0 +1,188 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+)
+
+var (
+	analyzerKey      = analyzerContextKey{}
+	operatorStatsKey = operatorStatsContextKey{}
+)
+
+type (
+	analyzerContextKey      struct{}
+	operatorStatsContextKey struct{}
+)
+
+// Analyzer collects the runtime statistics of the operators of a query plan
+// when the query is explained in the analyze mode.
+type Analyzer struct {
+	stats map[any]*OperatorStats
+	mu    sync.Mutex
+}
+
+// NewAnalyzer creates a new analyzer.
+func NewAnalyzer(ctx context.Context) (*Analyzer, context.Context) {
+	analyzer := GetAnalyzer(ctx)
+	if analyzer != nil {
+		return analyzer, ctx
+	}
+	a := &Analyzer{
+		stats: make(map[any]*OperatorStats),
+	}
+	return a, context.WithValue(ctx, analyzerKey, a)
+}
+
+// GetAnalyzer returns the analyzer from the context.
+func GetAnalyzer(ctx context.Context) *Analyzer {
+	av := ctx.Value(analyzerKey)
+	if av == nil {
+		return nil
+	}
+	analyzer, ok := av.(*Analyzer)
+	if ok {
+		return analyzer
+	}
+	panic(fmt.Errorf("invalid analyzer context value: %v", av))
+}
+
+// StartOperator returns the statistics of the operator executed in the context,
+// and the context in which the storage records the parts and blocks it scans for the operator.
+// The statistics are nil if the query isn't analyzed.
+func StartOperator(ctx context.Context, operator any) (*OperatorStats, context.Context) {
+	a := GetAnalyzer(ctx)
+	if a == nil {
+		return nil, ctx
+	}
+	a.mu.Lock()
+	s, ok := a.stats[operator]
+	if !ok {
+		s = &OperatorStats{}
+		a.stats[operator] = s
+	}
+	a.mu.Unlock()
+	return s, context.WithValue(ctx, operatorStatsKey, s)
+}
+
+// Stats returns the statistics of the operator, or nil if the operator isn't executed.
+func (a *Analyzer) Stats(operator any) *OperatorStats {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.stats[operator]
+}
+
+// GetOperatorStats returns the statistics of the operator executed in the context.
+func GetOperatorStats(ctx context.Context) *OperatorStats {
+	sv := ctx.Value(operatorStatsKey)
+	if sv == nil {
+		return nil
+	}
+	s, ok := sv.(*OperatorStats)
+	if ok {
+		return s
+	}
+	panic(fmt.Errorf("invalid operator stats context value: %v", sv))
+}
+
+// OperatorStats is the runtime statistics of an operator.
+// All its methods are safe to call on a nil value, which discards the statistics.
+type OperatorStats struct {
+	subPlans []*commonv1.PlanNode
+	rows     atomic.Int64
+	blocks   atomic.Int64
+	parts    atomic.Int64
+	duration atomic.Int64
+	mu       sync.Mutex
+}
+
+// AddRows adds the rows the operator produces.
+func (s *OperatorStats) AddRows(n int) {
+	if s == nil {
+		return
+	}
+	s.rows.Add(int64(n))
+}
+
+// AddBlocks adds the blocks the operator scans.
+func (s *OperatorStats) AddBlocks(n int) {
+	if s == nil {
+		return
+	}
+	s.blocks.Add(int64(n))
+}
+
+// AddParts adds the parts the operator scans.
+func (s *OperatorStats) AddParts(n int) {
+	if s == nil {
+		return
+	}
+	s.parts.Add(int64(n))
+}
+
+// Observe adds the time elapsed since the start to the duration of the operator.
+func (s *OperatorStats) Observe(start time.Time) {
+	if s == nil {
+		return
+	}
+	s.duration.Add(int64(time.Since(start)))
+}
+
+// AddSubPlan adds a plan executed on behalf of the operator, like the plan of a data node
+// the distributed operator sends the query to.
+func (s *OperatorStats) AddSubPlan(plan *commonv1.PlanNode) {
+	if s == nil || plan == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subPlans = append(s.subPlans, plan)
+}
+
+// SubPlans returns the plans executed on behalf of the operator.
+func (s *OperatorStats) SubPlans() []*commonv1.PlanNode {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.subPlans
+}
+
+// ToProto returns the proto representation of the statistics.
+func (s *OperatorStats) ToProto() *commonv1.PlanStats {
+	if s == nil {
+		return nil
+	}
+	return &commonv1.PlanStats{
+		Rows:     s.rows.Load(),
+		Blocks:   s.blocks.Load(),
+		Parts:    s.parts.Load(),
+		Duration: s.duration.Load(),
+	}
+}
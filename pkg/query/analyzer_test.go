@@ -0,0 +1,83 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+)
+
+func TestStartOperatorWithoutAnalyzer(t *testing.T) {
+	ctx := context.Background()
+	stats, newCtx := StartOperator(ctx, "op")
+	assert.Nil(t, stats)
+	assert.Equal(t, ctx, newCtx, "context shouldn't change if the query isn't analyzed")
+
+	// The statistics discard the records if the query isn't analyzed.
+	stats.AddRows(1)
+	stats.AddBlocks(1)
+	stats.AddParts(1)
+	stats.AddSubPlan(&commonv1.PlanNode{Operator: "DataNode"})
+	stats.Observe(time.Now())
+	assert.Nil(t, stats.SubPlans())
+	assert.Nil(t, stats.ToProto())
+	assert.Nil(t, GetOperatorStats(newCtx))
+}
+
+func TestAnalyzer(t *testing.T) {
+	analyzer, ctx := NewAnalyzer(context.Background())
+	again, againCtx := NewAnalyzer(ctx)
+	assert.Equal(t, analyzer, again, "the analyzer in the context should be reused")
+	assert.Equal(t, ctx, againCtx)
+
+	op := &struct{ name string }{name: "scan"}
+	assert.Nil(t, analyzer.Stats(op), "the operator isn't executed yet")
+
+	start := time.Now()
+	stats, opCtx := StartOperator(ctx, op)
+	require.NotNil(t, stats)
+	assert.Equal(t, stats, GetOperatorStats(opCtx), "the storage records into the statistics in the context")
+	assert.Nil(t, GetOperatorStats(ctx))
+
+	stats.AddRows(3)
+	GetOperatorStats(opCtx).AddParts(2)
+	GetOperatorStats(opCtx).AddBlocks(5)
+	stats.AddSubPlan(&commonv1.PlanNode{Operator: "DataNode"})
+	stats.AddSubPlan(nil)
+	stats.Observe(start)
+
+	// An operator executed more than once accumulates the statistics.
+	reentered, _ := StartOperator(ctx, op)
+	assert.Equal(t, stats, reentered)
+	reentered.AddRows(1)
+
+	assert.Equal(t, stats, analyzer.Stats(op))
+	got := stats.ToProto()
+	assert.Equal(t, int64(4), got.Rows)
+	assert.Equal(t, int64(5), got.Blocks)
+	assert.Equal(t, int64(2), got.Parts)
+	assert.Positive(t, got.Duration)
+	require.Len(t, stats.SubPlans(), 1)
+	assert.Equal(t, "DataNode", stats.SubPlans()[0].Operator)
+}
@@ -0,0 +1,57 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package executor
+
+import (
+	"time"
+
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query"
+)
+
+// AnalyzeMIterator records the data points the iterator produces and the time it takes into the statistics.
+// It returns the iterator as is if the statistics are nil.
+func AnalyzeMIterator(stats *query.OperatorStats, iter MIterator) MIterator {
+	if stats == nil || iter == nil {
+		return iter
+	}
+	return &analyzedMIterator{MIterator: iter, stats: stats}
+}
+
+type analyzedMIterator struct {
+	MIterator
+	stats   *query.OperatorStats
+	counted bool
+}
+
+func (ai *analyzedMIterator) Next() bool {
+	defer ai.stats.Observe(time.Now())
+	ai.counted = false
+	return ai.MIterator.Next()
+}
+
+func (ai *analyzedMIterator) Current() []*measurev1.DataPoint {
+	defer ai.stats.Observe(time.Now())
+	current := ai.MIterator.Current()
+	// Current may be called more than once after Next, which counts the data points once.
+	if !ai.counted {
+		ai.counted = true
+		ai.stats.AddRows(len(current))
+	}
+	return current
+}
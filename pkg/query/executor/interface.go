@@ -24,8 +24,10 @@ import (
 	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
 	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	tracev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/trace/v1"
 	"github.com/apache/skywalking-banyandb/pkg/bus"
 	"github.com/apache/skywalking-banyandb/pkg/query/model"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
 )
 
 // StreamExecutionContext allows retrieving data through the stream module.
@@ -33,6 +35,12 @@ type StreamExecutionContext interface {
 	Query(ctx context.Context, opts model.StreamQueryOptions) (model.StreamQueryResult, error)
 }
 
+// StatisticsProvider provides the statistics of the data in a time range.
+// An execution context implements it to let the optimizer choose the indexes by their cost.
+type StatisticsProvider interface {
+	Statistics(ctx context.Context, timeRange timestamp.TimeRange) (*model.Statistics, error)
+}
+
 // StreamExecutable allows querying in the stream schema.
 type StreamExecutable interface {
 	Execute(context.Context) ([]*streamv1.Element, error)
@@ -58,6 +66,12 @@ type MeasureExecutable interface {
 	Execute(context.Context) (MIterator, error)
 }
 
+// TraceExecutable allows querying in the trace schema.
+type TraceExecutable interface {
+	Execute(context.Context) ([]*tracev1.Span, error)
+	Close()
+}
+
 // DistributedExecutionContext allows retrieving data through the distributed module.
 type DistributedExecutionContext interface {
 	bus.Broadcaster
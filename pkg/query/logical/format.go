@@ -18,7 +18,11 @@
 package logical
 
 import (
+	"fmt"
 	"strings"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query"
 )
 
 // FormatTagRefs outputs formatted tag refs.
@@ -31,3 +35,38 @@ func FormatTagRefs(sep string, exprGroup ...[]*TagRef) string {
 	}
 	return strings.Join(exprsStr, sep)
 }
+
+// NewPlanNode returns a node of the explained plan tree with the operator and the attributes in key-value pairs.
+func NewPlanNode(operator string, keyValues ...string) *commonv1.PlanNode {
+	node := &commonv1.PlanNode{Operator: operator}
+	for i := 0; i+1 < len(keyValues); i += 2 {
+		node.Attributes = append(node.Attributes, &commonv1.Tag{Key: keyValues[i], Value: keyValues[i+1]})
+	}
+	return node
+}
+
+// NewDataNodePlan returns a node of the explained plan tree holding the plan a data node executes.
+func NewDataNodePlan(node string, plan *commonv1.PlanNode) *commonv1.PlanNode {
+	dataNode := NewPlanNode("DataNode", "node", node)
+	dataNode.Children = []*commonv1.PlanNode{plan}
+	return dataNode
+}
+
+// Explain outputs the plan tree, with the runtime statistics of the operators collected by the analyzer.
+// The analyzer is nil if the plan isn't executed.
+func Explain(plan Plan, analyzer *query.Analyzer) *commonv1.PlanNode {
+	var node *commonv1.PlanNode
+	if e, ok := plan.(Explainer); ok {
+		node = e.Explain()
+	} else {
+		node = NewPlanNode(fmt.Sprintf("%T", plan), "plan", plan.String())
+	}
+	for _, child := range plan.Children() {
+		node.Children = append(node.Children, Explain(child, analyzer))
+	}
+	if stats := analyzer.Stats(plan); stats != nil {
+		node.Stats = stats.ToProto()
+		node.Children = append(node.Children, stats.SubPlans()...)
+	}
+	return node
+}
@@ -20,6 +20,7 @@ package logical
 import (
 	"fmt"
 
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	"github.com/apache/skywalking-banyandb/pkg/index"
 )
 
@@ -36,6 +37,12 @@ type Plan interface {
 	Schema() Schema
 }
 
+// Explainer describes a Plan in the explained plan tree.
+type Explainer interface {
+	// Explain returns the operator of the Plan and its attributes, excluding its children.
+	Explain() *commonv1.PlanNode
+}
+
 // Expr represents a predicate in criteria.
 type Expr interface {
 	fmt.Stringer
@@ -18,6 +18,7 @@
 package measure
 
 import (
+	"context"
 	"fmt"
 	"math"
 
@@ -25,6 +26,7 @@ import (
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
 	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/aggregation"
 	"github.com/apache/skywalking-banyandb/pkg/query/executor"
 	"github.com/apache/skywalking-banyandb/pkg/query/logical"
 )
@@ -55,10 +57,17 @@ func BuildSchema(md *databasev1.Measure, indexRules []*databasev1.IndexRule) (lo
 }
 
 // Analyze converts logical expressions to executable operation tree represented by Plan.
-func Analyze(criteria *measurev1.QueryRequest, metadata []*commonv1.Metadata, ss []logical.Schema, ecc []executor.MeasureExecutionContext) (logical.Plan, error) {
+func Analyze(ctx context.Context, criteria *measurev1.QueryRequest, metadata []*commonv1.Metadata, ss []logical.Schema, ecc []executor.MeasureExecutionContext) (logical.Plan, error) {
 	if len(metadata) != len(ss) {
 		return nil, fmt.Errorf("number of schemas %d not equal to metadata count %d", len(ss), len(metadata))
 	}
+	if err := validateAggregations(criteria); err != nil {
+		return nil, err
+	}
+	timeBucket, err := parseTimeBucket(criteria)
+	if err != nil {
+		return nil, err
+	}
 	groupByEntity := false
 	var groupByTags [][]*logical.Tag
 	if criteria.GetGroupBy() != nil {
@@ -80,7 +89,6 @@ func Analyze(criteria *measurev1.QueryRequest, metadata []*commonv1.Metadata, ss
 		plan = parseFields(criteria, metadata[0], ecc[0], groupByEntity, tagProjection)
 		s = ss[0]
 	} else {
-		var err error
 		if s, err = mergeSchema(ss); err != nil {
 			return nil, err
 		}
@@ -101,19 +109,34 @@ func Analyze(criteria *measurev1.QueryRequest, metadata []*commonv1.Metadata, ss
 	pushedLimit := int(limitParameter + criteria.GetOffset())
 
 	if criteria.GetGroupBy() != nil {
-		plan = newUnresolvedGroupBy(plan, groupByTags, groupByEntity)
+		plan = newUnresolvedGroupBy(plan, groupByTags, groupByEntity, timeBucket)
 		pushedLimit = math.MaxInt
 	}
 
 	if criteria.GetAgg() != nil {
+		partial := partialModeNone
+		if criteria.GetAggReturnPartial() {
+			partial = partialModeEmit
+		}
 		plan = newUnresolvedAggregation(plan,
 			logical.NewField(criteria.GetAgg().GetFieldName()),
 			criteria.GetAgg().GetFunction(),
 			criteria.GetGroupBy() != nil,
+			partial,
+			timeBucket,
 		)
 		pushedLimit = math.MaxInt
 	}
 
+	if len(criteria.GetAggs()) > 0 {
+		plan = newUnresolvedMultiAggregation(plan, criteria.GetAggs(), criteria.GetGroupBy() != nil, criteria.GetAggReturnPartial(), false, timeBucket)
+		pushedLimit = math.MaxInt
+	}
+
+	if criteria.GetHaving() != nil {
+		plan = newUnresolvedHaving(plan, criteria.GetHaving())
+	}
+
 	if criteria.GetTop() != nil {
 		plan = top(plan, criteria.GetTop())
 	}
@@ -126,6 +149,7 @@ func Analyze(criteria *measurev1.QueryRequest, metadata []*commonv1.Metadata, ss
 	rules := []logical.OptimizeRule{
 		logical.NewPushDownOrder(criteria.OrderBy),
 		logical.NewPushDownMaxSize(pushedLimit),
+		logical.NewSelectIndexByCost(ctx),
 	}
 	if err := logical.ApplyRules(p, rules...); err != nil {
 		return nil, err
@@ -135,6 +159,13 @@ func Analyze(criteria *measurev1.QueryRequest, metadata []*commonv1.Metadata, ss
 
 // DistributedAnalyze converts logical expressions to executable operation tree represented by Plan.
 func DistributedAnalyze(criteria *measurev1.QueryRequest, ss []logical.Schema) (logical.Plan, error) {
+	if err := validateAggregations(criteria); err != nil {
+		return nil, err
+	}
+	timeBucket, err := parseTimeBucket(criteria)
+	if err != nil {
+		return nil, err
+	}
 	var groupByTags [][]*logical.Tag
 	if criteria.GetGroupBy() != nil {
 		groupByProjectionTags := criteria.GetGroupBy().GetTagProjection()
@@ -144,14 +175,35 @@ func DistributedAnalyze(criteria *measurev1.QueryRequest, ss []logical.Schema) (
 		}
 	}
 
-	// TODO: to support all aggregation functions
 	needCompletePushDownAgg := criteria.GetAgg() != nil &&
-		(criteria.GetAgg().GetFunction() == modelv1.AggregationFunction_AGGREGATION_FUNCTION_MAX ||
-			criteria.GetAgg().GetFunction() == modelv1.AggregationFunction_AGGREGATION_FUNCTION_MIN) &&
+		canPushDownAgg(criteria.GetAgg().GetFunction()) &&
 		criteria.GetTop() == nil
+	// Data nodes return the partial states of the functions, which are merged here,
+	// since the results of data nodes, such as counts, means and percentiles, can't be aggregated again.
+	pushDownPartialAgg := needCompletePushDownAgg && aggregation.IsMergeable(criteria.GetAgg().GetFunction())
+	// Multiple aggregations are pushed down only if all of them can be.
+	if len(criteria.GetAggs()) > 0 && criteria.GetTop() == nil {
+		needCompletePushDownAgg = true
+		for _, agg := range criteria.GetAggs() {
+			if !canPushDownAgg(agg.GetFunction()) {
+				needCompletePushDownAgg = false
+				break
+			}
+			if aggregation.IsMergeable(agg.GetFunction()) {
+				pushDownPartialAgg = true
+			}
+		}
+		pushDownPartialAgg = needCompletePushDownAgg && pushDownPartialAgg
+	}
+	// A read policy may read a shard from several replicas, whose duplicated data points are removed only before
+	// the aggregations are done by the liaison.
+	if criteria.GetReadPolicy() != modelv1.ReadPolicy_READ_POLICY_UNSPECIFIED {
+		needCompletePushDownAgg = false
+		pushDownPartialAgg = false
+	}
 
 	// parse fields
-	plan := newUnresolvedDistributed(criteria, needCompletePushDownAgg)
+	plan := newUnresolvedDistributed(criteria, needCompletePushDownAgg, pushDownPartialAgg)
 
 	// parse limit and offset
 	limitParameter := criteria.GetLimit()
@@ -161,19 +213,34 @@ func DistributedAnalyze(criteria *measurev1.QueryRequest, ss []logical.Schema) (
 	pushedLimit := int(limitParameter + criteria.GetOffset())
 
 	if criteria.GetGroupBy() != nil {
-		plan = newUnresolvedGroupBy(plan, groupByTags, false)
+		plan = newUnresolvedGroupBy(plan, groupByTags, false, timeBucket)
 		pushedLimit = math.MaxInt
 	}
 
 	if criteria.GetAgg() != nil {
+		partial := partialModeNone
+		if pushDownPartialAgg {
+			partial = partialModeMerge
+		}
 		plan = newUnresolvedAggregation(plan,
 			logical.NewField(criteria.GetAgg().GetFieldName()),
 			criteria.GetAgg().GetFunction(),
 			criteria.GetGroupBy() != nil,
+			partial,
+			timeBucket,
 		)
 		pushedLimit = math.MaxInt
 	}
 
+	if len(criteria.GetAggs()) > 0 {
+		plan = newUnresolvedMultiAggregation(plan, criteria.GetAggs(), criteria.GetGroupBy() != nil, false, needCompletePushDownAgg, timeBucket)
+		pushedLimit = math.MaxInt
+	}
+
+	if criteria.GetHaving() != nil {
+		plan = newUnresolvedHaving(plan, criteria.GetHaving())
+	}
+
 	if criteria.GetTop() != nil {
 		plan = top(plan, criteria.GetTop())
 	}
@@ -181,7 +248,6 @@ func DistributedAnalyze(criteria *measurev1.QueryRequest, ss []logical.Schema) (
 	plan = limit(plan, criteria.GetOffset(), limitParameter)
 
 	var s logical.Schema
-	var err error
 	if len(ss) == 1 {
 		s = ss[0]
 	} else {
@@ -204,6 +270,14 @@ func DistributedAnalyze(criteria *measurev1.QueryRequest, ss []logical.Schema) (
 	return p, nil
 }
 
+// canPushDownAgg returns true if the results of the function on data nodes can be aggregated again by the liaison,
+// or the partial states of the function can be merged by the liaison.
+func canPushDownAgg(af modelv1.AggregationFunction) bool {
+	return af == modelv1.AggregationFunction_AGGREGATION_FUNCTION_MAX ||
+		af == modelv1.AggregationFunction_AGGREGATION_FUNCTION_MIN ||
+		aggregation.IsMergeable(af)
+}
+
 func parseFields(criteria *measurev1.QueryRequest, metadata *commonv1.Metadata, ec executor.MeasureExecutionContext,
 	groupByEntity bool, tagProjection [][]*logical.Tag,
 ) logical.UnresolvedPlan {
@@ -20,8 +20,12 @@ package measure
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"time"
 
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query"
 	"github.com/apache/skywalking-banyandb/pkg/query/executor"
 	"github.com/apache/skywalking-banyandb/pkg/query/logical"
 )
@@ -38,12 +42,14 @@ type limitPlan struct {
 }
 
 func (l *limitPlan) Execute(ec context.Context) (executor.MIterator, error) {
+	stats, ec := query.StartOperator(ec, l)
+	defer stats.Observe(time.Now())
 	dps, err := l.Parent.Input.(executor.MeasureExecutable).Execute(ec)
 	if err != nil {
 		return nil, err
 	}
 
-	return newLimitIterator(dps, l.offset, l.limit), nil
+	return executor.AnalyzeMIterator(stats, newLimitIterator(dps, l.offset, l.limit)), nil
 }
 
 func (l *limitPlan) Analyze(s logical.Schema) (logical.Plan, error) {
@@ -63,6 +69,10 @@ func (l *limitPlan) String() string {
 	return fmt.Sprintf("%s Limit: %d, %d", l.Input.String(), l.offset, l.limit)
 }
 
+func (l *limitPlan) Explain() *commonv1.PlanNode {
+	return logical.NewPlanNode("Limit", "offset", strconv.FormatUint(uint64(l.offset), 10), "limit", strconv.FormatUint(uint64(l.limit), 10))
+}
+
 func (l *limitPlan) Children() []logical.Plan {
 	return []logical.Plan{l.Input}
 }
@@ -20,13 +20,17 @@ package measure
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/pkg/errors"
 	"go.uber.org/multierr"
 
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
 	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query"
 	"github.com/apache/skywalking-banyandb/pkg/query/aggregation"
 	"github.com/apache/skywalking-banyandb/pkg/query/executor"
 	"github.com/apache/skywalking-banyandb/pkg/query/logical"
@@ -38,19 +42,36 @@ var (
 	errUnsupportedAggregationField = errors.New("unsupported aggregation operation on this field")
 )
 
+// partialMode tells how an aggregation deals with the partial states of an aggregation.PartialFunc.
+type partialMode int
+
+const (
+	partialModeNone partialMode = iota
+	// partialModeEmit outputs the partial states instead of the values, which happens on data nodes.
+	partialModeEmit
+	// partialModeMerge takes in the partial states returned by data nodes, which happens on the liaison.
+	partialModeMerge
+)
+
 type unresolvedAggregation struct {
 	unresolvedInput  logical.UnresolvedPlan
 	aggregationField *logical.Field
 	aggrFunc         modelv1.AggregationFunction
+	partial          partialMode
+	timeBucket       time.Duration
 	isGroup          bool
 }
 
-func newUnresolvedAggregation(input logical.UnresolvedPlan, aggrField *logical.Field, aggrFunc modelv1.AggregationFunction, isGroup bool) logical.UnresolvedPlan {
+func newUnresolvedAggregation(input logical.UnresolvedPlan, aggrField *logical.Field, aggrFunc modelv1.AggregationFunction,
+	isGroup bool, partial partialMode, timeBucket time.Duration,
+) logical.UnresolvedPlan {
 	return &unresolvedAggregation{
 		unresolvedInput:  input,
 		aggrFunc:         aggrFunc,
 		aggregationField: aggrField,
 		isGroup:          isGroup,
+		partial:          partial,
+		timeBucket:       timeBucket,
 	}
 }
 
@@ -85,6 +106,8 @@ type aggregationPlan[N aggregation.Number] struct {
 	aggregationFieldRef *logical.FieldRef
 	aggrFunc            aggregation.Func[N]
 	aggrType            modelv1.AggregationFunction
+	partial             partialMode
+	timeBucket          time.Duration
 	isGroup             bool
 }
 
@@ -95,6 +118,9 @@ func newAggregationPlan[N aggregation.Number](gba *unresolvedAggregation, prevPl
 	if err != nil {
 		return nil, err
 	}
+	if _, ok := aggrFunc.(aggregation.PartialFunc[N]); !ok && gba.partial != partialModeNone {
+		return nil, errors.Errorf("aggregation function %s doesn't support partial states", gba.aggrFunc)
+	}
 	return &aggregationPlan[N]{
 		Parent: &logical.Parent{
 			UnresolvedInput: gba.unresolvedInput,
@@ -104,6 +130,8 @@ func newAggregationPlan[N aggregation.Number](gba *unresolvedAggregation, prevPl
 		aggrFunc:            aggrFunc,
 		aggregationFieldRef: fieldRef,
 		isGroup:             gba.isGroup,
+		partial:             gba.partial,
+		timeBucket:          gba.timeBucket,
 	}, nil
 }
 
@@ -114,6 +142,21 @@ func (g *aggregationPlan[N]) String() string {
 		g.aggregationFieldRef.Field.Name)
 }
 
+func (g *aggregationPlan[N]) Explain() *commonv1.PlanNode {
+	node := logical.NewPlanNode("Aggregation", "function", g.aggrType.String(), "field", g.aggregationFieldRef.Field.Name,
+		"group", strconv.FormatBool(g.isGroup))
+	if g.timeBucket > 0 {
+		node.Attributes = append(node.Attributes, &commonv1.Tag{Key: "time_bucket", Value: g.timeBucket.String()})
+	}
+	switch g.partial {
+	case partialModeEmit:
+		node.Attributes = append(node.Attributes, &commonv1.Tag{Key: "partial", Value: "emit"})
+	case partialModeMerge:
+		node.Attributes = append(node.Attributes, &commonv1.Tag{Key: "partial", Value: "merge"})
+	}
+	return node
+}
+
 func (g *aggregationPlan[N]) Children() []logical.Plan {
 	return []logical.Plan{g.Input}
 }
@@ -123,14 +166,16 @@ func (g *aggregationPlan[N]) Schema() logical.Schema {
 }
 
 func (g *aggregationPlan[N]) Execute(ec context.Context) (executor.MIterator, error) {
+	stats, ec := query.StartOperator(ec, g)
+	defer stats.Observe(time.Now())
 	iter, err := g.Parent.Input.(executor.MeasureExecutable).Execute(ec)
 	if err != nil {
 		return nil, err
 	}
 	if g.isGroup {
-		return newAggGroupMIterator(iter, g.aggregationFieldRef, g.aggrFunc), nil
+		return executor.AnalyzeMIterator(stats, newAggGroupMIterator(iter, g.aggregationFieldRef, g.aggrFunc, g.partial, g.timeBucket)), nil
 	}
-	return newAggAllIterator(iter, g.aggregationFieldRef, g.aggrFunc), nil
+	return executor.AnalyzeMIterator(stats, newAggAllIterator(iter, g.aggregationFieldRef, g.aggrFunc, g.partial)), nil
 }
 
 type aggGroupIterator[N aggregation.Number] struct {
@@ -138,18 +183,24 @@ type aggGroupIterator[N aggregation.Number] struct {
 	aggregationFieldRef *logical.FieldRef
 	aggrFunc            aggregation.Func[N]
 
-	err error
+	err        error
+	partial    partialMode
+	timeBucket time.Duration
 }
 
 func newAggGroupMIterator[N aggregation.Number](
 	prev executor.MIterator,
 	aggregationFieldRef *logical.FieldRef,
 	aggrFunc aggregation.Func[N],
+	partial partialMode,
+	timeBucket time.Duration,
 ) executor.MIterator {
 	return &aggGroupIterator[N]{
 		prev:                prev,
 		aggregationFieldRef: aggregationFieldRef,
 		aggrFunc:            aggrFunc,
+		partial:             partial,
+		timeBucket:          timeBucket,
 	}
 }
 
@@ -170,23 +221,24 @@ func (ami *aggGroupIterator[N]) Current() []*measurev1.DataPoint {
 	for _, dp := range group {
 		value := dp.GetFields()[ami.aggregationFieldRef.Spec.FieldIdx].
 			GetValue()
-		v, err := aggregation.FromFieldValue[N](value)
-		if err != nil {
+		if err := aggregateValue(ami.aggrFunc, value, ami.partial); err != nil {
 			ami.err = err
 			return nil
 		}
-		ami.aggrFunc.In(v)
 		if resultDp != nil {
 			continue
 		}
 		resultDp = &measurev1.DataPoint{
 			TagFamilies: dp.TagFamilies,
 		}
+		if ami.timeBucket > 0 {
+			resultDp.Timestamp = timeBucketStart(dp.GetTimestamp(), ami.timeBucket)
+		}
 	}
 	if resultDp == nil {
 		return nil
 	}
-	val, err := aggregation.ToFieldValue(ami.aggrFunc.Val())
+	val, err := aggregatedValue(ami.aggrFunc, ami.partial)
 	if err != nil {
 		ami.err = err
 		return nil
@@ -209,19 +261,22 @@ type aggAllIterator[N aggregation.Number] struct {
 	aggregationFieldRef *logical.FieldRef
 	aggrFunc            aggregation.Func[N]
 
-	result *measurev1.DataPoint
-	err    error
+	result  *measurev1.DataPoint
+	err     error
+	partial partialMode
 }
 
 func newAggAllIterator[N aggregation.Number](
 	prev executor.MIterator,
 	aggregationFieldRef *logical.FieldRef,
 	aggrFunc aggregation.Func[N],
+	partial partialMode,
 ) executor.MIterator {
 	return &aggAllIterator[N]{
 		prev:                prev,
 		aggregationFieldRef: aggregationFieldRef,
 		aggrFunc:            aggrFunc,
+		partial:             partial,
 	}
 }
 
@@ -235,12 +290,10 @@ func (ami *aggAllIterator[N]) Next() bool {
 		for _, dp := range group {
 			value := dp.GetFields()[ami.aggregationFieldRef.Spec.FieldIdx].
 				GetValue()
-			v, err := aggregation.FromFieldValue[N](value)
-			if err != nil {
+			if err := aggregateValue(ami.aggrFunc, value, ami.partial); err != nil {
 				ami.err = err
 				return false
 			}
-			ami.aggrFunc.In(v)
 			if resultDp != nil {
 				continue
 			}
@@ -252,7 +305,7 @@ func (ami *aggAllIterator[N]) Next() bool {
 	if resultDp == nil {
 		return false
 	}
-	val, err := aggregation.ToFieldValue(ami.aggrFunc.Val())
+	val, err := aggregatedValue(ami.aggrFunc, ami.partial)
 	if err != nil {
 		ami.err = err
 		return false
@@ -277,3 +330,30 @@ func (ami *aggAllIterator[N]) Current() []*measurev1.DataPoint {
 func (ami *aggAllIterator[N]) Close() error {
 	return ami.prev.Close()
 }
+
+func aggregateValue[N aggregation.Number](aggrFunc aggregation.Func[N], value *modelv1.FieldValue, partial partialMode) error {
+	if partial == partialModeMerge {
+		state := value.GetBinaryData()
+		if len(state) == 0 {
+			return nil
+		}
+		return aggrFunc.(aggregation.PartialFunc[N]).Merge(state)
+	}
+	v, err := aggregation.FromFieldValue[N](value)
+	if err != nil {
+		return err
+	}
+	aggrFunc.In(v)
+	return nil
+}
+
+func aggregatedValue[N aggregation.Number](aggrFunc aggregation.Func[N], partial partialMode) (*modelv1.FieldValue, error) {
+	if partial == partialModeEmit {
+		state, err := aggrFunc.(aggregation.PartialFunc[N]).Partial()
+		if err != nil {
+			return nil, err
+		}
+		return &modelv1.FieldValue{Value: &modelv1.FieldValue_BinaryData{BinaryData: state}}, nil
+	}
+	return aggregation.ToFieldValue(aggrFunc.Val())
+}
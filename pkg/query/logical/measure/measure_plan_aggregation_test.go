@@ -0,0 +1,71 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/aggregation"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+)
+
+func intDataPoints(values ...int64) []*measurev1.DataPoint {
+	dps := make([]*measurev1.DataPoint, 0, len(values))
+	for _, v := range values {
+		dps = append(dps, &measurev1.DataPoint{Fields: []*measurev1.DataPoint_Field{
+			{Name: "value", Value: &modelv1.FieldValue{Value: &modelv1.FieldValue_Int{Int: &modelv1.Int{Value: v}}}},
+		}})
+	}
+	return dps
+}
+
+func TestAggregationWithPartialStates(t *testing.T) {
+	fieldRef := &logical.FieldRef{Field: logical.NewField("value"), Spec: &logical.FieldSpec{FieldIdx: 0}}
+	nodes := [][]int64{{1, 2, 3, 3}, {3, 4, 5}, {5, 6, 7, 8, 9, 10}}
+	newFunc := func() aggregation.Func[int64] {
+		f, err := aggregation.NewFunc[int64](modelv1.AggregationFunction_AGGREGATION_FUNCTION_DISTINCT_COUNT)
+		require.NoError(t, err)
+		return f
+	}
+
+	// Each data node emits the partial state of its own data points.
+	var partials []*measurev1.DataPoint
+	for _, values := range nodes {
+		iter := newAggAllIterator(&pushedDownAggregatedIterator{dataPoints: intDataPoints(values...)}, fieldRef, newFunc(), partialModeEmit)
+		require.True(t, iter.Next())
+		dps := iter.Current()
+		require.Len(t, dps, 1)
+		require.NotEmpty(t, dps[0].GetFields()[0].GetValue().GetBinaryData())
+		partials = append(partials, dps...)
+		require.NoError(t, iter.Close())
+	}
+
+	// The liaison merges the partial states.
+	iter := newAggAllIterator(&pushedDownAggregatedIterator{dataPoints: partials}, fieldRef, newFunc(), partialModeMerge)
+	require.True(t, iter.Next())
+	dps := iter.Current()
+	require.Len(t, dps, 1)
+	assert.Equal(t, int64(10), dps[0].GetFields()[0].GetValue().GetInt().GetValue())
+	assert.False(t, iter.Next())
+	require.NoError(t, iter.Close())
+}
@@ -22,12 +22,15 @@ import (
 	"container/list"
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"go.uber.org/multierr"
 	"google.golang.org/protobuf/proto"
 
 	"github.com/apache/skywalking-banyandb/api/data"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
 	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
@@ -37,6 +40,7 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
 	"github.com/apache/skywalking-banyandb/pkg/query"
+	"github.com/apache/skywalking-banyandb/pkg/query/aggregation"
 	"github.com/apache/skywalking-banyandb/pkg/query/executor"
 	"github.com/apache/skywalking-banyandb/pkg/query/logical"
 )
@@ -105,12 +109,14 @@ type unresolvedDistributed struct {
 	originalQuery           *measurev1.QueryRequest
 	groupByEntity           bool
 	needCompletePushDownAgg bool
+	pushDownPartialAgg      bool
 }
 
-func newUnresolvedDistributed(query *measurev1.QueryRequest, needCompletePushDownAgg bool) logical.UnresolvedPlan {
+func newUnresolvedDistributed(query *measurev1.QueryRequest, needCompletePushDownAgg, pushDownPartialAgg bool) logical.UnresolvedPlan {
 	return &unresolvedDistributed{
 		originalQuery:           query,
 		needCompletePushDownAgg: needCompletePushDownAgg,
+		pushDownPartialAgg:      pushDownPartialAgg,
 	}
 }
 
@@ -152,9 +158,15 @@ func (ud *unresolvedDistributed) Analyze(s logical.Schema) (logical.Plan, error)
 	if ud.needCompletePushDownAgg {
 		temp.GroupBy = ud.originalQuery.GroupBy
 		temp.Agg = ud.originalQuery.Agg
-	}
-	// push down groupBy, agg and top to data node and rewrite agg result to raw data
-	if ud.originalQuery.Agg != nil && ud.originalQuery.Top != nil {
+		temp.Aggs = ud.originalQuery.Aggs
+		temp.AggReturnPartial = ud.pushDownPartialAgg
+	}
+	// push down groupBy, agg and top to data node and rewrite agg result to raw data.
+	// The functions with partial states are computed here on the raw data, since the top of them can't be
+	// chosen by data nodes. So are all the functions under a read policy, since the data points of the replicas
+	// are de-duplicated here.
+	if ud.originalQuery.Agg != nil && ud.originalQuery.Top != nil && !aggregation.IsPartial(ud.originalQuery.Agg.GetFunction()) &&
+		ud.originalQuery.ReadPolicy == modelv1.ReadPolicy_READ_POLICY_UNSPECIFIED {
 		temp.RewriteAggTopNResult = true
 		temp.Agg = ud.originalQuery.Agg
 		temp.Top = ud.originalQuery.Top
@@ -233,12 +245,17 @@ type distributedPlan struct {
 }
 
 func (t *distributedPlan) Execute(ctx context.Context) (mi executor.MIterator, err error) {
+	stats, ctx := query.StartOperator(ctx, t)
+	defer stats.Observe(time.Now())
 	dctx := executor.FromDistributedExecutionContext(ctx)
 	queryRequest := proto.Clone(t.queryTemplate).(*measurev1.QueryRequest)
 	queryRequest.TimeRange = dctx.TimeRange()
 	if t.maxDataPointsSize > 0 {
 		queryRequest.Limit = t.maxDataPointsSize
 	}
+	if stats != nil {
+		queryRequest.Explain = commonv1.ExplainMode_EXPLAIN_MODE_ANALYZE
+	}
 	tracer := query.GetTracer(ctx)
 	var span *query.Span
 	if tracer != nil {
@@ -274,6 +291,9 @@ func (t *distributedPlan) Execute(ctx context.Context) (mi executor.MIterator, e
 			if span != nil {
 				span.AddSubTrace(resp.Trace)
 			}
+			if resp.Plan != nil {
+				stats.AddSubPlan(logical.NewDataNodePlan(m.Node(), resp.Plan))
+			}
 			if t.needCompletePushDownAgg {
 				pushedDownAggDps = append(pushedDownAggDps, resp.DataPoints...)
 				continue
@@ -284,19 +304,34 @@ func (t *distributedPlan) Execute(ctx context.Context) (mi executor.MIterator, e
 		}
 	}
 	if t.needCompletePushDownAgg {
-		return &pushedDownAggregatedIterator{dataPoints: pushedDownAggDps}, err
+		return executor.AnalyzeMIterator(stats, &pushedDownAggregatedIterator{dataPoints: pushedDownAggDps}), err
 	}
 	smi := &sortedMIterator{
 		Iterator: sort.NewItemIter(see, t.desc),
 	}
 	smi.init()
-	return smi, err
+	return executor.AnalyzeMIterator(stats, smi), err
 }
 
 func (t *distributedPlan) String() string {
 	return fmt.Sprintf("distributed:%s", t.queryTemplate.String())
 }
 
+func (t *distributedPlan) Explain() *commonv1.PlanNode {
+	node := logical.NewPlanNode("Distributed", "groups", strings.Join(t.queryTemplate.GetGroups(), ","), "name", t.queryTemplate.GetName(),
+		"sort_by_time", strconv.FormatBool(t.sortByTime), "desc", strconv.FormatBool(t.desc))
+	if name := t.sortTagSpec.Spec.GetName(); name != "" {
+		node.Attributes = append(node.Attributes, &commonv1.Tag{Key: "sort_tag", Value: name})
+	}
+	if t.maxDataPointsSize > 0 {
+		node.Attributes = append(node.Attributes, &commonv1.Tag{Key: "limit", Value: strconv.FormatUint(uint64(t.maxDataPointsSize), 10)})
+	}
+	if t.needCompletePushDownAgg {
+		node.Attributes = append(node.Attributes, &commonv1.Tag{Key: "push_down_aggregation", Value: "true"})
+	}
+	return node
+}
+
 func (t *distributedPlan) Children() []logical.Plan {
 	return []logical.Plan{}
 }
@@ -305,7 +340,7 @@ func (t *distributedPlan) Schema() logical.Schema {
 	if t.needCompletePushDownAgg {
 		return &pushDownAggSchema{
 			originalSchema:   t.s,
-			aggregationField: logical.NewField(t.queryTemplate.Agg.FieldName),
+			aggregationField: logical.NewField(t.queryTemplate.GetAgg().GetFieldName()),
 		}
 	}
 	return t.s
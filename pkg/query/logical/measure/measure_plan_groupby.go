@@ -21,16 +21,21 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"time"
 
 	"github.com/cespare/xxhash/v2"
 	"github.com/pkg/errors"
 	"go.uber.org/multierr"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
 	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/query"
 	"github.com/apache/skywalking-banyandb/pkg/query/executor"
 	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
 )
 
 var (
@@ -42,17 +47,51 @@ type unresolvedGroup struct {
 	unresolvedInput logical.UnresolvedPlan
 	// groupBy should be a subset of tag projection
 	groupBy       [][]*logical.Tag
+	timeBucket    time.Duration
 	groupByEntity bool
 }
 
-func newUnresolvedGroupBy(input logical.UnresolvedPlan, groupBy [][]*logical.Tag, groupByEntity bool) logical.UnresolvedPlan {
+func newUnresolvedGroupBy(input logical.UnresolvedPlan, groupBy [][]*logical.Tag, groupByEntity bool, timeBucket time.Duration) logical.UnresolvedPlan {
 	return &unresolvedGroup{
 		unresolvedInput: input,
 		groupBy:         groupBy,
-		groupByEntity:   groupByEntity,
+		// The data points of a series aren't sorted by time when they are sorted by the entity,
+		// so that the buckets of a series can't be split by the sort iterator.
+		groupByEntity: groupByEntity && timeBucket == 0,
+		timeBucket:    timeBucket,
 	}
 }
 
+// parseTimeBucket returns the time bucket of the group by, which is zero if the data points are grouped by tags only.
+func parseTimeBucket(criteria *measurev1.QueryRequest) (time.Duration, error) {
+	tb := criteria.GetGroupBy().GetTimeBucket()
+	if tb == "" {
+		return 0, nil
+	}
+	if criteria.GetAgg() == nil && len(criteria.GetAggs()) == 0 {
+		return 0, errors.New("time_bucket requires agg or aggs")
+	}
+	d, err := timestamp.ParseDuration(tb)
+	if err != nil {
+		return 0, errors.WithMessagef(err, "invalid time_bucket %s", tb)
+	}
+	if d <= 0 {
+		return 0, errors.Errorf("time_bucket %s must be positive", tb)
+	}
+	return d, nil
+}
+
+// timeBucketStart returns the start of the bucket which the timestamp falls into.
+// Buckets are aligned to the Unix epoch.
+func timeBucketStart(ts *timestamppb.Timestamp, timeBucket time.Duration) *timestamppb.Timestamp {
+	nanos := ts.AsTime().UnixNano()
+	offset := nanos % int64(timeBucket)
+	if offset < 0 {
+		offset += int64(timeBucket)
+	}
+	return timestamppb.New(time.Unix(0, nanos-offset))
+}
+
 func (gba *unresolvedGroup) Analyze(measureSchema logical.Schema) (logical.Plan, error) {
 	prevPlan, err := gba.unresolvedInput.Analyze(measureSchema)
 	if err != nil {
@@ -72,6 +111,7 @@ func (gba *unresolvedGroup) Analyze(measureSchema logical.Schema) (logical.Plan,
 		schema:          schema,
 		groupByTagsRefs: groupByTagRefs,
 		groupByEntity:   gba.groupByEntity,
+		timeBucket:      gba.timeBucket,
 	}, nil
 }
 
@@ -79,6 +119,7 @@ type groupBy struct {
 	*logical.Parent
 	schema          logical.Schema
 	groupByTagsRefs [][]*logical.TagRef
+	timeBucket      time.Duration
 	groupByEntity   bool
 }
 
@@ -89,11 +130,28 @@ func (g *groupBy) String() string {
 	} else {
 		method = "hash"
 	}
+	if g.timeBucket > 0 {
+		return fmt.Sprintf("%s GroupBy: groupBy=%s, timeBucket=%s, method=%s",
+			g.Input,
+			logical.FormatTagRefs(", ", g.groupByTagsRefs...), g.timeBucket, method)
+	}
 	return fmt.Sprintf("%s GroupBy: groupBy=%s, method=%s",
 		g.Input,
 		logical.FormatTagRefs(", ", g.groupByTagsRefs...), method)
 }
 
+func (g *groupBy) Explain() *commonv1.PlanNode {
+	method := "hash"
+	if g.groupByEntity {
+		method = "sort"
+	}
+	node := logical.NewPlanNode("GroupBy", "group_by", logical.FormatTagRefs(", ", g.groupByTagsRefs...), "method", method)
+	if g.timeBucket > 0 {
+		node.Attributes = append(node.Attributes, &commonv1.Tag{Key: "time_bucket", Value: g.timeBucket.String()})
+	}
+	return node
+}
+
 func (g *groupBy) Children() []logical.Plan {
 	return []logical.Plan{g.Input}
 }
@@ -103,10 +161,16 @@ func (g *groupBy) Schema() logical.Schema {
 }
 
 func (g *groupBy) Execute(ec context.Context) (executor.MIterator, error) {
+	stats, ec := query.StartOperator(ec, g)
+	defer stats.Observe(time.Now())
+	var iter executor.MIterator
+	var err error
 	if g.groupByEntity {
-		return g.sort(ec)
+		iter, err = g.sort(ec)
+	} else {
+		iter, err = g.hash(ec)
 	}
-	return g.hash(ec)
+	return executor.AnalyzeMIterator(stats, iter), err
 }
 
 func (g *groupBy) sort(ec context.Context) (executor.MIterator, error) {
@@ -131,7 +195,7 @@ func (g *groupBy) hash(ec context.Context) (mit executor.MIterator, err error) {
 	for iter.Next() {
 		dataPoints := iter.Current()
 		for _, dp := range dataPoints {
-			key, innerErr := formatGroupByKey(dp, g.groupByTagsRefs)
+			key, innerErr := formatGroupByKey(dp, g.groupByTagsRefs, g.timeBucket)
 			if innerErr != nil {
 				return nil, innerErr
 			}
@@ -150,8 +214,13 @@ func (g *groupBy) hash(ec context.Context) (mit executor.MIterator, err error) {
 	return newGroupIterator(groupMap, groupLst), nil
 }
 
-func formatGroupByKey(point *measurev1.DataPoint, groupByTagsRefs [][]*logical.TagRef) (uint64, error) {
+func formatGroupByKey(point *measurev1.DataPoint, groupByTagsRefs [][]*logical.TagRef, timeBucket time.Duration) (uint64, error) {
 	hash := xxhash.New()
+	if timeBucket > 0 {
+		if _, err := hash.Write(convert.Int64ToBytes(timeBucketStart(point.GetTimestamp(), timeBucket).AsTime().UnixNano())); err != nil {
+			return 0, err
+		}
+	}
 	for _, tagFamilyRef := range groupByTagsRefs {
 		for _, tagRef := range tagFamilyRef {
 			if tagRef.Spec.TagFamilyIdx >= len(point.GetTagFamilies()) {
@@ -172,6 +241,11 @@ func formatGroupByKey(point *measurev1.DataPoint, groupByTagsRefs [][]*logical.T
 				if innerErr != nil {
 					return 0, innerErr
 				}
+			case *modelv1.TagValue_Float:
+				_, innerErr := hash.Write(convert.Float64ToBytes(v.Float.GetValue()))
+				if innerErr != nil {
+					return 0, innerErr
+				}
 			case *modelv1.TagValue_IntArray, *modelv1.TagValue_StrArray, *modelv1.TagValue_BinaryData:
 				return 0, errors.New("group-by on array/binary tag is not supported")
 			}
@@ -247,7 +321,7 @@ func (gmi *groupSortIterator) Next() bool {
 			gmi.closed = true
 			return len(gmi.current) > 0
 		}
-		k, err := formatGroupByKey(dp, gmi.groupByTagsRefs)
+		k, err := formatGroupByKey(dp, gmi.groupByTagsRefs, 0)
 		if err != nil {
 			gmi.closed = true
 			gmi.err = err
@@ -0,0 +1,221 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/aggregation"
+	"github.com/apache/skywalking-banyandb/pkg/query/executor"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+)
+
+func TestTimeBucketStart(t *testing.T) {
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		ts   time.Time
+		want time.Time
+	}{
+		{ts: base, want: base},
+		{ts: base.Add(4*time.Minute + 59*time.Second), want: base},
+		{ts: base.Add(5 * time.Minute), want: base.Add(5 * time.Minute)},
+		{ts: time.Unix(-1, 0), want: time.Unix(-300, 0)},
+	}
+	for _, tt := range tests {
+		got := timeBucketStart(timestamppb.New(tt.ts), 5*time.Minute)
+		assert.True(t, tt.want.Equal(got.AsTime()), "want %s, got %s", tt.want, got.AsTime())
+	}
+}
+
+func TestParseTimeBucket(t *testing.T) {
+	agg := &measurev1.QueryRequest_Aggregation{FieldName: "value", Function: modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM}
+	d, err := parseTimeBucket(&measurev1.QueryRequest{GroupBy: &measurev1.QueryRequest_GroupBy{TimeBucket: "5m"}, Agg: agg})
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, d)
+	d, err = parseTimeBucket(&measurev1.QueryRequest{GroupBy: &measurev1.QueryRequest_GroupBy{TimeBucket: "1d"}, Agg: agg})
+	require.NoError(t, err)
+	assert.Equal(t, 24*time.Hour, d)
+	d, err = parseTimeBucket(&measurev1.QueryRequest{Agg: agg})
+	require.NoError(t, err)
+	assert.Zero(t, d)
+
+	_, err = parseTimeBucket(&measurev1.QueryRequest{GroupBy: &measurev1.QueryRequest_GroupBy{TimeBucket: "5m"}})
+	assert.Error(t, err)
+	_, err = parseTimeBucket(&measurev1.QueryRequest{GroupBy: &measurev1.QueryRequest_GroupBy{TimeBucket: "5x"}, Agg: agg})
+	assert.Error(t, err)
+	_, err = parseTimeBucket(&measurev1.QueryRequest{GroupBy: &measurev1.QueryRequest_GroupBy{TimeBucket: "-5m"}, Agg: agg})
+	assert.Error(t, err)
+}
+
+func TestAggregationByTimeBucket(t *testing.T) {
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	dps := intDataPoints(1, 2, 3, 4)
+	for i, offset := range []time.Duration{0, time.Minute, 5 * time.Minute, 9 * time.Minute} {
+		dps[i].Timestamp = timestamppb.New(base.Add(offset))
+	}
+
+	// Group the data points by the buckets only.
+	groupMap := make(map[uint64][]*measurev1.DataPoint)
+	var groupLst []uint64
+	for _, dp := range dps {
+		key, err := formatGroupByKey(dp, nil, 5*time.Minute)
+		require.NoError(t, err)
+		if _, ok := groupMap[key]; !ok {
+			groupLst = append(groupLst, key)
+		}
+		groupMap[key] = append(groupMap[key], dp)
+	}
+	require.Len(t, groupLst, 2)
+
+	fieldRef := &logical.FieldRef{Field: logical.NewField("value"), Spec: &logical.FieldSpec{FieldIdx: 0}}
+	f, err := aggregation.NewFunc[int64](modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM)
+	require.NoError(t, err)
+	iter := newAggGroupMIterator(newGroupIterator(groupMap, groupLst), fieldRef, f, partialModeNone, 5*time.Minute)
+	var sums []int64
+	var starts []time.Time
+	for iter.Next() {
+		for _, dp := range iter.Current() {
+			sums = append(sums, dp.GetFields()[0].GetValue().GetInt().GetValue())
+			starts = append(starts, dp.GetTimestamp().AsTime())
+		}
+	}
+	require.NoError(t, iter.Close())
+	assert.Equal(t, []int64{3, 7}, sums)
+	assert.Equal(t, []time.Time{base, base.Add(5 * time.Minute)}, starts)
+}
+
+// groupByTimeBucket groups the data points by the buckets of 5 minutes only.
+func groupByTimeBucket(t *testing.T, dps []*measurev1.DataPoint) executor.MIterator {
+	groupMap := make(map[uint64][]*measurev1.DataPoint)
+	var groupLst []uint64
+	for _, dp := range dps {
+		key, err := formatGroupByKey(dp, nil, 5*time.Minute)
+		require.NoError(t, err)
+		if _, ok := groupMap[key]; !ok {
+			groupLst = append(groupLst, key)
+		}
+		groupMap[key] = append(groupMap[key], dp)
+	}
+	return newGroupIterator(groupMap, groupLst)
+}
+
+func TestAggregationByTimeBucketWithPartialStates(t *testing.T) {
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	// Each data node holds the data points of both buckets.
+	nodes := [][]int64{{1, 2, 10}, {4, 20, 30, 40}}
+	offsets := [][]time.Duration{{0, time.Minute, 5 * time.Minute}, {2 * time.Minute, 6 * time.Minute, 7 * time.Minute, 9 * time.Minute}}
+	fieldRef := &logical.FieldRef{Field: logical.NewField("value"), Spec: &logical.FieldSpec{FieldIdx: 0}}
+	tests := []struct {
+		af   modelv1.AggregationFunction
+		want []int64
+	}{
+		{af: modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM, want: []int64{7, 100}},
+		{af: modelv1.AggregationFunction_AGGREGATION_FUNCTION_COUNT, want: []int64{3, 4}},
+		// The means of the nodes in the second bucket are 10 and 30, which would be averaged to 20.
+		{af: modelv1.AggregationFunction_AGGREGATION_FUNCTION_MEAN, want: []int64{2, 25}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.af.String(), func(t *testing.T) {
+			newFunc := func() aggregation.Func[int64] {
+				f, err := aggregation.NewFunc[int64](tt.af)
+				require.NoError(t, err)
+				return f
+			}
+			// Each data node emits the partial state of each bucket.
+			var partials []*measurev1.DataPoint
+			for i, values := range nodes {
+				dps := intDataPoints(values...)
+				for j, offset := range offsets[i] {
+					dps[j].Timestamp = timestamppb.New(base.Add(offset))
+				}
+				iter := newAggGroupMIterator(groupByTimeBucket(t, dps), fieldRef, newFunc(), partialModeEmit, 5*time.Minute)
+				for iter.Next() {
+					partials = append(partials, iter.Current()...)
+				}
+				require.NoError(t, iter.Close())
+			}
+			require.Len(t, partials, 4)
+
+			// The liaison groups the partial states by their buckets again, and merges them.
+			iter := newAggGroupMIterator(groupByTimeBucket(t, partials), fieldRef, newFunc(), partialModeMerge, 5*time.Minute)
+			var got []int64
+			var starts []time.Time
+			for iter.Next() {
+				for _, dp := range iter.Current() {
+					got = append(got, dp.GetFields()[0].GetValue().GetInt().GetValue())
+					starts = append(starts, dp.GetTimestamp().AsTime())
+				}
+			}
+			require.NoError(t, iter.Close())
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, []time.Time{base, base.Add(5 * time.Minute)}, starts)
+		})
+	}
+}
+
+func TestDistributedAnalyzePushesDownTimeBucket(t *testing.T) {
+	s, err := BuildSchema(&databasev1.Measure{
+		Metadata: &commonv1.Metadata{Name: "service_cpm_minute", Group: "sw_metric"},
+		Entity:   &databasev1.Entity{TagNames: []string{"id"}},
+		TagFamilies: []*databasev1.TagFamilySpec{{Name: "default", Tags: []*databasev1.TagSpec{
+			{Name: "id", Type: databasev1.TagType_TAG_TYPE_STRING},
+		}}},
+		Fields: []*databasev1.FieldSpec{{Name: "value", FieldType: databasev1.FieldType_FIELD_TYPE_INT}},
+	}, nil)
+	require.NoError(t, err)
+	projection := &modelv1.TagProjection{TagFamilies: []*modelv1.TagProjection_TagFamily{{Name: "default", Tags: []string{"id"}}}}
+	for _, af := range []modelv1.AggregationFunction{
+		modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM,
+		modelv1.AggregationFunction_AGGREGATION_FUNCTION_COUNT,
+		modelv1.AggregationFunction_AGGREGATION_FUNCTION_MEAN,
+	} {
+		agg := &measurev1.QueryRequest_Aggregation{Function: af, FieldName: "value", Name: "result"}
+		for name, req := range map[string]*measurev1.QueryRequest{
+			"agg":  {Agg: agg},
+			"aggs": {Aggs: []*measurev1.QueryRequest_Aggregation{agg}},
+		} {
+			t.Run(af.String()+"/"+name, func(t *testing.T) {
+				req.Name = "service_cpm_minute"
+				req.Groups = []string{"sw_metric"}
+				req.TagProjection = projection
+				req.FieldProjection = &measurev1.QueryRequest_FieldProjection{Names: []string{"value"}}
+				req.GroupBy = &measurev1.QueryRequest_GroupBy{TagProjection: projection, FieldName: "value", TimeBucket: "5m"}
+				plan, analyzeErr := DistributedAnalyze(req, []logical.Schema{s})
+				require.NoError(t, analyzeErr)
+				for len(plan.Children()) > 0 {
+					plan = plan.Children()[0]
+				}
+				dp, ok := plan.(*distributedPlan)
+				require.True(t, ok)
+				// Data nodes aggregate each bucket, and return the partial states merged by the liaison.
+				assert.True(t, dp.needCompletePushDownAgg)
+				assert.True(t, dp.queryTemplate.GetAggReturnPartial())
+				assert.Equal(t, "5m", dp.queryTemplate.GetGroupBy().GetTimeBucket())
+			})
+		}
+	}
+}
@@ -0,0 +1,238 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query"
+	"github.com/apache/skywalking-banyandb/pkg/query/executor"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+)
+
+var (
+	_ logical.UnresolvedPlan = (*unresolvedHaving)(nil)
+	_ logical.Plan           = (*havingPlan)(nil)
+
+	errInvalidHaving = errors.New("invalid having")
+)
+
+type unresolvedHaving struct {
+	unresolvedInput logical.UnresolvedPlan
+	having          *measurev1.Having
+}
+
+func newUnresolvedHaving(input logical.UnresolvedPlan, having *measurev1.Having) logical.UnresolvedPlan {
+	return &unresolvedHaving{
+		unresolvedInput: input,
+		having:          having,
+	}
+}
+
+func (uh *unresolvedHaving) Analyze(measureSchema logical.Schema) (logical.Plan, error) {
+	prevPlan, err := uh.unresolvedInput.Analyze(measureSchema)
+	if err != nil {
+		return nil, err
+	}
+	predicate, err := buildHavingPredicate(prevPlan.Schema(), uh.having)
+	if err != nil {
+		return nil, err
+	}
+	return &havingPlan{
+		Parent: &logical.Parent{
+			UnresolvedInput: uh.unresolvedInput,
+			Input:           prevPlan,
+		},
+		having:    uh.having,
+		predicate: predicate,
+	}, nil
+}
+
+type havingPlan struct {
+	*logical.Parent
+	having    *measurev1.Having
+	predicate havingPredicate
+}
+
+func (h *havingPlan) String() string {
+	return fmt.Sprintf("%s having: %s", h.Input, h.having.String())
+}
+
+func (h *havingPlan) Explain() *commonv1.PlanNode {
+	return logical.NewPlanNode("Having", "having", h.having.String())
+}
+
+func (h *havingPlan) Children() []logical.Plan {
+	return []logical.Plan{h.Input}
+}
+
+func (h *havingPlan) Schema() logical.Schema {
+	return h.Input.Schema()
+}
+
+func (h *havingPlan) Execute(ec context.Context) (executor.MIterator, error) {
+	stats, ec := query.StartOperator(ec, h)
+	defer stats.Observe(time.Now())
+	iter, err := h.Parent.Input.(executor.MeasureExecutable).Execute(ec)
+	if err != nil {
+		return nil, err
+	}
+	return executor.AnalyzeMIterator(stats, &havingIterator{prev: iter, predicate: h.predicate}), nil
+}
+
+type havingIterator struct {
+	prev      executor.MIterator
+	predicate havingPredicate
+	current   []*measurev1.DataPoint
+}
+
+func (hi *havingIterator) Next() bool {
+	for hi.prev.Next() {
+		hi.current = hi.current[:0]
+		for _, dp := range hi.prev.Current() {
+			if hi.predicate(dp) {
+				hi.current = append(hi.current, dp)
+			}
+		}
+		if len(hi.current) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (hi *havingIterator) Current() []*measurev1.DataPoint {
+	return hi.current
+}
+
+func (hi *havingIterator) Close() error {
+	return hi.prev.Close()
+}
+
+// havingPredicate returns true if the aggregated data point is kept.
+type havingPredicate func(dp *measurev1.DataPoint) bool
+
+func buildHavingPredicate(s logical.Schema, having *measurev1.Having) (havingPredicate, error) {
+	switch exp := having.GetExp().(type) {
+	case *measurev1.Having_Le:
+		left, err := buildHavingPredicate(s, exp.Le.GetLeft())
+		if err != nil {
+			return nil, err
+		}
+		right, err := buildHavingPredicate(s, exp.Le.GetRight())
+		if err != nil {
+			return nil, err
+		}
+		switch exp.Le.GetOp() {
+		case modelv1.LogicalExpression_LOGICAL_OP_AND:
+			return func(dp *measurev1.DataPoint) bool {
+				return left(dp) && right(dp)
+			}, nil
+		case modelv1.LogicalExpression_LOGICAL_OP_OR:
+			return func(dp *measurev1.DataPoint) bool {
+				return left(dp) || right(dp)
+			}, nil
+		}
+		return nil, errors.WithMessagef(errInvalidHaving, "unsupported logical operation %s", exp.Le.GetOp())
+	case *measurev1.Having_Condition:
+		return buildHavingCondition(s, exp.Condition)
+	}
+	return nil, errors.WithMessage(errInvalidHaving, "empty expression")
+}
+
+func buildHavingCondition(s logical.Schema, cond *measurev1.HavingCondition) (havingPredicate, error) {
+	fieldRefs, err := s.CreateFieldRef(logical.NewField(cond.GetName()))
+	if err != nil {
+		return nil, err
+	}
+	if len(fieldRefs) == 0 {
+		return nil, errors.WithMessagef(errInvalidHaving, "aggregation %s is not found", cond.GetName())
+	}
+	fieldIdx := fieldRefs[0].Spec.FieldIdx
+	var match func(c int) bool
+	switch cond.GetOp() {
+	case modelv1.Condition_BINARY_OP_EQ:
+		match = func(c int) bool { return c == 0 }
+	case modelv1.Condition_BINARY_OP_NE:
+		match = func(c int) bool { return c != 0 }
+	case modelv1.Condition_BINARY_OP_LT:
+		match = func(c int) bool { return c < 0 }
+	case modelv1.Condition_BINARY_OP_GT:
+		match = func(c int) bool { return c > 0 }
+	case modelv1.Condition_BINARY_OP_LE:
+		match = func(c int) bool { return c <= 0 }
+	case modelv1.Condition_BINARY_OP_GE:
+		match = func(c int) bool { return c >= 0 }
+	default:
+		return nil, errors.WithMessagef(errInvalidHaving, "unsupported operation %s on aggregation %s", cond.GetOp(), cond.GetName())
+	}
+	expected := cond.GetValue()
+	if expected.GetInt() == nil && expected.GetFloat() == nil {
+		return nil, errors.WithMessagef(errInvalidHaving, "the value compared with aggregation %s must be an int or a float", cond.GetName())
+	}
+	return func(dp *measurev1.DataPoint) bool {
+		if fieldIdx >= len(dp.GetFields()) {
+			return false
+		}
+		c, ok := compareFieldValues(dp.GetFields()[fieldIdx].GetValue(), expected)
+		return ok && match(c)
+	}, nil
+}
+
+// compareFieldValues compares two numeric field values. Ints are compared as floats if either of the values is a float.
+func compareFieldValues(a, b *modelv1.FieldValue) (int, bool) {
+	if a.GetInt() != nil && b.GetInt() != nil {
+		return compare(a.GetInt().GetValue(), b.GetInt().GetValue()), true
+	}
+	af, ok := fieldValueToFloat(a)
+	if !ok {
+		return 0, false
+	}
+	bf, ok := fieldValueToFloat(b)
+	if !ok {
+		return 0, false
+	}
+	return compare(af, bf), true
+}
+
+func fieldValueToFloat(v *modelv1.FieldValue) (float64, bool) {
+	switch {
+	case v.GetInt() != nil:
+		return float64(v.GetInt().GetValue()), true
+	case v.GetFloat() != nil:
+		return v.GetFloat().GetValue(), true
+	}
+	return 0, false
+}
+
+func compare[N int64 | float64](a, b N) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -20,6 +20,7 @@ package measure
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -135,8 +136,9 @@ func (uis *unresolvedIndexScan) Analyze(s logical.Schema) (logical.Plan, error)
 }
 
 var (
-	_ logical.Plan   = (*localIndexScan)(nil)
-	_ logical.Sorter = (*localIndexScan)(nil)
+	_ logical.Plan          = (*localIndexScan)(nil)
+	_ logical.Sorter        = (*localIndexScan)(nil)
+	_ logical.IndexSelector = (*localIndexScan)(nil)
 )
 
 type localIndexScan struct {
@@ -153,6 +155,8 @@ type localIndexScan struct {
 	entities             [][]*modelv1.TagValue
 	projectionFields     []string
 	projectionTags       []model.TagProjection
+	estimatedRows        float64
+	estimated            bool
 	groupByEntity        bool
 }
 
@@ -160,6 +164,52 @@ func (i *localIndexScan) Sort(order *logical.OrderBy) {
 	i.order = order
 }
 
+// SelectIndex estimates the rows the scan reads by the series the index query matches in each segment.
+// Unlike the stream, the measure has no tag filter to check the conditions left out of the lookup,
+// so that the index query is always looked up.
+func (i *localIndexScan) SelectIndex(ctx context.Context) error {
+	if len(i.entities) == 0 {
+		// The measure in the index mode reads the data points from the index directly.
+		return nil
+	}
+	sp, ok := i.ec.(executor.StatisticsProvider)
+	if !ok {
+		return nil
+	}
+	stats, err := sp.Statistics(ctx, i.timeRange)
+	if err != nil {
+		return err
+	}
+	defer stats.Release()
+	targets := logical.TargetedSeries(i.entities)
+	var rows float64
+	for _, segment := range stats.Segments {
+		if segment.Series == 0 {
+			continue
+		}
+		series := float64(segment.Series)
+		matched := series
+		if i.query != nil {
+			for _, idx := range segment.Indexes {
+				n, known, errEstimate := inverted.EstimateDocs(i.query, idx)
+				if errEstimate != nil {
+					return errEstimate
+				}
+				if !known {
+					return nil
+				}
+				matched = float64(n)
+			}
+		}
+		if targets > 0 && targets < matched {
+			matched = targets
+		}
+		rows += float64(segment.Rows) * matched / series
+	}
+	i.estimatedRows, i.estimated = rows, true
+	return nil
+}
+
 func (i *localIndexScan) Execute(ctx context.Context) (mit executor.MIterator, err error) {
 	var orderBy *index.OrderBy
 
@@ -180,6 +230,8 @@ func (i *localIndexScan) Execute(ctx context.Context) (mit executor.MIterator, e
 		}
 		orderBy.Type = index.OrderByTypeSeries
 	}
+	stats, ctx := query.StartOperator(ctx, i)
+	defer stats.Observe(time.Now())
 	ctx, stop := i.startSpan(ctx, query.GetTracer(ctx), orderBy)
 	defer stop(err)
 	result, err := i.ec.Query(ctx, model.MeasureQueryOptions{
@@ -194,9 +246,9 @@ func (i *localIndexScan) Execute(ctx context.Context) (mit executor.MIterator, e
 	if err != nil {
 		return nil, fmt.Errorf("failed to query measure: %w", err)
 	}
-	return &resultMIterator{
+	return executor.AnalyzeMIterator(stats, &resultMIterator{
 		result: result,
-	}, nil
+	}), nil
 }
 
 func (i *localIndexScan) String() string {
@@ -205,6 +257,22 @@ func (i *localIndexScan) String() string {
 		i.query, logical.FormatTagRefs(", ", i.projectionTagsRefs...), i.order)
 }
 
+func (i *localIndexScan) Explain() *commonv1.PlanNode {
+	node := logical.NewPlanNode("IndexScan", "group", i.metadata.GetGroup(), "name", i.metadata.GetName(),
+		"time_range", i.timeRange.String(), "conditions", fmt.Sprintf("%v", i.query),
+		"projection", logical.FormatTagRefs(", ", i.projectionTagsRefs...))
+	if i.order != nil {
+		node.Attributes = append(node.Attributes, &commonv1.Tag{Key: "order", Value: i.order.String()})
+	}
+	if i.groupByEntity {
+		node.Attributes = append(node.Attributes, &commonv1.Tag{Key: "order_by_series", Value: "true"})
+	}
+	if i.estimated {
+		node.Attributes = append(node.Attributes, &commonv1.Tag{Key: "estimated_rows", Value: strconv.FormatFloat(i.estimatedRows, 'f', 0, 64)})
+	}
+	return node
+}
+
 func (i *localIndexScan) Children() []logical.Plan {
 	return []logical.Plan{}
 }
@@ -0,0 +1,139 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+	"github.com/apache/skywalking-banyandb/pkg/query/model"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+// seriesStatistics counts the series of the terms by the field of the series index.
+type seriesStatistics struct {
+	terms  map[string]map[string]uint64
+	series uint64
+}
+
+func (ss seriesStatistics) DocCount() (uint64, error) {
+	return ss.series, nil
+}
+
+func (ss seriesStatistics) TermDocCount(fieldKey index.FieldKey, opts index.RangeOpts) (uint64, error) {
+	return ss.terms[fieldKey.Marshal()][string(opts.Lower.(*index.BytesTermValue).Value)], nil
+}
+
+// statisticsContext is an execution context which only provides the statistics.
+type statisticsContext struct {
+	stats *model.Statistics
+}
+
+func (sc statisticsContext) Query(context.Context, model.MeasureQueryOptions) (model.MeasureQueryResult, error) {
+	return nil, nil
+}
+
+func (sc statisticsContext) Statistics(context.Context, timestamp.TimeRange) (*model.Statistics, error) {
+	return sc.stats, nil
+}
+
+func strCondition(name, value string) *modelv1.Criteria {
+	return &modelv1.Criteria{Exp: &modelv1.Criteria_Condition{Condition: &modelv1.Condition{
+		Name:  name,
+		Op:    modelv1.Condition_BINARY_OP_EQ,
+		Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: value}}},
+	}}}
+}
+
+func TestLocalIndexScanEstimatesRows(t *testing.T) {
+	serviceRule := &databasev1.IndexRule{
+		Metadata: &commonv1.Metadata{Id: 1, Name: "service", Group: "sw_metric"},
+		Tags:     []string{"service"},
+		Type:     databasev1.IndexRule_TYPE_INVERTED,
+	}
+	s, err := BuildSchema(&databasev1.Measure{
+		Metadata: &commonv1.Metadata{Name: "service_cpm_minute", Group: "sw_metric"},
+		Entity:   &databasev1.Entity{TagNames: []string{"id"}},
+		TagFamilies: []*databasev1.TagFamilySpec{{Name: "default", Tags: []*databasev1.TagSpec{
+			{Name: "id", Type: databasev1.TagType_TAG_TYPE_STRING},
+			{Name: "service", Type: databasev1.TagType_TAG_TYPE_STRING},
+		}}},
+		Fields: []*databasev1.FieldSpec{{Name: "value", FieldType: databasev1.FieldType_FIELD_TYPE_INT}},
+	}, []*databasev1.IndexRule{serviceRule})
+	require.NoError(t, err)
+	serviceField := index.FieldKey{IndexRuleID: serviceRule.Metadata.Id}.Marshal()
+	ec := statisticsContext{stats: model.NewStatistics([]model.SegmentStatistics{
+		{
+			Indexes: []index.Statistics{seriesStatistics{terms: map[string]map[string]uint64{serviceField: {"svc1": 2}}, series: 10}},
+			Series:  10,
+			Rows:    1000,
+		},
+		{
+			Indexes: []index.Statistics{seriesStatistics{terms: map[string]map[string]uint64{serviceField: {"svc1": 5}}, series: 5}},
+			Series:  5,
+			Rows:    100,
+		},
+	}, nil)}
+	now := time.Now()
+	for _, tc := range []struct {
+		criteria *modelv1.Criteria
+		name     string
+		want     string
+	}{
+		{
+			name:     "the series the index matches",
+			criteria: strCondition("service", "svc1"),
+			// 2 of 10 series in the first segment, and all series in the second one.
+			want: "300",
+		},
+		{
+			name: "the series the entity targets",
+			criteria: &modelv1.Criteria{Exp: &modelv1.Criteria_Le{Le: &modelv1.LogicalExpression{
+				Op:    modelv1.LogicalExpression_LOGICAL_OP_AND,
+				Left:  strCondition("id", "1"),
+				Right: strCondition("service", "svc1"),
+			}}},
+			// 1 of 10 series in the first segment, and 1 of 5 in the second one.
+			want: "120",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			plan, errAnalyze := indexScan(now.Add(-time.Hour), now, &commonv1.Metadata{Name: "service_cpm_minute", Group: "sw_metric"},
+				[][]*logical.Tag{logical.NewTags("default", "id")}, []*logical.Field{logical.NewField("value")}, false, tc.criteria, ec).Analyze(s)
+			require.NoError(t, errAnalyze)
+			scan := plan.(*localIndexScan)
+			require.NoError(t, scan.SelectIndex(context.Background()))
+			var got string
+			for _, attr := range scan.Explain().GetAttributes() {
+				if attr.GetKey() == "estimated_rows" {
+					got = attr.GetValue()
+				}
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
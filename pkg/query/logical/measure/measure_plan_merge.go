@@ -20,6 +20,8 @@ package measure
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"time"
 
 	"go.uber.org/multierr"
 
@@ -27,6 +29,7 @@ import (
 	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
 	"github.com/apache/skywalking-banyandb/pkg/iter/sort"
+	"github.com/apache/skywalking-banyandb/pkg/query"
 	"github.com/apache/skywalking-banyandb/pkg/query/executor"
 	"github.com/apache/skywalking-banyandb/pkg/query/logical"
 	"github.com/apache/skywalking-banyandb/pkg/query/model"
@@ -125,6 +128,8 @@ type mergePlan struct {
 }
 
 func (m *mergePlan) Execute(ctx context.Context) (executor.MIterator, error) {
+	stats, ctx := query.StartOperator(ctx, m)
+	defer stats.Observe(time.Now())
 	var allErr error
 	var iters []sort.Iterator[*comparableDataPoint]
 
@@ -150,7 +155,7 @@ func (m *mergePlan) Execute(ctx context.Context) (executor.MIterator, error) {
 		Iterator: sort.NewItemIter(iters, m.desc),
 	}
 	iter.init()
-	return iter, nil
+	return executor.AnalyzeMIterator(stats, iter), nil
 }
 
 func (m *mergePlan) Children() []logical.Plan {
@@ -166,6 +171,11 @@ func (m *mergePlan) String() string {
 		len(m.subPlans), m.sortByTime, m.desc, m.sortTagSpec.Spec.GetName())
 }
 
+func (m *mergePlan) Explain() *commonv1.PlanNode {
+	return logical.NewPlanNode("Merge", "sub_plans", strconv.Itoa(len(m.subPlans)), "sort_by_time", strconv.FormatBool(m.sortByTime),
+		"desc", strconv.FormatBool(m.desc), "sort_tag", m.sortTagSpec.Spec.GetName())
+}
+
 type sortableDataPoints struct {
 	iter        executor.MIterator
 	current     *comparableDataPoint
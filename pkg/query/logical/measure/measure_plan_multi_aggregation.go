@@ -0,0 +1,417 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query"
+	"github.com/apache/skywalking-banyandb/pkg/query/aggregation"
+	"github.com/apache/skywalking-banyandb/pkg/query/executor"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+)
+
+var (
+	_ logical.UnresolvedPlan = (*unresolvedMultiAggregation)(nil)
+	_ logical.Plan           = (*multiAggregationPlan)(nil)
+)
+
+// countAllField is the field name of an aggregation counting the data points, which is count(*).
+const countAllField = "*"
+
+// aggregationName returns the name of the field an aggregation produces.
+func aggregationName(agg *measurev1.QueryRequest_Aggregation) string {
+	if agg.GetName() != "" {
+		return agg.GetName()
+	}
+	return agg.GetFieldName()
+}
+
+func validateAggregations(criteria *measurev1.QueryRequest) error {
+	if criteria.GetAgg().GetFieldName() == countAllField {
+		return errors.Errorf("counting the data points by %s is only supported by aggs", countAllField)
+	}
+	if len(criteria.GetAggs()) == 0 {
+		if criteria.GetHaving() != nil && criteria.GetAgg() == nil {
+			return errors.New("having requires agg or aggs")
+		}
+		return nil
+	}
+	if criteria.GetAgg() != nil {
+		return errors.New("agg and aggs are exclusive")
+	}
+	names := make(map[string]struct{}, len(criteria.GetAggs()))
+	for _, agg := range criteria.GetAggs() {
+		name := aggregationName(agg)
+		if agg.GetFieldName() == countAllField && agg.GetFunction() != modelv1.AggregationFunction_AGGREGATION_FUNCTION_COUNT {
+			return errors.Errorf("aggregation %s can't take %s, which is only taken by %s", name, countAllField,
+				modelv1.AggregationFunction_AGGREGATION_FUNCTION_COUNT)
+		}
+		if _, ok := names[name]; ok {
+			return errors.Errorf("aggregation %s is defined more than once", name)
+		}
+		names[name] = struct{}{}
+	}
+	return nil
+}
+
+type unresolvedMultiAggregation struct {
+	unresolvedInput logical.UnresolvedPlan
+	aggs            []*measurev1.QueryRequest_Aggregation
+	isGroup         bool
+	// emitPartial makes the functions with partial states output the states, which happens on data nodes.
+	emitPartial bool
+	// pushedDown means the data points have been aggregated by data nodes,
+	// whose fields are the results of the aggregations in order.
+	pushedDown bool
+	timeBucket time.Duration
+}
+
+func newUnresolvedMultiAggregation(input logical.UnresolvedPlan, aggs []*measurev1.QueryRequest_Aggregation,
+	isGroup, emitPartial, pushedDown bool, timeBucket time.Duration,
+) logical.UnresolvedPlan {
+	return &unresolvedMultiAggregation{
+		unresolvedInput: input,
+		aggs:            aggs,
+		isGroup:         isGroup,
+		emitPartial:     emitPartial,
+		pushedDown:      pushedDown,
+		timeBucket:      timeBucket,
+	}
+}
+
+func (uma *unresolvedMultiAggregation) Analyze(measureSchema logical.Schema) (logical.Plan, error) {
+	prevPlan, err := uma.unresolvedInput.Analyze(measureSchema)
+	if err != nil {
+		return nil, err
+	}
+	schema := prevPlan.Schema()
+	aggregators := make([]fieldAggregator, 0, len(uma.aggs))
+	fieldMap := make(map[string]*logical.FieldSpec, len(uma.aggs))
+	for i, agg := range uma.aggs {
+		spec, fieldIdx, specErr := aggregationFieldSpec(schema, agg)
+		if specErr != nil {
+			return nil, specErr
+		}
+		partial := partialModeNone
+		if uma.pushedDown {
+			fieldIdx = i
+			if aggregation.IsMergeable(agg.GetFunction()) {
+				partial = partialModeMerge
+			}
+		} else if uma.emitPartial && aggregation.IsMergeable(agg.GetFunction()) {
+			partial = partialModeEmit
+		}
+		aggregator, newErr := newFieldAggregator(agg.GetFunction(), spec, fieldIdx, partial)
+		if newErr != nil {
+			return nil, newErr
+		}
+		aggregators = append(aggregators, aggregator)
+		fieldMap[aggregationName(agg)] = &logical.FieldSpec{
+			FieldIdx: i,
+			Spec:     &databasev1.FieldSpec{Name: aggregationName(agg), FieldType: spec.GetFieldType()},
+		}
+	}
+	return &multiAggregationPlan{
+		Parent: &logical.Parent{
+			UnresolvedInput: uma.unresolvedInput,
+			Input:           prevPlan,
+		},
+		schema:      &aggregatedSchema{Schema: schema, fieldMap: fieldMap},
+		aggs:        uma.aggs,
+		aggregators: aggregators,
+		isGroup:     uma.isGroup,
+		timeBucket:  uma.timeBucket,
+	}, nil
+}
+
+// aggregationFieldSpec returns the spec and the index of the field an aggregation takes in.
+// Counting the data points takes in no field, whose index is countAllFieldIdx.
+func aggregationFieldSpec(schema logical.Schema, agg *measurev1.QueryRequest_Aggregation) (*databasev1.FieldSpec, int, error) {
+	if agg.GetFieldName() == countAllField {
+		return &databasev1.FieldSpec{Name: countAllField, FieldType: databasev1.FieldType_FIELD_TYPE_INT}, countAllFieldIdx, nil
+	}
+	fieldRefs, err := schema.CreateFieldRef(logical.NewField(agg.GetFieldName()))
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(fieldRefs) == 0 {
+		return nil, 0, errors.Wrapf(errFieldNotDefined, "aggregation %s", aggregationName(agg))
+	}
+	return fieldRefs[0].Spec.Spec, fieldRefs[0].Spec.FieldIdx, nil
+}
+
+type multiAggregationPlan struct {
+	*logical.Parent
+	schema      logical.Schema
+	aggs        []*measurev1.QueryRequest_Aggregation
+	aggregators []fieldAggregator
+	timeBucket  time.Duration
+	isGroup     bool
+}
+
+func (m *multiAggregationPlan) String() string {
+	aggs := make([]string, 0, len(m.aggs))
+	for _, agg := range m.aggs {
+		aggs = append(aggs, fmt.Sprintf("%s=%s(%s)", aggregationName(agg), agg.GetFunction(), agg.GetFieldName()))
+	}
+	return fmt.Sprintf("%s aggregations: aggregations{%s}", m.Input, strings.Join(aggs, ","))
+}
+
+func (m *multiAggregationPlan) Explain() *commonv1.PlanNode {
+	aggs := make([]string, 0, len(m.aggs))
+	for _, agg := range m.aggs {
+		aggs = append(aggs, fmt.Sprintf("%s=%s(%s)", aggregationName(agg), agg.GetFunction(), agg.GetFieldName()))
+	}
+	node := logical.NewPlanNode("Aggregations", "aggregations", strings.Join(aggs, ","), "group", strconv.FormatBool(m.isGroup))
+	if m.timeBucket > 0 {
+		node.Attributes = append(node.Attributes, &commonv1.Tag{Key: "time_bucket", Value: m.timeBucket.String()})
+	}
+	return node
+}
+
+func (m *multiAggregationPlan) Children() []logical.Plan {
+	return []logical.Plan{m.Input}
+}
+
+func (m *multiAggregationPlan) Schema() logical.Schema {
+	return m.schema
+}
+
+func (m *multiAggregationPlan) Execute(ec context.Context) (executor.MIterator, error) {
+	stats, ec := query.StartOperator(ec, m)
+	defer stats.Observe(time.Now())
+	iter, err := m.Parent.Input.(executor.MeasureExecutable).Execute(ec)
+	if err != nil {
+		return nil, err
+	}
+	return executor.AnalyzeMIterator(stats, &multiAggIterator{
+		prev:        iter,
+		aggs:        m.aggs,
+		aggregators: m.aggregators,
+		isGroup:     m.isGroup,
+		timeBucket:  m.timeBucket,
+	}), nil
+}
+
+// multiAggIterator aggregates each group of the input into a data point if isGroup is true.
+// Otherwise, it aggregates all the data points into one.
+type multiAggIterator struct {
+	prev        executor.MIterator
+	err         error
+	result      *measurev1.DataPoint
+	pending     *measurev1.DataPoint
+	aggs        []*measurev1.QueryRequest_Aggregation
+	aggregators []fieldAggregator
+	timeBucket  time.Duration
+	isGroup     bool
+	done        bool
+}
+
+func (mai *multiAggIterator) Next() bool {
+	if mai.err != nil || mai.done {
+		return false
+	}
+	if !mai.isGroup {
+		mai.done = true
+		mai.start()
+		for mai.prev.Next() {
+			if !mai.add(mai.prev.Current()) {
+				return false
+			}
+		}
+		mai.result = mai.finish()
+		return mai.result != nil
+	}
+	for mai.prev.Next() {
+		mai.start()
+		if !mai.add(mai.prev.Current()) {
+			return false
+		}
+		if mai.result = mai.finish(); mai.result != nil {
+			return true
+		}
+		if mai.err != nil {
+			return false
+		}
+	}
+	return false
+}
+
+func (mai *multiAggIterator) start() {
+	for _, a := range mai.aggregators {
+		a.reset()
+	}
+	mai.pending = nil
+}
+
+func (mai *multiAggIterator) add(group []*measurev1.DataPoint) bool {
+	for _, dp := range group {
+		for _, a := range mai.aggregators {
+			if err := a.in(dp); err != nil {
+				mai.err = err
+				return false
+			}
+		}
+		if mai.pending == nil {
+			mai.pending = &measurev1.DataPoint{
+				TagFamilies: dp.TagFamilies,
+			}
+			if mai.isGroup && mai.timeBucket > 0 {
+				mai.pending.Timestamp = timeBucketStart(dp.GetTimestamp(), mai.timeBucket)
+			}
+		}
+	}
+	return true
+}
+
+func (mai *multiAggIterator) finish() *measurev1.DataPoint {
+	if mai.pending == nil {
+		return nil
+	}
+	resultDp := mai.pending
+	resultDp.Fields = make([]*measurev1.DataPoint_Field, 0, len(mai.aggregators))
+	for i, a := range mai.aggregators {
+		val, err := a.val()
+		if err != nil {
+			mai.err = err
+			return nil
+		}
+		resultDp.Fields = append(resultDp.Fields, &measurev1.DataPoint_Field{
+			Name:  aggregationName(mai.aggs[i]),
+			Value: val,
+		})
+	}
+	return resultDp
+}
+
+func (mai *multiAggIterator) Current() []*measurev1.DataPoint {
+	if mai.err != nil || mai.result == nil {
+		return nil
+	}
+	return []*measurev1.DataPoint{mai.result}
+}
+
+func (mai *multiAggIterator) Close() error {
+	return multierr.Combine(mai.err, mai.prev.Close())
+}
+
+// fieldAggregator aggregates a field of data points regardless of the type of the field.
+type fieldAggregator interface {
+	in(dp *measurev1.DataPoint) error
+	val() (*modelv1.FieldValue, error)
+	reset()
+}
+
+func newFieldAggregator(af modelv1.AggregationFunction, spec *databasev1.FieldSpec, fieldIdx int, partial partialMode) (fieldAggregator, error) {
+	switch spec.GetFieldType() {
+	case databasev1.FieldType_FIELD_TYPE_INT:
+		return newTypedFieldAggregator[int64](af, fieldIdx, partial)
+	case databasev1.FieldType_FIELD_TYPE_FLOAT:
+		return newTypedFieldAggregator[float64](af, fieldIdx, partial)
+	default:
+		return nil, errors.WithMessagef(errUnsupportedAggregationField, "field: %s", spec)
+	}
+}
+
+type typedFieldAggregator[N aggregation.Number] struct {
+	aggrFunc aggregation.Func[N]
+	fieldIdx int
+	partial  partialMode
+}
+
+func newTypedFieldAggregator[N aggregation.Number](af modelv1.AggregationFunction, fieldIdx int, partial partialMode) (fieldAggregator, error) {
+	aggrFunc, err := aggregation.NewFunc[N](af)
+	if err != nil {
+		return nil, err
+	}
+	return &typedFieldAggregator[N]{aggrFunc: aggrFunc, fieldIdx: fieldIdx, partial: partial}, nil
+}
+
+// countAllFieldIdx is the field index of counting the data points, which takes in each data point as a value.
+const countAllFieldIdx = -1
+
+func (t *typedFieldAggregator[N]) in(dp *measurev1.DataPoint) error {
+	if t.fieldIdx == countAllFieldIdx {
+		t.aggrFunc.In(1)
+		return nil
+	}
+	if t.fieldIdx >= len(dp.GetFields()) {
+		return errors.Errorf("field %d is not found in the data point", t.fieldIdx)
+	}
+	return aggregateValue(t.aggrFunc, dp.GetFields()[t.fieldIdx].GetValue(), t.partial)
+}
+
+func (t *typedFieldAggregator[N]) val() (*modelv1.FieldValue, error) {
+	return aggregatedValue(t.aggrFunc, t.partial)
+}
+
+func (t *typedFieldAggregator[N]) reset() {
+	t.aggrFunc.Reset()
+}
+
+// aggregatedSchema is the schema of the data points produced by multiple aggregations.
+// Its fields are the results of the aggregations, while the tags are the ones of the input.
+type aggregatedSchema struct {
+	logical.Schema
+	fieldMap map[string]*logical.FieldSpec
+}
+
+func (as *aggregatedSchema) CreateFieldRef(fields ...*logical.Field) ([]*logical.FieldRef, error) {
+	fieldRefs := make([]*logical.FieldRef, 0, len(fields))
+	for _, field := range fields {
+		if fs, ok := as.fieldMap[field.Name]; ok {
+			fieldRefs = append(fieldRefs, &logical.FieldRef{Field: field, Spec: fs})
+		}
+	}
+	return fieldRefs, nil
+}
+
+func (as *aggregatedSchema) ProjTags(refs ...[]*logical.TagRef) logical.Schema {
+	return &aggregatedSchema{
+		Schema:   as.Schema.ProjTags(refs...),
+		fieldMap: as.fieldMap,
+	}
+}
+
+func (as *aggregatedSchema) ProjFields(fieldRefs ...*logical.FieldRef) logical.Schema {
+	fieldMap := make(map[string]*logical.FieldSpec, len(fieldRefs))
+	for i, fr := range fieldRefs {
+		if spec, ok := as.fieldMap[fr.Field.Name]; ok {
+			fieldMap[fr.Field.Name] = &logical.FieldSpec{
+				FieldIdx: i,
+				Spec:     spec.Spec,
+			}
+		}
+	}
+	return &aggregatedSchema{
+		Schema:   as.Schema,
+		fieldMap: fieldMap,
+	}
+}
@@ -0,0 +1,210 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package measure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/aggregation"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+)
+
+var intFieldSpec = &databasev1.FieldSpec{Name: "value", FieldType: databasev1.FieldType_FIELD_TYPE_INT}
+
+func newMultiAggIterator(t *testing.T, prev *pushedDownAggregatedIterator, isGroup bool,
+	aggs ...*measurev1.QueryRequest_Aggregation,
+) *multiAggIterator {
+	aggregators := make([]fieldAggregator, 0, len(aggs))
+	for _, agg := range aggs {
+		a, err := newFieldAggregator(agg.GetFunction(), intFieldSpec, 0, partialModeNone)
+		require.NoError(t, err)
+		aggregators = append(aggregators, a)
+	}
+	return &multiAggIterator{prev: prev, aggs: aggs, aggregators: aggregators, isGroup: isGroup}
+}
+
+func intCondition(name string, op modelv1.Condition_BinaryOp, value int64) *measurev1.Having {
+	return &measurev1.Having{Exp: &measurev1.Having_Condition{Condition: &measurev1.HavingCondition{
+		Name:  name,
+		Op:    op,
+		Value: &modelv1.FieldValue{Value: &modelv1.FieldValue_Int{Int: &modelv1.Int{Value: value}}},
+	}}}
+}
+
+func TestMultiAggregation(t *testing.T) {
+	aggs := []*measurev1.QueryRequest_Aggregation{
+		{Name: "total", FieldName: "value", Function: modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM},
+		{Name: "peak", FieldName: "value", Function: modelv1.AggregationFunction_AGGREGATION_FUNCTION_MAX},
+		{FieldName: "value", Function: modelv1.AggregationFunction_AGGREGATION_FUNCTION_COUNT},
+	}
+	iter := newMultiAggIterator(t, &pushedDownAggregatedIterator{dataPoints: intDataPoints(1, 5, 3)}, false, aggs...)
+	require.True(t, iter.Next())
+	dps := iter.Current()
+	require.Len(t, dps, 1)
+	fields := dps[0].GetFields()
+	require.Len(t, fields, 3)
+	assert.Equal(t, "total", fields[0].GetName())
+	assert.Equal(t, int64(9), fields[0].GetValue().GetInt().GetValue())
+	assert.Equal(t, "peak", fields[1].GetName())
+	assert.Equal(t, int64(5), fields[1].GetValue().GetInt().GetValue())
+	assert.Equal(t, "value", fields[2].GetName())
+	assert.Equal(t, int64(3), fields[2].GetValue().GetInt().GetValue())
+	assert.False(t, iter.Next())
+	require.NoError(t, iter.Close())
+}
+
+func TestMultiAggregationWithPartialStates(t *testing.T) {
+	aggs := []*measurev1.QueryRequest_Aggregation{
+		{Name: "total", FieldName: "value", Function: modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM},
+		{Name: "values", FieldName: "value", Function: modelv1.AggregationFunction_AGGREGATION_FUNCTION_COUNT},
+		{Name: "avg", FieldName: "value", Function: modelv1.AggregationFunction_AGGREGATION_FUNCTION_MEAN},
+		{Name: "points", FieldName: countAllField, Function: modelv1.AggregationFunction_AGGREGATION_FUNCTION_COUNT},
+		{Name: "peak", FieldName: "value", Function: modelv1.AggregationFunction_AGGREGATION_FUNCTION_MAX},
+	}
+	newIterator := func(dps []*measurev1.DataPoint, pushedDown bool) *multiAggIterator {
+		aggregators := make([]fieldAggregator, 0, len(aggs))
+		for i, agg := range aggs {
+			spec, fieldIdx := intFieldSpec, 0
+			if agg.GetFieldName() == countAllField {
+				var err error
+				spec, fieldIdx, err = aggregationFieldSpec(nil, agg)
+				require.NoError(t, err)
+			}
+			partial := partialModeNone
+			switch {
+			case pushedDown:
+				fieldIdx = i
+				if aggregation.IsMergeable(agg.GetFunction()) {
+					partial = partialModeMerge
+				}
+			case aggregation.IsMergeable(agg.GetFunction()):
+				partial = partialModeEmit
+			}
+			a, err := newFieldAggregator(agg.GetFunction(), spec, fieldIdx, partial)
+			require.NoError(t, err)
+			aggregators = append(aggregators, a)
+		}
+		return &multiAggIterator{prev: &pushedDownAggregatedIterator{dataPoints: dps}, aggs: aggs, aggregators: aggregators}
+	}
+
+	// Each data node emits the partial states of its own data points.
+	var partials []*measurev1.DataPoint
+	for _, values := range [][]int64{{1, 2}, {4, 5, 6, 7, 8, 3}} {
+		iter := newIterator(intDataPoints(values...), false)
+		require.True(t, iter.Next())
+		partials = append(partials, iter.Current()...)
+		require.NoError(t, iter.Close())
+	}
+
+	// The liaison merges the partial states, rather than aggregating the results of the data nodes again.
+	iter := newIterator(partials, true)
+	require.True(t, iter.Next())
+	dps := iter.Current()
+	require.Len(t, dps, 1)
+	got := make(map[string]int64, len(aggs))
+	for _, f := range dps[0].GetFields() {
+		got[f.GetName()] = f.GetValue().GetInt().GetValue()
+	}
+	assert.Equal(t, map[string]int64{"total": 36, "values": 8, "avg": 4, "points": 8, "peak": 8}, got)
+	assert.False(t, iter.Next())
+	require.NoError(t, iter.Close())
+}
+
+func TestHaving(t *testing.T) {
+	aggs := []*measurev1.QueryRequest_Aggregation{
+		{Name: "total", FieldName: "value", Function: modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM},
+		{Name: "peak", FieldName: "value", Function: modelv1.AggregationFunction_AGGREGATION_FUNCTION_MAX},
+	}
+	s := &aggregatedSchema{fieldMap: map[string]*logical.FieldSpec{
+		"total": {FieldIdx: 0, Spec: intFieldSpec},
+		"peak":  {FieldIdx: 1, Spec: intFieldSpec},
+	}}
+	tests := []struct {
+		having *measurev1.Having
+		name   string
+		want   []int64
+	}{
+		{
+			name:   "condition",
+			having: intCondition("total", modelv1.Condition_BINARY_OP_GE, 5),
+			want:   []int64{5, 8},
+		},
+		{
+			name: "and",
+			having: &measurev1.Having{Exp: &measurev1.Having_Le{Le: &measurev1.HavingExpression{
+				Op:    modelv1.LogicalExpression_LOGICAL_OP_AND,
+				Left:  intCondition("total", modelv1.Condition_BINARY_OP_GT, 1),
+				Right: intCondition("peak", modelv1.Condition_BINARY_OP_LT, 8),
+			}}},
+			want: []int64{5},
+		},
+		{
+			name: "or",
+			having: &measurev1.Having{Exp: &measurev1.Having_Le{Le: &measurev1.HavingExpression{
+				Op:    modelv1.LogicalExpression_LOGICAL_OP_OR,
+				Left:  intCondition("total", modelv1.Condition_BINARY_OP_EQ, 1),
+				Right: intCondition("peak", modelv1.Condition_BINARY_OP_NE, 5),
+			}}},
+			want: []int64{1, 8},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			predicate, err := buildHavingPredicate(s, tt.having)
+			require.NoError(t, err)
+			// Each data point is a group, so that the aggregations output a data point per group.
+			prev := newMultiAggIterator(t, &pushedDownAggregatedIterator{dataPoints: intDataPoints(1, 5, 8)}, true, aggs...)
+			iter := &havingIterator{prev: prev, predicate: predicate}
+			var got []int64
+			for iter.Next() {
+				for _, dp := range iter.Current() {
+					got = append(got, dp.GetFields()[0].GetValue().GetInt().GetValue())
+				}
+			}
+			require.NoError(t, iter.Close())
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := buildHavingPredicate(s, intCondition("unknown", modelv1.Condition_BINARY_OP_EQ, 1))
+	assert.ErrorIs(t, err, errInvalidHaving)
+	_, err = buildHavingPredicate(s, intCondition("total", modelv1.Condition_BINARY_OP_IN, 1))
+	assert.ErrorIs(t, err, errInvalidHaving)
+}
+
+func TestValidateAggregations(t *testing.T) {
+	agg := &measurev1.QueryRequest_Aggregation{FieldName: "value", Function: modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM}
+	having := intCondition("value", modelv1.Condition_BINARY_OP_GT, 1)
+	assert.NoError(t, validateAggregations(&measurev1.QueryRequest{Agg: agg, Having: having}))
+	assert.NoError(t, validateAggregations(&measurev1.QueryRequest{Aggs: []*measurev1.QueryRequest_Aggregation{agg}, Having: having}))
+	assert.Error(t, validateAggregations(&measurev1.QueryRequest{Having: having}))
+	assert.Error(t, validateAggregations(&measurev1.QueryRequest{Agg: agg, Aggs: []*measurev1.QueryRequest_Aggregation{agg}}))
+	assert.Error(t, validateAggregations(&measurev1.QueryRequest{Aggs: []*measurev1.QueryRequest_Aggregation{agg, agg}}))
+
+	countAll := &measurev1.QueryRequest_Aggregation{FieldName: countAllField, Function: modelv1.AggregationFunction_AGGREGATION_FUNCTION_COUNT}
+	sumAll := &measurev1.QueryRequest_Aggregation{FieldName: countAllField, Function: modelv1.AggregationFunction_AGGREGATION_FUNCTION_SUM}
+	assert.NoError(t, validateAggregations(&measurev1.QueryRequest{Aggs: []*measurev1.QueryRequest_Aggregation{agg, countAll}}))
+	assert.Error(t, validateAggregations(&measurev1.QueryRequest{Aggs: []*measurev1.QueryRequest_Aggregation{sumAll}}))
+	assert.Error(t, validateAggregations(&measurev1.QueryRequest{Agg: countAll}))
+}
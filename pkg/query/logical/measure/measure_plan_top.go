@@ -20,12 +20,15 @@ package measure
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 	"go.uber.org/multierr"
 
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	measurev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/measure/v1"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query"
 	"github.com/apache/skywalking-banyandb/pkg/query/executor"
 	"github.com/apache/skywalking-banyandb/pkg/query/logical"
 )
@@ -83,6 +86,10 @@ func (g *topOp) String() string {
 	return fmt.Sprintf("%s top %s", g.Input, g.topNStream.String())
 }
 
+func (g *topOp) Explain() *commonv1.PlanNode {
+	return logical.NewPlanNode("Top", "top", g.topNStream.String(), "field", g.fieldRef.Field.Name)
+}
+
 func (g *topOp) Children() []logical.Plan {
 	return []logical.Plan{g.Input}
 }
@@ -92,6 +99,8 @@ func (g *topOp) Schema() logical.Schema {
 }
 
 func (g *topOp) Execute(ec context.Context) (mit executor.MIterator, err error) {
+	stats, ec := query.StartOperator(ec, g)
+	defer stats.Observe(time.Now())
 	iter, err := g.Parent.Input.(executor.MeasureExecutable).Execute(ec)
 	if err != nil {
 		return nil, err
@@ -110,7 +119,7 @@ func (g *topOp) Execute(ec context.Context) (mit executor.MIterator, err error)
 			g.topNStream.Insert(NewTopElement(dp, value))
 		}
 	}
-	return newTopIterator(g.topNStream.Elements()), nil
+	return executor.AnalyzeMIterator(stats, newTopIterator(g.topNStream.Elements())), nil
 }
 
 type topIterator struct {
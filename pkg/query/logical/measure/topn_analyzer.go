@@ -110,11 +110,13 @@ func TopNAnalyze(criteria *measurev1.TopNRequest, sourceMeasureSchemaList []*dat
 	if criteria.GetAgg() != 0 {
 		groupByProjectionTags := sourceMeasureSchema.GetEntity().GetTagNames()
 		groupByTags := [][]*logical.Tag{logical.NewTags(measure.TopNTagFamily, groupByProjectionTags...)}
-		plan = newUnresolvedGroupBy(plan, groupByTags, false)
+		plan = newUnresolvedGroupBy(plan, groupByTags, false, 0)
 		plan = newUnresolvedAggregation(plan,
 			&logical.Field{Name: topNAggSchema.FieldName},
 			criteria.GetAgg(),
-			true)
+			true,
+			partialModeNone,
+			0)
 	}
 
 	plan = top(plan, &measurev1.QueryRequest_Top{
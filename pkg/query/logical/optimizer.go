@@ -18,6 +18,8 @@
 package logical
 
 import (
+	"context"
+
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
 )
 
@@ -59,6 +61,12 @@ type Sorter interface {
 	Sort(order *OrderBy)
 }
 
+// IndexSelector chooses how a Plan scans the data by the cost estimated with the statistics of the data.
+type IndexSelector interface {
+	Plan
+	SelectIndex(ctx context.Context) error
+}
+
 var _ OptimizeRule = (*PushDownOrder)(nil)
 
 // PushDownOrder pushes down the order to a Plan.
@@ -101,3 +109,23 @@ func (pde PushDownMaxSize) Optimize(plan Plan) (Plan, error) {
 	}
 	return plan, nil
 }
+
+// SelectIndexByCost chooses the indexes a Plan scans by their cost.
+type SelectIndexByCost struct {
+	ctx context.Context
+}
+
+// NewSelectIndexByCost returns a new SelectIndexByCost, which loads the statistics in the context.
+func NewSelectIndexByCost(ctx context.Context) SelectIndexByCost {
+	return SelectIndexByCost{ctx: ctx}
+}
+
+// Optimize a Plan by choosing the indexes it scans.
+func (sic SelectIndexByCost) Optimize(plan Plan) (Plan, error) {
+	if v, ok := plan.(IndexSelector); ok {
+		if err := v.SelectIndex(sic.ctx); err != nil {
+			return plan, err
+		}
+	}
+	return plan, nil
+}
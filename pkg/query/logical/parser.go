@@ -21,6 +21,7 @@ import (
 	"github.com/pkg/errors"
 
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/index"
 	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
 )
 
@@ -65,6 +66,14 @@ func ParseExprOrEntity(entityDict map[string]int, entity []*modelv1.TagValue, co
 			return nil, [][]*modelv1.TagValue{parsedEntity}, nil
 		}
 		return newInt64Literal(v.Int.GetValue()), nil, nil
+	case *modelv1.TagValue_Float:
+		if ok {
+			parsedEntity := make([]*modelv1.TagValue, len(entity))
+			copy(parsedEntity, entity)
+			parsedEntity[entityIdx] = cond.Value
+			return nil, [][]*modelv1.TagValue{parsedEntity}, nil
+		}
+		return newFloat64Literal(v.Float.GetValue()), nil, nil
 	case *modelv1.TagValue_IntArray:
 		if ok && cond.Op == modelv1.Condition_BINARY_OP_IN {
 			entities := make([][]*modelv1.TagValue, len(v.IntArray.Value))
@@ -98,6 +107,8 @@ func ParseExpr(cond *modelv1.Condition) (LiteralExpr, error) {
 		return newStrArrLiteral(v.StrArray.GetValue()), nil
 	case *modelv1.TagValue_Int:
 		return newInt64Literal(v.Int.GetValue()), nil
+	case *modelv1.TagValue_Float:
+		return newFloat64Literal(v.Float.GetValue()), nil
 	case *modelv1.TagValue_IntArray:
 		return newInt64ArrLiteral(v.IntArray.GetValue()), nil
 	case *modelv1.TagValue_Null:
@@ -106,6 +117,56 @@ func ParseExpr(cond *modelv1.Condition) (LiteralExpr, error) {
 	return nil, errors.WithMessagef(ErrUnsupportedConditionValue, "condition parses %v", cond)
 }
 
+// ParseTreePath returns the path of a SUBTREE or ANCESTORS condition.
+func ParseTreePath(cond *modelv1.Condition) (string, error) {
+	v := cond.GetValue().GetStr()
+	if v == nil {
+		return "", errors.WithMessagef(ErrUnsupportedConditionValue, "the path of %s should be a string: %v", cond.Op, cond)
+	}
+	return v.GetValue(), nil
+}
+
+// ParsePattern compiles the pattern of a PREFIX, WILDCARD or REGEX condition.
+func ParsePattern(cond *modelv1.Condition) (*index.Pattern, error) {
+	v := cond.GetValue().GetStr()
+	if v == nil {
+		return nil, errors.WithMessagef(ErrUnsupportedConditionValue, "the pattern of %s should be a string: %v", cond.Op, cond)
+	}
+	var patternType index.PatternType
+	switch cond.Op {
+	case modelv1.Condition_BINARY_OP_PREFIX:
+		patternType = index.PatternTypePrefix
+	case modelv1.Condition_BINARY_OP_WILDCARD:
+		patternType = index.PatternTypeWildcard
+	case modelv1.Condition_BINARY_OP_REGEX:
+		patternType = index.PatternTypeRegex
+	default:
+		return nil, errors.WithMessagef(ErrUnsupportedConditionOp, "%s isn't a pattern: %v", cond.Op, cond)
+	}
+	pattern, err := index.NewPattern(patternType, v.GetValue())
+	if err != nil {
+		return nil, errors.WithMessagef(ErrUnsupportedConditionValue, "%v", err)
+	}
+	return pattern, nil
+}
+
+// AncestorsExpr returns the expression of the path and its ancestors in a tree.
+func AncestorsExpr(path, sep string) LiteralExpr {
+	return newStrArrLiteral(index.TreePaths(path, sep))
+}
+
+// TargetedSeries returns the number of the series the entities select, or zero if an entity selects any series.
+func TargetedSeries(entities [][]*modelv1.TagValue) float64 {
+	for _, entity := range entities {
+		for _, v := range entity {
+			if v == pbv1.AnyTagValue {
+				return 0
+			}
+		}
+	}
+	return float64(len(entities))
+}
+
 // ParseEntities merges entities based on the logical operation.
 func ParseEntities(op modelv1.LogicalExpression_LogicalOp, input []*modelv1.TagValue, left, right [][]*modelv1.TagValue) [][]*modelv1.TagValue {
 	count := len(input)
@@ -49,7 +49,7 @@ func buildLocalFilter(criteria *modelv1.Criteria, schema logical.Schema,
 		if parsedEntity != nil {
 			return nil, parsedEntity, nil
 		}
-		if ok, indexRule := schema.IndexDefined(cond.Name); ok && indexRule.Type == indexRuleType {
+		if ok, indexRule := schema.IndexDefined(cond.Name); ok && storedIn(indexRule, indexRuleType) {
 			return parseConditionToFilter(cond, indexRule, expr, entity)
 		}
 		return ENode, [][]*modelv1.TagValue{entity}, nil
@@ -102,6 +102,15 @@ func buildLocalFilter(criteria *modelv1.Criteria, schema logical.Schema,
 	return nil, nil, logical.ErrInvalidCriteriaType
 }
 
+// storedIn reports whether the index of the rule is stored in the index of indexRuleType.
+// The tree index is stored in the inverted index.
+func storedIn(indexRule *databasev1.IndexRule, indexRuleType databasev1.IndexRule_Type) bool {
+	if indexRule.Type == databasev1.IndexRule_TYPE_TREE {
+		return indexRuleType == databasev1.IndexRule_TYPE_INVERTED
+	}
+	return indexRule.Type == indexRuleType
+}
+
 func parseConditionToFilter(cond *modelv1.Condition, indexRule *databasev1.IndexRule,
 	expr logical.LiteralExpr, entity []*modelv1.TagValue,
 ) (index.Filter, [][]*modelv1.TagValue, error) {
@@ -120,7 +129,42 @@ func parseConditionToFilter(cond *modelv1.Condition, indexRule *databasev1.Index
 		if indexRule.Type == databasev1.IndexRule_TYPE_INVERTED {
 			return newMatch(indexRule, expr, cond.MatchOption), [][]*modelv1.TagValue{entity}, nil
 		}
-		return nil, nil, errors.WithMessagef(logical.ErrUnsupportedConditionOp, "index filter parses %v for skipping index", cond)
+		return nil, nil, errors.WithMessagef(logical.ErrUnsupportedConditionOp, "index filter parses %v for %s index", cond, indexRule.Type)
+	case modelv1.Condition_BINARY_OP_SUBTREE:
+		path, err := logical.ParseTreePath(cond)
+		if err != nil {
+			return nil, nil, err
+		}
+		if indexRule.Type == databasev1.IndexRule_TYPE_TREE {
+			return newSubtree(indexRule, path), [][]*modelv1.TagValue{entity}, nil
+		}
+		// The tag filter takes over the subtree which isn't indexed by a tree index.
+		return ENode, [][]*modelv1.TagValue{entity}, nil
+	case modelv1.Condition_BINARY_OP_ANCESTORS:
+		path, err := logical.ParseTreePath(cond)
+		if err != nil {
+			return nil, nil, err
+		}
+		if indexRule.Type == databasev1.IndexRule_TYPE_SKIPPING {
+			return ENode, [][]*modelv1.TagValue{entity}, nil
+		}
+		// The ancestors are looked up by their values, which are indexed by both the inverted and the tree index.
+		ee := logical.AncestorsExpr(path, index.TreeSeparator(indexRule)).SubExprs()
+		or := newOr(len(ee))
+		for i := range ee {
+			or.append(newEq(indexRule, ee[i]))
+		}
+		return or, [][]*modelv1.TagValue{entity}, nil
+	case modelv1.Condition_BINARY_OP_PREFIX, modelv1.Condition_BINARY_OP_WILDCARD, modelv1.Condition_BINARY_OP_REGEX:
+		p, err := logical.ParsePattern(cond)
+		if err != nil {
+			return nil, nil, err
+		}
+		if indexRule.Analyzer != index.AnalyzerUnspecified && indexRule.Analyzer != index.AnalyzerKeyword {
+			// The analyzed tokens differ from the values, the tag filter matches the pattern against the values.
+			return ENode, [][]*modelv1.TagValue{entity}, nil
+		}
+		return newPattern(indexRule, p), [][]*modelv1.TagValue{entity}, nil
 	case modelv1.Condition_BINARY_OP_NE:
 		return newNot(indexRule, newEq(indexRule, expr)), [][]*modelv1.TagValue{entity}, nil
 	case modelv1.Condition_BINARY_OP_HAVING:
@@ -224,6 +268,10 @@ func execute(searcher index.GetSearcher, seriesID common.SeriesID, n *node, lp l
 		if resultTS, err = merge(resultTS, rt, lp); err != nil {
 			return nil, nil, err
 		}
+		// The remaining branches of an AND can't add any rows to an empty result.
+		if _, ok := lp.(*andNode); ok && result != nil && result.IsEmpty() {
+			break
+		}
 	}
 	return result, resultTS, nil
 }
@@ -493,6 +541,86 @@ func (match *match) String() string {
 	return convert.JSONToString(match)
 }
 
+type subtree struct {
+	index.Filter
+	Key  fieldKey
+	Path string
+}
+
+func newSubtree(indexRule *databasev1.IndexRule, path string) *subtree {
+	return &subtree{
+		Key:  newFieldKeyWithIndexRule(indexRule),
+		Path: index.NormalizeTreePath(path, index.TreeSeparator(indexRule)),
+	}
+}
+
+func (st *subtree) Execute(searcher index.GetSearcher, seriesID common.SeriesID, tr *index.RangeOpts) (posting.List, posting.List, error) {
+	s, err := searcher(st.Key.Type)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.MatchTerms(index.NewStringField(index.TreeFieldKey(st.Key.toIndex(seriesID, tr)), st.Path))
+}
+
+func (st *subtree) ShouldSkip(_ index.FilterOp) (bool, error) {
+	return false, nil
+}
+
+func (st *subtree) MarshalJSON() ([]byte, error) {
+	data := make(map[string]interface{}, 1)
+	data["subtree"] = map[string]interface{}{
+		"index": st.Key.IndexRule.Metadata.Name + ":" + st.Key.IndexRule.Metadata.Group,
+		"path":  st.Path,
+	}
+	return json.Marshal(data)
+}
+
+func (st *subtree) String() string {
+	return convert.JSONToString(st)
+}
+
+type pattern struct {
+	index.Filter
+	Pattern *index.Pattern
+	Key     fieldKey
+}
+
+func newPattern(indexRule *databasev1.IndexRule, p *index.Pattern) *pattern {
+	return &pattern{
+		Key:     newFieldKeyWithIndexRule(indexRule),
+		Pattern: p,
+	}
+}
+
+func (p *pattern) Execute(searcher index.GetSearcher, seriesID common.SeriesID, tr *index.RangeOpts) (posting.List, posting.List, error) {
+	s, err := searcher(p.Key.Type)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.MatchPattern(p.Key.toIndex(seriesID, tr), p.Pattern)
+}
+
+func (p *pattern) ShouldSkip(tagFamilyFilters index.FilterOp) (bool, error) {
+	// The bloom filter only answers whether a complete literal exists.
+	if literal, ok := p.Pattern.Literal(); ok {
+		return !tagFamilyFilters.Eq(p.Key.Tags[0], literal), nil
+	}
+	return false, nil
+}
+
+func (p *pattern) MarshalJSON() ([]byte, error) {
+	data := make(map[string]interface{}, 1)
+	data[p.Pattern.Type.String()] = map[string]interface{}{
+		"index": p.Key.IndexRule.Metadata.Name + ":" + p.Key.IndexRule.Metadata.Group,
+		"expr":  p.Pattern.Expr,
+	}
+	return json.Marshal(data)
+}
+
+func (p *pattern) String() string {
+	return convert.JSONToString(p)
+}
+
 type rangeOp struct {
 	*leaf
 	Opts index.RangeOpts
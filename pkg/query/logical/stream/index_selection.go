@@ -0,0 +1,225 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"sort"
+
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+	"github.com/apache/skywalking-banyandb/pkg/query/model"
+)
+
+// The cost model measures the cost in the unit of reading a row.
+// Scanning the series reads all their rows, while looking up the index reads the posting lists of the leaves,
+// and then the rows they match.
+const (
+	// indexLookupCost is the cost of looking up a leaf of the index filter in a series.
+	indexLookupCost = 64
+	// maxBranchSelectivity is the fraction of the rows above which a branch of an AND isn't worth looking up,
+	// since the tag filter checks the rows anyway.
+	maxBranchSelectivity = 0.5
+	// unknownSelectivity is the selectivity of the filters whose rows the statistics can't estimate,
+	// like the ones matching the analyzed text or a pattern.
+	unknownSelectivity = 0.1
+)
+
+// scanMethod is how a local index scan reads the data.
+type scanMethod string
+
+const (
+	// scanByIndex looks up the rows in the inverted index before reading the blocks of the series.
+	scanByIndex scanMethod = "index"
+	// scanBySeries reads all blocks of the series, and leaves the conditions to the tag filter.
+	// The skipping index still skips the blocks in either method.
+	scanBySeries scanMethod = "series"
+)
+
+// estimation is the estimated result of an index filter.
+type estimation struct {
+	// rows is the number of the rows the filter matches.
+	rows float64
+	// postings is the number of the rows in the posting lists the filter reads.
+	postings float64
+	// lookups is the number of the index lookups the filter takes in a series.
+	lookups int
+	// known reports whether the rows are estimated by the statistics rather than guessed.
+	known bool
+}
+
+// costEstimator estimates the cost of the index filters by the statistics of the segments.
+type costEstimator struct {
+	stats  *model.Statistics
+	rows   float64
+	series float64
+}
+
+func newCostEstimator(stats *model.Statistics) *costEstimator {
+	ce := &costEstimator{stats: stats}
+	for _, s := range stats.Segments {
+		ce.rows += float64(s.Rows)
+		ce.series += float64(s.Series)
+	}
+	return ce
+}
+
+// selectScan chooses the scan method of the filter, which is reordered and pruned to look up the most selective branches first.
+// The filter is nil if the series are scanned.
+func (ce *costEstimator) selectScan(filter index.Filter, entities [][]*modelv1.TagValue) (index.Filter, scanMethod, estimation, error) {
+	if err := ce.reorder(filter); err != nil {
+		return nil, "", estimation{}, err
+	}
+	est, err := ce.estimate(filter)
+	if err != nil {
+		return nil, "", estimation{}, err
+	}
+	// The fraction of the data in the series the query targets.
+	fraction := 1.0
+	targets := ce.series
+	if n := logical.TargetedSeries(entities); n > 0 && n < ce.series {
+		targets = n
+		fraction = n / ce.series
+	}
+	seriesCost := ce.rows * fraction
+	indexCost := indexLookupCost*targets*float64(est.lookups) + (est.postings+est.rows)*fraction
+	if est.known && seriesCost <= indexCost {
+		return nil, scanBySeries, est, nil
+	}
+	return filter, scanByIndex, est, nil
+}
+
+// reorder sorts the branches of the ANDs in the filter by their selectivity,
+// and prunes the branches matching too many rows to be worth looking up.
+func (ce *costEstimator) reorder(filter index.Filter) error {
+	var sub []index.Filter
+	switch n := filter.(type) {
+	case *andNode:
+		sub = n.SubNodes
+	case *orNode:
+		sub = n.SubNodes
+	default:
+		// Pruning the branches under a NOT would narrow the rows it matches, so that it's left as is.
+		return nil
+	}
+	ee := make([]estimation, len(sub))
+	for i := range sub {
+		if err := ce.reorder(sub[i]); err != nil {
+			return err
+		}
+		var err error
+		if ee[i], err = ce.estimate(sub[i]); err != nil {
+			return err
+		}
+	}
+	and, ok := filter.(*andNode)
+	if !ok {
+		return nil
+	}
+	idx := make([]int, len(sub))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool { return ee[idx[i]].rows < ee[idx[j]].rows })
+	pruned := make([]index.Filter, 0, len(sub))
+	for _, i := range idx {
+		// The most selective branch is always looked up, and the tag filter checks the pruned ones.
+		if len(pruned) > 0 && ee[i].known && ee[i].rows > maxBranchSelectivity*ce.rows {
+			continue
+		}
+		pruned = append(pruned, sub[i])
+	}
+	and.SubNodes = pruned
+	return nil
+}
+
+func (ce *costEstimator) estimate(filter index.Filter) (estimation, error) {
+	switch n := filter.(type) {
+	case *andNode:
+		// An AND matches no more rows than its most selective branch.
+		est := estimation{rows: ce.rows, known: true}
+		for _, sn := range n.SubNodes {
+			e, err := ce.estimate(sn)
+			if err != nil {
+				return estimation{}, err
+			}
+			est.lookups += e.lookups
+			est.postings += e.postings
+			est.known = est.known && e.known
+			if e.rows < est.rows {
+				est.rows = e.rows
+			}
+		}
+		return est, nil
+	case *orNode:
+		est := estimation{known: true}
+		for _, sn := range n.SubNodes {
+			e, err := ce.estimate(sn)
+			if err != nil {
+				return estimation{}, err
+			}
+			est.lookups += e.lookups
+			est.postings += e.postings
+			est.known = est.known && e.known
+			est.rows += e.rows
+		}
+		if est.rows > ce.rows {
+			est.rows = ce.rows
+		}
+		return est, nil
+	case *not:
+		e, err := ce.estimate(n.Inner)
+		if err != nil {
+			return estimation{}, err
+		}
+		rows := ce.rows - e.rows
+		if rows < 0 {
+			rows = 0
+		}
+		// A NOT reads all rows having the field besides the ones of its inner filter.
+		return estimation{rows: rows, postings: e.postings + ce.rows, lookups: e.lookups + 1, known: e.known}, nil
+	case *eq:
+		term := n.Expr.Field(n.Key.toIndex(0, nil)).GetTerm()
+		if term == nil {
+			break
+		}
+		return ce.estimateTerms(n.Key, index.RangeOpts{Lower: term, Upper: term, IncludesLower: true, IncludesUpper: true})
+	case *rangeOp:
+		return ce.estimateTerms(n.Key, n.Opts)
+	case *emptyNode:
+		return estimation{rows: ce.rows, known: true}, nil
+	}
+	rows := ce.rows * unknownSelectivity
+	return estimation{rows: rows, postings: rows, lookups: 1}, nil
+}
+
+func (ce *costEstimator) estimateTerms(key fieldKey, opts index.RangeOpts) (estimation, error) {
+	est := estimation{lookups: 1, known: true}
+	fk := key.toIndex(0, nil)
+	for _, s := range ce.stats.Segments {
+		for _, idx := range s.Indexes {
+			n, err := idx.TermDocCount(fk, opts)
+			if err != nil {
+				return estimation{}, err
+			}
+			est.rows += float64(n)
+		}
+	}
+	est.postings = est.rows
+	return est, nil
+}
@@ -0,0 +1,134 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+	"github.com/apache/skywalking-banyandb/pkg/query/model"
+)
+
+// termStatistics counts the documents of the terms by the index rule ID.
+type termStatistics map[uint32]map[string]uint64
+
+func (ts termStatistics) DocCount() (uint64, error) {
+	var n uint64
+	for _, terms := range ts {
+		for _, c := range terms {
+			n += c
+		}
+	}
+	return n, nil
+}
+
+func (ts termStatistics) TermDocCount(fieldKey index.FieldKey, opts index.RangeOpts) (uint64, error) {
+	return ts[fieldKey.IndexRuleID][opts.Lower.String()], nil
+}
+
+func newTestEq(t *testing.T, id uint32, tag, value string) *eq {
+	expr, err := logical.ParseExpr(&modelv1.Condition{
+		Name:  tag,
+		Op:    modelv1.Condition_BINARY_OP_EQ,
+		Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: value}}},
+	})
+	require.NoError(t, err)
+	return newEq(&databasev1.IndexRule{
+		Metadata: &commonv1.Metadata{Id: id, Name: tag, Group: "default"},
+		Tags:     []string{tag},
+		Type:     databasev1.IndexRule_TYPE_INVERTED,
+	}, expr)
+}
+
+func TestCostEstimatorSelectScan(t *testing.T) {
+	stats := model.NewStatistics([]model.SegmentStatistics{
+		{
+			Indexes: []index.Statistics{termStatistics{
+				1: {"svc1": 60000, "svc2": 10000},
+				2: {"/a": 3},
+			}},
+			Series: 5,
+			Rows:   70000,
+		},
+		{
+			Indexes: []index.Statistics{termStatistics{
+				1: {"svc1": 30000},
+				2: {"/a": 2},
+			}},
+			Series: 5,
+			Rows:   30000,
+		},
+	}, nil)
+	anyEntity := [][]*modelv1.TagValue{{pbv1.AnyTagValue}}
+
+	t.Run("prune the unselective branches of an AND", func(t *testing.T) {
+		service, endpoint := newTestEq(t, 1, "service", "svc1"), newTestEq(t, 2, "endpoint", "/a")
+		and := newAnd(2)
+		and.append(service).append(endpoint)
+		filter, scan, est, err := newCostEstimator(stats).selectScan(and, anyEntity)
+		require.NoError(t, err)
+		assert.Equal(t, scanByIndex, scan)
+		assert.Equal(t, and, filter)
+		assert.Equal(t, []index.Filter{endpoint}, and.SubNodes)
+		assert.InDelta(t, 5, est.rows, 0)
+		assert.Equal(t, 1, est.lookups)
+	})
+
+	t.Run("reorder the branches of an AND by selectivity", func(t *testing.T) {
+		service, endpoint := newTestEq(t, 1, "service", "svc2"), newTestEq(t, 2, "endpoint", "/a")
+		and := newAnd(2)
+		and.append(service).append(endpoint)
+		_, scan, _, err := newCostEstimator(stats).selectScan(and, anyEntity)
+		require.NoError(t, err)
+		assert.Equal(t, scanByIndex, scan)
+		assert.Equal(t, []index.Filter{endpoint, service}, and.SubNodes)
+	})
+
+	t.Run("scan the series if the index matches most rows", func(t *testing.T) {
+		filter, scan, est, err := newCostEstimator(stats).selectScan(newTestEq(t, 1, "service", "svc1"), anyEntity)
+		require.NoError(t, err)
+		assert.Equal(t, scanBySeries, scan)
+		assert.Nil(t, filter)
+		assert.InDelta(t, 90000, est.rows, 0)
+	})
+
+	t.Run("scan the targeted series", func(t *testing.T) {
+		svc := &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "svc"}}}
+		endpoint := newTestEq(t, 2, "endpoint", "/a")
+		filter, scan, _, err := newCostEstimator(stats).selectScan(endpoint, [][]*modelv1.TagValue{{svc}})
+		require.NoError(t, err)
+		assert.Equal(t, scanByIndex, scan)
+		assert.Equal(t, endpoint, filter)
+		// Looking up the index costs more than reading the few rows of the only series the query targets.
+		_, scan, _, err = newCostEstimator(model.NewStatistics([]model.SegmentStatistics{{
+			Indexes: []index.Statistics{termStatistics{2: {"/a": 1}}},
+			Series:  1000,
+			Rows:    50000,
+		}}, nil)).selectScan(endpoint, [][]*modelv1.TagValue{{svc}})
+		require.NoError(t, err)
+		assert.Equal(t, scanBySeries, scan)
+	})
+}
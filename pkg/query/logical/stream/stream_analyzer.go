@@ -20,10 +20,13 @@ package stream
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"time"
 
 	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
 	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query"
 	"github.com/apache/skywalking-banyandb/pkg/query/executor"
 	"github.com/apache/skywalking-banyandb/pkg/query/logical"
 )
@@ -47,7 +50,9 @@ func BuildSchema(sm *databasev1.Stream, indexRules []*databasev1.IndexRule) (log
 }
 
 // Analyze converts logical expressions to executable operation tree represented by Plan.
-func Analyze(criteria *streamv1.QueryRequest, metadata []*commonv1.Metadata, ss []logical.Schema, ecc []executor.StreamExecutionContext) (logical.Plan, error) {
+func Analyze(ctx context.Context, criteria *streamv1.QueryRequest, metadata []*commonv1.Metadata,
+	ss []logical.Schema, ecc []executor.StreamExecutionContext,
+) (logical.Plan, error) {
 	// parse fields
 	if len(metadata) != len(ss) {
 		return nil, fmt.Errorf("number of schemas %d not equal to number of metadata %d", len(ss), len(metadata))
@@ -85,6 +90,7 @@ func Analyze(criteria *streamv1.QueryRequest, metadata []*commonv1.Metadata, ss
 	rules := []logical.OptimizeRule{
 		logical.NewPushDownOrder(criteria.OrderBy),
 		logical.NewPushDownMaxSize(int(limitParameter + criteria.GetOffset())),
+		logical.NewSelectIndexByCost(ctx),
 	}
 	if err := logical.ApplyRules(p, rules...); err != nil {
 		return nil, err
@@ -137,7 +143,12 @@ func (l *limit) Close() {
 	l.Parent.Input.(executor.StreamExecutable).Close()
 }
 
-func (l *limit) Execute(ec context.Context) ([]*streamv1.Element, error) {
+func (l *limit) Execute(ec context.Context) (ee []*streamv1.Element, err error) {
+	stats, ec := query.StartOperator(ec, l)
+	defer func(start time.Time) {
+		stats.AddRows(len(ee))
+		stats.Observe(start)
+	}(time.Now())
 	var allEntities []*streamv1.Element
 	targetCount := int(l.limitNum)
 	offset := int(l.offsetNum)
@@ -188,6 +199,10 @@ func (l *limit) String() string {
 	return fmt.Sprintf("%s Limit: %d", l.Input.String(), l.limitNum)
 }
 
+func (l *limit) Explain() *commonv1.PlanNode {
+	return logical.NewPlanNode("Limit", "offset", strconv.FormatUint(uint64(l.offsetNum), 10), "limit", strconv.FormatUint(uint64(l.limitNum), 10))
+}
+
 func (l *limit) Children() []logical.Plan {
 	return []logical.Plan{l.Input}
 }
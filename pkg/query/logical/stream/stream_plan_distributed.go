@@ -20,12 +20,15 @@ package stream
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"go.uber.org/multierr"
 	"google.golang.org/protobuf/proto"
 
 	"github.com/apache/skywalking-banyandb/api/data"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
 	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
 	"github.com/apache/skywalking-banyandb/pkg/bus"
@@ -132,12 +135,20 @@ type distributedPlan struct {
 func (t *distributedPlan) Close() {}
 
 func (t *distributedPlan) Execute(ctx context.Context) (ee []*streamv1.Element, err error) {
+	stats, ctx := query.StartOperator(ctx, t)
+	defer func(start time.Time) {
+		stats.AddRows(len(ee))
+		stats.Observe(start)
+	}(time.Now())
 	dctx := executor.FromDistributedExecutionContext(ctx)
 	queryRequest := proto.Clone(t.queryTemplate).(*streamv1.QueryRequest)
 	queryRequest.TimeRange = dctx.TimeRange()
 	if t.maxElementSize > 0 {
 		queryRequest.Limit = t.maxElementSize
 	}
+	if stats != nil {
+		queryRequest.Explain = commonv1.ExplainMode_EXPLAIN_MODE_ANALYZE
+	}
 	tracer := query.GetTracer(ctx)
 	var span *query.Span
 	if tracer != nil {
@@ -171,6 +182,9 @@ func (t *distributedPlan) Execute(ctx context.Context) (ee []*streamv1.Element,
 			if span != nil {
 				span.AddSubTrace(resp.Trace)
 			}
+			if resp.Plan != nil {
+				stats.AddSubPlan(logical.NewDataNodePlan(m.Node(), resp.Plan))
+			}
 			see = append(see,
 				newSortableElements(resp.Elements, t.sortByTime, t.sortTagSpec))
 		}
@@ -193,6 +207,18 @@ func (t *distributedPlan) String() string {
 	return fmt.Sprintf("distributed:%s", t.queryTemplate.String())
 }
 
+func (t *distributedPlan) Explain() *commonv1.PlanNode {
+	node := logical.NewPlanNode("Distributed", "groups", strings.Join(t.queryTemplate.GetGroups(), ","), "name", t.queryTemplate.GetName(),
+		"sort_by_time", strconv.FormatBool(t.sortByTime), "desc", strconv.FormatBool(t.desc))
+	if name := t.sortTagSpec.Spec.GetName(); name != "" {
+		node.Attributes = append(node.Attributes, &commonv1.Tag{Key: "sort_tag", Value: name})
+	}
+	if t.maxElementSize > 0 {
+		node.Attributes = append(node.Attributes, &commonv1.Tag{Key: "limit", Value: strconv.FormatUint(uint64(t.maxElementSize), 10)})
+	}
+	return node
+}
+
 func (t *distributedPlan) Children() []logical.Plan {
 	return []logical.Plan{}
 }
@@ -291,7 +317,12 @@ func (l *distributedLimit) Close() {
 	l.Parent.Input.(executor.StreamExecutable).Close()
 }
 
-func (l *distributedLimit) Execute(ec context.Context) ([]*streamv1.Element, error) {
+func (l *distributedLimit) Execute(ec context.Context) (ee []*streamv1.Element, err error) {
+	stats, ec := query.StartOperator(ec, l)
+	defer func(start time.Time) {
+		stats.AddRows(len(ee))
+		stats.Observe(start)
+	}(time.Now())
 	entities, err := l.Parent.Input.(executor.StreamExecutable).Execute(ec)
 	if err != nil {
 		return nil, err
@@ -326,6 +357,10 @@ func (l *distributedLimit) String() string {
 	return fmt.Sprintf("%s Distributed Limit: %d, %d", l.Input.String(), l.offset, l.limit)
 }
 
+func (l *distributedLimit) Explain() *commonv1.PlanNode {
+	return logical.NewPlanNode("DistributedLimit", "offset", strconv.FormatUint(uint64(l.offset), 10), "limit", strconv.FormatUint(uint64(l.limit), 10))
+}
+
 func (l *distributedLimit) Children() []logical.Plan {
 	return []logical.Plan{l.Input}
 }
@@ -21,6 +21,7 @@ import (
 	"context"
 	"encoding/hex"
 	"fmt"
+	"strconv"
 	"time"
 
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -31,6 +32,7 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/convert"
 	"github.com/apache/skywalking-banyandb/pkg/index"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
+	"github.com/apache/skywalking-banyandb/pkg/query"
 	"github.com/apache/skywalking-banyandb/pkg/query/executor"
 	"github.com/apache/skywalking-banyandb/pkg/query/logical"
 	"github.com/apache/skywalking-banyandb/pkg/query/model"
@@ -41,6 +43,7 @@ var (
 	_ logical.Plan              = (*localIndexScan)(nil)
 	_ logical.Sorter            = (*localIndexScan)(nil)
 	_ logical.VolumeLimiter     = (*localIndexScan)(nil)
+	_ logical.IndexSelector     = (*localIndexScan)(nil)
 	_ executor.StreamExecutable = (*localIndexScan)(nil)
 )
 
@@ -54,9 +57,11 @@ type localIndexScan struct {
 	metadata          *commonv1.Metadata
 	l                 *logger.Logger
 	timeRange         timestamp.TimeRange
+	scan              scanMethod
 	projectionTagRefs [][]*logical.TagRef
 	projectionTags    []model.TagProjection
 	entities          [][]*modelv1.TagValue
+	estimatedRows     float64
 	maxElementSize    int
 }
 
@@ -74,7 +79,39 @@ func (i *localIndexScan) Sort(order *logical.OrderBy) {
 	i.order = order
 }
 
-func (i *localIndexScan) Execute(ctx context.Context) ([]*streamv1.Element, error) {
+// SelectIndex chooses to scan the series rather than look up the inverted index if the index isn't selective enough,
+// and lets the index look up the most selective conditions first.
+func (i *localIndexScan) SelectIndex(ctx context.Context) error {
+	if i.invertedFilter == nil || i.invertedFilter == ENode {
+		return nil
+	}
+	sp, ok := i.ec.(executor.StatisticsProvider)
+	if !ok {
+		return nil
+	}
+	stats, err := sp.Statistics(ctx, i.timeRange)
+	if err != nil {
+		return err
+	}
+	defer stats.Release()
+	ce := newCostEstimator(stats)
+	if ce.rows == 0 {
+		return nil
+	}
+	filter, scan, est, err := ce.selectScan(i.invertedFilter, i.entities)
+	if err != nil {
+		return err
+	}
+	i.invertedFilter, i.scan, i.estimatedRows = filter, scan, est.rows
+	return nil
+}
+
+func (i *localIndexScan) Execute(ctx context.Context) (ee []*streamv1.Element, err error) {
+	stats, ctx := query.StartOperator(ctx, i)
+	defer func(start time.Time) {
+		stats.AddRows(len(ee))
+		stats.Observe(start)
+	}(time.Now())
 	select {
 	case <-ctx.Done():
 		return nil, ctx.Err()
@@ -90,7 +127,6 @@ func (i *localIndexScan) Execute(ctx context.Context) ([]*streamv1.Element, erro
 			Sort:  i.order.Sort,
 		}
 	}
-	var err error
 	if i.result, err = i.ec.Query(ctx, model.StreamQueryOptions{
 		Name:           i.metadata.GetName(),
 		TimeRange:      &i.timeRange,
@@ -115,6 +151,26 @@ func (i *localIndexScan) String() string {
 		i.invertedFilter, logical.FormatTagRefs(", ", i.projectionTagRefs...), i.order, i.maxElementSize)
 }
 
+func (i *localIndexScan) Explain() *commonv1.PlanNode {
+	node := logical.NewPlanNode("IndexScan", "group", i.metadata.GetGroup(), "name", i.metadata.GetName(),
+		"time_range", i.timeRange.String(), "conditions", fmt.Sprintf("%v", i.invertedFilter),
+		"projection", logical.FormatTagRefs(", ", i.projectionTagRefs...))
+	if i.skippingFilter != nil {
+		node.Attributes = append(node.Attributes, &commonv1.Tag{Key: "skipping_conditions", Value: i.skippingFilter.String()})
+	}
+	if i.order != nil {
+		node.Attributes = append(node.Attributes, &commonv1.Tag{Key: "order", Value: i.order.String()})
+	}
+	if i.maxElementSize > 0 {
+		node.Attributes = append(node.Attributes, &commonv1.Tag{Key: "limit", Value: strconv.Itoa(i.maxElementSize)})
+	}
+	if i.scan != "" {
+		node.Attributes = append(node.Attributes, &commonv1.Tag{Key: "scan", Value: string(i.scan)},
+			&commonv1.Tag{Key: "estimated_rows", Value: strconv.FormatFloat(i.estimatedRows, 'f', 0, 64)})
+	}
+	return node
+}
+
 func (i *localIndexScan) Children() []logical.Plan {
 	return []logical.Plan{}
 }
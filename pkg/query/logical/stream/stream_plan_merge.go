@@ -20,6 +20,8 @@ package stream
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"time"
 
 	"go.uber.org/multierr"
 
@@ -27,6 +29,7 @@ import (
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
 	streamv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/stream/v1"
 	"github.com/apache/skywalking-banyandb/pkg/iter/sort"
+	"github.com/apache/skywalking-banyandb/pkg/query"
 	"github.com/apache/skywalking-banyandb/pkg/query/executor"
 	"github.com/apache/skywalking-banyandb/pkg/query/logical"
 	"github.com/apache/skywalking-banyandb/pkg/query/model"
@@ -124,7 +127,12 @@ func (m *mergePlan) Close() {
 }
 
 // Execute implements executor.StreamExecutable.
-func (m *mergePlan) Execute(ctx context.Context) ([]*streamv1.Element, error) {
+func (m *mergePlan) Execute(ctx context.Context) (ee []*streamv1.Element, err error) {
+	stats, ctx := query.StartOperator(ctx, m)
+	defer func(start time.Time) {
+		stats.AddRows(len(ee))
+		stats.Observe(start)
+	}(time.Now())
 	var allErr error
 	var see []sort.Iterator[*comparableElement]
 
@@ -149,6 +157,11 @@ func (m *mergePlan) Execute(ctx context.Context) ([]*streamv1.Element, error) {
 }
 
 // Children implements logical.Plan.
+func (m *mergePlan) Explain() *commonv1.PlanNode {
+	return logical.NewPlanNode("Merge", "sub_plans", strconv.Itoa(len(m.subPlans)), "sort_by_time", strconv.FormatBool(m.sortByTime),
+		"desc", strconv.FormatBool(m.desc), "sort_tag", m.sortTagSpec.Spec.GetName())
+}
+
 func (m *mergePlan) Children() []logical.Plan {
 	return m.subPlans
 }
@@ -29,6 +29,7 @@ import (
 	"github.com/apache/skywalking-banyandb/pkg/index"
 	"github.com/apache/skywalking-banyandb/pkg/logger"
 	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query"
 	"github.com/apache/skywalking-banyandb/pkg/query/executor"
 	"github.com/apache/skywalking-banyandb/pkg/query/logical"
 	"github.com/apache/skywalking-banyandb/pkg/query/model"
@@ -163,7 +164,12 @@ func newTagFilter(s logical.Schema, parent logical.Plan, tagFilter logical.TagFi
 	}
 }
 
-func (t *tagFilterPlan) Execute(ec context.Context) ([]*streamv1.Element, error) {
+func (t *tagFilterPlan) Execute(ec context.Context) (ee []*streamv1.Element, err error) {
+	stats, ec := query.StartOperator(ec, t)
+	defer func(start time.Time) {
+		stats.AddRows(len(ee))
+		stats.Observe(start)
+	}(time.Now())
 	var filteredElements []*streamv1.Element
 
 	for {
@@ -195,6 +201,10 @@ func (t *tagFilterPlan) String() string {
 	return fmt.Sprintf("%s tag-filter:%s", t.parent, t.tagFilter.String())
 }
 
+func (t *tagFilterPlan) Explain() *commonv1.PlanNode {
+	return logical.NewPlanNode("TagFilter", "filter", t.tagFilter.String())
+}
+
 func (t *tagFilterPlan) Children() []logical.Plan {
 	return []logical.Plan{t.parent}
 }
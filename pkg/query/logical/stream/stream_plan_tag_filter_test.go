@@ -0,0 +1,126 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package stream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	"github.com/apache/skywalking-banyandb/pkg/index"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+	"github.com/apache/skywalking-banyandb/pkg/query/model"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+// statisticsContext is an execution context which only provides the statistics.
+type statisticsContext struct {
+	stats *model.Statistics
+}
+
+func (sc statisticsContext) Query(context.Context, model.StreamQueryOptions) (model.StreamQueryResult, error) {
+	return nil, nil
+}
+
+func (sc statisticsContext) Statistics(context.Context, timestamp.TimeRange) (*model.Statistics, error) {
+	return sc.stats, nil
+}
+
+func strCondition(name, value string) *modelv1.Criteria {
+	return &modelv1.Criteria{Exp: &modelv1.Criteria_Condition{Condition: &modelv1.Condition{
+		Name:  name,
+		Op:    modelv1.Condition_BINARY_OP_EQ,
+		Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: value}}},
+	}}}
+}
+
+func TestTagFilterChecksConditionsLeftOutOfIndexScan(t *testing.T) {
+	indexRule := func(id uint32, tag string) *databasev1.IndexRule {
+		return &databasev1.IndexRule{
+			Metadata: &commonv1.Metadata{Id: id, Name: tag, Group: "default"},
+			Tags:     []string{tag},
+			Type:     databasev1.IndexRule_TYPE_INVERTED,
+		}
+	}
+	s, err := BuildSchema(&databasev1.Stream{
+		Metadata: &commonv1.Metadata{Name: "sw", Group: "default"},
+		Entity:   &databasev1.Entity{TagNames: []string{"service_id"}},
+		TagFamilies: []*databasev1.TagFamilySpec{{
+			Name: "searchable",
+			Tags: []*databasev1.TagSpec{
+				{Name: "service_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+				{Name: "endpoint", Type: databasev1.TagType_TAG_TYPE_STRING},
+				{Name: "status", Type: databasev1.TagType_TAG_TYPE_STRING},
+			},
+		}},
+	}, []*databasev1.IndexRule{indexRule(1, "endpoint"), indexRule(2, "status")})
+	require.NoError(t, err)
+	// Both conditions match most rows, so that the index scan reads all rows of the series.
+	ec := statisticsContext{stats: model.NewStatistics([]model.SegmentStatistics{{
+		Indexes: []index.Statistics{termStatistics{
+			1: {"/a": 90},
+			2: {"200": 95},
+		}},
+		Series: 1,
+		Rows:   100,
+	}}, nil)}
+	criteria := &modelv1.Criteria{Exp: &modelv1.Criteria_Le{Le: &modelv1.LogicalExpression{
+		Op:    modelv1.LogicalExpression_LOGICAL_OP_AND,
+		Left:  strCondition("endpoint", "/a"),
+		Right: strCondition("status", "200"),
+	}}}
+	now := time.Now()
+	plan, err := tagFilter(now.Add(-time.Hour), now, &commonv1.Metadata{Name: "sw", Group: "default"}, criteria,
+		[][]*logical.Tag{logical.NewTags("searchable", "endpoint", "status")}, ec).Analyze(s)
+	require.NoError(t, err)
+	tf, ok := plan.(*tagFilterPlan)
+	require.True(t, ok, "the conditions on the indexed tags are checked by the tag filter")
+	scan := tf.parent.(*localIndexScan)
+	require.NoError(t, scan.SelectIndex(context.Background()))
+	assert.Equal(t, scanBySeries, scan.scan)
+	assert.Nil(t, scan.invertedFilter)
+
+	tagFamilies := func(endpoint, status string) logical.TagFamilies {
+		return logical.TagFamilies{{
+			Name: "searchable",
+			Tags: []*modelv1.Tag{
+				{Key: "endpoint", Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: endpoint}}}},
+				{Key: "status", Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: status}}}},
+			},
+		}}
+	}
+	for _, tc := range []struct {
+		endpoint string
+		status   string
+		want     bool
+	}{
+		{endpoint: "/a", status: "200", want: true},
+		{endpoint: "/b", status: "200"},
+		{endpoint: "/a", status: "500"},
+	} {
+		matched, errMatch := tf.tagFilter.Match(tagFamilies(tc.endpoint, tc.status), tf.s)
+		require.NoError(t, errMatch)
+		assert.Equal(t, tc.want, matched, "endpoint=%s, status=%s", tc.endpoint, tc.status)
+	}
+}
@@ -27,6 +27,7 @@ import (
 
 	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
 	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/index"
 	"github.com/apache/skywalking-banyandb/pkg/index/analyzer"
 )
 
@@ -162,6 +163,19 @@ func parseFilter(cond *modelv1.Condition, expr ComparableExpr, indexChecker Inde
 		return newInTag(cond.Name, expr), nil
 	case modelv1.Condition_BINARY_OP_NOT_IN:
 		return newNotTag(newInTag(cond.Name, expr)), nil
+	case modelv1.Condition_BINARY_OP_SUBTREE, modelv1.Condition_BINARY_OP_ANCESTORS:
+		path, err := ParseTreePath(cond)
+		if err != nil {
+			return nil, err
+		}
+		_, indexRule := indexChecker.IndexDefined(cond.Name)
+		return newTreeTag(cond.Name, expr, cond.Op, path, index.TreeSeparator(indexRule)), nil
+	case modelv1.Condition_BINARY_OP_PREFIX, modelv1.Condition_BINARY_OP_WILDCARD, modelv1.Condition_BINARY_OP_REGEX:
+		pattern, err := ParsePattern(cond)
+		if err != nil {
+			return nil, err
+		}
+		return newPatternTag(cond.Name, expr, pattern), nil
 	default:
 		return nil, errors.WithMessagef(ErrUnsupportedConditionOp, "tag filter parses %v", cond)
 	}
@@ -193,6 +207,10 @@ func parseExpr(value *modelv1.TagValue, analyzer *analysis.Analyzer) (Comparable
 		return &int64Literal{
 			int64: v.Int.GetValue(),
 		}, nil
+	case *modelv1.TagValue_Float:
+		return &float64Literal{
+			float64: v.Float.GetValue(),
+		}, nil
 	case *modelv1.TagValue_IntArray:
 		return &int64ArrLiteral{
 			arr: v.IntArray.GetValue(),
@@ -575,3 +593,112 @@ func (h *havingTag) MarshalJSON() ([]byte, error) {
 func (h *havingTag) String() string {
 	return convert.JSONToString(h)
 }
+
+type treeTag struct {
+	*tagLeaf
+	sep  string
+	path string
+	op   modelv1.Condition_BinaryOp
+}
+
+func newTreeTag(tagName string, values LiteralExpr, op modelv1.Condition_BinaryOp, path, sep string) *treeTag {
+	return &treeTag{
+		tagLeaf: &tagLeaf{
+			Name: tagName,
+			Expr: values,
+		},
+		op:   op,
+		path: path,
+		sep:  sep,
+	}
+}
+
+func (t *treeTag) Match(accessor TagValueIndexAccessor, registry TagSpecRegistry) (bool, error) {
+	expr, err := tagExpr(accessor, registry, t.Name, nil)
+	if err != nil {
+		return false, err
+	}
+	var values []string
+	switch v := expr.(type) {
+	case *strLiteral:
+		values = []string{v.string}
+	case *strArrLiteral:
+		values = v.arr
+	}
+	var ancestors []string
+	if t.op == modelv1.Condition_BINARY_OP_ANCESTORS {
+		ancestors = index.TreePaths(t.path, t.sep)
+	}
+	for _, v := range values {
+		if t.op == modelv1.Condition_BINARY_OP_SUBTREE {
+			if index.InSubtree(v, t.path, t.sep) {
+				return true, nil
+			}
+			continue
+		}
+		v = index.NormalizeTreePath(v, t.sep)
+		for _, a := range ancestors {
+			if a == v {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func (t *treeTag) MarshalJSON() ([]byte, error) {
+	data := make(map[string]interface{}, 1)
+	if t.op == modelv1.Condition_BINARY_OP_SUBTREE {
+		data["subtree"] = t.tagLeaf
+	} else {
+		data["ancestors"] = t.tagLeaf
+	}
+	return json.Marshal(data)
+}
+
+func (t *treeTag) String() string {
+	return convert.JSONToString(t)
+}
+
+type patternTag struct {
+	*tagLeaf
+	pattern *index.Pattern
+}
+
+func newPatternTag(tagName string, values LiteralExpr, pattern *index.Pattern) *patternTag {
+	return &patternTag{
+		tagLeaf: &tagLeaf{
+			Name: tagName,
+			Expr: values,
+		},
+		pattern: pattern,
+	}
+}
+
+func (p *patternTag) Match(accessor TagValueIndexAccessor, registry TagSpecRegistry) (bool, error) {
+	expr, err := tagExpr(accessor, registry, p.Name, nil)
+	if err != nil {
+		return false, err
+	}
+	switch v := expr.(type) {
+	case *strLiteral:
+		return p.pattern.Match(v.string), nil
+	case *strArrLiteral:
+		for _, s := range v.arr {
+			if p.pattern.Match(s) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func (p *patternTag) MarshalJSON() ([]byte, error) {
+	data := make(map[string]interface{}, 1)
+	data[p.pattern.Type.String()] = p.tagLeaf
+	return json.Marshal(data)
+}
+
+func (p *patternTag) String() string {
+	return convert.JSONToString(p)
+}
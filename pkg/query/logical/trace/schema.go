@@ -17,3 +17,155 @@
 
 // Package trace implements execution operations for querying trace data.
 package trace
+
+import (
+	"fmt"
+
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+)
+
+// traceTagFamilyName is the virtual tag family holding all tags of a trace.
+// The trace model has no tag families, so every tag is registered under this one.
+const traceTagFamilyName = ""
+
+var _ logical.Schema = (*schema)(nil)
+
+type schema struct {
+	trace            *databasev1.Trace
+	common           *logical.CommonSchema
+	traceIDTagName   string
+	timestampTagName string
+	children         []logical.Schema
+	// tagNames are the names of all the tags, which are returned if the projection is absent.
+	tagNames []string
+}
+
+// BuildSchema returns Schema loaded from the metadata repository.
+func BuildSchema(tr *databasev1.Trace, indexRules []*databasev1.IndexRule) (logical.Schema, error) {
+	s := &schema{
+		common: &logical.CommonSchema{
+			IndexRules: indexRules,
+			TagSpecMap: make(map[string]*logical.TagSpec),
+		},
+		trace:            tr,
+		traceIDTagName:   tr.GetTraceIdTagName(),
+		timestampTagName: tr.GetTimestampTagName(),
+	}
+	tagFamilies := toTagFamilySpecs(tr)
+	s.common.RegisterTagFamilies(tagFamilies)
+	s.tagNames = tagNamesOf(tagFamilies)
+	return s, nil
+}
+
+func toTagFamilySpecs(tr *databasev1.Trace) []*databasev1.TagFamilySpec {
+	tags := make([]*databasev1.TagSpec, 0, len(tr.GetTags()))
+	for _, t := range tr.GetTags() {
+		tags = append(tags, &databasev1.TagSpec{
+			Name: t.GetName(),
+			Type: t.GetType(),
+		})
+	}
+	return []*databasev1.TagFamilySpec{{Name: traceTagFamilyName, Tags: tags}}
+}
+
+func tagNamesOf(tagFamilies []*databasev1.TagFamilySpec) []string {
+	var names []string
+	for _, tf := range tagFamilies {
+		for _, t := range tf.GetTags() {
+			names = append(names, t.GetName())
+		}
+	}
+	return names
+}
+
+func (s *schema) FindTagSpecByName(name string) *logical.TagSpec {
+	return s.common.FindTagSpecByName(name)
+}
+
+func (s *schema) CreateFieldRef(_ ...*logical.Field) ([]*logical.FieldRef, error) {
+	panic("no field for trace")
+}
+
+func (s *schema) IndexRuleDefined(indexRuleName string) (bool, *databasev1.IndexRule) {
+	return s.common.IndexRuleDefined(indexRuleName)
+}
+
+func (s *schema) EntityList() []string {
+	return s.common.EntityList
+}
+
+// IndexDefined checks whether the field given is indexed.
+func (s *schema) IndexDefined(tagName string) (bool, *databasev1.IndexRule) {
+	return s.common.IndexDefined(tagName)
+}
+
+// CreateTagRef create TagRef to the given tags.
+func (s *schema) CreateTagRef(tags ...[]*logical.Tag) ([][]*logical.TagRef, error) {
+	return s.common.CreateRef(tags...)
+}
+
+// ProjTags creates a projection view from the present traceSchema
+// with a given list of projections.
+func (s *schema) ProjTags(refs ...[]*logical.TagRef) logical.Schema {
+	if len(refs) == 0 {
+		return nil
+	}
+	return &schema{
+		trace:            s.trace,
+		common:           s.common.ProjTags(refs...),
+		traceIDTagName:   s.traceIDTagName,
+		timestampTagName: s.timestampTagName,
+		tagNames:         s.tagNames,
+	}
+}
+
+func (s *schema) ProjFields(...*logical.FieldRef) logical.Schema {
+	panic("trace does not support field")
+}
+
+func (s *schema) Children() []logical.Schema {
+	return s.children
+}
+
+func mergeSchema(schemas []logical.Schema) (logical.Schema, error) {
+	if len(schemas) == 0 {
+		return nil, nil
+	}
+	if len(schemas) == 1 {
+		return schemas[0], nil
+	}
+	var commonSchemas []*logical.CommonSchema
+	var tagFamilies []*databasev1.TagFamilySpec
+	var traceIDTagName, timestampTagName string
+	for _, sm := range schemas {
+		if sm == nil {
+			continue
+		}
+		s := sm.(*schema)
+		if s == nil {
+			continue
+		}
+		if traceIDTagName == "" {
+			traceIDTagName, timestampTagName = s.traceIDTagName, s.timestampTagName
+		} else if traceIDTagName != s.traceIDTagName || timestampTagName != s.timestampTagName {
+			return nil, fmt.Errorf("traces have different trace id or timestamp tags: %s/%s vs %s/%s",
+				traceIDTagName, timestampTagName, s.traceIDTagName, s.timestampTagName)
+		}
+		tagFamilies = logical.MergeTagFamilySpecs(tagFamilies, toTagFamilySpecs(s.trace))
+		commonSchemas = append(commonSchemas, s.common)
+	}
+	merged, err := logical.MergeSchemas(commonSchemas)
+	if err != nil {
+		return nil, err
+	}
+	ret := &schema{
+		common:           merged,
+		children:         schemas,
+		traceIDTagName:   traceIDTagName,
+		timestampTagName: timestampTagName,
+	}
+	ret.common.RegisterTagFamilies(tagFamilies)
+	ret.tagNames = tagNamesOf(tagFamilies)
+	return ret, nil
+}
@@ -0,0 +1,61 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package trace
+
+import (
+	tracev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/trace/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+)
+
+const defaultLimit uint32 = 20
+
+// Parent refers to a parent node in the execution tree(plan).
+type Parent struct {
+	UnresolvedInput logical.UnresolvedPlan
+	Input           logical.Plan
+}
+
+// DistributedAnalyze converts logical expressions to executable operation tree represented by Plan.
+func DistributedAnalyze(criteria *tracev1.QueryRequest, ss []logical.Schema) (logical.Plan, error) {
+	var s logical.Schema
+	if len(ss) == 1 {
+		s = ss[0]
+	} else {
+		var err error
+		if s, err = mergeSchema(ss); err != nil {
+			return nil, err
+		}
+	}
+	plan := newUnresolvedDistributed(criteria)
+
+	// parse limit
+	limitParameter := criteria.GetLimit()
+	if limitParameter == 0 {
+		limitParameter = defaultLimit
+	}
+	plan = newDistributedLimit(plan, criteria.GetOffset(), limitParameter)
+	return plan.Analyze(s)
+}
+
+// ToTags converts a trace tag projection to Tag sets.
+func ToTags(projection []string) [][]*logical.Tag {
+	if len(projection) == 0 {
+		return nil
+	}
+	return [][]*logical.Tag{logical.NewTags(traceTagFamilyName, projection...)}
+}
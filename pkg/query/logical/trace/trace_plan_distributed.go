@@ -0,0 +1,408 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package trace
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/multierr"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/apache/skywalking-banyandb/api/data"
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	tracev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/trace/v1"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/convert"
+	"github.com/apache/skywalking-banyandb/pkg/iter/sort"
+	"github.com/apache/skywalking-banyandb/pkg/logger"
+	pbv1 "github.com/apache/skywalking-banyandb/pkg/pb/v1"
+	"github.com/apache/skywalking-banyandb/pkg/query"
+	"github.com/apache/skywalking-banyandb/pkg/query/executor"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+)
+
+const defaultQueryTimeout = 30 * time.Second
+
+var _ logical.UnresolvedPlan = (*unresolvedDistributed)(nil)
+
+type unresolvedDistributed struct {
+	originalQuery *tracev1.QueryRequest
+}
+
+func newUnresolvedDistributed(query *tracev1.QueryRequest) logical.UnresolvedPlan {
+	return &unresolvedDistributed{
+		originalQuery: query,
+	}
+}
+
+func (ud *unresolvedDistributed) Analyze(s logical.Schema) (logical.Plan, error) {
+	ts, ok := s.(*schema)
+	if !ok {
+		return nil, fmt.Errorf("unsupported schema type %T", s)
+	}
+	sortTagName, err := ud.sortTagName(ts)
+	if err != nil {
+		return nil, err
+	}
+	projection := ud.originalQuery.GetTagProjection()
+	if len(projection) == 0 {
+		// All the tags are returned if the projection is absent.
+		projection = ts.tagNames
+	}
+	if len(projection) > 0 {
+		projTagsRefs, errRef := s.CreateTagRef(ToTags(projection)...)
+		if errRef != nil {
+			return nil, errRef
+		}
+		s = s.ProjTags(projTagsRefs...)
+	}
+	limit := ud.originalQuery.GetLimit()
+	if limit == 0 {
+		limit = defaultLimit
+	}
+	// The sort tag and the trace ID tag are required to merge and deduplicate the spans returned by data nodes.
+	// They are appended to the projection pushed down to data nodes and removed after merging.
+	pushedProjection := slices.Clone(projection)
+	var hiddenTags []string
+	for _, name := range []string{sortTagName, ts.traceIDTagName} {
+		if !slices.Contains(pushedProjection, name) {
+			pushedProjection = append(pushedProjection, name)
+			hiddenTags = append(hiddenTags, name)
+		}
+	}
+	temp := &tracev1.QueryRequest{
+		TagProjection: pushedProjection,
+		Name:          ud.originalQuery.Name,
+		Groups:        ud.originalQuery.Groups,
+		Criteria:      ud.originalQuery.Criteria,
+		Limit:         limit + ud.originalQuery.Offset,
+		OrderBy:       ud.originalQuery.OrderBy,
+	}
+	return &distributedPlan{
+		queryTemplate:  temp,
+		s:              s,
+		sortTagName:    sortTagName,
+		traceIDTagName: ts.traceIDTagName,
+		hiddenTags:     hiddenTags,
+		desc:           ud.originalQuery.GetOrderBy().GetSort() == modelv1.Sort_SORT_DESC,
+	}, nil
+}
+
+func (ud *unresolvedDistributed) sortTagName(s *schema) (string, error) {
+	orderBy := ud.originalQuery.GetOrderBy()
+	if orderBy.GetIndexRuleName() == "" {
+		if s.timestampTagName == "" {
+			return "", fmt.Errorf("timestamp tag is not defined in trace %s", ud.originalQuery.GetName())
+		}
+		return s.timestampTagName, nil
+	}
+	ok, indexRule := s.IndexRuleDefined(orderBy.GetIndexRuleName())
+	if !ok {
+		return "", fmt.Errorf("index rule %s not found", orderBy.GetIndexRuleName())
+	}
+	if len(indexRule.Tags) != 1 {
+		return "", fmt.Errorf("index rule %s should have only one tag", orderBy.GetIndexRuleName())
+	}
+	if s.FindTagSpecByName(indexRule.Tags[0]) == nil {
+		return "", fmt.Errorf("tag %s not found", indexRule.Tags[0])
+	}
+	return indexRule.Tags[0], nil
+}
+
+var _ executor.TraceExecutable = (*distributedPlan)(nil)
+
+type distributedPlan struct {
+	s              logical.Schema
+	queryTemplate  *tracev1.QueryRequest
+	sortTagName    string
+	traceIDTagName string
+	hiddenTags     []string
+	maxSpanSize    uint32
+	desc           bool
+}
+
+func (t *distributedPlan) Close() {}
+
+func (t *distributedPlan) Execute(ctx context.Context) (spans []*tracev1.Span, err error) {
+	stats, ctx := query.StartOperator(ctx, t)
+	defer func(start time.Time) {
+		stats.AddRows(len(spans))
+		stats.Observe(start)
+	}(time.Now())
+	dctx := executor.FromDistributedExecutionContext(ctx)
+	queryRequest := proto.Clone(t.queryTemplate).(*tracev1.QueryRequest)
+	queryRequest.TimeRange = dctx.TimeRange()
+	if t.maxSpanSize > 0 {
+		queryRequest.Limit = t.maxSpanSize
+	}
+	if stats != nil {
+		queryRequest.Explain = commonv1.ExplainMode_EXPLAIN_MODE_ANALYZE
+	}
+	tracer := query.GetTracer(ctx)
+	var span *query.Span
+	if tracer != nil {
+		span, _ = tracer.StartSpan(ctx, "distributed-client")
+		queryRequest.Trace = true
+		span.Tag("request", convert.BytesToString(logger.Proto(queryRequest)))
+		defer func() {
+			if err != nil {
+				span.Error(err)
+			} else {
+				span.Stop()
+			}
+		}()
+	}
+	ff, err := dctx.Broadcast(defaultQueryTimeout, data.TopicTraceQuery,
+		bus.NewMessageWithNodeSelectors(bus.MessageID(dctx.TimeRange().Begin.Nanos), dctx.NodeSelectors(), dctx.TimeRange(), queryRequest))
+	if err != nil {
+		return nil, err
+	}
+	var allErr error
+	var see []sort.Iterator[*comparableSpan]
+	for _, f := range ff {
+		if m, getErr := f.Get(); getErr != nil {
+			allErr = multierr.Append(allErr, getErr)
+		} else {
+			d := m.Data()
+			if d == nil {
+				continue
+			}
+			resp := d.(*tracev1.QueryResponse)
+			if span != nil {
+				span.AddSubTrace(resp.TraceQueryResult)
+			}
+			if resp.Plan != nil {
+				stats.AddSubPlan(logical.NewDataNodePlan(m.Node(), resp.Plan))
+			}
+			see = append(see, newSortableSpans(resp.Spans, t.sortTagName))
+		}
+	}
+	iter := sort.NewItemIter(see, t.desc)
+	var result []*tracev1.Span
+	seen := make(map[spanKey]struct{})
+	for iter.Next() {
+		s := iter.Val().Span
+		// Replicas return identical spans, so they are deduplicated by the trace ID and the span data.
+		// Spans of different traces are kept even if their data are the same.
+		key := spanKey{
+			traceID: tagValue(s.Tags, t.traceIDTagName).GetStr().GetValue(),
+			span:    convert.BytesToString(s.Span),
+		}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		for _, name := range t.hiddenTags {
+			s.Tags = removeTag(s.Tags, name)
+		}
+		result = append(result, s)
+	}
+	return result, allErr
+}
+
+func (t *distributedPlan) String() string {
+	return fmt.Sprintf("distributed:%s", t.queryTemplate.String())
+}
+
+func (t *distributedPlan) Explain() *commonv1.PlanNode {
+	node := logical.NewPlanNode("Distributed", "groups", strings.Join(t.queryTemplate.GetGroups(), ","), "name", t.queryTemplate.GetName(),
+		"sort_tag", t.sortTagName, "desc", strconv.FormatBool(t.desc))
+	if t.maxSpanSize > 0 {
+		node.Attributes = append(node.Attributes, &commonv1.Tag{Key: "limit", Value: strconv.FormatUint(uint64(t.maxSpanSize), 10)})
+	}
+	return node
+}
+
+func (t *distributedPlan) Children() []logical.Plan {
+	return []logical.Plan{}
+}
+
+func (t *distributedPlan) Schema() logical.Schema {
+	return t.s
+}
+
+func (t *distributedPlan) Limit(maxVal int) {
+	t.maxSpanSize = uint32(maxVal)
+}
+
+type spanKey struct {
+	traceID string
+	span    string
+}
+
+func tagValue(tags []*modelv1.Tag, name string) *modelv1.TagValue {
+	for _, tag := range tags {
+		if tag.GetKey() == name {
+			return tag.GetValue()
+		}
+	}
+	return nil
+}
+
+func removeTag(tags []*modelv1.Tag, name string) []*modelv1.Tag {
+	return slices.DeleteFunc(tags, func(tag *modelv1.Tag) bool {
+		return tag.GetKey() == name
+	})
+}
+
+var _ sort.Comparable = (*comparableSpan)(nil)
+
+type comparableSpan struct {
+	*tracev1.Span
+	sortField []byte
+}
+
+func newComparableSpan(s *tracev1.Span, sortTagName string) (*comparableSpan, error) {
+	var sortField []byte
+	for _, tag := range s.Tags {
+		if tag.GetKey() != sortTagName {
+			continue
+		}
+		if ts := tag.GetValue().GetTimestamp(); ts != nil {
+			sortField = convert.Uint64ToBytes(uint64(ts.AsTime().UnixNano()))
+			break
+		}
+		var err error
+		sortField, err = pbv1.MarshalTagValue(tag.GetValue())
+		if err != nil {
+			return nil, err
+		}
+		break
+	}
+	return &comparableSpan{
+		Span:      s,
+		sortField: sortField,
+	}, nil
+}
+
+func (s *comparableSpan) SortedField() []byte {
+	return s.sortField
+}
+
+var _ sort.Iterator[*comparableSpan] = (*sortableSpans)(nil)
+
+type sortableSpans struct {
+	cur         *comparableSpan
+	sortTagName string
+	spans       []*tracev1.Span
+	index       int
+}
+
+func newSortableSpans(spans []*tracev1.Span, sortTagName string) *sortableSpans {
+	return &sortableSpans{
+		spans:       spans,
+		sortTagName: sortTagName,
+	}
+}
+
+func (*sortableSpans) Close() error {
+	return nil
+}
+
+func (s *sortableSpans) Next() bool {
+	for s.index < len(s.spans) {
+		cur, err := newComparableSpan(s.spans[s.index], s.sortTagName)
+		s.index++
+		if err != nil {
+			continue
+		}
+		s.cur = cur
+		return true
+	}
+	return false
+}
+
+func (s *sortableSpans) Val() *comparableSpan {
+	return s.cur
+}
+
+var _ executor.TraceExecutable = (*distributedLimit)(nil)
+
+type distributedLimit struct {
+	*Parent
+	limit  uint32
+	offset uint32
+}
+
+func (l *distributedLimit) Close() {
+	l.Parent.Input.(executor.TraceExecutable).Close()
+}
+
+func (l *distributedLimit) Execute(ec context.Context) (spans []*tracev1.Span, err error) {
+	stats, ec := query.StartOperator(ec, l)
+	defer func(start time.Time) {
+		stats.AddRows(len(spans))
+		stats.Observe(start)
+	}(time.Now())
+	spans, err = l.Parent.Input.(executor.TraceExecutable).Execute(ec)
+	if err != nil {
+		return nil, err
+	}
+
+	start := int(l.offset)
+	if start > len(spans) {
+		return []*tracev1.Span{}, nil
+	}
+
+	end := start + int(l.limit)
+	if end > len(spans) {
+		end = len(spans)
+	}
+	return spans[start:end], nil
+}
+
+func (l *distributedLimit) Analyze(s logical.Schema) (logical.Plan, error) {
+	var err error
+	l.Input, err = l.UnresolvedInput.Analyze(s)
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *distributedLimit) Schema() logical.Schema {
+	return l.Input.Schema()
+}
+
+func (l *distributedLimit) String() string {
+	return fmt.Sprintf("%s Distributed Limit: %d, %d", l.Input.String(), l.offset, l.limit)
+}
+
+func (l *distributedLimit) Explain() *commonv1.PlanNode {
+	return logical.NewPlanNode("DistributedLimit", "offset", strconv.FormatUint(uint64(l.offset), 10), "limit", strconv.FormatUint(uint64(l.limit), 10))
+}
+
+func (l *distributedLimit) Children() []logical.Plan {
+	return []logical.Plan{l.Input}
+}
+
+func newDistributedLimit(input logical.UnresolvedPlan, offset, limit uint32) logical.UnresolvedPlan {
+	return &distributedLimit{
+		Parent: &Parent{
+			UnresolvedInput: input,
+		},
+		offset: offset,
+		limit:  limit,
+	}
+}
@@ -0,0 +1,230 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package trace
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	tracev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/trace/v1"
+	"github.com/apache/skywalking-banyandb/pkg/bus"
+	"github.com/apache/skywalking-banyandb/pkg/query/executor"
+	"github.com/apache/skywalking-banyandb/pkg/query/logical"
+)
+
+type mockFuture struct {
+	resp *tracev1.QueryResponse
+}
+
+func (f *mockFuture) Get() (bus.Message, error) {
+	return bus.NewMessage(1, f.resp), nil
+}
+
+func (f *mockFuture) GetAll() ([]bus.Message, error) {
+	m, err := f.Get()
+	return []bus.Message{m}, err
+}
+
+type mockDistributedContext struct {
+	requests  []*tracev1.QueryRequest
+	responses []*tracev1.QueryResponse
+}
+
+func (m *mockDistributedContext) Broadcast(_ time.Duration, _ bus.Topic, message bus.Message) ([]bus.Future, error) {
+	m.requests = append(m.requests, message.Data().(*tracev1.QueryRequest))
+	ff := make([]bus.Future, 0, len(m.responses))
+	for _, resp := range m.responses {
+		ff = append(ff, &mockFuture{resp: resp})
+	}
+	return ff, nil
+}
+
+func (m *mockDistributedContext) TimeRange() *modelv1.TimeRange {
+	return &modelv1.TimeRange{Begin: timestamppb.New(time.Unix(0, 0)), End: timestamppb.New(time.Unix(100, 0))}
+}
+
+func (m *mockDistributedContext) NodeSelectors() map[string][]string {
+	return nil
+}
+
+func newTestSchema(t *testing.T) logical.Schema {
+	s, err := BuildSchema(&databasev1.Trace{
+		Metadata: &commonv1.Metadata{Group: "sw_trace", Name: "segment"},
+		Tags: []*databasev1.TraceTagSpec{
+			{Name: "trace_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+			{Name: "timestamp", Type: databasev1.TagType_TAG_TYPE_TIMESTAMP},
+			{Name: "duration", Type: databasev1.TagType_TAG_TYPE_INT},
+		},
+		TraceIdTagName:   "trace_id",
+		TimestampTagName: "timestamp",
+	}, []*databasev1.IndexRule{
+		{Metadata: &commonv1.Metadata{Group: "sw_trace", Name: "duration"}, Tags: []string{"duration"}},
+	})
+	require.NoError(t, err)
+	return s
+}
+
+func newTestSpan(traceID string, ts int64, duration int64) *tracev1.Span {
+	return &tracev1.Span{
+		Tags: []*modelv1.Tag{
+			{Key: "trace_id", Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: traceID}}}},
+			{Key: "timestamp", Value: &modelv1.TagValue{Value: &modelv1.TagValue_Timestamp{Timestamp: timestamppb.New(time.Unix(ts, 0))}}},
+			{Key: "duration", Value: &modelv1.TagValue{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{Value: duration}}}},
+		},
+		Span: []byte(traceID),
+	}
+}
+
+func executePlan(t *testing.T, req *tracev1.QueryRequest, dctx *mockDistributedContext) []*tracev1.Span {
+	plan, err := DistributedAnalyze(req, []logical.Schema{newTestSchema(t)})
+	require.NoError(t, err)
+	te := plan.(executor.TraceExecutable)
+	defer te.Close()
+	spans, err := te.Execute(executor.WithDistributedExecutionContext(context.Background(), dctx))
+	require.NoError(t, err)
+	return spans
+}
+
+func spanIDs(spans []*tracev1.Span) []string {
+	ids := make([]string, 0, len(spans))
+	for _, s := range spans {
+		ids = append(ids, string(s.Span))
+	}
+	return ids
+}
+
+func TestDistributedPlanMergeByTimestamp(t *testing.T) {
+	dctx := &mockDistributedContext{
+		responses: []*tracev1.QueryResponse{
+			{Spans: []*tracev1.Span{newTestSpan("a", 1, 10), newTestSpan("c", 3, 30), newTestSpan("e", 5, 50)}},
+			// The replica returns the span "c" again, which should be deduplicated.
+			{Spans: []*tracev1.Span{newTestSpan("b", 2, 20), newTestSpan("c", 3, 30), newTestSpan("d", 4, 40)}},
+		},
+	}
+	spans := executePlan(t, &tracev1.QueryRequest{
+		Groups:        []string{"sw_trace"},
+		Name:          "segment",
+		TagProjection: []string{"trace_id"},
+		Offset:        1,
+		Limit:         3,
+	}, dctx)
+
+	require.Len(t, dctx.requests, 1)
+	pushed := dctx.requests[0]
+	assert.Equal(t, []string{"trace_id", "timestamp"}, pushed.TagProjection)
+	assert.Equal(t, uint32(4), pushed.Limit)
+	assert.NotNil(t, pushed.TimeRange)
+
+	assert.Equal(t, []string{"b", "c", "d"}, spanIDs(spans))
+	for _, s := range spans {
+		for _, tag := range s.Tags {
+			assert.NotEqual(t, "timestamp", tag.Key, "the sort tag should be removed from the result")
+		}
+	}
+}
+
+func TestDistributedPlanMergeByIndexRule(t *testing.T) {
+	dctx := &mockDistributedContext{
+		responses: []*tracev1.QueryResponse{
+			{Spans: []*tracev1.Span{newTestSpan("a", 1, 50), newTestSpan("b", 2, 20)}},
+			{Spans: []*tracev1.Span{newTestSpan("c", 3, 40), newTestSpan("d", 4, 10)}},
+		},
+	}
+	spans := executePlan(t, &tracev1.QueryRequest{
+		Groups:        []string{"sw_trace"},
+		Name:          "segment",
+		TagProjection: []string{"trace_id", "duration"},
+		OrderBy:       &modelv1.QueryOrder{IndexRuleName: "duration", Sort: modelv1.Sort_SORT_DESC},
+	}, dctx)
+
+	require.Len(t, dctx.requests, 1)
+	assert.Equal(t, []string{"trace_id", "duration"}, dctx.requests[0].TagProjection)
+	assert.Equal(t, defaultLimit, dctx.requests[0].Limit)
+	assert.Equal(t, []string{"a", "c", "b", "d"}, spanIDs(spans))
+	assert.Len(t, spans[0].Tags, 3)
+}
+
+func TestDistributedAnalyzeUnknownIndexRule(t *testing.T) {
+	_, err := DistributedAnalyze(&tracev1.QueryRequest{
+		Groups:  []string{"sw_trace"},
+		Name:    "segment",
+		OrderBy: &modelv1.QueryOrder{IndexRuleName: "unknown"},
+	}, []logical.Schema{newTestSchema(t)})
+	assert.Error(t, err)
+}
+
+func TestDistributedPlanEmptyProjection(t *testing.T) {
+	dctx := &mockDistributedContext{
+		responses: []*tracev1.QueryResponse{
+			{Spans: []*tracev1.Span{newTestSpan("a", 1, 10)}},
+		},
+	}
+	spans := executePlan(t, &tracev1.QueryRequest{
+		Groups: []string{"sw_trace"},
+		Name:   "segment",
+	}, dctx)
+
+	require.Len(t, dctx.requests, 1)
+	assert.Equal(t, []string{"trace_id", "timestamp", "duration"}, dctx.requests[0].TagProjection)
+	require.Len(t, spans, 1)
+	var keys []string
+	for _, tag := range spans[0].Tags {
+		keys = append(keys, tag.Key)
+	}
+	assert.Equal(t, []string{"trace_id", "timestamp", "duration"}, keys, "all the tags should be returned without a projection")
+}
+
+func TestDistributedPlanDeduplicateReplicas(t *testing.T) {
+	withSpan := func(s *tracev1.Span, data string) *tracev1.Span {
+		s.Span = []byte(data)
+		return s
+	}
+	dctx := &mockDistributedContext{
+		responses: []*tracev1.QueryResponse{
+			{Spans: []*tracev1.Span{withSpan(newTestSpan("t1", 1, 10), "s1"), withSpan(newTestSpan("t2", 2, 20), "s1")}},
+			// The replicas of the first node return the same spans.
+			{Spans: []*tracev1.Span{withSpan(newTestSpan("t1", 1, 10), "s1"), withSpan(newTestSpan("t3", 3, 30), "s2")}},
+			{Spans: []*tracev1.Span{withSpan(newTestSpan("t2", 2, 20), "s1"), withSpan(newTestSpan("t3", 3, 30), "s3")}},
+		},
+	}
+	spans := executePlan(t, &tracev1.QueryRequest{
+		Groups:        []string{"sw_trace"},
+		Name:          "segment",
+		TagProjection: []string{"duration"},
+	}, dctx)
+
+	require.Len(t, dctx.requests, 1)
+	assert.Equal(t, []string{"duration", "timestamp", "trace_id"}, dctx.requests[0].TagProjection)
+	// The spans sharing the data of other traces are kept, only the copies of the same span are removed.
+	assert.Equal(t, []string{"s1", "s1", "s2", "s3"}, spanIDs(spans))
+	var durations []int64
+	for _, s := range spans {
+		require.Len(t, s.Tags, 1, "the sort tag and the trace ID tag should be removed from the result")
+		assert.Equal(t, "duration", s.Tags[0].Key)
+		durations = append(durations, s.Tags[0].Value.GetInt().GetValue())
+	}
+	assert.Equal(t, []int64{10, 20, 30, 30}, durations)
+}
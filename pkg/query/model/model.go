@@ -315,6 +315,38 @@ type StreamQueryResult interface {
 	Release()
 }
 
+// SegmentStatistics is the statistics of the data in a segment.
+type SegmentStatistics struct {
+	// Indexes are the statistics of the indexes the filters look up, which are the element indexes of the shards
+	// in a stream segment, or the series index in a measure segment.
+	Indexes []index.Statistics
+	// Series is the number of the series in the segment.
+	Series uint64
+	// Rows is the number of the rows in the parts of the segment.
+	Rows uint64
+	// Parts is the number of the parts of the segment.
+	Parts int
+}
+
+// Statistics is the statistics of the segments in a time range, by which the query optimizer
+// estimates the cost of scanning the data.
+type Statistics struct {
+	release  func()
+	Segments []SegmentStatistics
+}
+
+// NewStatistics returns Statistics which call release when they're released.
+func NewStatistics(segments []SegmentStatistics, release func()) *Statistics {
+	return &Statistics{Segments: segments, release: release}
+}
+
+// Release releases the segments the statistics refer to.
+func (s *Statistics) Release() {
+	if s != nil && s.release != nil {
+		s.release()
+	}
+}
+
 // TraceQueryOptions is the options of a trace query.
 type TraceQueryOptions struct {
 	TimeRange      *timestamp.TimeRange
@@ -134,6 +134,7 @@ func standaloneServerWithAuth(path string, ports []int, schemaLoaders []SchemaLo
 		"--measure-root-path=" + path,
 		"--metadata-root-path=" + path,
 		"--property-root-path=" + path,
+		"--trace-root-path=" + path,
 		fmt.Sprintf("--etcd-listen-client-url=%s", endpoint), fmt.Sprintf("--etcd-listen-peer-url=http://%s:%d", host, ports[3]),
 	}
 	tlsEnabled := false
@@ -243,6 +244,7 @@ func startDataNode(etcdEndpoint, dataDir string, flags ...string) (string, strin
 		"--stream-root-path="+dataDir,
 		"--measure-root-path="+dataDir,
 		"--property-root-path="+dataDir,
+		"--trace-root-path="+dataDir,
 		"--etcd-endpoints", etcdEndpoint,
 		"--node-host-provider", "flag",
 		"--node-host", nodeHost,
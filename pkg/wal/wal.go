@@ -0,0 +1,474 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package wal implements a segmented write-ahead log.
+//
+// Records are appended by a single committer which groups the concurrent writes
+// into one write and one fsync. Every record carries a log sequence number(LSN)
+// which increases monotonically, so that the records persisted elsewhere can be
+// truncated by their LSNs.
+package wal
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	segmentSuffix = ".wal"
+	// recordHeaderSize is the size of the length, the checksum and the LSN of a record.
+	recordHeaderSize = 4 + 4 + 8
+
+	defaultSegmentSize  = 64 << 20
+	defaultSyncInterval = time.Second
+	maxBatchBytes       = 4 << 20
+
+	dirPerm  = 0o755
+	filePerm = 0o600
+)
+
+var (
+	// ErrClosed is returned when writing to a closed WAL.
+	ErrClosed = errors.New("wal is closed")
+
+	errCorrupted = errors.New("corrupted record")
+	crcTable     = crc32.MakeTable(crc32.Castagnoli)
+)
+
+// SyncPolicy determines when the written records are synced to the disk.
+type SyncPolicy int
+
+// SyncPolicy constants.
+const (
+	// SyncAlways syncs every group commit before acknowledging the writers.
+	SyncAlways SyncPolicy = iota
+	// SyncInterval syncs the written records periodically.
+	SyncInterval
+	// SyncNone leaves the syncing to the operating system.
+	SyncNone
+)
+
+var syncPolicyNames = map[SyncPolicy]string{
+	SyncAlways:   "always",
+	SyncInterval: "interval",
+	SyncNone:     "none",
+}
+
+// ParseSyncPolicy parses the name of a SyncPolicy.
+func ParseSyncPolicy(name string) (SyncPolicy, error) {
+	for p, n := range syncPolicyNames {
+		if n == name {
+			return p, nil
+		}
+	}
+	return SyncAlways, errors.Errorf("unknown wal sync policy %q, it should be one of always, interval and none", name)
+}
+
+// String returns the name of the policy.
+func (p SyncPolicy) String() string {
+	return syncPolicyNames[p]
+}
+
+// Set parses the policy from a flag.
+func (p *SyncPolicy) Set(name string) error {
+	policy, err := ParseSyncPolicy(name)
+	if err != nil {
+		return err
+	}
+	*p = policy
+	return nil
+}
+
+// Type returns the type name of the flag.
+func (p *SyncPolicy) Type() string {
+	return "walSyncPolicy"
+}
+
+// Options configures a WAL.
+type Options struct {
+	SyncPolicy   SyncPolicy
+	SyncInterval time.Duration
+	SegmentSize  int64
+}
+
+type segment struct {
+	path     string
+	firstLSN uint64
+}
+
+type request struct {
+	done chan result
+	data []byte
+}
+
+type result struct {
+	err error
+	lsn uint64
+}
+
+// WAL is a write-ahead log stored in a directory.
+type WAL struct {
+	err      error
+	file     *os.File
+	requests chan *request
+	closeCh  chan struct{}
+	dir      string
+	segments []segment
+	buf      []byte
+	opts     Options
+	wg       sync.WaitGroup
+	size     int64
+	nextLSN  uint64
+	mu       sync.Mutex
+	dirty    bool
+}
+
+// Open opens the WAL in the directory, repairing the torn tail which is left by a crash.
+func Open(dir string, opts Options) (*WAL, error) {
+	if opts.SegmentSize <= 0 {
+		opts.SegmentSize = defaultSegmentSize
+	}
+	if opts.SyncInterval <= 0 {
+		opts.SyncInterval = defaultSyncInterval
+	}
+	if err := os.MkdirAll(dir, dirPerm); err != nil {
+		return nil, errors.WithMessagef(err, "cannot create wal directory %s", dir)
+	}
+	w := &WAL{
+		dir:      dir,
+		opts:     opts,
+		nextLSN:  1,
+		requests: make(chan *request),
+		closeCh:  make(chan struct{}),
+	}
+	if err := w.load(); err != nil {
+		return nil, err
+	}
+	w.wg.Add(1)
+	go w.commitLoop()
+	return w, nil
+}
+
+func (w *WAL) load() error {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return errors.WithMessagef(err, "cannot read wal directory %s", w.dir)
+	}
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != segmentSuffix {
+			continue
+		}
+		firstLSN, parseErr := strconv.ParseUint(strings.TrimSuffix(e.Name(), segmentSuffix), 16, 64)
+		if parseErr != nil {
+			return errors.WithMessagef(parseErr, "invalid wal segment name %s", e.Name())
+		}
+		w.segments = append(w.segments, segment{path: filepath.Join(w.dir, e.Name()), firstLSN: firstLSN})
+	}
+	sort.Slice(w.segments, func(i, j int) bool {
+		return w.segments[i].firstLSN < w.segments[j].firstLSN
+	})
+	if len(w.segments) == 0 {
+		return w.createSegment()
+	}
+	for i, s := range w.segments {
+		data, readErr := os.ReadFile(s.path)
+		if readErr != nil {
+			return errors.WithMessagef(readErr, "cannot read wal segment %s", s.path)
+		}
+		w.nextLSN = max(w.nextLSN, s.firstLSN)
+		valid, lastLSN, decodeErr := decodeRecords(data, nil)
+		if lastLSN > 0 {
+			w.nextLSN = lastLSN + 1
+		}
+		if decodeErr == nil {
+			continue
+		}
+		if i < len(w.segments)-1 {
+			return errors.WithMessagef(decodeErr, "wal segment %s", s.path)
+		}
+		// A crash in the middle of a write leaves a torn record at the tail of the last segment.
+		if truncateErr := os.Truncate(s.path, int64(valid)); truncateErr != nil {
+			return errors.WithMessagef(truncateErr, "cannot truncate the torn tail of wal segment %s", s.path)
+		}
+	}
+	last := w.segments[len(w.segments)-1]
+	f, err := os.OpenFile(last.path, os.O_WRONLY|os.O_APPEND, filePerm)
+	if err != nil {
+		return errors.WithMessagef(err, "cannot open wal segment %s", last.path)
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return errors.WithMessagef(err, "cannot stat wal segment %s", last.path)
+	}
+	w.file = f
+	w.size = stat.Size()
+	return nil
+}
+
+// decodeRecords decodes the records in data and passes them to fn if it isn't nil.
+// It returns the size of the valid records and the LSN of the last valid record.
+func decodeRecords(data []byte, fn func(lsn uint64, record []byte) error) (int, uint64, error) {
+	var offset int
+	var lastLSN uint64
+	for offset < len(data) {
+		if len(data)-offset < recordHeaderSize {
+			return offset, lastLSN, errors.WithMessage(errCorrupted, "incomplete header")
+		}
+		length := int(binary.BigEndian.Uint32(data[offset:]))
+		checksum := binary.BigEndian.Uint32(data[offset+4:])
+		end := offset + recordHeaderSize + length
+		if end > len(data) {
+			return offset, lastLSN, errors.WithMessage(errCorrupted, "incomplete payload")
+		}
+		if crc32.Checksum(data[offset+8:end], crcTable) != checksum {
+			return offset, lastLSN, errors.WithMessage(errCorrupted, "checksum mismatch")
+		}
+		lsn := binary.BigEndian.Uint64(data[offset+8:])
+		if fn != nil {
+			if err := fn(lsn, data[offset+recordHeaderSize:end]); err != nil {
+				return offset, lastLSN, err
+			}
+		}
+		lastLSN = lsn
+		offset = end
+	}
+	return offset, lastLSN, nil
+}
+
+func appendRecord(dst []byte, lsn uint64, data []byte) []byte {
+	start := len(dst)
+	dst = binary.BigEndian.AppendUint32(dst, uint32(len(data)))
+	dst = binary.BigEndian.AppendUint32(dst, 0)
+	dst = binary.BigEndian.AppendUint64(dst, lsn)
+	dst = append(dst, data...)
+	binary.BigEndian.PutUint32(dst[start+4:], crc32.Checksum(dst[start+8:], crcTable))
+	return dst
+}
+
+// createSegment creates a new segment starting with the next LSN and makes it the active one.
+func (w *WAL) createSegment() error {
+	s := segment{
+		path:     filepath.Join(w.dir, fmt.Sprintf("%016x%s", w.nextLSN, segmentSuffix)),
+		firstLSN: w.nextLSN,
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, filePerm)
+	if err != nil {
+		return errors.WithMessagef(err, "cannot create wal segment %s", s.path)
+	}
+	if w.file != nil {
+		if err = w.file.Sync(); err != nil {
+			_ = f.Close()
+			return errors.WithMessagef(err, "cannot sync wal segment %s", w.file.Name())
+		}
+		if err = w.file.Close(); err != nil {
+			_ = f.Close()
+			return errors.WithMessagef(err, "cannot close wal segment %s", w.file.Name())
+		}
+	}
+	w.file = f
+	w.size = 0
+	w.dirty = false
+	w.segments = append(w.segments, s)
+	return syncDir(w.dir)
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// Write appends a record and blocks until it is committed according to the sync policy.
+// It returns the LSN of the record.
+func (w *WAL) Write(data []byte) (uint64, error) {
+	req := &request{data: data, done: make(chan result, 1)}
+	select {
+	case w.requests <- req:
+	case <-w.closeCh:
+		return 0, ErrClosed
+	}
+	res := <-req.done
+	return res.lsn, res.err
+}
+
+func (w *WAL) commitLoop() {
+	defer w.wg.Done()
+	var tickCh <-chan time.Time
+	if w.opts.SyncPolicy == SyncInterval {
+		ticker := time.NewTicker(w.opts.SyncInterval)
+		defer ticker.Stop()
+		tickCh = ticker.C
+	}
+	batch := make([]*request, 0, 16)
+	for {
+		select {
+		case <-w.closeCh:
+			return
+		case <-tickCh:
+			w.syncIfDirty()
+		case req := <-w.requests:
+			batch = append(batch[:0], req)
+			size := len(req.data)
+			// The writers blocked during the previous commit join this group.
+		GROUP:
+			for size < maxBatchBytes {
+				select {
+				case next := <-w.requests:
+					batch = append(batch, next)
+					size += len(next.data)
+				default:
+					break GROUP
+				}
+			}
+			w.commit(batch)
+		}
+	}
+}
+
+func (w *WAL) commit(batch []*request) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	err := w.err
+	if err == nil && w.size >= w.opts.SegmentSize {
+		err = w.createSegment()
+	}
+	firstLSN := w.nextLSN
+	if err == nil {
+		w.buf = w.buf[:0]
+		for i, req := range batch {
+			w.buf = appendRecord(w.buf, firstLSN+uint64(i), req.data)
+		}
+		var n int
+		n, err = w.file.Write(w.buf)
+		w.size += int64(n)
+		if err == nil && w.opts.SyncPolicy == SyncAlways {
+			err = w.file.Sync()
+		}
+	}
+	if err != nil {
+		// The tail of the segment is unknown after a failed write, so the WAL refuses the following writes.
+		w.err = err
+		for _, req := range batch {
+			req.done <- result{err: errors.WithMessage(err, "cannot write wal")}
+		}
+		return
+	}
+	w.nextLSN += uint64(len(batch))
+	w.dirty = w.opts.SyncPolicy != SyncAlways
+	for i, req := range batch {
+		req.done <- result{lsn: firstLSN + uint64(i)}
+	}
+}
+
+func (w *WAL) syncIfDirty() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.dirty || w.err != nil {
+		return
+	}
+	if err := w.file.Sync(); err != nil {
+		w.err = err
+		return
+	}
+	w.dirty = false
+}
+
+// NextLSN returns the LSN which will be assigned to the next record.
+func (w *WAL) NextLSN() uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.nextLSN
+}
+
+// Replay passes all the records in the WAL to fn in the order of their LSNs.
+func (w *WAL) Replay(fn func(lsn uint64, data []byte) error) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, s := range w.segments {
+		data, err := os.ReadFile(s.path)
+		if err != nil {
+			return errors.WithMessagef(err, "cannot read wal segment %s", s.path)
+		}
+		if _, _, err = decodeRecords(data, fn); err != nil {
+			return errors.WithMessagef(err, "cannot replay wal segment %s", s.path)
+		}
+	}
+	return nil
+}
+
+// Truncate removes the records whose LSNs are less than lsn.
+// The records are removed by segments, so that some of them might be kept.
+func (w *WAL) Truncate(lsn uint64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	if lsn >= w.nextLSN && w.size > 0 {
+		// All the records are obsolete, rotate the active segment to remove it.
+		if err := w.createSegment(); err != nil {
+			return err
+		}
+	}
+	var removed int
+	for removed < len(w.segments)-1 && w.segments[removed+1].firstLSN <= lsn {
+		if err := os.Remove(w.segments[removed].path); err != nil && !os.IsNotExist(err) {
+			return errors.WithMessagef(err, "cannot remove wal segment %s", w.segments[removed].path)
+		}
+		removed++
+	}
+	if removed == 0 {
+		return nil
+	}
+	w.segments = append(w.segments[:0], w.segments[removed:]...)
+	return syncDir(w.dir)
+}
+
+// Close syncs the written records and closes the WAL.
+func (w *WAL) Close() error {
+	select {
+	case <-w.closeCh:
+		return nil
+	default:
+	}
+	close(w.closeCh)
+	w.wg.Wait()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var err error
+	if w.err == nil {
+		err = w.file.Sync()
+	}
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,231 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package wal
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const crashDirEnv = "BANYANDB_WAL_CRASH_DIR"
+
+func replayAll(t *testing.T, w *WAL) map[string]uint64 {
+	records := make(map[string]uint64)
+	var lastLSN uint64
+	require.NoError(t, w.Replay(func(lsn uint64, data []byte) error {
+		assert.Greater(t, lsn, lastLSN)
+		lastLSN = lsn
+		records[string(data)] = lsn
+		return nil
+	}))
+	return records
+}
+
+func TestWriteAndReplay(t *testing.T) {
+	for _, policy := range []SyncPolicy{SyncAlways, SyncInterval, SyncNone} {
+		t.Run(policy.String(), func(t *testing.T) {
+			dir := t.TempDir()
+			w, err := Open(dir, Options{SyncPolicy: policy})
+			require.NoError(t, err)
+			var wg sync.WaitGroup
+			lsns := make([]uint64, 100)
+			for i := range lsns {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					lsn, writeErr := w.Write([]byte(strconv.Itoa(i)))
+					assert.NoError(t, writeErr)
+					lsns[i] = lsn
+				}(i)
+			}
+			wg.Wait()
+			require.NoError(t, w.Close())
+			_, err = w.Write([]byte("closed"))
+			assert.ErrorIs(t, err, ErrClosed)
+
+			w, err = Open(dir, Options{SyncPolicy: policy})
+			require.NoError(t, err)
+			defer w.Close()
+			records := replayAll(t, w)
+			require.Len(t, records, len(lsns))
+			for i, lsn := range lsns {
+				assert.Equal(t, lsn, records[strconv.Itoa(i)])
+			}
+			assert.Equal(t, uint64(len(lsns)+1), w.NextLSN())
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	dir := t.TempDir()
+	w, err := Open(dir, Options{SegmentSize: 64})
+	require.NoError(t, err)
+	defer w.Close()
+	for i := 0; i < 20; i++ {
+		_, err = w.Write([]byte(fmt.Sprintf("record-%02d", i)))
+		require.NoError(t, err)
+	}
+	segments := len(w.segments)
+	require.Greater(t, segments, 2)
+
+	require.NoError(t, w.Truncate(11))
+	assert.Less(t, len(w.segments), segments)
+	records := replayAll(t, w)
+	for i := 10; i < 20; i++ {
+		assert.Contains(t, records, fmt.Sprintf("record-%02d", i))
+	}
+	assert.Less(t, len(records), 20)
+
+	require.NoError(t, w.Truncate(w.NextLSN()))
+	assert.Empty(t, replayAll(t, w))
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	lsn, err := w.Write([]byte("after truncation"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(21), lsn)
+}
+
+func TestWatermark(t *testing.T) {
+	var m Watermark
+	assert.False(t, m.Covers(1))
+
+	// The records 3 and 5 are persisted before the records 1, 2 and 4.
+	m = m.Advance([]uint64{5, 3}, 1)
+	assert.Equal(t, Watermark{LSN: 1, Persisted: []uint64{3, 5}}, m)
+	for lsn, covered := range map[uint64]bool{1: false, 2: false, 3: true, 4: false, 5: true, 6: false} {
+		assert.Equal(t, covered, m.Covers(lsn), "lsn %d", lsn)
+	}
+
+	m = m.Advance([]uint64{1, 2}, 4)
+	assert.Equal(t, Watermark{LSN: 4, Persisted: []uint64{5}}, m)
+	assert.True(t, m.Covers(2))
+	assert.False(t, m.Covers(4))
+
+	m = m.Advance([]uint64{4}, 7)
+	assert.Equal(t, Watermark{LSN: 7}, m)
+	assert.True(t, m.Covers(6))
+	assert.False(t, m.Covers(7))
+}
+
+func TestTornTail(t *testing.T) {
+	dir := t.TempDir()
+	w, err := Open(dir, Options{})
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, err = w.Write([]byte(strconv.Itoa(i)))
+		require.NoError(t, err)
+	}
+	path := w.segments[len(w.segments)-1].path
+	require.NoError(t, w.Close())
+
+	// Simulate a crash in the middle of writing a record.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, filePerm)
+	require.NoError(t, err)
+	_, err = f.Write(appendRecord(nil, 4, []byte("torn"))[:recordHeaderSize+2])
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	w, err = Open(dir, Options{})
+	require.NoError(t, err)
+	defer w.Close()
+	assert.Len(t, replayAll(t, w), 3)
+	lsn, err := w.Write([]byte("3"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), lsn)
+	assert.Len(t, replayAll(t, w), 4)
+}
+
+func TestParseSyncPolicy(t *testing.T) {
+	for p, name := range syncPolicyNames {
+		got, err := ParseSyncPolicy(name)
+		require.NoError(t, err)
+		assert.Equal(t, p, got)
+	}
+	_, err := ParseSyncPolicy("sometimes")
+	assert.Error(t, err)
+}
+
+// TestCrashRecovery kills a process which keeps writing to the WAL,
+// then verifies that all the acknowledged records are replayed.
+func TestCrashRecovery(t *testing.T) {
+	dir := t.TempDir()
+	cmd := exec.Command(os.Args[0], "-test.run=^TestCrashWriter$")
+	cmd.Env = append(os.Environ(), crashDirEnv+"="+dir)
+	stdout, err := cmd.StdoutPipe()
+	require.NoError(t, err)
+	require.NoError(t, cmd.Start())
+
+	scanner := bufio.NewScanner(stdout)
+	var acked []string
+	for len(acked) < 500 && scanner.Scan() {
+		acked = append(acked, scanner.Text())
+	}
+	require.NoError(t, cmd.Process.Kill())
+	_ = cmd.Wait()
+	require.Len(t, acked, 500)
+
+	w, err := Open(dir, Options{})
+	require.NoError(t, err)
+	defer w.Close()
+	records := replayAll(t, w)
+	for _, a := range acked {
+		assert.Contains(t, records, a)
+	}
+	_, err = w.Write([]byte("after crash"))
+	require.NoError(t, err)
+}
+
+// TestCrashWriter is the process killed by TestCrashRecovery.
+func TestCrashWriter(t *testing.T) {
+	dir := os.Getenv(crashDirEnv)
+	if dir == "" {
+		t.Skip("only runs in the process of TestCrashRecovery")
+	}
+	w, err := Open(dir, Options{SyncPolicy: SyncAlways, SegmentSize: 4 << 10})
+	if err != nil {
+		os.Exit(1)
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				record := fmt.Sprintf("writer-%d-%d", g, i)
+				if _, writeErr := w.Write([]byte(record)); writeErr != nil {
+					os.Exit(1)
+				}
+				mu.Lock()
+				fmt.Println(record)
+				mu.Unlock()
+			}
+		}(g)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,55 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package wal
+
+import "slices"
+
+// Watermark tracks the records persisted elsewhere, which might be persisted out of the order of their LSNs.
+// It's stored along with the data the records are persisted to, so that the replay skips the persisted records
+// even if the WAL isn't truncated before a crash.
+type Watermark struct {
+	// Persisted holds the LSNs of the persisted records which aren't lower than LSN, in ascending order.
+	Persisted []uint64 `json:"persisted,omitempty"`
+	// LSN is the LSN below which all the records are persisted, the WAL can be truncated by it.
+	LSN uint64 `json:"lsn"`
+}
+
+// Covers reports whether the record of the LSN is persisted.
+func (m Watermark) Covers(lsn uint64) bool {
+	if lsn < m.LSN {
+		return true
+	}
+	_, ok := slices.BinarySearch(m.Persisted, lsn)
+	return ok
+}
+
+// Advance returns the watermark with the LSNs of the newly persisted records,
+// and lowest, the lowest LSN of the records which aren't persisted yet.
+func (m Watermark) Advance(persisted []uint64, lowest uint64) Watermark {
+	next := Watermark{LSN: max(m.LSN, lowest)}
+	for _, lsns := range [][]uint64{m.Persisted, persisted} {
+		for _, lsn := range lsns {
+			if lsn >= next.LSN {
+				next.Persisted = append(next.Persisted, lsn)
+			}
+		}
+	}
+	slices.Sort(next.Persisted)
+	next.Persisted = slices.Compact(next.Persisted)
+	return next
+}
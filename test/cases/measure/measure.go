@@ -74,6 +74,9 @@ var _ = g.DescribeTable("Scanning Measures", verify,
 	g.Entry("all_latency", helpers.Args{Input: "all_latency", Duration: 25 * time.Minute, Offset: -20 * time.Minute}),
 	g.Entry("duplicated in a part", helpers.Args{Input: "duplicated_part", Duration: 25 * time.Minute, Offset: -20 * time.Minute}),
 	g.Entry("match a tag belongs to the entity", helpers.Args{Input: "entity_match", Duration: 25 * time.Minute, Offset: -20 * time.Minute}),
+	g.Entry("subtree of a tree index", helpers.Args{Input: "tree_subtree", Duration: 25 * time.Minute, Offset: -20 * time.Minute}),
+	g.Entry("ancestors of a tree index", helpers.Args{Input: "tree_ancestors", Duration: 25 * time.Minute, Offset: -20 * time.Minute}),
+	g.Entry("prefix of an indexed tag", helpers.Args{Input: "pattern_prefix", Duration: 25 * time.Minute, Offset: -20 * time.Minute}),
 	g.Entry("all of index mode", helpers.Args{Input: "index_mode_all", Duration: 25 * time.Minute, Offset: -20 * time.Minute}),
 	g.Entry("all of index mode in a larger time range",
 		helpers.Args{Input: "index_mode_all", Want: "index_mode_all_xl", Duration: 96 * time.Hour, Offset: -72 * time.Hour}),
@@ -89,4 +89,8 @@ var _ = g.DescribeTable("Scanning Streams", func(args helpers.Args) {
 	g.Entry("multi-groups: update tag type", helpers.Args{Input: "multi_group_tag_type", Duration: 1 * time.Hour, IgnoreElementID: true}),
 	g.Entry("multi-groups: sort duration", helpers.Args{Input: "multi_group_sort_duration", Duration: 1 * time.Hour, IgnoreElementID: true}),
 	g.Entry("hybrid index", helpers.Args{Input: "hybrid_index", Duration: 1 * time.Hour, IgnoreElementID: true}),
+	g.Entry("subtree of a tree index", helpers.Args{Input: "tree_subtree", Duration: 1 * time.Hour, IgnoreElementID: true}),
+	g.Entry("ancestors of a tree index", helpers.Args{Input: "tree_ancestors", Duration: 1 * time.Hour, IgnoreElementID: true}),
+	g.Entry("wildcard of an indexed tag", helpers.Args{Input: "pattern_wildcard", Duration: 1 * time.Hour, IgnoreElementID: true}),
+	g.Entry("regex of an indexed tag", helpers.Args{Input: "pattern_regex", Duration: 1 * time.Hour, IgnoreElementID: true}),
 )
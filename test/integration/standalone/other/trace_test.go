@@ -0,0 +1,190 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package integration_other_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	g "github.com/onsi/ginkgo/v2"
+	gm "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gleak"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commonv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/common/v1"
+	databasev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/database/v1"
+	modelv1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/model/v1"
+	tracev1 "github.com/apache/skywalking-banyandb/api/proto/banyandb/trace/v1"
+	"github.com/apache/skywalking-banyandb/pkg/grpchelper"
+	"github.com/apache/skywalking-banyandb/pkg/pool"
+	"github.com/apache/skywalking-banyandb/pkg/test/flags"
+	"github.com/apache/skywalking-banyandb/pkg/test/gmatcher"
+	"github.com/apache/skywalking-banyandb/pkg/test/setup"
+	"github.com/apache/skywalking-banyandb/pkg/timestamp"
+)
+
+var _ = g.Describe("Trace application", func() {
+	var deferFn func()
+	var conn *grpc.ClientConn
+	var client tracev1.TraceServiceClient
+	var baseTime time.Time
+	var goods []gleak.Goroutine
+
+	g.BeforeEach(func() {
+		var addr string
+		addr, _, deferFn = setup.Standalone()
+		var err error
+		conn, err = grpchelper.Conn(addr, 10*time.Second, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		ctx := context.Background()
+		_, err = databasev1.NewGroupRegistryServiceClient(conn).Create(ctx, &databasev1.GroupRegistryServiceCreateRequest{
+			Group: &commonv1.Group{
+				Metadata: &commonv1.Metadata{Name: "sw_trace"},
+				Catalog:  commonv1.Catalog_CATALOG_TRACE,
+				ResourceOpts: &commonv1.ResourceOpts{
+					ShardNum:        2,
+					SegmentInterval: &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 1},
+					Ttl:             &commonv1.IntervalRule{Unit: commonv1.IntervalRule_UNIT_DAY, Num: 7},
+				},
+			},
+		})
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		_, err = databasev1.NewTraceRegistryServiceClient(conn).Create(ctx, &databasev1.TraceRegistryServiceCreateRequest{
+			Trace: &databasev1.Trace{
+				Metadata: &commonv1.Metadata{Name: "segment", Group: "sw_trace"},
+				Tags: []*databasev1.TraceTagSpec{
+					{Name: "trace_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+					{Name: "service_id", Type: databasev1.TagType_TAG_TYPE_STRING},
+					{Name: "duration", Type: databasev1.TagType_TAG_TYPE_INT},
+					{Name: "timestamp", Type: databasev1.TagType_TAG_TYPE_TIMESTAMP},
+				},
+				TraceIdTagName:   "trace_id",
+				TimestampTagName: "timestamp",
+			},
+		})
+		gm.Expect(err).NotTo(gm.HaveOccurred())
+		client = tracev1.NewTraceServiceClient(conn)
+		ns := timestamp.NowMilli().UnixNano()
+		baseTime = time.Unix(0, ns-ns%int64(time.Minute))
+		writeSpans(client, baseTime)
+		goods = gleak.Goroutines()
+	})
+	g.AfterEach(func() {
+		gm.Expect(conn.Close()).To(gm.Succeed())
+		deferFn()
+		gm.Eventually(gleak.Goroutines, flags.EventuallyTimeout).ShouldNot(gleak.HaveLeaked(goods))
+		gm.Eventually(pool.AllRefsCount, flags.EventuallyTimeout).Should(gmatcher.HaveZeroRef())
+	})
+	g.It("queries the spans written to the standalone node", func() {
+		gm.Eventually(func(innerGm gm.Gomega) {
+			resp, err := client.Query(context.Background(), &tracev1.QueryRequest{
+				Groups: []string{"sw_trace"},
+				Name:   "segment",
+				TimeRange: &modelv1.TimeRange{
+					Begin: timestamppb.New(baseTime.Add(-time.Minute)),
+					End:   timestamppb.New(baseTime.Add(time.Minute)),
+				},
+				Criteria: &modelv1.Criteria{
+					Exp: &modelv1.Criteria_Condition{
+						Condition: &modelv1.Condition{
+							Name:  "service_id",
+							Op:    modelv1.Condition_BINARY_OP_EQ,
+							Value: &modelv1.TagValue{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: "svc1"}}},
+						},
+					},
+				},
+				OrderBy:       &modelv1.QueryOrder{Sort: modelv1.Sort_SORT_DESC},
+				TagProjection: []string{"trace_id", "duration"},
+			})
+			innerGm.Expect(err).NotTo(gm.HaveOccurred())
+			innerGm.Expect(resp.Spans).To(gm.HaveLen(2))
+			// The spans are sorted by the timestamp tag in the descending order.
+			for i, want := range []struct {
+				traceID  string
+				span     string
+				duration int64
+			}{
+				{traceID: "trace2", span: "span2", duration: 200},
+				{traceID: "trace0", span: "span0", duration: 0},
+			} {
+				s := resp.Spans[i]
+				innerGm.Expect(string(s.Span)).To(gm.Equal(want.span))
+				innerGm.Expect(s.Tags).To(gm.HaveLen(2))
+				innerGm.Expect(s.Tags[0].GetValue().GetStr().GetValue()).To(gm.Equal(want.traceID))
+				innerGm.Expect(s.Tags[1].GetValue().GetInt().GetValue()).To(gm.Equal(want.duration))
+			}
+		}, flags.EventuallyTimeout).Should(gm.Succeed())
+	})
+	g.It("returns every tag without a projection", func() {
+		gm.Eventually(func(innerGm gm.Gomega) {
+			resp, err := client.Query(context.Background(), &tracev1.QueryRequest{
+				Groups: []string{"sw_trace"},
+				Name:   "segment",
+				TimeRange: &modelv1.TimeRange{
+					Begin: timestamppb.New(baseTime.Add(-time.Minute)),
+					End:   timestamppb.New(baseTime.Add(time.Minute)),
+				},
+				Limit: 2,
+			})
+			innerGm.Expect(err).NotTo(gm.HaveOccurred())
+			innerGm.Expect(resp.Spans).To(gm.HaveLen(2))
+			s := resp.Spans[0]
+			innerGm.Expect(string(s.Span)).To(gm.Equal("span0"))
+			innerGm.Expect(s.Tags).To(gm.HaveLen(4))
+			innerGm.Expect(s.Tags[3].GetKey()).To(gm.Equal("timestamp"))
+			innerGm.Expect(s.Tags[3].GetValue().GetTimestamp().AsTime()).To(gm.BeTemporally("==", baseTime))
+		}, flags.EventuallyTimeout).Should(gm.Succeed())
+	})
+})
+
+func writeSpans(client tracev1.TraceServiceClient, baseTime time.Time) {
+	wc, err := client.Write(context.Background())
+	gm.Expect(err).NotTo(gm.HaveOccurred())
+	const num = 3
+	for i := 0; i < num; i++ {
+		service := "svc1"
+		if i%2 == 1 {
+			service = "svc2"
+		}
+		gm.Expect(wc.Send(&tracev1.WriteRequest{
+			Metadata: &commonv1.Metadata{Name: "segment", Group: "sw_trace"},
+			Tags: []*modelv1.TagValue{
+				{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: fmt.Sprintf("trace%d", i)}}},
+				{Value: &modelv1.TagValue_Str{Str: &modelv1.Str{Value: service}}},
+				{Value: &modelv1.TagValue_Int{Int: &modelv1.Int{Value: int64(i * 100)}}},
+				{Value: &modelv1.TagValue_Timestamp{Timestamp: timestamppb.New(baseTime.Add(time.Duration(i) * time.Second))}},
+			},
+			Span:    []byte(fmt.Sprintf("span%d", i)),
+			Version: uint64(i + 1),
+		})).To(gm.Succeed())
+	}
+	gm.Expect(wc.CloseSend()).To(gm.Succeed())
+	for i := 0; i < num; i++ {
+		resp, errRecv := wc.Recv()
+		if errors.Is(errRecv, io.EOF) {
+			break
+		}
+		gm.Expect(errRecv).NotTo(gm.HaveOccurred())
+		gm.Expect(resp.GetStatus()).To(gm.Equal(modelv1.Status_STATUS_SUCCEED.String()))
+	}
+}